@@ -5,12 +5,21 @@ import (
 
 	"github.com/keywaysh/cli/internal/analytics"
 	"github.com/keywaysh/cli/internal/cmd"
+	buildinfo "github.com/keywaysh/cli/internal/version"
 )
 
-// version is set at build time via ldflags
-var version = "dev"
+// Build information, set at build time via ldflags
+var (
+	version    = "dev"
+	commit     = ""
+	date       = ""
+	builtBy    = ""
+	releaseKey = "" // base64 ed25519 public key of the release checksums
+)
 
 func main() {
+	buildinfo.SetBuild(buildinfo.Build{Version: version, Commit: commit, Date: date, BuiltBy: builtBy, ReleaseKey: releaseKey})
+
 	// Set version for analytics
 	analytics.SetVersion(version)
 
@@ -18,6 +27,6 @@ func main() {
 	defer analytics.Shutdown()
 
 	if err := cmd.Execute(version); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/keywaysh/cli/internal/config"
@@ -23,6 +24,7 @@ const (
 	EventDiff   = "cli_diff"
 	EventDoctor = "cli_doctor"
 	EventScan   = "cli_scan"
+	EventSudo   = "cli_sudo"
 
 	// Provider integration
 	EventConnect    = "cli_connect"
@@ -41,14 +43,35 @@ const (
 	EventCommandLatency = "cli_command_latency"
 )
 
+// Telemetry sinks an organization policy can choose
+const (
+	SinkKeyway = "keyway" // Keyway's analytics (default)
+	SinkOTLP   = "otlp"   // The organization's own OpenTelemetry collector
+	SinkNone   = "none"   // Telemetry off
+)
+
+// Policy sets where telemetry goes, as decided by the organization
+type Policy struct {
+	Sink     string            `json:"sink"`
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
 var (
 	client     posthog.Client
+	otlp       *otlpSink
+	policy     Policy
 	distinctID string
 	initOnce   sync.Once
 	mu         sync.Mutex
 	version    = "dev" // Set via build flags
+	tracked    int
 )
 
+// shutdownTimeout bounds how long the CLI waits at exit for its events to be
+// sent: on a slow link they are dropped rather than delaying the command
+const shutdownTimeout = 500 * time.Millisecond
+
 // SetVersion sets the CLI version for analytics
 func SetVersion(v string) {
 	version = v
@@ -105,12 +128,35 @@ func getDistinctID() string {
 	return distinctID
 }
 
-// initClient initializes the PostHog client
+// Configure applies an organization's telemetry policy. It must be called
+// before the first event is tracked, later calls have no effect.
+func Configure(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	policy = p
+}
+
+// initClient initializes the PostHog client, or the OTLP sink if the
+// organization's policy redirects telemetry
 func initClient() {
 	if config.IsTelemetryDisabled() {
 		return
 	}
 
+	mu.Lock()
+	p := policy
+	mu.Unlock()
+	switch p.Sink {
+	case SinkNone:
+		return
+	case SinkOTLP:
+		if p.Endpoint != "" {
+			otlp = newOTLPSink(p.Endpoint, p.Headers)
+		}
+		// Never fall back to Keyway's analytics when the policy redirects them
+		return
+	}
+
 	apiKey := config.GetPostHogKey()
 	if apiKey == "" {
 		return
@@ -169,9 +215,15 @@ func Track(event string, properties map[string]interface{}) {
 
 	initOnce.Do(initClient)
 
+	if otlp != nil {
+		otlp.record(event, sanitizeProperties(properties))
+		countTracked()
+		return
+	}
 	if client == nil {
 		return
 	}
+	countTracked()
 
 	sanitized := sanitizeProperties(properties)
 
@@ -220,9 +272,41 @@ func Identify(userID string, properties map[string]interface{}) {
 	}
 }
 
-// Shutdown flushes and closes the PostHog client
+// Tracked returns the number of events tracked so far
+func Tracked() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return tracked
+}
+
+// countTracked counts an event sent to a sink
+func countTracked() {
+	mu.Lock()
+	tracked++
+	mu.Unlock()
+}
+
+// Shutdown flushes and closes the PostHog client, or exports to the OTLP sink,
+// waiting shutdownTimeout at most
 func Shutdown() {
-	if client != nil {
-		_ = client.Close()
+	shutdown(shutdownTimeout)
+}
+
+// shutdown flushes the events in the background, and gives up after timeout
+func shutdown(timeout time.Duration) {
+	c, sink := client, otlp
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if c != nil {
+			_ = c.Close()
+		}
+		if sink != nil {
+			_ = sink.flush()
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
 	}
 }
@@ -0,0 +1,180 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// otlpTimeout bounds the single export made when the CLI exits
+const otlpTimeout = 3 * time.Second
+
+// otlpAttributes are the only event properties exported, everything else
+// (repository names, environments, error messages) stays on the machine
+var otlpAttributes = []string{"command", "provider"}
+
+// otlpSink counts events and exports them as one OTLP/HTTP JSON metrics
+// request on Shutdown. Nothing identifies the user or the machine.
+type otlpSink struct {
+	endpoint string
+	headers  map[string]string
+	start    time.Time
+
+	mu     sync.Mutex
+	counts map[string]*otlpCount
+}
+
+type otlpCount struct {
+	attributes map[string]string
+	value      int64
+}
+
+func newOTLPSink(endpoint string, headers map[string]string) *otlpSink {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/metrics") {
+		endpoint += "/v1/metrics"
+	}
+	return &otlpSink{
+		endpoint: endpoint,
+		headers:  headers,
+		start:    time.Now(),
+		counts:   make(map[string]*otlpCount),
+	}
+}
+
+// record counts an event under its allowed attributes
+func (s *otlpSink) record(event string, properties map[string]interface{}) {
+	attributes := map[string]string{"event": event}
+	for _, name := range otlpAttributes {
+		if v, ok := properties[name]; ok {
+			attributes[name] = fmt.Sprint(v)
+		}
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var id strings.Builder
+	for _, name := range names {
+		id.WriteString(name + "=" + attributes[name] + ";")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counts[id.String()]; ok {
+		c.value++
+		return
+	}
+	s.counts[id.String()] = &otlpCount{attributes: attributes, value: 1}
+}
+
+// flush exports the counted events, if any
+func (s *otlpSink) flush() error {
+	s.mu.Lock()
+	body := s.payload(time.Now())
+	s.counts = make(map[string]*otlpCount)
+	s.mu.Unlock()
+	if body == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// payload builds an ExportMetricsServiceRequest with one cumulative counter
+func (s *otlpSink) payload(now time.Time) map[string]interface{} {
+	if len(s.counts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(s.counts))
+	for id := range s.counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	points := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		c := s.counts[id]
+		points = append(points, map[string]interface{}{
+			"attributes":        otlpKeyValues(c.attributes),
+			"startTimeUnixNano": fmt.Sprint(s.start.UnixNano()),
+			"timeUnixNano":      fmt.Sprint(now.UnixNano()),
+			"asInt":             fmt.Sprint(c.value),
+		})
+	}
+
+	resource := map[string]string{
+		"service.name":    "keyway-cli",
+		"service.version": version,
+		"os.type":         runtime.GOOS,
+		"host.arch":       runtime.GOARCH,
+	}
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": otlpKeyValues(resource)},
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "keyway-cli", "version": version},
+				"metrics": []interface{}{map[string]interface{}{
+					"name":        "keyway.cli.events",
+					"description": "Keyway CLI events",
+					"unit":        "1",
+					"sum": map[string]interface{}{
+						"dataPoints":             points,
+						"aggregationTemporality": 2, // cumulative over this run
+						"isMonotonic":            true,
+					},
+				}},
+			}},
+		}},
+	}
+}
+
+// otlpKeyValues converts attributes to OTLP KeyValue objects, sorted by key
+func otlpKeyValues(attributes map[string]string) []map[string]interface{} {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, map[string]interface{}{
+			"key":   k,
+			"value": map[string]string{"stringValue": attributes[k]},
+		})
+	}
+	return values
+}
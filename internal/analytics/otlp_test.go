@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOTLPSink_Flush(t *testing.T) {
+	var body map[string]interface{}
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	sink := newOTLPSink(server.URL+"/", map[string]string{"Authorization": "Bearer otel"})
+	sink.record(EventPush, map[string]interface{}{"repoFullName": "acme/api"})
+	sink.record(EventPush, map[string]interface{}{"repoFullName": "acme/web"})
+	sink.record(EventError, map[string]interface{}{"command": "pull", "error": "boom"})
+
+	if err := sink.flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/v1/metrics" {
+		t.Errorf("expected /v1/metrics, got %s", path)
+	}
+	if auth != "Bearer otel" {
+		t.Errorf("expected configured headers, got %q", auth)
+	}
+
+	raw, _ := json.Marshal(body)
+	for _, leaked := range []string{"acme", "boom"} {
+		if strings.Contains(string(raw), leaked) {
+			t.Errorf("expected %q not to be exported: %s", leaked, raw)
+		}
+	}
+
+	metrics := body["resourceMetrics"].([]interface{})[0].(map[string]interface{})["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{})
+	points := metrics[0].(map[string]interface{})["sum"].(map[string]interface{})["dataPoints"].([]interface{})
+	if len(points) != 2 {
+		t.Fatalf("expected 2 data points, got %d", len(points))
+	}
+	counts := map[string]string{}
+	for _, p := range points {
+		point := p.(map[string]interface{})
+		for _, a := range point["attributes"].([]interface{}) {
+			attr := a.(map[string]interface{})
+			if attr["key"] == "event" {
+				counts[attr["value"].(map[string]interface{})["stringValue"].(string)] = point["asInt"].(string)
+			}
+		}
+	}
+	if counts[EventPush] != "2" || counts[EventError] != "1" {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestOTLPSink_FlushEmpty(t *testing.T) {
+	sink := newOTLPSink("http://127.0.0.1:1", nil)
+	if err := sink.flush(); err != nil {
+		t.Errorf("expected no request without events, got %v", err)
+	}
+}
+
+func TestOTLPSink_Endpoint(t *testing.T) {
+	if got := newOTLPSink("https://otel.acme.dev/v1/metrics", nil).endpoint; got != "https://otel.acme.dev/v1/metrics" {
+		t.Errorf("unexpected endpoint %q", got)
+	}
+	if got := newOTLPSink("https://otel.acme.dev:4318", nil).endpoint; got != "https://otel.acme.dev:4318/v1/metrics" {
+		t.Errorf("unexpected endpoint %q", got)
+	}
+}
+
+func TestShutdown_DoesNotWaitForSlowSink(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	otlp = newOTLPSink(server.URL, nil)
+	defer func() { otlp = nil }()
+	otlp.record(EventPush, nil)
+
+	start := time.Now()
+	shutdown(50 * time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected shutdown to give up after its timeout, took %v", elapsed)
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // APIClient defines the interface for the Keyway API client
 // This interface enables mocking in tests
@@ -11,19 +14,62 @@ type APIClient interface {
 	ValidateToken(ctx context.Context) (*ValidateTokenResponse, error)
 	CheckGitHubAppInstallation(ctx context.Context, repoOwner, repoName string) (*GitHubAppInstallationStatus, error)
 	GetRepoIdsFromBackend(ctx context.Context, repoFullName string) (*RepoIds, error)
+	ListSessions(ctx context.Context) ([]AuthSession, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeOtherSessions(ctx context.Context) (int, error)
 
 	// Vault methods
 	InitVault(ctx context.Context, repoFullName string) (*InitVaultResponse, error)
 	CheckVaultExists(ctx context.Context, repoFullName string) (bool, error)
 	GetVaultDetails(ctx context.Context, repoFullName string) (*VaultDetails, error)
 	GetVaultEnvironments(ctx context.Context, repoFullName string) ([]string, error)
+	FindMovedVault(ctx context.Context, repoFullName string) (*MovedVault, error)
+	RelinkVault(ctx context.Context, vaultRepo, repoFullName string) error
+	GetKeyOwners(ctx context.Context, repoFullName string) (*KeyOwners, error)
+
+	// Environment methods
+	ListEnvironments(ctx context.Context, repoFullName string) ([]Environment, error)
+	CreateEnvironment(ctx context.Context, repoFullName, env string) error
+	DeleteEnvironment(ctx context.Context, repoFullName, env string) error
+	RenameEnvironment(ctx context.Context, repoFullName, env, newName string) error
+	GetEnvironmentFreeze(ctx context.Context, repoFullName, env string) (*EnvironmentFreeze, error)
+	FreezeEnvironment(ctx context.Context, repoFullName, env, reason string) (*EnvironmentFreeze, error)
+	UnfreezeEnvironment(ctx context.Context, repoFullName, env string) error
+	CreateSnapshot(ctx context.Context, repoFullName, env string) (*Snapshot, error)
+	RestoreSnapshot(ctx context.Context, repoFullName, env, snapshotID string) error
+	CreateNamedSnapshot(ctx context.Context, repoFullName, env, name string) (*Snapshot, error)
+	GetSnapshot(ctx context.Context, repoFullName, env, name string) (*SnapshotContent, error)
+	GetEnvironmentProtection(ctx context.Context, repoFullName, env string) (*EnvironmentProtection, error)
+	SetEnvironmentProtection(ctx context.Context, repoFullName, env string, rules EnvironmentProtection) (*EnvironmentProtection, error)
+	ListTrash(ctx context.Context, repoFullName, env string) ([]TrashedSecret, error)
+	RestoreTrashedSecret(ctx context.Context, repoFullName, env, key string) error
+	DeleteSecrets(ctx context.Context, repoFullName, env string, keys []string) error
+	RequestElevation(ctx context.Context, repoFullName, env string, duration time.Duration, reason string) (*Elevation, error)
+	RevokeElevation(ctx context.Context, repoFullName, env, elevationID string) error
+	StartStepUp(ctx context.Context, repoFullName, env string) (*StepUp, error)
+	PollStepUp(ctx context.Context, repoFullName, env, stepUpID string) (*StepUpStatus, error)
+	RecordInvocation(ctx context.Context, repoFullName, env string, invocation Invocation) error
+	ListEditHints(ctx context.Context, repoFullName string) ([]EditHint, error)
+	AnnounceEdit(ctx context.Context, repoFullName, env string, duration time.Duration, note string) (*EditHint, error)
+	WithdrawEdit(ctx context.Context, repoFullName, env string) error
+
+	// Event methods
+	GetVaultEvents(ctx context.Context, repoFullName string, filter EventFilter) ([]VaultEvent, error)
+	StreamVaultEvents(ctx context.Context, repoFullName string, filter EventFilter, lastEventID string, fn func(VaultEvent) error) error
 
 	// Org methods
 	StartOrganizationTrial(ctx context.Context, orgLogin string) (*StartTrialResponse, error)
+	GetOrganizationPolicy(ctx context.Context, orgLogin string) (*OrganizationPolicy, error)
+	ListBlueprints(ctx context.Context, orgLogin string) ([]Blueprint, error)
+	GetBlueprint(ctx context.Context, orgLogin, name string) (*Blueprint, error)
+	SearchOrganizationKey(ctx context.Context, orgLogin, key string) ([]KeyLocation, error)
 
 	// Secrets methods
-	PushSecrets(ctx context.Context, repo, env string, secrets map[string]string) (*PushSecretsResponse, error)
+	PushSecrets(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*PushSecretsResponse, error)
 	PullSecrets(ctx context.Context, repo, env string) (*PullSecretsResponse, error)
+	PullSecretsAt(ctx context.Context, repo, env string, rev Revision) (*PullSecretsResponse, error)
+	ListVersions(ctx context.Context, repo, env string, filter VersionFilter) ([]EnvironmentVersion, error)
+	ListSecretMetadata(ctx context.Context, repo, env string) ([]SecretMetadata, error)
 
 	// Provider methods
 	GetProviders(ctx context.Context) ([]Provider, error)
@@ -34,6 +80,7 @@ type APIClient interface {
 	GetAllProviderProjects(ctx context.Context, provider string) ([]ProviderProject, []Connection, error)
 
 	// Sync methods
+	GetSyncLinks(ctx context.Context, repo string) ([]SyncLink, error)
 	GetSyncStatus(ctx context.Context, repo, connectionID, projectID, environment string) (*SyncStatus, error)
 	GetSyncDiff(ctx context.Context, repo string, opts SyncOptions) (*SyncDiff, error)
 	GetSyncPreview(ctx context.Context, repo string, opts SyncOptions) (*SyncPreview, error)
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // MockClient is a mock implementation of APIClient for testing
@@ -13,16 +14,60 @@ type MockClient struct {
 	ValidateTokenFn              func(ctx context.Context) (*ValidateTokenResponse, error)
 	CheckGitHubAppInstallationFn func(ctx context.Context, repoOwner, repoName string) (*GitHubAppInstallationStatus, error)
 	GetRepoIdsFromBackendFn      func(ctx context.Context, repoFullName string) (*RepoIds, error)
+	ListSessionsFn               func(ctx context.Context) ([]AuthSession, error)
+	RevokeSessionFn              func(ctx context.Context, sessionID string) error
+	RevokeOtherSessionsFn        func(ctx context.Context) (int, error)
 
 	// Vault mocks
 	InitVaultFn            func(ctx context.Context, repoFullName string) (*InitVaultResponse, error)
 	CheckVaultExistsFn     func(ctx context.Context, repoFullName string) (bool, error)
 	GetVaultDetailsFn      func(ctx context.Context, repoFullName string) (*VaultDetails, error)
 	GetVaultEnvironmentsFn func(ctx context.Context, repoFullName string) ([]string, error)
+	FindMovedVaultFn       func(ctx context.Context, repoFullName string) (*MovedVault, error)
+	RelinkVaultFn          func(ctx context.Context, vaultRepo, repoFullName string) error
+	GetKeyOwnersFn         func(ctx context.Context, repoFullName string) (*KeyOwners, error)
+
+	// Org mocks
+	ListBlueprintsFn        func(ctx context.Context, orgLogin string) ([]Blueprint, error)
+	GetBlueprintFn          func(ctx context.Context, orgLogin, name string) (*Blueprint, error)
+	SearchOrganizationKeyFn func(ctx context.Context, orgLogin, key string) ([]KeyLocation, error)
+
+	// Environment mocks
+	ListEnvironmentsFn     func(ctx context.Context, repoFullName string) ([]Environment, error)
+	CreateEnvironmentFn    func(ctx context.Context, repoFullName, env string) error
+	DeleteEnvironmentFn    func(ctx context.Context, repoFullName, env string) error
+	RenameEnvironmentFn    func(ctx context.Context, repoFullName, env, newName string) error
+	GetEnvironmentFreezeFn func(ctx context.Context, repoFullName, env string) (*EnvironmentFreeze, error)
+	FreezeEnvironmentFn    func(ctx context.Context, repoFullName, env, reason string) (*EnvironmentFreeze, error)
+	UnfreezeEnvironmentFn  func(ctx context.Context, repoFullName, env string) error
+	CreateSnapshotFn       func(ctx context.Context, repoFullName, env string) (*Snapshot, error)
+	RestoreSnapshotFn      func(ctx context.Context, repoFullName, env, snapshotID string) error
+	CreateNamedSnapshotFn  func(ctx context.Context, repoFullName, env, name string) (*Snapshot, error)
+	GetSnapshotFn          func(ctx context.Context, repoFullName, env, name string) (*SnapshotContent, error)
+	GetProtectionFn        func(ctx context.Context, repoFullName, env string) (*EnvironmentProtection, error)
+	SetProtectionFn        func(ctx context.Context, repoFullName, env string, rules EnvironmentProtection) (*EnvironmentProtection, error)
+	ListTrashFn            func(ctx context.Context, repoFullName, env string) ([]TrashedSecret, error)
+	RestoreTrashedSecretFn func(ctx context.Context, repoFullName, env, key string) error
+	DeleteSecretsFn        func(ctx context.Context, repoFullName, env string, keys []string) error
+	RequestElevationFn     func(ctx context.Context, repoFullName, env string, duration time.Duration, reason string) (*Elevation, error)
+	RevokeElevationFn      func(ctx context.Context, repoFullName, env, elevationID string) error
+	StartStepUpFn          func(ctx context.Context, repoFullName, env string) (*StepUp, error)
+	PollStepUpFn           func(ctx context.Context, repoFullName, env, stepUpID string) (*StepUpStatus, error)
+	RecordInvocationFn     func(ctx context.Context, repoFullName, env string, invocation Invocation) error
+	ListEditHintsFn        func(ctx context.Context, repoFullName string) ([]EditHint, error)
+	AnnounceEditFn         func(ctx context.Context, repoFullName, env string, duration time.Duration, note string) (*EditHint, error)
+	WithdrawEditFn         func(ctx context.Context, repoFullName, env string) error
+
+	// Event mocks
+	GetVaultEventsFn    func(ctx context.Context, repoFullName string, filter EventFilter) ([]VaultEvent, error)
+	StreamVaultEventsFn func(ctx context.Context, repoFullName string, filter EventFilter, lastEventID string, fn func(VaultEvent) error) error
 
 	// Secrets mocks
-	PushSecretsFn func(ctx context.Context, repo, env string, secrets map[string]string) (*PushSecretsResponse, error)
-	PullSecretsFn func(ctx context.Context, repo, env string) (*PullSecretsResponse, error)
+	PushSecretsFn   func(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*PushSecretsResponse, error)
+	PullSecretsFn   func(ctx context.Context, repo, env string) (*PullSecretsResponse, error)
+	PullSecretsAtFn func(ctx context.Context, repo, env string, rev Revision) (*PullSecretsResponse, error)
+	ListVersionsFn  func(ctx context.Context, repo, env string, filter VersionFilter) ([]EnvironmentVersion, error)
+	ListSecretMetadataFn func(ctx context.Context, repo, env string) ([]SecretMetadata, error)
 
 	// Provider mocks
 	GetProvidersFn           func(ctx context.Context) ([]Provider, error)
@@ -33,6 +78,7 @@ type MockClient struct {
 	GetAllProviderProjectsFn func(ctx context.Context, provider string) ([]ProviderProject, []Connection, error)
 
 	// Sync mocks
+	GetSyncLinksFn   func(ctx context.Context, repo string) ([]SyncLink, error)
 	GetSyncStatusFn  func(ctx context.Context, repo, connectionID, projectID, environment string) (*SyncStatus, error)
 	GetSyncDiffFn    func(ctx context.Context, repo string, opts SyncOptions) (*SyncDiff, error)
 	GetSyncPreviewFn func(ctx context.Context, repo string, opts SyncOptions) (*SyncPreview, error)
@@ -103,6 +149,30 @@ func (m *MockClient) ValidateToken(ctx context.Context) (*ValidateTokenResponse,
 	}, nil
 }
 
+func (m *MockClient) ListSessions(ctx context.Context) ([]AuthSession, error) {
+	m.track("ListSessions")
+	if m.ListSessionsFn != nil {
+		return m.ListSessionsFn(ctx)
+	}
+	return []AuthSession{}, nil
+}
+
+func (m *MockClient) RevokeSession(ctx context.Context, sessionID string) error {
+	m.track("RevokeSession")
+	if m.RevokeSessionFn != nil {
+		return m.RevokeSessionFn(ctx, sessionID)
+	}
+	return nil
+}
+
+func (m *MockClient) RevokeOtherSessions(ctx context.Context) (int, error) {
+	m.track("RevokeOtherSessions")
+	if m.RevokeOtherSessionsFn != nil {
+		return m.RevokeOtherSessionsFn(ctx)
+	}
+	return 0, nil
+}
+
 func (m *MockClient) CheckGitHubAppInstallation(ctx context.Context, repoOwner, repoName string) (*GitHubAppInstallationStatus, error) {
 	m.track("CheckGitHubAppInstallation")
 	if m.CheckGitHubAppInstallationFn != nil {
@@ -155,11 +225,245 @@ func (m *MockClient) GetVaultEnvironments(ctx context.Context, repoFullName stri
 	return []string{"production", "staging", "development"}, nil
 }
 
+func (m *MockClient) FindMovedVault(ctx context.Context, repoFullName string) (*MovedVault, error) {
+	m.track("FindMovedVault")
+	if m.FindMovedVaultFn != nil {
+		return m.FindMovedVaultFn(ctx, repoFullName)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) RelinkVault(ctx context.Context, vaultRepo, repoFullName string) error {
+	m.track("RelinkVault")
+	if m.RelinkVaultFn != nil {
+		return m.RelinkVaultFn(ctx, vaultRepo, repoFullName)
+	}
+	return nil
+}
+
+func (m *MockClient) GetKeyOwners(ctx context.Context, repoFullName string) (*KeyOwners, error) {
+	m.track("GetKeyOwners")
+	if m.GetKeyOwnersFn != nil {
+		return m.GetKeyOwnersFn(ctx, repoFullName)
+	}
+	return &KeyOwners{}, nil
+}
+
+// Environment methods
+func (m *MockClient) ListEnvironments(ctx context.Context, repoFullName string) ([]Environment, error) {
+	m.track("ListEnvironments")
+	if m.ListEnvironmentsFn != nil {
+		return m.ListEnvironmentsFn(ctx, repoFullName)
+	}
+	return []Environment{{Name: "development"}, {Name: "production"}}, nil
+}
+
+func (m *MockClient) CreateEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.track("CreateEnvironment")
+	if m.CreateEnvironmentFn != nil {
+		return m.CreateEnvironmentFn(ctx, repoFullName, env)
+	}
+	return nil
+}
+
+func (m *MockClient) DeleteEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.track("DeleteEnvironment")
+	if m.DeleteEnvironmentFn != nil {
+		return m.DeleteEnvironmentFn(ctx, repoFullName, env)
+	}
+	return nil
+}
+
+func (m *MockClient) RenameEnvironment(ctx context.Context, repoFullName, env, newName string) error {
+	m.track("RenameEnvironment")
+	if m.RenameEnvironmentFn != nil {
+		return m.RenameEnvironmentFn(ctx, repoFullName, env, newName)
+	}
+	return nil
+}
+
+func (m *MockClient) GetEnvironmentFreeze(ctx context.Context, repoFullName, env string) (*EnvironmentFreeze, error) {
+	m.track("GetEnvironmentFreeze")
+	if m.GetEnvironmentFreezeFn != nil {
+		return m.GetEnvironmentFreezeFn(ctx, repoFullName, env)
+	}
+	return &EnvironmentFreeze{Frozen: false}, nil
+}
+
+func (m *MockClient) FreezeEnvironment(ctx context.Context, repoFullName, env, reason string) (*EnvironmentFreeze, error) {
+	m.track("FreezeEnvironment")
+	if m.FreezeEnvironmentFn != nil {
+		return m.FreezeEnvironmentFn(ctx, repoFullName, env, reason)
+	}
+	return &EnvironmentFreeze{Frozen: true, Reason: reason}, nil
+}
+
+func (m *MockClient) UnfreezeEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.track("UnfreezeEnvironment")
+	if m.UnfreezeEnvironmentFn != nil {
+		return m.UnfreezeEnvironmentFn(ctx, repoFullName, env)
+	}
+	return nil
+}
+
+func (m *MockClient) CreateSnapshot(ctx context.Context, repoFullName, env string) (*Snapshot, error) {
+	m.track("CreateSnapshot")
+	if m.CreateSnapshotFn != nil {
+		return m.CreateSnapshotFn(ctx, repoFullName, env)
+	}
+	return &Snapshot{ID: "snap_mock"}, nil
+}
+
+func (m *MockClient) RestoreSnapshot(ctx context.Context, repoFullName, env, snapshotID string) error {
+	m.track("RestoreSnapshot")
+	if m.RestoreSnapshotFn != nil {
+		return m.RestoreSnapshotFn(ctx, repoFullName, env, snapshotID)
+	}
+	return nil
+}
+
+func (m *MockClient) CreateNamedSnapshot(ctx context.Context, repoFullName, env, name string) (*Snapshot, error) {
+	m.track("CreateNamedSnapshot")
+	if m.CreateNamedSnapshotFn != nil {
+		return m.CreateNamedSnapshotFn(ctx, repoFullName, env, name)
+	}
+	return &Snapshot{ID: "snap_mock", Name: name}, nil
+}
+
+func (m *MockClient) GetSnapshot(ctx context.Context, repoFullName, env, name string) (*SnapshotContent, error) {
+	m.track("GetSnapshot")
+	if m.GetSnapshotFn != nil {
+		return m.GetSnapshotFn(ctx, repoFullName, env, name)
+	}
+	return &SnapshotContent{Snapshot: Snapshot{ID: "snap_mock", Name: name}}, nil
+}
+
+func (m *MockClient) RecordInvocation(ctx context.Context, repoFullName, env string, invocation Invocation) error {
+	m.track("RecordInvocation")
+	if m.RecordInvocationFn != nil {
+		return m.RecordInvocationFn(ctx, repoFullName, env, invocation)
+	}
+	return nil
+}
+
+func (m *MockClient) ListEditHints(ctx context.Context, repoFullName string) ([]EditHint, error) {
+	m.track("ListEditHints")
+	if m.ListEditHintsFn != nil {
+		return m.ListEditHintsFn(ctx, repoFullName)
+	}
+	return []EditHint{}, nil
+}
+
+func (m *MockClient) AnnounceEdit(ctx context.Context, repoFullName, env string, duration time.Duration, note string) (*EditHint, error) {
+	m.track("AnnounceEdit")
+	if m.AnnounceEditFn != nil {
+		return m.AnnounceEditFn(ctx, repoFullName, env, duration, note)
+	}
+	return &EditHint{Environment: env, Note: note, Mine: true}, nil
+}
+
+func (m *MockClient) WithdrawEdit(ctx context.Context, repoFullName, env string) error {
+	m.track("WithdrawEdit")
+	if m.WithdrawEditFn != nil {
+		return m.WithdrawEditFn(ctx, repoFullName, env)
+	}
+	return nil
+}
+
+func (m *MockClient) GetEnvironmentProtection(ctx context.Context, repoFullName, env string) (*EnvironmentProtection, error) {
+	m.track("GetEnvironmentProtection")
+	if m.GetProtectionFn != nil {
+		return m.GetProtectionFn(ctx, repoFullName, env)
+	}
+	return &EnvironmentProtection{}, nil
+}
+
+func (m *MockClient) SetEnvironmentProtection(ctx context.Context, repoFullName, env string, rules EnvironmentProtection) (*EnvironmentProtection, error) {
+	m.track("SetEnvironmentProtection")
+	if m.SetProtectionFn != nil {
+		return m.SetProtectionFn(ctx, repoFullName, env, rules)
+	}
+	return &rules, nil
+}
+
+func (m *MockClient) ListTrash(ctx context.Context, repoFullName, env string) ([]TrashedSecret, error) {
+	m.track("ListTrash")
+	if m.ListTrashFn != nil {
+		return m.ListTrashFn(ctx, repoFullName, env)
+	}
+	return []TrashedSecret{}, nil
+}
+
+func (m *MockClient) RestoreTrashedSecret(ctx context.Context, repoFullName, env, key string) error {
+	m.track("RestoreTrashedSecret")
+	if m.RestoreTrashedSecretFn != nil {
+		return m.RestoreTrashedSecretFn(ctx, repoFullName, env, key)
+	}
+	return nil
+}
+
+func (m *MockClient) DeleteSecrets(ctx context.Context, repoFullName, env string, keys []string) error {
+	m.track("DeleteSecrets")
+	if m.DeleteSecretsFn != nil {
+		return m.DeleteSecretsFn(ctx, repoFullName, env, keys)
+	}
+	return nil
+}
+
+func (m *MockClient) RequestElevation(ctx context.Context, repoFullName, env string, duration time.Duration, reason string) (*Elevation, error) {
+	m.track("RequestElevation")
+	if m.RequestElevationFn != nil {
+		return m.RequestElevationFn(ctx, repoFullName, env, duration, reason)
+	}
+	return &Elevation{ID: "test-elevation", Environment: env, Reason: reason, ExpiresAt: time.Now().Add(duration).UTC().Format(time.RFC3339)}, nil
+}
+
+func (m *MockClient) RevokeElevation(ctx context.Context, repoFullName, env, elevationID string) error {
+	m.track("RevokeElevation")
+	if m.RevokeElevationFn != nil {
+		return m.RevokeElevationFn(ctx, repoFullName, env, elevationID)
+	}
+	return nil
+}
+
+func (m *MockClient) StartStepUp(ctx context.Context, repoFullName, env string) (*StepUp, error) {
+	m.track("StartStepUp")
+	if m.StartStepUpFn != nil {
+		return m.StartStepUpFn(ctx, repoFullName, env)
+	}
+	return &StepUp{ID: "test-step-up", VerificationURL: "https://keyway.sh/step-up/test-step-up"}, nil
+}
+
+func (m *MockClient) PollStepUp(ctx context.Context, repoFullName, env, stepUpID string) (*StepUpStatus, error) {
+	m.track("PollStepUp")
+	if m.PollStepUpFn != nil {
+		return m.PollStepUpFn(ctx, repoFullName, env, stepUpID)
+	}
+	return &StepUpStatus{Status: StepUpApproved, Token: "test-step-up-token"}, nil
+}
+
+// Event methods
+func (m *MockClient) GetVaultEvents(ctx context.Context, repoFullName string, filter EventFilter) ([]VaultEvent, error) {
+	m.track("GetVaultEvents")
+	if m.GetVaultEventsFn != nil {
+		return m.GetVaultEventsFn(ctx, repoFullName, filter)
+	}
+	return []VaultEvent{}, nil
+}
+
+func (m *MockClient) StreamVaultEvents(ctx context.Context, repoFullName string, filter EventFilter, lastEventID string, fn func(VaultEvent) error) error {
+	m.track("StreamVaultEvents")
+	if m.StreamVaultEventsFn != nil {
+		return m.StreamVaultEventsFn(ctx, repoFullName, filter, lastEventID, fn)
+	}
+	return nil
+}
+
 // Secrets methods
-func (m *MockClient) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string) (*PushSecretsResponse, error) {
+func (m *MockClient) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*PushSecretsResponse, error) {
 	m.track("PushSecrets")
 	if m.PushSecretsFn != nil {
-		return m.PushSecretsFn(ctx, repo, env, secrets)
+		return m.PushSecretsFn(ctx, repo, env, secrets, idempotencyKey)
 	}
 	return &PushSecretsResponse{
 		Success: true,
@@ -186,6 +490,35 @@ func (m *MockClient) PullSecrets(ctx context.Context, repo, env string) (*PullSe
 	}, nil
 }
 
+func (m *MockClient) PullSecretsAt(ctx context.Context, repo, env string, rev Revision) (*PullSecretsResponse, error) {
+	m.track("PullSecretsAt")
+	if m.PullSecretsAtFn != nil {
+		return m.PullSecretsAtFn(ctx, repo, env, rev)
+	}
+	return &PullSecretsResponse{
+		Content: "API_KEY=old-api-key\nDB_HOST=localhost\n",
+	}, nil
+}
+
+func (m *MockClient) ListVersions(ctx context.Context, repo, env string, filter VersionFilter) ([]EnvironmentVersion, error) {
+	m.track("ListVersions")
+	if m.ListVersionsFn != nil {
+		return m.ListVersionsFn(ctx, repo, env, filter)
+	}
+	return []EnvironmentVersion{}, nil
+}
+
+func (m *MockClient) ListSecretMetadata(ctx context.Context, repo, env string) ([]SecretMetadata, error) {
+	m.track("ListSecretMetadata")
+	if m.ListSecretMetadataFn != nil {
+		return m.ListSecretMetadataFn(ctx, repo, env)
+	}
+	return []SecretMetadata{
+		{Key: "API_KEY", UpdatedAt: "2024-06-01T12:00:00Z", UpdatedBy: "alice"},
+		{Key: "DB_HOST", UpdatedAt: "2024-05-01T12:00:00Z", UpdatedBy: "bob"},
+	}, nil
+}
+
 // Provider methods
 func (m *MockClient) GetProviders(ctx context.Context) ([]Provider, error) {
 	m.track("GetProviders")
@@ -253,6 +586,14 @@ func (m *MockClient) GetAllProviderProjects(ctx context.Context, provider string
 }
 
 // Sync methods
+func (m *MockClient) GetSyncLinks(ctx context.Context, repo string) ([]SyncLink, error) {
+	m.track("GetSyncLinks")
+	if m.GetSyncLinksFn != nil {
+		return m.GetSyncLinksFn(ctx, repo)
+	}
+	return nil, nil
+}
+
 func (m *MockClient) GetSyncStatus(ctx context.Context, repo, connectionID, projectID, environment string) (*SyncStatus, error) {
 	m.track("GetSyncStatus")
 	if m.GetSyncStatusFn != nil {
@@ -320,5 +661,34 @@ func (m *MockClient) StartOrganizationTrial(ctx context.Context, orgLogin string
 	}, nil
 }
 
+func (m *MockClient) GetOrganizationPolicy(ctx context.Context, orgLogin string) (*OrganizationPolicy, error) {
+	m.track("GetOrganizationPolicy")
+	return &OrganizationPolicy{Telemetry: TelemetryPolicy{Sink: "keyway"}}, nil
+}
+
+func (m *MockClient) ListBlueprints(ctx context.Context, orgLogin string) ([]Blueprint, error) {
+	m.track("ListBlueprints")
+	if m.ListBlueprintsFn != nil {
+		return m.ListBlueprintsFn(ctx, orgLogin)
+	}
+	return []Blueprint{}, nil
+}
+
+func (m *MockClient) GetBlueprint(ctx context.Context, orgLogin, name string) (*Blueprint, error) {
+	m.track("GetBlueprint")
+	if m.GetBlueprintFn != nil {
+		return m.GetBlueprintFn(ctx, orgLogin, name)
+	}
+	return &Blueprint{Name: name}, nil
+}
+
+func (m *MockClient) SearchOrganizationKey(ctx context.Context, orgLogin, key string) ([]KeyLocation, error) {
+	m.track("SearchOrganizationKey")
+	if m.SearchOrganizationKeyFn != nil {
+		return m.SearchOrganizationKeyFn(ctx, orgLogin, key)
+	}
+	return []KeyLocation{}, nil
+}
+
 // Verify MockClient implements APIClient
 var _ APIClient = (*MockClient)(nil)
@@ -0,0 +1,102 @@
+package api
+
+import "github.com/keywaysh/cli/pkg/keyway"
+
+// The client and its types live in the public Go SDK, pkg/keyway. This package
+// re-exports them for the commands, next to the APIClient interface and the
+// mock they are tested with.
+
+// Client and response types
+type (
+	DeviceStartResponse         = keyway.DeviceStartResponse
+	DevicePollResponse          = keyway.DevicePollResponse
+	ValidateTokenResponse       = keyway.ValidateTokenResponse
+	GitHubAppInstallationStatus = keyway.GitHubAppInstallationStatus
+	RepoIds                     = keyway.RepoIds
+	Client                      = keyway.Client
+	TrialEligibility            = keyway.TrialEligibility
+	APIError                    = keyway.APIError
+	Environment                 = keyway.Environment
+	EnvironmentFreeze           = keyway.EnvironmentFreeze
+	Snapshot                    = keyway.Snapshot
+	SnapshotContent             = keyway.SnapshotContent
+	EnvironmentProtection       = keyway.EnvironmentProtection
+	ProtectionViolation         = keyway.ProtectionViolation
+	VaultEvent                  = keyway.VaultEvent
+	EventFilter                 = keyway.EventFilter
+	Revision                    = keyway.Revision
+	EnvironmentVersion          = keyway.EnvironmentVersion
+	VersionFilter               = keyway.VersionFilter
+	TrialInfo                   = keyway.TrialInfo
+	OrganizationInfo            = keyway.OrganizationInfo
+	TelemetryPolicy             = keyway.TelemetryPolicy
+	OrganizationPolicy          = keyway.OrganizationPolicy
+	StartTrialResponse          = keyway.StartTrialResponse
+	Provider                    = keyway.Provider
+	Connection                  = keyway.Connection
+	ConnectTokenResponse        = keyway.ConnectTokenResponse
+	ProviderProject             = keyway.ProviderProject
+	SyncDiff                    = keyway.SyncDiff
+	SyncPreview                 = keyway.SyncPreview
+	SyncResult                  = keyway.SyncResult
+	SyncStatus                  = keyway.SyncStatus
+	ProjectLink                 = keyway.ProjectLink
+	SyncLink                    = keyway.SyncLink
+	SyncOptions                 = keyway.SyncOptions
+	PushSecretsResponse         = keyway.PushSecretsResponse
+	PullSecretsResponse         = keyway.PullSecretsResponse
+	PullBase                    = keyway.PullBase
+	SecretMetadata              = keyway.SecretMetadata
+	TrashedSecret               = keyway.TrashedSecret
+	InitVaultResponse           = keyway.InitVaultResponse
+	VaultInfo                   = keyway.VaultInfo
+	VaultDetails                = keyway.VaultDetails
+	MovedVault                  = keyway.MovedVault
+	KeyOwners                   = keyway.KeyOwners
+	Blueprint                   = keyway.Blueprint
+	BlueprintKey                = keyway.BlueprintKey
+	KeyLocation                 = keyway.KeyLocation
+	AuthSession                 = keyway.AuthSession
+	Elevation                   = keyway.Elevation
+	StepUp                      = keyway.StepUp
+	StepUpStatus                = keyway.StepUpStatus
+	Invocation                  = keyway.Invocation
+	EditHint                    = keyway.EditHint
+	Budget                      = keyway.Budget
+)
+
+// Constants
+const (
+	APIVersion            = keyway.APIVersion
+	RuleRequiredReviewers = keyway.RuleRequiredReviewers
+	RuleAllowedTokens     = keyway.RuleAllowedTokens
+	RuleAllowedCIDRs      = keyway.RuleAllowedCIDRs
+	RuleKeyOwners         = keyway.RuleKeyOwners
+	RuleWebAuthn          = keyway.RuleWebAuthn
+	StepUpPending         = keyway.StepUpPending
+	StepUpApproved        = keyway.StepUpApproved
+	StepUpDenied          = keyway.StepUpDenied
+	StepUpExpired         = keyway.StepUpExpired
+	IdempotencyHeader     = keyway.IdempotencyHeader
+	TrashRetentionDays    = keyway.TrashRetentionDays
+	PermissionRead        = keyway.PermissionRead
+	PermissionTriage      = keyway.PermissionTriage
+	PermissionWrite       = keyway.PermissionWrite
+	PermissionMaintain    = keyway.PermissionMaintain
+	PermissionAdmin       = keyway.PermissionAdmin
+	MovedRenamed          = keyway.MovedRenamed
+	MovedTransferred      = keyway.MovedTransferred
+)
+
+// Functions
+var (
+	GetRepoIdsFromGitHub = keyway.GetRepoIdsFromGitHub
+	SetClientVersion     = keyway.SetClientVersion
+	NewClient            = keyway.NewClient
+	NewClientWithVersion = keyway.NewClientWithVersion
+	ParseRevision        = keyway.ParseRevision
+	NewBudget            = keyway.NewBudget
+)
+
+// Errors
+var ErrBudgetExceeded = keyway.ErrBudgetExceeded
@@ -12,6 +12,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/keywaysh/cli/internal/config"
 )
 
 // StoredAuth represents the stored authentication data
@@ -26,11 +28,36 @@ type StoredAuth struct {
 type Store struct {
 	configPath string
 	keyPath    string
+	// profile is the named login this store reads and writes, "" for the default one
+	profile string
+}
+
+// profile is the named login used by stores created afterwards
+var profile string
+
+// SetProfile selects the named login used by stores created afterwards.
+// Each profile is stored next to the default login, in the same file.
+func SetProfile(name string) {
+	profile = name
+}
+
+// Profile returns the selected named login, "" for the default one
+func Profile() string {
+	return profile
 }
 
 // NewStore creates a new auth store
 // Uses the same paths as the Node.js CLI for compatibility
 func NewStore() *Store {
+	// Shared config directory (e.g. mounted into a devcontainer)
+	if dir := config.GetConfigDir(); dir != "" {
+		return &Store{
+			configPath: filepath.Join(dir, "config.json"),
+			keyPath:    filepath.Join(dir, ".key"),
+			profile:    profile,
+		}
+	}
+
 	homeDir, _ := os.UserHomeDir()
 
 	// Match Node.js conf package paths for compatibility
@@ -47,6 +74,7 @@ func NewStore() *Store {
 	return &Store{
 		configPath: filepath.Join(configDir, "config.json"),
 		keyPath:    filepath.Join(homeDir, ".keyway", ".key"),
+		profile:    profile,
 	}
 }
 
@@ -66,7 +94,7 @@ func (s *Store) GetAuth() (*StoredAuth, error) {
 		return nil, err
 	}
 
-	encryptedAuth, ok := config["auth"]
+	encryptedAuth, ok := config[s.authKey()]
 	if !ok || encryptedAuth == "" {
 		return nil, nil
 	}
@@ -120,7 +148,8 @@ func (s *Store) SaveAuth(token, githubLogin, expiresAt string) error {
 		return err
 	}
 
-	config := map[string]string{"auth": encrypted}
+	config := s.readConfig()
+	config[s.authKey()] = encrypted
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
@@ -135,16 +164,46 @@ func (s *Store) ClearAuth() error {
 		return nil
 	}
 
-	config := map[string]string{}
+	config := s.readConfig()
+	delete(config, s.authKey())
 	data, _ := json.MarshalIndent(config, "", "  ")
 	return os.WriteFile(s.configPath, data, 0600)
 }
 
+// authKey returns the config entry holding this store's login
+func (s *Store) authKey() string {
+	if s.profile == "" {
+		return "auth"
+	}
+	return "auth:" + s.profile
+}
+
+// readConfig returns the entries of the config file, the logins of other
+// profiles included, or an empty map
+func (s *Store) readConfig() map[string]string {
+	config := map[string]string{}
+	if data, err := os.ReadFile(s.configPath); err == nil {
+		_ = json.Unmarshal(data, &config)
+	}
+	return config
+}
+
 // GetConfigPath returns the path to the config file
 func (s *Store) GetConfigPath() string {
 	return s.configPath
 }
 
+// Encrypt encrypts data with the key protecting the stored logins, for other
+// local caches of sensitive data
+func (s *Store) Encrypt(plaintext string) (string, error) {
+	return s.encrypt(plaintext)
+}
+
+// Decrypt decrypts data encrypted by Encrypt
+func (s *Store) Decrypt(data string) (string, error) {
+	return s.decrypt(data)
+}
+
 // getOrCreateKey gets or creates the encryption key
 func (s *Store) getOrCreateKey() ([]byte, error) {
 	// Try to read existing key
@@ -438,3 +438,48 @@ func TestStore_TruncatedEncryptionKey(t *testing.T) {
 		t.Error("expected nil auth with truncated key")
 	}
 }
+
+func TestNewStore_ConfigDirOverride(t *testing.T) {
+	os.Setenv("KEYWAY_CONFIG_DIR", "/shared/keyway")
+	defer os.Unsetenv("KEYWAY_CONFIG_DIR")
+
+	store := NewStore()
+	if store.configPath != filepath.Join("/shared/keyway", "config.json") {
+		t.Errorf("configPath = %q, want /shared/keyway/config.json", store.configPath)
+	}
+	if store.keyPath != filepath.Join("/shared/keyway", ".key") {
+		t.Errorf("keyPath = %q, want /shared/keyway/.key", store.keyPath)
+	}
+}
+
+func TestStore_Profiles(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	work := &Store{configPath: store.configPath, keyPath: store.keyPath, profile: "work"}
+
+	if err := store.SaveAuth("default-token", "me", ""); err != nil {
+		t.Fatalf("SaveAuth failed: %v", err)
+	}
+	if err := work.SaveAuth("work-token", "me-at-work", ""); err != nil {
+		t.Fatalf("SaveAuth failed: %v", err)
+	}
+
+	def, _ := store.GetAuth()
+	if def == nil || def.KeywayToken != "default-token" {
+		t.Errorf("expected the default login to be kept, got %+v", def)
+	}
+	got, _ := work.GetAuth()
+	if got == nil || got.KeywayToken != "work-token" {
+		t.Errorf("expected the work login, got %+v", got)
+	}
+
+	if err := work.ClearAuth(); err != nil {
+		t.Fatalf("ClearAuth failed: %v", err)
+	}
+	if got, _ := work.GetAuth(); got != nil {
+		t.Error("expected the work login to be cleared")
+	}
+	if def, _ := store.GetAuth(); def == nil {
+		t.Error("clearing a profile should keep the default login")
+	}
+}
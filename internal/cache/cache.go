@@ -0,0 +1,131 @@
+// Package cache keeps prefetched environments on disk, encrypted, so that
+// keyway run can start without waiting for the network.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cipher encrypts the cached entries
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(data string) (string, error)
+}
+
+// Entry is the content of an environment as fetched at some point
+type Entry struct {
+	Repo        string    `json:"repo"`
+	Environment string    `json:"environment"`
+	Content     string    `json:"content"`
+	Version     int       `json:"version,omitempty"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+// Age returns how long ago the entry was fetched
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.FetchedAt)
+}
+
+// Cache stores entries in a directory, one encrypted file per environment
+type Cache struct {
+	dir    string
+	cipher Cipher
+}
+
+// New returns a cache in dir, created on the first Put
+func New(dir string, cipher Cipher) *Cache {
+	return &Cache{dir: dir, cipher: cipher}
+}
+
+// Get returns the entry of an environment. Entries that cannot be read or
+// decrypted, e.g. after the key changed, are missing.
+func (c *Cache) Get(repo, env string) (*Entry, bool) {
+	data, err := os.ReadFile(c.path(repo, env))
+	if err != nil {
+		return nil, false
+	}
+	plaintext, err := c.cipher.Decrypt(string(data))
+	if err != nil {
+		return nil, false
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(plaintext), &entry); err != nil || entry.Repo != repo || entry.Environment != env {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put stores the entry of an environment, replacing the previous one
+func (c *Cache) Put(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := c.cipher.Encrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("cannot encrypt the cache: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	// Written aside then renamed, so that a concurrent Get never reads half an entry
+	path := c.path(entry.Repo, entry.Environment)
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(ciphertext); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Forget removes the entry of an environment, once it changed
+func (c *Cache) Forget(repo, env string) error {
+	if err := os.Remove(c.path(repo, env)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Prune removes the entries fetched more than maxAge ago
+func (c *Cache) Prune(maxAge time.Duration, now time.Time) error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err == nil && now.Sub(info.ModTime()) > maxAge {
+			_ = os.Remove(filepath.Join(c.dir, f.Name()))
+		}
+	}
+	return nil
+}
+
+// Clear removes every entry
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// path returns the file of an environment, named so that it doesn't reveal
+// the repository
+func (c *Cache) path(repo, env string) string {
+	sum := sha256.Sum256([]byte(repo + "\x00" + env))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".enc")
+}
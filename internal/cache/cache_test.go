@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reverseCipher stands for the auth store's cipher, reversing the text
+type reverseCipher struct{}
+
+func (reverseCipher) Encrypt(plaintext string) (string, error) {
+	return "enc:" + reverse(plaintext), nil
+}
+
+func (reverseCipher) Decrypt(data string) (string, error) {
+	if !strings.HasPrefix(data, "enc:") {
+		return "", errors.New("not encrypted")
+	}
+	return reverse(strings.TrimPrefix(data, "enc:")), nil
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func TestCache_PutGet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := New(dir, reverseCipher{})
+	fetchedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if _, ok := c.Get("owner/repo", "development"); ok {
+		t.Fatal("expected an empty cache")
+	}
+	if err := c.Put(Entry{Repo: "owner/repo", Environment: "development", Content: "API_KEY=sk_123\n", Version: 3, FetchedAt: fetchedAt}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry, ok := c.Get("owner/repo", "development")
+	if !ok {
+		t.Fatal("expected the entry back")
+	}
+	if entry.Content != "API_KEY=sk_123\n" || entry.Version != 3 || !entry.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if _, ok := c.Get("owner/repo", "production"); ok {
+		t.Error("expected another environment to be missing")
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected a single file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if strings.Contains(string(data), "sk_123") || strings.Contains(files[0].Name(), "repo") {
+		t.Error("expected the entry to be encrypted and its file name not to reveal the repository")
+	}
+}
+
+func TestCache_UnreadableEntriesAreMissing(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, reverseCipher{})
+	if err := c.Put(Entry{Repo: "owner/repo", Environment: "development", Content: "A=1\n"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(c.path("owner/repo", "development"), []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("owner/repo", "development"); ok {
+		t.Error("expected an entry that cannot be decrypted to be missing")
+	}
+}
+
+func TestCache_ForgetPruneClear(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := New(dir, reverseCipher{})
+	for _, env := range []string{"development", "staging", "production"} {
+		if err := c.Put(Entry{Repo: "owner/repo", Environment: env, Content: "A=1\n"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := c.Forget("owner/repo", "staging"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := c.Get("owner/repo", "staging"); ok {
+		t.Error("expected the forgotten entry to be gone")
+	}
+	if err := c.Forget("owner/repo", "staging"); err != nil {
+		t.Errorf("expected forgetting a missing entry to succeed, got %v", err)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(c.path("owner/repo", "production"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Prune(10*time.Minute, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := c.Get("owner/repo", "production"); ok {
+		t.Error("expected the old entry to be pruned")
+	}
+	if _, ok := c.Get("owner/repo", "development"); !ok {
+		t.Error("expected the recent entry to be kept")
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the cache directory to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/injector"
+	"github.com/spf13/cobra"
+)
+
+var activateCmd = &cobra.Command{
+	Use:   "activate",
+	Short: "Export secrets into the current shell",
+	Long: `Print the code that exports the secrets of an environment into the current
+shell and prefixes its prompt, like a Python virtualenv. Evaluate it:
+
+  eval "$(keyway activate -e development)"                       # bash, zsh, sh
+  keyway activate -e development --shell fish | source           # fish
+  keyway activate -e development --shell pwsh | Out-String | iex # PowerShell
+
+The exported keys are recorded, and keyway deactivate unsets exactly those.
+Activating again switches environments. Secrets stay in the shell's memory
+until then, prefer keyway run or keyway shell when that is enough.`,
+	Args: cobra.NoArgs,
+	RunE: runActivate,
+}
+
+var deactivateCmd = &cobra.Command{
+	Use:   "deactivate",
+	Short: "Unset the secrets exported by keyway activate",
+	Long: `Print the code that unsets the variables keyway activate exported into the
+current shell and restores its prompt. Evaluate it like keyway activate:
+
+  eval "$(keyway deactivate)"`,
+	Args: cobra.NoArgs,
+	RunE: runDeactivate,
+}
+
+func init() {
+	activateCmd.Flags().StringP("env", "e", "development", "Environment name")
+	activateCmd.Flags().String("shell", "", "Shell evaluating the code: bash, zsh, sh, fish or pwsh (default: $SHELL)")
+	activateCmd.Flags().String("remap", "", "Rename keys with a remap declared in .keyway.json")
+	activateCmd.Flags().Bool("expand", false, "Resolve ${VAR} references in values")
+	deactivateCmd.Flags().String("shell", "", "Shell evaluating the code: bash, zsh, sh, fish or pwsh (default: $SHELL)")
+}
+
+// activationMaxAge is how long an activation is remembered, for shells left open
+const activationMaxAge = 30 * 24 * time.Hour
+
+// activateOutput is where keyway activate and deactivate print the code to evaluate
+var activateOutput io.Writer = os.Stdout
+
+// ActivateOptions contains the parsed flags for the activate command
+type ActivateOptions struct {
+	EnvName string
+	Shell   string
+	Remap   string
+	Expand  bool
+}
+
+// activation records the keys keyway activate exported into a shell
+type activation struct {
+	Repo        string   `json:"repo"`
+	Environment string   `json:"environment"`
+	Keys        []string `json:"keys"`
+	ActivatedAt string   `json:"activatedAt"`
+}
+
+// runActivate is the entry point for the activate command (uses default dependencies)
+func runActivate(cmd *cobra.Command, args []string) error {
+	opts := ActivateOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Shell, _ = cmd.Flags().GetString("shell")
+	opts.Remap, _ = cmd.Flags().GetString("remap")
+	opts.Expand, _ = cmd.Flags().GetBool("expand")
+
+	// stdout only carries the code to evaluate
+	return runActivateWithDeps(opts, withQuietUI(defaultDeps))
+}
+
+// runActivateWithDeps is the testable version of runActivate
+func runActivateWithDeps(opts ActivateOptions, deps *Dependencies) error {
+	if active := os.Getenv(injector.ShellEnvVar); active != "" {
+		err := fmt.Errorf("already in a keyway shell (%s)", active)
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if opts.Shell == "" {
+		opts.Shell = injector.DefaultShell()
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return activate(s, opts)
+	}, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// activate prints the code exporting the secrets of the environment
+func activate(s *Session, opts ActivateOptions) error {
+	deps := s.Deps
+	var content string
+	err := s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("activate", err, deps)
+	}
+
+	secrets, err := runSecrets(content, opts.Remap, opts.Expand, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	for _, key := range sortedKeys(secrets) {
+		if _, isFile := env.DecodeFile(secrets[key]); isFile {
+			// The file would outlive the command writing it, with nothing to remove it
+			deps.UI.Warn(fmt.Sprintf("Skipped %s: file secrets need keyway run or keyway shell", key))
+			delete(secrets, key)
+		} else if !injector.ValidVarName(key) {
+			deps.UI.Warn(fmt.Sprintf("Skipped %s: not a valid variable name", key))
+			delete(secrets, key)
+		}
+	}
+
+	// Activating again replaces the previous activation of the shell
+	activations := loadActivations(deps)
+	var script string
+	if previous := os.Getenv(injector.ActivationEnvVar); previous != "" {
+		if script, err = deactivationScript(activations, previous, opts.Shell, deps); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+	}
+
+	id := uuid.NewString()
+	activateScript, err := injector.ActivateScript(opts.Shell, s.EnvName, id, secrets)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	activations[id] = activation{
+		Repo:        s.Repo,
+		Environment: s.EnvName,
+		Keys:        sortedKeys(secrets),
+		ActivatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := writeActivations(activations, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to record the activation: %s", err.Error()))
+		return err
+	}
+
+	fmt.Fprint(activateOutput, script+activateScript)
+	return nil
+}
+
+// DeactivateOptions contains the parsed flags for the deactivate command
+type DeactivateOptions struct {
+	Shell string
+}
+
+// runDeactivate is the entry point for the deactivate command (uses default dependencies)
+func runDeactivate(cmd *cobra.Command, args []string) error {
+	opts := DeactivateOptions{}
+	opts.Shell, _ = cmd.Flags().GetString("shell")
+
+	return runDeactivateWithDeps(opts, withQuietUI(defaultDeps))
+}
+
+// runDeactivateWithDeps is the testable version of runDeactivate
+func runDeactivateWithDeps(opts DeactivateOptions, deps *Dependencies) error {
+	id := os.Getenv(injector.ActivationEnvVar)
+	if id == "" {
+		err := fmt.Errorf("no environment activated in this shell")
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if opts.Shell == "" {
+		opts.Shell = injector.DefaultShell()
+	}
+
+	activations := loadActivations(deps)
+	script, err := deactivationScript(activations, id, opts.Shell, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if err := writeActivations(activations, deps); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Failed to forget the activation: %s", err.Error()))
+	}
+	fmt.Fprint(activateOutput, script)
+	return nil
+}
+
+// deactivationScript returns the code unsetting what an activation exported,
+// and removes it from activations
+func deactivationScript(activations map[string]activation, id, shell string, deps *Dependencies) (string, error) {
+	a, ok := activations[id]
+	if !ok {
+		deps.UI.Warn("This shell's activation is unknown, only the prompt is restored")
+	}
+	delete(activations, id)
+	return injector.DeactivateScript(shell, a.Keys)
+}
+
+// activationsPath returns the file holding the activations of shells
+func activationsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "activations.json")
+}
+
+// loadActivations returns the recorded activations, by id
+func loadActivations(deps *Dependencies) map[string]activation {
+	activations := make(map[string]activation)
+	path := activationsPath()
+	if path == "" {
+		return activations
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &activations)
+	}
+	return activations
+}
+
+// writeActivations writes the activations, without those older than activationMaxAge
+func writeActivations(activations map[string]activation, deps *Dependencies) error {
+	path := activationsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	for id, a := range activations {
+		if t, err := time.Parse(time.RFC3339, a.ActivatedAt); err != nil || time.Since(t) > activationMaxAge {
+			delete(activations, id)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(activations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/injector"
+)
+
+// captureActivateOutput redirects the code printed by keyway activate and deactivate
+func captureActivateOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := activateOutput
+	activateOutput = &buf
+	t.Cleanup(func() { activateOutput = previous })
+	return &buf
+}
+
+// onlyActivation returns the id and the activation recorded by a test
+func onlyActivation(t *testing.T, deps *Dependencies) (string, activation) {
+	t.Helper()
+	fsMock := deps.FS.(*MockFileSystem)
+	fsMock.Files[activationsPath()] = fsMock.Written[activationsPath()]
+	activations := loadActivations(deps)
+	if len(activations) != 1 {
+		t.Fatalf("expected one activation, got %v", activations)
+	}
+	for id, a := range activations {
+		return id, a
+	}
+	return "", activation{}
+}
+
+func TestRunActivateWithDeps_ExportsAndRecords(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	t.Setenv(injector.ShellEnvVar, "")
+	t.Setenv(injector.ActivationEnvVar, "")
+	out := captureActivateOutput(t)
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	file, _ := env.EncodeFile("sa.json", []byte("{}"))
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_123\nDB_URL=postgres://db\nGCP_SA=" + file + "\n"}
+
+	err := runActivateWithDeps(ActivateOptions{EnvName: "staging", Shell: "/bin/bash"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	script := out.String()
+	for _, want := range []string{"export API_KEY='sk_123'", "export DB_URL='postgres://db'", "export KEYWAY_ACTIVE='staging'", "PS1='(keyway:staging) '"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %q in:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "GCP_SA") {
+		t.Errorf("expected the file secret to be skipped, got:\n%s", script)
+	}
+	if len(uiMock.WarnCalls) != 1 {
+		t.Errorf("expected a warning for the file secret, got %v", uiMock.WarnCalls)
+	}
+
+	id, a := onlyActivation(t, deps)
+	if !strings.Contains(script, "export KEYWAY_ACTIVATION='"+id+"'") {
+		t.Errorf("expected the activation id in:\n%s", script)
+	}
+	if strings.Join(a.Keys, ",") != "API_KEY,DB_URL" || a.Environment != "staging" {
+		t.Errorf("unexpected activation: %+v", a)
+	}
+}
+
+func TestRunActivateWithDeps_ReplacesPreviousActivation(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	t.Setenv(injector.ShellEnvVar, "")
+	out := captureActivateOutput(t)
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	if err := writeActivations(map[string]activation{"previous": {Environment: "development", Keys: []string{"OLD_KEY"}, ActivatedAt: time.Now().UTC().Format(time.RFC3339)}}, deps); err != nil {
+		t.Fatal(err)
+	}
+	fsMock.Files[activationsPath()] = fsMock.Written[activationsPath()]
+	t.Setenv(injector.ActivationEnvVar, "previous")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_123\n"}
+
+	if err := runActivateWithDeps(ActivateOptions{EnvName: "staging", Shell: "zsh"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	script := out.String()
+	unset := strings.Index(script, "unset KEYWAY_ACTIVATION KEYWAY_ACTIVE OLD_KEY")
+	if unset == -1 || unset > strings.Index(script, "export API_KEY") {
+		t.Errorf("expected the previous activation to be undone first, got:\n%s", script)
+	}
+	if id, _ := onlyActivation(t, deps); id == "previous" {
+		t.Error("expected the previous activation to be forgotten")
+	}
+}
+
+func TestRunDeactivateWithDeps(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	out := captureActivateOutput(t)
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	if err := writeActivations(map[string]activation{"act_1": {Environment: "staging", Keys: []string{"API_KEY", "DB_URL"}, ActivatedAt: time.Now().UTC().Format(time.RFC3339)}}, deps); err != nil {
+		t.Fatal(err)
+	}
+	fsMock.Files[activationsPath()] = fsMock.Written[activationsPath()]
+	t.Setenv(injector.ActivationEnvVar, "act_1")
+
+	if err := runDeactivateWithDeps(DeactivateOptions{Shell: "fish"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"set -e API_KEY", "set -e DB_URL", "set -e KEYWAY_ACTIVE"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	fsMock.Files[activationsPath()] = fsMock.Written[activationsPath()]
+	if len(loadActivations(deps)) != 0 {
+		t.Error("expected the activation to be forgotten")
+	}
+}
+
+func TestRunDeactivateWithDeps_NotActivated(t *testing.T) {
+	t.Setenv(injector.ActivationEnvVar, "")
+	out := captureActivateOutput(t)
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runDeactivateWithDeps(DeactivateOptions{Shell: "bash"}, deps); err == nil {
+		t.Fatal("expected an error outside an activated shell")
+	}
+	if out.Len() != 0 || len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected only an error, printed %q", out.String())
+	}
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var auditStrengthCmd = &cobra.Command{
+	Use:   "audit-strength",
+	Short: "Find weak passwords and keys in the vault",
+	Long: `Check the values of passwords, tokens and keys (keys named like SECRET,
+TOKEN, PASSWORD, KEY...) for weak patterns, and print what to fix first:
+
+  critical  common passwords and placeholders (e.g. "changeme", "Password1!"),
+            values under 8 characters, values shared with production
+  high      values under 16 characters, values shared across environments
+  medium    repetitive values, with little randomness per character
+
+Config keys of .keyway.json and files are not checked. Values are never
+printed.
+
+--fail-on makes the command fail when a finding is at least that severe, e.g.
+in CI.
+
+--template formats the report with a Go template, which gets the fields of
+--json: .environments, .keysChecked and .findings.`,
+	Args: cobra.NoArgs,
+	RunE: runAuditStrength,
+}
+
+func init() {
+	auditStrengthCmd.Flags().StringP("env", "e", "", "Only check this environment (default: all)")
+	auditStrengthCmd.Flags().String("fail-on", "", "Fail when a finding is at least this severe: critical, high or medium")
+	addTemplateFlag(auditStrengthCmd)
+}
+
+// AuditStrengthOptions contains the parsed flags for the audit-strength command
+type AuditStrengthOptions struct {
+	EnvName    string
+	FailOn     string
+	JSONOutput bool
+	Template   string
+}
+
+// AuditStrengthReport is the output of keyway audit-strength
+type AuditStrengthReport struct {
+	Environments []string              `json:"environments"`
+	KeysChecked  int                   `json:"keysChecked"`
+	Findings     []env.StrengthFinding `json:"findings"`
+}
+
+// runAuditStrength is the entry point for the audit-strength command (uses default dependencies)
+func runAuditStrength(cmd *cobra.Command, args []string) error {
+	opts := AuditStrengthOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.FailOn, _ = cmd.Flags().GetString("fail-on")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runAuditStrengthWithDeps(opts, deps)
+}
+
+// runAuditStrengthWithDeps is the testable version of runAuditStrength
+func runAuditStrengthWithDeps(opts AuditStrengthOptions, deps *Dependencies) error {
+	if opts.FailOn != "" && !env.IsStrengthSeverity(opts.FailOn) {
+		deps.UI.Error(fmt.Sprintf("Invalid --fail-on %q: use critical, high or medium", opts.FailOn))
+		return fmt.Errorf("invalid --fail-on %q", opts.FailOn)
+	}
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return auditStrength(s, opts, tmpl)
+	}, withIntro("audit-strength"), withRepo, withLogin)
+}
+
+// auditStrength checks the secret values of the vault's environments
+func auditStrength(s *Session, opts AuditStrengthOptions, tmpl *template.Template) error {
+	deps := s.Deps
+
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	envs := make(map[string]map[string]string)
+	err = s.Spin("Fetching secrets...", func() error {
+		names := []string{normalizeEnvName(opts.EnvName)}
+		if opts.EnvName == "" {
+			var err error
+			if names, err = s.Client.GetVaultEnvironments(s.Ctx, s.Repo); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			envs[name] = env.ParseVault(resp.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("audit-strength", err, deps)
+	}
+
+	isSecret := func(key string) bool {
+		return sensitiveKeyRegex.MatchString(key) && project.KeyClass(key) == config.ClassSecret
+	}
+	report := AuditStrengthReport{Environments: []string{}, Findings: env.AuditStrength(envs, isSecret)}
+	for name, secrets := range envs {
+		report.Environments = append(report.Environments, name)
+		for key := range secrets {
+			if isSecret(key) {
+				report.KeysChecked++
+			}
+		}
+	}
+	sort.Strings(report.Environments)
+	if report.Findings == nil {
+		report.Findings = []env.StrengthFinding{}
+	}
+
+	failing := 0
+	if opts.FailOn != "" {
+		for _, f := range report.Findings {
+			if env.SeverityAtLeast(f.Severity, opts.FailOn) {
+				failing++
+			}
+		}
+	}
+
+	if tmpl != nil {
+		if err := printTemplate(tmpl, report, deps); err != nil {
+			return err
+		}
+	} else if opts.JSONOutput {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+	} else {
+		printStrengthReport(report, deps)
+	}
+
+	if failing > 0 {
+		return fmt.Errorf("%d finding(s) at least %s", failing, opts.FailOn)
+	}
+	return nil
+}
+
+// printStrengthReport prints the findings, the most urgent first
+func printStrengthReport(report AuditStrengthReport, deps *Dependencies) {
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d secret values checked in %s", report.KeysChecked, strings.Join(report.Environments, ", "))))
+
+	if len(report.Findings) == 0 {
+		deps.UI.Success("No weak secrets found")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, f := range report.Findings {
+		counts[f.Severity]++
+	}
+	deps.UI.Warn(fmt.Sprintf("%d weak secret(s): %d critical, %d high, %d medium",
+		len(report.Findings), counts[env.StrengthCritical], counts[env.StrengthHigh], counts[env.StrengthMedium]))
+	for _, f := range report.Findings {
+		deps.UI.Message(fmt.Sprintf("  %-8s %s %s: %s", strings.ToUpper(f.Severity), f.Key,
+			deps.UI.Dim("("+strings.Join(f.Environments, ", ")+")"), f.Reason))
+		deps.UI.Message(deps.UI.Dim("           → " + f.Remediation))
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunAuditStrengthWithDeps_ReportsWeakSecrets(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development", "production"}
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "DB_PASSWORD=changeme\nLOG_LEVEL=debug\n"},
+		{Content: "DB_PASSWORD=hV3q9ZkR2xW7mP4tYb8LcN6s\nAPI_TOKEN=hV3q9ZkR2xW7mP4tYb8LcN6s\n"},
+	}
+
+	if err := runAuditStrengthWithDeps(AuditStrengthOptions{}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.Contains(uiMock.WarnCalls[0], "1 weak secret(s): 1 critical") {
+		t.Errorf("unexpected summary: %v", uiMock.WarnCalls)
+	}
+	for _, m := range uiMock.MessageCalls {
+		if strings.Contains(m, "changeme") || strings.Contains(m, "hV3q9") {
+			t.Errorf("values should not be printed: %q", m)
+		}
+		if strings.Contains(m, "LOG_LEVEL") {
+			t.Errorf("non-secret keys should not be checked: %q", m)
+		}
+	}
+}
+
+func TestRunAuditStrengthWithDeps_FailOn(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=abc12345678\n"}
+
+	// 11 characters is high, not critical
+	if err := runAuditStrengthWithDeps(AuditStrengthOptions{EnvName: "production", FailOn: "critical"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := runAuditStrengthWithDeps(AuditStrengthOptions{EnvName: "production", FailOn: "high"}, deps); err == nil {
+		t.Fatal("expected an error for a high finding with --fail-on high")
+	}
+}
+
+func TestRunAuditStrengthWithDeps_InvalidFailOn(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runAuditStrengthWithDeps(AuditStrengthOptions{FailOn: "urgent"}, deps); err == nil {
+		t.Fatal("expected an error for an unknown severity")
+	}
+	if len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected one error, got %v", uiMock.ErrorCalls)
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/auth"
+	"github.com/keywaysh/cli/internal/config"
 )
 
 // handleAuthError checks if the error is a 401 and handles it appropriately.
@@ -21,7 +24,7 @@ func handleAuthError(err error, deps *Dependencies) (string, error) {
 
 	if deps.UI.IsInteractive() {
 		deps.UI.Warn("Session expired or invalid")
-		relogin, _ := deps.UI.Confirm("Open browser to sign in again?", true)
+		relogin, _ := deps.UI.Confirm("Sign in again?", true)
 		if relogin {
 			token, loginErr := RunDeviceLogin()
 			if loginErr != nil {
@@ -35,6 +38,10 @@ func handleAuthError(err error, deps *Dependencies) (string, error) {
 
 	// Non-interactive mode
 	deps.UI.Error("Session expired or invalid")
+	if config.IsCI() && os.Getenv("KEYWAY_TOKEN") != "" {
+		deps.UI.Message(deps.UI.Dim("KEYWAY_TOKEN was rejected, the API key may be expired or revoked (Dashboard > API Keys)"))
+		return "", err
+	}
 	deps.UI.Message(deps.UI.Dim("Run: keyway logout && keyway login"))
 	return "", err
 }
@@ -0,0 +1,589 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var bisectCmd = &cobra.Command{
+	Use:   "bisect",
+	Short: "Find the vault change that broke the app",
+	Long: `Find which change of an environment broke the app, like git bisect: give a
+version that works and one that doesn't, and keyway picks the versions in
+between for you to test, halving the range each time.
+
+Each version to test is written to --file (.env by default); keyway bisect
+reset puts the file back as it was. keyway bisect run tests each version by
+running a command with its secrets instead: exit code 0 means good, 125 skip,
+anything else up to 127 bad.
+
+Versions are given as version:N, or as a date for the version at that time.`,
+}
+
+var bisectStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start bisecting the history of an environment",
+	Args:  cobra.NoArgs,
+	RunE:  runBisectStart,
+}
+
+var bisectGoodCmd = &cobra.Command{
+	Use:   "good [VERSION]",
+	Short: "Mark the version being tested, or VERSION, as good",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBisectMark(bisectGood),
+}
+
+var bisectBadCmd = &cobra.Command{
+	Use:   "bad [VERSION]",
+	Short: "Mark the version being tested, or VERSION, as bad",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBisectMark(bisectBad),
+}
+
+var bisectSkipCmd = &cobra.Command{
+	Use:   "skip [VERSION]",
+	Short: "Skip the version being tested, or VERSION, when it cannot be tested",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBisectMark(bisectSkip),
+}
+
+var bisectRunCmd = &cobra.Command{
+	Use:   "run -- COMMAND [ARGS...]",
+	Short: "Test the versions by running a command with their secrets",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runBisectRun,
+}
+
+var bisectResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Stop bisecting and restore the env file",
+	Args:  cobra.NoArgs,
+	RunE:  runBisectReset,
+}
+
+func init() {
+	bisectStartCmd.Flags().StringP("env", "e", "development", "Environment name")
+	bisectStartCmd.Flags().String("good", "", "A version that works (version:N or YYYY-MM-DD)")
+	bisectStartCmd.Flags().String("bad", "", "A version that doesn't (default: the current one)")
+	bisectStartCmd.Flags().StringP("file", "f", ".env", "Env file the versions to test are written to")
+
+	bisectCmd.AddCommand(bisectStartCmd)
+	bisectCmd.AddCommand(bisectGoodCmd)
+	bisectCmd.AddCommand(bisectBadCmd)
+	bisectCmd.AddCommand(bisectSkipCmd)
+	bisectCmd.AddCommand(bisectRunCmd)
+	bisectCmd.AddCommand(bisectResetCmd)
+}
+
+// Marks of keyway bisect good, bad and skip
+const (
+	bisectGood = "good"
+	bisectBad  = "bad"
+	bisectSkip = "skip"
+)
+
+// bisectSkipExitCode is the exit code of a command run by keyway bisect run
+// for a version it cannot test
+const bisectSkipExitCode = 125
+
+// bisectState is a bisect in progress in a repository
+type bisectState struct {
+	Repo        string `json:"repo"`
+	Environment string `json:"environment"`
+	File        string `json:"file,omitempty"`
+	// Original is the content of File before the bisect, nil if it didn't exist
+	Original *string `json:"original,omitempty"`
+	Good     int     `json:"good,omitempty"`
+	Bad      int     `json:"bad,omitempty"`
+	// Current is the version being tested
+	Current   int       `json:"current,omitempty"`
+	Skipped   []int     `json:"skipped,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// candidates returns the versions that may still be the first bad one,
+// besides Bad itself
+func (b *bisectState) candidates() []int {
+	skipped := make(map[int]bool, len(b.Skipped))
+	for _, v := range b.Skipped {
+		skipped[v] = true
+	}
+	var versions []int
+	for v := b.Good + 1; v < b.Bad; v++ {
+		if !skipped[v] {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// next returns the version to test next, 0 once the first bad version is found
+func (b *bisectState) next() int {
+	versions := b.candidates()
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)/2]
+}
+
+// mark records the result of testing a version
+func (b *bisectState) mark(version int, result string) error {
+	switch result {
+	case bisectGood:
+		if b.Bad > 0 && version >= b.Bad {
+			return fmt.Errorf("version %d cannot be good, version %d is bad", version, b.Bad)
+		}
+		b.Good = version
+	case bisectBad:
+		if b.Good > 0 && version <= b.Good {
+			return fmt.Errorf("version %d cannot be bad, version %d is good", version, b.Good)
+		}
+		b.Bad = version
+	case bisectSkip:
+		b.Skipped = append(b.Skipped, version)
+	}
+	return nil
+}
+
+// BisectStartOptions contains the parsed flags for the bisect start command
+type BisectStartOptions struct {
+	EnvName string
+	Good    string
+	Bad     string
+	File    string
+}
+
+// runBisectStart is the entry point for the bisect start command (uses default dependencies)
+func runBisectStart(cmd *cobra.Command, args []string) error {
+	opts := BisectStartOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Good, _ = cmd.Flags().GetString("good")
+	opts.Bad, _ = cmd.Flags().GetString("bad")
+	opts.File, _ = cmd.Flags().GetString("file")
+
+	return runBisectStartWithDeps(opts, defaultDeps)
+}
+
+// runBisectMark returns the entry point of the bisect good, bad or skip command (uses default dependencies)
+func runBisectMark(result string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		version := ""
+		if len(args) == 1 {
+			version = args[0]
+		}
+		return runBisectMarkWithDeps(result, version, defaultDeps)
+	}
+}
+
+// runBisectRun is the entry point for the bisect run command (uses default dependencies)
+func runBisectRun(cmd *cobra.Command, args []string) error {
+	return runBisectRunWithDeps(args[0], args[1:], defaultDeps)
+}
+
+// runBisectReset is the entry point for the bisect reset command (uses default dependencies)
+func runBisectReset(cmd *cobra.Command, args []string) error {
+	return runBisectResetWithDeps(defaultDeps)
+}
+
+// runBisectStartWithDeps is the testable version of runBisectStart
+func runBisectStartWithDeps(opts BisectStartOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return bisectStart(s, opts)
+	}, withIntro("bisect start"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// bisectStart records a new bisect and checks out the first version to test
+func bisectStart(s *Session, opts BisectStartOptions) error {
+	deps := s.Deps
+	bisects := loadBisects(deps)
+	if b, ok := bisects[s.Repo]; ok {
+		deps.UI.Error(fmt.Sprintf("Already bisecting %s since %s", b.Environment, formatEventTime(b.StartedAt.Format(time.RFC3339))))
+		deps.UI.Message(deps.UI.Dim("Stop it first with: keyway bisect reset"))
+		return fmt.Errorf("bisect already in progress")
+	}
+
+	state := &bisectState{Repo: s.Repo, Environment: s.EnvName, File: opts.File, StartedAt: time.Now().UTC()}
+	if opts.File != "" {
+		if content, err := deps.FS.ReadFile(opts.File); err == nil {
+			original := string(content)
+			state.Original = &original
+		}
+	}
+
+	var err error
+	if opts.Bad != "" {
+		state.Bad, err = resolveVersion(s, opts.Bad)
+	} else {
+		state.Bad, err = currentVersion(s)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.Good != "" {
+		good, err := resolveVersion(s, opts.Good)
+		if err != nil {
+			return err
+		}
+		if err := state.mark(good, bisectGood); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+	}
+
+	return bisectAdvance(s, state)
+}
+
+// runBisectMarkWithDeps is the testable version of the bisect good, bad and skip commands
+func runBisectMarkWithDeps(result, version string, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		state, err := loadBisect(s)
+		if err != nil {
+			return err
+		}
+		v := state.Current
+		if version != "" {
+			if v, err = resolveVersion(s, version); err != nil {
+				return err
+			}
+		}
+		if v == 0 {
+			err := fmt.Errorf("no version is being tested, give one: keyway bisect %s version:N", result)
+			deps.UI.Error(err.Error())
+			return err
+		}
+		if err := state.mark(v, result); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		return bisectAdvance(s, state)
+	}, withIntro("bisect "+result), withRepo, withLogin)
+}
+
+// runBisectRunWithDeps is the testable version of runBisectRun
+func runBisectRunWithDeps(command string, args []string, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		state, err := loadBisect(s)
+		if err != nil {
+			return err
+		}
+		if state.Good == 0 || state.Bad == 0 {
+			err := fmt.Errorf("keyway bisect run needs a good and a bad version")
+			deps.UI.Error(err.Error())
+			return err
+		}
+
+		for v := state.next(); v != 0; v = state.next() {
+			secrets, err := pullVersion(s, state.Environment, v)
+			if err != nil {
+				return err
+			}
+			deps.UI.Step(fmt.Sprintf("Running %s with version %d", command, v))
+			result, err := bisectRunResult(deps.CmdRunner.RunCommand(command, args, secrets))
+			if err != nil {
+				deps.UI.Error(fmt.Sprintf("Stopped at version %d: %s", v, err.Error()))
+				state.Current = v
+				_ = saveBisect(state, deps)
+				return err
+			}
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Version %d is %s", v, result)))
+			if err := state.mark(v, result); err != nil {
+				return err
+			}
+		}
+		return bisectAdvance(s, state)
+	}, withIntro("bisect run"), withRepo, withLogin)
+}
+
+// bisectRunResult tells from the error of a command run by keyway bisect run
+// whether the version is good, bad or to skip. Commands that cannot run, or
+// exit with 128 and above (killed), stop the bisect.
+func bisectRunResult(err error) (string, error) {
+	if err == nil {
+		return bisectGood, nil
+	}
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(err, &exitErr) {
+		return "", err
+	}
+	switch code := exitErr.ExitCode(); {
+	case code == bisectSkipExitCode:
+		return bisectSkip, nil
+	case code < 0 || code >= 128:
+		return "", fmt.Errorf("the command exited with %d", code)
+	default:
+		return bisectBad, nil
+	}
+}
+
+// runBisectResetWithDeps is the testable version of runBisectReset
+func runBisectResetWithDeps(deps *Dependencies) error {
+	deps.UI.Intro("bisect reset")
+	repo, err := deps.Git.DetectRepo()
+	if err != nil {
+		deps.UI.Error("Not in a git repository with GitHub remote")
+		return err
+	}
+
+	bisects := loadBisects(deps)
+	state, ok := bisects[repo]
+	if !ok {
+		deps.UI.Info("No bisect in progress")
+		return nil
+	}
+
+	if state.File != "" {
+		if state.Original != nil {
+			err = deps.FS.WriteFile(state.File, []byte(*state.Original), 0600)
+		} else if removeErr := os.Remove(state.File); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = removeErr
+		}
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to restore %s: %s", state.File, err.Error()))
+			return err
+		}
+	}
+
+	delete(bisects, repo)
+	if err := writeBisects(bisects, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the bisect state: %s", err.Error()))
+		return err
+	}
+	if state.File != "" {
+		deps.UI.Success(fmt.Sprintf("Bisect stopped, %s restored", state.File))
+	} else {
+		deps.UI.Success("Bisect stopped")
+	}
+	return nil
+}
+
+// bisectAdvance checks out the next version to test, or reports the first bad
+// one once found, and saves the bisect
+func bisectAdvance(s *Session, state *bisectState) error {
+	deps := s.Deps
+	if state.Good == 0 || state.Bad == 0 {
+		if err := saveBisect(state, deps); err != nil {
+			return err
+		}
+		missing := bisectGood
+		if state.Bad == 0 {
+			missing = bisectBad
+		}
+		deps.UI.Info(fmt.Sprintf("Mark a %s version of %s to start: keyway bisect %s version:N (or a date)", missing, state.Environment, missing))
+		return nil
+	}
+
+	v := state.next()
+	if v == 0 {
+		state.Current = 0
+		if err := saveBisect(state, deps); err != nil {
+			return err
+		}
+		return reportFirstBad(s, state)
+	}
+
+	secrets, err := pullVersion(s, state.Environment, v)
+	if err != nil {
+		return err
+	}
+	if state.File != "" {
+		if err := deps.FS.WriteFile(state.File, []byte(env.Apply("", secrets)), 0600); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", state.File, err.Error()))
+			return err
+		}
+	}
+	state.Current = v
+	if err := saveBisect(state, deps); err != nil {
+		return err
+	}
+
+	left := len(state.candidates())
+	deps.UI.Success(fmt.Sprintf("Testing version %d of %s", v, state.Environment))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d version(s) left to test, about %d step(s)", left, bits.Len(uint(left)))))
+	if state.File != "" {
+		deps.UI.Outro(fmt.Sprintf("Written to %s, test it then run keyway bisect good or keyway bisect bad", state.File))
+	} else {
+		deps.UI.Outro("Test it, then run keyway bisect good or keyway bisect bad")
+	}
+	return nil
+}
+
+// reportFirstBad shows the first bad version and the keys it changed
+func reportFirstBad(s *Session, state *bisectState) error {
+	deps := s.Deps
+	var untested []int
+	for _, v := range state.Skipped {
+		if v > state.Good && v < state.Bad {
+			untested = append(untested, v)
+		}
+	}
+	sort.Ints(untested)
+	if len(untested) > 0 {
+		deps.UI.Warn(fmt.Sprintf("The first bad version is one of %d to %d, versions skipped could not be told apart", untested[0], state.Bad))
+	} else {
+		deps.UI.Success(fmt.Sprintf("Version %d is the first bad version of %s", state.Bad, state.Environment))
+	}
+
+	before, err := pullVersion(s, state.Environment, state.Bad-1)
+	if err == nil {
+		var after map[string]string
+		if after, err = pullVersion(s, state.Environment, state.Bad); err == nil {
+			diff := compareSecrets(fmt.Sprintf("version %d", state.Bad-1), fmt.Sprintf("version %d", state.Bad), before, after, false)
+			deps.UI.Message("")
+			deps.UI.Message(fmt.Sprintf("Changes in version %d:", state.Bad))
+			for _, key := range diff.OnlyInEnv2 {
+				deps.UI.DiffAdded(key)
+			}
+			for _, entry := range diff.Different {
+				deps.UI.DiffChanged(entry.Key)
+			}
+			for _, key := range diff.OnlyInEnv1 {
+				deps.UI.DiffRemoved(key)
+			}
+			deps.UI.Message("")
+		}
+	}
+
+	deps.UI.Outro(fmt.Sprintf("Compare with: keyway diff %s --against version:%d, then keyway bisect reset", state.Environment, state.Bad-1))
+	return nil
+}
+
+// resolveVersion returns the version a revision (version:N or a date) points to
+func resolveVersion(s *Session, revision string) (int, error) {
+	rev, err := api.ParseRevision(revision)
+	if err != nil {
+		s.Deps.UI.Error(err.Error())
+		return 0, err
+	}
+	if rev.Version > 0 {
+		return rev.Version, nil
+	}
+
+	envName := s.EnvName
+	if state, ok := loadBisects(s.Deps)[s.Repo]; ok {
+		envName = state.Environment
+	}
+	var resp *api.PullSecretsResponse
+	err = s.Spin(fmt.Sprintf("Looking up %s...", rev.String()), func() error {
+		var err error
+		resp, err = s.Client.PullSecretsAt(s.Ctx, s.Repo, envName, rev)
+		return err
+	})
+	if err != nil {
+		return 0, reportEnvError("bisect", err, s.Deps)
+	}
+	if resp.Version == 0 {
+		err := fmt.Errorf("no version of %s found at %s", envName, rev.String())
+		s.Deps.UI.Error(err.Error())
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// currentVersion returns the current version of the session's environment
+func currentVersion(s *Session) (int, error) {
+	var resp *api.PullSecretsResponse
+	err := s.Spin(fmt.Sprintf("Fetching %s...", s.EnvName), func() error {
+		var err error
+		resp, err = s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		return err
+	})
+	if err != nil {
+		return 0, reportEnvError("bisect", err, s.Deps)
+	}
+	if resp.Version == 0 {
+		err := fmt.Errorf("the history of %s is not available", s.EnvName)
+		s.Deps.UI.Error(err.Error())
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// pullVersion returns the secrets of an environment at a version
+func pullVersion(s *Session, envName string, version int) (map[string]string, error) {
+	var secrets map[string]string
+	err := s.Spin(fmt.Sprintf("Fetching version %d...", version), func() error {
+		resp, err := s.Client.PullSecretsAt(s.Ctx, s.Repo, envName, api.Revision{Version: version})
+		if err != nil {
+			return err
+		}
+		secrets = env.ParseVault(resp.Content)
+		return nil
+	})
+	if err != nil {
+		return nil, reportEnvError("bisect", err, s.Deps)
+	}
+	return secrets, nil
+}
+
+// loadBisect returns the bisect in progress in the session's repository
+func loadBisect(s *Session) (*bisectState, error) {
+	state, ok := loadBisects(s.Deps)[s.Repo]
+	if !ok {
+		s.Deps.UI.Error("No bisect in progress")
+		s.Deps.UI.Message(s.Deps.UI.Dim("Start one with: keyway bisect start -e <env> --good <version>"))
+		return nil, fmt.Errorf("no bisect in progress")
+	}
+	return &state, nil
+}
+
+// saveBisect stores a bisect in progress
+func saveBisect(state *bisectState, deps *Dependencies) error {
+	bisects := loadBisects(deps)
+	bisects[state.Repo] = *state
+	if err := writeBisects(bisects, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the bisect state: %s", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// bisectsPath returns the file holding the bisects in progress
+func bisectsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "bisects.json")
+}
+
+// loadBisects returns the bisects in progress, by repository
+func loadBisects(deps *Dependencies) map[string]bisectState {
+	bisects := make(map[string]bisectState)
+	path := bisectsPath()
+	if path == "" {
+		return bisects
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &bisects)
+	}
+	return bisects
+}
+
+// writeBisects writes the bisects in progress, readable by the user only since
+// they hold the original content of env files
+func writeBisects(bisects map[string]bisectState, deps *Dependencies) error {
+	path := bisectsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bisects, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// exitCodeError is a command exiting with a code
+type exitCodeError int
+
+func (e exitCodeError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitCodeError) ExitCode() int { return int(e) }
+
+// newBisectDeps returns test dependencies with versions 10 to 20 of an
+// environment in the vault, V holding the version number
+func newBisectDeps(t *testing.T) (*Dependencies, *MockUIProvider, *MockFileSystem, *MockAPIClient) {
+	t.Helper()
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "V=20\n", Version: 20}
+	apiMock.PullAtVersions = make(map[int]*api.PullSecretsResponse)
+	for v := 10; v <= 20; v++ {
+		content := fmt.Sprintf("V=%d\n", v)
+		if v >= 17 {
+			content += "BROKEN=yes\n"
+		}
+		apiMock.PullAtVersions[v] = &api.PullSecretsResponse{Content: content, Version: v}
+	}
+	return deps, uiMock, fsMock, apiMock
+}
+
+// syncWrites makes the files written by the test visible to the next reads
+func syncWrites(fsMock *MockFileSystem) {
+	for name, data := range fsMock.Written {
+		fsMock.Files[name] = data
+	}
+}
+
+func TestBisectState_Next(t *testing.T) {
+	state := &bisectState{Good: 10, Bad: 20}
+	if v := state.next(); v != 15 {
+		t.Errorf("expected 15, got %d", v)
+	}
+	_ = state.mark(15, bisectSkip)
+	if v := state.next(); v != 16 {
+		t.Errorf("expected 16 after skipping 15, got %d", v)
+	}
+	_ = state.mark(16, bisectGood)
+	_ = state.mark(18, bisectBad)
+	if v := state.next(); v != 17 {
+		t.Errorf("expected 17, got %d", v)
+	}
+	_ = state.mark(17, bisectBad)
+	if v := state.next(); v != 0 {
+		t.Errorf("expected the first bad version to be found, got %d", v)
+	}
+	if err := state.mark(12, bisectBad); err == nil {
+		t.Error("expected a bad version older than a good one to be rejected")
+	}
+}
+
+func TestRunBisectWithDeps_ManualSteps(t *testing.T) {
+	deps, uiMock, fsMock, _ := newBisectDeps(t)
+	fsMock.Files[".env"] = []byte("LOCAL=1\n")
+
+	if err := runBisectStartWithDeps(BisectStartOptions{EnvName: "production", Good: "version:10", File: ".env"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(fsMock.Written[".env"]), "V=15") {
+		t.Fatalf("expected version 15 to be written, got %q", fsMock.Written[".env"])
+	}
+	syncWrites(fsMock)
+
+	if err := runBisectMarkWithDeps(bisectBad, "", deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(fsMock.Written[".env"]), "V=13") {
+		t.Fatalf("expected version 13 to be written, got %q", fsMock.Written[".env"])
+	}
+	syncWrites(fsMock)
+
+	if err := runBisectStartWithDeps(BisectStartOptions{EnvName: "production", Good: "version:10"}, deps); err == nil {
+		t.Error("expected a second bisect to be refused")
+	}
+
+	if err := runBisectResetWithDeps(deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(fsMock.Written[".env"]) != "LOCAL=1\n" {
+		t.Errorf("expected .env to be restored, got %q", fsMock.Written[".env"])
+	}
+	syncWrites(fsMock)
+	if len(loadBisects(deps)) != 0 {
+		t.Error("expected the bisect to be dropped")
+	}
+	if len(uiMock.SuccessCalls) == 0 || !strings.Contains(uiMock.SuccessCalls[len(uiMock.SuccessCalls)-1], ".env restored") {
+		t.Errorf("unexpected messages: %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunBisectRunWithDeps_FindsFirstBad(t *testing.T) {
+	deps, uiMock, fsMock, _ := newBisectDeps(t)
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	var tested []int
+	runner.OnRun = func(secrets map[string]string) {
+		v, _ := strconv.Atoi(secrets["V"])
+		tested = append(tested, v)
+		runner.RunError = nil
+		if secrets["BROKEN"] == "yes" {
+			runner.RunError = exitCodeError(1)
+		}
+	}
+
+	if err := runBisectStartWithDeps(BisectStartOptions{EnvName: "production", Good: "version:10"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	syncWrites(fsMock)
+	if err := runBisectRunWithDeps("npm", []string{"test"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tested) > 4 {
+		t.Errorf("expected at most 4 runs, got %v", tested)
+	}
+	found := false
+	for _, msg := range uiMock.SuccessCalls {
+		found = found || strings.Contains(msg, "Version 17 is the first bad version")
+	}
+	if !found {
+		t.Errorf("expected version 17 to be found, got %v", uiMock.SuccessCalls)
+	}
+	if len(uiMock.DiffAddedCalls) != 1 || uiMock.DiffAddedCalls[0] != "BROKEN" {
+		t.Errorf("expected BROKEN to be shown as added in version 17, got %v", uiMock.DiffAddedCalls)
+	}
+}
+
+func TestRunBisectMarkWithDeps_NoBisect(t *testing.T) {
+	deps, uiMock, _, _ := newBisectDeps(t)
+
+	if err := runBisectMarkWithDeps(bisectGood, "", deps); err == nil {
+		t.Fatal("expected an error without a bisect in progress")
+	}
+	if len(uiMock.ErrorCalls) == 0 || uiMock.ErrorCalls[0] != "No bisect in progress" {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestBisectRunResult(t *testing.T) {
+	tests := []struct {
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{nil, bisectGood, false},
+		{exitCodeError(1), bisectBad, false},
+		{exitCodeError(bisectSkipExitCode), bisectSkip, false},
+		{exitCodeError(130), "", true},
+		{errors.New("executable file not found"), "", true},
+	}
+	for _, tt := range tests {
+		got, err := bisectRunResult(tt.err)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("bisectRunResult(%v) = %q, %v", tt.err, got, err)
+		}
+	}
+}
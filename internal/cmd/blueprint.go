@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var envCreateCmd = &cobra.Command{
+	Use:   "create <environment>",
+	Short: "Create an environment, optionally from an organization blueprint",
+	Long: `Create a new environment in the vault.
+
+With --from-blueprint, the environment starts with the keys of a blueprint of
+the repository's organization: fixed values, values generated on your machine
+(random hex, base64, passwords or UUIDs), and placeholders to set afterwards.
+Blueprints are managed in the organization settings on keyway.sh.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvCreate,
+}
+
+var envBlueprintsCmd = &cobra.Command{
+	Use:   "blueprints",
+	Short: "List the environment blueprints of the organization",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvBlueprints,
+}
+
+func init() {
+	envCreateCmd.Flags().String("from-blueprint", "", "Seed the environment with the keys of an organization blueprint")
+	envCreateCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	envCmd.AddCommand(envCreateCmd)
+	envCmd.AddCommand(envBlueprintsCmd)
+}
+
+// blueprintPlaceholder is the value of blueprint keys without value nor
+// generator. keyway push recognizes it as a placeholder.
+const blueprintPlaceholder = "<set me>"
+
+// EnvCreateOptions contains the parsed flags for the env create command
+type EnvCreateOptions struct {
+	EnvName   string
+	Blueprint string
+	Yes       bool
+}
+
+// runEnvCreate is the entry point for the env create command (uses default dependencies)
+func runEnvCreate(cmd *cobra.Command, args []string) error {
+	opts := EnvCreateOptions{EnvName: args[0]}
+	opts.Blueprint, _ = cmd.Flags().GetString("from-blueprint")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvCreateWithDeps(opts, defaultDeps)
+}
+
+// runEnvCreateWithDeps is the testable version of runEnvCreate
+func runEnvCreateWithDeps(opts EnvCreateOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envCreate(s, opts)
+	}, withIntro("env create"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// envCreate creates an environment, seeded from a blueprint
+func envCreate(s *Session, opts EnvCreateOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "creating an environment", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, s.EnvName, deps); err != nil {
+		return err
+	}
+
+	if err := validateEnvName(s.EnvName); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if _, found, err := findEnvironment(s, s.EnvName, "env create"); err != nil {
+		return err
+	} else if found {
+		err := fmt.Errorf("%s already exists", s.EnvName)
+		deps.UI.Error(err.Error())
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Add keys to it with: keyway set KEY -e %s", s.EnvName)))
+		return err
+	}
+
+	secrets := map[string]string{}
+	var placeholders []string
+	if opts.Blueprint != "" {
+		var blueprint *api.Blueprint
+		err := s.Spin(fmt.Sprintf("Fetching blueprint %s...", opts.Blueprint), func() error {
+			var err error
+			blueprint, err = s.Client.GetBlueprint(s.Ctx, repoOwner(s.Repo), opts.Blueprint)
+			return err
+		})
+		if err != nil {
+			if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+				err = fmt.Errorf("no blueprint %q in %s", opts.Blueprint, repoOwner(s.Repo))
+				deps.UI.Error(err.Error())
+				deps.UI.Message(deps.UI.Dim("List them with: keyway env blueprints"))
+				return err
+			}
+			return reportEnvError("env create", err, deps)
+		}
+
+		if secrets, placeholders, err = seedFromBlueprint(blueprint); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		if err := checkKeyNamingPolicy(sortedKeys(secrets), deps); err != nil {
+			return err
+		}
+		printBlueprint(blueprint, deps)
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		question := fmt.Sprintf("Create %s?", s.EnvName)
+		if opts.Blueprint != "" {
+			question = fmt.Sprintf("Create %s with these %d keys?", s.EnvName, len(secrets))
+		}
+		confirm, _ := deps.UI.Confirm(question, true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err := s.Spin("Creating environment...", func() error {
+		if err := s.Client.CreateEnvironment(s.Ctx, s.Repo, s.EnvName); err != nil {
+			return err
+		}
+		if len(secrets) == 0 {
+			return nil
+		}
+		_, err := s.Client.PushSecrets(s.Ctx, s.Repo, s.EnvName, secrets, uuid.NewString())
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env create", err, deps)
+	}
+
+	if opts.Blueprint != "" {
+		deps.UI.Success(fmt.Sprintf("Created %s from %s with %d keys", s.EnvName, opts.Blueprint, len(secrets)))
+	} else {
+		deps.UI.Success(fmt.Sprintf("Created %s", s.EnvName))
+	}
+	if len(placeholders) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d key(s) still need a value: %s", len(placeholders), strings.Join(placeholders, ", ")))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Set them with: keyway set %s -e %s", placeholders[0], s.EnvName)))
+	}
+	return nil
+}
+
+// seedFromBlueprint returns the values of the keys of a blueprint, generating
+// the random ones, and the sorted keys left with a placeholder
+func seedFromBlueprint(blueprint *api.Blueprint) (map[string]string, []string, error) {
+	secrets := make(map[string]string, len(blueprint.Keys))
+	var placeholders []string
+	for _, k := range blueprint.Keys {
+		if k.Key == "" {
+			continue
+		}
+		switch {
+		case k.Generate != "":
+			value, err := env.Generate(k.Generate)
+			if err != nil {
+				return nil, nil, fmt.Errorf("blueprint %s, key %s: %w", blueprint.Name, k.Key, err)
+			}
+			secrets[k.Key] = value
+		case k.Value != "":
+			secrets[k.Key] = k.Value
+		default:
+			secrets[k.Key] = blueprintPlaceholder
+		}
+		if env.IsPlaceholder(secrets[k.Key]) {
+			placeholders = append(placeholders, k.Key)
+		}
+	}
+	sort.Strings(placeholders)
+	return secrets, placeholders, nil
+}
+
+// printBlueprint shows the keys of a blueprint and how each gets its value,
+// never the values themselves
+func printBlueprint(blueprint *api.Blueprint, deps *Dependencies) {
+	keys := append([]api.BlueprintKey(nil), blueprint.Keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+
+	deps.UI.Message("")
+	for _, k := range keys {
+		var source string
+		switch {
+		case k.Generate != "":
+			source = "generated (" + k.Generate + ")"
+		case k.Value != "" && !env.IsPlaceholder(k.Value):
+			source = "fixed value"
+		default:
+			source = "placeholder"
+		}
+		line := fmt.Sprintf("  %s  %s", k.Key, deps.UI.Dim(source))
+		if k.Description != "" {
+			line += deps.UI.Dim(" - " + k.Description)
+		}
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+}
+
+// runEnvBlueprints is the entry point for the env blueprints command (uses default dependencies)
+func runEnvBlueprints(cmd *cobra.Command, args []string) error {
+	return runEnvBlueprintsWithDeps(defaultDeps)
+}
+
+// runEnvBlueprintsWithDeps is the testable version of runEnvBlueprints
+func runEnvBlueprintsWithDeps(deps *Dependencies) error {
+	return runPipeline(deps, listBlueprints, withIntro("env blueprints"), withRepo, withLogin)
+}
+
+// listBlueprints lists the blueprints of the repository's organization
+func listBlueprints(s *Session) error {
+	deps := s.Deps
+	org := repoOwner(s.Repo)
+	var blueprints []api.Blueprint
+	err := s.Spin("Fetching blueprints...", func() error {
+		var err error
+		blueprints, err = s.Client.ListBlueprints(s.Ctx, org)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env blueprints", err, deps)
+	}
+	if len(blueprints) == 0 {
+		deps.UI.Info(fmt.Sprintf("%s has no environment blueprints", org))
+		return nil
+	}
+
+	sort.Slice(blueprints, func(i, j int) bool { return blueprints[i].Name < blueprints[j].Name })
+	deps.UI.Message("")
+	for _, b := range blueprints {
+		line := fmt.Sprintf("  %s  %s", b.Name, deps.UI.Dim(fmt.Sprintf("%d keys", len(b.Keys))))
+		if b.Description != "" {
+			line += deps.UI.Dim(" - " + b.Description)
+		}
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim("Create an environment from one with: keyway env create <environment> --from-blueprint <name>"))
+	return nil
+}
+
+// repoOwner returns the owner of a repository, user or organization
+func repoOwner(repo string) string {
+	owner, _, _ := strings.Cut(repo, "/")
+	return owner
+}
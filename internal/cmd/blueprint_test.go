@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+var webServiceBlueprint = api.Blueprint{
+	Name: "web-service",
+	Keys: []api.BlueprintKey{
+		{Key: "SESSION_SECRET", Generate: "hex:32"},
+		{Key: "LOG_LEVEL", Value: "info"},
+		{Key: "DATABASE_URL", Description: "Postgres connection string"},
+	},
+}
+
+func TestRunEnvCreateWithDeps_FromBlueprint(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development", "production"}
+	apiMock.Blueprints = []api.Blueprint{webServiceBlueprint}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "ok"}
+
+	err := runEnvCreateWithDeps(EnvCreateOptions{EnvName: "qa", Blueprint: "web-service", Yes: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pushed := apiMock.PushedSecrets
+	if len(pushed) != 3 || pushed["LOG_LEVEL"] != "info" || pushed["DATABASE_URL"] != blueprintPlaceholder {
+		t.Fatalf("unexpected seeded keys: %v", pushed)
+	}
+	if b, err := hex.DecodeString(pushed["SESSION_SECRET"]); err != nil || len(b) != 32 {
+		t.Errorf("expected a generated secret, got %q", pushed["SESSION_SECRET"])
+	}
+	if !strings.Contains(strings.Join(uiMock.WarnCalls, "\n"), "DATABASE_URL") {
+		t.Errorf("expected the placeholder keys to be listed, got %v", uiMock.WarnCalls)
+	}
+	for _, msg := range uiMock.MessageCalls {
+		if strings.Contains(msg, pushed["SESSION_SECRET"]) {
+			t.Errorf("expected generated values not to be printed, got %q", msg)
+		}
+	}
+}
+
+func TestRunEnvCreateWithDeps_ExistingEnvironment(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development", "qa"}
+
+	err := runEnvCreateWithDeps(EnvCreateOptions{EnvName: "qa", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an error for an existing environment, got %v", err)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing pushed, got %v", apiMock.PushedSecrets)
+	}
+}
+
+func TestRunEnvCreateWithDeps_UnknownBlueprint(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Blueprints = []api.Blueprint{webServiceBlueprint}
+
+	err := runEnvCreateWithDeps(EnvCreateOptions{EnvName: "qa", Blueprint: "worker", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), `no blueprint "worker" in owner`) {
+		t.Fatalf("expected an unknown blueprint error, got %v", err)
+	}
+}
+
+func TestRunEnvCreateWithDeps_InvalidGenerator(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Blueprints = []api.Blueprint{{Name: "broken", Keys: []api.BlueprintKey{{Key: "TOKEN", Generate: "random"}}}}
+
+	err := runEnvCreateWithDeps(EnvCreateOptions{EnvName: "qa", Blueprint: "broken", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "TOKEN") {
+		t.Fatalf("expected the invalid generator to be reported, got %v", err)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing pushed, got %v", apiMock.PushedSecrets)
+	}
+}
+
+func TestRunEnvBlueprintsWithDeps(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Blueprints = []api.Blueprint{webServiceBlueprint, {Name: "worker", Description: "Queue consumers"}}
+
+	if err := runEnvBlueprintsWithDeps(deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(out, "web-service") || !strings.Contains(out, "3 keys") || !strings.Contains(out, "Queue consumers") {
+		t.Errorf("unexpected listing:\n%s", out)
+	}
+}
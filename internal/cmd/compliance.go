@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var complianceCmd = &cobra.Command{
+	Use:   "compliance",
+	Short: "Produce evidence of the vault for audits",
+	Long:  `Produce and check evidence of the vault's content and history, for SOC 2 or ISO 27001 audits.`,
+}
+
+var complianceExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Dump every environment and the audit trail to a signed, encrypted archive",
+	Long: `Dump every environment of the vault and its audit trail into one archive,
+encrypted with a one-time key printed once and never stored, and signed with
+this machine's signing key.
+
+The archive follows the keyway.compliance.v1 schema: a manifest readable
+without the key (repository, environments, number of events, SHA-256 digest of
+the content), and the encrypted content with the secrets of each environment
+and the events of the vault. Auditors check and decrypt it with
+keyway compliance verify.
+
+The audit trail holds up to the latest 10000 events. A longer trail cannot be
+exported whole: the export stops unless --allow-truncated is given, and the
+manifest of a truncated archive says so.
+
+Exports require admin access to the repository, and are limited to one per
+repository per hour from this machine.`,
+	Args: cobra.NoArgs,
+	RunE: runComplianceExport,
+}
+
+var complianceVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check the signature and digest of a compliance archive",
+	Long: `Check that a compliance archive is intact and signed by the expected key,
+and optionally write its decrypted content. No Keyway account is needed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runComplianceVerify,
+}
+
+func init() {
+	complianceExportCmd.Flags().StringP("output", "o", "keyway-compliance.json", "Archive file to write")
+	complianceExportCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	complianceExportCmd.Flags().Bool("allow-truncated", false, "Export only the latest events of a longer audit trail")
+
+	complianceVerifyCmd.Flags().String("key", "", "One-time key printed by keyway compliance export")
+	complianceVerifyCmd.Flags().String("signer", "", "Expected signing key fingerprint")
+	complianceVerifyCmd.Flags().String("decrypt", "", "Write the decrypted content (JSON) to this file")
+
+	complianceCmd.AddCommand(complianceExportCmd)
+	complianceCmd.AddCommand(complianceVerifyCmd)
+}
+
+// complianceExportInterval is how often a repository can be exported from
+// this machine. The server applies its own limits.
+const complianceExportInterval = time.Hour
+
+// complianceEventLimit is the number of audit events a compliance export asks for
+const complianceEventLimit = 10000
+
+// ComplianceExportOptions contains the parsed flags for the compliance export command
+type ComplianceExportOptions struct {
+	Output         string
+	Yes            bool
+	AllowTruncated bool
+}
+
+// ComplianceVerifyOptions contains the parsed flags for the compliance verify command
+type ComplianceVerifyOptions struct {
+	Archive string
+	Key     string
+	Signer  string
+	Decrypt string
+}
+
+// runComplianceExport is the entry point for the compliance export command (uses default dependencies)
+func runComplianceExport(cmd *cobra.Command, args []string) error {
+	opts := ComplianceExportOptions{}
+	opts.Output, _ = cmd.Flags().GetString("output")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+	opts.AllowTruncated, _ = cmd.Flags().GetBool("allow-truncated")
+
+	return runComplianceExportWithDeps(opts, defaultDeps)
+}
+
+// runComplianceVerify is the entry point for the compliance verify command (uses default dependencies)
+func runComplianceVerify(cmd *cobra.Command, args []string) error {
+	opts := ComplianceVerifyOptions{Archive: args[0]}
+	opts.Key, _ = cmd.Flags().GetString("key")
+	opts.Signer, _ = cmd.Flags().GetString("signer")
+	opts.Decrypt, _ = cmd.Flags().GetString("decrypt")
+
+	return runComplianceVerifyWithDeps(opts, defaultDeps)
+}
+
+// runComplianceExportWithDeps is the testable version of runComplianceExport
+func runComplianceExportWithDeps(opts ComplianceExportOptions, deps *Dependencies) error {
+	if opts.Output == "" {
+		opts.Output = "keyway-compliance.json"
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return complianceExport(s, opts)
+	}, withIntro("compliance export"), withRepo, withLogin)
+}
+
+// complianceExport dumps the vault and its audit trail to a sealed archive
+func complianceExport(s *Session, opts ComplianceExportOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionAdmin, "a compliance export", deps); err != nil {
+		return err
+	}
+	if last, ok := lastComplianceExport(s.Repo, deps); ok {
+		if next := last.Add(complianceExportInterval); time.Now().Before(next) {
+			err := fmt.Errorf("%s was exported at %s, the next export is possible at %s", s.Repo, last.Local().Format("15:04"), next.Local().Format("15:04"))
+			deps.UI.Error(err.Error())
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Compliance exports are limited to one per repository per %s", complianceExportInterval)))
+			return err
+		}
+	}
+
+	var envNames []string
+	err := s.Spin("Fetching environments...", func() error {
+		var err error
+		envNames, err = s.Client.GetVaultEnvironments(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("compliance export", err, deps)
+	}
+	sort.Strings(envNames)
+
+	deps.UI.Step(fmt.Sprintf("Environments: %s", strings.Join(envNames, ", ")))
+	question := fmt.Sprintf("Export the %d environments and audit trail of %s to %s?", len(envNames), s.Repo, opts.Output)
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(question, true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	dump := env.ComplianceDump{Repo: s.Repo, ExportedAt: time.Now().UTC()}
+	if deps.AuthStore != nil {
+		if stored, err := deps.AuthStore.GetAuth(); err == nil && stored != nil {
+			dump.ExportedBy = stored.GitHubLogin
+		}
+	}
+	err = s.Spin(fmt.Sprintf("Downloading %d environments...", len(envNames)), func() error {
+		for _, name := range envNames {
+			resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			dump.Environments = append(dump.Environments, env.ComplianceEnvironment{
+				Name:    name,
+				Version: resp.Version,
+				Secrets: env.ParseVault(resp.Content),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("compliance export", err, deps)
+	}
+
+	var events []api.VaultEvent
+	err = s.Spin("Downloading the audit trail...", func() error {
+		var err error
+		events, err = s.Client.GetVaultEvents(s.Ctx, s.Repo, api.EventFilter{Limit: complianceEventLimit})
+		return err
+	})
+	if err != nil {
+		return reportEnvError("compliance export", err, deps)
+	}
+	// The API returns at most the latest complianceEventLimit events, and
+	// cannot page through older ones
+	if len(events) >= complianceEventLimit {
+		if !opts.AllowTruncated {
+			err := fmt.Errorf("the audit trail of %s reaches the %d events an export can hold, older ones would be left out", s.Repo, complianceEventLimit)
+			deps.UI.Error(err.Error())
+			deps.UI.Message(deps.UI.Dim("Export the latest events, marked as truncated in the archive, with --allow-truncated"))
+			return err
+		}
+		dump.AuditTrailTruncated = true
+		deps.UI.Warn(fmt.Sprintf("The audit trail holds the latest %d events, older ones are in the dashboard", complianceEventLimit))
+	}
+	dump.AuditTrail = make([]env.ComplianceEvent, 0, len(events))
+	for _, e := range events {
+		dump.AuditTrail = append(dump.AuditTrail, env.ComplianceEvent{
+			ID:          e.ID,
+			Type:        e.Type,
+			Environment: e.Environment,
+			Keys:        e.Keys,
+			Actor:       e.Actor,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+	signer, err := loadSigningKey(deps)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot load the signing key: %s", err.Error()))
+		return err
+	}
+	archive, key, err := env.SealCompliance(dump, signer)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to encrypt the archive: %s", err.Error()))
+		return err
+	}
+	if err := deps.FS.WriteFile(opts.Output, archive, 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Output, err.Error()))
+		return err
+	}
+	if err := recordComplianceExport(s.Repo, dump.ExportedAt, deps); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Could not record the export: %s", err.Error()))
+	}
+
+	manifest, err := env.ReadComplianceManifest(archive)
+	if err != nil {
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Exported %d environments and %d events to %s", len(dump.Environments), len(dump.AuditTrail), deps.UI.File(opts.Output)))
+	deps.UI.Step(fmt.Sprintf("Signed by %s", deps.UI.Value(env.KeyFingerprint(signer.Public().(ed25519.PublicKey)))))
+	deps.UI.Step(fmt.Sprintf("Digest: %s", manifest.Digest))
+	deps.UI.Message("")
+	deps.UI.Message(fmt.Sprintf("One-time key (shown once, send it separately): %s", deps.UI.Bold(key)))
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Auditors check it with: keyway compliance verify %s --key <key>", filepath.Base(opts.Output))))
+	return nil
+}
+
+// runComplianceVerifyWithDeps is the testable version of runComplianceVerify
+func runComplianceVerifyWithDeps(opts ComplianceVerifyOptions, deps *Dependencies) error {
+	deps.UI.Intro("compliance verify")
+
+	data, err := deps.FS.ReadFile(opts.Archive)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("File not found: %s", opts.Archive))
+		return err
+	}
+	manifest, err := env.ReadComplianceManifest(data)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	from := manifest.Repo
+	if manifest.CreatedBy != "" {
+		from += " by " + manifest.CreatedBy
+	}
+	deps.UI.Step(fmt.Sprintf("From: %s, %s", deps.UI.Value(from), manifest.CreatedAt.Local().Format("2006-01-02 15:04")))
+	deps.UI.Step(fmt.Sprintf("Environments: %s", strings.Join(manifest.Environments, ", ")))
+
+	key := opts.Key
+	if key == "" {
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("The one-time key is required - use --key in non-interactive mode")
+			return fmt.Errorf("key is required")
+		}
+		if key, err = deps.UI.Password("One-time key:"); err != nil {
+			return err
+		}
+	}
+
+	opened, err := env.OpenCompliance(data, key)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	fingerprint := env.KeyFingerprint(opened.Signer)
+	if opts.Signer != "" && !strings.EqualFold(strings.TrimSpace(opts.Signer), fingerprint) {
+		deps.UI.Error(fmt.Sprintf("Archive is signed by %s, not %s", fingerprint, opts.Signer))
+		return fmt.Errorf("unexpected archive signer %s", fingerprint)
+	}
+
+	deps.UI.Success(fmt.Sprintf("Intact: %d environments and %d events, digest %s", len(opened.Dump.Environments), len(opened.Dump.AuditTrail), manifest.Digest))
+	deps.UI.Step(fmt.Sprintf("Signed by: %s", deps.UI.Value(fingerprint)))
+	if manifest.Truncated {
+		deps.UI.Warn(fmt.Sprintf("The audit trail is truncated to its latest %d events", manifest.Events))
+	}
+	if opts.Signer == "" {
+		deps.UI.Message(deps.UI.Dim("Check this fingerprint with the sender, or pass it with --signer"))
+	}
+
+	if opts.Decrypt != "" {
+		if err := deps.FS.WriteFile(opts.Decrypt, opened.Content, 0600); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Decrypt, err.Error()))
+			return err
+		}
+		deps.UI.Success(fmt.Sprintf("Decrypted content written to %s", deps.UI.File(opts.Decrypt)))
+		deps.UI.Warn(fmt.Sprintf("%s holds every secret of the vault unencrypted, delete it once reviewed", opts.Decrypt))
+	}
+	return nil
+}
+
+// complianceExportsPath returns the file holding when each repository was last exported
+func complianceExportsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "compliance-exports.json")
+}
+
+// lastComplianceExport returns when a repository was last exported from this machine
+func lastComplianceExport(repo string, deps *Dependencies) (time.Time, bool) {
+	exports := loadComplianceExports(deps)
+	last, ok := exports[repo]
+	return last, ok
+}
+
+// loadComplianceExports returns the time of the last export, by repository
+func loadComplianceExports(deps *Dependencies) map[string]time.Time {
+	exports := make(map[string]time.Time)
+	path := complianceExportsPath()
+	if path == "" {
+		return exports
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &exports)
+	}
+	return exports
+}
+
+// recordComplianceExport stores the time of an export of a repository
+func recordComplianceExport(repo string, at time.Time, deps *Dependencies) error {
+	path := complianceExportsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	exports := loadComplianceExports(deps)
+	exports[repo] = at
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(exports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
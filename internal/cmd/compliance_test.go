@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// exportCompliance runs a compliance export of two environments and returns
+// the archive and its key
+func exportCompliance(t *testing.T) ([]byte, string, *MockFileSystem) {
+	t.Helper()
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"production", "development"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n", Version: 4}
+	apiMock.Events = []api.VaultEvent{{ID: "ev_1", Type: "secrets.pushed", Environment: "production", Actor: "alice", CreatedAt: "2024-05-30T10:00:00Z"}}
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Output: "evidence.json", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.EventFilter.Limit != complianceEventLimit {
+		t.Errorf("expected the audit trail to be requested up to %d events, got %d", complianceEventLimit, apiMock.EventFilter.Limit)
+	}
+	archive := fsMock.Written["evidence.json"]
+	if archive == nil {
+		t.Fatal("expected the archive to be written")
+	}
+
+	var key string
+	for _, msg := range uiMock.MessageCalls {
+		if _, k, ok := strings.Cut(msg, "send it separately): "); ok {
+			key = k
+		}
+	}
+	if key == "" {
+		t.Fatalf("expected the one-time key to be printed, got %v", uiMock.MessageCalls)
+	}
+	return archive, key, fsMock
+}
+
+func TestRunComplianceExportWithDeps(t *testing.T) {
+	archive, key, fsMock := exportCompliance(t)
+
+	if strings.Contains(string(archive), "secret") {
+		t.Error("expected values to be encrypted")
+	}
+	opened, err := env.OpenCompliance(archive, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dump := opened.Dump
+	if dump.Repo != "owner/repo" || len(dump.Environments) != 2 {
+		t.Fatalf("unexpected dump: %+v", dump)
+	}
+	if dump.Environments[0].Name != "development" || dump.Environments[1].Secrets["API_KEY"] != "secret" || dump.Environments[1].Version != 4 {
+		t.Errorf("unexpected environments: %+v", dump.Environments)
+	}
+	if len(dump.AuditTrail) != 1 || dump.AuditTrail[0].Actor != "alice" {
+		t.Errorf("unexpected audit trail: %+v", dump.AuditTrail)
+	}
+
+	var exports map[string]time.Time
+	if err := json.Unmarshal(fsMock.Written[complianceExportsPath()], &exports); err != nil || exports["owner/repo"].IsZero() {
+		t.Errorf("expected the export to be recorded, got %s", fsMock.Written[complianceExportsPath()])
+	}
+}
+
+func TestRunComplianceExportWithDeps_RequiresAdmin(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionMaintain}
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Yes: true}, deps); err == nil {
+		t.Fatal("expected an error without admin access")
+	}
+	if len(fsMock.Written) != 0 {
+		t.Errorf("expected nothing to be written, got %v", fsMock.Written)
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "requires admin access") {
+		t.Errorf("expected a permission error, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunComplianceExportWithDeps_RateLimited(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"production"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+	data, _ := json.Marshal(map[string]time.Time{"owner/repo": time.Now().Add(-10 * time.Minute)})
+	fsMock.Files[complianceExportsPath()] = data
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for a second export within the interval")
+	}
+	if _, ok := fsMock.Written["keyway-compliance.json"]; ok {
+		t.Error("expected no archive to be written")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.Contains(uiMock.ErrorCalls[0], "next export") {
+		t.Errorf("expected a rate limit error, got %v", uiMock.ErrorCalls)
+	}
+
+	data, _ = json.Marshal(map[string]time.Time{"owner/repo": time.Now().Add(-2 * complianceExportInterval)})
+	fsMock.Files[complianceExportsPath()] = data
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Yes: true}, deps); err != nil {
+		t.Fatalf("expected an export once the interval passed, got %v", err)
+	}
+}
+
+func TestRunComplianceExportWithDeps_RequiresConfirmation(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"production"}
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{}, deps); err == nil {
+		t.Fatal("expected an error without --yes in non-interactive mode")
+	}
+	if len(fsMock.Written) != 0 {
+		t.Errorf("expected nothing to be written, got %v", fsMock.Written)
+	}
+}
+
+func TestRunComplianceExportWithDeps_TruncatedTrail(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"production"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+	apiMock.Events = make([]api.VaultEvent, complianceEventLimit)
+	for i := range apiMock.Events {
+		apiMock.Events[i] = api.VaultEvent{ID: fmt.Sprintf("ev_%d", i), Type: "secrets.pushed"}
+	}
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Output: "evidence.json", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for an audit trail longer than an export holds")
+	}
+	if _, ok := fsMock.Written["evidence.json"]; ok {
+		t.Error("expected no archive to be written")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.Contains(uiMock.ErrorCalls[0], "older ones would be left out") {
+		t.Errorf("expected a truncation error, got %v", uiMock.ErrorCalls)
+	}
+
+	if err := runComplianceExportWithDeps(ComplianceExportOptions{Output: "evidence.json", Yes: true, AllowTruncated: true}, deps); err != nil {
+		t.Fatalf("unexpected error with --allow-truncated: %v", err)
+	}
+	manifest, err := env.ReadComplianceManifest(fsMock.Written["evidence.json"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !manifest.Truncated || manifest.Events != complianceEventLimit {
+		t.Errorf("expected the manifest to record a truncated trail of %d events, got %+v", complianceEventLimit, manifest)
+	}
+}
+
+func TestRunComplianceVerifyWithDeps(t *testing.T) {
+	archive, key, _ := exportCompliance(t)
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	fsMock.Files["evidence.json"] = archive
+
+	if err := runComplianceVerifyWithDeps(ComplianceVerifyOptions{Archive: "evidence.json", Key: key, Decrypt: "dump.json"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.SuccessCalls) == 0 || !strings.Contains(uiMock.SuccessCalls[0], "2 environments and 1 events") {
+		t.Errorf("expected a summary, got %v", uiMock.SuccessCalls)
+	}
+	var dump env.ComplianceDump
+	if err := json.Unmarshal(fsMock.Written["dump.json"], &dump); err != nil || dump.Schema != env.ComplianceSchema {
+		t.Errorf("expected the decrypted dump to be written, got %s", fsMock.Written["dump.json"])
+	}
+}
+
+func TestRunComplianceVerifyWithDeps_SignerMismatch(t *testing.T) {
+	archive, key, _ := exportCompliance(t)
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["evidence.json"] = archive
+
+	if err := runComplianceVerifyWithDeps(ComplianceVerifyOptions{Archive: "evidence.json", Key: key, Signer: "0000:0000", Decrypt: "dump.json"}, deps); err == nil {
+		t.Fatal("expected signer mismatch error")
+	}
+	if _, ok := fsMock.Written["dump.json"]; ok {
+		t.Error("expected the dump not to be written")
+	}
+}
+
+func TestRunComplianceVerifyWithDeps_NotAnArchive(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["bundle.json"] = []byte(`{"version": 1, "manifest": {}}`)
+
+	if err := runComplianceVerifyWithDeps(ComplianceVerifyOptions{Archive: "bundle.json", Key: "kwk1_x"}, deps); err == nil {
+		t.Fatal("expected an error for a file that is not a compliance archive")
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/injector"
+	"github.com/spf13/cobra"
+)
+
+var composeCmd = &cobra.Command{
+	Use:   "compose [docker compose args]",
+	Short: "Run docker compose with vault values for ${VAR} substitution",
+	Long: `Run docker compose with the secrets of an environment in its environment, so
+${VAR} references in compose files are filled from the vault and no .env file
+has to be checked out. Values are never written to disk.
+
+Only substitution in compose files is covered: services using env_file still
+need that file.
+
+Flags of keyway go before the compose command, everything after is passed to
+docker compose as is.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCompose,
+}
+
+func init() {
+	composeCmd.Flags().StringP("env", "e", "development", "Environment name")
+	composeCmd.Flags().SetInterspersed(false)
+}
+
+// composeFiles are the files docker compose reads by default, in its order of preference
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeRefRegex matches ${VAR}, ${VAR:-default}, ${VAR?error}... and $VAR, but not $$VAR
+var composeRefRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)([:]?[-?+][^}]*)?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ComposeOptions contains the parsed flags for the compose command
+type ComposeOptions struct {
+	EnvName string
+	Args    []string
+}
+
+// runCompose is the entry point for the compose command (uses default dependencies)
+func runCompose(cmd *cobra.Command, args []string) error {
+	opts := ComposeOptions{Args: args}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runComposeWithDeps(opts, defaultDeps)
+}
+
+// runComposeWithDeps is the testable version of runCompose
+func runComposeWithDeps(opts ComposeOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return compose(s, opts.Args)
+	}, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// compose runs docker compose with the environment's secrets
+func compose(s *Session, args []string) error {
+	deps, envName := s.Deps, s.EnvName
+	var content string
+	err := s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, envName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("compose", err, deps)
+	}
+
+	secrets, err := applyDerived(env.ParseVault(content), deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	for _, file := range composeFileArgs(args, deps) {
+		data, err := deps.FS.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if missing := missingComposeVars(string(data), secrets); len(missing) > 0 {
+			deps.UI.Warn(fmt.Sprintf("%s uses variables that are not in %s: %s", file, envName, strings.Join(missing, ", ")))
+		}
+	}
+
+	secrets, cleanupFiles, err := injector.WriteFiles(secrets)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write file secrets: %s", err.Error()))
+		return err
+	}
+	defer cleanupFiles()
+
+	deps.UI.Success(fmt.Sprintf("Injected %d secrets", len(secrets)))
+
+	return deps.CmdRunner.RunCommand("docker", append([]string{"compose"}, args...), secrets)
+}
+
+// composeFileArgs returns the compose files given with -f/--file, or the
+// default compose file docker compose would pick
+func composeFileArgs(args []string, deps *Dependencies) []string {
+	var files []string
+	for i, arg := range args {
+		switch {
+		case (arg == "-f" || arg == "--file") && i+1 < len(args):
+			files = append(files, args[i+1])
+		case strings.HasPrefix(arg, "--file="):
+			files = append(files, strings.TrimPrefix(arg, "--file="))
+		case strings.HasPrefix(arg, "-f") && len(arg) > 2:
+			files = append(files, arg[2:])
+		}
+	}
+	if len(files) > 0 {
+		return files
+	}
+	for _, file := range composeFiles {
+		if _, err := deps.FS.ReadFile(file); err == nil {
+			return []string{file}
+		}
+	}
+	return nil
+}
+
+// missingComposeVars returns the variables a compose file needs without a
+// default value, that are neither in secrets nor in the current environment
+func missingComposeVars(content string, secrets map[string]string) []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, m := range composeRefRegex.FindAllStringSubmatch(content, -1) {
+		name := m[1]
+		if name == "" {
+			name = m[3]
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		// ${VAR:-default} and ${VAR-default} work without a value
+		if strings.HasPrefix(strings.TrimPrefix(m[2], ":"), "-") {
+			continue
+		}
+		seen[name] = true
+		if _, ok := secrets[name]; ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunComposeWithDeps_PassesArgsAndSecrets(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "POSTGRES_PASSWORD=hunter2\n"}
+
+	err := runComposeWithDeps(ComposeOptions{EnvName: "staging", Args: []string{"up", "-d"}}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmdRunner.LastCommand != "docker" || strings.Join(cmdRunner.LastArgs, " ") != "compose up -d" {
+		t.Errorf("unexpected command: %s %v", cmdRunner.LastCommand, cmdRunner.LastArgs)
+	}
+	if cmdRunner.LastSecrets["POSTGRES_PASSWORD"] != "hunter2" {
+		t.Errorf("expected secrets in the compose environment, got %v", cmdRunner.LastSecrets)
+	}
+}
+
+func TestRunComposeWithDeps_WarnsMissingVariables(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDepsWithRunner()
+	deps.FS.(*MockFileSystem).Files["compose.yaml"] = []byte(`services:
+  db:
+    image: postgres:${PG_VERSION:-16}
+    environment:
+      POSTGRES_PASSWORD: ${POSTGRES_PASSWORD}
+      API_TOKEN: $KEYWAY_TEST_MISSING_TOKEN
+      LITERAL: $$NOT_A_VAR
+`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "POSTGRES_PASSWORD=hunter2\n"}
+
+	err := runComposeWithDeps(ComposeOptions{EnvName: "development", Args: []string{"up"}}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.HasSuffix(uiMock.WarnCalls[0], ": KEYWAY_TEST_MISSING_TOKEN") {
+		t.Errorf("expected only KEYWAY_TEST_MISSING_TOKEN to be reported, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestComposeFileArgs(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	files := composeFileArgs([]string{"-f", "a.yml", "--file=b.yml", "-fc.yml", "up"}, deps)
+	if strings.Join(files, ",") != "a.yml,b.yml,c.yml" {
+		t.Errorf("unexpected files: %v", files)
+	}
+
+	deps.FS.(*MockFileSystem).Files["docker-compose.yml"] = []byte("services: {}")
+	if files := composeFileArgs([]string{"up"}, deps); len(files) != 1 || files[0] != "docker-compose.yml" {
+		t.Errorf("expected default compose file, got %v", files)
+	}
+}
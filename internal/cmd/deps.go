@@ -5,7 +5,12 @@ package cmd
 // Mock implementations for testing are in mocks_test.go.
 
 import (
+	"context"
+	"os"
+
 	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/cache"
+	"github.com/keywaysh/cli/internal/github"
 )
 
 // MonorepoInfo contains information about detected monorepo setup
@@ -21,6 +26,8 @@ type GitClient interface {
 	AddEnvToGitignore() error
 	IsGitRepository() bool
 	DetectMonorepo() MonorepoInfo
+	CurrentBranch() string
+	DefaultBranch() string
 }
 
 // AuthProvider abstracts authentication for testing
@@ -41,7 +48,9 @@ type UIProvider interface {
 	IsInteractive() bool
 	Confirm(message string, defaultValue bool) (bool, error)
 	Select(message string, options []string) (string, error)
+	MultiSelect(message string, options []string) ([]string, error)
 	Password(prompt string) (string, error)
+	Input(prompt, defaultValue string) (string, error)
 	Spin(message string, fn func() error) error
 	Value(v interface{}) string
 	File(path string) string
@@ -81,6 +90,20 @@ type APIClientFactory interface {
 // CommandRunner abstracts command execution for testing
 type CommandRunner interface {
 	RunCommand(name string, args []string, secrets map[string]string) error
+	StartCommand(name string, args []string, secrets map[string]string) (RunningCommand, error)
+}
+
+// RunningCommand is a command started by CommandRunner.StartCommand
+type RunningCommand interface {
+	Signal(sig os.Signal) error
+	// Stop asks the command to exit, after which Wait returns whatever its exit code
+	Stop(sig os.Signal) error
+	Wait() error
+}
+
+// RemoteRunner abstracts running commands on remote hosts over SSH for testing
+type RemoteRunner interface {
+	Run(host, command string, stdin []byte) error
 }
 
 // BrowserOpener abstracts browser operations for testing
@@ -102,6 +125,7 @@ type StoredAuthInfo struct {
 // HTTPClient abstracts HTTP operations for testing
 type HTTPClient interface {
 	Head(url string) (int, error)
+	Get(url string) (int, error)
 }
 
 // FileWalker abstracts directory walking for testing
@@ -121,6 +145,31 @@ type FileStat interface {
 	Stat(name string) (FileInfo, error)
 }
 
+// Clipboard abstracts the system clipboard for testing
+type Clipboard interface {
+	ReadAll() (string, error)
+	WriteAll(text string) error
+}
+
+// SecretCache abstracts the encrypted cache of prefetched environments for testing
+type SecretCache interface {
+	Get(repo, env string) (*cache.Entry, bool)
+	Put(entry cache.Entry) error
+	Forget(repo, env string) error
+}
+
+// GitHubSecretsClient manages GitHub Actions secrets
+type GitHubSecretsClient interface {
+	ListActionsSecrets(ctx context.Context, repo, environment string) ([]github.Secret, error)
+	PutActionsSecret(ctx context.Context, repo, environment, name, value string) error
+	DeleteActionsSecret(ctx context.Context, repo, environment, name string) error
+}
+
+// GitHubClientFactory creates GitHub clients
+type GitHubClientFactory interface {
+	NewClient(token string) GitHubSecretsClient
+}
+
 // Dependencies holds all external dependencies for commands
 type Dependencies struct {
 	Git        GitClient
@@ -135,4 +184,8 @@ type Dependencies struct {
 	Stat       FileStat
 	AuthStore  AuthStore
 	HTTP       HTTPClient
+	Remote     RemoteRunner
+	Clipboard  Clipboard
+	GitHub     GitHubClientFactory
+	Cache      SecretCache
 }
@@ -12,14 +12,21 @@ package cmd
 // The testable business logic lives in the *WithDeps functions in each command file.
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/auth"
+	"github.com/keywaysh/cli/internal/cache"
+	"github.com/keywaysh/cli/internal/config"
 	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/github"
 	"github.com/keywaysh/cli/internal/git"
 	"github.com/keywaysh/cli/internal/injector"
 	"github.com/keywaysh/cli/internal/ui"
@@ -33,6 +40,8 @@ func (r *realGitClient) DetectRepo() (string, error) { return git.DetectRepo() }
 func (r *realGitClient) CheckEnvGitignore() bool     { return git.CheckEnvGitignore() }
 func (r *realGitClient) AddEnvToGitignore() error    { return git.AddEnvToGitignore() }
 func (r *realGitClient) IsGitRepository() bool       { return git.IsGitRepository() }
+func (r *realGitClient) CurrentBranch() string        { return git.CurrentBranch() }
+func (r *realGitClient) DefaultBranch() string        { return git.DefaultBranch() }
 func (r *realGitClient) DetectMonorepo() MonorepoInfo {
 	info := git.DetectMonorepo()
 	return MonorepoInfo{IsMonorepo: info.IsMonorepo, Tool: info.Tool}
@@ -61,9 +70,15 @@ func (r *realUIProvider) Confirm(message string, defaultValue bool) (bool, error
 func (r *realUIProvider) Select(message string, options []string) (string, error) {
 	return ui.Select(message, options)
 }
+func (r *realUIProvider) MultiSelect(message string, options []string) ([]string, error) {
+	return ui.MultiSelect(message, options)
+}
 func (r *realUIProvider) Password(prompt string) (string, error) {
 	return ui.Password(prompt)
 }
+func (r *realUIProvider) Input(prompt, defaultValue string) (string, error) {
+	return ui.Input(prompt, defaultValue)
+}
 func (r *realUIProvider) Spin(message string, fn func() error) error { return ui.Spin(message, fn) }
 func (r *realUIProvider) Value(v interface{}) string                 { return ui.Value(v) }
 func (r *realUIProvider) File(path string) string                    { return ui.File(path) }
@@ -91,7 +106,13 @@ func (r *realFileSystem) WriteFile(name string, data []byte, perm uint32) error
 type realAPIFactory struct{}
 
 func (r *realAPIFactory) NewClient(token string) api.APIClient {
-	return api.NewClient(token)
+	client := api.NewClient(token)
+	if commandBudget != nil {
+		client.SetBudget(commandBudget)
+	}
+	// Each command is a new process: the bases of delta pulls are kept on disk
+	client.SetPullBaseStore(&realPullBaseStore{})
+	return client
 }
 
 // realEnvHelper wraps the env package
@@ -117,6 +138,25 @@ func (r *realCommandRunner) RunCommand(name string, args []string, secrets map[s
 	return injector.RunCommand(name, args, secrets)
 }
 
+func (r *realCommandRunner) StartCommand(name string, args []string, secrets map[string]string) (RunningCommand, error) {
+	p, err := injector.StartCommand(name, args, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// realRemoteRunner wraps the system ssh client
+type realRemoteRunner struct{}
+
+func (r *realRemoteRunner) Run(host, command string, stdin []byte) error {
+	cmd := exec.Command("ssh", "--", host, command)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 // realBrowserOpener wraps the browser package
 type realBrowserOpener struct{}
 
@@ -187,6 +227,118 @@ func (r *realHTTPClient) Head(url string) (int, error) {
 	return resp.StatusCode, nil
 }
 
+func (r *realHTTPClient) Get(url string) (int, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// realClipboard wraps the system clipboard
+type realClipboard struct{}
+
+func (r *realClipboard) ReadAll() (string, error)   { return clipboard.ReadAll() }
+func (r *realClipboard) WriteAll(text string) error { return clipboard.WriteAll(text) }
+
+// realGitHubFactory creates real GitHub clients
+type realGitHubFactory struct{}
+
+func (r *realGitHubFactory) NewClient(token string) GitHubSecretsClient {
+	return github.NewClient(token)
+}
+
+// realSecretCache wraps the cache package, encrypted with the key of the
+// stored logins and kept apart for each profile
+type realSecretCache struct{}
+
+func (r *realSecretCache) open() (*cache.Cache, error) {
+	dir := secretCacheDir()
+	if dir == "" {
+		return nil, fmt.Errorf("cannot find the home directory")
+	}
+	return cache.New(dir, auth.NewStore()), nil
+}
+
+// secretCacheDir returns the directory of the cache of the current profile
+func secretCacheDir() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	profile := auth.Profile()
+	if profile == "" {
+		profile = "default"
+	}
+	return filepath.Join(dir, "cache", profile)
+}
+
+func (r *realSecretCache) Get(repo, env string) (*cache.Entry, bool) {
+	c, err := r.open()
+	if err != nil {
+		return nil, false
+	}
+	return c.Get(repo, env)
+}
+
+func (r *realSecretCache) Put(entry cache.Entry) error {
+	c, err := r.open()
+	if err != nil {
+		return err
+	}
+	// Entries too old for keyway run to use are only taking space
+	_ = c.Prune(prefetchMaxAge, time.Now())
+	return c.Put(entry)
+}
+
+func (r *realSecretCache) Forget(repo, env string) error {
+	c, err := r.open()
+	if err != nil {
+		return err
+	}
+	return c.Forget(repo, env)
+}
+
+// realPullBaseStore keeps the bases of delta pulls next to the prefetched
+// environments, encrypted the same way and cleared with them on logout. They
+// are apart from the prefetched entries, which keyway run would use as is.
+type realPullBaseStore struct{}
+
+// pullBaseMaxAge is how long a base is kept without being pulled again
+const pullBaseMaxAge = 30 * 24 * time.Hour
+
+func (r *realPullBaseStore) open() (*cache.Cache, error) {
+	dir := secretCacheDir()
+	if dir == "" {
+		return nil, fmt.Errorf("cannot find the home directory")
+	}
+	return cache.New(filepath.Join(dir, "pulls"), auth.NewStore()), nil
+}
+
+func (r *realPullBaseStore) LoadPullBase(repo, env string) (api.PullBase, bool) {
+	c, err := r.open()
+	if err != nil {
+		return api.PullBase{}, false
+	}
+	entry, ok := c.Get(repo, env)
+	if !ok {
+		return api.PullBase{}, false
+	}
+	return api.PullBase{Content: entry.Content, Version: entry.Version}, true
+}
+
+func (r *realPullBaseStore) SavePullBase(repo, env string, base api.PullBase) error {
+	c, err := r.open()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_ = c.Prune(pullBaseMaxAge, now)
+	return c.Put(cache.Entry{Repo: repo, Environment: env, Content: base.Content, Version: base.Version, FetchedAt: now})
+}
+
 // DefaultDeps returns the default (real) dependencies
 func DefaultDeps() *Dependencies {
 	return &Dependencies{
@@ -202,6 +354,10 @@ func DefaultDeps() *Dependencies {
 		Stat:       &realFileStat{},
 		AuthStore:  &realAuthStore{},
 		HTTP:       &realHTTPClient{},
+		Remote:     &realRemoteRunner{},
+		Clipboard:  &realClipboard{},
+		GitHub:     &realGitHubFactory{},
+		Cache:      &realSecretCache{},
 	}
 }
 
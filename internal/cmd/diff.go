@@ -3,11 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/env"
 	"github.com/keywaysh/cli/internal/ui"
 	"github.com/spf13/cobra"
@@ -22,11 +24,15 @@ var diffCmd = &cobra.Command{
 
 When run without arguments in an interactive terminal, prompts for environment selection.
 
-Examples:
-  keyway diff                           # Interactive selection
-  keyway diff production staging
-  keyway diff development production --show-values
-  keyway diff prod dev --keys-only`,
+With --file, compares a local env file with an environment of the vault, as
+keyway push would: keys excluded in .keyway.json are left out, and values the
+comparators consider equivalent are not differences. Nothing is written.
+
+With --against, compares one environment (or a local file) with a historical
+snapshot of the vault, identified by version number or date.
+
+--exit-code makes the command exit with 7 when there are differences, e.g. to
+fail a CI step when a file drifted from the vault.`,
 	Args: cobra.RangeArgs(0, 2),
 	RunE: runDiff,
 }
@@ -34,7 +40,9 @@ Examples:
 func init() {
 	diffCmd.Flags().Bool("show-values", false, "Show actual value differences (sensitive!)")
 	diffCmd.Flags().Bool("keys-only", false, "Only show key names, no status details")
-	diffCmd.Flags().Bool("json", false, "Output as JSON")
+	diffCmd.Flags().String("against", "", "Compare with a historical snapshot (version:N or YYYY-MM-DD)")
+	diffCmd.Flags().StringP("file", "f", "", "Compare a local env file with the environment (or with --against, the snapshot)")
+	diffCmd.Flags().Bool("exit-code", false, "Exit with 7 when there are differences")
 }
 
 // DiffResult represents the comparison between two environments
@@ -46,6 +54,8 @@ type DiffResult struct {
 	Different  []DiffEntry `json:"different"`
 	Same       []string    `json:"same"`
 	Stats      DiffStats   `json:"stats"`
+	// Owners of the keys that differ, see keyway owners
+	Owners map[string][]string `json:"owners,omitempty"`
 }
 
 type DiffEntry struct {
@@ -54,6 +64,7 @@ type DiffEntry struct {
 	Value2   string `json:"value2,omitempty"`
 	Preview1 string `json:"preview1,omitempty"`
 	Preview2 string `json:"preview2,omitempty"`
+	Config   bool   `json:"config,omitempty"` // plain config key, previews hold the real values
 }
 
 type DiffStats struct {
@@ -72,6 +83,10 @@ type DiffOptions struct {
 	ShowValues bool
 	KeysOnly   bool
 	JSONOutput bool
+	Against    string
+	File       string
+	// ExitCode makes differences fail the command, with ExitDrift
+	ExitCode bool
 }
 
 // runDiff is the entry point for the diff command (uses default dependencies)
@@ -80,6 +95,9 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	opts.ShowValues, _ = cmd.Flags().GetBool("show-values")
 	opts.KeysOnly, _ = cmd.Flags().GetBool("keys-only")
 	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Against, _ = cmd.Flags().GetString("against")
+	opts.File, _ = cmd.Flags().GetString("file")
+	opts.ExitCode, _ = cmd.Flags().GetBool("exit-code")
 
 	if len(args) >= 1 {
 		opts.Env1 = args[0]
@@ -93,23 +111,25 @@ func runDiff(cmd *cobra.Command, args []string) error {
 
 // runDiffWithDeps is the testable version of runDiff
 func runDiffWithDeps(opts DiffOptions, deps *Dependencies) error {
-	deps.UI.Intro("diff")
-
-	repo, err := deps.Git.DetectRepo()
-	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
-		return err
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
 	}
-	deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(repo)))
+	return runPipeline(deps, func(s *Session) error {
+		return diffEnvironments(s, opts)
+	}, withIntro("diff"), withRepo, withLogin)
+}
 
-	token, err := deps.Auth.EnsureLogin()
-	if err != nil {
-		return err
+// diffEnvironments compares two environments, prompting for them when not given
+func diffEnvironments(s *Session, opts DiffOptions) error {
+	deps, repo := s.Deps, s.Repo
+	if opts.Against != "" {
+		return runDiffAgainst(s, opts)
+	}
+	if opts.File != "" {
+		return runDiffFile(s, opts)
 	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
-
+	var err error
 	env1 := opts.Env1
 	env2 := opts.Env2
 
@@ -122,9 +142,9 @@ func runDiffWithDeps(opts DiffOptions, deps *Dependencies) error {
 
 		// Fetch available environments
 		var environments []string
-		err = deps.UI.Spin("Fetching environments...", func() error {
+		err = s.Spin("Fetching environments...", func() error {
 			var fetchErr error
-			environments, fetchErr = client.GetVaultEnvironments(ctx, repo)
+			environments, fetchErr = s.Client.GetVaultEnvironments(s.Ctx, repo)
 			return fetchErr
 		})
 		if err != nil {
@@ -181,19 +201,19 @@ func runDiffWithDeps(opts DiffOptions, deps *Dependencies) error {
 	var secrets1, secrets2 map[string]string
 	var pullErr1, pullErr2 error
 
-	err = deps.UI.Spin(fmt.Sprintf("Fetching %s and %s...", env1, env2), func() error {
-		resp1, err := client.PullSecrets(ctx, repo, env1)
+	err = s.Spin(fmt.Sprintf("Fetching %s and %s...", env1, env2), func() error {
+		resp1, err := s.Client.PullSecrets(s.Ctx, repo, env1)
 		if err != nil {
 			pullErr1 = err
 		} else {
-			secrets1 = env.Parse(resp1.Content)
+			secrets1 = env.ParseVault(resp1.Content)
 		}
 
-		resp2, err := client.PullSecrets(ctx, repo, env2)
+		resp2, err := s.Client.PullSecrets(s.Ctx, repo, env2)
 		if err != nil {
 			pullErr2 = err
 		} else {
-			secrets2 = env.Parse(resp2.Content)
+			secrets2 = env.ParseVault(resp2.Content)
 		}
 
 		return nil
@@ -219,6 +239,11 @@ func runDiffWithDeps(opts DiffOptions, deps *Dependencies) error {
 
 	// Compare secrets
 	result := compareSecrets(env1, env2, secrets1, secrets2, opts.ShowValues)
+	if err := revealConfigValues(result, secrets1, secrets2, deps); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	addDiffOwners(s.Ctx, s.Client, repo, result, deps)
 
 	// Track diff event
 	analytics.Track(analytics.EventDiff, map[string]interface{}{
@@ -231,14 +256,199 @@ func runDiffWithDeps(opts DiffOptions, deps *Dependencies) error {
 	})
 
 	if opts.JSONOutput {
-		return printDiffJSON(result)
+		if err := printDiffJSON(result); err != nil {
+			return err
+		}
+		return diffDrift(result, opts)
 	}
 
 	// Display results
 	printDiffResults(result, env1, env2, opts.ShowValues, opts.KeysOnly)
 
 	deps.UI.Outro("")
-	return nil
+	return diffDrift(result, opts)
+}
+
+// runDiffAgainst compares an environment, or a local file, with a historical vault snapshot
+func runDiffAgainst(s *Session, opts DiffOptions) error {
+	deps, repo := s.Deps, s.Repo
+	rev, err := api.ParseRevision(opts.Against)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if opts.Env2 != "" {
+		deps.UI.Error("--against compares a single environment with its history")
+		return fmt.Errorf("too many arguments")
+	}
+
+	envName := "development"
+	if opts.Env1 != "" {
+		envName = normalizeEnvName(opts.Env1)
+	}
+
+	oldLabel := fmt.Sprintf("%s@%s", envName, rev.String())
+	newLabel := envName
+	if opts.File != "" {
+		newLabel = opts.File
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Comparing %s vs %s", deps.UI.Bold(oldLabel), deps.UI.Bold(newLabel))))
+
+	var oldSecrets, newSecrets map[string]string
+	if opts.File != "" {
+		content, err := deps.FS.ReadFile(opts.File)
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("File not found: %s", opts.File))
+			return err
+		}
+		newSecrets = env.Parse(string(content))
+	}
+
+	err = s.Spin(fmt.Sprintf("Fetching %s...", oldLabel), func() error {
+		resp, err := s.Client.PullSecretsAt(s.Ctx, repo, envName, rev)
+		if err != nil {
+			return err
+		}
+		oldSecrets = env.ParseVault(resp.Content)
+
+		if newSecrets == nil {
+			resp, err = s.Client.PullSecrets(s.Ctx, repo, envName)
+			if err != nil {
+				return err
+			}
+			newSecrets = env.ParseVault(resp.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			deps.UI.Error(fmt.Sprintf("No snapshot of %s found at %s", envName, rev.String()))
+		} else {
+			deps.UI.Error(err.Error())
+		}
+		return err
+	}
+
+	result := compareSecrets(oldLabel, newLabel, oldSecrets, newSecrets, opts.ShowValues)
+	if err := revealConfigValues(result, oldSecrets, newSecrets, deps); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	addDiffOwners(s.Ctx, s.Client, repo, result, deps)
+
+	analytics.Track(analytics.EventDiff, map[string]interface{}{
+		"env1":              envName,
+		"against":           true,
+		"local_file":        opts.File != "",
+		"differences_count": result.Stats.Different + result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2,
+		"same_count":        result.Stats.Same,
+	})
+
+	if opts.JSONOutput {
+		if err := printDiffJSON(result); err != nil {
+			return err
+		}
+		return diffDrift(result, opts)
+	}
+
+	printDiffResults(result, oldLabel, newLabel, opts.ShowValues, opts.KeysOnly)
+
+	deps.UI.Outro("")
+	return diffDrift(result, opts)
+}
+
+// runDiffFile compares a local env file with the current state of an
+// environment, with the same rules as keyway push, and writes nothing
+func runDiffFile(s *Session, opts DiffOptions) error {
+	deps, repo := s.Deps, s.Repo
+	if opts.Env2 != "" {
+		deps.UI.Error("--file compares a file with a single environment")
+		return fmt.Errorf("too many arguments")
+	}
+	envName := normalizeEnvName(opts.Env1)
+	if envName == "" {
+		envName = deriveEnvFromFile(opts.File, deps)
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Comparing %s vs %s", deps.UI.Bold(envName), deps.UI.Bold(opts.File))))
+
+	content, err := deps.FS.ReadFile(opts.File)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("File not found: %s", opts.File))
+		return err
+	}
+	local, _, err := stripExcluded(env.Parse(string(content)), deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	var vault map[string]string
+	err = s.Spin(fmt.Sprintf("Fetching %s...", envName), func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, repo, envName)
+		if err != nil {
+			return err
+		}
+		vault = env.ParseVault(resp.Content)
+		return nil
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); !ok || apiErr.StatusCode != 404 {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		deps.UI.Warn(fmt.Sprintf("Environment '%s' is empty or doesn't exist", envName))
+		vault = make(map[string]string)
+	}
+
+	// Values push would leave as they are in the vault are not differences
+	equal, err := loadValueEqual(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	equal = env.KeepFiles(equal)
+	expandedVault, _ := env.Expand(vault, os.LookupEnv)
+	equal = env.KeepReferences(equal, expandedVault)
+	for k, v := range local {
+		if vaultVal, ok := vault[k]; ok && vaultVal != v && equal(k, v, vaultVal) {
+			local[k] = vaultVal
+		}
+	}
+
+	result := compareSecrets(envName, opts.File, vault, local, opts.ShowValues)
+	if err := revealConfigValues(result, vault, local, deps); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	addDiffOwners(s.Ctx, s.Client, repo, result, deps)
+
+	analytics.Track(analytics.EventDiff, map[string]interface{}{
+		"env1":              envName,
+		"local_file":        true,
+		"differences_count": result.Stats.Different + result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2,
+		"same_count":        result.Stats.Same,
+	})
+
+	if opts.JSONOutput {
+		if err := printDiffJSON(result); err != nil {
+			return err
+		}
+		return diffDrift(result, opts)
+	}
+
+	printDiffResults(result, envName, opts.File, opts.ShowValues, opts.KeysOnly)
+
+	deps.UI.Outro("")
+	return diffDrift(result, opts)
+}
+
+// diffDrift returns a driftError when --exit-code is set and result has differences
+func diffDrift(result *DiffResult, opts DiffOptions) error {
+	count := result.Stats.Different + result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2
+	if !opts.ExitCode || count == 0 {
+		return nil
+	}
+	return driftError{fmt.Errorf("%d key(s) differ between %s and %s", count, result.Env1, result.Env2)}
 }
 
 func normalizeEnvName(env string) string {
@@ -319,6 +529,45 @@ func compareSecrets(env1, env2 string, secrets1, secrets2 map[string]string, inc
 	return result
 }
 
+// revealConfigValues replaces the masked previews of config keys (see .keyway.json)
+// with their actual values. Secret keys are left masked.
+func revealConfigValues(result *DiffResult, secrets1, secrets2 map[string]string, deps *Dependencies) error {
+	project, err := loadProject(deps)
+	if err != nil {
+		return err
+	}
+	for i, entry := range result.Different {
+		if project.IsConfigKey(entry.Key) {
+			result.Different[i].Config = true
+			result.Different[i].Preview1 = secrets1[entry.Key]
+			result.Different[i].Preview2 = secrets2[entry.Key]
+		}
+	}
+	return nil
+}
+
+// addDiffOwners sets the owners of the keys that differ
+func addDiffOwners(ctx context.Context, client api.APIClient, repo string, result *DiffResult, deps *Dependencies) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return
+	}
+	owners := loadKeyOwners(ctx, client, repo, project, deps)
+	if len(owners.Rules) == 0 {
+		return
+	}
+	keys := append(append([]string{}, result.OnlyInEnv1...), result.OnlyInEnv2...)
+	for _, entry := range result.Different {
+		keys = append(keys, entry.Key)
+	}
+	result.Owners = make(map[string][]string)
+	for _, key := range keys {
+		if keyOwners := owners.Of(key); len(keyOwners) > 0 {
+			result.Owners[key] = keyOwners
+		}
+	}
+}
+
 // previewValue returns a safe preview of a secret value
 // Shows last 2 chars + length to help identify changes without exposing sensitive data
 // Last chars are more distinctive than first chars (which are often common prefixes like sk_, gh_, etc.)
@@ -356,7 +605,7 @@ func printDiffResults(result *DiffResult, env1, env2 string, showValues, keysOnl
 			if keysOnly {
 				fmt.Printf("  %s\n", key)
 			} else {
-				fmt.Printf("  %s %s\n", ui.Value("-"), key)
+				fmt.Printf("  %s %s%s\n", ui.Value("-"), key, ownersSuffix(result, key))
 			}
 		}
 	}
@@ -369,7 +618,7 @@ func printDiffResults(result *DiffResult, env1, env2 string, showValues, keysOnl
 			if keysOnly {
 				fmt.Printf("  %s\n", key)
 			} else {
-				fmt.Printf("  %s %s\n", ui.Value("+"), key)
+				fmt.Printf("  %s %s%s\n", ui.Value("+"), key, ownersSuffix(result, key))
 			}
 		}
 	}
@@ -381,12 +630,12 @@ func printDiffResults(result *DiffResult, env1, env2 string, showValues, keysOnl
 		for _, entry := range result.Different {
 			if keysOnly {
 				fmt.Printf("  %s\n", entry.Key)
-			} else if showValues {
-				fmt.Printf("  %s %s\n", yellow.Sprint("~"), entry.Key)
+			} else if showValues && !entry.Config {
+				fmt.Printf("  %s %s%s\n", yellow.Sprint("~"), entry.Key, ownersSuffix(result, entry.Key))
 				fmt.Printf("    %s: %s\n", env1, maskValue(entry.Value1))
 				fmt.Printf("    %s: %s\n", env2, maskValue(entry.Value2))
 			} else {
-				fmt.Printf("  %s %s %s\n", yellow.Sprint("~"), entry.Key, ui.Dim(fmt.Sprintf("%s → %s", entry.Preview1, entry.Preview2)))
+				fmt.Printf("  %s %s %s%s\n", yellow.Sprint("~"), entry.Key, ui.Dim(fmt.Sprintf("%s → %s", entry.Preview1, entry.Preview2)), ownersSuffix(result, entry.Key))
 			}
 		}
 	}
@@ -411,6 +660,15 @@ func printDiffResults(result *DiffResult, env1, env2 string, showValues, keysOnl
 	}
 }
 
+// ownersSuffix returns the owners of a key to show after it, if it has some
+func ownersSuffix(result *DiffResult, key string) string {
+	owners := result.Owners[key]
+	if len(owners) == 0 {
+		return ""
+	}
+	return " " + ui.Dim(strings.Join(owners, ", "))
+}
+
 func printDiffJSON(result *DiffResult) error {
 	// Simple JSON output without external dependency
 	fmt.Println("{")
@@ -457,6 +715,30 @@ func printDiffJSON(result *DiffResult) error {
 	}
 	fmt.Println("],")
 
+	// Owners
+	if len(result.Owners) > 0 {
+		keys := make([]string, 0, len(result.Owners))
+		for k := range result.Owners {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Print("  \"owners\": {")
+		for i, k := range keys {
+			if i > 0 {
+				fmt.Print(", ")
+			}
+			fmt.Printf("%q: [", k)
+			for j, owner := range result.Owners[k] {
+				if j > 0 {
+					fmt.Print(", ")
+				}
+				fmt.Printf("%q", owner)
+			}
+			fmt.Print("]")
+		}
+		fmt.Println("},")
+	}
+
 	// Stats
 	fmt.Println("  \"stats\": {")
 	fmt.Printf("    \"totalEnv1\": %d,\n", result.Stats.TotalEnv1)
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/keywaysh/cli/internal/api"
@@ -812,3 +814,150 @@ func TestRunDiffWithDeps_InteractiveSecondSelectError(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestRunDiffWithDeps_AgainstVersion(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new"}
+	apiMock.PullAtResponse = &api.PullSecretsResponse{Content: "API_KEY=old"}
+
+	err := runDiffWithDeps(DiffOptions{Env1: "prod", Against: "version:42"}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if apiMock.PulledRevision.Version != 42 {
+		t.Errorf("expected revision 42, got %+v", apiMock.PulledRevision)
+	}
+}
+
+func TestRunDiffWithDeps_AgainstWithLocalFile(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env.production"] = []byte("API_KEY=local")
+	apiMock.PullAtResponse = &api.PullSecretsResponse{Content: "API_KEY=old"}
+	apiMock.PullError = errors.New("should not pull current state")
+
+	err := runDiffWithDeps(DiffOptions{Env1: "production", Against: "2024-06-01", File: ".env.production"}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if apiMock.PulledRevision.At.IsZero() {
+		t.Error("expected a date revision")
+	}
+	if len(uiMock.ErrorCalls) != 0 {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunDiffWithDeps_AgainstInvalidRevision(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runDiffWithDeps(DiffOptions{Env1: "production", Against: "yesterday"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for invalid revision")
+	}
+	if len(uiMock.ErrorCalls) == 0 {
+		t.Error("expected UI.Error to be called")
+	}
+}
+
+func TestRunDiffWithDeps_AgainstSnapshotNotFound(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullAtError = &api.APIError{StatusCode: 404, Detail: "Not found"}
+
+	err := runDiffWithDeps(DiffOptions{Env1: "production", Against: "version:1"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(uiMock.ErrorCalls) == 0 || uiMock.ErrorCalls[0] != "No snapshot of production found at version:1" {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunDiffWithDeps_FileWithTwoEnvironments(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	err := runDiffWithDeps(DiffOptions{Env1: "production", Env2: "staging", File: ".env"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error when --file is used with two environments")
+	}
+}
+
+func TestRunDiffWithDeps_FileAgainstVault(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env.production"] = []byte("API_KEY=local\nNEW=1\nSAME=x\nLOCAL_ONLY=debug\n")
+	fsMock.Files[".keyway.json"] = []byte(`{"exclude": ["LOCAL_ONLY"]}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=vault\nOLD=2\nSAME=x\n"}
+
+	output := captureStdout(t, func() {
+		if err := runDiffWithDeps(DiffOptions{Env1: "prod", File: ".env.production", JSONOutput: true}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var result DiffResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output, err)
+	}
+	if result.Env1 != "production" || result.Env2 != ".env.production" {
+		t.Errorf("unexpected labels: %s vs %s", result.Env1, result.Env2)
+	}
+	if !reflect.DeepEqual(result.OnlyInEnv1, []string{"OLD"}) || !reflect.DeepEqual(result.OnlyInEnv2, []string{"NEW"}) {
+		t.Errorf("unexpected keys, excluded ones must be left out: %+v", result)
+	}
+	if len(result.Different) != 1 || result.Different[0].Key != "API_KEY" || !reflect.DeepEqual(result.Same, []string{"SAME"}) {
+		t.Errorf("unexpected values: %+v", result)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("diff must not write to the vault")
+	}
+}
+
+func TestRunDiffWithDeps_ExitCodeOnDrift(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env"] = []byte("API_KEY=local\n")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=vault\n"}
+
+	err := runDiffWithDeps(DiffOptions{Env1: "production", File: ".env", ExitCode: true}, deps)
+
+	if ExitCode(err) != ExitDrift {
+		t.Errorf("expected exit code %d on drift, got %d (%v)", ExitDrift, ExitCode(err), err)
+	}
+
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=local\n"}
+	if err := runDiffWithDeps(DiffOptions{Env1: "production", File: ".env", ExitCode: true}, deps); err != nil {
+		t.Errorf("expected no error without drift, got %v", err)
+	}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=vault\n"}
+	if err := runDiffWithDeps(DiffOptions{Env1: "production", File: ".env"}, deps); err != nil {
+		t.Errorf("expected drift to succeed without --exit-code, got %v", err)
+	}
+}
+
+func TestRevealConfigValues(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{"config": ["LOG_LEVEL"]}`)
+	secrets1 := map[string]string{"LOG_LEVEL": "debug", "API_KEY": "sk_one"}
+	secrets2 := map[string]string{"LOG_LEVEL": "info", "API_KEY": "sk_two"}
+	result := compareSecrets("dev", "prod", secrets1, secrets2, false)
+
+	if err := revealConfigValues(result, secrets1, secrets2, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, entry := range result.Different {
+		switch entry.Key {
+		case "LOG_LEVEL":
+			if !entry.Config || entry.Preview1 != "debug" || entry.Preview2 != "info" {
+				t.Errorf("expected config values revealed, got %+v", entry)
+			}
+		case "API_KEY":
+			if entry.Config || entry.Preview1 == "sk_one" {
+				t.Errorf("expected secret to stay masked, got %+v", entry)
+			}
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/auth"
 	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/platform"
 	"github.com/keywaysh/cli/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +22,6 @@ var doctorCmd = &cobra.Command{
 }
 
 func init() {
-	doctorCmd.Flags().Bool("json", false, "Output results as JSON")
 	doctorCmd.Flags().Bool("strict", false, "Treat warnings as failures")
 }
 
@@ -90,6 +91,11 @@ func runDoctorWithDeps(opts DoctorOptions, deps *Dependencies) error {
 	gitignoreCheck := checkGitignoreWithDeps(deps)
 	checks = append(checks, gitignoreCheck)
 
+	// 7. Runtime check (WSL, devcontainers)
+	if info := platform.Detect(); info.Name() != "" {
+		checks = append(checks, checkRuntime(info, auth.NewStore().GetConfigPath()))
+	}
+
 	// Apply strict mode
 	if opts.Strict {
 		for i := range checks {
@@ -307,6 +313,33 @@ func checkGitignoreWithDeps(deps *Dependencies) checkResult {
 	}
 }
 
+func checkRuntime(info platform.Info, configPath string) checkResult {
+	if info.WSL && platform.IsWindowsMount(configPath) {
+		return checkResult{
+			ID:     "runtime",
+			Name:   "Runtime",
+			Status: "warn",
+			Detail: fmt.Sprintf("WSL with credentials on the Windows side (%s). Set KEYWAY_CONFIG_DIR to a path inside WSL", configPath),
+		}
+	}
+
+	if (info.Devcontainer || info.Codespaces) && config.GetConfigDir() == "" && config.GetToken() == "" {
+		return checkResult{
+			ID:     "runtime",
+			Name:   "Runtime",
+			Status: "pass",
+			Detail: fmt.Sprintf("%s (mount your host config and set KEYWAY_CONFIG_DIR to reuse its login)", info.Name()),
+		}
+	}
+
+	return checkResult{
+		ID:     "runtime",
+		Name:   "Runtime",
+		Status: "pass",
+		Detail: info.Name(),
+	}
+}
+
 func checkVersion(currentVersion string) checkResult {
 	ctx, cancel := context.WithTimeout(context.Background(), version.CheckTimeout)
 	defer cancel()
@@ -3,6 +3,8 @@ package cmd
 import (
 	"errors"
 	"testing"
+
+	"github.com/keywaysh/cli/internal/platform"
 )
 
 func TestCheckResult_Structure(t *testing.T) {
@@ -416,3 +418,28 @@ func TestCheckGitignoreWithDeps_EnvNotIgnored(t *testing.T) {
 		t.Errorf("expected warn status, got %q", result.Status)
 	}
 }
+
+func TestCheckRuntime(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       platform.Info
+		configPath string
+		status     string
+	}{
+		{"WSL with Windows-side config", platform.Info{WSL: true}, "/mnt/c/Users/me/keyway/config.json", "warn"},
+		{"WSL with Linux-side config", platform.Info{WSL: true}, "/home/me/.config/keyway-nodejs/config.json", "pass"},
+		{"devcontainer", platform.Info{Devcontainer: true}, "/root/.config/keyway-nodejs/config.json", "pass"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkRuntime(tt.info, tt.configPath)
+			if result.Status != tt.status {
+				t.Errorf("expected status %q, got %q (%s)", tt.status, result.Status, result.Detail)
+			}
+			if result.ID != "runtime" {
+				t.Errorf("expected id 'runtime', got %q", result.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Tell teammates you are editing an environment",
+	Long: `Announce that you are editing an environment locally, so that teammates
+about to push it know to wait. The hint is advisory: it blocks nothing and
+expires on its own after --for.
+
+Without flags, the environments being edited are listed. keyway push and
+keyway set warn when someone else is editing the environment they write.`,
+	Args: cobra.NoArgs,
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringP("env", "e", "development", "Environment being edited")
+	editCmd.Flags().Bool("announce", false, "Show teammates that you are editing the environment")
+	editCmd.Flags().Duration("for", 30*time.Minute, "How long the hint lasts")
+	editCmd.Flags().String("note", "", "What you are changing, shown with the hint")
+	editCmd.Flags().Bool("done", false, "Remove your hint on the environment")
+}
+
+// maxEditHintDuration bounds how long an edit hint lasts, so that a
+// forgotten one does not hold teammates back for a day
+const maxEditHintDuration = 4 * time.Hour
+
+// EditOptions contains the parsed flags for the edit command
+type EditOptions struct {
+	EnvName  string
+	Announce bool
+	Duration time.Duration
+	Note     string
+	Done     bool
+}
+
+// runEdit is the entry point for the edit command (uses default dependencies)
+func runEdit(cmd *cobra.Command, args []string) error {
+	opts := EditOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Announce, _ = cmd.Flags().GetBool("announce")
+	opts.Duration, _ = cmd.Flags().GetDuration("for")
+	opts.Note, _ = cmd.Flags().GetString("note")
+	opts.Done, _ = cmd.Flags().GetBool("done")
+
+	return runEditWithDeps(opts, defaultDeps)
+}
+
+// runEditWithDeps is the testable version of runEdit
+func runEditWithDeps(opts EditOptions, deps *Dependencies) error {
+	if opts.Announce && opts.Done {
+		deps.UI.Error("--announce and --done cannot be used together")
+		return fmt.Errorf("--announce and --done cannot be used together")
+	}
+	if opts.Done {
+		return runPipeline(deps, editDone, withIntro("edit"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+	}
+	if !opts.Announce {
+		return runPipeline(deps, listEdits, withIntro("edit"), withRepo, withLogin)
+	}
+
+	if opts.Duration <= 0 || opts.Duration > maxEditHintDuration {
+		deps.UI.Error(fmt.Sprintf("The duration must be between 1s and %s", maxEditHintDuration))
+		return fmt.Errorf("invalid duration %s", opts.Duration)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return announceEdit(s, opts)
+	}, withIntro("edit"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// announceEdit registers an edit hint, after showing who else is editing
+func announceEdit(s *Session, opts EditOptions) error {
+	deps := s.Deps
+	warnConcurrentEdits(s.Ctx, s.Client, s.Repo, s.EnvName, deps)
+
+	var hint *api.EditHint
+	err := s.Spin("Announcing edit...", func() error {
+		var err error
+		hint, err = s.Client.AnnounceEdit(s.Ctx, s.Repo, s.EnvName, opts.Duration, opts.Note)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("edit", err, deps)
+	}
+
+	until := hint.ExpiresAt
+	if t, err := time.Parse(time.RFC3339, hint.ExpiresAt); err == nil {
+		until = t.Local().Format("15:04")
+	}
+	deps.UI.Success(fmt.Sprintf("Teammates see that you are editing %s until %s", s.EnvName, until))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Once pushed, remove the hint with: keyway edit --done -e %s", s.EnvName)))
+	return nil
+}
+
+// editDone removes the edit hint of the current user on an environment
+func editDone(s *Session) error {
+	deps := s.Deps
+	err := s.Spin("Removing edit hint...", func() error {
+		return s.Client.WithdrawEdit(s.Ctx, s.Repo, s.EnvName)
+	})
+	if err != nil {
+		if apiErr, isAPIErr := err.(*api.APIError); !isAPIErr || apiErr.StatusCode != 404 {
+			return reportEnvError("edit", err, deps)
+		}
+		// Already expired on the server
+	}
+	deps.UI.Success(fmt.Sprintf("No longer editing %s", s.EnvName))
+	return nil
+}
+
+// listEdits shows the environments being edited
+func listEdits(s *Session) error {
+	deps := s.Deps
+	var hints []api.EditHint
+	err := s.Spin("Fetching edit hints...", func() error {
+		var err error
+		hints, err = s.Client.ListEditHints(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("edit", err, deps)
+	}
+	hints = activeEditHints(hints, time.Now())
+	if len(hints) == 0 {
+		deps.UI.Info("Nobody is editing an environment")
+		return nil
+	}
+
+	sort.Slice(hints, func(i, j int) bool {
+		if hints[i].Environment != hints[j].Environment {
+			return hints[i].Environment < hints[j].Environment
+		}
+		return hints[i].Username < hints[j].Username
+	})
+	for _, h := range hints {
+		deps.UI.Step(describeEditHint(h, deps))
+	}
+	return nil
+}
+
+// warnConcurrentEdits warns about the teammates editing an environment.
+// Failures to read the hints are ignored, they are only advisory.
+func warnConcurrentEdits(ctx context.Context, client api.APIClient, repo, envName string, deps *Dependencies) {
+	hints, err := client.ListEditHints(ctx, repo)
+	if err != nil {
+		return
+	}
+	for _, h := range activeEditHints(hints, time.Now()) {
+		if h.Environment == envName && !h.Mine {
+			deps.UI.Warn(describeEditHint(h, deps))
+		}
+	}
+}
+
+// activeEditHints drops the hints expired since the server listed them
+func activeEditHints(hints []api.EditHint, now time.Time) []api.EditHint {
+	var active []api.EditHint
+	for _, h := range hints {
+		if t, err := time.Parse(time.RFC3339, h.ExpiresAt); err == nil && !t.After(now) {
+			continue
+		}
+		active = append(active, h)
+	}
+	return active
+}
+
+// describeEditHint returns e.g. "alice is editing production (rotating keys, until 12:30)"
+func describeEditHint(h api.EditHint, deps *Dependencies) string {
+	who := h.Username
+	if h.Mine {
+		who = "You are"
+	} else {
+		who += " is"
+	}
+	line := fmt.Sprintf("%s editing %s", who, h.Environment)
+
+	var details []string
+	if h.Note != "" {
+		details = append(details, h.Note)
+	}
+	if t, err := time.Parse(time.RFC3339, h.ExpiresAt); err == nil {
+		details = append(details, "until "+t.Local().Format("15:04"))
+	}
+	if len(details) > 0 {
+		line += " " + deps.UI.Dim("("+strings.Join(details, ", ")+")")
+	}
+	return line
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunEditWithDeps_Announce(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	err := runEditWithDeps(EditOptions{EnvName: "production", Announce: true, Duration: 15 * time.Minute, Note: "rotating Stripe keys"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.AnnouncedEdits) != 1 || apiMock.AnnouncedEdits[0].Environment != "production" || apiMock.AnnouncedEdits[0].Note != "rotating Stripe keys" {
+		t.Errorf("unexpected announcements: %+v", apiMock.AnnouncedEdits)
+	}
+	if len(uiMock.SuccessCalls) != 1 || !strings.Contains(uiMock.SuccessCalls[0], "editing production") {
+		t.Errorf("expected a success message, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunEditWithDeps_AnnounceWarnsAboutTeammates(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.EditHints = []api.EditHint{
+		{Environment: "production", Username: "alice", ExpiresAt: time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339)},
+		{Environment: "staging", Username: "bob"},
+	}
+
+	if err := runEditWithDeps(EditOptions{EnvName: "production", Announce: true, Duration: time.Minute}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.Contains(uiMock.WarnCalls[0], "alice is editing production") {
+		t.Errorf("expected a warning about alice only, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunEditWithDeps_RejectsLongDuration(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	if err := runEditWithDeps(EditOptions{EnvName: "production", Announce: true, Duration: 24 * time.Hour}, deps); err == nil {
+		t.Fatal("expected an error for a duration over the maximum")
+	}
+	if len(apiMock.AnnouncedEdits) != 0 {
+		t.Errorf("expected no announcement, got %+v", apiMock.AnnouncedEdits)
+	}
+}
+
+func TestRunEditWithDeps_AnnounceAndDoneConflict(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runEditWithDeps(EditOptions{EnvName: "production", Announce: true, Done: true, Duration: time.Minute}, deps); err == nil {
+		t.Fatal("expected an error for --announce with --done")
+	}
+}
+
+func TestRunEditWithDeps_Done(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	if err := runEditWithDeps(EditOptions{EnvName: "production", Done: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.WithdrawnEdits) != 1 || apiMock.WithdrawnEdits[0] != "production" {
+		t.Errorf("expected the hint on production to be withdrawn, got %v", apiMock.WithdrawnEdits)
+	}
+	if len(uiMock.SuccessCalls) != 1 {
+		t.Errorf("expected a success message, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunEditWithDeps_DoneAlreadyExpired(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.EditHintsError = &api.APIError{StatusCode: 404, Detail: "No edit hint"}
+
+	if err := runEditWithDeps(EditOptions{EnvName: "production", Done: true}, deps); err != nil {
+		t.Fatalf("expected an expired hint to be ignored, got %v", err)
+	}
+}
+
+func TestRunEditWithDeps_List(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.EditHints = []api.EditHint{
+		{Environment: "staging", Username: "bob", Note: "new queue"},
+		{Environment: "production", Username: "alice"},
+		{Environment: "production", Username: "carol", ExpiresAt: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)},
+		{Environment: "development", Username: "testuser", Mine: true},
+	}
+
+	if err := runEditWithDeps(EditOptions{EnvName: "development"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var listed []string
+	for _, step := range uiMock.StepCalls {
+		if strings.Contains(step, "editing") {
+			listed = append(listed, step)
+		}
+	}
+	want := []string{"You are editing development", "alice is editing production", "bob is editing staging (new queue)"}
+	if len(listed) != len(want) {
+		t.Fatalf("expected %d hints, got %v", len(want), listed)
+	}
+	for i, w := range want {
+		if !strings.Contains(listed[i], w) {
+			t.Errorf("hint %d = %q, want it to contain %q", i, listed[i], w)
+		}
+	}
+}
+
+func TestRunEditWithDeps_ListEmpty(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runEditWithDeps(EditOptions{EnvName: "development"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.InfoCalls) != 1 || !strings.Contains(uiMock.InfoCalls[0], "Nobody") {
+		t.Errorf("expected an info message, got %v", uiMock.InfoCalls)
+	}
+}
+
+func TestRunSetWithDeps_WarnsAboutConcurrentEdit(t *testing.T) {
+	deps, _, _, uiMock, _, _, apiMock := NewTestDepsWithEnv()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secret saved"}
+	apiMock.EditHints = []api.EditHint{{Environment: "production", Username: "alice", Note: "rotating keys"}}
+
+	err := runSetWithDeps(SetOptions{Key: "API_KEY", Value: "secret123", EnvName: "production", EnvFlagSet: true, Yes: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) == 0 || !strings.Contains(strings.Join(uiMock.WarnCalls, "\n"), "alice is editing production") {
+		t.Errorf("expected a warning about alice, got %v", uiMock.WarnCalls)
+	}
+}
@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:     "env",
+	Aliases: []string{"envs"},
+	Short:   "Manage vault environments",
+	Long:    `Manage the environments of the vault for the current repository.`,
+}
+
+var envFreezeCmd = &cobra.Command{
+	Use:   "freeze <environment>",
+	Short: "Reject pushes to an environment",
+	Long:  `Freeze an environment so that pushes and sets are rejected until it is unfrozen.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvFreeze,
+}
+
+var envUnfreezeCmd = &cobra.Command{
+	Use:   "unfreeze <environment>",
+	Short: "Allow pushes to a frozen environment again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvUnfreeze,
+}
+
+var envProtectCmd = &cobra.Command{
+	Use:   "protect <environment>",
+	Short: "Show or set the rules pushes to an environment must satisfy",
+	Long: `Show or set the protection rules of an environment. Without flags, the
+current rules are shown. Setting rules replaces all of them.
+
+Rules:
+  --reviewers           GitHub users who can approve pushes
+  --required-reviewers  Number of approvals a push needs (default 1 with --reviewers)
+  --allow-token         Names of the API keys allowed to push (any if unset)
+  --allow-cidr          IP ranges pushes must come from (anywhere if unset)
+  --require-webauthn    Confirm each push with Touch ID, Windows Hello or a
+                        security key, in the browser
+
+A push blocked by --require-webauthn opens the confirmation in the browser and
+goes through once it is done. API keys cannot confirm, CI pushes are refused.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvProtect,
+}
+
+func init() {
+	envFreezeCmd.Flags().String("reason", "", "Why the environment is frozen (shown to anyone who tries to push)")
+	envFreezeCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	envUnfreezeCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	envProtectCmd.Flags().StringSlice("reviewers", nil, "GitHub users who can approve pushes")
+	envProtectCmd.Flags().Int("required-reviewers", 0, "Number of approvals a push needs")
+	envProtectCmd.Flags().StringSlice("allow-token", nil, "API key names allowed to push")
+	envProtectCmd.Flags().StringSlice("allow-cidr", nil, "IP ranges (CIDR) pushes must come from")
+	envProtectCmd.Flags().Bool("require-webauthn", false, "Require a WebAuthn confirmation (Touch ID, security key) for each push")
+	envProtectCmd.Flags().Bool("clear", false, "Remove all protection rules")
+	envProtectCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	envCmd.AddCommand(envFreezeCmd)
+	envCmd.AddCommand(envUnfreezeCmd)
+	envCmd.AddCommand(envProtectCmd)
+}
+
+// EnvFreezeOptions contains the parsed flags for the env freeze/unfreeze commands
+type EnvFreezeOptions struct {
+	EnvName string
+	Reason  string
+	Yes     bool
+}
+
+// runEnvFreeze is the entry point for the env freeze command (uses default dependencies)
+func runEnvFreeze(cmd *cobra.Command, args []string) error {
+	opts := EnvFreezeOptions{EnvName: args[0]}
+	opts.Reason, _ = cmd.Flags().GetString("reason")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvFreezeWithDeps(opts, defaultDeps)
+}
+
+// runEnvUnfreeze is the entry point for the env unfreeze command (uses default dependencies)
+func runEnvUnfreeze(cmd *cobra.Command, args []string) error {
+	opts := EnvFreezeOptions{EnvName: args[0]}
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvUnfreezeWithDeps(opts, defaultDeps)
+}
+
+// runEnvFreezeWithDeps is the testable version of runEnvFreeze
+func runEnvFreezeWithDeps(opts EnvFreezeOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envFreeze(s, opts)
+	}, withIntro("env freeze"), withRepo, withEnvironment(opts.EnvName), withLogin)
+}
+
+// envFreeze freezes an environment after confirmation
+func envFreeze(s *Session, opts EnvFreezeOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionMaintain, "freezing an environment", deps); err != nil {
+		return err
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Freeze %s? Pushes will be rejected until it is unfrozen.", s.EnvName), true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	var freeze *api.EnvironmentFreeze
+	err := s.Spin("Freezing environment...", func() error {
+		var err error
+		freeze, err = s.Client.FreezeEnvironment(s.Ctx, s.Repo, s.EnvName, opts.Reason)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env freeze", err, deps)
+	}
+
+	deps.UI.Success(fmt.Sprintf("%s is frozen", s.EnvName))
+	if freeze != nil && freeze.Reason != "" {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Reason: %s", freeze.Reason)))
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Unfreeze with: keyway env unfreeze %s", s.EnvName)))
+	return nil
+}
+
+// runEnvUnfreezeWithDeps is the testable version of runEnvUnfreeze
+func runEnvUnfreezeWithDeps(opts EnvFreezeOptions, deps *Dependencies) error {
+	return runPipeline(deps, envUnfreeze, withIntro("env unfreeze"), withRepo, withEnvironment(opts.EnvName), withLogin)
+}
+
+// envUnfreeze lifts the freeze of an environment
+func envUnfreeze(s *Session) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionMaintain, "unfreezing an environment", deps); err != nil {
+		return err
+	}
+
+	err := s.Spin("Unfreezing environment...", func() error {
+		return s.Client.UnfreezeEnvironment(s.Ctx, s.Repo, s.EnvName)
+	})
+	if err != nil {
+		return reportEnvError("env unfreeze", err, deps)
+	}
+
+	deps.UI.Success(fmt.Sprintf("%s is no longer frozen", s.EnvName))
+	return nil
+}
+
+// EnvProtectOptions contains the parsed flags for the env protect command
+type EnvProtectOptions struct {
+	EnvName string
+	Rules   api.EnvironmentProtection
+	// Set is true when any rule flag was given, otherwise the rules are shown
+	Set   bool
+	Clear bool
+	Yes   bool
+}
+
+// runEnvProtect is the entry point for the env protect command (uses default dependencies)
+func runEnvProtect(cmd *cobra.Command, args []string) error {
+	opts := EnvProtectOptions{EnvName: args[0]}
+	opts.Rules.Reviewers, _ = cmd.Flags().GetStringSlice("reviewers")
+	opts.Rules.RequiredReviewers, _ = cmd.Flags().GetInt("required-reviewers")
+	opts.Rules.AllowedTokens, _ = cmd.Flags().GetStringSlice("allow-token")
+	opts.Rules.AllowedCIDRs, _ = cmd.Flags().GetStringSlice("allow-cidr")
+	opts.Rules.RequireWebAuthn, _ = cmd.Flags().GetBool("require-webauthn")
+	opts.Clear, _ = cmd.Flags().GetBool("clear")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+	for _, name := range []string{"reviewers", "required-reviewers", "allow-token", "allow-cidr", "require-webauthn"} {
+		opts.Set = opts.Set || cmd.Flags().Changed(name)
+	}
+
+	return runEnvProtectWithDeps(opts, defaultDeps)
+}
+
+// runEnvProtectWithDeps is the testable version of runEnvProtect
+func runEnvProtectWithDeps(opts EnvProtectOptions, deps *Dependencies) error {
+	deps.UI.Intro("env protect")
+
+	if opts.Set && opts.Clear {
+		deps.UI.Error("--clear cannot be combined with rules")
+		return fmt.Errorf("--clear cannot be combined with rules")
+	}
+	rules := opts.Rules
+	if opts.Set {
+		if err := validateProtection(&rules); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return envProtect(s, opts, rules)
+	}, withRepo, withEnvironment(opts.EnvName), withLogin)
+}
+
+// envProtect shows the protection rules of an environment, or replaces them
+func envProtect(s *Session, opts EnvProtectOptions, rules api.EnvironmentProtection) error {
+	deps := s.Deps
+	if !opts.Set && !opts.Clear {
+		var current *api.EnvironmentProtection
+		err := s.Spin("Fetching protection rules...", func() error {
+			var err error
+			current, err = s.Client.GetEnvironmentProtection(s.Ctx, s.Repo, s.EnvName)
+			return err
+		})
+		if err != nil {
+			return reportEnvError("env protect", err, deps)
+		}
+		if current == nil || current.IsEmpty() {
+			deps.UI.Info(fmt.Sprintf("%s has no protection rules", s.EnvName))
+			return nil
+		}
+		printProtection(current, deps)
+		return nil
+	}
+
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionAdmin, "changing protection rules", deps); err != nil {
+		return err
+	}
+
+	if opts.Clear {
+		rules = api.EnvironmentProtection{}
+	} else {
+		printProtection(&rules, deps)
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		question := fmt.Sprintf("Replace the protection rules of %s?", s.EnvName)
+		if opts.Clear {
+			question = fmt.Sprintf("Remove all protection rules from %s?", s.EnvName)
+		}
+		confirm, _ := deps.UI.Confirm(question, true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err := s.Spin("Saving protection rules...", func() error {
+		_, err := s.Client.SetEnvironmentProtection(s.Ctx, s.Repo, s.EnvName, rules)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env protect", err, deps)
+	}
+
+	if opts.Clear {
+		deps.UI.Success(fmt.Sprintf("%s is no longer protected", s.EnvName))
+	} else {
+		deps.UI.Success(fmt.Sprintf("%s is protected", s.EnvName))
+	}
+	return nil
+}
+
+// validateProtection checks rules given on the command line and fills in
+// one required reviewer when only reviewers are listed
+func validateProtection(rules *api.EnvironmentProtection) error {
+	if rules.RequiredReviewers < 0 {
+		return fmt.Errorf("--required-reviewers cannot be negative")
+	}
+	if rules.RequiredReviewers == 0 && len(rules.Reviewers) > 0 {
+		rules.RequiredReviewers = 1
+	}
+	if rules.RequiredReviewers > 0 && len(rules.Reviewers) < rules.RequiredReviewers {
+		return fmt.Errorf("%d approvals required but only %d reviewers listed", rules.RequiredReviewers, len(rules.Reviewers))
+	}
+	for _, cidr := range rules.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR range %q (e.g. 10.0.0.0/8)", cidr)
+		}
+	}
+	return nil
+}
+
+// printProtection shows protection rules, one per line
+func printProtection(rules *api.EnvironmentProtection, deps *Dependencies) {
+	if rules.RequiredReviewers > 0 {
+		deps.UI.Step(fmt.Sprintf("Required reviewers: %s of %s", deps.UI.Value(rules.RequiredReviewers), strings.Join(rules.Reviewers, ", ")))
+	}
+	if len(rules.AllowedTokens) > 0 {
+		deps.UI.Step(fmt.Sprintf("Allowed tokens: %s", strings.Join(rules.AllowedTokens, ", ")))
+	}
+	if len(rules.AllowedCIDRs) > 0 {
+		deps.UI.Step(fmt.Sprintf("Allowed IP ranges: %s", strings.Join(rules.AllowedCIDRs, ", ")))
+	}
+	if rules.RequireWebAuthn {
+		deps.UI.Step("WebAuthn confirmation: each push (Touch ID, Windows Hello, security key)")
+	}
+}
+
+// explainProtectionViolation tells which protection rule blocked a push, and
+// what would let it through
+func explainProtectionViolation(err error, deps *Dependencies) {
+	apiErr, ok := err.(*api.APIError)
+	if !ok || apiErr.Protection == nil {
+		return
+	}
+	v := apiErr.Protection
+	switch v.Rule {
+	case api.RuleRequiredReviewers:
+		msg := fmt.Sprintf("Blocked by rule: required reviewers (%d approval(s) needed", v.Required)
+		if len(v.Reviewers) > 0 {
+			msg += " from " + strings.Join(v.Reviewers, ", ")
+		}
+		deps.UI.Message(msg + ")")
+		if v.ApprovalURL != "" {
+			deps.UI.Message(fmt.Sprintf("Approval requested: %s", deps.UI.Link(v.ApprovalURL)))
+		}
+	case api.RuleKeyOwners:
+		msg := "Blocked by rule: key owners"
+		if len(v.Keys) > 0 {
+			msg += fmt.Sprintf(" (%s need approval", strings.Join(v.Keys, ", "))
+			if len(v.Reviewers) > 0 {
+				msg += " from " + strings.Join(v.Reviewers, ", ")
+			}
+			msg += ")"
+		}
+		deps.UI.Message(msg)
+		if v.ApprovalURL != "" {
+			deps.UI.Message(fmt.Sprintf("Approval requested: %s", deps.UI.Link(v.ApprovalURL)))
+		}
+	case api.RuleAllowedTokens:
+		token := v.Token
+		if token == "" {
+			token = "your login"
+		}
+		deps.UI.Message(fmt.Sprintf("Blocked by rule: allowed tokens (%s is not one of %s)", token, strings.Join(v.Allowed, ", ")))
+	case api.RuleAllowedCIDRs:
+		ip := v.ClientIP
+		if ip == "" {
+			ip = "your IP"
+		}
+		deps.UI.Message(fmt.Sprintf("Blocked by rule: allowed IP ranges (%s is not in %s)", ip, strings.Join(v.Allowed, ", ")))
+	case api.RuleWebAuthn:
+		deps.UI.Message("Blocked by rule: WebAuthn confirmation (push from an interactive terminal to confirm with Touch ID, Windows Hello or a security key)")
+	default:
+		deps.UI.Message(fmt.Sprintf("Blocked by rule: %s", v.Rule))
+	}
+}
+
+// reportEnvError displays an API error from an env subcommand and tracks it
+func reportEnvError(command string, err error, deps *Dependencies) error {
+	analytics.Track(analytics.EventError, map[string]interface{}{
+		"command": command,
+		"error":   err.Error(),
+	})
+	deps.UI.Error(err.Error())
+	explainProtectionViolation(err, deps)
+	if apiErr, ok := err.(*api.APIError); ok && apiErr.UpgradeURL != "" {
+		deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
+	}
+	return err
+}
+
+// checkEnvironmentNotFrozen returns an error if the environment is frozen.
+// Failures to read the freeze state are ignored: the server enforces the
+// freeze anyway, this check only fails early with a readable message.
+func checkEnvironmentNotFrozen(ctx context.Context, client api.APIClient, repo, envName string, deps *Dependencies) error {
+	freeze, err := client.GetEnvironmentFreeze(ctx, repo, envName)
+	if err != nil || freeze == nil || !freeze.Frozen {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s is frozen", envName)
+	if freeze.Reason != "" {
+		msg += ": " + freeze.Reason
+	}
+	if freeze.FrozenBy != "" {
+		msg += fmt.Sprintf(" (by %s)", freeze.FrozenBy)
+	}
+	deps.UI.Error(msg)
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Unfreeze with: keyway env unfreeze %s", envName)))
+	return fmt.Errorf("environment %s is frozen", envName)
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunEnvFreezeWithDeps_Success(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	err := runEnvFreezeWithDeps(EnvFreezeOptions{EnvName: "production", Reason: "release week", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.FreezeReason != "release week" {
+		t.Errorf("expected reason 'release week', got %q", apiMock.FreezeReason)
+	}
+	if len(uiMock.SuccessCalls) == 0 {
+		t.Error("expected Success to be called")
+	}
+}
+
+func TestRunEnvFreezeWithDeps_RequiresConfirmation(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	err := runEnvFreezeWithDeps(EnvFreezeOptions{EnvName: "production"}, deps)
+
+	if err == nil {
+		t.Fatal("expected confirmation error")
+	}
+	if apiMock.FreezeReason != "" {
+		t.Error("expected FreezeEnvironment not to be called")
+	}
+}
+
+func TestRunEnvFreezeWithDeps_APIError(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.FreezeError = &api.APIError{StatusCode: 403, Detail: "Only admins can freeze environments"}
+
+	err := runEnvFreezeWithDeps(EnvFreezeOptions{EnvName: "production", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(uiMock.ErrorCalls) == 0 || uiMock.ErrorCalls[0] != "Only admins can freeze environments" {
+		t.Errorf("expected API error to be displayed, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunEnvUnfreezeWithDeps_Success(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	err := runEnvUnfreezeWithDeps(EnvFreezeOptions{EnvName: "production"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !apiMock.Unfrozen {
+		t.Error("expected UnfreezeEnvironment to be called")
+	}
+	if len(uiMock.SuccessCalls) == 0 {
+		t.Error("expected Success to be called")
+	}
+}
+
+func TestRunPushWithDeps_FrozenEnvironment(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=secret123")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.Freeze = &api.EnvironmentFreeze{Frozen: true, Reason: "release week", FrozenBy: "alice"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for frozen environment")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected PushSecrets not to be called")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "release week (by alice)") {
+		t.Errorf("expected freeze reason in error, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPushWithDeps_FreezeCheckFailureIgnored(t *testing.T) {
+	deps, _, _, _, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=secret123")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "development"}}
+	apiMock.FreezeError = errors.New("not found")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets == nil {
+		t.Error("expected PushSecrets to be called")
+	}
+}
+
+func TestRunEnvProtectWithDeps_SetRules(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionAdmin}
+
+	opts := EnvProtectOptions{
+		EnvName: "production",
+		Rules:   api.EnvironmentProtection{Reviewers: []string{"alice", "bob"}, AllowedCIDRs: []string{"10.0.0.0/8"}},
+		Set:     true,
+		Yes:     true,
+	}
+	err := runEnvProtectWithDeps(opts, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.SetProtection == nil {
+		t.Fatal("expected SetEnvironmentProtection to be called")
+	}
+	if apiMock.SetProtection.RequiredReviewers != 1 {
+		t.Errorf("expected 1 required reviewer by default, got %d", apiMock.SetProtection.RequiredReviewers)
+	}
+	if len(uiMock.SuccessCalls) == 0 {
+		t.Error("expected Success to be called")
+	}
+}
+
+func TestRunEnvProtectWithDeps_InvalidCIDR(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	opts := EnvProtectOptions{
+		EnvName: "production",
+		Rules:   api.EnvironmentProtection{AllowedCIDRs: []string{"10.0.0.0"}},
+		Set:     true,
+		Yes:     true,
+	}
+	err := runEnvProtectWithDeps(opts, deps)
+
+	if err == nil || !strings.Contains(err.Error(), "invalid CIDR") {
+		t.Fatalf("expected invalid CIDR error, got %v", err)
+	}
+	if apiMock.SetProtection != nil {
+		t.Error("expected SetEnvironmentProtection not to be called")
+	}
+}
+
+func TestRunEnvProtectWithDeps_RequiresAdmin(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionWrite}
+
+	err := runEnvProtectWithDeps(EnvProtectOptions{EnvName: "production", Clear: true, Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected permission error")
+	}
+	if apiMock.SetProtection != nil {
+		t.Error("expected SetEnvironmentProtection not to be called")
+	}
+}
+
+func TestRunEnvProtectWithDeps_ShowRules(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Protection = &api.EnvironmentProtection{AllowedTokens: []string{"deploy-bot"}}
+
+	err := runEnvProtectWithDeps(EnvProtectOptions{EnvName: "production"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.SetProtection != nil {
+		t.Error("expected rules not to be changed")
+	}
+	found := false
+	for _, step := range uiMock.StepCalls {
+		if strings.Contains(step, "deploy-bot") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected allowed token to be shown, got %v", uiMock.StepCalls)
+	}
+}
+
+func TestRunPushWithDeps_ProtectionViolation(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=secret123")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushError = &api.APIError{
+		StatusCode: 403,
+		Detail:     "Push blocked by environment protection",
+		Protection: &api.ProtectionViolation{Rule: api.RuleAllowedCIDRs, ClientIP: "203.0.113.7", Allowed: []string{"10.0.0.0/8"}},
+	}
+
+	err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	found := false
+	for _, msg := range uiMock.MessageCalls {
+		if strings.Contains(msg, "allowed IP ranges (203.0.113.7 is not in 10.0.0.0/8)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected blocking rule to be explained, got %v", uiMock.MessageCalls)
+	}
+}
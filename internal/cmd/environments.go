@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"text/template"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the environments of the vault",
+	Long: `List the environments of the vault, with their number of secrets and when
+they last changed.`,
+	Args: cobra.NoArgs,
+	RunE: runEnvList,
+}
+
+var envDeleteCmd = &cobra.Command{
+	Use:   "delete <environment>",
+	Short: "Delete an environment and its secrets",
+	Long: `Delete an environment of the vault, with all its secrets. Repository admins only.
+
+Frozen environments cannot be deleted, unfreeze them first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvDelete,
+}
+
+var envRenameCmd = &cobra.Command{
+	Use:   "rename <environment> <new-name>",
+	Short: "Rename an environment",
+	Long: `Rename an environment of the vault. Its secrets, history, snapshots and
+protection rules follow it. Repository admins only.
+
+Scripts, CI jobs and .keyway.json rules using the old name must be updated.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEnvRename,
+}
+
+func init() {
+	addTemplateFlag(envListCmd)
+	envDeleteCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	envRenameCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envDeleteCmd)
+	envCmd.AddCommand(envRenameCmd)
+}
+
+// envNamePattern is what environment names look like, once normalized
+var envNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// validateEnvName returns an error if name cannot name an environment
+func validateEnvName(name string) error {
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid environment name %q: use lowercase letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// EnvListOptions contains the parsed flags for the env list command
+type EnvListOptions struct {
+	JSONOutput bool
+	Template   string
+}
+
+// EnvDeleteOptions contains the parsed flags for the env delete and rename commands
+type EnvDeleteOptions struct {
+	EnvName string
+	NewName string
+	Yes     bool
+}
+
+// envListView is what --template gets from keyway env list
+type envListView struct {
+	Repository   string            `json:"repository"`
+	Environments []api.Environment `json:"environments"`
+}
+
+// runEnvList is the entry point for the env list command (uses default dependencies)
+func runEnvList(cmd *cobra.Command, args []string) error {
+	opts := EnvListOptions{}
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runEnvListWithDeps(opts, deps)
+}
+
+// runEnvListWithDeps is the testable version of runEnvList
+func runEnvListWithDeps(opts EnvListOptions, deps *Dependencies) error {
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return envList(s, opts, tmpl)
+	}, withIntro("env list"), withRepo, withLogin)
+}
+
+// envList shows the environments of the vault
+func envList(s *Session, opts EnvListOptions, tmpl *template.Template) error {
+	deps := s.Deps
+	var envs []api.Environment
+	err := s.Spin("Fetching environments...", func() error {
+		var err error
+		envs, err = s.Client.ListEnvironments(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env list", err, deps)
+	}
+	if envs == nil {
+		envs = []api.Environment{}
+	}
+
+	if tmpl != nil {
+		return printTemplate(tmpl, envListView{Repository: s.Repo, Environments: envs}, deps)
+	}
+	if opts.JSONOutput {
+		output, err := json.MarshalIndent(envs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(envs) == 0 {
+		deps.UI.Info("The vault has no environments yet")
+		deps.UI.Message(deps.UI.Dim("Create one with: keyway env create <environment>"))
+		return nil
+	}
+
+	rows := make([][]string, 0, len(envs))
+	for _, e := range envs {
+		updated := ""
+		if e.UpdatedAt != "" {
+			updated = formatEventTime(e.UpdatedAt)
+		}
+		rows = append(rows, []string{e.Name, fmt.Sprintf("%d", e.SecretCount), updated, e.UpdatedBy})
+	}
+	lines := formatTable(append([][]string{{"ENVIRONMENT", "SECRETS", "UPDATED", "BY"}}, rows...))
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(lines[0]))
+	for _, line := range lines[1:] {
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+	deps.UI.Outro(fmt.Sprintf("%d environments", len(envs)))
+	return nil
+}
+
+// runEnvDelete is the entry point for the env delete command (uses default dependencies)
+func runEnvDelete(cmd *cobra.Command, args []string) error {
+	opts := EnvDeleteOptions{EnvName: args[0]}
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvDeleteWithDeps(opts, defaultDeps)
+}
+
+// runEnvDeleteWithDeps is the testable version of runEnvDelete
+func runEnvDeleteWithDeps(opts EnvDeleteOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envDelete(s, opts)
+	}, withIntro("env delete"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// envDelete deletes an environment once confirmed
+func envDelete(s *Session, opts EnvDeleteOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionAdmin, "deleting an environment", deps); err != nil {
+		return err
+	}
+	existing, err := requireEnvironment(s, s.EnvName, "env delete")
+	if err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, s.EnvName, deps); err != nil {
+		return err
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		deps.UI.Warn(fmt.Sprintf("%s and its %d secrets will be deleted, this cannot be undone", s.EnvName, existing.SecretCount))
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Delete %s?", s.EnvName), false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err = s.Spin("Deleting environment...", func() error {
+		return s.Client.DeleteEnvironment(s.Ctx, s.Repo, s.EnvName)
+	})
+	if err != nil {
+		return reportEnvError("env delete", err, deps)
+	}
+	forgetPrefetched(s.Repo, s.EnvName, deps)
+
+	analytics.Track("cli_env_delete", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"secretCount":  existing.SecretCount,
+	})
+	deps.UI.Success(fmt.Sprintf("Deleted %s", s.EnvName))
+	return nil
+}
+
+// runEnvRename is the entry point for the env rename command (uses default dependencies)
+func runEnvRename(cmd *cobra.Command, args []string) error {
+	opts := EnvDeleteOptions{EnvName: args[0], NewName: args[1]}
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvRenameWithDeps(opts, defaultDeps)
+}
+
+// runEnvRenameWithDeps is the testable version of runEnvRename
+func runEnvRenameWithDeps(opts EnvDeleteOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envRename(s, normalizeEnvName(opts.NewName), opts.Yes)
+	}, withIntro("env rename"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// envRename renames an environment once confirmed
+func envRename(s *Session, newName string, yes bool) error {
+	deps := s.Deps
+	if err := validateEnvName(newName); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if newName == s.EnvName {
+		deps.UI.Error(fmt.Sprintf("%s already has that name", s.EnvName))
+		return fmt.Errorf("same name")
+	}
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionAdmin, "renaming an environment", deps); err != nil {
+		return err
+	}
+	for _, name := range []string{s.EnvName, newName} {
+		if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, name, deps); err != nil {
+			return err
+		}
+	}
+	if _, err := requireEnvironment(s, s.EnvName, "env rename"); err != nil {
+		return err
+	}
+	if _, found, err := findEnvironment(s, newName, "env rename"); err != nil {
+		return err
+	} else if found {
+		err := fmt.Errorf("%s already exists", newName)
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, s.EnvName, deps); err != nil {
+		return err
+	}
+
+	if !yes && deps.UI.IsInteractive() {
+		deps.UI.Warn(fmt.Sprintf("Scripts, CI jobs and %s rules using %s must be updated", ".keyway.json", s.EnvName))
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Rename %s to %s?", s.EnvName, newName), false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err := s.Spin("Renaming environment...", func() error {
+		return s.Client.RenameEnvironment(s.Ctx, s.Repo, s.EnvName, newName)
+	})
+	if err != nil {
+		return reportEnvError("env rename", err, deps)
+	}
+	forgetPrefetched(s.Repo, s.EnvName, deps)
+
+	analytics.Track("cli_env_rename", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+	})
+	deps.UI.Success(fmt.Sprintf("Renamed %s to %s", s.EnvName, newName))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Use it with: keyway pull -e %s", newName)))
+	return nil
+}
+
+// findEnvironment returns an environment of the vault, and whether it exists
+func findEnvironment(s *Session, name, command string) (api.Environment, bool, error) {
+	var envs []api.Environment
+	err := s.Spin("Fetching environments...", func() error {
+		var err error
+		envs, err = s.Client.ListEnvironments(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return api.Environment{}, false, reportEnvError(command, err, s.Deps)
+	}
+	for _, e := range envs {
+		if e.Name == name {
+			return e, true, nil
+		}
+	}
+	return api.Environment{}, false, nil
+}
+
+// requireEnvironment returns an environment of the vault, reporting an unknown one
+func requireEnvironment(s *Session, name, command string) (api.Environment, error) {
+	e, found, err := findEnvironment(s, name, command)
+	if err != nil {
+		return e, err
+	}
+	if !found {
+		err := fmt.Errorf("no environment named %s", name)
+		s.Deps.UI.Error(fmt.Sprintf("No environment named %s", name))
+		s.Deps.UI.Message(s.Deps.UI.Dim("List them with: keyway env list"))
+		return e, err
+	}
+	return e, nil
+}
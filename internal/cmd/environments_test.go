@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunEnvListWithDeps_JSON(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Environments = []api.Environment{
+		{Name: "development", SecretCount: 12},
+		{Name: "production", SecretCount: 9, UpdatedBy: "alice"},
+	}
+
+	output := captureStdout(t, func() {
+		if err := runEnvListWithDeps(EnvListOptions{JSONOutput: true}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var envs []api.Environment
+	if err := json.Unmarshal([]byte(output), &envs); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	if len(envs) != 2 || envs[1].Name != "production" || envs[1].SecretCount != 9 {
+		t.Errorf("unexpected environments: %+v", envs)
+	}
+}
+
+func TestRunEnvListWithDeps_Table(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Environments = []api.Environment{{Name: "staging", SecretCount: 4}}
+
+	if err := runEnvListWithDeps(EnvListOptions{}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(strings.Join(uiMock.MessageCalls, "\n"), "staging") {
+		t.Errorf("expected staging to be listed, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunEnvDeleteWithDeps(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development", "qa"}
+
+	if err := runEnvDeleteWithDeps(EnvDeleteOptions{EnvName: "qa", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.DeletedEnvironment != "qa" {
+		t.Errorf("expected qa to be deleted, got %q", apiMock.DeletedEnvironment)
+	}
+}
+
+func TestRunEnvDeleteWithDeps_Unknown(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development"}
+
+	err := runEnvDeleteWithDeps(EnvDeleteOptions{EnvName: "qa", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "no environment named qa") {
+		t.Fatalf("expected an unknown environment error, got %v", err)
+	}
+	if apiMock.DeletedEnvironment != "" {
+		t.Errorf("expected nothing deleted, got %q", apiMock.DeletedEnvironment)
+	}
+}
+
+func TestRunEnvDeleteWithDeps_Declined(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"qa"}
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = false
+
+	if err := runEnvDeleteWithDeps(EnvDeleteOptions{EnvName: "qa"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.DeletedEnvironment != "" {
+		t.Errorf("expected nothing deleted, got %q", apiMock.DeletedEnvironment)
+	}
+}
+
+func TestRunEnvDeleteWithDeps_RequiresYes(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"qa"}
+
+	err := runEnvDeleteWithDeps(EnvDeleteOptions{EnvName: "qa"}, deps)
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected a confirmation error, got %v", err)
+	}
+}
+
+func TestRunEnvDeleteWithDeps_Frozen(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"production"}
+	apiMock.Freeze = &api.EnvironmentFreeze{Frozen: true}
+
+	err := runEnvDeleteWithDeps(EnvDeleteOptions{EnvName: "production", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Fatalf("expected a frozen error, got %v", err)
+	}
+	if apiMock.DeletedEnvironment != "" {
+		t.Errorf("expected nothing deleted, got %q", apiMock.DeletedEnvironment)
+	}
+}
+
+func TestRunEnvRenameWithDeps(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development", "qa"}
+
+	if err := runEnvRenameWithDeps(EnvDeleteOptions{EnvName: "qa", NewName: "uat", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RenamedEnvironment != [2]string{"qa", "uat"} {
+		t.Errorf("expected qa to be renamed to uat, got %v", apiMock.RenamedEnvironment)
+	}
+}
+
+func TestRunEnvRenameWithDeps_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		newName string
+		want    string
+	}{
+		{"existing name", "development", "already exists"},
+		{"invalid name", "qa/2", "invalid environment name"},
+		{"same name", "qa", "same name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, _, _, _, _, apiMock := NewTestDeps()
+			apiMock.VaultEnvs = []string{"development", "qa"}
+
+			err := runEnvRenameWithDeps(EnvDeleteOptions{EnvName: "qa", NewName: tt.newName, Yes: true}, deps)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q error, got %v", tt.want, err)
+			}
+			if apiMock.RenamedEnvironment != [2]string{} {
+				t.Errorf("expected nothing renamed, got %v", apiMock.RenamedEnvironment)
+			}
+		})
+	}
+}
+
+func TestRunEnvCreateWithDeps_CreatesEmptyEnvironment(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultEnvs = []string{"development"}
+
+	if err := runEnvCreateWithDeps(EnvCreateOptions{EnvName: "qa", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.CreatedEnvironment != "qa" {
+		t.Errorf("expected qa to be created, got %q", apiMock.CreatedEnvironment)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing pushed, got %v", apiMock.PushedSecrets)
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show recent vault changes, or follow them live",
+	Long: `Show who changed which keys, in which environment.
+
+With --follow, keeps streaming new changes as they happen (Ctrl+C to stop).
+
+--template formats the output with a Go template, which gets .repository and
+.events, each event having .type, .environment, .keys, .actor and .createdAt.
+With --follow, the template runs for each new event.`,
+	Args: cobra.NoArgs,
+	RunE: runEvents,
+}
+
+func init() {
+	eventsCmd.Flags().BoolP("follow", "f", false, "Stream new events as they happen")
+	eventsCmd.Flags().StringP("env", "e", "", "Only show events for this environment")
+	eventsCmd.Flags().String("key", "", "Only show events touching keys matching this pattern")
+	eventsCmd.Flags().String("actor", "", "Only show events by this user")
+	eventsCmd.Flags().IntP("limit", "n", 20, "Number of past events to show")
+	addTemplateFlag(eventsCmd)
+}
+
+// eventsReconnectDelay is how long to wait before reconnecting a dropped stream
+var eventsReconnectDelay = 3 * time.Second
+
+// EventsOptions contains the parsed flags for the events command
+type EventsOptions struct {
+	Follow     bool
+	EnvName    string
+	KeyPattern string
+	Actor      string
+	Limit      int
+	JSONOutput bool
+	Template   string
+}
+
+// eventsView is what --template gets from keyway events
+type eventsView struct {
+	Repository string           `json:"repository"`
+	Events     []api.VaultEvent `json:"events"`
+}
+
+// runEvents is the entry point for the events command (uses default dependencies)
+func runEvents(cmd *cobra.Command, args []string) error {
+	opts := EventsOptions{}
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.KeyPattern, _ = cmd.Flags().GetString("key")
+	opts.Actor, _ = cmd.Flags().GetString("actor")
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	return runEventsWithDeps(opts, defaultDeps)
+}
+
+// runEventsWithDeps is the testable version of runEvents
+func runEventsWithDeps(opts EventsOptions, deps *Dependencies) error {
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return showEvents(s, opts, tmpl)
+	}, withIntro("events"), withRepo, withLogin)
+}
+
+// showEvents prints the vault events matching the filters, or follows them
+func showEvents(s *Session, opts EventsOptions, tmpl *template.Template) error {
+	deps := s.Deps
+	if opts.KeyPattern != "" {
+		if _, err := path.Match(opts.KeyPattern, ""); err != nil {
+			deps.UI.Error(fmt.Sprintf("Invalid key pattern: %s", opts.KeyPattern))
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(s.Ctx, os.Interrupt)
+	defer stop()
+	s.Ctx = ctx
+
+	filter := api.EventFilter{
+		Environment: normalizeEventEnv(opts.EnvName),
+		Actor:       opts.Actor,
+		Limit:       opts.Limit,
+	}
+
+	if !opts.Follow {
+		var events []api.VaultEvent
+		err := s.Spin("Fetching events...", func() error {
+			var err error
+			events, err = s.Client.GetVaultEvents(s.Ctx, s.Repo, filter)
+			return err
+		})
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+
+		if tmpl != nil {
+			view := eventsView{Repository: s.Repo, Events: []api.VaultEvent{}}
+			for i := len(events) - 1; i >= 0; i-- {
+				if eventMatchesKey(events[i], opts.KeyPattern) {
+					view.Events = append(view.Events, events[i])
+				}
+			}
+			return printTemplate(tmpl, view, deps)
+		}
+
+		shown := 0
+		// The API returns newest first, print oldest first like a log
+		for i := len(events) - 1; i >= 0; i-- {
+			if printEvent(events[i], opts, deps) {
+				shown++
+			}
+		}
+		if shown == 0 {
+			deps.UI.Info("No matching events")
+		}
+		return nil
+	}
+
+	deps.UI.Message(deps.UI.Dim("Following vault changes (Ctrl+C to stop)..."))
+	filter.Limit = 0
+
+	lastID := ""
+	var templateErr error
+	for {
+		err := s.Client.StreamVaultEvents(s.Ctx, s.Repo, filter, lastID, func(event api.VaultEvent) error {
+			lastID = event.ID
+			if tmpl != nil {
+				if !eventMatchesKey(event, opts.KeyPattern) {
+					return nil
+				}
+				templateErr = printTemplate(tmpl, eventsView{Repository: s.Repo, Events: []api.VaultEvent{event}}, deps)
+				return templateErr
+			}
+			printEvent(event, opts, deps)
+			return nil
+		})
+		if s.Ctx.Err() != nil {
+			return nil
+		}
+		if templateErr != nil {
+			return templateErr
+		}
+
+		if isAuthError(err) {
+			if err := s.Relogin(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode < 500 {
+			deps.UI.Error(apiErr.Error())
+			return err
+		}
+
+		deps.UI.Warn("Connection lost, reconnecting...")
+		select {
+		case <-s.Ctx.Done():
+			return nil
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
+
+// normalizeEventEnv expands env shorthands, keeping "" as "all environments"
+func normalizeEventEnv(envName string) string {
+	if envName == "" {
+		return ""
+	}
+	return normalizeEnvName(envName)
+}
+
+// printEvent prints an event if it matches the client-side filters
+func printEvent(event api.VaultEvent, opts EventsOptions, deps *Dependencies) bool {
+	if !eventMatchesKey(event, opts.KeyPattern) {
+		return false
+	}
+
+	if opts.JSONOutput {
+		output, _ := json.Marshal(event)
+		fmt.Println(string(output))
+		return true
+	}
+
+	actor := event.Actor
+	if actor == "" {
+		actor = "unknown"
+	}
+	line := fmt.Sprintf("%s  %-12s %-16s %s", deps.UI.Dim(formatEventTime(event.CreatedAt)), event.Environment, actor, deps.UI.Bold(event.Type))
+	if len(event.Keys) > 0 {
+		line += "  " + strings.Join(event.Keys, ", ")
+	}
+	deps.UI.Message(line)
+	return true
+}
+
+// eventMatchesKey returns true if any key of the event matches pattern
+func eventMatchesKey(event api.VaultEvent, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	for _, key := range event.Keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatEventTime converts an RFC 3339 timestamp to local time
+func formatEventTime(createdAt string) string {
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return createdAt
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunEventsWithDeps_Recent(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Events = []api.VaultEvent{
+		{ID: "2", Type: "secret.deleted", Environment: "production", Keys: []string{"OLD_KEY"}, Actor: "bob"},
+		{ID: "1", Type: "secrets.pushed", Environment: "production", Keys: []string{"API_KEY"}, Actor: "alice"},
+	}
+
+	err := runEventsWithDeps(EventsOptions{EnvName: "prod", Limit: 20}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.EventFilter.Environment != "production" {
+		t.Errorf("expected normalized env filter, got %q", apiMock.EventFilter.Environment)
+	}
+	var lines []string
+	for _, m := range uiMock.MessageCalls {
+		if strings.Contains(m, "production") {
+			lines = append(lines, m)
+		}
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], "alice") || !strings.Contains(lines[1], "OLD_KEY") {
+		t.Errorf("expected events oldest first, got %v", lines)
+	}
+}
+
+func TestRunEventsWithDeps_KeyFilter(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Events = []api.VaultEvent{
+		{ID: "1", Type: "secrets.pushed", Keys: []string{"API_KEY"}},
+	}
+
+	err := runEventsWithDeps(EventsOptions{KeyPattern: "STRIPE_*"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.InfoCalls) == 0 || uiMock.InfoCalls[0] != "No matching events" {
+		t.Errorf("expected no matching events, got %v", uiMock.InfoCalls)
+	}
+}
+
+func TestRunEventsWithDeps_FollowStopsOnClientError(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.StreamEvents = []api.VaultEvent{
+		{ID: "1", Type: "secrets.pushed", Environment: "staging", Keys: []string{"STRIPE_KEY"}, Actor: "alice"},
+	}
+	apiMock.StreamError = &api.APIError{StatusCode: 403, Detail: "No access"}
+
+	err := runEventsWithDeps(EventsOptions{Follow: true, KeyPattern: "STRIPE_*"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if apiMock.StreamCalls != 1 {
+		t.Errorf("expected no reconnect on 403, got %d calls", apiMock.StreamCalls)
+	}
+	found := false
+	for _, m := range uiMock.MessageCalls {
+		if strings.Contains(m, "STRIPE_KEY") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected streamed event to be printed, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestEventMatchesKey(t *testing.T) {
+	event := api.VaultEvent{Keys: []string{"DB_URL", "STRIPE_SECRET"}}
+	if !eventMatchesKey(event, "STRIPE_*") {
+		t.Error("expected match")
+	}
+	if eventMatchesKey(event, "AWS_*") {
+		t.Error("expected no match")
+	}
+	if !eventMatchesKey(api.VaultEvent{}, "") {
+		t.Error("expected empty pattern to match everything")
+	}
+}
+
+func TestRunEventsWithDeps_Template(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Events = []api.VaultEvent{
+		{ID: "2", Type: "secret.deleted", Keys: []string{"OLD_KEY"}, Actor: "bob"},
+		{ID: "1", Type: "secrets.pushed", Keys: []string{"API_KEY", "DB_URL"}, Actor: "alice"},
+	}
+
+	output := captureStdout(t, func() {
+		if err := runEventsWithDeps(EventsOptions{Limit: 20, Template: `{{range .events}}{{.actor}} {{join "," .keys}}\n{{end}}`}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if string(output) != "alice API_KEY,DB_URL\nbob OLD_KEY\n" {
+		t.Errorf("expected events oldest first, got %q", output)
+	}
+}
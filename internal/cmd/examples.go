@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var examplesCmd = &cobra.Command{
+	Use:   "examples [COMMAND]",
+	Short: "Show examples of a command for this repository",
+	Long: `Show examples of a command, ready to copy and paste: the repository and
+environment of the working directory (or pinned with keyway use) are filled in.
+Without a command, lists the commands that have examples.
+
+The same examples are shown by the --help of each command.`,
+	RunE: runExamples,
+}
+
+// commandExample is an example of a command, shown by its --help and by
+// keyway examples
+type commandExample struct {
+	// Command is the command line. {repo}, {env} and {file} are replaced by
+	// the repository, environment and env file of the working directory.
+	Command     string
+	Description string
+}
+
+// commandExamples are the examples of the commands, by command path without
+// the keyway prefix, e.g. "env scratch"
+var commandExamples = map[string][]commandExample{
+	"prefetch": {
+		{"keyway prefetch", "Cache the development environment of {repo}"},
+		{"keyway prefetch -e {env}", "Cache an environment"},
+	},
+	"prefetch hook": {
+		{"keyway prefetch hook zsh", "Shell hook prefetching on cd, for ~/.zshrc"},
+	},
+	"push": {
+		{"keyway push", "Push .env, asking for the environment"},
+		{"keyway push -e {env} -f {file}", "Push a file to an environment"},
+		{"keyway push -e {env} --dry-run", "Preview the changes"},
+		{"keyway push -e {env} --dry-run --json > diff.json", "Preview as JSON, for CI gates"},
+		{"keyway push -e {env} --select", "Choose which changed keys to push"},
+		{"keyway push -e {env} --prune", "Also remove keys that are not in the file"},
+		{"keyway push -e {env} --yes --rollback-on-failure", "In CI: roll back when a health check fails"},
+	},
+	"pull": {
+		{"keyway pull -e {env}", "Download secrets into {file}"},
+		{"keyway pull -e {env} -f .env.{env}", "Into a file named after the environment"},
+		{"keyway pull -e {env} --config-only -f config/public.env", "Only config keys, safe to commit"},
+		{"keyway pull -e {env} --select", "Choose which keys to pull"},
+		{"keyway pull -e {env} --revision v42", "The environment as it was at version 42"},
+	},
+	"watch": {
+		{"keyway watch -e {env}", "Keep {file} in sync as the vault changes"},
+		{"keyway watch -e {env} -f .env.{env}", "Keep another file in sync"},
+		{"keyway watch -e {env} --interval 1m", "Poll instead of following events"},
+	},
+	"render": {
+		{"keyway render config.yaml.tmpl -e {env}", "Write config.yaml with the secrets"},
+		{"keyway render config.tmpl -e {env} -o config/app.toml", "Write to another file"},
+		{"keyway render nginx.conf.tmpl -e {env} -o - | less", "Print instead of writing"},
+	},
+	"run": {
+		{"keyway run -e {env} -- npm run dev", "Run a dev server with the secrets, none on disk"},
+		{"keyway run -e {env} -- python3 main.py", "Any command works"},
+		{"keyway run -e {env} --reload-on-change -- ./server", "Hand rotated secrets to a running server"},
+		{"keyway run -e {env} --watch -- npm run dev", "Restart the command when its secrets change"},
+		{"keyway run -e {env} --remap worker -- ./worker", "Rename keys with a remap of .keyway.json"},
+		{"keyway run -e {env} --expand -- ./server", "Resolve ${VAR} references in values"},
+	},
+	"set": {
+		{"keyway set API_KEY -e {env}", "Prompt for the value (masked)"},
+		{"keyway set API_KEY=sk_live_xxx -e {env}", "Set with an inline value"},
+		{"keyway set API_KEY=xxx LOG_LEVEL=info -e {env}", "Set several secrets in one push"},
+		{"keyway set API_KEY -e {env} -y", "Skip confirmation if updating"},
+	},
+	"diff": {
+		{"keyway diff", "Pick the environments to compare"},
+		{"keyway diff production {env}", "Compare two environments"},
+		{"keyway diff production {env} --keys-only", "Only the key names"},
+		{"keyway diff {env} --file {file} --exit-code", "Compare a local file, fail on differences"},
+		{"keyway diff {env} --against version:42", "Compare with a historical version"},
+		{"keyway diff {env} --against 2024-06-01 --file {file}", "Compare a local file with a past date"},
+	},
+	"history": {
+		{"keyway history -e {env}", "Versions of an environment"},
+		{"keyway history STRIPE_KEY -e {env}", "Versions of one key"},
+		{"keyway history -e {env} -n 50 --json", "The last 50 versions as JSON"},
+	},
+	"rollback": {
+		{"keyway rollback v42 -e {env}", "Go back to version 42, after typing the environment name"},
+		{"keyway rollback 2024-06-01 -e {env} --yes", "Go back to a date, without asking"},
+	},
+	"undo": {
+		{"keyway undo", "Revert the last push from this machine"},
+		{"keyway undo -e {env}", "Revert the last push to an environment"},
+	},
+	"examples": {
+		{"keyway examples", "Commands with examples"},
+		{"keyway examples push", "Examples of keyway push for {repo}"},
+	},
+	"events": {
+		{"keyway events", "Last 20 changes of {repo}"},
+		{"keyway events --follow -e {env}", "Live tail of an environment"},
+		{"keyway events -f --key 'STRIPE_*'", "Only changes touching Stripe keys"},
+		{"keyway events -f --json | jq .", "One JSON object per line"},
+	},
+	"audit-strength": {
+		{"keyway audit-strength", "Weak passwords and keys in {repo}"},
+		{"keyway audit-strength -e {env} --fail-on critical", "Fail on critical findings, for CI"},
+		{"keyway audit-strength --json", "Findings as JSON"},
+		{"keyway audit-strength --template '{{range .findings}}{{.severity}}\\t{{.key}}\\n{{end}}'", "Custom report"},
+	},
+	"bisect": {
+		{"keyway bisect start -e {env} --good 2024-06-01", "Start from a version known to be good"},
+		{"keyway bisect bad", "The version being tested is bad"},
+		{"keyway bisect good", "The version being tested is good"},
+		{"keyway bisect run -- npm test", "Let a command tell good from bad"},
+		{"keyway bisect reset", "Stop and go back to the latest version"},
+	},
+	"compliance export": {
+		{"keyway compliance export", "Signed, encrypted dump of {repo} with its audit trail"},
+		{"keyway compliance export -o evidence/2024-q2.json", "Into a file of your choice"},
+	},
+	"compliance verify": {
+		{"keyway compliance verify keyway-compliance.json --key kwk1_...", "Check the signature of a dump"},
+		{"keyway compliance verify keyway-compliance.json --key kwk1_... --signer 3f2a:91c0:...", "Also check who signed it"},
+		{"keyway compliance verify keyway-compliance.json --key kwk1_... --decrypt dump.json", "Decrypt it once verified"},
+	},
+	"compose": {
+		{"keyway compose up", "docker compose up with the development secrets"},
+		{"keyway compose -e {env} up -d --build", "With the secrets of an environment"},
+		{"keyway compose -e {env} -- -f compose.prod.yml config", "Pass flags to docker compose after --"},
+	},
+	"edit": {
+		{"keyway edit -e {env} --announce", "Tell teammates you are editing an environment"},
+		{"keyway edit -e {env} --announce --for 1h --note \"rotating Stripe keys\"", "With a duration and a note"},
+		{"keyway edit", "Environments being edited"},
+		{"keyway edit -e {env} --done", "You are done editing"},
+	},
+	"env": {
+		{"keyway env list", "Environments of {repo}"},
+		{"keyway env create qa", "Create an environment"},
+		{"keyway env rename qa uat", "Rename it"},
+		{"keyway env delete uat", "Delete it"},
+		{"keyway env scratch -e {env}", "Try changes in a scratch copy of an environment"},
+	},
+	"env create": {
+		{"keyway env create qa", "Create an empty environment"},
+		{"keyway env create qa --from-blueprint web-service", "Create it with the keys of a blueprint"},
+	},
+	"env blueprints": {
+		{"keyway env blueprints", "Blueprints available to env create"},
+	},
+	"env delete": {
+		{"keyway env delete qa", "Delete an environment, after confirming"},
+		{"keyway env delete qa --yes", "Without asking"},
+	},
+	"env freeze": {
+		{"keyway env freeze production --reason \"release week\"", "Block writes to an environment"},
+	},
+	"env unfreeze": {
+		{"keyway env unfreeze production", "Allow writes again"},
+	},
+	"env graph": {
+		{"keyway env graph > docs/environments.mmd", "Mermaid graph of the environments"},
+		{"keyway env graph --format dot | dot -Tsvg > environments.svg", "As an SVG, with Graphviz"},
+		{"keyway env graph -o docs/environments.mmd", "Into a file"},
+	},
+	"env list": {
+		{"keyway env list", "Environments of {repo}"},
+		{"keyway env list --json", "As JSON"},
+		{"keyway env list --template '{{range .environments}}{{.name}}\\n{{end}}'", "One name per line"},
+	},
+	"env protect": {
+		{"keyway env protect production", "Require a review for changes"},
+		{"keyway env protect production --reviewers alice,bob --required-reviewers 1", "Choose the reviewers"},
+		{"keyway env protect production --allow-token deploy-bot --allow-cidr 10.0.0.0/8", "Restrict tokens and networks"},
+		{"keyway env protect production --require-webauthn", "Require a security key"},
+		{"keyway env protect production --clear", "Remove the protection"},
+	},
+	"env rename": {
+		{"keyway env rename qa uat", "Rename an environment"},
+	},
+	"env scratch": {
+		{"keyway env scratch -e {env}", "Start a scratch copy of an environment"},
+		{"keyway env scratch diff", "Changes made in the scratch copy"},
+		{"keyway env scratch merge FEATURE_FLAGS API_TIMEOUT", "Merge some of them back"},
+		{"keyway env scratch drop", "Throw the scratch copy away"},
+	},
+	"env scratch merge": {
+		{"keyway env scratch merge", "Merge every change back, after confirming"},
+		{"keyway env scratch merge FEATURE_FLAGS API_TIMEOUT --yes", "Merge some keys, without asking"},
+	},
+	"export": {
+		{"keyway export -e {env} --keys 'VENDOR_*' --sign", "Signed, encrypted bundle for a vendor"},
+		{"keyway export -e {env} --keys 'STRIPE_*' --keys SENTRY_DSN -o acme.json", "Several patterns, into a file"},
+		{"keyway export -e {env} --keys '*' --unflatten __ -o config.yaml", "As a nested config file"},
+	},
+	"import": {
+		{"keyway import keyway-export.json --key kwk1_...", "Import a bundle"},
+		{"keyway import acme.json --key kwk1_... --signer 3f2a:91c0:... -f .env.keyway", "Check who signed it, write to a file"},
+		{"keyway import config.yaml --flatten __ -f {file}", "Flatten a nested config file"},
+	},
+	"file push": {
+		{"keyway file push ./gcp-sa.json --as GCP_SA_JSON", "Store a file as a secret"},
+		{"keyway file push ./release.keystore --as ANDROID_KEYSTORE -e {env}", "In an environment"},
+	},
+	"get": {
+		{"keyway get DATABASE_URL -e {env}", "Print a value"},
+		{"keyway get TLS_KEY --plain -e {env} > tls.key", "Without a newline, e.g. into a file"},
+		{"keyway get STRIPE_KEY --copy", "Copy it to the clipboard"},
+		{"keyway get TOTP_SEED --qr --timeout 1m", "Show it as a QR code"},
+		{"keyway get TLS_CERT --inspect -e {env}", "Masked, type-aware preview (JSON, PEM, JWT)"},
+	},
+	"impact": {
+		{"keyway impact DB_HOST", "Derived keys and environments a change would affect"},
+		{"keyway impact DB_HOST -e {env}", "In an environment"},
+	},
+	"migrate": {
+		{"keyway migrate dotenv-vault", "Move environments from dotenv-vault"},
+		{"keyway migrate doppler --project backend", "From a Doppler project"},
+		{"keyway migrate doppler --uninstall -y", "Then delete its project files"},
+	},
+	"owners": {
+		{"keyway owners", "Who owns the keys of {repo}"},
+		{"keyway owners -e {env}", "In an environment"},
+	},
+	"rotation": {
+		{"keyway rotation plan STRIPE_KEY --dry-run", "Show the plan without changing anything"},
+		{"keyway rotation plan STRIPE_KEY", "Rotate a key across the organization"},
+		{"keyway rotation plan STRIPE_KEY --resume", "Resume an interrupted rotation"},
+	},
+	"rotation plan": {
+		{"keyway rotation plan STRIPE_KEY --dry-run", "Show the plan without changing anything"},
+		{"keyway rotation plan SESSION_SECRET --generate hex:32", "Generate the new value"},
+		{"keyway rotation plan STRIPE_KEY --resume", "Resume where it stopped"},
+		{"keyway rotation plan STRIPE_KEY --abandon", "Forget a rotation that stopped"},
+	},
+	"scan": {
+		{"keyway scan", "Scan the current directory"},
+		{"keyway scan ./src", "Scan a directory"},
+		{"keyway scan --json", "Output as JSON, for CI"},
+		{"keyway scan -e test -e mocks", "Exclude more directories"},
+	},
+	"secrets": {
+		{"keyway secrets list -e {env}", "Keys with masked values"},
+		{"keyway secrets get DATABASE_URL -e {env}", "Print one value"},
+		{"keyway secrets set API_KEY=sk_live_xxx -e {env}", "Set a secret"},
+		{"keyway secrets set LOG_LEVEL=debug FEATURE_FLAGS=beta -y", "Set several, without asking"},
+		{"keyway secrets rm OLD_API_KEY -e {env}", "Remove a secret"},
+	},
+	"secrets get": {
+		{"keyway secrets get DATABASE_URL -e {env}", "Print a value, e.g. for DATABASE_URL=$(...)"},
+		{"keyway secrets get TLS_KEY --plain -e {env} > tls.key", "Without a newline"},
+	},
+	"secrets list": {
+		{"keyway secrets list", "Keys with masked values and who last set them"},
+		{"keyway secrets list -e {env} --json", "As JSON"},
+		{"keyway secrets list --template '{{range .keys}}{{.name}}\\t{{.updatedAt}}\\n{{end}}'", "Custom output"},
+	},
+	"secrets rm": {
+		{"keyway secrets rm OLD_API_KEY", "Remove a secret"},
+		{"keyway secrets rm LEGACY_URL LEGACY_TOKEN -e {env} -y", "Several, without asking"},
+	},
+	"sessions": {
+		{"keyway sessions list", "Where you are logged in"},
+		{"keyway sessions revoke 3f9c2a", "Log a session out"},
+		{"keyway sessions revoke --all-others", "Log out everywhere else"},
+	},
+	"sessions list": {
+		{"keyway sessions list", "Where you are logged in"},
+		{"keyway sessions list --template '{{range .sessions}}{{.id}}\\t{{.lastUsedAt}}\\n{{end}}'", "Custom output"},
+	},
+	"shell": {
+		{"keyway shell", "Shell with the development secrets"},
+		{"keyway shell -e {env}", "With the secrets of an environment"},
+		{"keyway shell -e {env} --shell /bin/zsh", "With another shell"},
+		{"keyway shell -e {env} --remap api", "With keys renamed by a remap"},
+	},
+	"shim": {
+		{"keyway shim npm", "Shim all scripts"},
+		{"keyway shim npm dev test", "Shim only dev and test"},
+		{"keyway shim npm dev -e {env}", "Inject the secrets of an environment"},
+		{"keyway shim npm --remove", "Restore the original scripts"},
+	},
+	"ship": {
+		{"keyway ship -e {env} --host deploy@web1 --path /srv/app/.env", "Stream an environment to a server over SSH"},
+		{"keyway ship -e {env} --host web1 --path /srv/app/.env --restart myapp", "Then restart a systemd service"},
+	},
+	"snapshot": {
+		{"keyway snapshot create v1.42-release -e {env}", "Tag the state of an environment"},
+		{"keyway snapshot diff v1.42-release -e {env}", "Changes since then"},
+		{"keyway snapshot restore v1.42-release -e {env}", "Go back to it"},
+	},
+	"snapshot create": {
+		{"keyway snapshot create v1.42-release -e {env}", "Tag the state of an environment"},
+	},
+	"snapshot diff": {
+		{"keyway snapshot diff v1.42-release -e {env}", "Changes since a snapshot"},
+		{"keyway snapshot diff v1.42-release -e {env} --keys-only", "Only the key names"},
+	},
+	"snapshot restore": {
+		{"keyway snapshot restore v1.42-release -e {env}", "Go back to a snapshot, after confirming"},
+		{"keyway snapshot restore v1.42-release -e {env} --yes", "Without asking"},
+	},
+	"sudo": {
+		{"keyway sudo --env {env} --duration 30m --reason \"hotfix INC-142\"", "Elevate your access for a while"},
+		{"keyway sudo --status", "Current elevations"},
+		{"keyway sudo --drop --env {env}", "Drop an elevation early"},
+	},
+	"sync": {
+		{"keyway sync", "Pick a provider"},
+		{"keyway sync vercel", "Sync with Vercel"},
+		{"keyway sync railway", "Sync with Railway"},
+		{"keyway sync github-secrets --env {env}", "Mirror into GitHub Actions secrets"},
+		{"keyway sync vercel --push --env {env}", "Push the vault to Vercel"},
+		{"keyway sync vercel --pull --env {env}", "Pull from Vercel into the vault"},
+	},
+	"sync github-secrets": {
+		{"keyway sync github-secrets --env {env}", "Mirror into GitHub Actions secrets (GITHUB_TOKEN or gh auth token)"},
+		{"keyway sync github-secrets -e {env} --github-env staging", "Into a GitHub environment"},
+		{"keyway sync github-secrets --keys 'STRIPE_*' --keys SENTRY_DSN --prune", "Some keys, removing the others"},
+		{"keyway sync github-secrets --dry-run", "Preview the changes"},
+	},
+	"trash": {
+		{"keyway trash list -e {env}", "Keys removed in the last 30 days"},
+		{"keyway trash restore OLD_API_KEY -e {env}", "Restore one"},
+	},
+	"trash list": {
+		{"keyway trash list -e {env}", "Keys removed in the last 30 days"},
+		{"keyway trash list --template '{{range .keys}}{{.name}} {{.daysLeft}}\\n{{end}}'", "Custom output"},
+	},
+	"unused": {
+		{"keyway unused", "Vault keys the code never uses, env vars missing from the vault"},
+		{"keyway unused ./services/api -e {env}", "In a directory, for an environment"},
+		{"keyway unused --json", "As JSON"},
+	},
+	"usage": {
+		{"keyway usage", "How you used keyway over the last 30 days"},
+		{"keyway usage --since 7d", "Over the last 7 days"},
+		{"keyway usage --since all --export csv > keyway-usage.csv", "Everything, as CSV"},
+		{"keyway usage --clear", "Delete the recorded usage"},
+	},
+	"use": {
+		{"keyway use {repo} --env {env}", "Pin a repository and environment"},
+		{"keyway use --profile work", "Switch to another profile"},
+		{"keyway use", "What is pinned"},
+		{"keyway use --unset", "Unpin"},
+	},
+	"vault relink": {
+		{"keyway vault relink", "Reconnect the vault of a renamed repository"},
+		{"keyway vault relink --from acme/old-name --mode migrate", "Copy the secrets of the old name into a new vault"},
+	},
+	"verify-install": {
+		{"keyway verify-install", "Check this binary against its published release"},
+		{"keyway verify-install --json", "As JSON"},
+		{"keyway verify-install --allow-unsigned", "Compare a build without release key against unsigned checksums"},
+	},
+}
+
+func init() {
+	// Help shows the examples of the registry, filled in for this repository
+	help := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if examples := renderExamples(examplesPath(cmd), exampleValues(defaultDeps)); examples != "" {
+			cmd.Example = examples
+		}
+		help(cmd, args)
+	})
+}
+
+// ExamplesOptions contains the parsed arguments of the examples command
+type ExamplesOptions struct {
+	Command []string
+}
+
+// runExamples is the entry point for the examples command (uses default dependencies)
+func runExamples(cmd *cobra.Command, args []string) error {
+	return runExamplesWithDeps(ExamplesOptions{Command: args}, defaultDeps)
+}
+
+// runExamplesWithDeps is the testable version of runExamples
+func runExamplesWithDeps(opts ExamplesOptions, deps *Dependencies) error {
+	// Printed as is, so that the lines can be copied
+	if len(opts.Command) == 0 {
+		for _, path := range sortedKeys(commandExamples) {
+			fmt.Printf("keyway %s\n", path)
+		}
+		deps.UI.Message(deps.UI.Dim("Show the examples of one with: keyway examples <command>"))
+		return nil
+	}
+
+	cmd, rest, err := rootCmd.Find(opts.Command)
+	if err != nil || cmd == rootCmd || len(rest) > 0 {
+		deps.UI.Error(fmt.Sprintf("Unknown command: keyway %s", strings.Join(opts.Command, " ")))
+		return fmt.Errorf("unknown command %q", strings.Join(opts.Command, " "))
+	}
+	path := examplesPath(cmd)
+	examples := renderExamples(path, exampleValues(deps))
+	if examples == "" {
+		deps.UI.Warn(fmt.Sprintf("No examples for keyway %s yet", path))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("See: keyway %s --help", path)))
+		return nil
+	}
+	fmt.Println(examples)
+	return nil
+}
+
+// examplesPath returns the key of a command in commandExamples
+func examplesPath(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+}
+
+// exampleValues returns what the placeholders of examples stand for: the
+// repository and environment pinned or detected in the working directory,
+// else sample values
+func exampleValues(deps *Dependencies) map[string]string {
+	values := map[string]string{"{repo}": "acme/api", "{env}": "development", "{file}": ".env"}
+	pinned := loadPinnedContext(deps)
+	if pinned.Repo != "" {
+		values["{repo}"] = pinned.Repo
+	} else if repo, err := deps.Git.DetectRepo(); err == nil {
+		values["{repo}"] = repo
+	}
+	if pinned.Env != "" {
+		values["{env}"] = pinned.Env
+	}
+	if project, err := loadProject(deps); err == nil {
+		if file := project.OutputFile(values["{env}"]); file != "" {
+			values["{file}"] = file
+		}
+	}
+	return values
+}
+
+// renderExamples returns the examples of a command with their placeholders
+// replaced, one per line with its description aligned as a comment, or an
+// empty string if the command has none
+func renderExamples(path string, values map[string]string) string {
+	examples := commandExamples[path]
+	if len(examples) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, 2*len(values))
+	for placeholder, value := range values {
+		pairs = append(pairs, placeholder, value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	commands := make([]string, len(examples))
+	width := 0
+	for i, example := range examples {
+		commands[i] = replacer.Replace(example.Command)
+		width = max(width, len(commands[i]))
+	}
+	lines := make([]string, len(examples))
+	for i, example := range examples {
+		lines[i] = fmt.Sprintf("  %-*s  # %s", width, commands[i], replacer.Replace(example.Description))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCommandExamples_MatchCommands(t *testing.T) {
+	for path, examples := range commandExamples {
+		cmd, rest, err := rootCmd.Find(strings.Fields(path))
+		if err != nil || len(rest) > 0 || examplesPath(cmd) != path {
+			t.Errorf("examples of %q do not match a command", path)
+			continue
+		}
+		for _, example := range examples {
+			if !strings.HasPrefix(example.Command, "keyway "+path) {
+				t.Errorf("example %q is not an example of keyway %s", example.Command, path)
+			}
+		}
+	}
+}
+
+func TestCommands_NoStaticExamples(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		// --help fills in Example from the registry
+		if strings.Contains(cmd.Long, "Examples:") || (cmd.Example != "" && commandExamples[examplesPath(cmd)] == nil) {
+			t.Errorf("keyway %s has static examples, move them to commandExamples", examplesPath(cmd))
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+}
+
+func TestRenderExamples(t *testing.T) {
+	got := renderExamples("undo", map[string]string{"{env}": "staging"})
+	want := "  keyway undo             # Revert the last push from this machine\n" +
+		"  keyway undo -e staging  # Revert the last push to an environment"
+	if got != want {
+		t.Errorf("unexpected examples:\n%s", got)
+	}
+	if renderExamples("lsp", nil) != "" {
+		t.Error("expected no examples for a command without any")
+	}
+}
+
+func TestRunExamplesWithDeps_FillsInContext(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files[pinnedContextPath()] = []byte(`{"environment":"staging"}`)
+	fsMock.Files[".keyway.json"] = []byte(`{"outputs": {"staging": ".env.staging"}}`)
+
+	out := captureStdout(t, func() {
+		if err := runExamplesWithDeps(ExamplesOptions{Command: []string{"events"}}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(string(out), "keyway events --follow -e staging") || !strings.Contains(string(out), "Last 20 changes of owner/repo") {
+		t.Errorf("expected the pinned environment and the repository, got:\n%s", out)
+	}
+	out = captureStdout(t, func() {
+		_ = runExamplesWithDeps(ExamplesOptions{Command: []string{"push"}}, deps)
+	})
+	if !strings.Contains(string(out), "keyway push -e staging -f .env.staging ") {
+		t.Errorf("expected the output file of the environment, got:\n%s", out)
+	}
+}
+
+func TestRunExamplesWithDeps_UnknownCommand(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runExamplesWithDeps(ExamplesOptions{Command: []string{"nope"}}, deps); err == nil {
+		t.Fatal("expected an error for an unknown command")
+	}
+	if len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected an error to be shown, got %v", uiMock.ErrorCalls)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"net"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of failed commands in CI, so that a pipeline can tell why a step
+// failed without parsing its log. Outside of CI every failure exits with 1.
+const (
+	ExitFailure     = 1 // any other failure
+	ExitUsage       = 2 // invalid command, flags or arguments
+	ExitAuth        = 3 // no login or KEYWAY_TOKEN, or the token was rejected
+	ExitDenied      = 4 // missing permission, or blocked by a protection rule
+	ExitNotFound    = 5 // vault, environment or key not found
+	ExitUnavailable = 6 // API unreachable or failing, or network budget exceeded
+)
+
+// ExitDrift is the exit code of keyway diff --exit-code when there are
+// differences, in and out of CI
+const ExitDrift = 7
+
+// errLoginRequired is returned when no login or token is available
+var errLoginRequired = errors.New("no Keyway session found")
+
+// driftError is the differences keyway diff --exit-code reports, which the
+// command already showed: it is not printed again as an error
+type driftError struct {
+	error
+}
+
+func (e driftError) Unwrap() error {
+	return e.error
+}
+
+// usageError is an invalid command line, as opposed to a failure of the command
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error {
+	return e.error
+}
+
+// ExitCode returns the exit code of a command that returned err
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var drift driftError
+	if errors.As(err, &drift) {
+		return ExitDrift
+	}
+	if !config.IsCI() {
+		return ExitFailure
+	}
+	return exitCodeFor(err)
+}
+
+// exitCodeFor returns the CI exit code telling what err is
+func exitCodeFor(err error) int {
+	var usage usageError
+	if errors.As(err, &usage) {
+		return ExitUsage
+	}
+	if errors.Is(err, errLoginRequired) {
+		return ExitAuth
+	}
+	if errors.Is(err, api.ErrBudgetExceeded) {
+		return ExitUnavailable
+	}
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == 401:
+			return ExitAuth
+		case apiErr.StatusCode == 403:
+			return ExitDenied
+		case apiErr.StatusCode == 404:
+			return ExitNotFound
+		case apiErr.StatusCode >= 500:
+			return ExitUnavailable
+		}
+		return ExitFailure
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ExitUnavailable
+	}
+	return ExitFailure
+}
+
+// markUsageErrors makes the flag and argument errors of cmd and its
+// subcommands usage errors
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(c *cobra.Command, a []string) error {
+			if err := args(c, a); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
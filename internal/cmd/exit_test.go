@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode_InCI(t *testing.T) {
+	t.Setenv("KEYWAY_CI", "1")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"failure", errors.New("push aborted"), ExitFailure},
+		{"usage", usageError{errors.New("unknown flag: --envv")}, ExitUsage},
+		{"no login", fmt.Errorf("%w - KEYWAY_TOKEN is not set", errLoginRequired), ExitAuth},
+		{"rejected token", &api.APIError{StatusCode: 401}, ExitAuth},
+		{"protection", &api.APIError{StatusCode: 403, Protection: &api.ProtectionViolation{Rule: api.RuleAllowedCIDRs}}, ExitDenied},
+		{"not found", fmt.Errorf("pull: %w", &api.APIError{StatusCode: 404}), ExitNotFound},
+		{"server error", &api.APIError{StatusCode: 503}, ExitUnavailable},
+		{"conflict", &api.APIError{StatusCode: 409}, ExitFailure},
+		{"unreachable", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ExitUnavailable},
+		{"budget", fmt.Errorf("%w (set KEYWAY_NETWORK_BUDGET to raise it)", api.ErrBudgetExceeded), ExitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode_OutsideCI(t *testing.T) {
+	t.Setenv("KEYWAY_CI", "0")
+	if got := ExitCode(&api.APIError{StatusCode: 404}); got != ExitFailure {
+		t.Errorf("expected every failure to exit with 1 outside of CI, got %d", got)
+	}
+	if got := ExitCode(nil); got != 0 {
+		t.Errorf("expected 0 on success, got %d", got)
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	root := &cobra.Command{Use: "keyway"}
+	sub := &cobra.Command{Use: "get", Args: cobra.ExactArgs(1), RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	sub.Flags().String("env", "", "")
+	root.AddCommand(sub)
+	markUsageErrors(root)
+
+	for _, args := range [][]string{{"get"}, {"get", "KEY", "--envv", "x"}} {
+		root.SetArgs(args)
+		var usage usageError
+		if err := root.Execute(); !errors.As(err, &usage) {
+			t.Errorf("expected a usage error for %v, got %v", args, err)
+		}
+	}
+	root.SetArgs([]string{"get", "KEY"})
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEnsureLogin_InCIWithoutToken(t *testing.T) {
+	t.Setenv("KEYWAY_TOKEN", "")
+	t.Setenv("KEYWAY_PROFILE", "")
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	t.Setenv("KEYWAY_CI", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	_, err := EnsureLogin()
+
+	if !errors.Is(err, errLoginRequired) {
+		t.Fatalf("expected a login error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "KEYWAY_TOKEN is not set") || !strings.Contains(err.Error(), "GitHub Actions secret") {
+		t.Errorf("expected KEYWAY_TOKEN to be suggested, got %q", err.Error())
+	}
+}
@@ -0,0 +1,446 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export some keys as an encrypted bundle for a vendor",
+	Long: `Export the keys matching --keys to an encrypted bundle, to hand a subset of
+credentials to a partner. The bundle is encrypted with a one-time key that is
+printed once and never stored: send it through another channel than the bundle.
+
+With --sign, the bundle is signed with this machine's signing key, and the
+vendor can check its fingerprint with keyway import --signer.
+
+With --unflatten, the keys are written unencrypted as a nested JSON or YAML
+config instead (by the extension of --output), splitting them on the separator:
+database__host becomes database: {host: ...} with --unflatten __.
+
+The vendor does not need a Keyway account:
+
+  keyway import keyway-export.json --key kwk1_...`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <bundle|config>",
+	Short: "Import a bundle made with keyway export, or a config file, into an env file",
+	Long: `Decrypt a bundle made with keyway export and merge its keys into an env file.
+No Keyway account is needed.
+
+With --flatten, import a nested JSON or YAML config instead, joining the path
+of each value with the separator: database: {host: ...} becomes database__host
+with --flatten __. List items are numbered from 0. keyway export --unflatten
+does the reverse.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().StringP("env", "e", "production", "Environment to export from")
+	exportCmd.Flags().StringSlice("keys", nil, "Keys or glob patterns to export (required)")
+	exportCmd.Flags().StringP("output", "o", "keyway-export.json", "Bundle file to write")
+	exportCmd.Flags().Bool("sign", false, "Sign the bundle with this machine's signing key")
+	exportCmd.Flags().String("unflatten", "", "Write an unencrypted nested config, splitting keys on this separator (e.g. __)")
+	exportCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	_ = exportCmd.MarkFlagRequired("keys")
+
+	importCmd.Flags().String("key", "", "One-time key printed by keyway export")
+	importCmd.Flags().StringP("file", "f", ".env", "Env file to merge the keys into")
+	importCmd.Flags().String("signer", "", "Expected signing key fingerprint")
+	importCmd.Flags().String("flatten", "", "Import a JSON or YAML config, joining nested keys with this separator (e.g. __)")
+	importCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+}
+
+// ExportOptions contains the parsed flags for the export command
+type ExportOptions struct {
+	EnvName   string
+	Keys      []string
+	Output    string
+	Sign      bool
+	Unflatten string
+	Yes       bool
+}
+
+// ImportOptions contains the parsed flags for the import command
+type ImportOptions struct {
+	Bundle  string // the bundle, or the config file with Flatten
+	Key     string
+	File    string
+	Signer  string
+	Flatten string
+	Yes     bool
+}
+
+// runExport is the entry point for the export command (uses default dependencies)
+func runExport(cmd *cobra.Command, args []string) error {
+	opts := ExportOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Keys, _ = cmd.Flags().GetStringSlice("keys")
+	opts.Output, _ = cmd.Flags().GetString("output")
+	opts.Sign, _ = cmd.Flags().GetBool("sign")
+	opts.Unflatten, _ = cmd.Flags().GetString("unflatten")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runExportWithDeps(opts, defaultDeps)
+}
+
+// runImport is the entry point for the import command (uses default dependencies)
+func runImport(cmd *cobra.Command, args []string) error {
+	opts := ImportOptions{Bundle: args[0]}
+	opts.Key, _ = cmd.Flags().GetString("key")
+	opts.File, _ = cmd.Flags().GetString("file")
+	opts.Signer, _ = cmd.Flags().GetString("signer")
+	opts.Flatten, _ = cmd.Flags().GetString("flatten")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runImportWithDeps(opts, defaultDeps)
+}
+
+// runExportWithDeps is the testable version of runExport
+func runExportWithDeps(opts ExportOptions, deps *Dependencies) error {
+	deps.UI.Intro("export")
+
+	if len(opts.Keys) == 0 {
+		deps.UI.Error("Keys to export are required, e.g. --keys 'VENDOR_*'")
+		return fmt.Errorf("keys are required")
+	}
+	for _, pattern := range opts.Keys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			deps.UI.Error(fmt.Sprintf("Invalid key pattern: %s", pattern))
+			return fmt.Errorf("invalid key pattern %q", pattern)
+		}
+	}
+	if opts.Output == "" {
+		opts.Output = "keyway-export.json"
+	}
+	if opts.Unflatten != "" {
+		if opts.Sign {
+			deps.UI.Error("--sign only applies to bundles, not to --unflatten")
+			return fmt.Errorf("--sign and --unflatten cannot be combined")
+		}
+		if env.ConfigFormat(opts.Output) == "" {
+			deps.UI.Error(fmt.Sprintf("Cannot tell the config format of %s, use a .json, .yaml or .yml file", opts.Output))
+			return fmt.Errorf("unsupported config file %s", opts.Output)
+		}
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return export(s, opts)
+	}, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// export seals the keys matching opts.Keys into a bundle
+func export(s *Session, opts ExportOptions) error {
+	deps, repo, envName := s.Deps, s.Repo, s.EnvName
+	var content string
+	err := s.Spin("Downloading secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, repo, envName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("export", err, deps)
+	}
+
+	secrets := selectKeys(env.ParseVault(content), opts.Keys)
+	if len(secrets) == 0 {
+		deps.UI.Warn(fmt.Sprintf("No keys of %s match %s", envName, strings.Join(opts.Keys, ", ")))
+		return nil
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	deps.UI.Message("")
+	for _, k := range keys {
+		deps.UI.Message("  " + k)
+	}
+	deps.UI.Message("")
+
+	question := fmt.Sprintf("Export %d keys of %s to %s?", len(keys), envName, opts.Output)
+	if opts.Unflatten != "" {
+		question = fmt.Sprintf("Write %d keys of %s unencrypted to %s?", len(keys), envName, opts.Output)
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(question, true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	if opts.Unflatten != "" {
+		return exportConfig(s, secrets, opts)
+	}
+
+	var signer ed25519.PrivateKey
+	if opts.Sign {
+		signer, err = loadSigningKey(deps)
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Cannot load the signing key: %s", err.Error()))
+			return err
+		}
+	}
+
+	manifest := env.BundleManifest{
+		Repo:        repo,
+		Environment: envName,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if deps.AuthStore != nil {
+		if stored, err := deps.AuthStore.GetAuth(); err == nil && stored != nil {
+			manifest.CreatedBy = stored.GitHubLogin
+		}
+	}
+	bundle, key, err := env.SealBundle(manifest, secrets, signer)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to encrypt the bundle: %s", err.Error()))
+		return err
+	}
+	if err := deps.FS.WriteFile(opts.Output, bundle, 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Output, err.Error()))
+		return err
+	}
+
+	deps.UI.Success(fmt.Sprintf("Exported %d keys to %s", len(keys), deps.UI.File(opts.Output)))
+	if signer != nil {
+		deps.UI.Step(fmt.Sprintf("Signed by %s", deps.UI.Value(env.KeyFingerprint(signer.Public().(ed25519.PublicKey)))))
+	}
+	deps.UI.Message("")
+	deps.UI.Message(fmt.Sprintf("One-time key (shown once, send it separately): %s", deps.UI.Bold(key)))
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("The vendor imports it with: keyway import %s --key <key>", filepath.Base(opts.Output))))
+
+	analytics.Track(analytics.EventPull, map[string]interface{}{
+		"repoFullName": repo,
+		"environment":  envName,
+		"target":       "bundle",
+		"signed":       opts.Sign,
+	})
+
+	return nil
+}
+
+// exportConfig writes secrets as a nested config, split on opts.Unflatten
+func exportConfig(s *Session, secrets map[string]string, opts ExportOptions) error {
+	deps := s.Deps
+	data, err := env.Unflatten(secrets, env.ConfigFormat(opts.Output), opts.Unflatten)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if err := deps.FS.WriteFile(opts.Output, data, 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Output, err.Error()))
+		return err
+	}
+
+	deps.UI.Success(fmt.Sprintf("Exported %d keys to %s", len(secrets), deps.UI.File(opts.Output)))
+	deps.UI.Warn(fmt.Sprintf("%s is not encrypted, keep it out of git", opts.Output))
+
+	analytics.Track(analytics.EventPull, map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"target":       "config",
+	})
+	return nil
+}
+
+// runImportWithDeps is the testable version of runImport
+func runImportWithDeps(opts ImportOptions, deps *Dependencies) error {
+	deps.UI.Intro("import")
+
+	if opts.Flatten != "" {
+		return importConfig(opts, deps)
+	}
+
+	data, err := deps.FS.ReadFile(opts.Bundle)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("File not found: %s", opts.Bundle))
+		return err
+	}
+	manifest, err := env.ReadBundleManifest(data)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	from := fmt.Sprintf("%s (%s)", manifest.Repo, manifest.Environment)
+	if manifest.CreatedBy != "" {
+		from += " by " + manifest.CreatedBy
+	}
+	deps.UI.Step(fmt.Sprintf("From: %s", deps.UI.Value(from)))
+	deps.UI.Step(fmt.Sprintf("Keys: %s", strings.Join(manifest.Keys, ", ")))
+
+	key := opts.Key
+	if key == "" {
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("The one-time key is required - use --key in non-interactive mode")
+			return fmt.Errorf("key is required")
+		}
+		if key, err = deps.UI.Password("One-time key:"); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := env.OpenBundle(data, key)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	if bundle.Signer != nil {
+		fingerprint := env.KeyFingerprint(bundle.Signer)
+		if opts.Signer != "" && !strings.EqualFold(strings.TrimSpace(opts.Signer), fingerprint) {
+			deps.UI.Error(fmt.Sprintf("Bundle is signed by %s, not %s", fingerprint, opts.Signer))
+			return fmt.Errorf("unexpected bundle signer %s", fingerprint)
+		}
+		deps.UI.Step(fmt.Sprintf("Signed by: %s", deps.UI.Value(fingerprint)))
+		if opts.Signer == "" {
+			deps.UI.Message(deps.UI.Dim("Check this fingerprint with the sender, or pass it with --signer"))
+		}
+	} else {
+		if opts.Signer != "" {
+			deps.UI.Error("Bundle is not signed")
+			return fmt.Errorf("bundle is not signed")
+		}
+		deps.UI.Warn("Bundle is not signed, its origin cannot be verified")
+	}
+
+	return mergeIntoEnvFile(opts.File, bundle.Secrets, opts.Yes, deps)
+}
+
+// importConfig merges the flattened keys of a JSON or YAML config into the env file
+func importConfig(opts ImportOptions, deps *Dependencies) error {
+	format := env.ConfigFormat(opts.Bundle)
+	if format == "" {
+		deps.UI.Error(fmt.Sprintf("Cannot tell the config format of %s, use a .json, .yaml or .yml file", opts.Bundle))
+		return fmt.Errorf("unsupported config file %s", opts.Bundle)
+	}
+	data, err := deps.FS.ReadFile(opts.Bundle)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("File not found: %s", opts.Bundle))
+		return err
+	}
+	secrets, err := env.Flatten(data, format, opts.Flatten)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("%s: %s", opts.Bundle, err.Error()))
+		return err
+	}
+	if len(secrets) == 0 {
+		deps.UI.Warn(fmt.Sprintf("%s has no values", opts.Bundle))
+		return nil
+	}
+	deps.UI.Step(fmt.Sprintf("Keys: %s", strings.Join(sortedKeys(secrets), ", ")))
+
+	return mergeIntoEnvFile(opts.File, secrets, opts.Yes, deps)
+}
+
+// mergeIntoEnvFile sets secrets in an env file, asking before replacing values
+func mergeIntoEnvFile(file string, secrets map[string]string, yes bool, deps *Dependencies) error {
+	existing := ""
+	if current, err := deps.FS.ReadFile(file); err == nil {
+		existing = string(current)
+	}
+	var replaced []string
+	for k, v := range env.Parse(existing) {
+		if newValue, ok := secrets[k]; ok && newValue != v {
+			replaced = append(replaced, k)
+		}
+	}
+	sort.Strings(replaced)
+
+	if len(replaced) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d keys of %s will be replaced: %s", len(replaced), file, strings.Join(replaced, ", ")))
+		if !yes && deps.UI.IsInteractive() {
+			confirm, _ := deps.UI.Confirm("Replace them?", false)
+			if !confirm {
+				deps.UI.Warn("Aborted.")
+				return nil
+			}
+		} else if !yes {
+			return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+		}
+	}
+
+	if err := deps.FS.WriteFile(file, []byte(env.Apply(existing, secrets)), 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", file, err.Error()))
+		return err
+	}
+
+	deps.UI.Success(fmt.Sprintf("Imported %d keys into %s", len(secrets), deps.UI.File(file)))
+	return nil
+}
+
+// selectKeys returns the secrets whose key matches one of the glob patterns
+func selectKeys(secrets map[string]string, patterns []string) map[string]string {
+	selected := make(map[string]string)
+	for key, value := range secrets {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, key); ok {
+				selected[key] = value
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// signingKeyPath returns the file holding this machine's bundle signing key
+func signingKeyPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "signing.key")
+}
+
+// loadSigningKey returns this machine's bundle signing key, creating it on first use
+func loadSigningKey(deps *Dependencies) (ed25519.PrivateKey, error) {
+	keyPath := signingKeyPath()
+	if keyPath == "" {
+		return nil, fmt.Errorf("could not locate the keyway config directory")
+	}
+	if data, err := deps.FS.ReadFile(keyPath); err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("%s is not a valid signing key", keyPath)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return nil, err
+	}
+	if err := deps.FS.WriteFile(keyPath, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+func TestRunExportWithDeps_SelectsKeys(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "VENDOR_TOKEN=tok_123\nVENDOR_URL=https://vendor.test\nDATABASE_URL=postgres://db\n"}
+
+	err := runExportWithDeps(ExportOptions{EnvName: "production", Keys: []string{"VENDOR_*"}, Output: "bundle.json", Sign: true, Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, ok := fsMock.Written["bundle.json"]
+	if !ok {
+		t.Fatal("expected bundle to be written")
+	}
+	manifest, err := env.ReadBundleManifest(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(manifest.Keys, ",") != "VENDOR_TOKEN,VENDOR_URL" {
+		t.Errorf("expected only vendor keys, got %v", manifest.Keys)
+	}
+
+	var key string
+	for _, msg := range uiMock.MessageCalls {
+		if _, after, ok := strings.Cut(msg, "kwk1_"); ok {
+			key = "kwk1_" + after
+		}
+	}
+	opened, err := env.OpenBundle(data, key)
+	if err != nil {
+		t.Fatalf("expected printed key to open the bundle: %v", err)
+	}
+	if opened.Signer == nil {
+		t.Error("expected bundle to be signed")
+	}
+}
+
+func TestRunExportWithDeps_NoMatch(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DATABASE_URL=postgres://db\n"}
+
+	err := runExportWithDeps(ExportOptions{EnvName: "production", Keys: []string{"VENDOR_*"}, Output: "bundle.json", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fsMock.Written["bundle.json"]; ok {
+		t.Error("expected no bundle to be written")
+	}
+}
+
+func TestRunImportWithDeps_MergesKeys(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	bundle, key, _ := env.SealBundle(env.BundleManifest{Repo: "owner/repo", Environment: "production"}, map[string]string{"VENDOR_TOKEN": "tok_123"}, nil)
+	fsMock.Files["bundle.json"] = bundle
+	fsMock.Files[".env"] = []byte("LOCAL=1\n")
+
+	err := runImportWithDeps(ImportOptions{Bundle: "bundle.json", Key: key, File: ".env"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written := env.Parse(string(fsMock.Written[".env"]))
+	if written["LOCAL"] != "1" || written["VENDOR_TOKEN"] != "tok_123" {
+		t.Errorf("unexpected env file: %v", written)
+	}
+}
+
+func TestRunImportWithDeps_SignerMismatch(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	signer, _ := loadSigningKey(deps)
+	bundle, key, _ := env.SealBundle(env.BundleManifest{}, map[string]string{"VENDOR_TOKEN": "tok_123"}, signer)
+	fsMock.Files["bundle.json"] = bundle
+
+	err := runImportWithDeps(ImportOptions{Bundle: "bundle.json", Key: key, File: ".env", Signer: "0000:0000"}, deps)
+
+	if err == nil {
+		t.Fatal("expected signer mismatch error")
+	}
+	if _, ok := fsMock.Written[".env"]; ok {
+		t.Error("expected env file not to be written")
+	}
+}
+
+func TestRunExportWithDeps_Unflatten(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "database__host=db.internal\ndatabase__port=5432\nAPI_KEY=sk_123\n"}
+
+	err := runExportWithDeps(ExportOptions{EnvName: "development", Keys: []string{"database__*"}, Output: "config.yaml", Unflatten: "__", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := env.Flatten(fsMock.Written["config.yaml"], env.ConfigYAML, "__")
+	if err != nil {
+		t.Fatalf("expected a YAML config: %v", err)
+	}
+	if len(got) != 2 || got["database__host"] != "db.internal" || got["database__port"] != "5432" {
+		t.Errorf("unexpected config: %v", got)
+	}
+	if len(uiMock.WarnCalls) == 0 {
+		t.Error("expected a warning that the file is not encrypted")
+	}
+}
+
+func TestRunExportWithDeps_UnflattenRejectsSignAndUnknownFormat(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runExportWithDeps(ExportOptions{EnvName: "development", Keys: []string{"*"}, Output: "config.yaml", Unflatten: "__", Sign: true, Yes: true}, deps); err == nil {
+		t.Error("expected an error for --sign with --unflatten")
+	}
+	if err := runExportWithDeps(ExportOptions{EnvName: "development", Keys: []string{"*"}, Output: "config.toml", Unflatten: "__", Yes: true}, deps); err == nil {
+		t.Error("expected an error for an unknown config format")
+	}
+}
+
+func TestRunImportWithDeps_Flatten(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["config.yaml"] = []byte("database:\n  host: db.internal\n  port: 5432\n")
+	fsMock.Files[".env"] = []byte("LOCAL=1\n")
+
+	err := runImportWithDeps(ImportOptions{Bundle: "config.yaml", File: ".env", Flatten: "__"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written := env.Parse(string(fsMock.Written[".env"]))
+	if written["LOCAL"] != "1" || written["database__host"] != "db.internal" || written["database__port"] != "5432" {
+		t.Errorf("unexpected env file: %v", written)
+	}
+}
+
+func TestRunImportWithDeps_FlattenAsksBeforeReplacing(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["config.json"] = []byte(`{"database": {"host": "new"}}`)
+	fsMock.Files[".env"] = []byte("database__host=old\n")
+
+	err := runImportWithDeps(ImportOptions{Bundle: "config.json", File: ".env", Flatten: "__"}, deps)
+
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected a confirmation error, got %v", err)
+	}
+	if _, ok := fsMock.Written[".env"]; ok {
+		t.Error("expected env file not to be written")
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var fileCmd = &cobra.Command{
+	Use:   "file",
+	Short: "Store small files (service accounts, keystores) as secrets",
+	Long: fmt.Sprintf(`Store small files, up to %d KB, as secrets.
+
+keyway run writes them to a private temporary directory and sets their
+variable to the file path. keyway pull writes them to the path set under
+"files" in .keyway.json, and puts that path in the env file:
+
+  {
+    "files": { "GCP_SA_JSON": "secrets/gcp-sa.json" }
+  }`, env.MaxFileSize/1024),
+}
+
+var filePushCmd = &cobra.Command{
+	Use:   "push <path>",
+	Short: "Store a file as a secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFilePush,
+}
+
+func init() {
+	filePushCmd.Flags().String("as", "", "Key to store the file under (required)")
+	filePushCmd.Flags().StringP("env", "e", "", "Environment name (default: development)")
+	filePushCmd.Flags().BoolP("yes", "y", false, "Skip confirmation if the key exists")
+
+	fileCmd.AddCommand(filePushCmd)
+}
+
+// FilePushOptions contains the parsed flags for the file push command
+type FilePushOptions struct {
+	Path       string
+	Key        string
+	EnvName    string
+	EnvFlagSet bool
+	Yes        bool
+}
+
+// runFilePush is the entry point for the file push command (uses default dependencies)
+func runFilePush(cmd *cobra.Command, args []string) error {
+	opts := FilePushOptions{
+		Path:       args[0],
+		EnvFlagSet: cmd.Flags().Changed("env"),
+	}
+	opts.Key, _ = cmd.Flags().GetString("as")
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runFilePushWithDeps(opts, defaultDeps)
+}
+
+// runFilePushWithDeps is the testable version of runFilePush
+func runFilePushWithDeps(opts FilePushOptions, deps *Dependencies) error {
+	deps.UI.Intro("file push")
+
+	if opts.Key == "" {
+		deps.UI.Error("Key is required, e.g. --as GCP_SA_JSON")
+		return fmt.Errorf("key is required")
+	}
+	if !isValidKeyName(opts.Key) {
+		deps.UI.Error("Key must contain only alphanumeric characters and underscores")
+		return fmt.Errorf("invalid key format")
+	}
+
+	data, err := deps.FS.ReadFile(opts.Path)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("File not found: %s", opts.Path))
+		return err
+	}
+	value, err := env.EncodeFile(filepath.Base(opts.Path), data)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot store %s: %s", opts.Path, err.Error()))
+		return err
+	}
+
+	deps.UI.Step(fmt.Sprintf("File: %s (%d bytes)", deps.UI.File(opts.Path), len(data)))
+	deps.UI.Step(fmt.Sprintf("Key: %s", deps.UI.Value(opts.Key)))
+
+	return runSetRemote(SetOptions{
+		Key:        opts.Key,
+		Value:      value,
+		EnvName:    opts.EnvName,
+		EnvFlagSet: opts.EnvFlagSet,
+		Yes:        opts.Yes,
+	}, deps)
+}
+
+// writePulledFiles writes the secrets holding files to their path declared in
+// .keyway.json, and returns the paths by key. Files without a path are left
+// in the env file as is, with a warning.
+func writePulledFiles(secrets map[string]string, deps *Dependencies) (map[string]string, error) {
+	var keys []string
+	for k, v := range secrets {
+		if env.IsFile(v) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, key := range keys {
+		path := project.Files[key]
+		if path == "" {
+			deps.UI.Warn(fmt.Sprintf("%s holds a file, set its path under \"files\" in %s to write it", key, config.ProjectFile))
+			continue
+		}
+		file, _ := env.DecodeFile(secrets[key])
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+		if err := deps.FS.WriteFile(path, file.Data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		deps.UI.Step(fmt.Sprintf("File: %s → %s", key, deps.UI.File(path)))
+		paths[key] = path
+	}
+	return paths, nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+func TestRunFilePushWithDeps_Success(t *testing.T) {
+	deps, _, _, _, fsMock, _, apiMock := NewTestDepsWithEnv()
+	fsMock.Files["gcp-sa.json"] = []byte(`{"type":"service_account"}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secret saved"}
+
+	err := runFilePushWithDeps(FilePushOptions{Path: "gcp-sa.json", Key: "GCP_SA_JSON", EnvName: "production", EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file, ok := env.DecodeFile(apiMock.PushedSecrets["GCP_SA_JSON"])
+	if !ok || file.Name != "gcp-sa.json" || string(file.Data) != `{"type":"service_account"}` {
+		t.Errorf("expected the file to be pushed, got %v", apiMock.PushedSecrets)
+	}
+	if apiMock.PushedSecrets["API_KEY"] != "secret" {
+		t.Error("expected existing secrets to be kept")
+	}
+}
+
+func TestRunFilePushWithDeps_RequiresKey(t *testing.T) {
+	deps, _, _, _, fsMock, _, apiMock := NewTestDepsWithEnv()
+	fsMock.Files["gcp-sa.json"] = []byte("{}")
+
+	err := runFilePushWithDeps(FilePushOptions{Path: "gcp-sa.json"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error without --as")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
+
+func TestRunFilePushWithDeps_TooLarge(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _, apiMock := NewTestDepsWithEnv()
+	fsMock.Files["big.bin"] = make([]byte, env.MaxFileSize+1)
+
+	err := runFilePushWithDeps(FilePushOptions{Path: "big.bin", Key: "BIG"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for a file over the limit")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "limit") {
+		t.Errorf("expected size limit error, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPullWithDeps_WritesFileSecrets(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	path := filepath.Join(t.TempDir(), "secrets", "gcp-sa.json")
+	encoded, _ := env.EncodeFile("gcp-sa.json", []byte("{}"))
+	fsMock.Files[".keyway.json"] = []byte(fmt.Sprintf(`{"files": {"GCP_SA_JSON": %q}}`, path))
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\nGCP_SA_JSON=" + encoded + "\n"}
+
+	err := runPullWithDeps(PullOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(fsMock.Written[path]) != "{}" {
+		t.Errorf("expected the file to be written to %s, got %v", path, fsMock.Written)
+	}
+	pulled := env.Parse(string(fsMock.Written[".env"]))
+	if pulled["GCP_SA_JSON"] != path {
+		t.Errorf("expected the env file to hold the path, got %q", pulled["GCP_SA_JSON"])
+	}
+}
+
+func TestRunPushWithDeps_KeepsVaultFiles(t *testing.T) {
+	deps, _, _, _, fsMock, _, apiMock := NewTestDepsWithEnv()
+	encoded, _ := env.EncodeFile("gcp-sa.json", []byte("{}"))
+	fsMock.Files[".env"] = []byte("API_KEY=new\nGCP_SA_JSON=secrets/gcp-sa.json\n")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old\nGCP_SA_JSON=" + encoded + "\n"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets["GCP_SA_JSON"] != encoded {
+		t.Errorf("expected the vault file to be kept, got %q", apiMock.PushedSecrets["GCP_SA_JSON"])
+	}
+	if apiMock.PushedSecrets["API_KEY"] != "new" {
+		t.Errorf("expected API_KEY to be updated, got %q", apiMock.PushedSecrets["API_KEY"])
+	}
+}
+
+func TestRunRunWithDeps_InjectsFilePaths(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	encoded, _ := env.EncodeFile("gcp-sa.json", []byte(`{"type":"service_account"}`))
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "GCP_SA_JSON=" + encoded + "\n"}
+
+	var seen string
+	cmdRunner.OnRun = func(secrets map[string]string) {
+		data, _ := os.ReadFile(secrets["GCP_SA_JSON"])
+		seen = string(data)
+	}
+
+	err := runRunWithDeps(RunOptions{EnvName: "development", EnvFlagSet: true, Command: "node", Args: []string{"app.js"}}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen != `{"type":"service_account"}` {
+		t.Errorf("expected the command to read the file, got %q", seen)
+	}
+	if _, err := os.Stat(cmdRunner.LastSecrets["GCP_SA_JSON"]); !os.IsNotExist(err) {
+		t.Error("expected the file to be removed after the command")
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// sensitiveKeyRegex matches key names whose value is typed without echo
+var sensitiveKeyRegex = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|PRIVATE|CREDENTIAL|(^|_)KEY$|API_?KEY|DSN)`)
+
+// pullFromTemplate is what keyway pull offers when the vault can't be read,
+// e.g. an external contributor working on a fork: fill the env file from the
+// repository's committed template (.env.example...) instead of stopping there
+func pullFromTemplate(repo, file string, deps *Dependencies) error {
+	deps.UI.Warn(fmt.Sprintf("You don't have access to the vault of %s", repo))
+
+	template, content := findEnvTemplate(file, deps)
+	if template == "" {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Ask a maintainer of %s for access, or create %s yourself", repo, file)))
+		return fmt.Errorf("no access to the vault of %s", repo)
+	}
+	if !deps.UI.IsInteractive() {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Start from the template: cp %s %s", template, file)))
+		return fmt.Errorf("no access to the vault of %s", repo)
+	}
+	create, _ := deps.UI.Confirm(fmt.Sprintf("Create %s from %s instead?", file, template), true)
+	if !create {
+		return fmt.Errorf("no access to the vault of %s", repo)
+	}
+
+	// Values already in the env file are kept unless replaced
+	local := map[string]string{}
+	if data, err := deps.FS.ReadFile(file); err == nil {
+		local = env.Parse(string(data))
+	}
+	example := env.Parse(content)
+
+	values := make(map[string]string)
+	empty := 0
+	for _, key := range templateKeys(content) {
+		current, hasCurrent := local[key]
+		if !hasCurrent && !env.IsPlaceholder(example[key]) {
+			current = example[key]
+		}
+
+		var value string
+		var err error
+		if sensitiveKeyRegex.MatchString(key) {
+			prompt := fmt.Sprintf("%s:", key)
+			if current != "" {
+				prompt = fmt.Sprintf("%s (Enter to keep the current value):", key)
+			}
+			if value, err = deps.UI.Password(prompt); err == nil && value == "" {
+				value = current
+			}
+		} else {
+			value, err = deps.UI.Input(fmt.Sprintf("%s:", key), current)
+		}
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			empty++
+		}
+		values[key] = value
+	}
+
+	if err := deps.FS.WriteFile(filepath.Clean(file), []byte(env.Apply(content, values)), 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", file, err.Error()))
+		return err
+	}
+
+	analytics.Track(analytics.EventPull, map[string]interface{}{
+		"repoFullName": repo,
+		"source":       "template",
+		"keyCount":     len(values),
+	})
+
+	deps.UI.Success(fmt.Sprintf("Created %s from %s", file, template))
+	if empty > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d key(s) left empty, fill them in %s when you need them", empty, file)))
+	}
+	return nil
+}
+
+// findEnvTemplate returns the first template documenting file, and its content
+func findEnvTemplate(file string, deps *Dependencies) (string, string) {
+	for _, candidate := range env.TemplateCandidates(file) {
+		if data, err := deps.FS.ReadFile(candidate); err == nil {
+			return candidate, string(data)
+		}
+	}
+	return "", ""
+}
+
+// templateKeys returns the keys of env file content, in the file's order
+func templateKeys(content string) []string {
+	return env.Keys(content)
+}
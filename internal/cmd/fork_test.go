@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+func TestRunPullWithDeps_NoAccessFillsFromTemplate(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = true
+	uiMock.InputResults = []string{"8080"}
+	uiMock.PasswordResult = "sk_test_123"
+	apiMock.PullError = &api.APIError{StatusCode: 403, Detail: "No access"}
+	fsMock.Files[".env.example"] = []byte("# Server\nPORT=3000\nNODE_ENV=development\n\n# Stripe\nSTRIPE_SECRET_KEY=your-stripe-key\n")
+
+	opts := PullOptions{EnvName: "development", File: ".env", EnvFlagSet: true}
+	if err := runPullWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(uiMock.InputCalls, []string{"PORT:", "NODE_ENV:"}) {
+		t.Errorf("unexpected prompts: %v", uiMock.InputCalls)
+	}
+	if !reflect.DeepEqual(uiMock.PasswordCalls, []string{"STRIPE_SECRET_KEY:"}) {
+		t.Errorf("expected a masked prompt for the secret, got %v", uiMock.PasswordCalls)
+	}
+
+	written := string(fsMock.Written[".env"])
+	if !strings.HasPrefix(written, "# Server\n") || !strings.Contains(written, "# Stripe\n") {
+		t.Errorf("expected the template's comments to be kept, got:\n%s", written)
+	}
+	got := env.Parse(written)
+	want := map[string]string{"PORT": "8080", "NODE_ENV": "development", "STRIPE_SECRET_KEY": "sk_test_123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("written values = %v, want %v", got, want)
+	}
+}
+
+func TestRunPullWithDeps_NoAccessNonInteractive(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.PullError = &api.APIError{StatusCode: 403, Detail: "No access"}
+	fsMock.Files[".env.example"] = []byte("PORT=3000\n")
+
+	opts := PullOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}
+	if err := runPullWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error in non-interactive mode")
+	}
+	if _, ok := fsMock.Written[".env"]; ok {
+		t.Error("expected no file written")
+	}
+	if !strings.Contains(strings.Join(uiMock.MessageCalls, "\n"), "cp .env.example .env") {
+		t.Errorf("expected a hint to copy the template, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunPullWithDeps_NoAccessWithoutTemplate(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	apiMock.PullError = &api.APIError{StatusCode: 403, Detail: "No access"}
+
+	opts := PullOptions{EnvName: "development", File: ".env", EnvFlagSet: true}
+	if err := runPullWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error without a template")
+	}
+	if len(uiMock.ConfirmCalls) != 0 {
+		t.Errorf("expected no offer without a template, got %v", uiMock.ConfirmCalls)
+	}
+}
+
+func TestPullFromTemplate_KeepsLocalValues(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = true
+	fsMock.Files[".env.staging.example"] = []byte("API_TOKEN=<token>\nLOG_LEVEL=info\n")
+	fsMock.Files[".env.staging"] = []byte("API_TOKEN=tok_local\n")
+
+	if err := pullFromTemplate("acme/api", ".env.staging", deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := env.Parse(string(fsMock.Written[".env.staging"]))
+	if got["API_TOKEN"] != "tok_local" || got["LOG_LEVEL"] != "info" {
+		t.Errorf("unexpected values: %v", got)
+	}
+	if uiMock.PasswordCalls[0] != "API_TOKEN (Enter to keep the current value):" {
+		t.Errorf("unexpected prompt: %v", uiMock.PasswordCalls)
+	}
+}
+
+func TestTemplateKeys(t *testing.T) {
+	got := templateKeys("# c\nB=1\nA=2\n\nB=3\nC=\n")
+	if !reflect.DeepEqual(got, []string{"B", "A", "C"}) {
+		t.Errorf("templateKeys() = %v", got)
+	}
+}
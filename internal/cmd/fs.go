@@ -1,13 +1,75 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/filelock"
 )
 
+// writeLockTimeout is how long a write waits for another keyway process
+// writing the same file
+const writeLockTimeout = 10 * time.Second
+
 // osReadFile wraps os.ReadFile
 var osReadFile = os.ReadFile
 
-// osWriteFile wraps os.WriteFile with proper permissions
+// osWriteFile writes a file under a lock shared by keyway processes, so that
+// concurrent writes are queued. The content is written aside then renamed, so
+// the file is never seen interleaved or truncated.
 var osWriteFile = func(name string, data []byte, perm uint32) error {
-	return os.WriteFile(name, data, os.FileMode(perm))
+	// Write through symlinks rather than replacing them
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved
+	}
+	lock, err := filelock.Acquire(writeLockPath(name), writeLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	// Like os.WriteFile, perm only applies to new files
+	mode := os.FileMode(perm)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".keyway-"+filepath.Base(name)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// writeLockPath returns the lock file of a file, kept in the state directory
+// so that no lock file shows up next to env files
+func writeLockPath(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	dir := config.GetStateDir()
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "keyway")
+	}
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(dir, "locks", hex.EncodeToString(sum[:16])+".lock")
 }
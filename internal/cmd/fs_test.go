@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOsWriteFile_ConcurrentWritesNeverInterleave(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), ".env")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content := strings.Repeat(fmt.Sprintf("KEY_%d=%d\n", i, i), 2000)
+			if err := osWriteFile(path, []byte(content), 0600); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2000 {
+		t.Fatalf("expected a complete write, got %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if line != lines[0] {
+			t.Fatalf("expected a single writer's content, got %q and %q", lines[0], line)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected no leftover temp file or lock next to the file, got %d entries", len(entries))
+	}
+}
+
+func TestOsWriteFile_KeepsModeAndSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and symlinks differ on Windows")
+	}
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.env")
+	if err := os.WriteFile(target, []byte("A=1\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".env")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := osWriteFile(link, []byte("A=2\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Error("expected the symlink to be kept")
+	}
+	info, _ := os.Stat(target)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected the mode to be kept, got %v", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "A=2\n" {
+		t.Errorf("expected the target to be written, got %q", data)
+	}
+}
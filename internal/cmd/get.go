@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the value of one key, copy it, or show it as a QR code",
+	Long: `Print the value of one key, for scripts, or hand it over without it being
+echoed in plaintext:
+
+  --copy  copies the value to the clipboard, and clears the clipboard after
+          --timeout unless something else was copied since
+  --qr    shows the value as a QR code to scan with a phone (e.g. a TOTP seed),
+          on a separate screen cleared after --timeout
+
+Both wait until the timeout, or Ctrl+C, to clear the value.
+
+  --plain  prints the value exactly, without the trailing newline, e.g. to
+           pipe it into a file or another program byte for byte
+
+  --inspect  shows what the value holds instead of the value: JSON indented,
+             certificates with their subject and expiry, JWTs with their
+             header and claims. Strings, private keys and custom claims are
+             masked, except for config keys of .keyway.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGet,
+}
+
+func init() {
+	getCmd.Flags().StringP("env", "e", "development", "Environment name")
+	getCmd.Flags().Bool("copy", false, "Copy the value to the clipboard instead of printing it")
+	getCmd.Flags().Bool("qr", false, "Show the value as a QR code instead of printing it")
+	getCmd.Flags().Bool("inspect", false, "Show a masked, type-aware preview of the value (JSON, PEM, JWT)")
+	getCmd.Flags().Bool("plain", false, "Print the value exactly, without a trailing newline")
+	getCmd.Flags().Duration("timeout", defaultRevealTimeout, "How long the copied value or the QR code stays available")
+}
+
+// defaultRevealTimeout is how long a copied value or a QR code stays available
+const defaultRevealTimeout = 30 * time.Second
+
+// getOutput is where keyway get prints the value or the QR code
+var getOutput io.Writer = os.Stdout
+
+// GetOptions contains the parsed flags for the get command
+type GetOptions struct {
+	Key     string
+	EnvName string
+	Copy    bool
+	QR      bool
+	Inspect bool
+	Timeout time.Duration
+	Plain   bool
+}
+
+// runGet is the entry point for the get command (uses default dependencies)
+func runGet(cmd *cobra.Command, args []string) error {
+	opts := GetOptions{Key: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Copy, _ = cmd.Flags().GetBool("copy")
+	opts.QR, _ = cmd.Flags().GetBool("qr")
+	opts.Inspect, _ = cmd.Flags().GetBool("inspect")
+	opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	opts.Plain, _ = cmd.Flags().GetBool("plain")
+
+	deps := defaultDeps
+	if !opts.Copy && !opts.QR {
+		// stdout only carries the value
+		deps = withQuietUI(deps)
+	}
+	return runGetWithDeps(opts, deps)
+}
+
+// runGetWithDeps is the testable version of runGet
+func runGetWithDeps(opts GetOptions, deps *Dependencies) error {
+	deps.UI.Intro("get")
+
+	if opts.Copy && opts.QR {
+		deps.UI.Error("--copy and --qr cannot be combined")
+		return fmt.Errorf("--copy and --qr cannot be combined")
+	}
+	if opts.Inspect && (opts.Copy || opts.QR) {
+		deps.UI.Error("--inspect cannot be combined with --copy or --qr")
+		return fmt.Errorf("--inspect cannot be combined with --copy or --qr")
+	}
+	if opts.Plain && (opts.Copy || opts.QR || opts.Inspect) {
+		deps.UI.Error("--plain cannot be combined with --copy, --qr or --inspect")
+		return fmt.Errorf("--plain cannot be combined with --copy, --qr or --inspect")
+	}
+	if opts.QR && !deps.UI.IsInteractive() {
+		// A QR code in a log is the value in plaintext, for anyone with a phone
+		deps.UI.Error("--qr needs an interactive terminal")
+		return fmt.Errorf("--qr needs an interactive terminal")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRevealTimeout
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return get(s, opts)
+	}, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// get fetches the value of a key and hands it over
+func get(s *Session, opts GetOptions) error {
+	deps := s.Deps
+	var content string
+	err := s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("get", err, deps)
+	}
+
+	secrets, err := applyDerived(env.ParseVault(content), deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	value, ok := secrets[opts.Key]
+	if !ok {
+		deps.UI.Error(fmt.Sprintf("%s is not in %s", opts.Key, s.EnvName))
+		return fmt.Errorf("%s is not in %s", opts.Key, s.EnvName)
+	}
+
+	switch {
+	case opts.Copy:
+		return copyValue(opts.Key, value, opts.Timeout, deps)
+	case opts.QR:
+		return showQRCode(opts.Key, value, opts.Timeout, deps)
+	case opts.Inspect:
+		return inspectValue(opts.Key, value, deps)
+	case opts.Plain:
+		fmt.Fprint(getOutput, value)
+		return nil
+	default:
+		fmt.Fprintln(getOutput, value)
+		return nil
+	}
+}
+
+// inspectValue prints a type-aware preview of a value. Config keys of
+// .keyway.json are not secret and shown unmasked.
+func inspectValue(key, value string, deps *Dependencies) error {
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	preview := env.Inspect(value, project.KeyClass(key) == config.ClassConfig, time.Now())
+	fmt.Fprintf(getOutput, "%s (%s)\n", key, preview.Kind)
+	for _, line := range preview.Lines {
+		fmt.Fprintf(getOutput, "  %s\n", line)
+	}
+	return nil
+}
+
+// copyValue copies a value to the clipboard and clears it after timeout,
+// unless something else was copied in the meantime
+func copyValue(key, value string, timeout time.Duration, deps *Dependencies) error {
+	if err := deps.Clipboard.WriteAll(value); err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot copy to the clipboard: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Copied %s to the clipboard", key))
+	deps.UI.Warn(fmt.Sprintf("The clipboard will be cleared in %s, Ctrl+C to clear it now", timeout))
+
+	waitForExpiry(timeout)
+
+	if current, err := deps.Clipboard.ReadAll(); err == nil && current == value {
+		_ = deps.Clipboard.WriteAll("")
+		deps.UI.Info("Clipboard cleared")
+	}
+	return nil
+}
+
+// showQRCode shows a value as a QR code on the terminal's alternate screen,
+// which leaves nothing in the scrollback once it is cleared after timeout
+func showQRCode(key, value string, timeout time.Duration, deps *Dependencies) error {
+	qr, err := qrcode.New(value, qrcode.Medium)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot encode %s as a QR code: %s", key, err.Error()))
+		return err
+	}
+
+	fmt.Fprint(getOutput, "\033[?1049h\033[H")
+	fmt.Fprint(getOutput, qr.ToSmallString(false))
+	fmt.Fprintf(getOutput, "\n  %s - scan it now, it will be cleared in %s (Ctrl+C to clear it now)\n", key, timeout)
+
+	waitForExpiry(timeout)
+
+	fmt.Fprint(getOutput, "\033[?1049l")
+	deps.UI.Info(fmt.Sprintf("QR code of %s cleared", key))
+	return nil
+}
+
+// waitForExpiry returns after timeout, or earlier on Ctrl+C
+func waitForExpiry(timeout time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(timeout):
+	}
+}
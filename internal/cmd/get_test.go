@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// captureGetOutput redirects what keyway get prints for the test
+func captureGetOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := getOutput
+	getOutput = &buf
+	t.Cleanup(func() { getOutput = previous })
+	return &buf
+}
+
+func TestRunGetWithDeps_PrintsValue(t *testing.T) {
+	out := captureGetOutput(t)
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_123\nOTHER=x\n"}
+
+	if err := runGetWithDeps(GetOptions{Key: "API_KEY", EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "sk_live_123\n" {
+		t.Errorf("expected only the value, got %q", out.String())
+	}
+}
+
+func TestRunGetWithDeps_Plain(t *testing.T) {
+	out := captureGetOutput(t)
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_123\nOTHER=x\n"}
+
+	if err := runGetWithDeps(GetOptions{Key: "API_KEY", EnvName: "production", Plain: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "sk_live_123" {
+		t.Errorf("expected the value without a newline, got %q", out.String())
+	}
+}
+
+func TestRunGetWithDeps_MissingKey(t *testing.T) {
+	captureGetOutput(t)
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "OTHER=x\n"}
+
+	if err := runGetWithDeps(GetOptions{Key: "API_KEY", EnvName: "staging"}, deps); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "API_KEY is not in staging" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunGetWithDeps_CopyClearsClipboard(t *testing.T) {
+	out := captureGetOutput(t)
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_123\n"}
+	clip := deps.Clipboard.(*MockClipboard)
+
+	err := runGetWithDeps(GetOptions{Key: "API_KEY", EnvName: "production", Copy: true, Timeout: time.Millisecond}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(clip.Writes) != 2 || clip.Writes[0] != "sk_live_123" || clip.Writes[1] != "" {
+		t.Errorf("expected the value to be copied then cleared, got %q", clip.Writes)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing printed, got %q", out.String())
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.Contains(uiMock.WarnCalls[0], "cleared in 1ms") {
+		t.Errorf("expected an expiry warning, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunGetWithDeps_QRCode(t *testing.T) {
+	out := captureGetOutput(t)
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "TOTP_SEED=JBSWY3DPEHPK3PXP\n"}
+
+	err := runGetWithDeps(GetOptions{Key: "TOTP_SEED", EnvName: "production", QR: true, Timeout: time.Millisecond}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "JBSWY3DPEHPK3PXP") {
+		t.Error("the value should not be printed in plaintext")
+	}
+	if !strings.HasPrefix(out.String(), "\033[?1049h") || !strings.HasSuffix(out.String(), "\033[?1049l") {
+		t.Error("expected the QR code on the alternate screen, left once expired")
+	}
+	if !strings.Contains(out.String(), "cleared in 1ms") {
+		t.Error("expected an expiry warning next to the QR code")
+	}
+}
+
+func TestRunGetWithDeps_InvalidModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        GetOptions
+		interactive bool
+		message     string
+	}{
+		{"copy and qr", GetOptions{Key: "K", EnvName: "staging", Copy: true, QR: true}, true, "--copy and --qr cannot be combined"},
+		{"qr without terminal", GetOptions{Key: "K", EnvName: "staging", QR: true}, false, "--qr needs an interactive terminal"},
+		{"plain and copy", GetOptions{Key: "K", EnvName: "staging", Plain: true, Copy: true}, true, "--plain cannot be combined with --copy, --qr or --inspect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, _, _, uiMock, _, _ := NewTestDeps()
+			uiMock.Interactive = tt.interactive
+
+			if err := runGetWithDeps(tt.opts, deps); err == nil {
+				t.Fatal("expected an error")
+			}
+			if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != tt.message {
+				t.Errorf("expected %q, got %v", tt.message, uiMock.ErrorCalls)
+			}
+		})
+	}
+}
+
+func TestRunGetWithDeps_Inspect(t *testing.T) {
+	out := captureGetOutput(t)
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{"config": ["FEATURES"]}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: `CREDENTIALS='{"user":"admin","password":"hunter22"}'` + "\n" + `FEATURES='{"beta":"on"}'` + "\n"}
+
+	if err := runGetWithDeps(GetOptions{Key: "CREDENTIALS", EnvName: "production", Inspect: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "CREDENTIALS (json)\n") || !strings.Contains(out.String(), `"password": "**22 (8 chars)"`) {
+		t.Errorf("expected a masked JSON preview, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "hunter22") {
+		t.Errorf("secret value leaked: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runGetWithDeps(GetOptions{Key: "FEATURES", EnvName: "production", Inspect: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"beta": "on"`) {
+		t.Errorf("expected a config key to be shown as is, got %q", out.String())
+	}
+}
+
+func TestRunGetWithDeps_InspectWithCopy(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runGetWithDeps(GetOptions{Key: "API_KEY", Inspect: true, Copy: true}, deps); err == nil {
+		t.Error("expected --inspect and --copy to be rejected")
+	}
+}
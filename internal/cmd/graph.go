@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var envGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export a graph of environments, derived keys and sync targets",
+	Long: `Export a Mermaid or Graphviz (DOT) graph of how configuration flows in this
+repository: the vault's environments, the file each one is pulled to
+("outputs" in .keyway.json), the providers each one syncs to, and the keys
+derived from other keys ("derived" in .keyway.json).
+
+The graph holds key names only, never values, and can be embedded in docs.`,
+	Args: cobra.NoArgs,
+	RunE: runEnvGraph,
+}
+
+func init() {
+	envGraphCmd.Flags().String("format", graphFormatMermaid, "Output format: mermaid or dot")
+	envGraphCmd.Flags().StringP("output", "o", "", "Write the graph to a file instead of stdout")
+
+	envCmd.AddCommand(envGraphCmd)
+}
+
+// Graph output formats
+const (
+	graphFormatMermaid = "mermaid"
+	graphFormatDOT     = "dot"
+)
+
+// EnvGraphOptions contains the parsed flags for the env graph command
+type EnvGraphOptions struct {
+	Format string
+	Output string
+}
+
+// envGraph is what keyway envs graph draws
+type envGraph struct {
+	Envs    []string
+	Outputs map[string]string   // environment -> file it is pulled to
+	Derived map[string][]string // derived key -> keys it is built from
+	Links   []api.SyncLink
+}
+
+// runEnvGraph is the entry point for the env graph command (uses default dependencies)
+func runEnvGraph(cmd *cobra.Command, args []string) error {
+	opts := EnvGraphOptions{}
+	opts.Format, _ = cmd.Flags().GetString("format")
+	opts.Output, _ = cmd.Flags().GetString("output")
+
+	deps := defaultDeps
+	if opts.Output == "" {
+		// stdout only carries the graph
+		deps = withQuietUI(deps)
+	}
+	return runEnvGraphWithDeps(opts, deps)
+}
+
+// runEnvGraphWithDeps is the testable version of runEnvGraph
+func runEnvGraphWithDeps(opts EnvGraphOptions, deps *Dependencies) error {
+	deps.UI.Intro("env graph")
+
+	if opts.Format == "" {
+		opts.Format = graphFormatMermaid
+	}
+	if opts.Format != graphFormatMermaid && opts.Format != graphFormatDOT {
+		deps.UI.Error(fmt.Sprintf("Unknown format %q, use mermaid or dot", opts.Format))
+		return fmt.Errorf("unknown format %q", opts.Format)
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return envGraphRender(s, opts)
+	}, withProject, withRepo, withLogin)
+}
+
+// envGraphRender builds the graph of the repository and writes it out
+func envGraphRender(s *Session, opts EnvGraphOptions) error {
+	deps, project := s.Deps, s.Project
+	var envs []string
+	var links []api.SyncLink
+	err := s.Spin("Fetching environments...", func() error {
+		var err error
+		if envs, err = s.Client.GetVaultEnvironments(s.Ctx, s.Repo); err != nil {
+			return err
+		}
+		links, err = s.Client.GetSyncLinks(s.Ctx, s.Repo)
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			links, err = nil, nil
+		}
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env graph", err, deps)
+	}
+
+	graph := envGraph{
+		Outputs: make(map[string]string),
+		Derived: make(map[string][]string),
+		Links:   links,
+	}
+	seen := make(map[string]bool)
+	addEnv := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			graph.Envs = append(graph.Envs, name)
+		}
+	}
+	for _, e := range envs {
+		addEnv(e)
+	}
+	for e := range project.Outputs {
+		addEnv(e)
+		graph.Outputs[e] = project.OutputFile(e)
+	}
+	for _, link := range links {
+		addEnv(link.KeywayEnvironment)
+	}
+	sort.Strings(graph.Envs)
+	for key, template := range project.Derived {
+		graph.Derived[key] = env.References(template)
+	}
+
+	var out string
+	if opts.Format == graphFormatDOT {
+		out = renderGraphDOT(graph)
+	} else {
+		out = renderGraphMermaid(graph)
+	}
+
+	if opts.Output == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := deps.FS.WriteFile(opts.Output, []byte(out), 0644); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Output, err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Wrote the graph of %d environments to %s", len(graph.Envs), deps.UI.File(opts.Output)))
+	return nil
+}
+
+// graphNodeID turns a name into an identifier valid in Mermaid and DOT
+func graphNodeID(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// syncLinkLabel describes a sync target, e.g. "vercel: web (production)"
+func syncLinkLabel(link api.SyncLink) string {
+	project := link.ProjectID
+	if link.ProjectName != nil && *link.ProjectName != "" {
+		project = *link.ProjectName
+	}
+	label := fmt.Sprintf("%s: %s", link.Provider, project)
+	if link.ProviderEnvironment != "" {
+		label += fmt.Sprintf(" (%s)", link.ProviderEnvironment)
+	}
+	return label
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderGraphMermaid renders the graph as a Mermaid flowchart
+func renderGraphMermaid(g envGraph) string {
+	label := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	b.WriteString("  subgraph environments [Environments]\n")
+	for _, e := range g.Envs {
+		fmt.Fprintf(&b, "    %s([%s])\n", graphNodeID("env_", e), label(e))
+	}
+	b.WriteString("  end\n")
+	for _, e := range sortedKeys(g.Outputs) {
+		fmt.Fprintf(&b, "  %s -- pull --> %s[/%s/]\n", graphNodeID("env_", e), graphNodeID("file_", e), label(g.Outputs[e]))
+	}
+	for i, link := range g.Links {
+		fmt.Fprintf(&b, "  %s -- sync --> %s[%s]\n", graphNodeID("env_", link.KeywayEnvironment), fmt.Sprintf("sync_%d", i), label(syncLinkLabel(link)))
+	}
+	if len(g.Derived) > 0 {
+		b.WriteString("  subgraph derived [Derived keys]\n")
+		for _, key := range sortedKeys(g.Derived) {
+			for _, ref := range g.Derived[key] {
+				fmt.Fprintf(&b, "    %s[%s] --> %s[%s]\n", graphNodeID("key_", ref), label(ref), graphNodeID("key_", key), label(key))
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	return b.String()
+}
+
+// renderGraphDOT renders the graph in Graphviz DOT
+func renderGraphDOT(g envGraph) string {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph keyway {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	b.WriteString("  subgraph cluster_environments {\n")
+	b.WriteString("    label=\"Environments\";\n")
+	for _, e := range g.Envs {
+		fmt.Fprintf(&b, "    %s [label=%s, shape=ellipse];\n", graphNodeID("env_", e), quote(e))
+	}
+	b.WriteString("  }\n")
+	for _, e := range sortedKeys(g.Outputs) {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=note];\n", graphNodeID("file_", e), quote(g.Outputs[e]))
+		fmt.Fprintf(&b, "  %s -> %s [label=\"pull\"];\n", graphNodeID("env_", e), graphNodeID("file_", e))
+	}
+	for i, link := range g.Links {
+		fmt.Fprintf(&b, "  sync_%d [label=%s, shape=component];\n", i, quote(syncLinkLabel(link)))
+		fmt.Fprintf(&b, "  %s -> sync_%d [label=\"sync\"];\n", graphNodeID("env_", link.KeywayEnvironment), i)
+	}
+	if len(g.Derived) > 0 {
+		b.WriteString("  subgraph cluster_derived {\n")
+		b.WriteString("    label=\"Derived keys\";\n")
+		for _, key := range sortedKeys(g.Derived) {
+			for _, ref := range g.Derived[key] {
+				fmt.Fprintf(&b, "    %s [label=%s];\n", graphNodeID("key_", ref), quote(ref))
+				fmt.Fprintf(&b, "    %s [label=%s];\n", graphNodeID("key_", key), quote(key))
+				fmt.Fprintf(&b, "    %s -> %s;\n", graphNodeID("key_", ref), graphNodeID("key_", key))
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
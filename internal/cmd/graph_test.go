@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+const graphProjectConfig = `{
+  "outputs": {"production": "deploy/{env}.env"},
+  "derived": {"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app"}
+}`
+
+func TestRunEnvGraphWithDeps_Mermaid(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(graphProjectConfig)
+	apiMock.VaultEnvs = []string{"development", "production"}
+	name := "web"
+	apiMock.SyncLinks = []api.SyncLink{{Provider: "vercel", ProjectName: &name, KeywayEnvironment: "production", ProviderEnvironment: "production"}}
+
+	err := runEnvGraphWithDeps(EnvGraphOptions{Output: "graph.mmd"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	graph := string(fsMock.Written["graph.mmd"])
+	for _, want := range []string{
+		"flowchart LR",
+		`env_development(["development"])`,
+		`env_production -- pull --> file_production[/"deploy/production.env"/]`,
+		`env_production -- sync --> sync_0["vercel: web (production)"]`,
+		`key_DB_HOST["DB_HOST"] --> key_DATABASE_URL["DATABASE_URL"]`,
+	} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("expected graph to contain %q, got:\n%s", want, graph)
+		}
+	}
+}
+
+func TestRunEnvGraphWithDeps_DOT(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(graphProjectConfig)
+	apiMock.VaultEnvs = []string{"staging"}
+	apiMock.SyncLinksError = &api.APIError{StatusCode: 404}
+
+	err := runEnvGraphWithDeps(EnvGraphOptions{Format: "dot", Output: "graph.dot"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	graph := string(fsMock.Written["graph.dot"])
+	for _, want := range []string{
+		"digraph keyway {",
+		`env_staging [label="staging", shape=ellipse];`,
+		`env_production -> file_production [label="pull"];`,
+		"key_DB_USER -> key_DATABASE_URL;",
+	} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("expected graph to contain %q, got:\n%s", want, graph)
+		}
+	}
+}
+
+func TestRunEnvGraphWithDeps_UnknownFormat(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runEnvGraphWithDeps(EnvGraphOptions{Format: "svg"}, deps); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// healthCheckInterval is the pause between two attempts of a failing health
+// check, replaced in tests
+var healthCheckInterval = 5 * time.Second
+
+// HealthCheckResult is the outcome of a health check run after a push
+type HealthCheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthChecksFor returns the health checks of .keyway.json to run after a
+// push to envName. Their commands come with the repository, so they are
+// trusted like validators, before anything is pushed.
+func healthChecksFor(repo, envName string, trust bool, deps *Dependencies) ([]config.HealthCheck, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return nil, err
+	}
+	checks := project.HealthChecksFor(envName)
+	for _, check := range checks {
+		if len(check.Exec) == 0 {
+			continue
+		}
+		hook := config.Validator{Name: fmt.Sprintf("health check %s", check.Name), Exec: check.Exec}
+		if err := ensureValidatorTrusted(repo, hook, trust, deps); err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
+
+// verifyPush runs the health checks once a push to envName is applied. When
+// one fails, it offers to restore the snapshot taken before the push, or
+// restores it without asking with rollback (--rollback-on-failure).
+func verifyPush(ctx context.Context, client api.APIClient, repo, envName, snapshotID string, checks []config.HealthCheck, secrets map[string]string, rollback bool, deps *Dependencies) ([]HealthCheckResult, error) {
+	results := make([]HealthCheckResult, 0, len(checks))
+	var failed []string
+	for _, check := range checks {
+		err := deps.UI.Spin(fmt.Sprintf("Checking %s...", check.Name), func() error {
+			return runHealthCheck(check, secrets, deps)
+		})
+		result := HealthCheckResult{Name: check.Name, Passed: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			failed = append(failed, check.Name)
+			deps.UI.Error(fmt.Sprintf("Health check %s failed: %s", check.Name, err.Error()))
+		} else {
+			deps.UI.Success(fmt.Sprintf("Health check %s passed", check.Name))
+		}
+		results = append(results, result)
+	}
+	if len(failed) == 0 {
+		return results, nil
+	}
+
+	failure := fmt.Errorf("health check failed after pushing to %s", envName)
+	if snapshotID == "" {
+		deps.UI.Warn(fmt.Sprintf("No snapshot was taken before the push, %s cannot be restored automatically", envName))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Find the previous version with: keyway history -e %s", envName)))
+		return results, failure
+	}
+
+	if !rollback && deps.UI.IsInteractive() {
+		rollback, _ = deps.UI.Confirm(fmt.Sprintf("Roll %s back to its state before the push?", envName), true)
+	}
+	if !rollback {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Roll back with: keyway undo -e %s", envName)))
+		return results, failure
+	}
+
+	err := deps.UI.Spin("Restoring snapshot...", func() error {
+		return client.RestoreSnapshot(ctx, repo, envName, snapshotID)
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Could not roll %s back: %s", envName, err.Error()))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Retry with: keyway undo -e %s", envName)))
+		return results, failure
+	}
+	forgetPrefetched(repo, envName, deps)
+
+	// The push is rolled back, keyway undo must not restore the snapshot again
+	history := loadPushHistory(deps)
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].SnapshotID == snapshotID {
+			history = append(history[:i], history[i+1:]...)
+			_ = savePushHistory(history, deps)
+			break
+		}
+	}
+	deps.UI.Success(fmt.Sprintf("Rolled %s back to its state before the push", envName))
+	return results, failure
+}
+
+// runHealthCheck runs a check until it passes or its wait is over
+func runHealthCheck(check config.HealthCheck, secrets map[string]string, deps *Dependencies) error {
+	deadline := time.Now().Add(check.WaitDuration())
+	for {
+		err := healthCheckOnce(check, secrets, deps)
+		if err == nil || !time.Now().Before(deadline) {
+			return err
+		}
+		time.Sleep(healthCheckInterval)
+	}
+}
+
+// healthCheckOnce requests the URL of a check, or runs its command with the
+// secrets of the environment
+func healthCheckOnce(check config.HealthCheck, secrets map[string]string, deps *Dependencies) error {
+	if check.URL == "" {
+		return deps.CmdRunner.RunCommand(check.Exec[0], check.Exec[1:], secrets)
+	}
+	status, err := deps.HTTP.Get(check.URL)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("%s answered %d", check.URL, status)
+	}
+	return nil
+}
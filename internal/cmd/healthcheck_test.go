@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// healthCheckProject declares an HTTP check for every environment and a
+// command for production only
+const healthCheckProject = `{"healthChecks": [
+	{"name": "api", "url": "https://api.acme.dev/healthz"},
+	{"name": "smoke", "exec": ["./smoke"], "environments": ["production"]}
+]}`
+
+// pushWithHealthChecks sets up a push of API_KEY=new to an environment
+func pushWithHealthChecks(t *testing.T, envName string) (*Dependencies, *MockUIProvider, *MockAPIClient, *MockHTTPClient) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=new\n")
+	fsMock.Files[".keyway.json"] = []byte(healthCheckProject)
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: envName}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old\n"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+	return deps, uiMock, apiMock, deps.HTTP.(*MockHTTPClient)
+}
+
+// writtenPushHistory returns the pushes keyway undo last saw written
+func writtenPushHistory(deps *Dependencies) []pushRecord {
+	var history []pushRecord
+	_ = json.Unmarshal(deps.FS.(*MockFileSystem).Written[pushHistoryPath()], &history)
+	return history
+}
+
+func TestRunPushWithDeps_HealthChecksPass(t *testing.T) {
+	deps, uiMock, apiMock, httpMock := pushWithHealthChecks(t, "production")
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	var secrets map[string]string
+	runner.OnRun = func(s map[string]string) { secrets = s }
+
+	opts := PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true, TrustValidators: true}
+	if err := runPushWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(httpMock.GetURLs, []string{"https://api.acme.dev/healthz"}) {
+		t.Errorf("expected the health endpoint to be requested, got %v", httpMock.GetURLs)
+	}
+	if runner.LastCommand != "./smoke" || secrets["API_KEY"] != "new" {
+		t.Errorf("expected the command to run with the new secrets, got %s %v", runner.LastCommand, secrets)
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Error("nothing must be rolled back")
+	}
+	if !slices.Contains(uiMock.SuccessCalls, "Health check smoke passed") {
+		t.Errorf("expected the checks to be reported, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunPushWithDeps_HealthCheckFailsRollsBack(t *testing.T) {
+	deps, _, apiMock, httpMock := pushWithHealthChecks(t, "staging")
+	httpMock.StatusCode = 503
+
+	opts := PushOptions{EnvName: "staging", File: ".env", Yes: true, EnvFlagSet: true, RollbackOnFailure: true}
+	if err := runPushWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error when a health check fails")
+	}
+	if apiMock.PushedByEnv["staging"]["API_KEY"] != "new" {
+		t.Errorf("expected the push to be applied first, got %v", apiMock.PushedByEnv)
+	}
+	if apiMock.RestoredSnapshot != "snap_1" {
+		t.Errorf("expected the snapshot to be restored, got %q", apiMock.RestoredSnapshot)
+	}
+}
+
+func TestVerifyPush_ForgetsRolledBackPush(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	deps.HTTP.(*MockHTTPClient).StatusCode = 500
+	data, _ := json.Marshal([]pushRecord{
+		{Repo: "owner/repo", Env: "staging", SnapshotID: "snap_0"},
+		{Repo: "owner/repo", Env: "staging", SnapshotID: "snap_1"},
+	})
+	fsMock.Files[pushHistoryPath()] = data
+	checks := []config.HealthCheck{{Name: "api", URL: "https://api.acme.dev/healthz"}}
+
+	results, err := verifyPush(context.Background(), apiMock, "owner/repo", "staging", "snap_1", checks, nil, true, deps)
+	if err == nil {
+		t.Fatal("expected an error when a health check fails")
+	}
+	if len(results) != 1 || results[0].Passed || results[0].Error != "https://api.acme.dev/healthz answered 500" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if apiMock.RestoredSnapshot != "snap_1" {
+		t.Errorf("expected the snapshot to be restored, got %q", apiMock.RestoredSnapshot)
+	}
+	if history := writtenPushHistory(deps); len(history) != 1 || history[0].SnapshotID != "snap_0" {
+		t.Errorf("expected only the rolled back push to be forgotten, got %v", history)
+	}
+}
+
+func TestRunPushWithDeps_HealthCheckFailsAsks(t *testing.T) {
+	deps, uiMock, apiMock, _ := pushWithHealthChecks(t, "production")
+	deps.CmdRunner.(*MockCommandRunner).RunError = errors.New("exit status 1")
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = false
+
+	opts := PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true, TrustValidators: true}
+	if err := runPushWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error when a health check fails")
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Error("the rollback was declined")
+	}
+	if history := writtenPushHistory(deps); len(history) != 1 {
+		t.Errorf("expected the push to stay undoable, got %v", history)
+	}
+}
+
+func TestRunPushWithDeps_NoHealthCheck(t *testing.T) {
+	deps, _, _, httpMock := pushWithHealthChecks(t, "staging")
+	httpMock.StatusCode = 503
+
+	opts := PushOptions{EnvName: "staging", File: ".env", Yes: true, EnvFlagSet: true, NoHealthCheck: true}
+	if err := runPushWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(httpMock.GetURLs) != 0 {
+		t.Errorf("expected no check to run, got %v", httpMock.GetURLs)
+	}
+}
+
+func TestRunPushWithDeps_UntrustedHealthCheck(t *testing.T) {
+	deps, _, apiMock, _ := pushWithHealthChecks(t, "production")
+
+	opts := PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}
+	if err := runPushWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error for an untrusted command")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("nothing must be pushed before the checks are trusted")
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [KEY]",
+	Short: "Show the versions of an environment, or of one key",
+	Long: `Show the versions of an environment, newest first: who pushed, when and
+which keys each push added, changed or removed. Values are never shown.
+
+With a key, only the versions that changed it are listed.
+
+--template formats the output with a Go template, which gets .repository,
+.environment, .key and .versions, each version having .version, .actor,
+.createdAt, .added, .changed and .removed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringP("env", "e", "development", "Environment name")
+	historyCmd.Flags().IntP("limit", "n", 20, "Number of versions to show")
+	addTemplateFlag(historyCmd)
+}
+
+// HistoryOptions contains the parsed flags for the history command
+type HistoryOptions struct {
+	EnvName    string
+	Key        string
+	Limit      int
+	JSONOutput bool
+	Template   string
+}
+
+// historyView is what --template gets from keyway history
+type historyView struct {
+	Repository  string                   `json:"repository"`
+	Environment string                   `json:"environment"`
+	Key         string                   `json:"key,omitempty"`
+	Versions    []api.EnvironmentVersion `json:"versions"`
+}
+
+// runHistory is the entry point for the history command (uses default dependencies)
+func runHistory(cmd *cobra.Command, args []string) error {
+	opts := HistoryOptions{}
+	if len(args) > 0 {
+		opts.Key = args[0]
+	}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runHistoryWithDeps(opts, deps)
+}
+
+// runHistoryWithDeps is the testable version of runHistory
+func runHistoryWithDeps(opts HistoryOptions, deps *Dependencies) error {
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return history(s, opts, tmpl)
+	}, withIntro("history"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// history shows the versions of an environment, or the ones changing a key
+func history(s *Session, opts HistoryOptions, tmpl *template.Template) error {
+	deps := s.Deps
+	var versions []api.EnvironmentVersion
+	err := s.Spin("Fetching history...", func() error {
+		var err error
+		versions, err = s.Client.ListVersions(s.Ctx, s.Repo, s.EnvName, api.VersionFilter{Key: opts.Key, Limit: opts.Limit})
+		return err
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 && opts.Key == "" {
+			err = fmt.Errorf("the history of %s is not available", s.EnvName)
+			deps.UI.Error(err.Error())
+			return err
+		}
+		return reportEnvError("history", err, deps)
+	}
+	if versions == nil {
+		versions = []api.EnvironmentVersion{}
+	}
+
+	if tmpl != nil {
+		return printTemplate(tmpl, historyView{Repository: s.Repo, Environment: s.EnvName, Key: opts.Key, Versions: versions}, deps)
+	}
+	if opts.JSONOutput {
+		output, err := json.MarshalIndent(versions, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(versions) == 0 {
+		if opts.Key != "" {
+			deps.UI.Info(fmt.Sprintf("No version of %s changed %s", s.EnvName, opts.Key))
+		} else {
+			deps.UI.Info(fmt.Sprintf("%s has no versions yet", s.EnvName))
+		}
+		return nil
+	}
+
+	header := []string{"VERSION", "WHEN", "BY", "CHANGES"}
+	if opts.Key != "" {
+		header[3] = opts.Key
+	}
+	rows := make([][]string, 0, len(versions))
+	for _, v := range versions {
+		changes := versionChanges(v)
+		if opts.Key != "" {
+			changes = keyAction(v, opts.Key)
+		}
+		rows = append(rows, []string{fmt.Sprintf("v%d", v.Version), formatEventTime(v.CreatedAt), v.Actor, changes})
+	}
+
+	if !deps.UI.IsInteractive() {
+		for _, row := range rows {
+			fmt.Fprintln(getOutput, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+
+	lines := formatTable(append([][]string{header}, rows...))
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(lines[0]))
+	for _, line := range lines[1:] {
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("See what a version held with: keyway diff %s --against v%d", s.EnvName, versions[0].Version)))
+	if len(versions) > 1 && opts.Key == "" {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Undo the last version with: keyway rollback v%d -e %s", versions[1].Version, s.EnvName)))
+	}
+	deps.UI.Outro(fmt.Sprintf("%d versions of %s", len(versions), s.EnvName))
+	return nil
+}
+
+// versionChanges summarizes the keys a version added, changed and removed
+func versionChanges(v api.EnvironmentVersion) string {
+	var parts []string
+	if len(v.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("+%d", len(v.Added)))
+	}
+	if len(v.Changed) > 0 {
+		parts = append(parts, fmt.Sprintf("~%d", len(v.Changed)))
+	}
+	if len(v.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("-%d", len(v.Removed)))
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, " ")
+}
+
+// keyAction says what a version did to key
+func keyAction(v api.EnvironmentVersion, key string) string {
+	for _, k := range v.Added {
+		if k == key {
+			return "added"
+		}
+	}
+	for _, k := range v.Removed {
+		if k == key {
+			return "removed"
+		}
+	}
+	return "changed"
+}
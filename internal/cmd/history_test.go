@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+var productionVersions = []api.EnvironmentVersion{
+	{Version: 12, Actor: "alice", CreatedAt: "2024-06-02T10:00:00Z", Added: []string{"SENTRY_DSN"}, Changed: []string{"STRIPE_KEY", "API_KEY"}},
+	{Version: 11, Actor: "bob", CreatedAt: "2024-06-01T09:00:00Z", Removed: []string{"LEGACY_TOKEN"}},
+}
+
+func TestRunHistoryWithDeps(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	apiMock.Versions = productionVersions
+
+	if err := runHistoryWithDeps(HistoryOptions{EnvName: "production", Limit: 20}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	for _, want := range []string{"v12", "alice", "+1 ~2", "v11", "-1"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in the history, got %s", want, output)
+		}
+	}
+	if apiMock.VersionFilter.Limit != 20 || apiMock.VersionFilter.Key != "" {
+		t.Errorf("unexpected filter: %+v", apiMock.VersionFilter)
+	}
+}
+
+func TestRunHistoryWithDeps_Key(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	apiMock.Versions = []api.EnvironmentVersion{
+		{Version: 12, Actor: "alice", CreatedAt: "2024-06-02T10:00:00Z", Changed: []string{"STRIPE_KEY"}},
+		{Version: 3, Actor: "bob", CreatedAt: "2024-01-10T09:00:00Z", Added: []string{"STRIPE_KEY"}},
+	}
+
+	if err := runHistoryWithDeps(HistoryOptions{EnvName: "production", Key: "STRIPE_KEY"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.VersionFilter.Key != "STRIPE_KEY" {
+		t.Errorf("expected the key to be sent, got %+v", apiMock.VersionFilter)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(output, "changed") || !strings.Contains(output, "added") {
+		t.Errorf("expected what each version did to the key, got %s", output)
+	}
+}
+
+func TestRunHistoryWithDeps_JSON(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Versions = productionVersions
+
+	output := captureStdout(t, func() {
+		if err := runHistoryWithDeps(HistoryOptions{EnvName: "production", JSONOutput: true}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var versions []api.EnvironmentVersion
+	if err := json.Unmarshal([]byte(output), &versions); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	if len(versions) != 2 || versions[0].Version != 12 || len(versions[0].Changed) != 2 {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+}
+
+func TestRunHistoryWithDeps_NotAvailable(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VersionsError = &api.APIError{StatusCode: 404}
+
+	err := runHistoryWithDeps(HistoryOptions{EnvName: "production"}, deps)
+	if err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("expected the history to be unavailable, got %v", err)
+	}
+}
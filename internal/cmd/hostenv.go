@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// checkHostEnvironment enforces the label of a host on commands using its
+// secrets: on a host labeled production, the environment must be given with
+// --env and be production, so that nothing falls back to development. It
+// returns the label, "" on unlabeled hosts.
+func checkHostEnvironment(envName string, envFlagSet bool, deps *Dependencies) (string, error) {
+	label := normalizeEnvName(config.GetHostEnvironment())
+	if label == "" {
+		return "", nil
+	}
+	if !envFlagSet {
+		err := fmt.Errorf("this host is labeled %s: pass --env %s explicitly", label, label)
+		deps.UI.Error(err.Error())
+		return label, err
+	}
+	if normalizeEnvName(envName) != label {
+		err := fmt.Errorf("this host is labeled %s, refusing to use %s secrets", label, envName)
+		deps.UI.Error(err.Error())
+		deps.UI.Message(deps.UI.Dim("The label comes from KEYWAY_HOST_ENV or the host-env file of keyway's system config"))
+		return label, err
+	}
+	return label, nil
+}
+
+// recordHostInvocation records the use of an environment's secrets on a
+// labeled host in the vault's audit log. A failure is reported, not fatal,
+// so that an audit outage doesn't take the service down with it.
+func recordHostInvocation(ctx context.Context, client api.APIClient, repo, envName, label, command, program string, deps *Dependencies) {
+	hostname, _ := os.Hostname()
+	invocation := api.Invocation{Command: command, Hostname: hostname, HostLabel: label}
+	if program != "" {
+		// Only the program: its arguments may hold secrets
+		invocation.Program = filepath.Base(program)
+	}
+	if err := client.RecordInvocation(ctx, repo, envName, invocation); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Could not record this %s in the audit log: %s", command, err.Error()))
+	}
+}
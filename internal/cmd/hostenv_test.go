@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/cache"
+)
+
+func TestRunRunWithDeps_LabeledHostNeedsExplicitEnvironment(t *testing.T) {
+	t.Setenv("KEYWAY_HOST_ENV", "production")
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=prod\n"}
+
+	// The development default is refused
+	err := runRunWithDeps(RunOptions{EnvName: "development", Command: "./server"}, deps)
+	if err == nil || !strings.Contains(err.Error(), "pass --env production") {
+		t.Fatalf("expected the default environment to be refused, got %v", err)
+	}
+	// So is another environment given explicitly
+	err = runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server"}, deps)
+	if err == nil || !strings.Contains(err.Error(), "refusing to use staging") {
+		t.Fatalf("expected another environment to be refused, got %v", err)
+	}
+	if cmdRunner.LastCommand != "" || len(apiMock.Invocations) != 0 {
+		t.Fatal("expected nothing to run nor be recorded")
+	}
+
+	err = runRunWithDeps(RunOptions{EnvName: "prod", EnvFlagSet: true, Command: "/srv/app/server", Args: []string{"--token", "secret"}}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.Invocations) != 1 {
+		t.Fatalf("expected the run to be recorded, got %v", apiMock.Invocations)
+	}
+	inv := apiMock.Invocations[0]
+	if inv.Command != "run" || inv.Program != "server" || inv.HostLabel != "production" {
+		t.Errorf("unexpected invocation %+v", inv)
+	}
+}
+
+func TestRunRunWithDeps_LabeledHostIgnoresPrefetchCache(t *testing.T) {
+	t.Setenv("KEYWAY_HOST_ENV", "production")
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	deps.Cache = &MockSecretCache{Entries: map[string]cache.Entry{
+		"owner/repo/production": {Repo: "owner/repo", Environment: "production", Content: "API_KEY=cached\n", FetchedAt: time.Now()},
+	}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=fresh\n"}
+
+	if err := runRunWithDeps(RunOptions{EnvName: "production", EnvFlagSet: true, Command: "./server"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "fresh" {
+		t.Errorf("expected the secrets to be fetched, got %v", cmdRunner.LastSecrets)
+	}
+}
+
+func TestRunPullWithDeps_LabeledHost(t *testing.T) {
+	t.Setenv("KEYWAY_HOST_ENV", "production")
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=prod\n"}
+	apiMock.InvocationError = errors.New("service unavailable")
+
+	err := runPullWithDeps(PullOptions{EnvName: "development", File: ".env", Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "pass --env production") {
+		t.Fatalf("expected the default environment to be refused, got %v", err)
+	}
+
+	// An audit outage is reported without failing the pull
+	if err := runPullWithDeps(PullOptions{EnvName: "production", EnvFlagSet: true, File: ".env", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.Invocations) != 1 || apiMock.Invocations[0].Command != "pull" {
+		t.Errorf("expected the pull to be recorded, got %v", apiMock.Invocations)
+	}
+	if !strings.Contains(strings.Join(uiMock.WarnCalls, "\n"), "audit log") {
+		t.Errorf("expected the audit failure to be reported, got %v", uiMock.WarnCalls)
+	}
+}
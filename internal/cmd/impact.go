@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var impactCmd = &cobra.Command{
+	Use:   "impact <KEY>",
+	Short: "Show which derived keys and environments a change would affect",
+	Long: `Show the impact of changing a key before you change it.
+
+Derived keys are declared in .keyway.json as templates over other keys:
+
+  {
+    "derived": {
+      "DATABASE_URL": "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app"
+    }
+  }
+
+keyway run and keyway pull recompute derived values, so changing DB_HOST
+also changes DATABASE_URL everywhere DB_HOST is set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImpact,
+}
+
+func init() {
+	impactCmd.Flags().StringP("env", "e", "", "Only check this environment (default: all)")
+}
+
+// ImpactOptions contains the parsed flags for the impact command
+type ImpactOptions struct {
+	Key     string
+	EnvName string
+}
+
+// runImpact is the entry point for the impact command (uses default dependencies)
+func runImpact(cmd *cobra.Command, args []string) error {
+	opts := ImpactOptions{Key: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runImpactWithDeps(opts, defaultDeps)
+}
+
+// runImpactWithDeps is the testable version of runImpact
+func runImpactWithDeps(opts ImpactOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return impact(s, opts)
+	}, withIntro("impact"), withProject, withRepo, withLogin)
+}
+
+// impact lists the keys derived from the key and the environments that set them
+func impact(s *Session, opts ImpactOptions) error {
+	deps := s.Deps
+	dependents := env.Dependents(s.Project.Derived, opts.Key)
+	if len(dependents) == 0 {
+		deps.UI.Info(fmt.Sprintf("No derived keys depend on %s", opts.Key))
+	} else {
+		deps.UI.Message(fmt.Sprintf("Derived from %s (%d):", deps.UI.Bold(opts.Key), len(dependents)))
+		for _, key := range dependents {
+			deps.UI.DiffChanged(key)
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("    %s", s.Project.Derived[key])))
+		}
+		deps.UI.Message("")
+	}
+
+	envs := []string{opts.EnvName}
+	if opts.EnvName == "" {
+		err := s.Spin("Fetching environments...", func() error {
+			var err error
+			envs, err = s.Client.GetVaultEnvironments(s.Ctx, s.Repo)
+			return err
+		})
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to fetch environments: %v", err))
+			return err
+		}
+	}
+
+	// Look up which environments define the key or any of its dependents
+	keys := append([]string{opts.Key}, dependents...)
+	var rows []impactRow
+	err := s.Spin("Checking environments...", func() error {
+		var err error
+		rows, err = checkImpact(s.Ctx, s.Client, s.Repo, envs, keys)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	affected := 0
+	for _, row := range rows {
+		switch {
+		case row.Err != nil:
+			deps.UI.Warn(fmt.Sprintf("%s: could not be read (%s)", row.Env, row.Err.Error()))
+		case len(row.Keys) == 0:
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("  %s: not affected", row.Env)))
+		default:
+			affected++
+			deps.UI.Message(fmt.Sprintf("  %s: %s", deps.UI.Bold(row.Env), strings.Join(row.Keys, ", ")))
+		}
+	}
+
+	deps.UI.Outro(fmt.Sprintf("%d environment(s) affected by a change to %s", affected, opts.Key))
+	return nil
+}
+
+// impactRow lists the keys of interest that are set in one environment
+type impactRow struct {
+	Env  string
+	Keys []string
+	Err  error
+}
+
+// checkImpact pulls each environment and reports which of keys are affected there.
+// keys[0] is the key being changed, the rest are its dependents.
+// Auth errors abort the check, other errors are reported per environment.
+func checkImpact(ctx context.Context, client api.APIClient, repo string, envs, keys []string) ([]impactRow, error) {
+	rows := make([]impactRow, 0, len(envs))
+	for _, envName := range envs {
+		resp, err := client.PullSecrets(ctx, repo, envName)
+		if err != nil {
+			if isAuthError(err) {
+				return nil, err
+			}
+			rows = append(rows, impactRow{Env: envName, Err: err})
+			continue
+		}
+		secrets := env.ParseVault(resp.Content)
+
+		// Derived values are recomputed wherever their source key is set,
+		// even if they are not stored in the vault
+		row := impactRow{Env: envName}
+		if _, ok := secrets[keys[0]]; ok {
+			row.Keys = keys
+		} else {
+			for _, key := range keys[1:] {
+				if _, ok := secrets[key]; ok {
+					row.Keys = append(row.Keys, key)
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+const testProjectConfig = `{"derived": {"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app"}}`
+
+func TestRunImpactWithDeps_ListsDerivedAndEnvironments(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(testProjectConfig)
+	apiMock.VaultEnvs = []string{"production"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DB_HOST=db\nDB_USER=app"}
+
+	err := runImpactWithDeps(ImpactOptions{Key: "DB_HOST"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.DiffChangedCalls) != 1 || uiMock.DiffChangedCalls[0] != "DATABASE_URL" {
+		t.Errorf("expected DATABASE_URL as dependent, got %v", uiMock.DiffChangedCalls)
+	}
+	if len(uiMock.OutroCalls) != 1 || uiMock.OutroCalls[0] != "1 environment(s) affected by a change to DB_HOST" {
+		t.Errorf("unexpected outro: %v", uiMock.OutroCalls)
+	}
+}
+
+func TestCheckImpact(t *testing.T) {
+	apiMock := &MockAPIClient{PullResponse: &api.PullSecretsResponse{Content: "DATABASE_URL=x"}}
+
+	rows, err := checkImpact(context.Background(), apiMock, "owner/repo", []string{"staging"}, []string{"DB_HOST", "DATABASE_URL"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 1 || len(rows[0].Keys) != 1 || rows[0].Keys[0] != "DATABASE_URL" {
+		t.Errorf("expected only the stored derived key, got %+v", rows)
+	}
+}
+
+func TestRunImpactWithDeps_InvalidProjectConfig(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{invalid`)
+
+	err := runImpactWithDeps(ImpactOptions{Key: "DB_HOST"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for invalid .keyway.json")
+	}
+	if len(uiMock.ErrorCalls) == 0 {
+		t.Error("expected UI.Error to be called")
+	}
+}
+
+func TestRunRunWithDeps_RecomputesDerived(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	fsMock.Files[".keyway.json"] = []byte(testProjectConfig)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DB_HOST=db\nDB_USER=app\nDATABASE_URL=stale"}
+
+	err := runRunWithDeps(RunOptions{EnvName: "development", EnvFlagSet: true, Command: "env"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.LastSecrets["DATABASE_URL"] != "postgres://app@db/app" {
+		t.Errorf("expected recomputed DATABASE_URL, got %q", runner.LastSecrets["DATABASE_URL"])
+	}
+}
+
+func TestRunPullWithDeps_RecomputesDerived(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{"derived": {"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app", "BROKEN": "${NOPE}"}}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DB_HOST=db\nDB_USER=app\n"}
+
+	err := runPullWithDeps(PullOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(fsMock.Written[".env"]); got != "DB_HOST=db\nDB_USER=app\nDATABASE_URL=postgres://app@db/app\n" {
+		t.Errorf("unexpected .env content: %q", got)
+	}
+	if len(uiMock.WarnCalls) != 1 || uiMock.WarnCalls[0] != "Cannot derive BROKEN: missing NOPE" {
+		t.Errorf("expected warning for BROKEN, got %v", uiMock.WarnCalls)
+	}
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -33,7 +32,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 // runInitWithDeps is the testable version of runInit
 func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
-	deps.UI.Intro("init")
+	return runPipeline(deps, initVault, withIntro("init"), withRepo)
+}
+
+// initVault creates the vault of the repository, after the GitHub App is
+// installed, and offers to push the env files found
+func initVault(s *Session) error {
+	deps := s.Deps
+	repo := s.Repo
 
 	// Check gitignore
 	if !deps.Git.CheckEnvGitignore() {
@@ -48,13 +54,6 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 		}
 	}
 
-	repo, err := deps.Git.DetectRepo()
-	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
-		return err
-	}
-	deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(repo)))
-
 	// Check for monorepo setup and warn user
 	monorepoInfo := deps.Git.DetectMonorepo()
 	if monorepoInfo.IsMonorepo {
@@ -70,28 +69,22 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 	}
 
 	// Ensure login and GitHub App
-	token, err := ensureLoginAndGitHubAppWithDeps(repo, deps)
-	if err != nil {
+	if err := s.Login(); err != nil {
+		return err
+	}
+	if err := ensureGitHubApp(s); err != nil {
 		deps.UI.Error(err.Error())
 		return err
 	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
-
-	// Check if vault already exists (single API call)
-	vaultDetails, err := client.GetVaultDetails(ctx, repo)
-	if err != nil {
-		// Handle auth errors (expired token)
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
-			}
-			// Retry with new token
-			client = deps.APIFactory.NewClient(newToken)
-			vaultDetails, err = client.GetVaultDetails(ctx, repo)
+	// Check if vault already exists (single API call). A missing vault is an
+	// error here, so the call doesn't go through a spinner.
+	vaultDetails, err := s.Client.GetVaultDetails(s.Ctx, repo)
+	if isAuthError(err) {
+		if err := s.Relogin(err); err != nil {
+			return err
 		}
+		vaultDetails, err = s.Client.GetVaultDetails(s.Ctx, repo)
 	}
 
 	// Check if vault exists based on error type
@@ -138,36 +131,13 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 	})
 
 	// Create vault
-	err = deps.UI.Spin("Creating vault...", func() error {
-		_, err := client.InitVault(ctx, repo)
+	err = s.Spin("Creating vault...", func() error {
+		_, err := s.Client.InitVault(s.Ctx, repo)
 		return err
 	})
 
 	if err != nil {
 		if apiErr, ok := err.(*api.APIError); ok {
-			// Handle auth errors (expired token)
-			if apiErr.StatusCode == 401 {
-				newToken, authErr := handleAuthError(err, deps)
-				if authErr != nil {
-					return authErr
-				}
-				// Retry with new token
-				client = deps.APIFactory.NewClient(newToken)
-				err = deps.UI.Spin("Creating vault...", func() error {
-					_, err := client.InitVault(ctx, repo)
-					return err
-				})
-				if err == nil {
-					goto vaultCreated
-				}
-				// If still error, continue to handle it
-				apiErr, ok = err.(*api.APIError)
-				if !ok {
-					deps.UI.Error(err.Error())
-					return err
-				}
-			}
-
 			// Already exists (409 Conflict)
 			if apiErr.StatusCode == 409 {
 				deps.UI.Success("Already initialized!")
@@ -199,7 +169,7 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 					var trialResult *api.StartTrialResponse
 					trialErr := deps.UI.Spin("Starting trial...", func() error {
 						var err error
-						trialResult, err = client.StartOrganizationTrial(ctx, trialInfo.OrgLogin)
+						trialResult, err = s.Client.StartOrganizationTrial(s.Ctx, trialInfo.OrgLogin)
 						return err
 					})
 
@@ -212,7 +182,7 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 
 					// Retry vault creation now that trial is active
 					err = deps.UI.Spin("Creating vault...", func() error {
-						_, err := client.InitVault(ctx, repo)
+						_, err := s.Client.InitVault(s.Ctx, repo)
 						return err
 					})
 
@@ -230,7 +200,7 @@ func runInitWithDeps(opts InitOptions, deps *Dependencies) error {
 			})
 			deps.UI.Error(apiErr.Error())
 			if apiErr.UpgradeURL != "" {
-				deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(apiErr.UpgradeURL)))
+				deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
 			}
 		} else {
 			analytics.Track(analytics.EventError, map[string]interface{}{
@@ -258,7 +228,7 @@ vaultCreated:
 	}
 
 	// Check for env files and offer to push
-	candidates := deps.Env.Discover()
+	candidates := discoverEnvFiles(deps)
 	if len(candidates) > 0 && deps.UI.IsInteractive() {
 		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Found %d env file(s): %s", len(candidates), formatEnvCandidates(candidates))))
 
@@ -304,30 +274,25 @@ func buildDeepLinkInstallURL(baseURL string, repoIds *api.RepoIds) string {
 		strings.TrimSuffix(baseURL, "/"), repoIds.OwnerID, repoIds.RepoID)
 }
 
-func ensureLoginAndGitHubAppWithDeps(repo string, deps *Dependencies) (string, error) {
-	// First ensure login
-	token, err := deps.Auth.EnsureLogin()
-	if err != nil {
-		return "", err
-	}
+// ensureGitHubApp makes sure the GitHub App is installed on the repository of
+// a logged in session, offering to install it
+func ensureGitHubApp(s *Session) error {
+	deps, repo, client, ctx := s.Deps, s.Repo, s.Client, s.Ctx
 
 	// Check GitHub App installation
 	parts := strings.Split(repo, "/")
 	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid repository format: %s", repo)
+		return fmt.Errorf("invalid repository format: %s", repo)
 	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
-
 	status, err := client.CheckGitHubAppInstallation(ctx, parts[0], parts[1])
 	if err != nil {
 		// If we can't check, continue anyway
-		return token, nil
+		return nil
 	}
 
 	if status.Installed {
-		return token, nil
+		return nil
 	}
 
 	// GitHub App not installed
@@ -340,13 +305,13 @@ func ensureLoginAndGitHubAppWithDeps(repo string, deps *Dependencies) (string, e
 
 	if !deps.UI.IsInteractive() {
 		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Install: %s", installURL)))
-		return "", fmt.Errorf("GitHub App installation required")
+		return fmt.Errorf("GitHub App installation required")
 	}
 
 	install, _ := deps.UI.Confirm("Open browser to install GitHub App?", true)
 	if !install {
 		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Install later: %s", installURL)))
-		return "", fmt.Errorf("GitHub App installation required")
+		return fmt.Errorf("GitHub App installation required")
 	}
 
 	_ = deps.Browser.OpenURL(installURL)
@@ -389,11 +354,11 @@ func ensureLoginAndGitHubAppWithDeps(repo string, deps *Dependencies) (string, e
 	})
 
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	deps.UI.Success("GitHub App installed!")
-	return token, nil
+	return nil
 }
 
 func formatCandidates(candidates []env.Candidate) string {
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -199,11 +200,11 @@ func TestRunInitWithDeps_CreateVaultSuccess(t *testing.T) {
 	}
 }
 
-func TestEnsureLoginAndGitHubAppWithDeps_InvalidRepoFormat(t *testing.T) {
-	deps, _, _, _, _, _ := NewTestDeps()
+func TestEnsureGitHubApp_InvalidRepoFormat(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
 
 	// Execute with invalid repo format
-	_, err := ensureLoginAndGitHubAppWithDeps("invalid-repo", deps)
+	err := ensureGitHubApp(&Session{Deps: deps, Ctx: context.Background(), Repo: "invalid-repo", Client: apiMock})
 
 	// Assert
 	if err == nil {
@@ -214,58 +215,37 @@ func TestEnsureLoginAndGitHubAppWithDeps_InvalidRepoFormat(t *testing.T) {
 	}
 }
 
-func TestEnsureLoginAndGitHubAppWithDeps_AppAlreadyInstalled(t *testing.T) {
+func TestEnsureGitHubApp_AppAlreadyInstalled(t *testing.T) {
 	deps, _, _, _, _, apiMock := NewTestDeps()
 
 	// Setup - app already installed
 	apiMock.CheckGitHubAppInstallationResponse = &api.GitHubAppInstallationStatus{Installed: true}
 
 	// Execute
-	token, err := ensureLoginAndGitHubAppWithDeps("owner/repo", deps)
+	err := ensureGitHubApp(&Session{Deps: deps, Ctx: context.Background(), Repo: "owner/repo", Client: apiMock})
 
 	// Assert
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if token == "" {
-		t.Error("expected token, got empty")
-	}
 }
 
-func TestEnsureLoginAndGitHubAppWithDeps_AuthError(t *testing.T) {
-	deps, _, authMock, _, _, _ := NewTestDeps()
-
-	// Setup - auth fails
-	authMock.Error = errors.New("not logged in")
-
-	// Execute
-	_, err := ensureLoginAndGitHubAppWithDeps("owner/repo", deps)
-
-	// Assert
-	if err == nil {
-		t.Fatal("expected error, got nil")
-	}
-}
-
-func TestEnsureLoginAndGitHubAppWithDeps_AppCheckError(t *testing.T) {
+func TestEnsureGitHubApp_AppCheckError(t *testing.T) {
 	deps, _, _, _, _, apiMock := NewTestDeps()
 
 	// Setup - check fails but should continue
 	apiMock.CheckGitHubAppInstallationError = errors.New("check failed")
 
 	// Execute
-	token, err := ensureLoginAndGitHubAppWithDeps("owner/repo", deps)
+	err := ensureGitHubApp(&Session{Deps: deps, Ctx: context.Background(), Repo: "owner/repo", Client: apiMock})
 
 	// Assert - should succeed despite check failure
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if token == "" {
-		t.Error("expected token, got empty")
-	}
 }
 
-func TestEnsureLoginAndGitHubAppWithDeps_AppNotInstalledNonInteractive(t *testing.T) {
+func TestEnsureGitHubApp_AppNotInstalledNonInteractive(t *testing.T) {
 	deps, _, _, uiMock, _, apiMock := NewTestDeps()
 
 	// Setup - app not installed, non-interactive
@@ -276,7 +256,7 @@ func TestEnsureLoginAndGitHubAppWithDeps_AppNotInstalledNonInteractive(t *testin
 	uiMock.Interactive = false
 
 	// Execute
-	_, err := ensureLoginAndGitHubAppWithDeps("owner/repo", deps)
+	err := ensureGitHubApp(&Session{Deps: deps, Ctx: context.Background(), Repo: "owner/repo", Client: apiMock})
 
 	// Assert
 	if err == nil {
@@ -287,7 +267,7 @@ func TestEnsureLoginAndGitHubAppWithDeps_AppNotInstalledNonInteractive(t *testin
 	}
 }
 
-func TestEnsureLoginAndGitHubAppWithDeps_UserDeclinesInstall(t *testing.T) {
+func TestEnsureGitHubApp_UserDeclinesInstall(t *testing.T) {
 	deps, _, _, uiMock, _, apiMock := NewTestDeps()
 
 	// Setup
@@ -299,7 +279,7 @@ func TestEnsureLoginAndGitHubAppWithDeps_UserDeclinesInstall(t *testing.T) {
 	uiMock.ConfirmResult = false // User declines
 
 	// Execute
-	_, err := ensureLoginAndGitHubAppWithDeps("owner/repo", deps)
+	err := ensureGitHubApp(&Session{Deps: deps, Ctx: context.Background(), Repo: "owner/repo", Client: apiMock})
 
 	// Assert
 	if err == nil {
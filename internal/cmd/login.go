@@ -10,7 +10,9 @@ import (
 	"github.com/keywaysh/cli/internal/analytics"
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/auth"
+	"github.com/keywaysh/cli/internal/config"
 	"github.com/keywaysh/cli/internal/git"
+	"github.com/keywaysh/cli/internal/platform"
 	"github.com/keywaysh/cli/internal/ui"
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
@@ -19,8 +21,11 @@ import (
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate with GitHub via Keyway",
-	Long:  `Authenticate with GitHub using the device flow or a personal access token.`,
-	RunE:  runLogin,
+	Long: `Authenticate with GitHub using the device flow or a personal access token.
+
+Use --status to check the stored login without logging in; with --json, both
+print {"authenticated", "user", "expiresAt"} for scripts.`,
+	RunE: runLogin,
 }
 
 var logoutCmd = &cobra.Command{
@@ -31,9 +36,50 @@ var logoutCmd = &cobra.Command{
 
 func init() {
 	loginCmd.Flags().Bool("token", false, "Authenticate using a GitHub fine-grained PAT")
+	loginCmd.Flags().Bool("status", false, "Show whether you are logged in, without logging in")
+}
+
+// LoginStatus is the output of login --json
+type LoginStatus struct {
+	Authenticated bool   `json:"authenticated"`
+	User          string `json:"user,omitempty"`
+	ExpiresAt     string `json:"expiresAt,omitempty"`
+}
+
+// currentLoginStatus reads the stored login, expired logins count as logged out
+func currentLoginStatus() LoginStatus {
+	stored, err := auth.NewStore().GetAuth()
+	if err != nil || stored == nil || stored.KeywayToken == "" {
+		return LoginStatus{}
+	}
+	return LoginStatus{Authenticated: true, User: stored.GitHubLogin, ExpiresAt: stored.ExpiresAt}
+}
+
+// showLoginStatus prints the stored login, as JSON with --json
+func showLoginStatus() error {
+	status := currentLoginStatus()
+	if ui.IsJSON() {
+		return ui.PrintJSON(status)
+	}
+	switch {
+	case !status.Authenticated:
+		ui.Warn("Not logged in, run keyway login")
+	case status.User != "":
+		ui.Success(fmt.Sprintf("Logged in as %s", ui.Value("@"+status.User)))
+	default:
+		ui.Success("Logged in")
+	}
+	if status.ExpiresAt != "" {
+		ui.Message(ui.Dim(fmt.Sprintf("Expires: %s", formatEventTime(status.ExpiresAt))))
+	}
+	return nil
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
+	if status, _ := cmd.Flags().GetBool("status"); status {
+		return showLoginStatus()
+	}
+
 	ui.Intro("login")
 
 	useToken, _ := cmd.Flags().GetBool("token")
@@ -50,6 +96,9 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if ui.IsJSON() {
+		return ui.PrintJSON(currentLoginStatus())
+	}
 	ui.Outro("Ready to sync secrets!")
 	return nil
 }
@@ -105,12 +154,18 @@ func RunDeviceLogin() (string, error) {
 
 	ui.Step(fmt.Sprintf("Code: %s", ui.Bold(start.UserCode)))
 	ui.Message(ui.Dim(fmt.Sprintf("Open: %s", verifyURL)))
-	ui.Message(ui.Dim("If the browser doesn't open, copy the URL above and paste it in your browser."))
 
-	// Try to open browser (in goroutine to avoid blocking in headless/CLI environments)
-	go func() {
-		_ = browser.OpenURL(verifyURL)
-	}()
+	if platform.CanOpenBrowser() {
+		ui.Message(ui.Dim("If the browser doesn't open, copy the URL above and paste it in your browser."))
+
+		// Try to open browser (in goroutine to avoid blocking in headless/CLI environments)
+		go func() {
+			_ = browser.OpenURL(verifyURL)
+		}()
+	} else {
+		// WSL, devcontainers and SSH sessions: no browser here, complete the device flow elsewhere
+		ui.Message(ui.Dim("No browser available here. Open the URL above on any device and enter the code."))
+	}
 
 	pollInterval := time.Duration(start.Interval) * time.Second
 	if pollInterval < 3*time.Second {
@@ -166,6 +221,7 @@ func RunDeviceLogin() (string, error) {
 	if err := store.SaveAuth(token, githubLogin, expiresAt); err != nil {
 		return "", fmt.Errorf("failed to save credentials: %w", err)
 	}
+	warnIfWindowsSideStore(store.GetConfigPath())
 
 	// Track login event
 	analytics.Track(analytics.EventLogin, map[string]interface{}{
@@ -187,7 +243,22 @@ func RunDeviceLogin() (string, error) {
 	return token, nil
 }
 
+// warnIfWindowsSideStore warns when, inside WSL, credentials live on a Windows drive
+// where file permissions are not enforced
+func warnIfWindowsSideStore(configPath string) {
+	if platform.IsWSL() && platform.IsWindowsMount(configPath) {
+		ui.Warn(fmt.Sprintf("Credentials are stored on the Windows side (%s)", configPath))
+		ui.Message(ui.Dim("Files under /mnt/ ignore Unix permissions. Set KEYWAY_CONFIG_DIR to a path inside WSL."))
+	}
+}
+
 func runTokenLogin() error {
+	if !platform.CanOpenBrowser() {
+		ui.Warn("No browser available to create a token here, using device login instead")
+		_, err := RunDeviceLogin()
+		return err
+	}
+
 	repo, _ := git.DetectRepo()
 	if repo != "" {
 		ui.Step(fmt.Sprintf("Detected repository: %s", ui.Value(repo)))
@@ -236,6 +307,7 @@ func runTokenLogin() error {
 	if err := store.SaveAuth(token, validation.Username, ""); err != nil {
 		return fmt.Errorf("failed to save credentials: %w", err)
 	}
+	warnIfWindowsSideStore(store.GetConfigPath())
 
 	// Track login event
 	analytics.Track(analytics.EventLogin, map[string]interface{}{
@@ -264,6 +336,13 @@ func runLogout(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Prefetched secrets must not outlive the login that fetched them
+	if dir := secretCacheDir(); dir != "" {
+		if err := os.RemoveAll(dir); err != nil {
+			ui.Warn(fmt.Sprintf("Could not clear prefetched secrets: %s", err.Error()))
+		}
+	}
+
 	ui.Success("Logged out of Keyway")
 	ui.Message(ui.Dim(fmt.Sprintf("Auth cache cleared: %s", store.GetConfigPath())))
 
@@ -285,8 +364,11 @@ func EnsureLogin() (string, error) {
 	}
 
 	// Need to login
+	if provider := config.CIProvider(); provider != "" {
+		return "", fmt.Errorf("%w - KEYWAY_TOKEN is not set, add an API key (Dashboard > API Keys) as a %s secret", errLoginRequired, provider)
+	}
 	if !ui.IsInteractive() {
-		return "", fmt.Errorf("no Keyway session found - run 'keyway login' to authenticate")
+		return "", fmt.Errorf("%w - run 'keyway login' to authenticate", errLoginRequired)
 	}
 
 	proceed, _ := ui.Confirm("No Keyway session found. Open browser to sign in?", true)
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/jsonrpc"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a JSON-RPC server for editor integrations",
+	Long: `Run a long-lived JSON-RPC 2.0 server on stdin/stdout for editor extensions.
+
+Messages use LSP-style Content-Length framing. Secret values are never sent,
+only masked previews. Keys declared as config in .keyway.json are shown as is.
+
+Methods:
+  initialize          Server info and the detected repository
+  keyway/listKeys     {environment}          List keys in an environment
+  keyway/hover        {environment, key}     Masked preview of a secret, and what it holds
+  keyway/validate     {content, environment} Diagnostics for .env content
+  keyway/refresh      {}                     Drop cached vault contents
+  shutdown, exit`,
+	Args: cobra.NoArgs,
+	RunE: runLSP,
+}
+
+// LSPOptions contains the settings for the lsp command
+type LSPOptions struct {
+	Version string
+	In      io.Reader
+	Out     io.Writer
+}
+
+// runLSP is the entry point for the lsp command (uses default dependencies)
+func runLSP(cmd *cobra.Command, args []string) error {
+	opts := LSPOptions{
+		Version: rootCmd.Version,
+		In:      os.Stdin,
+		Out:     os.Stdout,
+	}
+	return runLSPWithDeps(opts, defaultDeps)
+}
+
+// runLSPWithDeps is the testable version of runLSP
+func runLSPWithDeps(opts LSPOptions, deps *Dependencies) error {
+	// stdout carries the protocol, keep every other output off it
+	deps = withQuietUI(deps)
+
+	session := &lspSession{deps: deps, cache: make(map[string]map[string]string)}
+
+	server := jsonrpc.NewServer()
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		repo, _ := deps.Git.DetectRepo()
+		return map[string]interface{}{
+			"serverInfo": map[string]string{"name": "keyway", "version": opts.Version},
+			"repository": repo,
+			"methods":    []string{"keyway/listKeys", "keyway/hover", "keyway/validate", "keyway/refresh"},
+		}, nil
+	})
+	server.Handle("keyway/listKeys", session.listKeys)
+	server.Handle("keyway/hover", session.hover)
+	server.Handle("keyway/validate", session.validate)
+	server.Handle("keyway/refresh", func(params json.RawMessage) (interface{}, error) {
+		session.cache = make(map[string]map[string]string)
+		return nil, nil
+	})
+	server.Handle("shutdown", func(params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+	server.Handle("exit", func(params json.RawMessage) (interface{}, error) {
+		return nil, jsonrpc.ErrExit
+	})
+
+	return server.Serve(opts.In, opts.Out)
+}
+
+// lspSession holds the client and the per-environment secrets cache,
+// so editors can query on every keystroke without hitting the API
+type lspSession struct {
+	deps  *Dependencies
+	login *Session // repository and client, once logged in
+	cache map[string]map[string]string
+}
+
+type lspEnvParams struct {
+	Environment string `json:"environment"`
+	Key         string `json:"key"`
+	Content     string `json:"content"`
+}
+
+// secrets returns the cached secrets of an environment, fetching them on first use
+func (s *lspSession) secrets(envName string) (map[string]string, error) {
+	if envName == "" {
+		envName = "development"
+	}
+	if cached, ok := s.cache[envName]; ok {
+		return cached, nil
+	}
+
+	if s.login == nil {
+		repo, err := s.deps.Git.DetectRepo()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository with GitHub remote")
+		}
+		token, err := s.deps.Auth.EnsureLogin()
+		if err != nil {
+			return nil, err
+		}
+		s.login = &Session{Deps: s.deps, Ctx: context.Background(), Repo: repo, Client: s.deps.APIFactory.NewClient(token)}
+	}
+
+	resp, err := s.login.Client.PullSecrets(s.login.Ctx, s.login.Repo, envName)
+	if isAuthError(err) {
+		if err := s.login.Relogin(err); err != nil {
+			s.login = nil
+			return nil, fmt.Errorf("session expired - run 'keyway login'")
+		}
+		resp, err = s.login.Client.PullSecrets(s.login.Ctx, s.login.Repo, envName)
+	}
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			s.cache[envName] = map[string]string{}
+			return s.cache[envName], nil
+		}
+		return nil, err
+	}
+
+	s.cache[envName] = env.ParseVault(resp.Content)
+	return s.cache[envName], nil
+}
+
+func (s *lspSession) listKeys(params json.RawMessage) (interface{}, error) {
+	var p lspEnvParams
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	secrets, err := s.secrets(p.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return map[string]interface{}{"keys": keys}, nil
+}
+
+func (s *lspSession) hover(params json.RawMessage) (interface{}, error) {
+	var p lspEnvParams
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Key == "" {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "key is required"}
+	}
+	secrets, err := s.secrets(p.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := loadProject(s.deps)
+	if err != nil {
+		return nil, err
+	}
+
+	value, exists := secrets[p.Key]
+	class := project.KeyClass(p.Key)
+	result := map[string]interface{}{"key": p.Key, "exists": exists, "class": class}
+	if exists {
+		if class == config.ClassConfig {
+			result["preview"] = value
+		} else {
+			result["preview"] = previewValue(value)
+		}
+		details := env.Inspect(value, class == config.ClassConfig, time.Now())
+		result["kind"] = details.Kind
+		result["details"] = details.Lines
+	}
+	return result, nil
+}
+
+func (s *lspSession) validate(params json.RawMessage) (interface{}, error) {
+	var p lspEnvParams
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	diagnostics := env.Lint(p.Content)
+	if diagnostics == nil {
+		diagnostics = []env.Issue{}
+	}
+
+	// Compare against the vault only when asked, validation must work offline
+	if p.Environment != "" {
+		vault, err := s.secrets(p.Environment)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, compareWithVault(p.Content, p.Environment, vault)...)
+	}
+
+	return map[string]interface{}{"diagnostics": diagnostics}, nil
+}
+
+// compareWithVault reports keys missing from content and keys unknown to the vault
+func compareWithVault(content, envName string, vault map[string]string) []env.Issue {
+	var issues []env.Issue
+	local := env.Parse(content)
+	diff := env.CalculatePushDiff(local, vault)
+
+	for _, key := range diff.Removed {
+		issues = append(issues, env.Issue{Key: key, Severity: env.SeverityWarning, Message: fmt.Sprintf("%s is in %s but missing here", key, envName)})
+	}
+	for _, key := range diff.Added {
+		issues = append(issues, env.Issue{Line: lineOfKey(content, key), Key: key, Severity: env.SeverityWarning, Message: fmt.Sprintf("%s is not in %s", key, envName)})
+	}
+	return issues
+}
+
+// lineOfKey returns the 1-based line where key is last defined, or 0
+func lineOfKey(content, key string) int {
+	found := 0
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "="); idx > 0 && strings.TrimSpace(line[:idx]) == key {
+			found = i + 1
+		}
+	}
+	return found
+}
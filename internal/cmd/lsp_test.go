@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/jsonrpc"
+)
+
+// runLSPSession sends requests to the lsp server and returns the decoded responses
+func runLSPSession(t *testing.T, deps *Dependencies, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var in bytes.Buffer
+	for _, req := range requests {
+		jsonrpc.WriteMessage(&in, []byte(req))
+	}
+
+	var out bytes.Buffer
+	if err := runLSPWithDeps(LSPOptions{Version: "1.0.0", In: &in, Out: &out}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var responses []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := jsonrpc.ReadMessage(r)
+		if err != nil {
+			break
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(body, &resp)
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestRunLSPWithDeps_ListKeysAndHover(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DB_URL=postgres://x\nAPI_KEY=sk_live_abcdef"}
+
+	responses := runLSPSession(t, deps,
+		`{"jsonrpc":"2.0","id":1,"method":"keyway/listKeys","params":{"environment":"production"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"keyway/hover","params":{"environment":"production","key":"API_KEY"}}`,
+	)
+
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	keys := responses[0]["result"].(map[string]interface{})["keys"].([]interface{})
+	if len(keys) != 2 || keys[0] != "API_KEY" || keys[1] != "DB_URL" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	hover := responses[1]["result"].(map[string]interface{})
+	if hover["preview"] != "**ef (14 chars)" || hover["kind"] != "text" {
+		t.Errorf("unexpected hover: %v", hover)
+	}
+	if strings.Contains(string(mustJSON(responses)), "sk_live") {
+		t.Error("secret value leaked in lsp response")
+	}
+}
+
+func TestRunLSPWithDeps_HoverConfigKey(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{"config": ["LOG_LEVEL"]}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "LOG_LEVEL=debug"}
+
+	responses := runLSPSession(t, deps,
+		`{"jsonrpc":"2.0","id":1,"method":"keyway/hover","params":{"key":"LOG_LEVEL"}}`,
+	)
+
+	hover := responses[0]["result"].(map[string]interface{})
+	if hover["class"] != "config" || hover["preview"] != "debug" {
+		t.Errorf("expected unmasked config value, got %v", hover)
+	}
+}
+
+func TestRunLSPWithDeps_Validate(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=x\nDB_URL=y"}
+
+	responses := runLSPSession(t, deps,
+		`{"jsonrpc":"2.0","id":1,"method":"keyway/validate","params":{"content":"API_KEY=1\nbroken line\nEXTRA=2"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"keyway/validate","params":{"content":"API_KEY=1\nEXTRA=2","environment":"production"}}`,
+	)
+
+	offline := responses[0]["result"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(offline) != 1 {
+		t.Errorf("expected 1 syntax diagnostic, got %v", offline)
+	}
+	withVault := responses[1]["result"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(withVault) != 2 {
+		t.Fatalf("expected missing DB_URL and unknown EXTRA, got %v", withVault)
+	}
+	extra := withVault[1].(map[string]interface{})
+	if extra["key"] != "EXTRA" || extra["line"].(float64) != 2 {
+		t.Errorf("unexpected diagnostic: %v", extra)
+	}
+}
+
+func TestRunLSPWithDeps_SessionExpired(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullError = &api.APIError{StatusCode: 401, Detail: "Unauthorized"}
+
+	responses := runLSPSession(t, deps,
+		`{"jsonrpc":"2.0","id":1,"method":"keyway/listKeys","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	rpcErr, ok := responses[0]["error"].(map[string]interface{})
+	if !ok || !strings.Contains(rpcErr["message"].(string), "keyway login") {
+		t.Errorf("expected login hint, got %v", responses[0])
+	}
+}
+
+func mustJSON(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain keeps local state written by commands (push history, usage log)
+// out of the real config directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "keyway-cmd-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("KEYWAY_CONFIG_DIR", dir)
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
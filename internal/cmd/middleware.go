@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Commands run through two middleware chains:
+//
+//   - around every command's RunE, installed once by Execute: the
+//     accessibility and CI modes, usage and latency recording, the network budget,
+//     the organization's policy and the environment pinned with keyway use
+//     (commandMiddleware)
+//   - inside a command's runXxxWithDeps, the setup it needs before its body
+//     runs: the repository, the environment, a logged in client and
+//     .keyway.json, resolved into a Session (runPipeline)
+
+// RunFunc is a cobra RunE
+type RunFunc func(cmd *cobra.Command, args []string) error
+
+// CommandMiddleware wraps the RunE of a command
+type CommandMiddleware func(next RunFunc) RunFunc
+
+// wrapCommands wraps the RunE of cmd and of all its subcommands, the first
+// middleware being the outermost
+func wrapCommands(cmd *cobra.Command, mws ...CommandMiddleware) {
+	if cmd.RunE != nil {
+		run := RunFunc(cmd.RunE)
+		for i := len(mws) - 1; i >= 0; i-- {
+			run = mws[i](run)
+		}
+		cmd.RunE = run
+	}
+	for _, sub := range cmd.Commands() {
+		wrapCommands(sub, mws...)
+	}
+}
+
+// withUsageRecord records the command in the local usage log
+func withUsageRecord(ver string) CommandMiddleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *cobra.Command, args []string) error {
+			start := time.Now()
+			err := next(cmd, args)
+			recordUsage(cmd, start, err, ver)
+			return err
+		}
+	}
+}
+
+// withLatency sends how long the command took, and whether it succeeded
+func withLatency(next RunFunc) RunFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		err := next(cmd, args)
+		analytics.Track(analytics.EventCommandLatency, map[string]interface{}{
+			"command":    commandName(cmd),
+			"durationMs": time.Since(start).Milliseconds(),
+			"success":    err == nil,
+			"ci":         config.IsCI(),
+		})
+		return err
+	}
+}
+
+// commandBudgets bounds the requests of the commands on the critical path of a
+// deploy or a dev loop, so that a slow link can't stretch them over a long
+// series of calls. Other commands, the long-running ones included, are unbounded.
+var commandBudgets = map[string]int{
+	"push": 30,
+	"pull": 20,
+	"set":  20,
+}
+
+// commandBudget is the budget of the running command, shared by the API
+// clients it creates
+var commandBudget *api.Budget
+
+// withNetworkBudget bounds the requests the command sends, KEYWAY_NETWORK_BUDGET
+// overriding the bound, and with KEYWAY_VERBOSE reports them and the analytics
+// events tracked
+func withNetworkBudget(next RunFunc) RunFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		limit := commandBudgets[commandName(cmd)]
+		if override, ok := config.GetNetworkBudget(); ok {
+			limit = override
+		}
+		budget := api.NewBudget(limit)
+		commandBudget = budget
+		events := analytics.Tracked()
+
+		err := next(cmd, args)
+		commandBudget = nil
+
+		if errors.Is(err, api.ErrBudgetExceeded) {
+			err = fmt.Errorf("%w (set KEYWAY_NETWORK_BUDGET to raise it)", err)
+		}
+		if config.IsVerbose() {
+			bound := "no budget"
+			if budget.Limit() > 0 {
+				bound = fmt.Sprintf("budget %d", budget.Limit())
+			}
+			fmt.Fprintf(os.Stderr, "%s\n", dim(fmt.Sprintf("Network: %d requests (%s), %d analytics events", budget.Used(), bound, analytics.Tracked()-events)))
+		}
+		return err
+	}
+}
+
+// withAccessibility turns the accessibility mode on with --accessible or
+// KEYWAY_ACCESSIBLE
+func withAccessibility(next RunFunc) RunFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		on, _ := cmd.Flags().GetBool("accessible")
+		ui.SetAccessible(on || config.IsAccessible())
+		return next(cmd, args)
+	}
+}
+
+// withCI switches to what CI runs need when a CI is detected: no prompts,
+// plain output, and exit codes telling failures apart (see ExitCode)
+func withCI(next RunFunc) RunFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		ui.SetCI(config.IsCI())
+		return next(cmd, args)
+	}
+}
+
+// jsonCommands are the commands printing a JSON result with --json. The
+// others would run with nothing on stdout, so --json is refused on them.
+var jsonCommands = map[string]bool{
+	"audit-strength":  true,
+	"diff":            true,
+	"doctor":          true,
+	"env list":        true,
+	"events":          true,
+	"history":         true,
+	"login":           true,
+	"pull":            true,
+	"push":            true,
+	"scan":            true,
+	"secrets list":    true,
+	"sessions list":   true,
+	"snapshot create": true,
+	"snapshot diff":   true,
+	"trash list":      true,
+	"unused":          true,
+	"verify-install":  true,
+}
+
+// withJSONOutput turns the JSON output mode on with --json: stdout only
+// carries the JSON result, and the default dependencies report progress as
+// NDJSON on stderr (see withJSONUI)
+func withJSONOutput(next RunFunc) RunFunc {
+	return func(cmd *cobra.Command, args []string) error {
+		on, _ := cmd.Flags().GetBool("json")
+		if on && !jsonCommands[commandName(cmd)] {
+			return fmt.Errorf("--json is not supported by keyway %s, it has no JSON result", commandName(cmd))
+		}
+		ui.SetJSON(on)
+		if !on {
+			return next(cmd, args)
+		}
+		previous := defaultDeps
+		defaultDeps = withJSONUI(defaultDeps)
+		defer func() { defaultDeps = previous }()
+		return next(cmd, args)
+	}
+}
+
+// withPolicy blocks the command when the CLI doesn't meet the policy of the
+// repository's organization
+func withPolicy(policy *api.OrganizationPolicy, ver string) CommandMiddleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *cobra.Command, args []string) error {
+			if err := enforceCommandPolicy(cmd, policy, ver); err != nil {
+				return err
+			}
+			return next(cmd, args)
+		}
+	}
+}
+
+// commandMiddleware is the chain run around every command
+func commandMiddleware(policy *api.OrganizationPolicy, pinned PinnedContext, ver string) []CommandMiddleware {
+	return []CommandMiddleware{
+		withAccessibility,
+		withCI,
+		withJSONOutput,
+		withUsageRecord(ver),
+		withNetworkBudget,
+		withLatency,
+		withPolicy(policy, ver),
+		withPinnedEnv(pinned.Env),
+	}
+}
+
+// commandName returns the command path without the binary name, e.g. "env freeze"
+func commandName(cmd *cobra.Command) string {
+	if cmd.Root() == cmd {
+		return cmd.Name()
+	}
+	return cmd.CommandPath()[len(cmd.Root().Name())+1:]
+}
+
+// Session is what a command's setup resolved before its body runs
+type Session struct {
+	Deps    *Dependencies
+	Ctx     context.Context
+	Repo    string
+	EnvName string
+	Client  api.APIClient
+	Project *config.Project
+}
+
+// Handler is the body of a command
+type Handler func(s *Session) error
+
+// Middleware prepares a Session before the handler runs, or stops the command
+type Middleware func(next Handler) Handler
+
+// runPipeline runs the handler through the middleware, the first being the outermost
+func runPipeline(deps *Dependencies, h Handler, mws ...Middleware) error {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h(&Session{Deps: deps, Ctx: context.Background()})
+}
+
+// withIntro shows the command's intro
+func withIntro(command string) Middleware {
+	return func(next Handler) Handler {
+		return func(s *Session) error {
+			s.Deps.UI.Intro(command)
+			return next(s)
+		}
+	}
+}
+
+// withRepo detects the GitHub repository of the working directory
+func withRepo(next Handler) Handler {
+	return func(s *Session) error {
+		repo, err := s.Deps.Git.DetectRepo()
+		if err != nil {
+			s.Deps.UI.Error("Not in a git repository with GitHub remote")
+			return err
+		}
+		s.Repo = repo
+		s.Deps.UI.Step(fmt.Sprintf("Repository: %s", s.Deps.UI.Value(repo)))
+		return next(s)
+	}
+}
+
+// withEnvironment sets the environment the command works on, which is required
+func withEnvironment(envName string) Middleware {
+	return func(next Handler) Handler {
+		return func(s *Session) error {
+			if envName == "" {
+				s.Deps.UI.Error("Environment name is required")
+				return fmt.Errorf("environment name is required")
+			}
+			s.EnvName = envName
+			s.Deps.UI.Step(fmt.Sprintf("Environment: %s", s.Deps.UI.Value(envName)))
+			return next(s)
+		}
+	}
+}
+
+// withLogin logs in if needed and creates the API client
+func withLogin(next Handler) Handler {
+	return func(s *Session) error {
+		if err := s.Login(); err != nil {
+			return err
+		}
+		return next(s)
+	}
+}
+
+// Login logs in if needed and creates the API client. withLogin does it before
+// the handler, a command that first checks local state calls it itself.
+func (s *Session) Login() error {
+	token, err := s.Deps.Auth.EnsureLogin()
+	if err != nil {
+		s.Deps.UI.Error(err.Error())
+		return err
+	}
+	s.Client = s.Deps.APIFactory.NewClient(token)
+	return nil
+}
+
+// withProject loads .keyway.json
+func withProject(next Handler) Handler {
+	return func(s *Session) error {
+		project, err := loadProject(s.Deps)
+		if err != nil {
+			s.Deps.UI.Error(err.Error())
+			return err
+		}
+		s.Project = project
+		return next(s)
+	}
+}
+
+// Spin runs fn behind a spinner. When the API rejects the token, it logs in
+// again and runs fn once more with the new client.
+func (s *Session) Spin(message string, fn func() error) error {
+	err := s.Deps.UI.Spin(message, fn)
+	if err != nil && isAuthError(err) {
+		if err := s.Relogin(err); err != nil {
+			return err
+		}
+		err = s.Deps.UI.Spin(message, fn)
+	}
+	return err
+}
+
+// Relogin logs in again after the API rejected the token, and replaces the
+// client. Spin does it for the calls it runs, long-running loops call it themselves.
+func (s *Session) Relogin(err error) error {
+	newToken, authErr := handleAuthError(err, s.Deps)
+	if authErr != nil {
+		return authErr
+	}
+	s.Client = s.Deps.APIFactory.NewClient(newToken)
+	return nil
+}
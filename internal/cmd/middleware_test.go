@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func TestWrapCommands_Order(t *testing.T) {
+	var calls []string
+	trace := func(name string) CommandMiddleware {
+		return func(next RunFunc) RunFunc {
+			return func(cmd *cobra.Command, args []string) error {
+				calls = append(calls, name+" "+cmd.Name())
+				return next(cmd, args)
+			}
+		}
+	}
+
+	root := &cobra.Command{Use: "keyway"}
+	parent := &cobra.Command{Use: "env"}
+	child := &cobra.Command{Use: "freeze", RunE: func(cmd *cobra.Command, args []string) error {
+		calls = append(calls, "run")
+		return nil
+	}}
+	parent.AddCommand(child)
+	root.AddCommand(parent)
+
+	wrapCommands(root, trace("outer"), trace("inner"))
+	if root.RunE != nil || parent.RunE != nil {
+		t.Error("commands without RunE should not be wrapped")
+	}
+	if err := child.RunE(child, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"outer freeze", "inner freeze", "run"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected %v, got %v", want, calls)
+	}
+	if got := commandName(child); got != "env freeze" {
+		t.Errorf("expected command name 'env freeze', got %q", got)
+	}
+}
+
+func TestWithAccessibility_FlagOrEnv(t *testing.T) {
+	defer ui.SetAccessible(false)
+	var seen bool
+	run := withAccessibility(func(cmd *cobra.Command, args []string) error {
+		seen = ui.IsAccessible()
+		return nil
+	})
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "pull"}
+		cmd.Flags().Bool("accessible", false, "")
+		if err := cmd.Flags().Parse(args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cmd
+	}
+
+	t.Setenv("KEYWAY_ACCESSIBLE", "")
+	_ = run(newCmd(), nil)
+	if seen {
+		t.Error("expected the accessibility mode to be off by default")
+	}
+	_ = run(newCmd("--accessible"), nil)
+	if !seen {
+		t.Error("expected --accessible to turn the accessibility mode on")
+	}
+	t.Setenv("KEYWAY_ACCESSIBLE", "1")
+	_ = run(newCmd(), nil)
+	if !seen {
+		t.Error("expected KEYWAY_ACCESSIBLE to turn the accessibility mode on")
+	}
+}
+
+func TestWithCI_DetectsCI(t *testing.T) {
+	noColor := color.NoColor
+	defer func() {
+		ui.SetCI(false)
+		color.NoColor = noColor
+	}()
+	var seen bool
+	run := withCI(func(cmd *cobra.Command, args []string) error {
+		seen = ui.IsCI()
+		return nil
+	})
+
+	t.Setenv("KEYWAY_CI", "0")
+	_ = run(&cobra.Command{Use: "pull"}, nil)
+	if seen {
+		t.Error("expected the CI mode to be off outside of CI")
+	}
+	t.Setenv("KEYWAY_CI", "")
+	t.Setenv("GITLAB_CI", "true")
+	_ = run(&cobra.Command{Use: "pull"}, nil)
+	if !seen {
+		t.Error("expected GitLab CI to turn the CI mode on")
+	}
+}
+
+func TestWithPolicy_BlocksBeforeRunning(t *testing.T) {
+	ran := false
+	run := withPolicy(&api.OrganizationPolicy{MinCLIVersion: "2.0.0"}, "1.0.0")(func(cmd *cobra.Command, args []string) error {
+		ran = true
+		return nil
+	})
+
+	if err := run(&cobra.Command{Use: "push"}, nil); err == nil {
+		t.Error("expected the policy to block the command")
+	}
+	if ran {
+		t.Error("command should not run when blocked")
+	}
+	if err := run(&cobra.Command{Use: "doctor"}, nil); err != nil || !ran {
+		t.Errorf("exempt commands should run, got %v", err)
+	}
+}
+
+func TestWithNetworkBudget_BoundsRequests(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{"content":"API_KEY=secret\n"}}`))
+	}))
+	defer server.Close()
+	t.Setenv("KEYWAY_API_URL", server.URL)
+	t.Setenv("KEYWAY_NETWORK_BUDGET", "1")
+
+	run := withNetworkBudget(func(cmd *cobra.Command, args []string) error {
+		client := (&realAPIFactory{}).NewClient("token")
+		if _, err := client.PullSecrets(context.Background(), "owner/repo", "development"); err != nil {
+			return err
+		}
+		_, err := client.PullSecrets(context.Background(), "owner/repo", "production")
+		return err
+	})
+	err := run(&cobra.Command{Use: "push"}, nil)
+
+	if !errors.Is(err, api.ErrBudgetExceeded) {
+		t.Fatalf("expected the budget to be exceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "KEYWAY_NETWORK_BUDGET") {
+		t.Errorf("expected a hint to raise the budget, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request to be sent, got %d", requests)
+	}
+	if commandBudget != nil {
+		t.Error("expected the budget to be dropped once the command returned")
+	}
+}
+
+func TestWithNetworkBudget_PerCommand(t *testing.T) {
+	t.Setenv("KEYWAY_NETWORK_BUDGET", "")
+	limits := make(map[string]int)
+	run := withNetworkBudget(func(cmd *cobra.Command, args []string) error {
+		limits[cmd.Name()] = commandBudget.Limit()
+		return nil
+	})
+
+	for _, name := range []string{"push", "lsp"} {
+		if err := run(&cobra.Command{Use: name}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if limits["push"] != commandBudgets["push"] {
+		t.Errorf("expected push to get its budget, got %d", limits["push"])
+	}
+	if limits["lsp"] != 0 {
+		t.Errorf("expected lsp to be unbounded, got %d", limits["lsp"])
+	}
+}
+
+func TestRunPipeline_ResolvesSession(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".keyway.json"] = []byte(`{"derived": {"URL": "${HOST}"}}`)
+
+	var got *Session
+	err := runPipeline(deps, func(s *Session) error {
+		got = s
+		return nil
+	}, withIntro("test"), withRepo, withEnvironment("staging"), withLogin, withProject)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Repo != "owner/repo" || got.EnvName != "staging" {
+		t.Errorf("unexpected session: repo %q, env %q", got.Repo, got.EnvName)
+	}
+	if got.Client != apiMock {
+		t.Error("expected the session to hold the API client")
+	}
+	if got.Project == nil || got.Project.Derived["URL"] != "${HOST}" {
+		t.Errorf("expected .keyway.json to be loaded, got %+v", got.Project)
+	}
+}
+
+func TestRunPipeline_StopsOnFailedSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(git *MockGitClient, auth *MockAuthProvider)
+		envName string
+		message string
+	}{
+		{
+			name:    "no repository",
+			setup:   func(git *MockGitClient, auth *MockAuthProvider) { git.RepoError = errors.New("no remote") },
+			envName: "staging",
+			message: "Not in a git repository with GitHub remote",
+		},
+		{
+			name:    "no environment",
+			setup:   func(git *MockGitClient, auth *MockAuthProvider) {},
+			message: "Environment name is required",
+		},
+		{
+			name:    "not logged in",
+			setup:   func(git *MockGitClient, auth *MockAuthProvider) { auth.Error = errors.New("not logged in") },
+			envName: "staging",
+			message: "not logged in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, gitMock, authMock, uiMock, _, _ := NewTestDeps()
+			tt.setup(gitMock, authMock)
+
+			ran := false
+			err := runPipeline(deps, func(s *Session) error {
+				ran = true
+				return nil
+			}, withRepo, withEnvironment(tt.envName), withLogin)
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if ran {
+				t.Error("handler should not run when the setup fails")
+			}
+			if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != tt.message {
+				t.Errorf("expected error %q, got %v", tt.message, uiMock.ErrorCalls)
+			}
+		})
+	}
+}
+
+func TestWithJSONOutput_SwapsDefaultDeps(t *testing.T) {
+	defer ui.SetJSON(false)
+	original := defaultDeps
+	var seen *Dependencies
+	run := withJSONOutput(func(cmd *cobra.Command, args []string) error {
+		seen = defaultDeps
+		return nil
+	})
+	cmd := &cobra.Command{Use: "pull"}
+	cmd.Flags().Bool("json", false, "")
+	if err := cmd.Flags().Parse([]string{"--json"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_ = run(cmd, nil)
+
+	if !ui.IsJSON() {
+		t.Error("expected --json to turn the JSON output mode on")
+	}
+	if _, ok := seen.UI.(quietUI); !ok {
+		t.Errorf("expected the command to run with the JSON UI, got %T", seen.UI)
+	}
+	if defaultDeps != original {
+		t.Error("expected the default dependencies to be restored")
+	}
+}
+
+func TestWithJSONOutput_RefusesCommandsWithoutResult(t *testing.T) {
+	defer ui.SetJSON(false)
+	ran := false
+	run := withJSONOutput(func(cmd *cobra.Command, args []string) error {
+		ran = true
+		return nil
+	})
+	root := &cobra.Command{Use: "keyway"}
+	cmd := &cobra.Command{Use: "set"}
+	root.AddCommand(cmd)
+	cmd.Flags().Bool("json", false, "")
+	if err := cmd.Flags().Parse([]string{"--json"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := run(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "keyway set") {
+		t.Fatalf("expected --json to be refused on set, got %v", err)
+	}
+	if ran {
+		t.Error("expected the command not to run")
+	}
+	if ui.IsJSON() {
+		t.Error("expected the JSON output mode to stay off")
+	}
+}
+
+func TestJSONCommands_Exist(t *testing.T) {
+	names := map[string]bool{}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		names[commandName(c)] = true
+		for _, child := range c.Commands() {
+			walk(child)
+		}
+	}
+	walk(rootCmd)
+	for name := range jsonCommands {
+		if !names[name] {
+			t.Errorf("jsonCommands lists %q, which is not a command", name)
+		}
+	}
+}
+
+func TestSession_Login(t *testing.T) {
+	deps, _, authMock, uiMock, _, apiMock := NewTestDeps()
+	s := &Session{Deps: deps, Ctx: context.Background()}
+
+	if err := s.Login(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Client != apiMock {
+		t.Error("expected Login to create the API client")
+	}
+
+	authMock.Error = errors.New("not logged in")
+	s = &Session{Deps: deps, Ctx: context.Background()}
+	if err := s.Login(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if s.Client != nil {
+		t.Error("expected no client when the login fails")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "not logged in" {
+		t.Errorf("expected the login error to be shown, got %v", uiMock.ErrorCalls)
+	}
+}
@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <doppler|dotenv-vault>",
+	Short: "Move the environments of Doppler or dotenv-vault into the vault",
+	Long: `Read the environments of another secret manager from the repository's
+project files, push each one to the vault, and check that the vault holds the
+same values afterwards.
+
+  doppler       reads the project of doppler.yaml (or --project), and the
+                secrets of each environment's root config, with the token of
+                DOPPLER_TOKEN or of the Doppler CLI's login. dev, stg and prd
+                become development, staging and production.
+  dotenv-vault  decrypts the environments of .env.vault with the keys of
+                .env.keys, without network access to dotenv.org.
+
+Keys already in the vault and not in the other tool are kept. The keys added
+or changed in each environment are shown first, and asked for confirmation
+unless --yes is passed.
+
+With --uninstall, once every environment matches, the other tool's project
+files are deleted (doppler.yaml, or .env.vault and .env.me) and package.json
+scripts starting with "doppler run --" run under keyway run instead.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"doppler", "dotenv-vault"},
+	RunE:      runMigrate,
+}
+
+func init() {
+	migrateCmd.Flags().String("project", "", "Doppler project (default: from doppler.yaml)")
+	migrateCmd.Flags().Bool("uninstall", false, "Delete the other tool's project files and rewrite its package.json scripts once migrated")
+	migrateCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompts")
+}
+
+// Tools keyway migrate reads from
+const (
+	migrateDoppler     = "doppler"
+	migrateDotenvVault = "dotenv-vault"
+)
+
+// MigrateOptions contains the parsed flags for the migrate command
+type MigrateOptions struct {
+	Tool      string
+	Project   string
+	Uninstall bool
+	Yes       bool
+}
+
+// dopplerRunRegex matches the doppler run wrapper of a script
+var dopplerRunRegex = regexp.MustCompile(`\bdoppler run\s+--\s+`)
+
+// runMigrate is the entry point for the migrate command (uses default dependencies)
+func runMigrate(cmd *cobra.Command, args []string) error {
+	opts := MigrateOptions{Tool: args[0]}
+	opts.Project, _ = cmd.Flags().GetString("project")
+	opts.Uninstall, _ = cmd.Flags().GetBool("uninstall")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runMigrateWithDeps(opts, defaultDeps)
+}
+
+// runMigrateWithDeps is the testable version of runMigrate
+func runMigrateWithDeps(opts MigrateOptions, deps *Dependencies) error {
+	if opts.Tool != migrateDoppler && opts.Tool != migrateDotenvVault {
+		deps.UI.Error(fmt.Sprintf("Cannot migrate from %s", opts.Tool))
+		deps.UI.Message(deps.UI.Dim("Supported: doppler, dotenv-vault"))
+		return fmt.Errorf("unsupported tool: %s", opts.Tool)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return migrateSecrets(s, opts)
+	}, withIntro("migrate"), withRepo, withLogin)
+}
+
+// migrateSecrets pushes the environments of another tool to the vault
+func migrateSecrets(s *Session, opts MigrateOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "migrating secrets", deps); err != nil {
+		return err
+	}
+
+	var sources []migrate.Environment
+	var err error
+	if opts.Tool == migrateDoppler {
+		sources, err = readDoppler(s, opts)
+	} else {
+		sources, err = readDotenvVault(deps)
+	}
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		deps.UI.Warn(fmt.Sprintf("No environment found in %s", opts.Tool))
+		return nil
+	}
+
+	// Environments of the vault, merged with the other tool's
+	merged := make([]map[string]string, len(sources))
+	changes := 0
+	deps.UI.Message("")
+	for i, source := range sources {
+		if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, source.Name, deps); err != nil {
+			return err
+		}
+		if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, source.Name, deps); err != nil {
+			return err
+		}
+
+		current, err := pullForMigration(s, source.Name)
+		if err != nil {
+			return reportEnvError("migrate", err, deps)
+		}
+		merged[i] = make(map[string]string, len(current)+len(source.Secrets))
+		for k, v := range current {
+			merged[i][k] = v
+		}
+
+		deps.UI.Message(fmt.Sprintf("%s %s %s", deps.UI.Bold(source.Name), deps.UI.Dim("←"), source.Source))
+		for _, k := range sortedKeys(source.Secrets) {
+			old, ok := current[k]
+			switch {
+			case !ok:
+				deps.UI.DiffAdded(k)
+				changes++
+			case old != source.Secrets[k]:
+				deps.UI.DiffChanged(k)
+				changes++
+			}
+			merged[i][k] = source.Secrets[k]
+		}
+		deps.UI.Message("")
+	}
+
+	if changes == 0 {
+		deps.UI.Info("The vault already holds every key and value")
+	} else {
+		if err := checkKeyNamingPolicy(migratedKeys(sources), deps); err != nil {
+			return err
+		}
+		if !opts.Yes {
+			if !deps.UI.IsInteractive() {
+				deps.UI.Error("Use --yes to migrate in non-interactive mode")
+				return fmt.Errorf("confirmation required")
+			}
+			confirm, _ := deps.UI.Confirm(fmt.Sprintf("Push %d change(s) to %d environment(s)?", changes, len(sources)), true)
+			if !confirm {
+				deps.UI.Warn("Aborted.")
+				return nil
+			}
+		}
+
+		for i, source := range sources {
+			err := s.Spin(fmt.Sprintf("Pushing %s...", source.Name), func() error {
+				_, err := s.Client.PushSecrets(s.Ctx, s.Repo, source.Name, merged[i], uuid.NewString())
+				return err
+			})
+			if err != nil {
+				return reportEnvError("migrate", err, deps)
+			}
+			forgetPrefetched(s.Repo, source.Name, deps)
+		}
+	}
+
+	analytics.Track("cli_migrate", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"tool":         opts.Tool,
+		"environments": len(sources),
+		"changes":      changes,
+	})
+
+	// Parity: every key of the other tool holds the same value in the vault
+	mismatched := 0
+	for _, source := range sources {
+		vault, err := pullForMigration(s, source.Name)
+		if err != nil {
+			return reportEnvError("migrate", err, deps)
+		}
+		var differ []string
+		for _, k := range sortedKeys(source.Secrets) {
+			if v, ok := vault[k]; !ok || v != source.Secrets[k] {
+				differ = append(differ, k)
+			}
+		}
+		if len(differ) > 0 {
+			deps.UI.Error(fmt.Sprintf("%s: %d key(s) differ from %s: %s", source.Name, len(differ), source.Source, strings.Join(differ, ", ")))
+			mismatched++
+			continue
+		}
+		deps.UI.Success(fmt.Sprintf("%s matches %s (%d keys)", source.Name, source.Source, len(source.Secrets)))
+	}
+	if mismatched > 0 {
+		if opts.Uninstall {
+			deps.UI.Warn(fmt.Sprintf("%s was left installed", opts.Tool))
+		}
+		return fmt.Errorf("%d environment(s) differ after the migration", mismatched)
+	}
+
+	if opts.Uninstall {
+		uninstallMigrated(opts.Tool, deps)
+	} else {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Remove %s's project files with: keyway migrate %s --uninstall", opts.Tool, opts.Tool)))
+	}
+	return nil
+}
+
+// readDoppler reads the environments of the repository's Doppler project
+func readDoppler(s *Session, opts MigrateOptions) ([]migrate.Environment, error) {
+	deps := s.Deps
+	dir, _ := os.Getwd()
+	token, loginProject := migrate.DopplerLogin(dir)
+
+	project := opts.Project
+	if project == "" {
+		if data, err := deps.FS.ReadFile(migrate.DopplerFile); err == nil {
+			setups, err := migrate.ParseDopplerSetup(data)
+			if err != nil {
+				deps.UI.Error(err.Error())
+				return nil, err
+			}
+			projects := make(map[string]bool)
+			for _, setup := range setups {
+				projects[setup.Project] = true
+			}
+			if len(projects) > 1 {
+				names := sortedKeys(projects)
+				deps.UI.Error(fmt.Sprintf("%s maps several projects: %s", migrate.DopplerFile, strings.Join(names, ", ")))
+				deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Migrate one with: keyway migrate doppler --project %s", names[0])))
+				return nil, fmt.Errorf("several Doppler projects")
+			}
+			project = setups[0].Project
+		} else {
+			project = loginProject
+		}
+	}
+	if project == "" {
+		deps.UI.Error(fmt.Sprintf("No Doppler project: no %s here, and doppler setup was not run", migrate.DopplerFile))
+		deps.UI.Message(deps.UI.Dim("Name it with: keyway migrate doppler --project <name>"))
+		return nil, fmt.Errorf("no Doppler project")
+	}
+	if token == "" {
+		deps.UI.Error("No Doppler token: set DOPPLER_TOKEN, or run doppler login")
+		return nil, fmt.Errorf("no Doppler token")
+	}
+	deps.UI.Step(fmt.Sprintf("Doppler project: %s", deps.UI.Value(project)))
+
+	var envs []migrate.Environment
+	err := s.Spin("Reading Doppler...", func() error {
+		var err error
+		envs, err = migrate.NewDopplerClient(token).ReadProject(s.Ctx, project)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return nil, err
+	}
+	return envs, nil
+}
+
+// readDotenvVault decrypts the environments of the repository's .env.vault
+func readDotenvVault(deps *Dependencies) ([]migrate.Environment, error) {
+	vault, err := deps.FS.ReadFile(migrate.DotenvVaultFile)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("No %s in the current directory", migrate.DotenvVaultFile))
+		return nil, err
+	}
+	keys, err := deps.FS.ReadFile(migrate.DotenvKeysFile)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("No %s in the current directory, it holds the keys to decrypt %s", migrate.DotenvKeysFile, migrate.DotenvVaultFile))
+		deps.UI.Message(deps.UI.Dim("Fetch it with: npx dotenv-vault@latest keys"))
+		return nil, err
+	}
+
+	envs, skipped, err := migrate.ReadDotenvVault(vault, keys)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		deps.UI.Warn(fmt.Sprintf("No key in %s for %s, skipped", migrate.DotenvKeysFile, strings.Join(skipped, ", ")))
+	}
+	return envs, nil
+}
+
+// pullForMigration returns the secrets of an environment, none if the vault
+// doesn't have it yet
+func pullForMigration(s *Session, envName string) (map[string]string, error) {
+	var secrets map[string]string
+	err := s.Spin(fmt.Sprintf("Fetching %s...", envName), func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, envName)
+		if err != nil {
+			if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+				secrets = map[string]string{}
+				return nil
+			}
+			return err
+		}
+		secrets = env.ParseVault(resp.Content)
+		return nil
+	})
+	return secrets, err
+}
+
+// migratedKeys returns the sorted keys of every environment
+func migratedKeys(envs []migrate.Environment) []string {
+	keys := make(map[string]bool)
+	for _, e := range envs {
+		for k := range e.Secrets {
+			keys[k] = true
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// uninstallMigrated deletes the project files of a migrated tool and moves
+// package.json scripts from doppler run to keyway run
+func uninstallMigrated(tool string, deps *Dependencies) {
+	files := []string{migrate.DopplerFile}
+	if tool == migrateDotenvVault {
+		files = []string{migrate.DotenvVaultFile, migrate.DotenvMeFile}
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err == nil {
+			deps.UI.Success(fmt.Sprintf("Deleted %s", file))
+		} else if !os.IsNotExist(err) {
+			deps.UI.Warn(fmt.Sprintf("Could not delete %s: %s", file, err.Error()))
+		}
+	}
+	if tool == migrateDotenvVault {
+		deps.UI.Warn(fmt.Sprintf("%s still holds the keys of the old vault, delete it once you no longer need them", migrate.DotenvKeysFile))
+	}
+
+	content, err := deps.FS.ReadFile("package.json")
+	if err != nil {
+		return
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(content, &pkg) != nil {
+		return
+	}
+	updates := map[string]string{}
+	var manual []string
+	for name, script := range pkg.Scripts {
+		switch {
+		case tool == migrateDoppler && dopplerRunRegex.MatchString(script):
+			updates[name] = dopplerRunRegex.ReplaceAllString(script, "keyway run -- ")
+		case strings.Contains(script, tool):
+			manual = append(manual, name)
+		}
+	}
+	if len(updates) > 0 {
+		newContent, missed := rewritePackageScripts(content, pkg.Scripts, updates)
+		manual = append(manual, missed...)
+		if err := deps.FS.WriteFile("package.json", newContent, 0644); err != nil {
+			deps.UI.Warn(fmt.Sprintf("Could not write package.json: %s", err.Error()))
+		} else if len(updates) > len(missed) {
+			deps.UI.Success(fmt.Sprintf("%d package.json script(s) now run under keyway run", len(updates)-len(missed)))
+		}
+	}
+	if len(manual) > 0 {
+		sort.Strings(manual)
+		deps.UI.Warn(fmt.Sprintf("These package.json scripts still use %s, edit them by hand: %s", tool, strings.Join(manual, ", ")))
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// dotenvVaultFiles returns a .env.vault and its .env.keys holding the
+// environments of envs, by name
+func dotenvVaultFiles(t *testing.T, envs map[string]string) (vault, keys []byte) {
+	t.Helper()
+	var v, k strings.Builder
+	for _, name := range sortedKeys(envs) {
+		key := make([]byte, 32)
+		rand.Read(key)
+		block, _ := aes.NewCipher(key)
+		gcm, _ := cipher.NewGCM(block)
+		nonce := make([]byte, gcm.NonceSize())
+		rand.Read(nonce)
+		sealed := base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(envs[name]), nil))
+
+		upper := strings.ToUpper(name)
+		v.WriteString("DOTENV_VAULT_" + upper + "=\"" + sealed + "\"\n")
+		k.WriteString("DOTENV_KEY_" + upper + "=\"dotenv://:key_" + hex.EncodeToString(key) + "@dotenv.org/vault/.env.vault?environment=" + name + "\"\n")
+	}
+	return []byte(v.String()), []byte(k.String())
+}
+
+func TestRunMigrateWithDeps_DotenvVault(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env.vault"], fsMock.Files[".env.keys"] = dotenvVaultFiles(t, map[string]string{
+		"development": "API_KEY=dev_123\nDEBUG=true\n",
+		"production":  "API_KEY=live_456\n",
+	})
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "API_KEY=old\nKEYWAY_ONLY=1\n"}, // development, before
+		{Content: ""}, // production, before
+		{Content: "API_KEY=dev_123\nDEBUG=true\nKEYWAY_ONLY=1\n"},
+		{Content: "API_KEY=live_456\n"},
+	}
+
+	err := runMigrateWithDeps(MigrateOptions{Tool: "dotenv-vault", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantDev := map[string]string{"API_KEY": "dev_123", "DEBUG": "true", "KEYWAY_ONLY": "1"}
+	if !reflect.DeepEqual(apiMock.PushedByEnv["development"], wantDev) {
+		t.Errorf("expected development to be merged into the vault, got %v", apiMock.PushedByEnv["development"])
+	}
+	if apiMock.PushedByEnv["production"]["API_KEY"] != "live_456" {
+		t.Errorf("unexpected production push: %v", apiMock.PushedByEnv["production"])
+	}
+	if !reflect.DeepEqual(uiMock.DiffChangedCalls, []string{"API_KEY"}) {
+		t.Errorf("expected API_KEY to be shown as changed, got %v", uiMock.DiffChangedCalls)
+	}
+	if len(uiMock.SuccessCalls) != 2 || !strings.Contains(uiMock.SuccessCalls[0], "development matches development (2 keys)") {
+		t.Errorf("expected parity to be reported, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunMigrateWithDeps_ParityMismatch(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env.vault"], fsMock.Files[".env.keys"] = dotenvVaultFiles(t, map[string]string{
+		"staging": "API_KEY=stg\n",
+	})
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: ""},
+		{Content: "API_KEY=truncated\n"},
+	}
+
+	err := runMigrateWithDeps(MigrateOptions{Tool: "dotenv-vault", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected an error when the vault differs after the migration")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.Contains(uiMock.ErrorCalls[0], "staging: 1 key(s) differ") {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunMigrateWithDeps_DopplerUninstall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") != "backend" {
+			t.Errorf("unexpected project: %s", r.URL.RawQuery)
+		}
+		if r.URL.Path == "/v3/configs" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"configs": []map[string]interface{}{{"name": "prd", "environment": "prd", "root": true}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"API_KEY": "live", "DOPPLER_CONFIG": "prd"})
+	}))
+	defer server.Close()
+	t.Setenv("DOPPLER_API_HOST", server.URL)
+	t.Setenv("DOPPLER_TOKEN", "dp.st.test")
+
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files["doppler.yaml"] = []byte("setup:\n  project: backend\n  config: dev\n")
+	fsMock.Files["package.json"] = []byte(`{
+  "scripts": {
+    "start": "doppler run -- node server.js",
+    "seed": "doppler run --config prd -- node seed.js"
+  }
+}`)
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: ""}, {Content: "API_KEY=live\n"}}
+
+	err := runMigrateWithDeps(MigrateOptions{Tool: "doppler", Uninstall: true, Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(apiMock.PushedByEnv["production"], map[string]string{"API_KEY": "live"}) {
+		t.Errorf("expected prd to be pushed to production, got %v", apiMock.PushedByEnv)
+	}
+	written := string(fsMock.Written["package.json"])
+	if !strings.Contains(written, `"start": "keyway run -- node server.js"`) {
+		t.Errorf("expected start to run under keyway, got %s", written)
+	}
+	if !strings.Contains(written, `"seed": "doppler run --config prd -- node seed.js"`) {
+		t.Errorf("scripts with other doppler flags should be left for a manual edit, got %s", written)
+	}
+}
+
+func TestRunMigrateWithDeps_UnsupportedTool(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runMigrateWithDeps(MigrateOptions{Tool: "vault"}, deps); err == nil {
+		t.Fatal("expected an error for an unsupported tool")
+	}
+	if len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected one error, got %v", uiMock.ErrorCalls)
+	}
+}
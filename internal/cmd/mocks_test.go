@@ -3,8 +3,15 @@ package cmd
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/cache"
+	"github.com/keywaysh/cli/internal/github"
 )
 
 // MockGitClient is a mock implementation of GitClient
@@ -15,6 +22,8 @@ type MockGitClient struct {
 	AddGitignoreErr  error
 	IsGitRepo        bool
 	Monorepo         MonorepoInfo
+	Branch           string
+	DefaultBranchName string
 }
 
 func (m *MockGitClient) DetectRepo() (string, error) {
@@ -37,6 +46,14 @@ func (m *MockGitClient) DetectMonorepo() MonorepoInfo {
 	return m.Monorepo
 }
 
+func (m *MockGitClient) CurrentBranch() string {
+	return m.Branch
+}
+
+func (m *MockGitClient) DefaultBranch() string {
+	return m.DefaultBranchName
+}
+
 // MockAuthProvider is a mock implementation of AuthProvider
 type MockAuthProvider struct {
 	Token string
@@ -54,8 +71,11 @@ type MockUIProvider struct {
 	ConfirmError    error
 	SelectResult    string
 	SelectError     error
+	MultiSelectResult []string
+	MultiSelectError  error
 	PasswordResult  string
 	PasswordError   error
+	InputResults    []string // Returned in order, then the default value
 	SpinError       error
 
 	// Track calls for assertions
@@ -69,7 +89,10 @@ type MockUIProvider struct {
 	MessageCalls     []string
 	ConfirmCalls     []string
 	SelectCalls      []string
+	SelectOptions    [][]string
+	MultiSelectCalls [][]string
 	PasswordCalls    []string
+	InputCalls       []string
 	DiffAddedCalls   []string
 	DiffChangedCalls []string
 	DiffRemovedCalls []string
@@ -91,12 +114,26 @@ func (m *MockUIProvider) Confirm(message string, defaultValue bool) (bool, error
 }
 func (m *MockUIProvider) Select(message string, options []string) (string, error) {
 	m.SelectCalls = append(m.SelectCalls, message)
+	m.SelectOptions = append(m.SelectOptions, options)
 	return m.SelectResult, m.SelectError
 }
+func (m *MockUIProvider) MultiSelect(message string, options []string) ([]string, error) {
+	m.MultiSelectCalls = append(m.MultiSelectCalls, options)
+	return m.MultiSelectResult, m.MultiSelectError
+}
 func (m *MockUIProvider) Password(prompt string) (string, error) {
 	m.PasswordCalls = append(m.PasswordCalls, prompt)
 	return m.PasswordResult, m.PasswordError
 }
+func (m *MockUIProvider) Input(prompt, defaultValue string) (string, error) {
+	m.InputCalls = append(m.InputCalls, prompt)
+	if len(m.InputResults) == 0 {
+		return defaultValue, nil
+	}
+	result := m.InputResults[0]
+	m.InputResults = m.InputResults[1:]
+	return result, nil
+}
 func (m *MockUIProvider) Spin(message string, fn func() error) error {
 	if m.SpinError != nil {
 		return m.SpinError
@@ -120,12 +157,14 @@ type MockFileSystem struct {
 	WriteError error
 	ReadError  error
 	Written    map[string][]byte
+	Perms      map[string]uint32
 }
 
 func NewMockFileSystem() *MockFileSystem {
 	return &MockFileSystem{
 		Files:   make(map[string][]byte),
 		Written: make(map[string][]byte),
+		Perms:   make(map[string]uint32),
 	}
 }
 
@@ -144,6 +183,9 @@ func (m *MockFileSystem) WriteFile(name string, data []byte, perm uint32) error
 		return m.WriteError
 	}
 	m.Written[name] = data
+	if m.Perms != nil {
+		m.Perms[name] = perm
+	}
 	return nil
 }
 
@@ -153,9 +195,21 @@ type MockAPIClient struct {
 	VaultEnvsError                     error
 	PullResponse                       *api.PullSecretsResponse
 	PullError                          error
+	PullSequence                       []*api.PullSecretsResponse // Returned in order before PullResponse
+	PullAtResponse                     *api.PullSecretsResponse
+	PullAtError                        error
+	PulledRevision                     api.Revision // Captures revision sent in PullSecretsAt call
+	Versions                           []api.EnvironmentVersion
+	VersionsError                      error
+	VersionFilter                      api.VersionFilter // Captures filter sent in ListVersions call
+	SecretMetadata                     []api.SecretMetadata
+	SecretMetadataError                error
 	PushResponse                       *api.PushSecretsResponse
 	PushError                          error
 	PushedSecrets                      map[string]string // Captures secrets sent in PushSecrets call
+	PushedIdempotencyKey               string            // Captures idempotency key sent in PushSecrets call
+	SyncLinks                          []api.SyncLink
+	SyncLinksError                     error
 	InitResponse                       *api.InitVaultResponse
 	InitError                          error
 	VaultExists                        bool
@@ -166,6 +220,71 @@ type MockAPIClient struct {
 	ValidateTokenError                 error
 	CheckGitHubAppInstallationResponse *api.GitHubAppInstallationStatus
 	CheckGitHubAppInstallationError    error
+	Freeze                             *api.EnvironmentFreeze
+	FreezeError                        error
+	FreezeReason                       string // Captures reason sent in FreezeEnvironment call
+	Unfrozen                           bool
+	Events                             []api.VaultEvent
+	EventsError                        error
+	StreamEvents                       []api.VaultEvent
+	StreamError                        error // Returned once StreamEvents are delivered
+	StreamCalls                        int
+	EventFilter                        api.EventFilter // Captures filter sent in event calls
+	Snapshot                           *api.Snapshot
+	SnapshotError                      error
+	RestoreError                       error
+	RestoredSnapshot                   string // Captures snapshot ID sent in RestoreSnapshot call
+	NamedSnapshots                     map[string]*api.SnapshotContent // Named snapshots by name, CreateNamedSnapshot adds to it
+	NamedSnapshotError                 error
+	Trash                              []api.TrashedSecret
+	TrashError                         error
+	RestoredKey                        string // Captures key sent in RestoreTrashedSecret call
+	DeletedKeys                        []string // Captures keys sent in DeleteSecrets call
+	DeleteError                        error
+	Protection                         *api.EnvironmentProtection
+	ProtectionError                    error
+	SetProtection                      *api.EnvironmentProtection // Captures rules sent in SetEnvironmentProtection call
+	Policy                             *api.OrganizationPolicy
+	PolicyError                        error
+	PolicyCalls                        int
+	Sessions                           []api.AuthSession
+	SessionsError                      error
+	RevokedSessions                    []string // Captures IDs sent in RevokeSession calls
+	RevokedOthers                      int      // Returned by RevokeOtherSessions
+	RevokeOthersCalls                  int
+	Elevation                          *api.Elevation
+	ElevationError                     error
+	ElevationDuration                  time.Duration // Captures duration sent in RequestElevation call
+	ElevationReason                    string        // Captures reason sent in RequestElevation call
+	RevokedElevation                   string        // Captures ID sent in RevokeElevation call
+	StepUp                             *api.StepUp
+	StepUpStatuses                     []*api.StepUpStatus // Returned in order by PollStepUp, the last one repeated
+	StepUpError                        error
+	StepUpPolls                        int // Counts PollStepUp calls
+	MovedVault                         *api.MovedVault
+	MovedVaultError                    error
+	RelinkedVault                      []string // Captures the vault and repository sent in RelinkVault call
+	RelinkError                        error
+	PushedByEnv                        map[string]map[string]string // Captures every PushSecrets call, by environment
+	KeyOwners                          *api.KeyOwners
+	KeyOwnersError                     error
+	PullAtVersions                     map[int]*api.PullSecretsResponse
+	Blueprints                         []api.Blueprint
+	BlueprintsError                    error
+	KeyLocations                       []api.KeyLocation
+	KeyLocationsError                  error
+	Invocations                        []api.Invocation // Captures every RecordInvocation call
+	InvocationError                    error
+	EditHints                          []api.EditHint
+	EditHintsError                     error
+	AnnouncedEdits                     []api.EditHint // Captures every AnnounceEdit call
+	WithdrawnEdits                     []string       // Environments of every WithdrawEdit call
+	Environments                       []api.Environment // Returned by ListEnvironments, built from VaultEnvs if nil
+	EnvironmentsError                  error
+	EnvironmentError                   error     // Returned by CreateEnvironment, DeleteEnvironment and RenameEnvironment
+	CreatedEnvironment                 string    // Captures env sent in CreateEnvironment call
+	DeletedEnvironment                 string    // Captures env sent in DeleteEnvironment call
+	RenamedEnvironment                 [2]string // Captures old and new names sent in RenameEnvironment call
 }
 
 func (m *MockAPIClient) StartDeviceLogin(ctx context.Context, repository string, repoIds *api.RepoIds) (*api.DeviceStartResponse, error) {
@@ -183,6 +302,17 @@ func (m *MockAPIClient) CheckGitHubAppInstallation(ctx context.Context, repoOwne
 func (m *MockAPIClient) GetRepoIdsFromBackend(ctx context.Context, repoFullName string) (*api.RepoIds, error) {
 	return nil, nil
 }
+func (m *MockAPIClient) ListSessions(ctx context.Context) ([]api.AuthSession, error) {
+	return m.Sessions, m.SessionsError
+}
+func (m *MockAPIClient) RevokeSession(ctx context.Context, sessionID string) error {
+	m.RevokedSessions = append(m.RevokedSessions, sessionID)
+	return m.SessionsError
+}
+func (m *MockAPIClient) RevokeOtherSessions(ctx context.Context) (int, error) {
+	m.RevokeOthersCalls++
+	return m.RevokedOthers, m.SessionsError
+}
 func (m *MockAPIClient) InitVault(ctx context.Context, repoFullName string) (*api.InitVaultResponse, error) {
 	return m.InitResponse, m.InitError
 }
@@ -195,13 +325,200 @@ func (m *MockAPIClient) GetVaultDetails(ctx context.Context, repoFullName string
 func (m *MockAPIClient) GetVaultEnvironments(ctx context.Context, repoFullName string) ([]string, error) {
 	return m.VaultEnvs, m.VaultEnvsError
 }
-func (m *MockAPIClient) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string) (*api.PushSecretsResponse, error) {
+func (m *MockAPIClient) FindMovedVault(ctx context.Context, repoFullName string) (*api.MovedVault, error) {
+	return m.MovedVault, m.MovedVaultError
+}
+func (m *MockAPIClient) RelinkVault(ctx context.Context, vaultRepo, repoFullName string) error {
+	m.RelinkedVault = []string{vaultRepo, repoFullName}
+	return m.RelinkError
+}
+func (m *MockAPIClient) GetKeyOwners(ctx context.Context, repoFullName string) (*api.KeyOwners, error) {
+	if m.KeyOwners == nil && m.KeyOwnersError == nil {
+		return &api.KeyOwners{}, nil
+	}
+	return m.KeyOwners, m.KeyOwnersError
+}
+func (m *MockAPIClient) ListEnvironments(ctx context.Context, repoFullName string) ([]api.Environment, error) {
+	if m.Environments == nil {
+		for _, name := range m.VaultEnvs {
+			m.Environments = append(m.Environments, api.Environment{Name: name})
+		}
+	}
+	return m.Environments, m.EnvironmentsError
+}
+func (m *MockAPIClient) CreateEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.CreatedEnvironment = env
+	return m.EnvironmentError
+}
+func (m *MockAPIClient) DeleteEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.DeletedEnvironment = env
+	return m.EnvironmentError
+}
+func (m *MockAPIClient) RenameEnvironment(ctx context.Context, repoFullName, env, newName string) error {
+	m.RenamedEnvironment = [2]string{env, newName}
+	return m.EnvironmentError
+}
+func (m *MockAPIClient) GetEnvironmentFreeze(ctx context.Context, repoFullName, env string) (*api.EnvironmentFreeze, error) {
+	if m.Freeze == nil && m.FreezeError == nil {
+		return &api.EnvironmentFreeze{}, nil
+	}
+	return m.Freeze, m.FreezeError
+}
+func (m *MockAPIClient) FreezeEnvironment(ctx context.Context, repoFullName, env, reason string) (*api.EnvironmentFreeze, error) {
+	m.FreezeReason = reason
+	if m.FreezeError != nil {
+		return nil, m.FreezeError
+	}
+	return &api.EnvironmentFreeze{Frozen: true, Reason: reason}, nil
+}
+func (m *MockAPIClient) UnfreezeEnvironment(ctx context.Context, repoFullName, env string) error {
+	m.Unfrozen = true
+	return m.FreezeError
+}
+func (m *MockAPIClient) CreateSnapshot(ctx context.Context, repoFullName, env string) (*api.Snapshot, error) {
+	if m.Snapshot == nil && m.SnapshotError == nil {
+		return &api.Snapshot{ID: "snap_1"}, nil
+	}
+	return m.Snapshot, m.SnapshotError
+}
+func (m *MockAPIClient) RestoreSnapshot(ctx context.Context, repoFullName, env, snapshotID string) error {
+	m.RestoredSnapshot = snapshotID
+	return m.RestoreError
+}
+func (m *MockAPIClient) CreateNamedSnapshot(ctx context.Context, repoFullName, env, name string) (*api.Snapshot, error) {
+	if m.NamedSnapshotError != nil {
+		return nil, m.NamedSnapshotError
+	}
+	if m.NamedSnapshots == nil {
+		m.NamedSnapshots = make(map[string]*api.SnapshotContent)
+	}
+	snapshot := &api.SnapshotContent{Snapshot: api.Snapshot{ID: "snap_" + name, Name: name}}
+	m.NamedSnapshots[name] = snapshot
+	return &snapshot.Snapshot, nil
+}
+func (m *MockAPIClient) GetSnapshot(ctx context.Context, repoFullName, env, name string) (*api.SnapshotContent, error) {
+	if snapshot, ok := m.NamedSnapshots[name]; ok {
+		return snapshot, nil
+	}
+	return nil, &api.APIError{StatusCode: 404, Detail: "Snapshot not found"}
+}
+func (m *MockAPIClient) RecordInvocation(ctx context.Context, repoFullName, env string, invocation api.Invocation) error {
+	m.Invocations = append(m.Invocations, invocation)
+	return m.InvocationError
+}
+func (m *MockAPIClient) ListEditHints(ctx context.Context, repoFullName string) ([]api.EditHint, error) {
+	return m.EditHints, m.EditHintsError
+}
+func (m *MockAPIClient) AnnounceEdit(ctx context.Context, repoFullName, env string, duration time.Duration, note string) (*api.EditHint, error) {
+	if m.EditHintsError != nil {
+		return nil, m.EditHintsError
+	}
+	hint := api.EditHint{Environment: env, Username: "testuser", Note: note, ExpiresAt: time.Now().Add(duration).UTC().Format(time.RFC3339), Mine: true}
+	m.AnnouncedEdits = append(m.AnnouncedEdits, hint)
+	return &hint, nil
+}
+func (m *MockAPIClient) WithdrawEdit(ctx context.Context, repoFullName, env string) error {
+	m.WithdrawnEdits = append(m.WithdrawnEdits, env)
+	return m.EditHintsError
+}
+func (m *MockAPIClient) GetEnvironmentProtection(ctx context.Context, repoFullName, env string) (*api.EnvironmentProtection, error) {
+	if m.Protection == nil && m.ProtectionError == nil {
+		return &api.EnvironmentProtection{}, nil
+	}
+	return m.Protection, m.ProtectionError
+}
+func (m *MockAPIClient) SetEnvironmentProtection(ctx context.Context, repoFullName, env string, rules api.EnvironmentProtection) (*api.EnvironmentProtection, error) {
+	m.SetProtection = &rules
+	if m.ProtectionError != nil {
+		return nil, m.ProtectionError
+	}
+	return &rules, nil
+}
+func (m *MockAPIClient) ListTrash(ctx context.Context, repoFullName, env string) ([]api.TrashedSecret, error) {
+	return m.Trash, m.TrashError
+}
+func (m *MockAPIClient) RestoreTrashedSecret(ctx context.Context, repoFullName, env, key string) error {
+	m.RestoredKey = key
+	return m.TrashError
+}
+func (m *MockAPIClient) DeleteSecrets(ctx context.Context, repoFullName, env string, keys []string) error {
+	m.DeletedKeys = keys
+	return m.DeleteError
+}
+func (m *MockAPIClient) RequestElevation(ctx context.Context, repoFullName, env string, duration time.Duration, reason string) (*api.Elevation, error) {
+	m.ElevationDuration = duration
+	m.ElevationReason = reason
+	return m.Elevation, m.ElevationError
+}
+func (m *MockAPIClient) RevokeElevation(ctx context.Context, repoFullName, env, elevationID string) error {
+	m.RevokedElevation = elevationID
+	return m.ElevationError
+}
+func (m *MockAPIClient) StartStepUp(ctx context.Context, repoFullName, env string) (*api.StepUp, error) {
+	if m.StepUpError != nil {
+		return nil, m.StepUpError
+	}
+	if m.StepUp != nil {
+		return m.StepUp, nil
+	}
+	return &api.StepUp{ID: "su_1", VerificationURL: "https://keyway.sh/step-up/su_1"}, nil
+}
+func (m *MockAPIClient) PollStepUp(ctx context.Context, repoFullName, env, stepUpID string) (*api.StepUpStatus, error) {
+	m.StepUpPolls++
+	if len(m.StepUpStatuses) == 0 {
+		return &api.StepUpStatus{Status: api.StepUpApproved, Token: "kw_stepup"}, nil
+	}
+	i := m.StepUpPolls - 1
+	if i >= len(m.StepUpStatuses) {
+		i = len(m.StepUpStatuses) - 1
+	}
+	return m.StepUpStatuses[i], nil
+}
+func (m *MockAPIClient) GetVaultEvents(ctx context.Context, repoFullName string, filter api.EventFilter) ([]api.VaultEvent, error) {
+	m.EventFilter = filter
+	return m.Events, m.EventsError
+}
+func (m *MockAPIClient) StreamVaultEvents(ctx context.Context, repoFullName string, filter api.EventFilter, lastEventID string, fn func(api.VaultEvent) error) error {
+	m.EventFilter = filter
+	m.StreamCalls++
+	for _, e := range m.StreamEvents {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return m.StreamError
+}
+func (m *MockAPIClient) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*api.PushSecretsResponse, error) {
 	m.PushedSecrets = secrets
+	m.PushedIdempotencyKey = idempotencyKey
+	if m.PushedByEnv == nil {
+		m.PushedByEnv = make(map[string]map[string]string)
+	}
+	m.PushedByEnv[env] = secrets
 	return m.PushResponse, m.PushError
 }
 func (m *MockAPIClient) PullSecrets(ctx context.Context, repo, env string) (*api.PullSecretsResponse, error) {
+	if len(m.PullSequence) > 0 {
+		resp := m.PullSequence[0]
+		m.PullSequence = m.PullSequence[1:]
+		return resp, nil
+	}
 	return m.PullResponse, m.PullError
 }
+func (m *MockAPIClient) PullSecretsAt(ctx context.Context, repo, env string, rev api.Revision) (*api.PullSecretsResponse, error) {
+	m.PulledRevision = rev
+	if resp, ok := m.PullAtVersions[rev.Version]; ok {
+		return resp, nil
+	}
+	return m.PullAtResponse, m.PullAtError
+}
+func (m *MockAPIClient) ListVersions(ctx context.Context, repo, env string, filter api.VersionFilter) ([]api.EnvironmentVersion, error) {
+	m.VersionFilter = filter
+	return m.Versions, m.VersionsError
+}
+func (m *MockAPIClient) ListSecretMetadata(ctx context.Context, repo, env string) ([]api.SecretMetadata, error) {
+	return m.SecretMetadata, m.SecretMetadataError
+}
 func (m *MockAPIClient) GetProviders(ctx context.Context) ([]api.Provider, error) {
 	return nil, nil
 }
@@ -220,6 +537,9 @@ func (m *MockAPIClient) ConnectWithToken(ctx context.Context, provider, provider
 func (m *MockAPIClient) GetAllProviderProjects(ctx context.Context, provider string) ([]api.ProviderProject, []api.Connection, error) {
 	return nil, nil, nil
 }
+func (m *MockAPIClient) GetSyncLinks(ctx context.Context, repo string) ([]api.SyncLink, error) {
+	return m.SyncLinks, m.SyncLinksError
+}
 func (m *MockAPIClient) GetSyncStatus(ctx context.Context, repo, connectionID, projectID, environment string) (*api.SyncStatus, error) {
 	return nil, nil
 }
@@ -235,6 +555,27 @@ func (m *MockAPIClient) ExecuteSync(ctx context.Context, repo string, opts api.S
 func (m *MockAPIClient) StartOrganizationTrial(ctx context.Context, orgLogin string) (*api.StartTrialResponse, error) {
 	return nil, nil
 }
+func (m *MockAPIClient) GetOrganizationPolicy(ctx context.Context, orgLogin string) (*api.OrganizationPolicy, error) {
+	m.PolicyCalls++
+	return m.Policy, m.PolicyError
+}
+func (m *MockAPIClient) ListBlueprints(ctx context.Context, orgLogin string) ([]api.Blueprint, error) {
+	return m.Blueprints, m.BlueprintsError
+}
+func (m *MockAPIClient) GetBlueprint(ctx context.Context, orgLogin, name string) (*api.Blueprint, error) {
+	if m.BlueprintsError != nil {
+		return nil, m.BlueprintsError
+	}
+	for i := range m.Blueprints {
+		if m.Blueprints[i].Name == name {
+			return &m.Blueprints[i], nil
+		}
+	}
+	return nil, &api.APIError{StatusCode: 404, Detail: "Blueprint not found"}
+}
+func (m *MockAPIClient) SearchOrganizationKey(ctx context.Context, orgLogin, key string) ([]api.KeyLocation, error) {
+	return m.KeyLocations, m.KeyLocationsError
+}
 
 // MockAPIFactory creates mock API clients
 type MockAPIFactory struct {
@@ -268,15 +609,172 @@ type MockCommandRunner struct {
 	LastCommand   string
 	LastArgs      []string
 	LastSecrets   map[string]string
+	OnRun         func(secrets map[string]string) // Called while the command "runs"
+	WaitForSignal bool                            // Started commands run until signaled
+	OnSignal      func(sig os.Signal)             // Called when a started command is signaled
+	Signals       []os.Signal
+	IgnoreStop    bool                            // Started commands keep running when stopped, until killed
+	Starts        int
 }
 
 func (m *MockCommandRunner) RunCommand(name string, args []string, secrets map[string]string) error {
 	m.LastCommand = name
 	m.LastArgs = args
 	m.LastSecrets = secrets
+	if m.OnRun != nil {
+		m.OnRun(secrets)
+	}
 	return m.RunError
 }
 
+func (m *MockCommandRunner) StartCommand(name string, args []string, secrets map[string]string) (RunningCommand, error) {
+	m.LastCommand = name
+	m.LastArgs = args
+	m.LastSecrets = secrets
+	if m.OnRun != nil {
+		m.OnRun(secrets)
+	}
+	m.Starts++
+	return &MockRunningCommand{runner: m, signaled: make(chan struct{})}, nil
+}
+
+// MockRunningCommand is a command started by MockCommandRunner
+type MockRunningCommand struct {
+	runner   *MockCommandRunner
+	signaled chan struct{}
+	exited   sync.Once
+}
+
+func (p *MockRunningCommand) Signal(sig os.Signal) error {
+	p.runner.Signals = append(p.runner.Signals, sig)
+	if p.runner.OnSignal != nil {
+		p.runner.OnSignal(sig)
+	}
+	p.exited.Do(func() { close(p.signaled) })
+	return nil
+}
+
+func (p *MockRunningCommand) Stop(sig os.Signal) error {
+	p.runner.Signals = append(p.runner.Signals, sig)
+	if !p.runner.IgnoreStop {
+		p.exited.Do(func() { close(p.signaled) })
+	}
+	return nil
+}
+
+func (p *MockRunningCommand) Wait() error {
+	if p.runner.WaitForSignal {
+		<-p.signaled
+	}
+	return p.runner.RunError
+}
+
+// MockRemoteRunner is a mock implementation of RemoteRunner
+type MockRemoteRunner struct {
+	RunError error
+	Hosts    []string
+	Commands []string
+	Stdin    [][]byte
+}
+
+func (m *MockRemoteRunner) Run(host, command string, stdin []byte) error {
+	m.Hosts = append(m.Hosts, host)
+	m.Commands = append(m.Commands, command)
+	m.Stdin = append(m.Stdin, stdin)
+	return m.RunError
+}
+
+// MockSecretCache is a mock implementation of SecretCache
+type MockSecretCache struct {
+	Entries   map[string]cache.Entry
+	PutError  error
+	Forgotten []string
+}
+
+func (m *MockSecretCache) Get(repo, env string) (*cache.Entry, bool) {
+	entry, ok := m.Entries[repo+"/"+env]
+	if !ok {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (m *MockSecretCache) Put(entry cache.Entry) error {
+	if m.PutError != nil {
+		return m.PutError
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]cache.Entry)
+	}
+	m.Entries[entry.Repo+"/"+entry.Environment] = entry
+	return nil
+}
+
+func (m *MockSecretCache) Forget(repo, env string) error {
+	delete(m.Entries, repo+"/"+env)
+	m.Forgotten = append(m.Forgotten, repo+"/"+env)
+	return nil
+}
+
+// MockClipboard is a mock implementation of Clipboard
+type MockClipboard struct {
+	Content    string
+	WriteError error
+	Writes     []string
+}
+
+func (m *MockClipboard) ReadAll() (string, error) {
+	return m.Content, nil
+}
+
+func (m *MockClipboard) WriteAll(text string) error {
+	if m.WriteError != nil {
+		return m.WriteError
+	}
+	m.Writes = append(m.Writes, text)
+	m.Content = text
+	return nil
+}
+
+// MockGitHubClient is a mock implementation of GitHubSecretsClient and GitHubClientFactory
+type MockGitHubClient struct {
+	Token      string
+	Secrets    []github.Secret
+	ListError  error
+	PutError   error
+	Puts       map[string]string
+	Deleted    []string
+	Repos      []string
+	GitHubEnvs []string
+}
+
+func (m *MockGitHubClient) NewClient(token string) GitHubSecretsClient {
+	m.Token = token
+	return m
+}
+
+func (m *MockGitHubClient) ListActionsSecrets(ctx context.Context, repo, environment string) ([]github.Secret, error) {
+	m.Repos = append(m.Repos, repo)
+	m.GitHubEnvs = append(m.GitHubEnvs, environment)
+	return m.Secrets, m.ListError
+}
+
+func (m *MockGitHubClient) PutActionsSecret(ctx context.Context, repo, environment, name, value string) error {
+	if m.PutError != nil {
+		return m.PutError
+	}
+	if m.Puts == nil {
+		m.Puts = make(map[string]string)
+	}
+	m.Puts[name] = value
+	return nil
+}
+
+func (m *MockGitHubClient) DeleteActionsSecret(ctx context.Context, repo, environment, name string) error {
+	m.Deleted = append(m.Deleted, name)
+	return nil
+}
+
 // MockBrowserOpener is a mock implementation of BrowserOpener
 type MockBrowserOpener struct {
 	OpenError error
@@ -302,12 +800,18 @@ func (m *MockAuthStore) GetAuth() (*StoredAuthInfo, error) {
 type MockHTTPClient struct {
 	StatusCode int
 	HeadError  error
+	GetURLs    []string
 }
 
 func (m *MockHTTPClient) Head(url string) (int, error) {
 	return m.StatusCode, m.HeadError
 }
 
+func (m *MockHTTPClient) Get(url string) (int, error) {
+	m.GetURLs = append(m.GetURLs, url)
+	return m.StatusCode, m.HeadError
+}
+
 // MockFileInfo is a mock implementation of FileInfo
 type MockFileInfo struct {
 	FileName  string
@@ -335,8 +839,14 @@ func (m *MockFileWalker) Walk(root string, fn func(path string, info FileInfo, e
 	if m.WalkError != nil {
 		return m.WalkError
 	}
+	skipped := ""
 	for _, f := range m.Files {
-		if err := fn(f.Path, f.Info, f.Error); err != nil {
+		if skipped != "" && strings.HasPrefix(f.Path, skipped+"/") {
+			continue
+		}
+		if err := fn(f.Path, f.Info, f.Error); err == filepath.SkipDir {
+			skipped = f.Path
+		} else if err != nil {
 			return err
 		}
 	}
@@ -398,6 +908,10 @@ func NewTestDeps() (*Dependencies, *MockGitClient, *MockAuthProvider, *MockUIPro
 		Stat:       stat,
 		AuthStore:  authStore,
 		HTTP:       httpClient,
+		Remote:     &MockRemoteRunner{},
+		Clipboard:  &MockClipboard{},
+		GitHub:     &MockGitHubClient{},
+		Cache:      &MockSecretCache{},
 	}
 
 	return deps, git, auth, ui, fs, apiClient
@@ -436,6 +950,10 @@ func NewTestDepsWithEnv() (*Dependencies, *MockGitClient, *MockAuthProvider, *Mo
 		Stat:       stat,
 		AuthStore:  authStore,
 		HTTP:       httpClient,
+		Remote:     &MockRemoteRunner{},
+		Clipboard:  &MockClipboard{},
+		GitHub:     &MockGitHubClient{},
+		Cache:      &MockSecretCache{},
 	}
 
 	return deps, git, auth, ui, fs, envHelper, apiClient
@@ -474,6 +992,10 @@ func NewTestDepsWithRunner() (*Dependencies, *MockGitClient, *MockAuthProvider,
 		Stat:       stat,
 		AuthStore:  authStore,
 		HTTP:       httpClient,
+		Remote:     &MockRemoteRunner{},
+		Clipboard:  &MockClipboard{},
+		GitHub:     &MockGitHubClient{},
+		Cache:      &MockSecretCache{},
 	}
 
 	return deps, git, auth, ui, cmdRunner, apiClient
@@ -522,6 +1044,10 @@ func NewTestDepsForDoctor() (*Dependencies, *MockGitClient, *MockUIProvider, *Mo
 		Stat:       stat,
 		AuthStore:  authStore,
 		HTTP:       httpClient,
+		Remote:     &MockRemoteRunner{},
+		Clipboard:  &MockClipboard{},
+		GitHub:     &MockGitHubClient{},
+		Cache:      &MockSecretCache{},
 	}
 
 	return deps, git, ui, stat, authStore, httpClient, apiClient
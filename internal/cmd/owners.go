@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "Show who owns the keys of an environment",
+	Long: `Show the owners of each key of an environment.
+
+Owners map keys, or glob patterns of keys, to the users and teams responsible
+for them, like a CODEOWNERS file. They come from the "owners" of .keyway.json
+and from the vault settings on keyway.sh, which win for the same pattern:
+
+  {"owners": {"STRIPE_*": ["@acme/payments"], "DATABASE_URL": ["@acme/platform"]}}
+
+An exact key wins over patterns, and a longer pattern over a shorter one.
+keyway push and keyway set warn when you change keys owned by others. When the
+vault requires their approval, the push waits for it.`,
+	Args: cobra.NoArgs,
+	RunE: runOwners,
+}
+
+func init() {
+	ownersCmd.Flags().StringP("env", "e", "development", "Environment name")
+}
+
+// OwnersOptions contains the parsed flags for the owners command
+type OwnersOptions struct {
+	EnvName string
+}
+
+// runOwners is the entry point for the owners command (uses default dependencies)
+func runOwners(cmd *cobra.Command, args []string) error {
+	opts := OwnersOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runOwnersWithDeps(opts, defaultDeps)
+}
+
+// runOwnersWithDeps is the testable version of runOwners
+func runOwnersWithDeps(opts OwnersOptions, deps *Dependencies) error {
+	return runPipeline(deps, listOwners, withIntro("owners"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin, withProject)
+}
+
+// listOwners lists the keys of an environment with their owners
+func listOwners(s *Session) error {
+	deps := s.Deps
+	var secrets map[string]string
+	err := s.Spin(fmt.Sprintf("Fetching %s...", s.EnvName), func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		secrets = env.ParseVault(resp.Content)
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("owners", err, deps)
+	}
+
+	owners := loadKeyOwners(s.Ctx, s.Client, s.Repo, s.Project, deps)
+	if len(owners.Rules) == 0 {
+		deps.UI.Info("No key owners are set")
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf(`Add them to %s: {"owners": {"STRIPE_*": ["@org/team"]}}`, config.ProjectFile)))
+		return nil
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	deps.UI.Message("")
+	unowned := 0
+	for _, key := range keys {
+		keyOwners := owners.Of(key)
+		if len(keyOwners) == 0 {
+			unowned++
+			continue
+		}
+		line := fmt.Sprintf("  %s  %s", key, deps.UI.Dim(strings.Join(keyOwners, ", ")))
+		if owners.Mine(key) {
+			line += deps.UI.Dim(" (you)")
+		}
+		deps.UI.Message(line)
+	}
+	if unowned > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("  %d key(s) without owner", unowned)))
+	}
+	deps.UI.Message("")
+
+	if owners.RequireApproval {
+		deps.UI.Outro("Changes to keys owned by others need their approval")
+		return nil
+	}
+	deps.UI.Outro("")
+	return nil
+}
+
+// keyOwnership holds the owners rules of a repository and who the user is
+type keyOwnership struct {
+	Rules           map[string][]string
+	RequireApproval bool
+	me              []string
+}
+
+// Of returns the owners of a key
+func (o *keyOwnership) Of(key string) []string {
+	return config.KeyOwners(o.Rules, key)
+}
+
+// Mine returns true if the user is one of the owners of a key
+func (o *keyOwnership) Mine(key string) bool {
+	for _, owner := range o.Of(key) {
+		for _, me := range o.me {
+			if config.SameOwner(owner, me) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OwnedByOthers returns the keys that have owners, none of them being the user
+func (o *keyOwnership) OwnedByOthers(keys []string) []string {
+	var others []string
+	for _, key := range keys {
+		if len(o.Of(key)) > 0 && !o.Mine(key) {
+			others = append(others, key)
+		}
+	}
+	return others
+}
+
+// loadKeyOwners returns the owners rules of .keyway.json with those of the
+// vault on top. The vault's rules are only advisory here since the server
+// enforces them, so failures to fetch them are ignored.
+func loadKeyOwners(ctx context.Context, client api.APIClient, repo string, project *config.Project, deps *Dependencies) *keyOwnership {
+	o := &keyOwnership{Rules: project.Owners}
+	remote, err := client.GetKeyOwners(ctx, repo)
+	if err == nil && remote != nil {
+		o.Rules = config.MergeOwners(project.Owners, remote.Rules)
+		o.RequireApproval = remote.RequireApproval
+		if remote.Login != "" {
+			o.me = append(o.me, remote.Login)
+		}
+		o.me = append(o.me, remote.Teams...)
+	}
+	if len(o.me) == 0 && deps.AuthStore != nil {
+		if stored, err := deps.AuthStore.GetAuth(); err == nil && stored != nil && stored.GitHubLogin != "" {
+			o.me = append(o.me, stored.GitHubLogin)
+		}
+	}
+	return o
+}
+
+// warnKeyOwners warns when a change touches keys owned by others. It does not
+// block: when the vault requires their approval, the server holds the push.
+func warnKeyOwners(ctx context.Context, client api.APIClient, repo string, keys []string, deps *Dependencies) {
+	if len(keys) == 0 {
+		return
+	}
+	project, err := loadProject(deps)
+	if err != nil {
+		return
+	}
+	owners := loadKeyOwners(ctx, client, repo, project, deps)
+	others := owners.OwnedByOthers(keys)
+	if len(others) == 0 {
+		return
+	}
+
+	deps.UI.Warn(fmt.Sprintf("Changing %d key(s) owned by others:", len(others)))
+	for _, key := range others {
+		deps.UI.Message(fmt.Sprintf("  %s  %s", key, deps.UI.Dim(strings.Join(owners.Of(key), ", "))))
+	}
+	if owners.RequireApproval {
+		deps.UI.Message(deps.UI.Dim("Their approval will be required before the change applies"))
+	}
+	deps.UI.Message("")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+)
+
+const paymentsOwnersProject = `{"owners": {"STRIPE_*": ["@acme/payments"], "DATABASE_URL": ["@alice"]}}`
+
+func TestRunOwnersWithDeps_ListsOwners(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(paymentsOwnersProject)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=sk\nDATABASE_URL=pg\nLOG_LEVEL=debug\n"}
+	apiMock.KeyOwners = &api.KeyOwners{Login: "alice", RequireApproval: true}
+
+	if err := runOwnersWithDeps(OwnersOptions{EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(output, "STRIPE_KEY  @acme/payments") {
+		t.Errorf("expected STRIPE_KEY's owner to be listed, got %v", uiMock.MessageCalls)
+	}
+	if !strings.Contains(output, "DATABASE_URL  @alice (you)") {
+		t.Errorf("expected DATABASE_URL to be marked as owned by the user, got %v", uiMock.MessageCalls)
+	}
+	if !strings.Contains(output, "1 key(s) without owner") {
+		t.Errorf("expected LOG_LEVEL to be counted without owner, got %v", uiMock.MessageCalls)
+	}
+	if len(uiMock.OutroCalls) == 0 || !strings.Contains(uiMock.OutroCalls[0], "approval") {
+		t.Errorf("expected the approval requirement to be shown, got %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunOwnersWithDeps_ServerRulesOverrideConfig(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(paymentsOwnersProject)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=sk\n"}
+	apiMock.KeyOwners = &api.KeyOwners{Rules: map[string][]string{"STRIPE_*": {"@acme/billing"}}}
+
+	if err := runOwnersWithDeps(OwnersOptions{EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(output, "@acme/billing") || strings.Contains(output, "@acme/payments") {
+		t.Errorf("expected the vault's rule to win, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunPushWithDeps_WarnsAboutKeysOwnedByOthers(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[config.ProjectFile] = []byte(paymentsOwnersProject)
+	fsMock.Files[".env"] = []byte("STRIPE_KEY=sk_new\nDATABASE_URL=pg_new\n")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=sk_old\nDATABASE_URL=pg_old\n"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "ok"}
+	apiMock.KeyOwners = &api.KeyOwners{Login: "alice", RequireApproval: true}
+
+	if err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) == 0 || !strings.Contains(uiMock.WarnCalls[0], "1 key(s) owned by others") {
+		t.Errorf("expected a warning about STRIPE_KEY only, got %v", uiMock.WarnCalls)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(output, "STRIPE_KEY  @acme/payments") || strings.Contains(output, "DATABASE_URL  @alice") {
+		t.Errorf("expected STRIPE_KEY to be listed, got %v", uiMock.MessageCalls)
+	}
+	if !strings.Contains(output, "approval will be required") {
+		t.Errorf("expected the approval requirement to be shown, got %v", uiMock.MessageCalls)
+	}
+	if apiMock.PushedSecrets["STRIPE_KEY"] != "sk_new" {
+		t.Errorf("expected the push not to be blocked, got %v", apiMock.PushedSecrets)
+	}
+}
+
+func TestRunSetWithDeps_NoWarningForOwnTeam(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(paymentsOwnersProject)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.KeyOwners = &api.KeyOwners{Login: "bob", Teams: []string{"acme/Payments"}}
+
+	if err := runSetWithDeps(SetOptions{Key: "STRIPE_KEY", Value: "sk", EnvName: "production", EnvFlagSet: true, Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, msg := range uiMock.WarnCalls {
+		if strings.Contains(msg, "owned by others") {
+			t.Errorf("expected no warning for a key of the user's team, got %v", uiMock.WarnCalls)
+		}
+	}
+}
+
+func TestRunPushWithDeps_KeyOwnersViolation(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("STRIPE_KEY=sk_new")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushError = &api.APIError{
+		StatusCode: 403,
+		Detail:     "Push needs the approval of the key owners",
+		Protection: &api.ProtectionViolation{Rule: api.RuleKeyOwners, Keys: []string{"STRIPE_KEY"}, Reviewers: []string{"@acme/payments"}, ApprovalURL: "https://keyway.sh/approvals/1"},
+	}
+
+	if err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps); err == nil {
+		t.Fatal("expected error")
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if !strings.Contains(output, "key owners (STRIPE_KEY need approval from @acme/payments)") {
+		t.Errorf("expected the rule to be explained, got %v", uiMock.MessageCalls)
+	}
+	if !strings.Contains(output, "https://keyway.sh/approvals/1") {
+		t.Errorf("expected the approval link, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestAddDiffOwners(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(paymentsOwnersProject)
+	result := compareSecrets("production", "staging",
+		map[string]string{"STRIPE_KEY": "a", "LOG_LEVEL": "info"},
+		map[string]string{"STRIPE_KEY": "b", "DATABASE_URL": "pg"}, false)
+
+	addDiffOwners(context.Background(), apiMock, "owner/repo", result, deps)
+
+	if result.Owners["STRIPE_KEY"][0] != "@acme/payments" || result.Owners["DATABASE_URL"][0] != "@alice" {
+		t.Errorf("expected the owners of the differing keys, got %v", result.Owners)
+	}
+	if _, ok := result.Owners["LOG_LEVEL"]; ok {
+		t.Errorf("expected no owner for LOG_LEVEL, got %v", result.Owners)
+	}
+}
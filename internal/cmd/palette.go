@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/ui"
+	"github.com/keywaysh/cli/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+// paletteRecentEnvironments is how many recently used environments the
+// command palette offers shortcuts for
+const paletteRecentEnvironments = 3
+
+// paletteEnvCommands are the commands the palette offers with each recent
+// environment, as they need nothing else to run
+var paletteEnvCommands = []string{"pull", "push", "shell"}
+
+// paletteEntry is a command the palette can run
+type paletteEntry struct {
+	// Args are the arguments of keyway, e.g. ["pull", "--env", "staging"]
+	Args  []string
+	Label string
+}
+
+// paletteEntries returns the commands of root to search, after shortcuts for
+// the recently used environments
+func paletteEntries(root *cobra.Command, recentEnvs []string) []paletteEntry {
+	var entries []paletteEntry
+	for _, envName := range recentEnvs {
+		for _, name := range paletteEnvCommands {
+			c, _, err := root.Find([]string{name})
+			if err != nil || c == root {
+				continue
+			}
+			entries = append(entries, paletteEntry{
+				Args:  []string{name, "--env", envName},
+				Label: fmt.Sprintf("%s -e %s  (recent)", name, envName),
+			})
+		}
+	}
+
+	var commands []paletteEntry
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			if sub.Hidden || !sub.IsAvailableCommand() {
+				continue
+			}
+			if sub.HasAvailableSubCommands() {
+				walk(sub)
+				if !sub.Runnable() {
+					continue
+				}
+			}
+			path := strings.TrimPrefix(sub.CommandPath(), root.Name()+" ")
+			commands = append(commands, paletteEntry{
+				Args:  strings.Fields(path),
+				Label: fmt.Sprintf("%s  - %s", path, sub.Short),
+			})
+		}
+	}
+	walk(root)
+	sort.SliceStable(commands, func(i, j int) bool {
+		return strings.Join(commands[i].Args, " ") < strings.Join(commands[j].Args, " ")
+	})
+	return append(entries, commands...)
+}
+
+// recentEnvironments returns the environments recently passed with --env,
+// from the local usage log
+func recentEnvironments(fs FileSystem) []string {
+	path := usage.Path()
+	if path == "" || usage.IsDisabled() {
+		return nil
+	}
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return usage.RecentEnvironments(usage.Parse(data), paletteRecentEnvironments)
+}
+
+// runPalette lets the user search the commands and runs the one picked
+func runPalette(root *cobra.Command) error {
+	entries := paletteEntries(root, recentEnvironments(defaultDeps.FS))
+	labels := make([]string, len(entries))
+	for i, e := range entries {
+		labels[i] = e.Label
+	}
+
+	selected, err := ui.Select("Type to search commands", labels)
+	if err != nil || selected == "" {
+		return err
+	}
+	for _, e := range entries {
+		if e.Label == selected {
+			return runPaletteEntry(root, e)
+		}
+	}
+	return nil
+}
+
+// runPaletteEntry runs the command of an entry, or shows how to call it when
+// it needs arguments the palette cannot guess
+func runPaletteEntry(root *cobra.Command, entry paletteEntry) error {
+	c, rest, err := root.Find(entry.Args)
+	if err != nil {
+		return err
+	}
+	if err := c.ParseFlags(rest); err != nil {
+		return err
+	}
+	if c.ValidateArgs(c.Flags().Args()) != nil || c.ValidateRequiredFlags() != nil {
+		ui.Message(fmt.Sprintf("Usage: %s", cyan(c.UseLine())))
+		if c.Example != "" {
+			ui.Message(c.Example)
+		} else if c.Long != "" {
+			ui.Message(ui.Dim(fmt.Sprintf("See: %s --help", c.CommandPath())))
+		}
+		return nil
+	}
+
+	fmt.Println()
+	ui.Message(ui.Dim("$ " + root.Name() + " " + strings.Join(entry.Args, " ")))
+	if c.RunE != nil {
+		return c.RunE(c, c.Flags().Args())
+	}
+	c.Run(c, c.Flags().Args())
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// paletteTestRoot returns a small command tree shaped like keyway's
+func paletteTestRoot(ran *[]string) *cobra.Command {
+	root := &cobra.Command{Use: "keyway"}
+	record := func(cmd *cobra.Command, args []string) error {
+		env, _ := cmd.Flags().GetString("env")
+		*ran = append(*ran, cmd.Name()+":"+env)
+		return nil
+	}
+	pull := &cobra.Command{Use: "pull", Short: "Download secrets", RunE: record}
+	pull.Flags().StringP("env", "e", "development", "")
+	push := &cobra.Command{Use: "push", Short: "Upload secrets", RunE: record}
+	push.Flags().StringP("env", "e", "development", "")
+	set := &cobra.Command{Use: "set <KEY> [VALUE]", Short: "Set a secret", Args: cobra.RangeArgs(1, 2), Example: "  keyway set API_KEY", RunE: record}
+	env := &cobra.Command{Use: "env", Short: "Manage environments"}
+	env.AddCommand(&cobra.Command{Use: "freeze <environment>", Short: "Freeze", Args: cobra.ExactArgs(1), RunE: record})
+	hidden := &cobra.Command{Use: "secret", Hidden: true, RunE: record}
+	root.AddCommand(pull, push, set, env, hidden)
+	return root
+}
+
+func TestPaletteEntries(t *testing.T) {
+	var ran []string
+	entries := paletteEntries(paletteTestRoot(&ran), []string{"staging"})
+
+	var labels []string
+	for _, e := range entries {
+		labels = append(labels, strings.Join(e.Args, " "))
+	}
+	want := "pull --env staging|push --env staging|env freeze|pull|push|set"
+	if got := strings.Join(labels, "|"); got != want {
+		t.Errorf("palette entries = %q, want %q", got, want)
+	}
+	if !strings.Contains(entries[0].Label, "(recent)") || !strings.Contains(entries[3].Label, "Download secrets") {
+		t.Errorf("unexpected labels: %q, %q", entries[0].Label, entries[3].Label)
+	}
+}
+
+func TestRunPaletteEntry(t *testing.T) {
+	var ran []string
+	root := paletteTestRoot(&ran)
+
+	if err := runPaletteEntry(root, paletteEntry{Args: []string{"pull", "--env", "staging"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "pull:staging" {
+		t.Errorf("expected pull to run on staging, ran %v", ran)
+	}
+}
+
+func TestRunPaletteEntry_NeedsArguments(t *testing.T) {
+	var ran []string
+	root := paletteTestRoot(&ran)
+
+	if err := runPaletteEntry(root, paletteEntry{Args: []string{"set"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("expected set not to run without a key, ran %v", ran)
+	}
+}
+
+func TestRecentEnvironments_UsageDisabled(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	t.Setenv("KEYWAY_DISABLE_USAGE", "1")
+	_, _, _, _, fsMock, _ := NewTestDeps()
+
+	if got := recentEnvironments(fsMock); got != nil {
+		t.Errorf("expected no environments with usage disabled, got %v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// requirePermission checks the caller's role on the repository before a command
+// does any work, so that a read-only collaborator is told upfront instead of
+// failing on the final API call. Lookup failures are ignored: the server still
+// enforces access.
+func requirePermission(ctx context.Context, client api.APIClient, repo, required, action string, deps *Dependencies) error {
+	details, err := client.GetVaultDetails(ctx, repo)
+	if err != nil || details.HasPermission(required) {
+		return nil
+	}
+	// Write access granted by keyway sudo is per environment, the server
+	// rejects writes to the others
+	if required == api.PermissionWrite && hasActiveElevation(repo, deps) {
+		return nil
+	}
+
+	deps.UI.Error(fmt.Sprintf("You have %s access to %s, %s requires %s access", details.Permission, repo, action, required))
+	if !details.HasPermission(api.PermissionWrite) {
+		deps.UI.Message(deps.UI.Dim("You can still read secrets: keyway pull, keyway run, keyway diff"))
+	}
+	if required == api.PermissionWrite {
+		deps.UI.Message(deps.UI.Dim(`Or get temporary write access: keyway sudo --env <env> --reason "..."`))
+	}
+	deps.UI.Message(deps.UI.Dim("Ask a repository admin for more access on GitHub"))
+	return fmt.Errorf("%s requires %s access to %s", action, required, repo)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunPushWithDeps_ReadOnlyDeniedUpfront(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=secret\n")
+	apiMock.VaultDetails = &api.VaultDetails{RepoFullName: "owner/repo", Permission: "read"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "development", EnvFlagSet: true, File: ".env", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected permission error")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "read access") {
+		t.Errorf("expected read access explanation, got %v", uiMock.ErrorCalls)
+	}
+	found := false
+	for _, msg := range uiMock.MessageCalls {
+		if strings.Contains(msg, "keyway pull") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected read-only alternatives to be suggested, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunSetWithDeps_ReadOnlyDenied(t *testing.T) {
+	deps, _, _, _, _, _, apiMock := NewTestDepsWithEnv()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: "triage"}
+
+	err := runSetWithDeps(SetOptions{Key: "API_KEY", Value: "v", EnvName: "development", EnvFlagSet: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected permission error")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
+
+func TestRunEnvFreezeWithDeps_RequiresMaintain(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: "write"}
+
+	err := runEnvFreezeWithDeps(EnvFreezeOptions{EnvName: "production", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected permission error")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "requires maintain access") {
+		t.Errorf("expected maintain explanation, got %v", uiMock.ErrorCalls)
+	}
+	for _, msg := range uiMock.MessageCalls {
+		if strings.Contains(msg, "keyway pull") {
+			t.Error("expected no read-only hint for a writer")
+		}
+	}
+}
+
+func TestRequirePermission_AllowsWhenUnknown(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultDetailsError = errors.New("network down")
+
+	if err := requirePermission(context.Background(), apiMock, "owner/repo", api.PermissionAdmin, "test", deps); err != nil {
+		t.Errorf("expected lookup failure to be ignored, got %v", err)
+	}
+
+	apiMock.VaultDetailsError = nil
+	apiMock.VaultDetails = &api.VaultDetails{}
+	if err := requirePermission(context.Background(), apiMock, "owner/repo", api.PermissionAdmin, "test", deps); err != nil {
+		t.Errorf("expected missing role to be allowed, got %v", err)
+	}
+}
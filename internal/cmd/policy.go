@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// orgPolicyTTL is how long an organization's policy is cached
+const orgPolicyTTL = 24 * time.Hour
+
+// orgPolicyTimeout bounds the policy fetch made before a command runs
+const orgPolicyTimeout = 1500 * time.Millisecond
+
+// policyExemptCommands still run when the policy blocks the CLI, so that a
+// blocked member can read help, diagnose, check the binary and log out
+var policyExemptCommands = map[string]bool{
+	"help":           true,
+	"completion":     true,
+	"doctor":         true,
+	"verify-install": true,
+	"logout":         true,
+}
+
+// cachedOrgPolicy is an organization's policy as last fetched
+type cachedOrgPolicy struct {
+	Policy    api.OrganizationPolicy `json:"policy"`
+	FetchedAt time.Time              `json:"fetchedAt"`
+}
+
+// applyOrgPolicy loads the policy of the organization owning the current
+// repository, and sends telemetry where it wants it. The policy is cached for a
+// day, and only fetched when getToken finds a token, so commands never prompt
+// for it. It returns nil outside an organization's repository.
+func applyOrgPolicy(deps *Dependencies, getToken func() string, now time.Time) *api.OrganizationPolicy {
+	policy := loadOrgPolicy(deps, getToken, now)
+	if policy == nil || config.IsTelemetryDisabled() {
+		return policy
+	}
+
+	analytics.Configure(analytics.Policy{
+		Sink:     policy.Telemetry.Sink,
+		Endpoint: policy.Telemetry.Endpoint,
+		Headers:  policy.Telemetry.Headers,
+	})
+	return policy
+}
+
+// loadOrgPolicy returns the cached policy of the current repository's
+// organization, refreshing it when it is stale
+func loadOrgPolicy(deps *Dependencies, getToken func() string, now time.Time) *api.OrganizationPolicy {
+	org := repoOrg(deps)
+	if org == "" {
+		return nil
+	}
+
+	policies := loadOrgPolicies(deps)
+	cached, ok := policies[org]
+	if !ok || now.Sub(cached.FetchedAt) > orgPolicyTTL {
+		if token := getToken(); token != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), orgPolicyTimeout)
+			policy, err := deps.APIFactory.NewClient(token).GetOrganizationPolicy(ctx, org)
+			cancel()
+
+			if apiErr, isAPIErr := err.(*api.APIError); isAPIErr && apiErr.StatusCode == 404 {
+				// Personal accounts and organizations without a policy
+				policy, err = &api.OrganizationPolicy{}, nil
+			}
+			if err == nil && policy != nil {
+				cached, ok = cachedOrgPolicy{Policy: *policy, FetchedAt: now}, true
+				policies[org] = cached
+				_ = saveOrgPolicies(policies, deps)
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return &cached.Policy
+}
+
+// cachedPolicyFor returns the last fetched policy of the current repository's
+// organization, however old, or nil. Commands use it to enforce the policy
+// without fetching it again.
+func cachedPolicyFor(deps *Dependencies) *api.OrganizationPolicy {
+	org := repoOrg(deps)
+	if org == "" {
+		return nil
+	}
+	cached, ok := loadOrgPolicies(deps)[org]
+	if !ok {
+		return nil
+	}
+	return &cached.Policy
+}
+
+// repoOrg returns the owner of the current repository, or "" outside one
+func repoOrg(deps *Dependencies) string {
+	repo, err := deps.Git.DetectRepo()
+	if err != nil || !strings.Contains(repo, "/") {
+		return ""
+	}
+	return strings.SplitN(repo, "/", 2)[0]
+}
+
+// enforceCommandPolicy blocks a command when the CLI doesn't meet the policy:
+// a version older than the minimum, or telemetry disabled where it is required
+func enforceCommandPolicy(cmd *cobra.Command, policy *api.OrganizationPolicy, ver string) error {
+	if policy == nil || policyExemptCommands[cmd.Name()] {
+		return nil
+	}
+
+	if policy.MinCLIVersion != "" && version.IsNewerVersion(policy.MinCLIVersion, ver) {
+		fmt.Fprintf(os.Stderr, "\n  Your organization requires keyway %s or later, this is %s.\n", policy.MinCLIVersion, ver)
+		fmt.Fprintf(os.Stderr, "  Update with: %s\n", version.GetUpdateCommand(version.DetectInstallMethod()))
+		return fmt.Errorf("blocked by organization policy: keyway %s or later is required", policy.MinCLIVersion)
+	}
+	if policy.Telemetry.Required && config.IsTelemetryDisabled() {
+		fmt.Fprintf(os.Stderr, "\n  Your organization requires telemetry, unset KEYWAY_DISABLE_TELEMETRY to continue.\n")
+		return fmt.Errorf("blocked by organization policy: telemetry is required")
+	}
+	return nil
+}
+
+// checkEnvironmentPolicy returns an error if the organization's policy
+// protects the environment and the caller is not a repository admin
+func checkEnvironmentPolicy(ctx context.Context, client api.APIClient, repo, envName string, deps *Dependencies) error {
+	policy := cachedPolicyFor(deps)
+	if policy == nil {
+		return nil
+	}
+	for _, protected := range policy.ProtectedEnvironments {
+		if normalizeEnvName(protected) == envName {
+			return requirePermission(ctx, client, repo, api.PermissionAdmin, fmt.Sprintf("changing %s (protected by your organization's policy)", envName), deps)
+		}
+	}
+	return nil
+}
+
+// checkKeyNamingPolicy returns an error if a new key doesn't match the naming
+// rule of the organization's policy. Existing keys are not checked, so that
+// they can still be updated.
+func checkKeyNamingPolicy(keys []string, deps *Dependencies) error {
+	policy := cachedPolicyFor(deps)
+	if policy == nil || policy.KeyPattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(policy.KeyPattern)
+	if err != nil {
+		// A broken rule is the organization's to fix, the server still enforces it
+		return nil
+	}
+
+	var invalid []string
+	for _, key := range keys {
+		if !pattern.MatchString(key) {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	deps.UI.Error(fmt.Sprintf("Blocked by your organization's policy, key names must match %s: %s", policy.KeyPattern, strings.Join(invalid, ", ")))
+	deps.UI.Message(deps.UI.Dim("Rename the keys, or ask an organization admin about the naming rule"))
+	return fmt.Errorf("blocked by organization policy: invalid key names %s", strings.Join(invalid, ", "))
+}
+
+// orgPolicyPath returns the file caching policies by organization
+func orgPolicyPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "org-policies.json")
+}
+
+// loadOrgPolicies returns the cached policies, by organization
+func loadOrgPolicies(deps *Dependencies) map[string]cachedOrgPolicy {
+	policies := make(map[string]cachedOrgPolicy)
+	path := orgPolicyPath()
+	if path == "" {
+		return policies
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &policies)
+	}
+	return policies
+}
+
+// saveOrgPolicies writes the policy cache
+func saveOrgPolicies(policies map[string]cachedOrgPolicy, deps *Dependencies) error {
+	path := orgPolicyPath()
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(policies, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
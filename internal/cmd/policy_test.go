@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+func withToken(token string) func() string {
+	return func() string { return token }
+}
+
+func TestApplyOrgPolicy_FetchesAndCaches(t *testing.T) {
+	t.Setenv("KEYWAY_DISABLE_TELEMETRY", "")
+	t.Cleanup(func() { analytics.Configure(analytics.Policy{}) })
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.Policy = &api.OrganizationPolicy{Telemetry: api.TelemetryPolicy{Sink: "otlp", Endpoint: "https://otel.owner.dev"}}
+	now := time.Now()
+
+	applyOrgPolicy(deps, withToken("token"), now)
+
+	if apiMock.PolicyCalls != 1 {
+		t.Fatalf("expected the policy to be fetched, got %d calls", apiMock.PolicyCalls)
+	}
+	var cached map[string]cachedOrgPolicy
+	if err := json.Unmarshal(fsMock.Written[orgPolicyPath()], &cached); err != nil {
+		t.Fatalf("expected the policy to be cached: %v", err)
+	}
+	if cached["owner"].Policy.Telemetry.Endpoint != "https://otel.owner.dev" {
+		t.Errorf("unexpected cache: %+v", cached)
+	}
+
+	// A fresh cache is used without fetching again
+	fsMock.Files[orgPolicyPath()] = fsMock.Written[orgPolicyPath()]
+	applyOrgPolicy(deps, withToken("token"), now.Add(time.Hour))
+	if apiMock.PolicyCalls != 1 {
+		t.Errorf("expected the cached policy to be used, got %d calls", apiMock.PolicyCalls)
+	}
+
+	// A stale one is refreshed
+	applyOrgPolicy(deps, withToken("token"), now.Add(orgPolicyTTL+time.Hour))
+	if apiMock.PolicyCalls != 2 {
+		t.Errorf("expected a stale policy to be refreshed, got %d calls", apiMock.PolicyCalls)
+	}
+}
+
+func TestApplyOrgPolicy_NoPolicyIsCached(t *testing.T) {
+	t.Setenv("KEYWAY_DISABLE_TELEMETRY", "")
+	t.Cleanup(func() { analytics.Configure(analytics.Policy{}) })
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.PolicyError = &api.APIError{StatusCode: 404}
+
+	applyOrgPolicy(deps, withToken("token"), time.Now())
+
+	if _, ok := fsMock.Written[orgPolicyPath()]; !ok {
+		t.Error("expected a missing policy to be cached too")
+	}
+}
+
+func TestApplyOrgPolicy_SkipsWithoutToken(t *testing.T) {
+	t.Setenv("KEYWAY_DISABLE_TELEMETRY", "")
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+
+	applyOrgPolicy(deps, withToken(""), time.Now())
+
+	if apiMock.PolicyCalls != 0 || len(fsMock.Written) != 0 {
+		t.Error("expected nothing to be fetched without a token")
+	}
+}
+
+func TestApplyOrgPolicy_FetchesWhenTelemetryDisabled(t *testing.T) {
+	t.Setenv("KEYWAY_DISABLE_TELEMETRY", "1")
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Policy = &api.OrganizationPolicy{MinCLIVersion: "1.4.0"}
+
+	policy := applyOrgPolicy(deps, withToken("token"), time.Now())
+
+	if apiMock.PolicyCalls != 1 || policy == nil || policy.MinCLIVersion != "1.4.0" {
+		t.Errorf("expected the policy to be enforced even without telemetry, got %+v", policy)
+	}
+}
+
+// cachePolicy stores an organization policy for the "owner" organization
+func cachePolicy(t *testing.T, fsMock *MockFileSystem, policy api.OrganizationPolicy) {
+	t.Helper()
+	data, err := json.Marshal(map[string]cachedOrgPolicy{"owner": {Policy: policy, FetchedAt: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsMock.Files[orgPolicyPath()] = data
+}
+
+func TestEnforceCommandPolicy(t *testing.T) {
+	push := &cobra.Command{Use: "push"}
+	doctor := &cobra.Command{Use: "doctor"}
+
+	tests := []struct {
+		name      string
+		cmd       *cobra.Command
+		policy    *api.OrganizationPolicy
+		ver       string
+		telemetry string
+		blocked   bool
+	}{
+		{"no policy", push, nil, "1.0.0", "", false},
+		{"recent enough", push, &api.OrganizationPolicy{MinCLIVersion: "1.4.0"}, "1.4.0", "", false},
+		{"too old", push, &api.OrganizationPolicy{MinCLIVersion: "1.4.0"}, "1.3.9", "", true},
+		{"dev build", push, &api.OrganizationPolicy{MinCLIVersion: "1.4.0"}, "dev", "", false},
+		{"exempt command", doctor, &api.OrganizationPolicy{MinCLIVersion: "1.4.0"}, "1.3.9", "", false},
+		{"telemetry required", push, &api.OrganizationPolicy{Telemetry: api.TelemetryPolicy{Required: true}}, "1.4.0", "1", true},
+		{"telemetry on", push, &api.OrganizationPolicy{Telemetry: api.TelemetryPolicy{Required: true}}, "1.4.0", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KEYWAY_DISABLE_TELEMETRY", tt.telemetry)
+			err := enforceCommandPolicy(tt.cmd, tt.policy, tt.ver)
+			if blocked := err != nil; blocked != tt.blocked {
+				t.Errorf("enforceCommandPolicy() = %v, want blocked %v", err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestRunPushWithDeps_PolicyProtectedEnvironment(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _, apiMock := NewTestDepsWithEnv()
+	cachePolicy(t, fsMock, api.OrganizationPolicy{ProtectedEnvironments: []string{"prod"}})
+	fsMock.Files[".env"] = []byte("API_KEY=new\n")
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionWrite}
+
+	err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err == nil || !strings.Contains(err.Error(), "admin") {
+		t.Fatalf("expected a protected environment to require admin, got %v", err)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "organization's policy") {
+		t.Errorf("expected the policy to be named, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPushWithDeps_PolicyKeyNaming(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _, apiMock := NewTestDepsWithEnv()
+	cachePolicy(t, fsMock, api.OrganizationPolicy{KeyPattern: "^[A-Z][A-Z0-9_]*$"})
+	fsMock.Files[".env"] = []byte("legacyKey=1\napiKey=2\nAPI_KEY=3\n")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "legacyKey=0\n"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "staging", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected the new key to be blocked")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.HasSuffix(uiMock.ErrorCalls[0], ": apiKey") {
+		t.Errorf("expected only the new key to be blocked, got %v", uiMock.ErrorCalls)
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/cache"
+	"github.com/keywaysh/cli/internal/injector"
+	"github.com/spf13/cobra"
+)
+
+var prefetchCmd = &cobra.Command{
+	Use:   "prefetch",
+	Short: "Cache an environment so keyway run starts instantly",
+	Long: `Fetch the secrets of an environment into an encrypted cache on disk, so
+that the next keyway run starts without waiting for the network.
+
+Prefetching is meant to run in the background from a shell hook, each time
+you cd into a repository. Add the hook to your shell's startup file:
+
+  eval "$(keyway prefetch hook bash)"   # ~/.bashrc
+  eval "$(keyway prefetch hook zsh)"    # ~/.zshrc
+  keyway prefetch hook fish | source    # ~/.config/fish/config.fish
+
+The cache is encrypted with the key of your stored login and cleared by
+keyway logout. keyway run uses entries fetched less than 10 minutes ago and
+fetches the secrets itself otherwise, or with --no-cache. Pushing or setting
+secrets from this machine drops the cached environment, and keyway run
+--reload-on-change refreshes it as soon as the vault reports a change.
+
+Prefetching never prompts: outside a repository, or when not logged in, it
+does nothing.`,
+	Args: cobra.NoArgs,
+	RunE: runPrefetch,
+}
+
+var prefetchHookCmd = &cobra.Command{
+	Use:   "hook [bash|zsh|fish]",
+	Short: "Print the shell hook prefetching secrets on cd",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runPrefetchHook,
+}
+
+func init() {
+	prefetchCmd.Flags().StringP("env", "e", "development", "Environment name")
+	prefetchCmd.Flags().Bool("quiet", false, "Print nothing, for shell hooks")
+	prefetchCmd.AddCommand(prefetchHookCmd)
+}
+
+// prefetchMaxAge is how old a prefetched environment keyway run still uses
+const prefetchMaxAge = 10 * time.Minute
+
+// prefetchRefreshAfter is how old a prefetched environment gets before
+// prefetching it again, so that a shell hook firing often stays cheap
+const prefetchRefreshAfter = time.Minute
+
+// prefetchHookOutput is where keyway prefetch hook prints the code to evaluate
+var prefetchHookOutput io.Writer = os.Stdout
+
+// PrefetchOptions contains the parsed flags for the prefetch command
+type PrefetchOptions struct {
+	EnvName string
+	Quiet   bool
+}
+
+// runPrefetch is the entry point for the prefetch command (uses default dependencies)
+func runPrefetch(cmd *cobra.Command, args []string) error {
+	opts := PrefetchOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Quiet, _ = cmd.Flags().GetBool("quiet")
+
+	deps := defaultDeps
+	if opts.Quiet {
+		deps = withQuietUI(deps)
+	}
+	return runPrefetchWithDeps(opts, deps)
+}
+
+// runPrefetchWithDeps is the testable version of runPrefetch
+func runPrefetchWithDeps(opts PrefetchOptions, deps *Dependencies) error {
+	if deps.Cache == nil {
+		return nil
+	}
+	repo, err := deps.Git.DetectRepo()
+	if err != nil {
+		// The hook fires in every directory, most of them not repositories
+		return nil
+	}
+	token := prefetchToken(deps)
+	if token == "" {
+		return nil
+	}
+	envName := normalizeEnvName(opts.EnvName)
+	if envName == "" {
+		envName = "development"
+	}
+
+	now := time.Now()
+	if entry, ok := deps.Cache.Get(repo, envName); ok && entry.Age(now) < prefetchRefreshAfter {
+		deps.UI.Info(fmt.Sprintf("%s was prefetched %s ago", envName, entry.Age(now).Round(time.Second)))
+		return nil
+	}
+
+	client := deps.APIFactory.NewClient(token)
+	resp, err := client.PullSecrets(context.Background(), repo, envName)
+	if err != nil {
+		if opts.Quiet {
+			return nil
+		}
+		deps.UI.Error(fmt.Sprintf("Could not prefetch %s: %s", envName, err.Error()))
+		return err
+	}
+	err = deps.Cache.Put(cache.Entry{
+		Repo:        repo,
+		Environment: envName,
+		Content:     resp.Content,
+		Version:     resp.Version,
+		FetchedAt:   now,
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Could not write the cache: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Prefetched %s for %s", envName, repo))
+	return nil
+}
+
+// prefetchToken returns the token of the current login, without prompting
+func prefetchToken(deps *Dependencies) string {
+	if token := os.Getenv("KEYWAY_TOKEN"); token != "" {
+		return token
+	}
+	if deps.AuthStore == nil {
+		return ""
+	}
+	stored, err := deps.AuthStore.GetAuth()
+	if err != nil || stored == nil {
+		return ""
+	}
+	return stored.KeywayToken
+}
+
+// prefetchedContent returns the content of an environment prefetched recently
+// enough for keyway run, if any
+func prefetchedContent(repo, envName string, deps *Dependencies) (string, bool) {
+	if deps.Cache == nil {
+		return "", false
+	}
+	entry, ok := deps.Cache.Get(repo, envName)
+	if !ok {
+		return "", false
+	}
+	age := entry.Age(time.Now())
+	if age < 0 || age > prefetchMaxAge {
+		return "", false
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Using secrets prefetched %s ago (--no-cache to fetch them)", age.Round(time.Second))))
+	return entry.Content, true
+}
+
+// refreshPrefetched updates the cached environment after it changed, when
+// keyway prefetch cached it, so that the next keyway run is up to date
+// without waiting for the shell hook
+func refreshPrefetched(repo, envName string, resp *api.PullSecretsResponse, deps *Dependencies) {
+	if deps.Cache == nil {
+		return
+	}
+	if _, ok := deps.Cache.Get(repo, envName); !ok {
+		return
+	}
+	_ = deps.Cache.Put(cache.Entry{
+		Repo:        repo,
+		Environment: envName,
+		Content:     resp.Content,
+		Version:     resp.Version,
+		FetchedAt:   time.Now(),
+	})
+}
+
+// forgetPrefetched drops the cached environment once it changed
+func forgetPrefetched(repo, envName string, deps *Dependencies) {
+	if deps.Cache != nil {
+		_ = deps.Cache.Forget(repo, envName)
+	}
+}
+
+// runPrefetchHook prints the shell hook running keyway prefetch on cd
+func runPrefetchHook(cmd *cobra.Command, args []string) error {
+	shell := injector.DefaultShell()
+	if len(args) == 1 {
+		shell = args[0]
+	}
+	script, err := prefetchHook(shell)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(prefetchHookOutput, script)
+	return nil
+}
+
+// prefetchHook returns the code running keyway prefetch in the background
+// when the working directory of a shell changes
+func prefetchHook(shell string) (string, error) {
+	const prefetch = "(keyway prefetch --quiet >/dev/null 2>&1 &)"
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "bash":
+		return `__keyway_prefetch() {
+  if [ "$PWD" != "$__KEYWAY_PREFETCH_DIR" ]; then
+    __KEYWAY_PREFETCH_DIR="$PWD"
+    ` + prefetch + `
+  fi
+}
+case ";$PROMPT_COMMAND;" in
+  *";__keyway_prefetch;"*) ;;
+  *) PROMPT_COMMAND="__keyway_prefetch${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`, nil
+	case "zsh":
+		return `__keyway_prefetch() { ` + prefetch + `; }
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd __keyway_prefetch
+__keyway_prefetch
+`, nil
+	case "fish":
+		return `function __keyway_prefetch --on-variable PWD
+    keyway prefetch --quiet >/dev/null 2>&1 &
+    disown
+end
+__keyway_prefetch
+`, nil
+	default:
+		return "", fmt.Errorf("no prefetch hook for %s, use bash, zsh or fish", shell)
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/cache"
+)
+
+func TestRunPrefetchWithDeps_StoresEnvironment(t *testing.T) {
+	t.Setenv("KEYWAY_TOKEN", "")
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	deps.AuthStore = &MockAuthStore{StoredAuth: &StoredAuthInfo{KeywayToken: "tok"}}
+	cacheMock := &MockSecretCache{}
+	deps.Cache = cacheMock
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_123\n", Version: 7}
+
+	if err := runPrefetchWithDeps(PrefetchOptions{EnvName: "staging"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, ok := cacheMock.Entries["owner/repo/staging"]
+	if !ok {
+		t.Fatalf("expected the environment to be cached, got %v", cacheMock.Entries)
+	}
+	if entry.Content != "API_KEY=sk_123\n" || entry.Version != 7 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestRunPrefetchWithDeps_SkipsFreshEntries(t *testing.T) {
+	t.Setenv("KEYWAY_TOKEN", "")
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	deps.AuthStore = &MockAuthStore{StoredAuth: &StoredAuthInfo{KeywayToken: "tok"}}
+	fetchedAt := time.Now().Add(-10 * time.Second)
+	cacheMock := &MockSecretCache{Entries: map[string]cache.Entry{
+		"owner/repo/development": {Repo: "owner/repo", Environment: "development", Content: "OLD=1\n", FetchedAt: fetchedAt},
+	}}
+	deps.Cache = cacheMock
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "NEW=1\n"}
+
+	if err := runPrefetchWithDeps(PrefetchOptions{EnvName: "development"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cacheMock.Entries["owner/repo/development"].Content != "OLD=1\n" {
+		t.Error("expected a fresh entry not to be fetched again")
+	}
+}
+
+func TestRunPrefetchWithDeps_NeverPrompts(t *testing.T) {
+	t.Setenv("KEYWAY_TOKEN", "")
+	deps, gitMock, authMock, _, _, apiMock := NewTestDeps()
+	cacheMock := &MockSecretCache{}
+	deps.Cache = cacheMock
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=1\n"}
+
+	// Not logged in: nothing is fetched, not even through EnsureLogin
+	authMock.Error = errors.New("login required")
+	if err := runPrefetchWithDeps(PrefetchOptions{EnvName: "development", Quiet: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cacheMock.Entries) != 0 {
+		t.Errorf("expected nothing cached without a stored login, got %v", cacheMock.Entries)
+	}
+
+	// Not in a repository
+	deps.AuthStore = &MockAuthStore{StoredAuth: &StoredAuthInfo{KeywayToken: "tok"}}
+	gitMock.RepoError = errors.New("not a git repository")
+	if err := runPrefetchWithDeps(PrefetchOptions{EnvName: "development", Quiet: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cacheMock.Entries) != 0 {
+		t.Errorf("expected nothing cached outside a repository, got %v", cacheMock.Entries)
+	}
+}
+
+func TestRunRunWithDeps_UsesPrefetchedSecrets(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	deps.Cache = &MockSecretCache{Entries: map[string]cache.Entry{
+		"owner/repo/development": {Repo: "owner/repo", Environment: "development", Content: "API_KEY=cached\n", FetchedAt: time.Now().Add(-time.Minute)},
+	}}
+	apiMock.PullError = errors.New("network down")
+
+	err := runRunWithDeps(RunOptions{EnvName: "development", EnvFlagSet: true, Command: "npm"}, deps)
+	if err != nil {
+		t.Fatalf("expected the prefetched secrets to be used, got %v", err)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "cached" {
+		t.Errorf("expected the cached value, got %v", cmdRunner.LastSecrets)
+	}
+}
+
+func TestRunRunWithDeps_IgnoresStaleOrSkippedCache(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	cacheMock := &MockSecretCache{Entries: map[string]cache.Entry{
+		"owner/repo/development": {Repo: "owner/repo", Environment: "development", Content: "API_KEY=cached\n", FetchedAt: time.Now().Add(-time.Hour)},
+	}}
+	deps.Cache = cacheMock
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=fresh\n"}
+
+	if err := runRunWithDeps(RunOptions{EnvName: "development", EnvFlagSet: true, Command: "npm"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "fresh" {
+		t.Errorf("expected a stale entry to be ignored, got %v", cmdRunner.LastSecrets)
+	}
+
+	entry := cacheMock.Entries["owner/repo/development"]
+	entry.FetchedAt = time.Now()
+	cacheMock.Entries["owner/repo/development"] = entry
+	if err := runRunWithDeps(RunOptions{EnvName: "development", EnvFlagSet: true, Command: "npm", NoCache: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "fresh" {
+		t.Errorf("expected --no-cache to fetch the secrets, got %v", cmdRunner.LastSecrets)
+	}
+}
+
+func TestRunSetWithDeps_ForgetsPrefetchedEnvironment(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	cacheMock := &MockSecretCache{}
+	deps.Cache = cacheMock
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+
+	if err := runSetWithDeps(SetOptions{Key: "API_KEY", Value: "x", EnvName: "development", EnvFlagSet: true, Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cacheMock.Forgotten) != 1 || cacheMock.Forgotten[0] != "owner/repo/development" {
+		t.Errorf("expected the cached environment to be dropped, got %v", cacheMock.Forgotten)
+	}
+}
+
+func TestPrefetchHook(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"/bin/bash", "PROMPT_COMMAND="},
+		{"/usr/bin/zsh", "add-zsh-hook chpwd __keyway_prefetch"},
+		{"fish", "--on-variable PWD"},
+	}
+	for _, tt := range tests {
+		script, err := prefetchHook(tt.shell)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.shell, err)
+		}
+		if !strings.Contains(script, tt.want) || !strings.Contains(script, "keyway prefetch --quiet") {
+			t.Errorf("%s: unexpected hook:\n%s", tt.shell, script)
+		}
+	}
+	if _, err := prefetchHook("pwsh"); err == nil {
+		t.Error("expected an error for a shell without hook")
+	}
+}
+
+func TestRunRunWithDeps_ReloadRefreshesPrefetchedEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("reload signals are not supported on Windows")
+	}
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	cacheMock := &MockSecretCache{Entries: map[string]cache.Entry{
+		"owner/repo/staging":     {Repo: "owner/repo", Environment: "staging", Content: "API_KEY=old\n", FetchedAt: time.Now().Add(-time.Hour)},
+		"owner/repo/development": {Repo: "owner/repo", Environment: "development", Content: "API_KEY=dev\n", FetchedAt: time.Now().Add(-time.Hour)},
+	}}
+	deps.Cache = cacheMock
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "API_KEY=old\n"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=rotated\n", Version: 2}
+	apiMock.StreamEvents = []api.VaultEvent{{ID: "1", Type: "secrets.pushed", Environment: "staging"}}
+	cmdRunner.WaitForSignal = true
+
+	if err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", ReloadOnChange: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry := cacheMock.Entries["owner/repo/staging"]
+	if entry.Content != "API_KEY=rotated\n" || entry.Version != 2 || time.Since(entry.FetchedAt) > time.Minute {
+		t.Errorf("expected the cached environment to be refreshed, got %+v", entry)
+	}
+	if cacheMock.Entries["owner/repo/development"].Content != "API_KEY=dev\n" {
+		t.Error("expected other environments to be left alone")
+	}
+}
+
+func TestRealAPIFactory_DeltaPullAcrossInvocations(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		sinces = append(sinces, since)
+		data := map[string]interface{}{"content": "API_KEY=secret\n", "version": 3}
+		if since == "3" {
+			data = map[string]interface{}{"delta": true, "version": 3}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+	t.Setenv("KEYWAY_API_URL", server.URL)
+
+	// Each keyway pull or run creates its client afresh
+	for i := 0; i < 2; i++ {
+		client := (&realAPIFactory{}).NewClient("token")
+		resp, err := client.PullSecrets(context.Background(), "owner/repo", "production")
+		if err != nil {
+			t.Fatalf("pull %d: unexpected error: %v", i+1, err)
+		}
+		if resp.Content != "API_KEY=secret\n" {
+			t.Errorf("pull %d: unexpected content %q", i+1, resp.Content)
+		}
+	}
+	if strings.Join(sinces, ",") != ",3" {
+		t.Errorf("expected the second invocation to pull since version 3, got since parameters %v", sinces)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(secretCacheDir(), "pulls", "*.enc"))
+	if len(files) != 1 {
+		t.Fatalf("expected the base to be stored apart from prefetched entries, got %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); strings.Contains(string(data), "secret") {
+		t.Error("expected the base to be encrypted")
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProgressEvent is one line of the NDJSON stream written to stderr in --json mode,
+// so that GUI wrappers and CI plugins can render progress without scraping spinner text.
+//
+//	{"type":"progress","phase":"started","percent":0,"message":"Fetching secrets...","time":"..."}
+//	{"type":"progress","phase":"completed","percent":100,"message":"Fetching secrets...","time":"..."}
+//	{"type":"warning","message":"...","time":"..."}
+type ProgressEvent struct {
+	Type    string `json:"type"`            // progress, warning or error
+	Phase   string `json:"phase,omitempty"` // started, completed or failed, for progress events
+	Percent *int   `json:"percent,omitempty"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"` // set when a progress phase failed
+	Time    string `json:"time"`
+}
+
+// Progress event types and phases
+const (
+	ProgressTypeProgress = "progress"
+	ProgressTypeWarning  = "warning"
+	ProgressTypeError    = "error"
+
+	ProgressPhaseStarted   = "started"
+	ProgressPhaseCompleted = "completed"
+	ProgressPhaseFailed    = "failed"
+)
+
+var (
+	// progressOutput receives the NDJSON progress stream (replaced in tests)
+	progressOutput io.Writer = os.Stderr
+	progressMu     sync.Mutex
+)
+
+// emitProgress writes one progress event as a single JSON line
+func emitProgress(event ProgressEvent) {
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	_, _ = progressOutput.Write(append(data, '\n'))
+}
+
+// progressPercent returns a pointer for ProgressEvent.Percent
+func progressPercent(p int) *int {
+	return &p
+}
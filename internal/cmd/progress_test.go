@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// captureProgress redirects the NDJSON progress stream for the duration of a test
+func captureProgress(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	orig := progressOutput
+	progressOutput = &buf
+	t.Cleanup(func() { progressOutput = orig })
+	return &buf
+}
+
+func decodeProgress(t *testing.T, buf *bytes.Buffer) []ProgressEvent {
+	t.Helper()
+	var events []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e ProgressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestJSONUI_SpinEmitsProgress(t *testing.T) {
+	buf := captureProgress(t)
+	deps, _, _, _, _, _ := NewTestDeps()
+	ui := withJSONUI(deps).UI
+
+	if err := ui.Spin("Fetching secrets...", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := decodeProgress(t, buf)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %s", len(events), buf.String())
+	}
+	if events[0].Phase != ProgressPhaseStarted || *events[0].Percent != 0 || events[0].Message != "Fetching secrets..." {
+		t.Errorf("unexpected start event: %+v", events[0])
+	}
+	if events[1].Phase != ProgressPhaseCompleted || *events[1].Percent != 100 || events[1].Time == "" {
+		t.Errorf("unexpected completion event: %+v", events[1])
+	}
+}
+
+func TestJSONUI_SpinFailure(t *testing.T) {
+	buf := captureProgress(t)
+	deps, _, _, _, _, _ := NewTestDeps()
+	ui := withJSONUI(deps).UI
+
+	err := ui.Spin("Uploading secrets...", func() error { return errors.New("boom") })
+
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("expected fn error to be returned, got %v", err)
+	}
+	events := decodeProgress(t, buf)
+	if len(events) != 2 || events[1].Phase != ProgressPhaseFailed || events[1].Error != "boom" || events[1].Percent != nil {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestJSONUI_WarningsAndErrors(t *testing.T) {
+	buf := captureProgress(t)
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+	ui := withJSONUI(deps).UI
+
+	ui.Warn("careful")
+	ui.Error("failed")
+	ui.Step("Repository: owner/repo")
+
+	events := decodeProgress(t, buf)
+	if len(events) != 2 || events[0].Type != ProgressTypeWarning || events[1].Type != ProgressTypeError {
+		t.Errorf("unexpected events: %+v", events)
+	}
+	if len(uiMock.StepCalls) != 0 || len(uiMock.WarnCalls) != 0 {
+		t.Error("expected nothing to reach the terminal UI")
+	}
+}
+
+func TestRunDiffWithDeps_JSONReportsProgress(t *testing.T) {
+	buf := captureProgress(t)
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=a"}
+
+	err := runDiffWithDeps(DiffOptions{Env1: "staging", Env2: "production", JSONOutput: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.IntroCalls) != 0 || len(uiMock.StepCalls) != 0 {
+		t.Error("expected no decorative output in --json mode")
+	}
+	if events := decodeProgress(t, buf); len(events) == 0 {
+		t.Error("expected progress events")
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// loadProject reads .keyway.json from the current directory.
+// A missing or unreadable file yields an empty project, only invalid JSON is an error.
+func loadProject(deps *Dependencies) (*config.Project, error) {
+	data, err := deps.FS.ReadFile(config.ProjectFile)
+	if err != nil {
+		return &config.Project{}, nil
+	}
+	return config.ParseProject(data)
+}
+
+// projectDefaultEnv returns the environment .keyway.json sets for .env on the
+// current branch, or an empty string
+func projectDefaultEnv(deps *Dependencies) string {
+	project, err := loadProject(deps)
+	if err != nil || project.DefaultEnv == nil {
+		return ""
+	}
+	return project.DefaultEnvFor(deps.Git.CurrentBranch(), deps.Git.DefaultBranch(), config.IsCI())
+}
+
+// namesEnv returns true if the name of an env file says its environment,
+// e.g. .env.staging
+func namesEnv(file string) bool {
+	return strings.HasPrefix(filepath.Base(file), ".env.")
+}
+
+// deriveEnvFromFile returns the environment of an env file: the one its name
+// says, else the one .keyway.json sets for the current branch, else development
+func deriveEnvFromFile(file string, deps *Dependencies) string {
+	if !namesEnv(file) {
+		if name := projectDefaultEnv(deps); name != "" {
+			return name
+		}
+	}
+	return deps.Env.DeriveEnvFromFile(file)
+}
+
+// discoverEnvFiles finds the env files of the current directory, with the
+// environment of each as deriveEnvFromFile tells it
+func discoverEnvFiles(deps *Dependencies) []EnvCandidate {
+	candidates := deps.Env.Discover()
+	defaultEnv := projectDefaultEnv(deps)
+	if defaultEnv == "" {
+		return candidates
+	}
+	for i, c := range candidates {
+		if !namesEnv(c.File) {
+			candidates[i].Env = defaultEnv
+		}
+	}
+	return candidates
+}
+
+// stripExcluded drops the keys excluded in .keyway.json from secrets, and
+// returns them sorted
+func stripExcluded(secrets map[string]string, deps *Dependencies) (map[string]string, []string, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(project.Exclude) == 0 {
+		return secrets, nil, nil
+	}
+	kept := make(map[string]string, len(secrets))
+	var excluded []string
+	for k, v := range secrets {
+		if project.IsExcluded(k) {
+			excluded = append(excluded, k)
+			continue
+		}
+		kept[k] = v
+	}
+	sort.Strings(excluded)
+	return kept, excluded, nil
+}
+
+// loadValueEqual builds the value comparison declared under "comparators" in .keyway.json.
+// It returns nil, meaning byte for byte comparison, when none are declared.
+func loadValueEqual(deps *Dependencies) (env.ValueEqual, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+	if len(project.Comparators) == 0 {
+		return nil, nil
+	}
+	byPattern := make(map[string]env.Comparator, len(project.Comparators))
+	for pattern, name := range project.Comparators {
+		comparator, ok := env.LookupComparator(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: unknown comparator %q for %s (use one of: %s)",
+				config.ProjectFile, name, pattern, strings.Join(env.ComparatorNames(), ", "))
+		}
+		byPattern[pattern] = comparator
+	}
+	return env.CompareByPattern(byPattern), nil
+}
+
+// applyDerived recomputes derived keys declared in .keyway.json.
+// It returns secrets unchanged when there is no project config, and warns
+// about derived keys that cannot be computed from the available secrets.
+func applyDerived(secrets map[string]string, deps *Dependencies) (map[string]string, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+	if len(project.Derived) == 0 {
+		return secrets, nil
+	}
+
+	derived, unresolved := env.Derive(secrets, project.Derived)
+	for _, key := range unresolved {
+		if missing := missingReferences(project.Derived[key], derived); len(missing) > 0 {
+			deps.UI.Warn(fmt.Sprintf("Cannot derive %s: missing %s", key, strings.Join(missing, ", ")))
+		} else {
+			deps.UI.Warn(fmt.Sprintf("Cannot derive %s: circular reference", key))
+		}
+	}
+	return derived, nil
+}
+
+// applyExpand resolves ${VAR} references in values when expand is set (--expand)
+// or .keyway.json enables it, and warns about the keys it cannot resolve.
+// References not to a key are resolved from the environment of keyway.
+func applyExpand(secrets map[string]string, expand bool, deps *Dependencies) (map[string]string, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+	if !expand && !project.Expand {
+		return secrets, nil
+	}
+
+	expanded, unresolved := env.Expand(secrets, os.LookupEnv)
+	failed := make(map[string]bool, len(unresolved))
+	for _, key := range unresolved {
+		failed[key] = true
+	}
+	for _, key := range unresolved {
+		if missing := env.MissingReferences(secrets[key], secrets, os.LookupEnv); len(missing) > 0 {
+			deps.UI.Warn(fmt.Sprintf("Cannot expand %s: %s not set", key, strings.Join(missing, ", ")))
+			continue
+		}
+		var blocking []string
+		for _, ref := range env.References(secrets[key]) {
+			if failed[ref] {
+				blocking = append(blocking, ref)
+			}
+		}
+		deps.UI.Warn(fmt.Sprintf("Cannot expand %s: references %s, which cannot be expanded", key, strings.Join(blocking, ", ")))
+	}
+	return expanded, nil
+}
+
+// applyRemap renames keys with the remap of .keyway.json called name.
+// It returns secrets unchanged when name is empty.
+func applyRemap(secrets map[string]string, name string, deps *Dependencies) (map[string]string, error) {
+	if name == "" {
+		return secrets, nil
+	}
+	rules, err := remapRules(name, deps)
+	if err != nil {
+		return nil, err
+	}
+	remapped, err := env.Remap(secrets, rules)
+	if err != nil {
+		return nil, fmt.Errorf("remap %s: %w", name, err)
+	}
+	return remapped, nil
+}
+
+// remapRules returns the rules of the remap of .keyway.json called name
+func remapRules(name string, deps *Dependencies) (map[string]string, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+	rules, ok := project.Remaps[name]
+	if !ok {
+		if len(project.Remaps) == 0 {
+			return nil, fmt.Errorf("unknown remap %q: no remaps in %s", name, config.ProjectFile)
+		}
+		return nil, fmt.Errorf("unknown remap %q (use one of: %s)", name, strings.Join(sortedKeys(project.Remaps), ", "))
+	}
+	return rules, nil
+}
+
+// remapPulled renames pulled keys with the remap of .keyway.json called name,
+// and returns the name of each key
+func remapPulled(secrets map[string]string, name string, deps *Dependencies) (map[string]string, map[string]string, error) {
+	rules, err := remapRules(name, deps)
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := env.RemapNames(sortedKeys(secrets), rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("remap %s: %w", name, err)
+	}
+	remapped := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		remapped[names[k]] = v
+	}
+	return remapped, names, nil
+}
+
+// unremapLocal gives back their vault names to the keys of a file pulled with
+// the remap of .keyway.json called name. It returns secrets unchanged when
+// name is empty.
+func unremapLocal(secrets map[string]string, name string, deps *Dependencies) (map[string]string, error) {
+	if name == "" {
+		return secrets, nil
+	}
+	rules, err := remapRules(name, deps)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := env.Unremap(secrets, rules)
+	if err != nil {
+		return nil, fmt.Errorf("remap %s: %w", name, err)
+	}
+	return restored, nil
+}
+
+// missingReferences lists the references of a template that have no value
+func missingReferences(template string, secrets map[string]string) []string {
+	var missing []string
+	for _, ref := range env.References(template) {
+		if _, ok := secrets[ref]; !ok {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// changedValues returns the entries of after that are new or differ from before
+func changedValues(before, after map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for k, v := range after {
+		if old, ok := before[k]; !ok || old != v {
+			changed[k] = v
+		}
+	}
+	return changed
+}
+
+// confirmationNeeded returns true if writing count changed keys to envName
+// must be confirmed. --yes skips the confirmation unless a "confirm" rule of
+// .keyway.json always asks in an interactive terminal, and a rule may skip it
+// for small changes.
+func confirmationNeeded(envName string, count int, yes bool, deps *Dependencies) bool {
+	rule := config.ConfirmRule{}
+	if project, err := loadProject(deps); err == nil {
+		rule = project.ConfirmRule(envName)
+	}
+	switch {
+	case rule.Always && deps.UI.IsInteractive():
+		return true
+	case yes:
+		return false
+	case rule.SkipUpTo > 0 && count <= rule.SkipUpTo:
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d change(s) to %s, no confirmation needed (see %s)", count, envName, config.ProjectFile)))
+		return false
+	default:
+		return true
+	}
+}
@@ -1,58 +1,150 @@
 package cmd
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/keywaysh/cli/internal/analytics"
 	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
 	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var pullCmd = &cobra.Command{
 	Use:   "pull",
 	Short: "Download secrets from the vault to an env file",
-	Long:  `Download secrets from the Keyway vault and save them to a local .env file.`,
-	RunE:  runPull,
+	Long: `Download secrets from the Keyway vault and save them to a local .env file.
+
+With --config-only (or --public-only), only keys declared as config in
+.keyway.json are written, so the file holds no secrets and can be committed.
+Config values that look like secrets (tokens, private keys...) are left out:
+
+  keyway pull -e production --public-only -f config/public.env
+
+The file may contain {env}, and .keyway.json can set a file per environment
+under "outputs", used when -f is not given:
+
+  keyway pull -e staging -f .env.{env}     # Writes .env.staging
+
+With --expand, ${VAR} references in values are resolved, e.g.
+DATABASE_URL=postgres://${DB_USER}:${DB_PASS}@db/app is written with the user
+and password filled in. The vault keeps the references: pushing the file back
+does not replace them with the values they resolved to.
+
+With --remap, keys are written under the names a remap of .keyway.json gives
+them, for services with their own naming conventions. The vault keeps a single
+copy of each key: push the file back with the same --remap.
+
+  keyway pull -e staging -f services/worker/.env --remap worker
+
+With --revision, the environment is pulled as it was at a version listed by
+keyway history (v42 or version:42), a date (YYYY-MM-DD) or an RFC 3339
+timestamp, so that a deployment can pin the exact secrets it was tested with:
+
+  keyway pull -e production --revision v42 -f .env.production
+
+An existing file is updated in place: its comments, blank lines, key order and
+local-only keys are kept, and new keys are inserted next to the keys they
+follow in the vault. --force replaces the file with the vault's content.
+
+Files are replaced in one step, never left half-written. A pull interrupted
+while writing is resumed by the next keyway pull in the repository.`,
+	RunE: runPull,
 }
 
 func init() {
 	pullCmd.Flags().StringP("env", "e", "development", "Environment name")
-	pullCmd.Flags().StringP("file", "f", ".env", "Env file to write to")
+	pullCmd.Flags().StringP("file", "f", ".env", "Env file to write to ({env} is replaced by the environment name)")
 	pullCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
 	pullCmd.Flags().Bool("force", false, "Replace entire file instead of merging")
+	pullCmd.Flags().Bool("config-only", false, "Only write config keys declared in .keyway.json (safe to commit)")
+	pullCmd.Flags().Bool("public-only", false, "Same as --config-only")
+	pullCmd.Flags().Bool("select", false, "Choose which keys to pull")
+	pullCmd.Flags().Bool("expand", false, "Resolve ${VAR} references in values")
+	pullCmd.Flags().String("remap", "", "Rename keys with a remap declared in .keyway.json")
+	pullCmd.Flags().String("revision", "", "Pull a historical snapshot (v42, version:42, YYYY-MM-DD or RFC 3339)")
 }
 
 // PullOptions contains the parsed flags for the pull command
 type PullOptions struct {
-	EnvName    string
-	File       string
-	Yes        bool
-	Force      bool
-	EnvFlagSet bool
+	EnvName     string
+	File        string
+	Yes         bool
+	Force       bool
+	EnvFlagSet  bool
+	FileFlagSet bool
+	ConfigOnly  bool
+	Select      bool
+	Expand      bool
+	Remap       string
+	Revision    string
+	JSONOutput  bool
+}
+
+// PullResult is the output of pull --json. Like PushPlan, it lists keys,
+// never secret values.
+type PullResult struct {
+	Repository  string   `json:"repository"`
+	Environment string   `json:"environment"`
+	Revision    string   `json:"revision,omitempty"` // version pulled with --revision
+	File        string   `json:"file"`
+	Added       []string `json:"added"`   // keys written that were not in the file
+	Changed     []string `json:"changed"` // keys whose local value was replaced
+	Kept        []string `json:"kept"`    // local-only keys left in the file
+	Removed     []string `json:"removed"` // local-only keys dropped (with --force)
+	Variables   int      `json:"variables"`
 }
 
 // runPull is the entry point for the pull command (uses default dependencies)
 func runPull(cmd *cobra.Command, args []string) error {
 	opts := PullOptions{
-		EnvFlagSet: cmd.Flags().Changed("env"),
+		EnvFlagSet:  cmd.Flags().Changed("env"),
+		FileFlagSet: cmd.Flags().Changed("file"),
 	}
 	opts.EnvName, _ = cmd.Flags().GetString("env")
 	opts.File, _ = cmd.Flags().GetString("file")
 	opts.Yes, _ = cmd.Flags().GetBool("yes")
 	opts.Force, _ = cmd.Flags().GetBool("force")
+	opts.ConfigOnly, _ = cmd.Flags().GetBool("config-only")
+	if publicOnly, _ := cmd.Flags().GetBool("public-only"); publicOnly {
+		opts.ConfigOnly = true
+	}
+	opts.Select, _ = cmd.Flags().GetBool("select")
+	opts.Expand, _ = cmd.Flags().GetBool("expand")
+	opts.Remap, _ = cmd.Flags().GetString("remap")
+	opts.Revision, _ = cmd.Flags().GetString("revision")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
 
 	return runPullWithDeps(opts, defaultDeps)
 }
 
 // runPullWithDeps is the testable version of runPull
 func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
-	deps.UI.Intro("pull")
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return pull(s, opts)
+	}, withIntro("pull"), withRepo)
+}
+
+// pull writes the secrets of the environment to the env file
+func pull(s *Session, opts PullOptions) error {
+	deps := s.Deps
+	hostLabel, err := checkHostEnvironment(opts.EnvName, opts.EnvFlagSet, deps)
+	if err != nil {
+		return err
+	}
 
-	// Check gitignore
-	if !deps.Git.CheckEnvGitignore() {
+	// Check gitignore (config-only files are meant to be committed)
+	if !opts.ConfigOnly && !deps.Git.CheckEnvGitignore() {
 		deps.UI.Warn(".env files are not in .gitignore - secrets may be committed")
 		if deps.UI.IsInteractive() {
 			add, _ := deps.UI.Confirm("Add .env* to .gitignore?", true)
@@ -64,28 +156,29 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 		}
 	}
 
-	repo, err := deps.Git.DetectRepo()
-	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
-		return err
+	repo := s.Repo
+	opts = resumeInterruptedPull(repo, opts, deps)
+
+	var rev api.Revision
+	if opts.Revision != "" {
+		parsed, err := api.ParseRevision(opts.Revision)
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		rev = parsed
 	}
-	deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(repo)))
 
-	token, err := deps.Auth.EnsureLogin()
-	if err != nil {
-		deps.UI.Error(err.Error())
+	if err := s.Login(); err != nil {
 		return err
 	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
-
 	envName := opts.EnvName
 
 	// Prompt for environment if not specified
 	if !opts.EnvFlagSet && deps.UI.IsInteractive() {
 		// Fetch available environments
-		vaultEnvs, err := client.GetVaultEnvironments(ctx, repo)
+		vaultEnvs, err := s.Client.GetVaultEnvironments(s.Ctx, repo)
 		if err != nil || len(vaultEnvs) == 0 {
 			vaultEnvs = []string{"development", "staging", "production"}
 		}
@@ -113,39 +206,51 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 
 	deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(envName)))
 
+	file, err := pullTargetFile(opts, envName, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if file != opts.File {
+		deps.UI.Step(fmt.Sprintf("File: %s", deps.UI.File(file)))
+	}
+
+	if hostLabel != "" {
+		recordHostInvocation(s.Ctx, s.Client, repo, envName, hostLabel, "pull", "", deps)
+	}
+
 	// Track pull event
 	analytics.Track(analytics.EventPull, map[string]interface{}{
 		"repoFullName": repo,
 		"environment":  envName,
 	})
 
+	// With --revision, the snapshot instead of the current secrets
+	fetch := func() (*api.PullSecretsResponse, error) {
+		if !rev.IsZero() {
+			return s.Client.PullSecretsAt(s.Ctx, repo, envName, rev)
+		}
+		return s.Client.PullSecrets(s.Ctx, repo, envName)
+	}
 	var vaultContent string
-	err = deps.UI.Spin("Downloading secrets...", func() error {
-		resp, err := client.PullSecrets(ctx, repo, envName)
+	var pulledVersion int
+	err = s.Spin("Downloading secrets...", func() error {
+		resp, err := fetch()
 		if err != nil {
 			return err
 		}
-		vaultContent = resp.Content
+		vaultContent, pulledVersion = resp.Content, resp.Version
 		return nil
 	})
 
 	if err != nil {
-		// Handle auth errors (expired token)
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
-			}
-			// Retry with new token
-			client = deps.APIFactory.NewClient(newToken)
-			err = deps.UI.Spin("Downloading secrets...", func() error {
-				resp, pullErr := client.PullSecrets(ctx, repo, envName)
-				if pullErr != nil {
-					return pullErr
-				}
-				vaultContent = resp.Content
-				return nil
-			})
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 && !rev.IsZero() {
+			deps.UI.Error(fmt.Sprintf("No snapshot of %s found at %s", envName, rev.String()))
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("List the versions with: keyway history -e %s", envName)))
+			return err
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 403 && apiErr.UpgradeURL == "" && rev.IsZero() {
+			return pullFromTemplate(repo, file, deps)
 		}
 		if err != nil {
 			analytics.Track(analytics.EventError, map[string]interface{}{
@@ -155,15 +260,26 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 			if apiErr, ok := err.(*api.APIError); ok {
 				deps.UI.Error(apiErr.Error())
 				if apiErr.UpgradeURL != "" {
-					deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(apiErr.UpgradeURL)))
+					deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
 				}
 			} else {
 				deps.UI.Error(err.Error())
 			}
+			explainMovedVault(s.Ctx, s.Client, repo, err, deps)
 			return err
 		}
 	}
 
+	// A date resolves to a version, show it so that it can be pinned
+	var revision string
+	if !rev.IsZero() {
+		revision = rev.String()
+		if pulledVersion > 0 {
+			revision = api.Revision{Version: pulledVersion}.String()
+		}
+		deps.UI.Step(fmt.Sprintf("Revision: %s", deps.UI.Value(revision)))
+	}
+
 	// Tip about keyway run (Zero-Trust)
 	if deps.UI.IsInteractive() {
 		deps.UI.Message("")
@@ -172,21 +288,139 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 		deps.UI.Message("")
 	}
 
+	vaultContent = env.NormalizeVault(vaultContent)
 	vaultSecrets := env.Parse(vaultContent)
-	envFilePath := filepath.Join(".", opts.File)
+
+	// Resolve ${VAR} references with --expand, then recompute derived keys
+	// declared in .keyway.json
+	derivedSecrets, err := applyExpand(vaultSecrets, opts.Expand, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	derivedSecrets, err = applyDerived(derivedSecrets, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if updates := changedValues(vaultSecrets, derivedSecrets); len(updates) > 0 {
+		vaultContent = env.Apply(vaultContent, updates)
+		vaultSecrets = derivedSecrets
+	}
+
+	// Keys excluded in .keyway.json are never written, e.g. pushed before
+	// they were excluded
+	vaultSecrets, excluded, err := stripExcluded(vaultSecrets, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if len(excluded) > 0 {
+		vaultContent = env.Remove(vaultContent, excluded)
+		deps.UI.Step(fmt.Sprintf("Excluded by %s: %s", config.ProjectFile, strings.Join(excluded, ", ")))
+	}
+
+	// Keep only the keys the user picked, the others stay as they are locally
+	if opts.Select {
+		picked, err := pickKeys("Keys to pull:", sortedKeys(vaultSecrets), deps)
+		if err != nil {
+			return err
+		}
+		pickedSecrets := make(map[string]string, len(picked))
+		for _, k := range picked {
+			pickedSecrets[k] = vaultSecrets[k]
+		}
+		vaultSecrets = pickedSecrets
+		vaultContent = env.Apply("", pickedSecrets)
+	}
+
+	// Keep only config keys so that no secret ends up in a committed file
+	if opts.ConfigOnly {
+		project, _ := loadProject(deps)
+		if len(project.Config) == 0 {
+			deps.UI.Error("No config keys declared in .keyway.json")
+			deps.UI.Message(deps.UI.Dim(`Add e.g. "config": ["NODE_ENV", "NEXT_PUBLIC_*"]`))
+			return fmt.Errorf("no config keys declared")
+		}
+		configSecrets := make(map[string]string)
+		var leaked []string
+		for k, v := range vaultSecrets {
+			if !project.IsConfigKey(k) {
+				continue
+			}
+			// A config pattern can catch a secret, e.g. NEXT_PUBLIC_* and a token
+			if kind := secretKind(k, v); kind != "" {
+				leaked = append(leaked, fmt.Sprintf("%s (%s)", k, kind))
+				continue
+			}
+			configSecrets[k] = v
+		}
+		deps.UI.Step(fmt.Sprintf("Config keys: %s (%d secrets skipped)", deps.UI.Value(len(configSecrets)), len(vaultSecrets)-len(configSecrets)))
+		if len(leaked) > 0 {
+			sort.Strings(leaked)
+			deps.UI.Warn(fmt.Sprintf("Left out config keys whose value looks like a secret: %s", strings.Join(leaked, ", ")))
+			deps.UI.Message(deps.UI.Dim("Remove them from \"config\" in .keyway.json, or store a non-secret value"))
+		}
+		vaultSecrets = configSecrets
+		vaultContent = env.Apply("", configSecrets)
+	}
+
+	// Journal the writes until they all land, so that a pull killed halfway
+	// is resumed by the next one
+	envFilePath := filepath.Clean(file)
+	if err := beginPullWrites(repo, envName, envFilePath, opts, deps); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Could not journal the pull: %s", err.Error()))
+	}
+	defer endPullWrites(envFilePath, deps)
+
+	if !opts.ConfigOnly {
+		paths, err := writePulledFiles(vaultSecrets, deps)
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		// The env file points at the written files instead of holding them
+		if len(paths) > 0 {
+			vaultContent = env.Apply(vaultContent, paths)
+			for k, path := range paths {
+				vaultSecrets[k] = path
+			}
+		}
+	}
+
+	// Write keys under the names of the remap, once everything keyed by vault
+	// names is done
+	if opts.Remap != "" {
+		remapped, names, err := remapPulled(vaultSecrets, opts.Remap, deps)
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		vaultSecrets = remapped
+		vaultContent = env.Rename(vaultContent, names)
+		deps.UI.Step(fmt.Sprintf("Remap: %s", deps.UI.Value(opts.Remap)))
+	}
 
 	// Read existing local file if it exists
 	var localSecrets map[string]string
+	var localContent string
 	localExists := false
 	if data, err := deps.FS.ReadFile(envFilePath); err == nil {
 		localExists = true
-		localSecrets = env.Parse(string(data))
+		localContent = string(data)
+		localSecrets = env.Parse(localContent)
 	} else {
 		localSecrets = make(map[string]string)
 	}
 
-	// Calculate diff
-	diff := env.CalculatePullDiff(localSecrets, vaultSecrets)
+	// Calculate diff, values that the comparators declared in .keyway.json
+	// consider equivalent are not changes
+	equal, err := loadValueEqual(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	diff := env.CalculatePullDiffWith(localSecrets, vaultSecrets, equal)
 
 	// Show diff if there are changes and file exists
 	if localExists && diff.HasChanges() {
@@ -225,9 +459,9 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 		if !opts.Yes && deps.UI.IsInteractive() {
 			var promptMsg string
 			if opts.Force {
-				promptMsg = fmt.Sprintf("Replace %s with secrets from vault?", opts.File)
+				promptMsg = fmt.Sprintf("Replace %s with secrets from vault?", file)
 			} else {
-				promptMsg = fmt.Sprintf("Merge secrets from vault into %s?", opts.File)
+				promptMsg = fmt.Sprintf("Merge secrets from vault into %s?", file)
 			}
 			confirm, _ := deps.UI.Confirm(promptMsg, true)
 			if !confirm {
@@ -235,7 +469,7 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 				return nil
 			}
 		} else if !opts.Yes {
-			return fmt.Errorf("file %s exists - use --yes to confirm", opts.File)
+			return fmt.Errorf("file %s exists - use --yes to confirm", file)
 		}
 	}
 
@@ -245,18 +479,52 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 		// Replace mode: use vault content as-is
 		finalContent = vaultContent
 	} else {
-		// Merge mode: start with vault secrets, add local-only secrets
-		finalContent = env.Merge(vaultContent, localSecrets, vaultSecrets)
+		// Merge mode: update the local file in place, keeping its comments,
+		// order and local-only secrets
+		updates := make(map[string]string, len(diff.Added)+len(diff.Changed))
+		for _, key := range append(append([]string{}, diff.Added...), diff.Changed...) {
+			updates[key] = vaultSecrets[key]
+		}
+		finalContent = env.Merge(localContent, updates, env.Keys(vaultContent))
 	}
 
-	// Write file with restricted permissions
-	if err := deps.FS.WriteFile(envFilePath, []byte(finalContent), 0600); err != nil {
+	// Write file with restricted permissions, unless it only holds config
+	perm := uint32(0600)
+	if opts.ConfigOnly {
+		perm = 0644
+	}
+	if dir := filepath.Dir(envFilePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to create %s: %s", dir, err.Error()))
+			return err
+		}
+	}
+	if err := deps.FS.WriteFile(envFilePath, []byte(finalContent), perm); err != nil {
 		deps.UI.Error(fmt.Sprintf("Failed to write file: %s", err.Error()))
 		return err
 	}
 
 	lines := env.CountLines(finalContent)
-	deps.UI.Success(fmt.Sprintf("Secrets downloaded to %s", deps.UI.File(opts.File)))
+	if opts.JSONOutput {
+		result := PullResult{
+			Repository:  repo,
+			Environment: envName,
+			Revision:    revision,
+			File:        file,
+			Added:       nonNil(diff.Added),
+			Changed:     nonNil(diff.Changed),
+			Kept:        []string{},
+			Removed:     []string{},
+			Variables:   lines,
+		}
+		if opts.Force {
+			result.Removed = nonNil(diff.LocalOnly)
+		} else {
+			result.Kept = nonNil(diff.LocalOnly)
+		}
+		return ui.PrintJSON(result)
+	}
+	deps.UI.Success(fmt.Sprintf("Secrets downloaded to %s", deps.UI.File(file)))
 	deps.UI.Message(fmt.Sprintf("Variables: %s", deps.UI.Value(lines)))
 
 	if !opts.Force && len(diff.LocalOnly) > 0 {
@@ -267,3 +535,165 @@ func runPullWithDeps(opts PullOptions, deps *Dependencies) error {
 
 	return nil
 }
+
+// pullTargetFile returns the file to write an environment to: the -f flag with
+// {env} expanded, else the environment's entry under outputs in .keyway.json,
+// else the default file
+func pullTargetFile(opts PullOptions, envName string, deps *Dependencies) (string, error) {
+	if opts.FileFlagSet {
+		return config.ExpandFileTemplate(opts.File, envName), nil
+	}
+	project, err := loadProject(deps)
+	if err != nil {
+		return "", err
+	}
+	if file := project.OutputFile(envName); file != "" {
+		return file, nil
+	}
+	return config.ExpandFileTemplate(opts.File, envName), nil
+}
+
+// pendingPull is a pull whose writes started but did not all land, e.g. because
+// it was killed between the files it writes and the env file pointing at them
+type pendingPull struct {
+	Repo        string    `json:"repo"`
+	Environment string    `json:"environment"`
+	Force       bool      `json:"force,omitempty"`
+	ConfigOnly  bool      `json:"configOnly,omitempty"`
+	Remap       string    `json:"remap,omitempty"`
+	Revision    string    `json:"revision,omitempty"`
+	Writes      []string  `json:"writes"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// pendingPullsPath returns the journal of the pulls being written, by env file
+func pendingPullsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "pending-pulls.json")
+}
+
+// loadPendingPulls returns the journaled pulls, by absolute env file path
+func loadPendingPulls(deps *Dependencies) map[string]pendingPull {
+	pending := make(map[string]pendingPull)
+	path := pendingPullsPath()
+	if path == "" {
+		return pending
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &pending)
+	}
+	return pending
+}
+
+// writePendingPulls writes the journal, readable by the user only
+func writePendingPulls(pending map[string]pendingPull, deps *Dependencies) error {
+	path := pendingPullsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
+
+// beginPullWrites journals a pull about to write the env file and the files
+// declared in .keyway.json. It holds no secret, only what is needed to pull again
+func beginPullWrites(repo, envName, envFile string, opts PullOptions, deps *Dependencies) error {
+	abs, err := filepath.Abs(envFile)
+	if err != nil {
+		return err
+	}
+	writes := []string{abs}
+	if project, err := loadProject(deps); err == nil {
+		for _, path := range project.Files {
+			if p, err := filepath.Abs(path); err == nil {
+				writes = append(writes, p)
+			}
+		}
+	}
+	pending := loadPendingPulls(deps)
+	pending[abs] = pendingPull{
+		Repo:        repo,
+		Environment: envName,
+		Force:       opts.Force,
+		ConfigOnly:  opts.ConfigOnly,
+		Remap:       opts.Remap,
+		Revision:    opts.Revision,
+		Writes:      writes,
+		StartedAt:   time.Now().UTC(),
+	}
+	return writePendingPulls(pending, deps)
+}
+
+// endPullWrites drops the journal entry of a pull once it returned, whatever
+// the outcome: only a pull that never got to return is resumed
+func endPullWrites(envFile string, deps *Dependencies) {
+	abs, err := filepath.Abs(envFile)
+	if err != nil {
+		return
+	}
+	pending := loadPendingPulls(deps)
+	delete(pending, abs)
+	_ = writePendingPulls(pending, deps)
+}
+
+// resumeInterruptedPull looks for a pull of the repository that was interrupted
+// while writing. It removes the temp files it left and, unless the flags ask for
+// another environment or file, pulls the same environment into the same file
+func resumeInterruptedPull(repo string, opts PullOptions, deps *Dependencies) PullOptions {
+	var file string
+	var interrupted pendingPull
+	for path, p := range loadPendingPulls(deps) {
+		if p.Repo == repo && (file == "" || p.StartedAt.After(interrupted.StartedAt)) {
+			file, interrupted = path, p
+		}
+	}
+	if file == "" {
+		return opts
+	}
+	for _, path := range interrupted.Writes {
+		removeWriteLeftovers(path)
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	sameEnv := !opts.EnvFlagSet || opts.EnvName == interrupted.Environment
+	sameFile := !opts.FileFlagSet || filepath.Clean(opts.File) == file
+	if !sameEnv || !sameFile {
+		deps.UI.Warn(fmt.Sprintf("The pull of %s into %s on %s was interrupted", interrupted.Environment, file, interrupted.StartedAt.Local().Format("Jan 2 15:04")))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Finish it with: keyway pull -e %s -f %s", interrupted.Environment, file)))
+		return opts
+	}
+
+	deps.UI.Warn(fmt.Sprintf("The pull of %s into %s was interrupted, resuming it", interrupted.Environment, file))
+	opts.EnvName, opts.EnvFlagSet = interrupted.Environment, true
+	opts.File, opts.FileFlagSet = file, true
+	opts.Force = opts.Force || interrupted.Force
+	opts.ConfigOnly = opts.ConfigOnly || interrupted.ConfigOnly
+	if opts.Remap == "" {
+		opts.Remap = interrupted.Remap
+	}
+	if opts.Revision == "" {
+		opts.Revision = interrupted.Revision
+	}
+	return opts
+}
+
+// removeWriteLeftovers removes the temp files a killed write left next to path
+func removeWriteLeftovers(path string) {
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".keyway-"+filepath.Base(path)+"-*"))
+	for _, leftover := range leftovers {
+		_ = os.Remove(leftover)
+	}
+}
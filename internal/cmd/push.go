@@ -1,22 +1,47 @@
 package cmd
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/keywaysh/cli/internal/analytics"
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/config"
 	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var pushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Upload secrets from an env file to the vault",
-	Long:  `Upload secrets from a local .env file to the Keyway vault.`,
-	RunE:  runPush,
+	Long: `Upload secrets from a local .env file to the Keyway vault.
+
+Use --dry-run to preview changes without pushing. Combined with --json, the
+preview is printed as a JSON document so CI can gate on it:
+
+  keyway push -e production --dry-run --json > diff.json
+
+Without --dry-run, --json prints the same document once the push is applied,
+with the stats reported by the server.
+
+A file pulled with --remap is pushed back with the same --remap: its keys get
+their vault names again, so that the vault keeps a single copy of each key.
+
+  keyway push -e staging -f services/worker/.env --remap worker
+
+Once the push is applied, the health checks declared under "healthChecks" in
+.keyway.json for the environment are run: a URL that must answer 2xx, or a
+command that must exit with 0. When one fails, keyway offers to restore the
+environment as it was before the push, or restores it with
+--rollback-on-failure.`,
+	RunE: runPush,
 }
 
 func init() {
@@ -24,15 +49,137 @@ func init() {
 	pushCmd.Flags().StringP("file", "f", "", "Env file to push")
 	pushCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
 	pushCmd.Flags().Bool("prune", false, "Remove secrets from vault that are not in local file")
+	pushCmd.Flags().Bool("dry-run", false, "Show what would be pushed without pushing")
+	pushCmd.Flags().Bool("allow-placeholders", false, "Push files that look like templates (.env.example, your-api-key...) without asking")
+	pushCmd.Flags().Bool("select", false, "Choose which changed keys to push")
+	pushCmd.Flags().Bool("no-anomaly-check", false, "Push values whose length or randomness changed drastically without asking")
+	pushCmd.Flags().Bool("trust-validators", false, "Run the executable validators of .keyway.json without asking to trust them")
+	pushCmd.Flags().Bool("strict", false, "Fail on lines the parser would skip or misread (missing =, unbalanced quotes, duplicate or non-ASCII keys)")
+	pushCmd.Flags().String("remap", "", "Give back their vault names to keys renamed by a remap declared in .keyway.json")
+	pushCmd.Flags().Bool("no-health-check", false, "Skip the health checks of .keyway.json after the push")
+	pushCmd.Flags().Bool("rollback-on-failure", false, "Restore the environment without asking when a health check fails after the push")
+	pushCmd.Flags().String("idempotency-key", "", "Apply this push at most once, even if sent again (default: $KEYWAY_IDEMPOTENCY_KEY or a new key)")
 }
 
 // PushOptions contains the parsed flags for the push command
 type PushOptions struct {
-	EnvName    string
-	File       string
-	Yes        bool
-	Prune      bool
-	EnvFlagSet bool
+	EnvName           string
+	File              string
+	Yes               bool
+	Prune             bool
+	EnvFlagSet        bool
+	DryRun            bool
+	JSONOutput        bool
+	AllowPlaceholders bool
+	NoAnomalyCheck    bool
+	Select            bool
+	IdempotencyKey    string
+	TrustValidators   bool
+	Strict            bool
+	Remap             string
+	NoHealthCheck     bool
+	RollbackOnFailure bool
+}
+
+// pushPlanSchemaVersion is bumped on any breaking change to PushPlan
+const pushPlanSchemaVersion = 1
+
+// PushPlan is the machine-readable output of push --dry-run --json.
+// The schema is stable: fields are only added, never renamed or removed,
+// without bumping SchemaVersion. Secret values are never included.
+type PushPlan struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Repository    string   `json:"repository"`
+	Environment   string   `json:"environment"`
+	File          string   `json:"file"`
+	Prune         bool     `json:"prune"`
+	Added         []string `json:"added"`   // keys that would be created
+	Changed       []string `json:"changed"` // keys whose value would be updated
+	Removed       []string `json:"removed"` // keys that would be deleted (only with --prune)
+	Kept          []string `json:"kept"`    // vault-only keys left untouched (without --prune)
+	Summary       struct {
+		Added   int `json:"added"`
+		Changed int `json:"changed"`
+		Removed int `json:"removed"`
+		Kept    int `json:"kept"`
+	} `json:"summary"`
+	HasDeletions bool        `json:"hasDeletions"`
+	Lint         []env.Issue `json:"lint,omitempty"` // values the lint rules flagged, see checkValueLint
+}
+
+// PushResult is the output of push --json: the plan that was applied and what
+// the server reported. Like PushPlan, it never includes secret values.
+type PushResult struct {
+	PushPlan
+	Message  string `json:"message"`
+	Replayed bool   `json:"replayed"` // already applied with the same idempotency key
+	Stats    struct {
+		Created int `json:"created"`
+		Updated int `json:"updated"`
+		Deleted int `json:"deleted"`
+	} `json:"stats"`
+	// HealthChecks are the checks of .keyway.json run once the push was applied
+	HealthChecks []HealthCheckResult `json:"healthChecks,omitempty"`
+}
+
+// buildPushPlan converts a push diff into a PushPlan
+func buildPushPlan(repo, envName, file string, prune bool, diff *env.PushDiff) PushPlan {
+	plan := PushPlan{
+		SchemaVersion: pushPlanSchemaVersion,
+		Repository:    repo,
+		Environment:   envName,
+		File:          file,
+		Prune:         prune,
+		Added:         nonNil(diff.Added),
+		Changed:       nonNil(diff.Changed),
+		Removed:       []string{},
+		Kept:          []string{},
+	}
+	if prune {
+		plan.Removed = nonNil(diff.Removed)
+	} else {
+		plan.Kept = nonNil(diff.Removed)
+	}
+	plan.Summary.Added = len(plan.Added)
+	plan.Summary.Changed = len(plan.Changed)
+	plan.Summary.Removed = len(plan.Removed)
+	plan.Summary.Kept = len(plan.Kept)
+	plan.HasDeletions = len(plan.Removed) > 0
+	return plan
+}
+
+// checkParseIssues reports the lines of an env file the parser skips or
+// misreads. With strict they fail the push, otherwise they are only counted.
+func checkParseIssues(file, content string, strict bool, deps *Dependencies) error {
+	issues := env.Lint(content)
+	if len(issues) == 0 {
+		return nil
+	}
+	if strict {
+		for _, issue := range issues {
+			deps.UI.Error(fmt.Sprintf("%s:%d: %s", file, issue.Line, issue.Message))
+		}
+		return fmt.Errorf("%s has %d parse issue(s) - fix them or push without --strict", file, len(issues))
+	}
+
+	skipped := 0
+	for _, issue := range issues {
+		if issue.Severity == env.SeverityError {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d line(s) of %s will be skipped or misread, run with --strict to list them", skipped, file))
+	}
+	return nil
+}
+
+// nonNil returns an empty slice instead of nil so JSON output has [] rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
 }
 
 // runPush is the entry point for the push command (uses default dependencies)
@@ -44,13 +191,37 @@ func runPush(cmd *cobra.Command, args []string) error {
 	opts.File, _ = cmd.Flags().GetString("file")
 	opts.Yes, _ = cmd.Flags().GetBool("yes")
 	opts.Prune, _ = cmd.Flags().GetBool("prune")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.IdempotencyKey, _ = cmd.Flags().GetString("idempotency-key")
+	if opts.IdempotencyKey == "" {
+		opts.IdempotencyKey = config.GetIdempotencyKey()
+	}
+	opts.AllowPlaceholders, _ = cmd.Flags().GetBool("allow-placeholders")
+	opts.NoAnomalyCheck, _ = cmd.Flags().GetBool("no-anomaly-check")
+	opts.Select, _ = cmd.Flags().GetBool("select")
+	opts.TrustValidators, _ = cmd.Flags().GetBool("trust-validators")
+	opts.Strict, _ = cmd.Flags().GetBool("strict")
+	opts.NoHealthCheck, _ = cmd.Flags().GetBool("no-health-check")
+	opts.RollbackOnFailure, _ = cmd.Flags().GetBool("rollback-on-failure")
+	opts.Remap, _ = cmd.Flags().GetString("remap")
 
 	return runPushWithDeps(opts, defaultDeps)
 }
 
 // runPushWithDeps is the testable version of runPush
 func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
-	deps.UI.Intro("push")
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return push(s, opts)
+	}, withIntro("push"), withRepo)
+}
+
+// push sends the secrets of the env file to the vault environment
+func push(s *Session, opts PushOptions) error {
+	deps := s.Deps
 
 	// Check gitignore
 	if !deps.Git.CheckEnvGitignore() {
@@ -69,7 +240,7 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 	file := opts.File
 
 	// Discover env files
-	candidates := deps.Env.Discover()
+	candidates := discoverEnvFiles(deps)
 
 	if len(candidates) == 0 && file == "" {
 		if !deps.UI.IsInteractive() {
@@ -87,22 +258,29 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		return nil
 	}
 
-	// Select file if not specified
+	// Select file if not specified, the one picked last time in this
+	// directory first
+	remembered := rememberedEnvFile(candidates, deps)
 	if file == "" && deps.UI.IsInteractive() && len(candidates) > 1 {
-		options := make([]string, len(candidates))
-		for i, c := range candidates {
+		ordered := orderEnvCandidates(candidates, remembered)
+		options := make([]string, len(ordered))
+		for i, c := range ordered {
 			options[i] = fmt.Sprintf("%s (env: %s)", c.File, c.Env)
+			if c.File == remembered {
+				options[i] += " - last used"
+			}
 		}
 		selected, err := deps.UI.Select("Select an env file to push:", options)
 		if err != nil {
 			return err
 		}
-		for _, c := range candidates {
-			if strings.HasPrefix(selected, c.File) {
-				file = c.File
+		for i, option := range options {
+			if option == selected {
+				file = ordered[i].File
 				if envName == "" {
-					envName = c.Env
+					envName = ordered[i].Env
 				}
+				rememberEnvFile(file, deps)
 				break
 			}
 		}
@@ -111,16 +289,17 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 	// Defaults
 	if file == "" {
 		if len(candidates) > 0 {
-			file = candidates[0].File
+			c := orderEnvCandidates(candidates, remembered)[0]
+			file = c.File
 			if envName == "" {
-				envName = candidates[0].Env
+				envName = c.Env
 			}
 		} else {
 			file = ".env"
 		}
 	}
 	if envName == "" {
-		envName = deps.Env.DeriveEnvFromFile(file)
+		envName = deriveEnvFromFile(file, deps)
 	}
 
 	// Read file
@@ -135,35 +314,57 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		return fmt.Errorf("file is empty")
 	}
 
+	if err := checkParseIssues(file, string(content), opts.Strict, deps); err != nil {
+		return err
+	}
+
 	secrets := env.Parse(string(content))
 	if len(secrets) == 0 {
 		deps.UI.Error("No valid environment variables found in file")
 		return fmt.Errorf("no variables found")
 	}
 
-	deps.UI.Step(fmt.Sprintf("File: %s", deps.UI.File(file)))
-	deps.UI.Step(fmt.Sprintf("Variables: %s", deps.UI.Value(len(secrets))))
-
-	repo, err := deps.Git.DetectRepo()
+	// A file pulled with --remap holds the names of the remap, not the vault's
+	secrets, err = unremapLocal(secrets, opts.Remap, deps)
 	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
+		deps.UI.Error(err.Error())
 		return err
 	}
-	deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(repo)))
 
-	token, err := deps.Auth.EnsureLogin()
+	// Keys excluded in .keyway.json never leave the machine
+	secrets, excluded, err := stripExcluded(secrets, deps)
 	if err != nil {
 		deps.UI.Error(err.Error())
 		return err
 	}
+	if len(excluded) > 0 {
+		deps.UI.Step(fmt.Sprintf("Excluded by %s: %s", config.ProjectFile, strings.Join(excluded, ", ")))
+		if len(secrets) == 0 {
+			deps.UI.Error("All variables in the file are excluded")
+			return fmt.Errorf("no variables to push")
+		}
+	}
+
+	deps.UI.Step(fmt.Sprintf("File: %s", deps.UI.File(file)))
+	deps.UI.Step(fmt.Sprintf("Variables: %s", deps.UI.Value(len(secrets))))
+
+	if proceed, err := confirmTemplatePush(file, secrets, opts, deps); err != nil || !proceed {
+		return err
+	}
+
+	repo := s.Repo
+	if err := s.Login(); err != nil {
+		return err
+	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
+	if err := requirePermission(s.Ctx, s.Client, repo, api.PermissionWrite, "pushing", deps); err != nil {
+		return err
+	}
 
 	// Prompt for environment if not specified
 	if !opts.EnvFlagSet && deps.UI.IsInteractive() {
 		// Fetch available environments
-		vaultEnvs, err := client.GetVaultEnvironments(ctx, repo)
+		vaultEnvs, err := s.Client.GetVaultEnvironments(s.Ctx, repo)
 		if err != nil || len(vaultEnvs) == 0 {
 			vaultEnvs = []string{"development", "staging", "production"}
 		}
@@ -200,56 +401,80 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 
 	deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(envName)))
 
+	// A dry run writes nothing, so it is allowed on frozen environments
+	if !opts.DryRun {
+		if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, repo, envName, deps); err != nil {
+			return err
+		}
+		if err := checkEnvironmentPolicy(s.Ctx, s.Client, repo, envName, deps); err != nil {
+			return err
+		}
+		warnConcurrentEdits(s.Ctx, s.Client, repo, envName, deps)
+	}
+
 	// Fetch current vault state to show preview
 	var vaultSecrets map[string]string
-	err = deps.UI.Spin("Fetching current vault state...", func() error {
-		resp, err := client.PullSecrets(ctx, repo, envName)
+	var notFoundErr error
+	err = s.Spin("Fetching current vault state...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, repo, envName)
 		if err != nil {
 			// Vault might not exist yet, that's ok
 			if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
 				vaultSecrets = make(map[string]string)
+				notFoundErr = err
 				return nil
 			}
 			return err
 		}
-		vaultSecrets = env.Parse(resp.Content)
+		vaultSecrets = env.ParseVault(resp.Content)
 		return nil
 	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok {
+			deps.UI.Error(apiErr.Error())
+		} else {
+			deps.UI.Error(err.Error())
+		}
+		return err
+	}
+
+	if notFoundErr != nil && explainMovedVault(s.Ctx, s.Client, repo, notFoundErr, deps) {
+		return fmt.Errorf("the vault of %s is under a previous name of the repository", repo)
+	}
 
+	// Calculate and show diff, values that the comparators declared in
+	// .keyway.json consider equivalent are not changes
+	equal, err := loadValueEqual(deps)
 	if err != nil {
-		// Handle auth errors (expired token)
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
-			}
-			// Retry with new token
-			client = deps.APIFactory.NewClient(newToken)
-			err = deps.UI.Spin("Fetching current vault state...", func() error {
-				resp, err := client.PullSecrets(ctx, repo, envName)
-				if err != nil {
-					if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
-						vaultSecrets = make(map[string]string)
-						return nil
-					}
-					return err
-				}
-				vaultSecrets = env.Parse(resp.Content)
-				return nil
-			})
+		deps.UI.Error(err.Error())
+		return err
+	}
+	// Files in the vault are pulled as a path, which must not replace them
+	equal = env.KeepFiles(equal)
+	// Nor must the values that ${VAR} references resolved to, with pull --expand
+	expandedVault, _ := env.Expand(vaultSecrets, os.LookupEnv)
+	equal = env.KeepReferences(equal, expandedVault)
+	diff := env.CalculatePushDiffWith(secrets, vaultSecrets, equal)
+
+	// Leave the keys the user didn't pick as they are in the vault
+	if opts.Select && diff.HasChanges() {
+		changed := append(append([]string{}, diff.Added...), diff.Changed...)
+		if opts.Prune {
+			changed = append(changed, diff.Removed...)
 		}
+		picked, err := pickKeys("Keys to push:", changed, deps)
 		if err != nil {
-			if apiErr, ok := err.(*api.APIError); ok {
-				deps.UI.Error(apiErr.Error())
-			} else {
-				deps.UI.Error(err.Error())
-			}
 			return err
 		}
+		secrets = keepUnpicked(secrets, vaultSecrets, changed, picked)
+		diff = env.CalculatePushDiffWith(secrets, vaultSecrets, equal)
 	}
 
-	// Calculate and show diff
-	diff := env.CalculatePushDiff(secrets, vaultSecrets)
+	if !opts.DryRun {
+		if err := checkKeyNamingPolicy(diff.Added, deps); err != nil {
+			return err
+		}
+	}
 
 	// When --prune is NOT set, merge vault secrets into local (additive mode)
 	// This preserves vault-only secrets instead of deleting them
@@ -265,6 +490,16 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		}
 	}
 
+	// Keep the vault's form of equivalent values so the push doesn't rewrite them
+	kept := make(map[string]string, len(secretsToSend))
+	for k, v := range secretsToSend {
+		if vaultVal, ok := vaultSecrets[k]; ok && vaultVal != v && equal(k, v, vaultVal) {
+			v = vaultVal
+		}
+		kept[k] = v
+	}
+	secretsToSend = kept
+
 	if diff.HasChanges() {
 		// Show additions and updates
 		if len(diff.Added) > 0 || len(diff.Changed) > 0 {
@@ -298,17 +533,65 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		deps.UI.Info("No changes detected")
 	}
 
+	changedKeys := append(append([]string{}, diff.Added...), diff.Changed...)
+	if opts.Prune {
+		changedKeys = append(changedKeys, diff.Removed...)
+	}
+	warnKeyOwners(s.Ctx, s.Client, repo, changedKeys, deps)
+
+	lintIssues, err := checkValueLint(envName, secrets, diff, opts.DryRun, deps)
+	if err != nil {
+		return err
+	}
+
+	if proceed, err := confirmValueAnomalies(diff, secrets, vaultSecrets, opts, deps); err != nil || !proceed {
+		return err
+	}
+
+	if err := checkValidators(s.Ctx, repo, envName, secrets, vaultSecrets, diff, opts.Prune, opts.TrustValidators, deps); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		if opts.JSONOutput {
+			plan := buildPushPlan(repo, envName, file, opts.Prune, diff)
+			plan.Lint = lintIssues
+			return ui.PrintJSON(plan)
+		}
+		deps.UI.Info("Dry run - nothing was pushed")
+		return nil
+	}
+
+	// Health checks run once the push is applied, their commands are trusted before
+	var healthChecks []config.HealthCheck
+	if !opts.NoHealthCheck {
+		if healthChecks, err = healthChecksFor(repo, envName, opts.TrustValidators, deps); err != nil {
+			return err
+		}
+	}
+
 	// Confirm
-	if !opts.Yes && deps.UI.IsInteractive() {
+	needsConfirm := confirmationNeeded(envName, len(changedKeys), opts.Yes, deps)
+	if needsConfirm && deps.UI.IsInteractive() {
 		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Push %d secrets from %s to %s?", len(secrets), file, repo), true)
 		if !confirm {
 			deps.UI.Warn("Push aborted.")
 			return nil
 		}
-	} else if !opts.Yes {
+	} else if needsConfirm {
 		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
 	}
 
+	// Snapshot the environment first so that keyway undo can revert this push.
+	// A push without a snapshot is still allowed, it just cannot be undone.
+	snapshot, snapErr := s.Client.CreateSnapshot(s.Ctx, repo, envName)
+	if snapErr != nil {
+		snapshot = nil
+		if apiErr, ok := snapErr.(*api.APIError); !ok || apiErr.StatusCode != 404 {
+			deps.UI.Warn(fmt.Sprintf("Could not snapshot %s, this push cannot be undone (%s)", envName, snapErr.Error()))
+		}
+	}
+
 	// Track push event
 	analytics.Track(analytics.EventPush, map[string]interface{}{
 		"repoFullName":  repo,
@@ -316,25 +599,32 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		"variableCount": len(secrets),
 	})
 
+	// The same key is sent on every attempt, so a push that reached the server
+	// before a failure is not applied again
+	idempotencyKey := opts.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.NewString()
+	}
+
 	var resp *api.PushSecretsResponse
-	err = deps.UI.Spin("Uploading secrets...", func() error {
+	err = s.Spin("Uploading secrets...", func() error {
 		var err error
-		resp, err = client.PushSecrets(ctx, repo, envName, secretsToSend)
+		resp, err = s.Client.PushSecrets(s.Ctx, repo, envName, secretsToSend, idempotencyKey)
 		return err
 	})
 
 	if err != nil {
-		// Handle auth errors (expired token)
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
+		// The environment wants the push confirmed with a WebAuthn
+		// authenticator, which takes someone at the keyboard
+		if requiresStepUp(err) && deps.UI.IsInteractive() {
+			confirmed, stepUpErr := confirmStepUp(s.Ctx, s.Client, repo, envName, deps)
+			if stepUpErr != nil {
+				return stepUpErr
 			}
-			// Retry with new token
-			client = deps.APIFactory.NewClient(newToken)
+			s.Client = confirmed
 			err = deps.UI.Spin("Uploading secrets...", func() error {
 				var pushErr error
-				resp, pushErr = client.PushSecrets(ctx, repo, envName, secretsToSend)
+				resp, pushErr = s.Client.PushSecrets(s.Ctx, repo, envName, secretsToSend, idempotencyKey)
 				return pushErr
 			})
 		}
@@ -345,20 +635,50 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 			})
 			if apiErr, ok := err.(*api.APIError); ok {
 				deps.UI.Error(apiErr.Error())
+				explainProtectionViolation(apiErr, deps)
 				if apiErr.UpgradeURL != "" {
 					analytics.Track(analytics.EventUpgradePrompt, map[string]interface{}{
 						"reason":  "push_error",
 						"command": "push",
 					})
-					deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(apiErr.UpgradeURL)))
+					deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
 				}
 			} else {
 				deps.UI.Error(err.Error())
+				// The push may have reached the server: the same key makes a rerun safe
+				deps.UI.Message(deps.UI.Dim(fmt.Sprintf("It may have been applied. Retry safely with: keyway push --idempotency-key %s", idempotencyKey)))
 			}
 			return err
 		}
 	}
 
+	forgetPrefetched(repo, envName, deps)
+	snapshotID := ""
+	if snapshot != nil {
+		snapshotID = snapshot.ID
+	}
+	if opts.JSONOutput {
+		result := PushResult{PushPlan: buildPushPlan(repo, envName, file, opts.Prune, diff), Message: resp.Message, Replayed: resp.Replayed}
+		if resp.Stats != nil {
+			result.Stats.Created = resp.Stats.Created
+			result.Stats.Updated = resp.Stats.Updated
+			result.Stats.Deleted = resp.Stats.Deleted
+		}
+		if snapshot != nil && snapshot.ID != "" && !resp.Replayed {
+			_ = recordPush(pushRecord{Repo: repo, Env: envName, File: file, SnapshotID: snapshot.ID, PushedAt: time.Now().UTC()}, deps)
+		}
+		var healthErr error
+		if len(healthChecks) > 0 && !resp.Replayed {
+			result.HealthChecks, healthErr = verifyPush(s.Ctx, s.Client, repo, envName, snapshotID, healthChecks, secretsToSend, opts.RollbackOnFailure, deps)
+		}
+		if err := ui.PrintJSON(result); err != nil {
+			return err
+		}
+		return healthErr
+	}
+	if resp.Replayed {
+		deps.UI.Info(fmt.Sprintf("Already applied by an earlier push with idempotency key %s, nothing changed", idempotencyKey))
+	}
 	deps.UI.Success(resp.Message)
 	if resp.Stats != nil {
 		parts := []string{}
@@ -376,8 +696,272 @@ func runPushWithDeps(opts PushOptions, deps *Dependencies) error {
 		}
 	}
 
+	if opts.Prune && len(diff.Removed) > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Removed keys stay in the trash for %d days, restore with: keyway trash restore <KEY> -e %s", api.TrashRetentionDays, envName)))
+	}
+
+	if snapshot != nil && snapshot.ID != "" && !resp.Replayed {
+		record := pushRecord{Repo: repo, Env: envName, File: file, SnapshotID: snapshot.ID, PushedAt: time.Now().UTC()}
+		if err := recordPush(record, deps); err == nil {
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Made a mistake? Run keyway undo within %d minutes", int(undoWindow.Minutes()))))
+		}
+	}
+
+	if len(healthChecks) > 0 && !resp.Replayed {
+		if _, err := verifyPush(s.Ctx, s.Client, repo, envName, snapshotID, healthChecks, secretsToSend, opts.RollbackOnFailure, deps); err != nil {
+			return err
+		}
+	}
+
 	dashboardURL := fmt.Sprintf("%s/vaults/%s", config.GetDashboardURL(), repo)
 	deps.UI.Outro(fmt.Sprintf("Dashboard: %s", deps.UI.Link(dashboardURL)))
 
 	return nil
 }
+
+// confirmTemplatePush asks for an extra confirmation when the file looks like a
+// template (.env.example) or holds placeholder values, since pushing it would
+// overwrite real secrets. --yes is not enough, non-interactive runs need
+// --allow-placeholders. It returns false if the user declined.
+func confirmTemplatePush(file string, secrets map[string]string, opts PushOptions, deps *Dependencies) (bool, error) {
+	isTemplate := env.IsTemplateFile(file)
+	placeholders := env.Placeholders(secrets)
+	if !isTemplate && len(placeholders) == 0 {
+		return true, nil
+	}
+
+	if isTemplate {
+		deps.UI.Warn(fmt.Sprintf("%s looks like a template file, not real secrets", file))
+	}
+	if len(placeholders) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d value(s) look like placeholders:", len(placeholders)))
+		for _, key := range placeholders {
+			deps.UI.Message(fmt.Sprintf("  %s=%s", key, secrets[key]))
+		}
+	}
+
+	if opts.AllowPlaceholders || opts.DryRun {
+		return true, nil
+	}
+	if !deps.UI.IsInteractive() {
+		deps.UI.Error("Refusing to push placeholder values")
+		return false, fmt.Errorf("file looks like a template - use --allow-placeholders to push it anyway")
+	}
+	confirm, _ := deps.UI.Confirm("Push these values anyway? They will replace the real secrets in the vault", false)
+	if !confirm {
+		deps.UI.Warn("Push aborted.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkValueLint runs the value rules on the keys a push adds or changes, e.g.
+// a password embedded in a webhook URL or DEBUG=true in production. Warnings
+// are shown, errors block the push but not a dry run. Rules are set to error,
+// warning or off under "lint" in .keyway.json.
+func checkValueLint(envName string, secrets map[string]string, diff *env.PushDiff, dryRun bool, deps *Dependencies) ([]env.Issue, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return nil, err
+	}
+	for _, rule := range sortedKeys(project.Lint) {
+		if !env.IsValueRule(rule) {
+			err := fmt.Errorf("invalid %s: unknown lint rule %q (use one of: %s)",
+				config.ProjectFile, rule, strings.Join(env.ValueRuleNames(), ", "))
+			deps.UI.Error(err.Error())
+			return nil, err
+		}
+	}
+
+	changed := make(map[string]string, len(diff.Added)+len(diff.Changed))
+	for _, key := range append(append([]string{}, diff.Added...), diff.Changed...) {
+		changed[key] = secrets[key]
+	}
+	issues := env.LintValues(changed, envName, project.Lint)
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	var blocking []string
+	deps.UI.Warn(fmt.Sprintf("%d value(s) look unsafe:", len(issues)))
+	for _, issue := range issues {
+		deps.UI.Message(fmt.Sprintf("  %s %s %s", issue.Severity, issue.Message, deps.UI.Dim("("+issue.Rule+")")))
+		if issue.Severity == env.SeverityError {
+			blocking = append(blocking, issue.Rule)
+		}
+	}
+	deps.UI.Message("")
+
+	if len(blocking) == 0 || dryRun {
+		return issues, nil
+	}
+	deps.UI.Error("Blocked by lint rules, fix the values before pushing")
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf(`Or lower the rule in %s, e.g. "lint": {"%s": "warning"}`, config.ProjectFile, blocking[0])))
+	return issues, fmt.Errorf("%d value(s) fail lint rules", len(blocking))
+}
+
+// confirmValueAnomalies asks for an extra confirmation when changed values look
+// like copy-paste mistakes, e.g. a 200 characters token replaced by "test".
+// Placeholders were already confirmed by confirmTemplatePush. Like it, --yes is
+// not enough, non-interactive runs need --no-anomaly-check.
+func confirmValueAnomalies(diff *env.PushDiff, secrets, vaultSecrets map[string]string, opts PushOptions, deps *Dependencies) (bool, error) {
+	if opts.NoAnomalyCheck {
+		return true, nil
+	}
+	changed := make(map[string]string, len(diff.Changed))
+	for _, key := range diff.Changed {
+		if !env.IsPlaceholder(secrets[key]) {
+			changed[key] = secrets[key]
+		}
+	}
+	anomalies := env.DetectAnomalies(vaultSecrets, changed)
+	if len(anomalies) == 0 {
+		return true, nil
+	}
+
+	deps.UI.Warn(fmt.Sprintf("%d value(s) changed drastically, check they were not pasted by mistake:", len(anomalies)))
+	for _, a := range anomalies {
+		deps.UI.Message(fmt.Sprintf("  %s %s", a.Key, deps.UI.Dim("("+a.Reason+")")))
+	}
+	deps.UI.Message("")
+
+	if opts.DryRun {
+		return true, nil
+	}
+	if !deps.UI.IsInteractive() {
+		deps.UI.Error("Refusing to push values that changed drastically")
+		return false, fmt.Errorf("values changed drastically - use --no-anomaly-check to push them anyway")
+	}
+	confirm, _ := deps.UI.Confirm("Push these values anyway?", false)
+	if !confirm {
+		deps.UI.Warn("Push aborted.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// pickKeys asks which of keys to act on. It needs an interactive terminal, and
+// fails if nothing is picked.
+func pickKeys(message string, keys []string, deps *Dependencies) ([]string, error) {
+	if !deps.UI.IsInteractive() {
+		deps.UI.Error("--select needs an interactive terminal")
+		return nil, fmt.Errorf("--select requires an interactive terminal")
+	}
+	picked, err := deps.UI.MultiSelect(message, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked) == 0 {
+		deps.UI.Warn("No key selected")
+		return nil, fmt.Errorf("no key selected")
+	}
+	return picked, nil
+}
+
+// keepUnpicked returns the local secrets with the changed keys that were not
+// picked set back to their vault values, so that pushing leaves them unchanged
+func keepUnpicked(secrets, vaultSecrets map[string]string, changed, picked []string) map[string]string {
+	isPicked := make(map[string]bool, len(picked))
+	for _, k := range picked {
+		isPicked[k] = true
+	}
+	result := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		result[k] = v
+	}
+	for _, k := range changed {
+		if isPicked[k] {
+			continue
+		}
+		if vaultVal, ok := vaultSecrets[k]; ok {
+			result[k] = vaultVal
+		} else {
+			delete(result, k)
+		}
+	}
+	return result
+}
+
+// orderEnvCandidates returns the env files found, the remembered one first,
+// then .env, then the others by name, so that the default does not depend on
+// the order the directory is read in
+func orderEnvCandidates(candidates []EnvCandidate, remembered string) []EnvCandidate {
+	rank := func(c EnvCandidate) int {
+		switch c.File {
+		case remembered:
+			return 0
+		case ".env":
+			return 1
+		default:
+			return 2
+		}
+	}
+	ordered := append([]EnvCandidate(nil), candidates...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if rank(ordered[i]) != rank(ordered[j]) {
+			return rank(ordered[i]) < rank(ordered[j])
+		}
+		return ordered[i].File < ordered[j].File
+	})
+	return ordered
+}
+
+// envFileChoicesPath returns the file holding the env file picked last, by directory
+func envFileChoicesPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "env-files.json")
+}
+
+// loadEnvFileChoices returns the env file picked last, by directory
+func loadEnvFileChoices(deps *Dependencies) map[string]string {
+	choices := make(map[string]string)
+	path := envFileChoicesPath()
+	if path == "" {
+		return choices
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &choices)
+	}
+	return choices
+}
+
+// rememberedEnvFile returns the env file picked last in the working
+// directory, if it is still one of the candidates
+func rememberedEnvFile(candidates []EnvCandidate, deps *Dependencies) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	file := loadEnvFileChoices(deps)[cwd]
+	for _, c := range candidates {
+		if c.File == file {
+			return file
+		}
+	}
+	return ""
+}
+
+// rememberEnvFile records the env file picked in the working directory.
+// Failing to record it only means asking again next time.
+func rememberEnvFile(file string, deps *Dependencies) {
+	path := envFileChoicesPath()
+	cwd, err := os.Getwd()
+	if path == "" || err != nil {
+		return
+	}
+	choices := loadEnvFileChoices(deps)
+	if choices[cwd] == file {
+		return
+	}
+	choices[cwd] = file
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	if data, err := json.MarshalIndent(choices, "", "  "); err == nil {
+		_ = deps.FS.WriteFile(path, data, 0600)
+	}
+}
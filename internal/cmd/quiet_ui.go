@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// quietUI wraps a UIProvider for machine-readable output modes.
+// Decorative output is dropped so stdout only carries the machine-readable
+// output; errors and warnings go to stderr. Prompts are never shown.
+// With ndjson set (--json mode), stderr carries NDJSON progress events
+// (see ProgressEvent) instead of plain text.
+type quietUI struct {
+	UIProvider
+	ndjson bool
+}
+
+func (q quietUI) Intro(command string)   {}
+func (q quietUI) Outro(message string)   {}
+func (q quietUI) Success(message string) {}
+func (q quietUI) Info(message string)    {}
+func (q quietUI) Step(message string)    {}
+func (q quietUI) Message(message string) {}
+func (q quietUI) DiffAdded(key string)   {}
+func (q quietUI) DiffChanged(key string) {}
+func (q quietUI) DiffRemoved(key string) {}
+func (q quietUI) DiffKept(key string)    {}
+
+func (q quietUI) Error(message string) {
+	if q.ndjson {
+		emitProgress(ProgressEvent{Type: ProgressTypeError, Message: message})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✗ %s\n", message)
+}
+
+func (q quietUI) Warn(message string) {
+	if q.ndjson {
+		emitProgress(ProgressEvent{Type: ProgressTypeWarning, Message: message})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠ %s\n", message)
+}
+
+func (q quietUI) IsInteractive() bool {
+	return false
+}
+
+func (q quietUI) Spin(message string, fn func() error) error {
+	if !q.ndjson {
+		return fn()
+	}
+
+	emitProgress(ProgressEvent{Type: ProgressTypeProgress, Phase: ProgressPhaseStarted, Percent: progressPercent(0), Message: message})
+	err := fn()
+	if err != nil {
+		emitProgress(ProgressEvent{Type: ProgressTypeProgress, Phase: ProgressPhaseFailed, Message: message, Error: err.Error()})
+		return err
+	}
+	emitProgress(ProgressEvent{Type: ProgressTypeProgress, Phase: ProgressPhaseCompleted, Percent: progressPercent(100), Message: message})
+	return nil
+}
+
+// withQuietUI returns a copy of deps whose UI only reports errors and warnings
+func withQuietUI(deps *Dependencies) *Dependencies {
+	quiet := *deps
+	quiet.UI = quietUI{UIProvider: deps.UI}
+	return &quiet
+}
+
+// withJSONUI returns a copy of deps for --json mode: nothing but the JSON
+// output goes to stdout, and progress is reported as NDJSON on stderr
+func withJSONUI(deps *Dependencies) *Dependencies {
+	quiet := *deps
+	quiet.UI = quietUI{UIProvider: deps.UI, ndjson: true}
+	return &quiet
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <template>",
+	Short: "Render secrets into a config file from a Go template",
+	Long: `Render the secrets of an environment into a config file, for apps that read
+their configuration from files rather than from environment variables. The
+template is a Go template (text/template), e.g. config.yaml.tmpl:
+
+  database:
+    url: {{ secret "DATABASE_URL" }}
+    pool: {{ .Secrets.DB_POOL | default "10" }}
+  stripe_key: {{ secret "STRIPE_KEY" | quote }}
+
+The template sees .Secrets (the secrets, derived keys included, a missing
+key being empty), .Env and .Repo, and these functions on top of Go's:
+
+  secret KEY      the value of KEY, an error if the environment lacks it
+  has KEY         whether the environment has KEY
+  default D V     V, or D when V is empty
+  quote, b64enc, b64dec, trim, indent N, upper, lower, json, join
+
+The file is written next to the template without its .tmpl or .tpl
+extension, or to --output, readable by the user only (0600). Keep it out of
+git like an env file. --output - prints it instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRender,
+}
+
+func init() {
+	renderCmd.Flags().StringP("env", "e", "development", "Environment name")
+	renderCmd.Flags().StringP("output", "o", "", "File to write (default: the template without its .tmpl extension, - for stdout)")
+}
+
+// renderOutput is where keyway render prints the file with --output -
+var renderOutput io.Writer = os.Stdout
+
+// RenderOptions contains the parsed flags for the render command
+type RenderOptions struct {
+	EnvName  string
+	Template string
+	Output   string
+}
+
+// runRender is the entry point for the render command (uses default dependencies)
+func runRender(cmd *cobra.Command, args []string) error {
+	opts := RenderOptions{Template: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Output, _ = cmd.Flags().GetString("output")
+
+	deps := defaultDeps
+	if opts.Output == "-" {
+		// stdout only carries the rendered file
+		deps = withQuietUI(deps)
+	}
+	return runRenderWithDeps(opts, deps)
+}
+
+// runRenderWithDeps is the testable version of runRender
+func runRenderWithDeps(opts RenderOptions, deps *Dependencies) error {
+	if opts.Output == "" {
+		opts.Output = renderOutputFile(opts.Template)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return render(s, opts)
+	}, withIntro("render"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// renderOutputFile returns the file a template is rendered to by default:
+// its path without the .tmpl or .tpl extension, or an empty string
+func renderOutputFile(template string) string {
+	for _, ext := range []string{".tmpl", ".tpl"} {
+		if strings.HasSuffix(template, ext) && len(template) > len(ext) {
+			return strings.TrimSuffix(template, ext)
+		}
+	}
+	return ""
+}
+
+// render fetches the environment and renders the template with its secrets
+func render(s *Session, opts RenderOptions) error {
+	deps := s.Deps
+	if opts.Output == "" {
+		deps.UI.Error(fmt.Sprintf("%s has no .tmpl extension, name the file to write with --output", opts.Template))
+		return fmt.Errorf("no output file for %s", opts.Template)
+	}
+	if filepath.Clean(opts.Output) == filepath.Clean(opts.Template) {
+		deps.UI.Error("--output would overwrite the template")
+		return fmt.Errorf("output is the template %s", opts.Template)
+	}
+	text, err := deps.FS.ReadFile(opts.Template)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot read %s: %s", opts.Template, err.Error()))
+		return err
+	}
+
+	var content string
+	err = s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("render", err, deps)
+	}
+	secrets, err := applyExpand(env.ParseVault(content), false, deps)
+	if err == nil {
+		secrets, err = applyDerived(secrets, deps)
+	}
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	rendered, err := renderTemplate(opts.Template, string(text), secrets, s.Repo, s.EnvName)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Cannot render %s: %s", opts.Template, strings.TrimPrefix(err.Error(), "template: ")))
+		return err
+	}
+
+	if opts.Output == "-" {
+		_, err := renderOutput.Write(rendered)
+		return err
+	}
+	if dir := filepath.Dir(opts.Output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+	}
+	// Writes keep the mode of an existing file: make it private first, so that
+	// the secrets are never readable by others
+	if err := os.Chmod(opts.Output, 0600); err != nil && !os.IsNotExist(err) {
+		deps.UI.Error(fmt.Sprintf("Failed to make %s private: %s", opts.Output, err.Error()))
+		return err
+	}
+	if err := deps.FS.WriteFile(opts.Output, rendered, 0600); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s: %s", opts.Output, err.Error()))
+		return err
+	}
+
+	analytics.Track("cli_render", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+	})
+	deps.UI.Success(fmt.Sprintf("Rendered %s into %s", opts.Template, deps.UI.File(opts.Output)))
+	return nil
+}
+
+// renderData is what a template of keyway render sees
+type renderData struct {
+	Secrets map[string]string
+	Env     string
+	Repo    string
+}
+
+// renderTemplate renders a template of keyway render with secrets
+func renderTemplate(name, text string, secrets map[string]string, repo, envName string) ([]byte, error) {
+	funcs := template.FuncMap{
+		"secret": func(key string) (string, error) {
+			value, ok := secrets[key]
+			if !ok {
+				return "", fmt.Errorf("%s is not in %s", key, envName)
+			}
+			return value, nil
+		},
+		"has": func(key string) bool {
+			_, ok := secrets[key]
+			return ok
+		},
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		"quote":  strconv.Quote,
+		"b64enc": func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+		"b64dec": func(value string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(value)
+			return string(data), err
+		},
+		"trim": strings.TrimSpace,
+		"indent": func(spaces int, value string) string {
+			pad := strings.Repeat(" ", spaces)
+			return pad + strings.ReplaceAll(value, "\n", "\n"+pad)
+		},
+	}
+	// A key missing from .Secrets is empty, not "<no value>", so that default works
+	tmpl, err := template.New(filepath.Base(name)).Option("missingkey=zero").Funcs(templateFuncs).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, renderData{Secrets: secrets, Env: envName, Repo: repo}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunRenderWithDeps_WritesFile(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files["config.yaml.tmpl"] = []byte(`env: {{ .Env }}
+database: {{ secret "DATABASE_URL" }}
+pool: {{ .Secrets.DB_POOL | default "10" }}
+stripe: {{ secret "STRIPE_KEY" | quote }}
+{{- if has "SENTRY_DSN" }}
+sentry: {{ .Secrets.SENTRY_DSN }}
+{{- end }}
+`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DATABASE_URL=postgres://db/app\nSTRIPE_KEY=sk_live_1\n"}
+
+	if err := runRenderWithDeps(RenderOptions{EnvName: "staging", Template: "config.yaml.tmpl"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "env: staging\ndatabase: postgres://db/app\npool: 10\nstripe: \"sk_live_1\"\n"
+	if got := string(fsMock.Written["config.yaml"]); got != want {
+		t.Errorf("unexpected config.yaml:\n%s", got)
+	}
+	if perm := fsMock.Perms["config.yaml"]; perm != 0600 {
+		t.Errorf("expected config.yaml to be written 0600, got %o", perm)
+	}
+}
+
+func TestRunRenderWithDeps_ExistingFileBecomesPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	dir := t.TempDir()
+	tmpl, output := filepath.Join(dir, "config.yaml.tmpl"), filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(tmpl, []byte(`key: {{ secret "API_KEY" }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(output, []byte("key: old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	deps.FS = &realFileSystem{}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_1\n"}
+
+	if err := runRenderWithDeps(RenderOptions{EnvName: "production", Template: tmpl}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected %s to be 0600, got %o", output, perm)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "key: sk_live_1" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestRunRenderWithDeps_MissingSecret(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files["app.toml.tmpl"] = []byte(`key = {{ secret "API_KEY" }}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "OTHER=1\n"}
+
+	if err := runRenderWithDeps(RenderOptions{EnvName: "production", Template: "app.toml.tmpl"}, deps); err == nil {
+		t.Fatal("expected an error for a missing secret")
+	}
+	if len(fsMock.Written) != 0 {
+		t.Error("nothing must be written")
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "API_KEY is not in production") {
+		t.Errorf("expected the missing key to be named, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunRenderWithDeps_Stdout(t *testing.T) {
+	var buf bytes.Buffer
+	previous := renderOutput
+	renderOutput = &buf
+	t.Cleanup(func() { renderOutput = previous })
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files["nginx.conf"] = []byte(`auth {{ b64enc (secret "BASIC_AUTH") }};`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "BASIC_AUTH=admin:pw\n"}
+
+	if err := runRenderWithDeps(RenderOptions{EnvName: "development", Template: "nginx.conf", Output: "-"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "auth YWRtaW46cHc=;" || len(fsMock.Written) != 0 {
+		t.Errorf("expected the file on stdout only, got %q", buf.String())
+	}
+}
+
+func TestRenderOutputFile(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml.tmpl":    "config.yaml",
+		"deploy/app.toml.tpl": "deploy/app.toml",
+		"config.yaml":         "",
+		".tmpl":               "",
+	}
+	for template, want := range tests {
+		if got := renderOutputFile(template); got != want {
+			t.Errorf("renderOutputFile(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
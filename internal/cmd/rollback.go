@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback REVISION",
+	Short: "Put an environment back in the state of an earlier version",
+	Long: `Put an environment back in the state it had at an earlier revision: a version
+listed by keyway history (v42 or version:42), a date (YYYY-MM-DD) or an RFC 3339
+timestamp.
+
+The keys that will change are shown first, then the name of the environment
+must be typed to confirm, unless --yes is passed. The state before the rollback
+can be brought back with keyway undo.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+func init() {
+	rollbackCmd.Flags().StringP("env", "e", "development", "Environment name")
+	rollbackCmd.Flags().Bool("show-values", false, "Show actual value differences (sensitive!)")
+	rollbackCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+}
+
+// RollbackOptions contains the parsed flags for the rollback command
+type RollbackOptions struct {
+	Revision   string
+	EnvName    string
+	ShowValues bool
+	Yes        bool
+}
+
+// runRollback is the entry point for the rollback command (uses default dependencies)
+func runRollback(cmd *cobra.Command, args []string) error {
+	opts := RollbackOptions{Revision: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.ShowValues, _ = cmd.Flags().GetBool("show-values")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runRollbackWithDeps(opts, defaultDeps)
+}
+
+// runRollbackWithDeps is the testable version of runRollback
+func runRollbackWithDeps(opts RollbackOptions, deps *Dependencies) error {
+	rev, err := api.ParseRevision(opts.Revision)
+	if err != nil {
+		deps.UI.Intro("rollback")
+		deps.UI.Error(err.Error())
+		return err
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return rollback(s, rev, opts)
+	}, withIntro("rollback"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// rollback pushes the secrets an environment had at rev, once confirmed
+func rollback(s *Session, rev api.Revision, opts RollbackOptions) error {
+	deps, envName := s.Deps, s.EnvName
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "rolling back an environment", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+
+	label := fmt.Sprintf("%s@%s", envName, rev.String())
+	var target, current map[string]string
+	err := s.Spin(fmt.Sprintf("Fetching %s...", label), func() error {
+		resp, err := s.Client.PullSecretsAt(s.Ctx, s.Repo, envName, rev)
+		if err != nil {
+			return err
+		}
+		target = env.ParseVault(resp.Content)
+		if resp, err = s.Client.PullSecrets(s.Ctx, s.Repo, envName); err != nil {
+			return err
+		}
+		current = env.ParseVault(resp.Content)
+		return nil
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			deps.UI.Error(fmt.Sprintf("No snapshot of %s found at %s", envName, rev.String()))
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("List the versions with: keyway history -e %s", envName)))
+			return err
+		}
+		return reportEnvError("rollback", err, deps)
+	}
+
+	result := compareSecrets(envName, label, current, target, opts.ShowValues)
+	changes := result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2 + result.Stats.Different
+	if changes == 0 {
+		deps.UI.Success(fmt.Sprintf("%s is already in the state of %s", envName, rev.String()))
+		return nil
+	}
+	if err := revealConfigValues(result, current, target, deps); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	addDiffOwners(s.Ctx, s.Client, s.Repo, result, deps)
+	printDiffResults(result, envName, label, opts.ShowValues, false)
+
+	if len(target) == 0 {
+		deps.UI.Warn(fmt.Sprintf("%s had no secrets at %s, all %d will be removed", envName, rev.String(), len(current)))
+	}
+	changed := append(append(append([]string{}, result.OnlyInEnv2...), result.OnlyInEnv1...), diffKeys(result)...)
+	warnKeyOwners(s.Ctx, s.Client, s.Repo, changed, deps)
+	if err := checkValidators(s.Ctx, s.Repo, envName, target, current, env.CalculatePushDiff(target, current), true, false, deps); err != nil {
+		return err
+	}
+
+	if !opts.Yes {
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("Use --yes to roll back in non-interactive mode")
+			return fmt.Errorf("confirmation required")
+		}
+		confirm, err := confirmTyped(envName, fmt.Sprintf("Type %s to roll it back to %s:", envName, rev.String()), deps)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			deps.UI.Warn("Rollback aborted.")
+			return nil
+		}
+	}
+
+	analytics.Track("cli_rollback", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  envName,
+		"byVersion":    rev.Version > 0,
+		"changes":      changes,
+	})
+
+	// Snapshot the current state first, so that keyway undo can revert the rollback
+	var before *api.Snapshot
+	err = s.Spin("Rolling back...", func() error {
+		var err error
+		before, err = s.Client.CreateSnapshot(s.Ctx, s.Repo, envName)
+		if err != nil {
+			return err
+		}
+		_, err = s.Client.PushSecrets(s.Ctx, s.Repo, envName, target, uuid.NewString())
+		return err
+	})
+	if err != nil {
+		return reportEnvError("rollback", err, deps)
+	}
+	forgetPrefetched(s.Repo, envName, deps)
+
+	deps.UI.Success(fmt.Sprintf("Rolled %s back to %s (%d changes)", envName, rev.String(), changes))
+	if before != nil && before.ID != "" {
+		record := pushRecord{Repo: s.Repo, Env: envName, SnapshotID: before.ID, PushedAt: time.Now().UTC()}
+		if err := recordPush(record, deps); err == nil {
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Made a mistake? Run keyway undo within %d minutes", int(undoWindow.Minutes()))))
+		}
+	}
+	deps.UI.Outro("")
+	return nil
+}
+
+// diffKeys returns the keys whose values differ in a comparison
+func diffKeys(result *DiffResult) []string {
+	keys := make([]string, 0, len(result.Different))
+	for _, entry := range result.Different {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// confirmTyped asks to type want to go on, for changes hard to take back
+func confirmTyped(want, prompt string, deps *Dependencies) (bool, error) {
+	typed, err := deps.UI.Input(prompt, "")
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(typed) != want {
+		if typed != "" {
+			deps.UI.Error(fmt.Sprintf("%q is not %s", strings.TrimSpace(typed), want))
+		}
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunRollbackWithDeps_TypedConfirmation(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.InputResults = []string{"production"}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{42: {Content: "API_KEY=old\nREGION=eu\n"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=broken\n"}
+
+	captureStdout(t, func() {
+		if err := runRollbackWithDeps(RollbackOptions{Revision: "v42", EnvName: "prod"}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if apiMock.PulledRevision.Version != 42 {
+		t.Errorf("expected version 42 to be pulled, got %+v", apiMock.PulledRevision)
+	}
+	if want := map[string]string{"API_KEY": "old", "REGION": "eu"}; !reflect.DeepEqual(apiMock.PushedByEnv["production"], want) {
+		t.Errorf("expected the old secrets to be pushed, got %v", apiMock.PushedByEnv)
+	}
+	if len(uiMock.InputCalls) != 1 {
+		t.Errorf("expected the environment name to be asked, got %v", uiMock.InputCalls)
+	}
+	if _, ok := fsMock.Written[pushHistoryPath()]; !ok {
+		t.Error("expected the rollback to be recorded for keyway undo")
+	}
+}
+
+func TestRunRollbackWithDeps_WrongNameAborts(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.InputResults = []string{"staging"}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "A=1\n"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "A=2\n"}
+
+	captureStdout(t, func() {
+		if err := runRollbackWithDeps(RollbackOptions{Revision: "version:3", EnvName: "production"}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing to be pushed, got %v", apiMock.PushedSecrets)
+	}
+}
+
+func TestRunRollbackWithDeps_NonInteractiveNeedsYes(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "A=1\n"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "A=2\n"}
+
+	captureStdout(t, func() {
+		if err := runRollbackWithDeps(RollbackOptions{Revision: "v3", EnvName: "production"}, deps); err == nil {
+			t.Fatal("expected an error without --yes")
+		}
+	})
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
+
+func TestRunRollbackWithDeps_AlreadyThere(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "A=1\n"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "A=1\n"}
+
+	if err := runRollbackWithDeps(RollbackOptions{Revision: "v3", EnvName: "production", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets != nil || len(uiMock.SuccessCalls) != 1 {
+		t.Errorf("expected nothing to be pushed, got %v and %v", apiMock.PushedSecrets, uiMock.SuccessCalls)
+	}
+}
+
+func TestRunRollbackWithDeps_UnknownRevision(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullAtError = &api.APIError{StatusCode: 404}
+
+	if err := runRollbackWithDeps(RollbackOptions{Revision: "v9", EnvName: "production", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for a missing version")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "No snapshot of production found at version:9" {
+		t.Errorf("unexpected error: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunRollbackWithDeps_InvalidRevision(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	if err := runRollbackWithDeps(RollbackOptions{Revision: "last-week", EnvName: "production", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for an invalid revision")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/keywaysh/cli/internal/api"
@@ -12,7 +14,6 @@ import (
 	"github.com/keywaysh/cli/internal/git"
 	"github.com/keywaysh/cli/internal/ui"
 	"github.com/keywaysh/cli/internal/version"
-	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 )
 
@@ -38,23 +39,9 @@ func runRoot(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check if user is logged in
-	store := auth.NewStore()
-	storedAuth, err := store.GetAuth()
-	var token string
-	isLoggedIn := false
-
-	if err == nil && storedAuth != nil && storedAuth.KeywayToken != "" {
-		isLoggedIn = true
-		token = storedAuth.KeywayToken
-	}
+	token := storedToken()
 
-	// Also check env var
-	if envToken := os.Getenv("KEYWAY_TOKEN"); envToken != "" {
-		isLoggedIn = true
-		token = envToken
-	}
-
-	if !isLoggedIn {
+	if token == "" {
 		// Not logged in: run full onboarding flow
 		return runOnboarding(cmd)
 	}
@@ -63,6 +50,19 @@ func runRoot(cmd *cobra.Command, args []string) error {
 	return runActionMenu(cmd, token)
 }
 
+// storedToken returns the token from KEYWAY_TOKEN or the stored login, without
+// prompting. It returns an empty string when not logged in.
+func storedToken() string {
+	if envToken := os.Getenv("KEYWAY_TOKEN"); envToken != "" {
+		return envToken
+	}
+	storedAuth, err := auth.NewStore().GetAuth()
+	if err == nil && storedAuth != nil {
+		return storedAuth.KeywayToken
+	}
+	return ""
+}
+
 func runOnboarding(cmd *cobra.Command) error {
 	ui.Intro("welcome")
 
@@ -142,38 +142,12 @@ func runActionMenu(cmd *cobra.Command, token string) error {
 		return runInit(initCmd, nil)
 	}
 
-	// Vault exists with secrets: show action menu
-	options := []string{
-		"Pull secrets from vault",
-		"Push secrets to vault",
-		"Sync with Vercel/Railway/Netlify",
-		"Open dashboard",
-		"Show help",
+	// Vault exists with secrets: search the commands, read-only for viewers
+	if !vaultDetails.HasPermission(api.PermissionWrite) {
+		ui.Message(ui.Dim(fmt.Sprintf("You have %s access to this vault, pushing is disabled", vaultDetails.Permission)))
 	}
-
-	selected, err := ui.Select("What would you like to do?", options)
-	if err != nil {
-		return err
-	}
-
-	switch selected {
-	case "Pull secrets from vault":
-		return runPull(pullCmd, nil)
-	case "Push secrets to vault":
-		return runPush(pushCmd, nil)
-	case "Sync with Vercel/Railway/Netlify":
-		return runSync(syncCmd, nil)
-	case "Open dashboard":
-		url := fmt.Sprintf("%s/vaults/%s", config.GetDashboardURL(), repo)
-		ui.Success(fmt.Sprintf("Opening %s", ui.Link(url)))
-		_ = browser.OpenURL(url)
-		return nil
-	case "Show help":
-		printCustomHelp(cmd)
-		return nil
-	}
-
-	return nil
+	ui.Step(fmt.Sprintf("Dashboard: %s", ui.Link(fmt.Sprintf("%s/vaults/%s", config.GetDashboardURL(), repo))))
+	return runPalette(cmd)
 }
 
 func printCustomHelp(cmd *cobra.Command) {
@@ -184,10 +158,26 @@ func printCustomHelp(cmd *cobra.Command) {
 	// Core Commands
 	fmt.Printf("  %s\n", bold("Core Commands:"))
 	fmt.Printf("    %s           %s\n", cyan("keyway init"), "Initialize vault for this repo")
+	fmt.Printf("    %s        %s\n", cyan("keyway migrate"), "Move environments from Doppler or dotenv-vault")
 	fmt.Printf("    %s           %s\n", cyan("keyway push"), "Upload secrets to vault")
+	fmt.Printf("    %s           %s\n", cyan("keyway undo"), "Revert the last push from this machine")
+	fmt.Printf("    %s       %s\n", cyan("keyway snapshot"), "Tag an environment's state, then diff or restore it")
+	fmt.Printf("    %s          %s\n", cyan("keyway trash"), "List and restore removed keys")
 	fmt.Printf("    %s           %s\n", cyan("keyway pull"), "Download secrets from vault")
-	fmt.Printf("    %s            %s\n", cyan("keyway set"), "Set a single secret in vault")
+	fmt.Printf("    %s          %s\n", cyan("keyway watch"), "Keep an env file in sync as the vault changes")
+	fmt.Printf("    %s         %s\n", cyan("keyway render"), "Render secrets into a config file from a template")
+	fmt.Printf("    %s            %s\n", cyan("keyway set"), "Set one or more secrets in vault")
+	fmt.Printf("    %s   %s\n", cyan("keyway secrets list"), "Keys with masked values and who last set them")
+	fmt.Printf("    %s    %s\n", cyan("keyway secrets get"), "Print one value for scripts, --plain without newline")
+	fmt.Printf("    %s    %s\n", cyan("keyway secrets set"), "Same as keyway set, KEY=VALUE pairs")
+	fmt.Printf("    %s     %s\n", cyan("keyway secrets rm"), "Delete one or more secrets, kept in the trash")
+	fmt.Printf("    %s            %s\n", cyan("keyway get"), "Print, copy or show as a QR code one value")
+	fmt.Printf("    %s           %s\n", cyan("keyway file"), "Store a small file (service account, keystore) as a secret")
 	fmt.Printf("    %s            %s\n", cyan("keyway run"), "Run command with injected secrets (Zero-Trust)")
+	fmt.Printf("    %s          %s\n", cyan("keyway shell"), "Start a subshell with secrets loaded")
+	fmt.Printf("    %s       %s\n", cyan("keyway activate"), "Export secrets into the current shell, until keyway deactivate")
+	fmt.Printf("    %s       %s\n", cyan("keyway prefetch"), "Cache secrets on cd so keyway run starts instantly")
+	fmt.Printf("    %s        %s\n", cyan("keyway compose"), "Run docker compose with vault values")
 	fmt.Printf("    %s           %s\n", cyan("keyway login"), "Sign in with GitHub")
 	fmt.Println()
 
@@ -203,7 +193,30 @@ func printCustomHelp(cmd *cobra.Command) {
 	fmt.Printf("  %s\n", bold("Utilities:"))
 	fmt.Printf("    %s           %s\n", cyan("keyway diff"), "Compare secrets between environments")
 	fmt.Printf("    %s           %s\n", cyan("keyway scan"), "Scan codebase for leaked secrets")
+	fmt.Printf("    %s         %s\n", cyan("keyway unused"), "Find unused vault keys and env vars missing from the vault")
+	fmt.Printf("    %s %s\n", cyan("keyway audit-strength"), "Find weak passwords and keys, --fail-on for CI")
+	fmt.Printf("    %s            %s\n", cyan("keyway use"), "Pin a repository, environment and profile for the next commands")
+	fmt.Printf("    %s           %s\n", cyan("keyway shim"), "Run npm/yarn scripts under keyway run")
+	fmt.Printf("    %s            %s\n", cyan("keyway env"), "List, create, rename, delete, freeze or protect environments")
+	fmt.Printf("    %s           %s\n", cyan("keyway sudo"), "Temporary write access to an environment")
+	fmt.Printf("    %s           %s\n", cyan("keyway edit"), "Tell teammates you are editing an environment")
+	fmt.Printf("    %s         %s\n", cyan("keyway owners"), "Show who owns the keys of an environment")
+	fmt.Printf("    %s   %s\n", cyan("keyway vault relink"), "Reconnect the vault of a renamed or transferred repo")
+	fmt.Printf("    %s            %s\n", cyan("keyway lsp"), "JSON-RPC server for editor extensions")
+	fmt.Printf("    %s           %s\n", cyan("keyway ship"), "Stream an environment to a host over SSH")
+	fmt.Printf("    %s         %s\n", cyan("keyway export"), "Encrypted bundle of some keys for a vendor")
+	fmt.Printf("    %s    %s\n", cyan("keyway compliance"), "Signed, encrypted dump of the vault for audits")
+	fmt.Printf("    %s         %s\n", cyan("keyway impact"), "Show what changing a key would affect")
+	fmt.Printf("    %s         %s\n", cyan("keyway bisect"), "Find the vault change that broke the app")
+	fmt.Printf("    %s         %s\n", cyan("keyway events"), "Show or follow vault changes")
+	fmt.Printf("    %s        %s\n", cyan("keyway history"), "Versions of an environment or a key: who pushed, when, what changed")
+	fmt.Printf("    %s       %s\n", cyan("keyway rollback"), "Put an environment back in the state of an earlier version")
+	fmt.Printf("    %s       %s\n", cyan("keyway rotation"), "Rotate a key in every vault of the organization holding it")
+	fmt.Printf("    %s       %s\n", cyan("keyway examples"), "Examples of a command, filled in for this repo")
+	fmt.Printf("    %s          %s\n", cyan("keyway usage"), "Show your command usage and timing (local only)")
 	fmt.Printf("    %s         %s\n", cyan("keyway doctor"), "Check your setup")
+	fmt.Printf("    %s %s\n", cyan("keyway verify-install"), "Check this binary against its published release")
+	fmt.Printf("    %s       %s\n", cyan("keyway sessions"), "List and revoke the sessions of your account")
 	fmt.Printf("    %s         %s\n", cyan("keyway logout"), "Clear stored credentials")
 	fmt.Println()
 
@@ -221,6 +234,7 @@ func printCustomHelp(cmd *cobra.Command) {
 // Execute runs the root command
 func Execute(ver string) error {
 	rootCmd.Version = ver
+	api.SetClientVersion(ver)
 
 	// Start non-blocking version check
 	updateChan := make(chan *version.UpdateInfo, 1)
@@ -231,13 +245,41 @@ func Execute(ver string) error {
 		updateChan <- info
 	}()
 
+	// Use the context pinned with keyway use, before anything detects the repository
+	pinned := loadPinnedContext(defaultDeps)
+	applyPinnedContext(pinned, defaultDeps)
+
+	// Writes to an environment elevated with keyway sudo use the elevation
+	applyElevations(defaultDeps)
+
+	// Apply the policy of the repository's organization: telemetry goes where it
+	// wants it, and commands are blocked when the CLI doesn't meet it
+	policy := applyOrgPolicy(defaultDeps, storedToken, time.Now())
+	wrapCommands(rootCmd, commandMiddleware(policy, pinned, ver)...)
+	markUsageErrors(rootCmd)
+
 	// Execute the command
 	err := rootCmd.Execute()
 
+	// Differences found by keyway diff --exit-code were just shown
+	var drift driftError
+	if errors.As(err, &drift) {
+		return err
+	}
+
 	// Display error and help for unknown commands
 	if err != nil {
 		red := color.New(color.FgRed).SprintFunc()
 		fmt.Fprintf(os.Stderr, "\n  %s %s\n", red("Error:"), err)
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.IsUpgradeRequired() {
+			displayUpgradeRequired(apiErr, ver)
+			return err
+		}
+		// The help would only bury the error in a CI log
+		if config.IsCI() {
+			return err
+		}
 		fmt.Println()
 		printCustomHelp(rootCmd)
 		return err
@@ -273,6 +315,26 @@ func displayUpdateNotice(info *version.UpdateInfo) {
 		cyan(info.UpdateCommand))
 }
 
+// displayUpgradeRequired explains how to update when the API no longer
+// supports this version of the CLI
+func displayUpgradeRequired(apiErr *api.APIError, ver string) {
+	fmt.Fprintln(os.Stderr)
+	if apiErr.MinVersion != "" {
+		fmt.Fprintf(os.Stderr, "  The Keyway API requires keyway %s or later, this is %s.\n", bold(apiErr.MinVersion), ver)
+	} else {
+		fmt.Fprintf(os.Stderr, "  The Keyway API no longer supports keyway %s.\n", ver)
+	}
+	if updateCmd := version.GetUpdateCommand(version.DetectInstallMethod()); updateCmd != "" {
+		fmt.Fprintf(os.Stderr, "  %s Run: %s\n", dim("→"), cyan(updateCmd))
+	} else {
+		// Self-hosted instances, the server's administrator knows which build to use
+		fmt.Fprintf(os.Stderr, "  %s Ask your Keyway administrator for a supported version\n", dim("→"))
+	}
+	if apiErr.UpgradeURL != "" {
+		fmt.Fprintf(os.Stderr, "  %s More: %s\n", dim("→"), config.DashboardLink(apiErr.UpgradeURL))
+	}
+}
+
 func init() {
 	// Add commands
 	rootCmd.AddCommand(loginCmd)
@@ -280,6 +342,8 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(renderCmd)
 	rootCmd.AddCommand(setCmd)
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(connectCmd)
@@ -290,4 +354,43 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(shimCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(lspCmd)
+	rootCmd.AddCommand(shipCmd)
+	rootCmd.AddCommand(impactCmd)
+	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(rotationCmd)
+	rootCmd.AddCommand(examplesCmd)
+	rootCmd.AddCommand(usageCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(fileCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(composeCmd)
+	rootCmd.AddCommand(unusedCmd)
+	rootCmd.AddCommand(useCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(sudoCmd)
+	rootCmd.AddCommand(activateCmd)
+	rootCmd.AddCommand(deactivateCmd)
+	rootCmd.AddCommand(verifyInstallCmd)
+	rootCmd.AddCommand(vaultCmd)
+	rootCmd.AddCommand(ownersCmd)
+	rootCmd.AddCommand(bisectCmd)
+	rootCmd.AddCommand(prefetchCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(complianceCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(auditStrengthCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(snapshotCmd)
+
+	rootCmd.PersistentFlags().Bool("json", false, "Print the result as JSON on stdout, the rest of the output on stderr")
+	rootCmd.PersistentFlags().Bool("accessible", false, "Screen reader friendly output: no spinners or animations, words instead of colors and symbols")
 }
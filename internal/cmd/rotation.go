@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var rotationCmd = &cobra.Command{
+	Use:   "rotation",
+	Short: "Rotate a key everywhere it is used",
+	Long:  `Rotate a key in every vault of the organization holding it.`,
+}
+
+var rotationPlanCmd = &cobra.Command{
+	Use:   "plan KEY",
+	Short: "Rotate a key in every environment of the organization holding it",
+	Long: `Find the environments of the organization's vaults holding a key, then give
+them a new value one after the other, non-production environments first:
+
+  1. the new value is pushed to the environment
+  2. the verify command of the key, if any, runs with its secrets
+  3. once every environment is verified, the revoke command runs with the old
+     value in KEYWAY_OLD_VALUE, e.g. to delete the old API key at the provider
+
+The new value comes from --generate or the key's rule, or is asked for. Rules
+are declared in .keyway.json, commands run like validators and must be trusted
+on first use:
+
+  {
+    "rotation": {
+      "STRIPE_KEY": {
+        "verify": ["./scripts/check-stripe"],
+        "revoke": ["./scripts/revoke-stripe-key"]
+      },
+      "SESSION_SECRET": {"generate": "hex:32"}
+    }
+  }
+
+When a step fails, the rotation stops where it is and keyway rotation plan KEY
+--resume goes on from there. No value is stored on this machine.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRotationPlan,
+}
+
+func init() {
+	rotationPlanCmd.Flags().String("org", "", "Organization to search (default: the owner of the repository)")
+	rotationPlanCmd.Flags().String("generate", "", "Generate the new value: hex:N, base64:N, password:N or uuid")
+	rotationPlanCmd.Flags().Bool("dry-run", false, "Show the plan without changing anything")
+	rotationPlanCmd.Flags().Bool("resume", false, "Go on with a rotation that stopped")
+	rotationPlanCmd.Flags().Bool("abandon", false, "Forget a rotation that stopped, environments keep their values")
+	rotationPlanCmd.Flags().Bool("trust-validators", false, "Run the verify and revoke commands of .keyway.json without asking")
+	rotationPlanCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	rotationCmd.AddCommand(rotationPlanCmd)
+}
+
+// RotationOptions contains the parsed flags for the rotation plan command
+type RotationOptions struct {
+	Key             string
+	Org             string
+	Generate        string
+	DryRun          bool
+	Resume          bool
+	Abandon         bool
+	TrustValidators bool
+	Yes             bool
+}
+
+// rotationStep is an environment getting the new value of a key
+type rotationStep struct {
+	Repo        string `json:"repo"`
+	Environment string `json:"environment"`
+	// PreviousVersion is the version of the environment holding the old value,
+	// read back to revoke it
+	PreviousVersion int  `json:"previousVersion,omitempty"`
+	Pushed          bool `json:"pushed,omitempty"`
+	Verified        bool `json:"verified,omitempty"`
+}
+
+// label names the environment of a step, e.g. "acme/api · production"
+func (s rotationStep) label() string {
+	return s.Repo + " · " + s.Environment
+}
+
+// rotationState is a rotation in progress, kept until the old value is revoked
+type rotationState struct {
+	Org       string         `json:"org"`
+	Key       string         `json:"key"`
+	Steps     []rotationStep `json:"steps"`
+	StartedAt time.Time      `json:"startedAt"`
+}
+
+// done returns the number of environments verified with the new value
+func (r rotationState) done() int {
+	n := 0
+	for _, step := range r.Steps {
+		if step.Verified {
+			n++
+		}
+	}
+	return n
+}
+
+// runRotationPlan is the entry point for the rotation plan command (uses default dependencies)
+func runRotationPlan(cmd *cobra.Command, args []string) error {
+	opts := RotationOptions{Key: args[0]}
+	opts.Org, _ = cmd.Flags().GetString("org")
+	opts.Generate, _ = cmd.Flags().GetString("generate")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.Resume, _ = cmd.Flags().GetBool("resume")
+	opts.Abandon, _ = cmd.Flags().GetBool("abandon")
+	opts.TrustValidators, _ = cmd.Flags().GetBool("trust-validators")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runRotationPlanWithDeps(opts, defaultDeps)
+}
+
+// runRotationPlanWithDeps is the testable version of runRotationPlan
+func runRotationPlanWithDeps(opts RotationOptions, deps *Dependencies) error {
+	if opts.Resume && opts.Abandon {
+		deps.UI.Intro("rotation plan")
+		deps.UI.Error("--resume and --abandon cannot be combined")
+		return fmt.Errorf("--resume and --abandon cannot be combined")
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return rotationPlan(s, opts)
+	}, withIntro("rotation plan"), withRepo, withLogin)
+}
+
+// rotationPlan plans the rotation of a key, then runs it step by step
+func rotationPlan(s *Session, opts RotationOptions) error {
+	deps := s.Deps
+	org := opts.Org
+	if org == "" {
+		org = repoOwner(s.Repo)
+	}
+	id := org + ":" + opts.Key
+	state, inProgress := loadRotations(deps)[id]
+
+	switch {
+	case opts.Abandon:
+		if !inProgress {
+			deps.UI.Info(fmt.Sprintf("No rotation of %s in progress", opts.Key))
+			return nil
+		}
+		if err := removeRotation(id, deps); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to remove the rotation: %s", err.Error()))
+			return err
+		}
+		deps.UI.Success(fmt.Sprintf("Forgot the rotation of %s", opts.Key))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d of %d environments already have the new value", state.done(), len(state.Steps))))
+		return nil
+	case inProgress && !opts.Resume:
+		err := fmt.Errorf("a rotation of %s is in progress", opts.Key)
+		deps.UI.Error(fmt.Sprintf("A rotation of %s is in progress, %d of %d environments done", opts.Key, state.done(), len(state.Steps)))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Go on with: keyway rotation plan %s --resume, or forget it with --abandon", opts.Key)))
+		return err
+	case !inProgress && opts.Resume:
+		err := fmt.Errorf("no rotation of %s to resume", opts.Key)
+		deps.UI.Error(fmt.Sprintf("No rotation of %s to resume", opts.Key))
+		return err
+	}
+
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	rule := project.RotationRuleFor(opts.Key)
+
+	if !inProgress {
+		var locations []api.KeyLocation
+		err := s.Spin(fmt.Sprintf("Searching %s for %s...", org, opts.Key), func() error {
+			var err error
+			locations, err = s.Client.SearchOrganizationKey(s.Ctx, org, opts.Key)
+			return err
+		})
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to search %s: %s", org, err.Error()))
+			return err
+		}
+		if len(locations) == 0 {
+			err := fmt.Errorf("no vault of %s holds %s", org, opts.Key)
+			deps.UI.Error(fmt.Sprintf("No vault of %s holds %s", org, opts.Key))
+			return err
+		}
+		state = newRotation(org, opts.Key, locations)
+	}
+	printRotationPlan(state, rule, deps)
+
+	if opts.DryRun {
+		deps.UI.Outro("Dry run, nothing was changed")
+		return nil
+	}
+
+	if err := checkRotationSteps(s, state, deps); err != nil {
+		return err
+	}
+	for name, command := range map[string][]string{"verify": rule.Verify, "revoke": rule.Revoke} {
+		if len(command) == 0 {
+			continue
+		}
+		hook := config.Validator{Name: fmt.Sprintf("rotation %s of %s", name, opts.Key), Exec: command}
+		if err := ensureValidatorTrusted(s.Repo, hook, opts.TrustValidators, deps); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Rotate %s in %d environments?", opts.Key, len(state.Steps)-state.done()), false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	newValue, err := rotationValue(s, state, rule, opts)
+	if err != nil {
+		return err
+	}
+	if !inProgress {
+		state.StartedAt = time.Now().UTC()
+	}
+	if err := saveRotation(state, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the rotation: %s", err.Error()))
+		return err
+	}
+
+	oldValues := make(map[int]string)
+	for i := range state.Steps {
+		if err := runRotationStep(s, &state, i, newValue, rule, oldValues); err != nil {
+			_ = saveRotation(state, deps)
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d of %d environments have the new value. Fix the problem, then go on with: keyway rotation plan %s --resume", state.done(), len(state.Steps), opts.Key)))
+			return err
+		}
+		if err := saveRotation(state, deps); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to save the rotation: %s", err.Error()))
+			return err
+		}
+	}
+
+	if err := revokeOldValues(s, state, newValue, rule, oldValues); err != nil {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Every environment has the new value. Revoke again with: keyway rotation plan %s --resume", opts.Key)))
+		return err
+	}
+	if err := removeRotation(id, deps); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Failed to remove the rotation: %s", err.Error()))
+	}
+
+	analytics.Track("cli_rotation", map[string]interface{}{
+		"org":          org,
+		"environments": len(state.Steps),
+		"verified":     len(rule.Verify) > 0,
+		"revoked":      len(rule.Revoke) > 0,
+		"resumed":      inProgress,
+	})
+	deps.UI.Success(fmt.Sprintf("Rotated %s in %d environments", opts.Key, len(state.Steps)))
+	return nil
+}
+
+// newRotation orders the environments holding a key: non-production ones
+// first, so that a bad value is caught before it reaches production
+func newRotation(org, key string, locations []api.KeyLocation) rotationState {
+	state := rotationState{Org: org, Key: key}
+	seen := make(map[string]bool, len(locations))
+	for _, l := range locations {
+		step := rotationStep{Repo: l.Repo, Environment: l.Environment}
+		if seen[step.label()] {
+			continue
+		}
+		seen[step.label()] = true
+		state.Steps = append(state.Steps, step)
+	}
+	sort.SliceStable(state.Steps, func(i, j int) bool {
+		a, b := state.Steps[i], state.Steps[j]
+		if pa, pb := env.IsProductionEnv(a.Environment), env.IsProductionEnv(b.Environment); pa != pb {
+			return pb
+		}
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		return a.Environment < b.Environment
+	})
+	return state
+}
+
+// printRotationPlan shows the steps of a rotation and where it is
+func printRotationPlan(state rotationState, rule config.RotationRule, deps *Dependencies) {
+	deps.UI.Message("")
+	deps.UI.Message(fmt.Sprintf("Rotation of %s in %d environments:", deps.UI.Bold(state.Key), len(state.Steps)))
+	for i, step := range state.Steps {
+		status := ""
+		switch {
+		case step.Verified:
+			status = " (done)"
+		case step.Pushed:
+			status = " (new value pushed, not verified)"
+		}
+		deps.UI.Message(fmt.Sprintf("  %d. %s%s", i+1, step.label(), deps.UI.Dim(status)))
+	}
+	if len(rule.Verify) > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("  Each is verified with: %s", strings.Join(rule.Verify, " "))))
+	}
+	if len(rule.Revoke) > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("  Then the old value is revoked with: %s", strings.Join(rule.Revoke, " "))))
+	}
+	deps.UI.Message("")
+}
+
+// checkRotationSteps checks that every environment left can be written
+// before the first one changes
+func checkRotationSteps(s *Session, state rotationState, deps *Dependencies) error {
+	checked := make(map[string]bool)
+	for _, step := range state.Steps {
+		if step.Verified {
+			continue
+		}
+		if !checked[step.Repo] {
+			if err := requirePermission(s.Ctx, s.Client, step.Repo, api.PermissionWrite, "rotating "+state.Key, deps); err != nil {
+				return err
+			}
+			checked[step.Repo] = true
+		}
+		if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, step.Repo, step.Environment, deps); err != nil {
+			return err
+		}
+		if err := checkEnvironmentPolicy(s.Ctx, s.Client, step.Repo, step.Environment, deps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotationValue returns the new value: when resuming, the one already pushed,
+// else generated or asked for
+func rotationValue(s *Session, state rotationState, rule config.RotationRule, opts RotationOptions) (string, error) {
+	deps := s.Deps
+	for _, step := range state.Steps {
+		if !step.Pushed {
+			continue
+		}
+		var value string
+		var found bool
+		err := s.Spin(fmt.Sprintf("Reading the new value from %s...", step.label()), func() error {
+			resp, err := s.Client.PullSecrets(s.Ctx, step.Repo, step.Environment)
+			if err != nil {
+				return err
+			}
+			value, found = env.ParseVault(resp.Content)[state.Key]
+			return nil
+		})
+		if err != nil {
+			return "", reportEnvError("rotation plan", err, deps)
+		}
+		if !found {
+			err := fmt.Errorf("%s no longer holds %s", step.label(), state.Key)
+			deps.UI.Error(err.Error())
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Start over with: keyway rotation plan %s --abandon", state.Key)))
+			return "", err
+		}
+		return value, nil
+	}
+
+	spec := opts.Generate
+	if spec == "" {
+		spec = rule.Generate
+	}
+	if spec != "" {
+		value, err := env.Generate(spec)
+		if err != nil {
+			deps.UI.Error(err.Error())
+		}
+		return value, err
+	}
+	if !deps.UI.IsInteractive() {
+		deps.UI.Error(fmt.Sprintf("No way to make the new value of %s", state.Key))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Use --generate, or a generate rule for %s in %s", state.Key, config.ProjectFile)))
+		return "", fmt.Errorf("no new value for %s", state.Key)
+	}
+	value, err := deps.UI.Password(fmt.Sprintf("New value of %s:", state.Key))
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		deps.UI.Error("The new value cannot be empty")
+		return "", fmt.Errorf("empty value")
+	}
+	return value, nil
+}
+
+// runRotationStep pushes the new value to an environment and verifies it,
+// keeping the old value for the revoke command
+func runRotationStep(s *Session, state *rotationState, i int, newValue string, rule config.RotationRule, oldValues map[int]string) error {
+	deps := s.Deps
+	step := &state.Steps[i]
+	if step.Verified {
+		return nil
+	}
+
+	var secrets map[string]string
+	err := s.Spin(fmt.Sprintf("Pushing to %s...", step.label()), func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, step.Repo, step.Environment)
+		if err != nil {
+			return err
+		}
+		secrets = env.ParseVault(resp.Content)
+		if secrets[state.Key] == newValue {
+			// Pushed before the rotation stopped
+			step.Pushed = true
+			return nil
+		}
+		oldValues[i] = secrets[state.Key]
+		step.PreviousVersion = resp.Version
+		secrets[state.Key] = newValue
+		if _, err := s.Client.PushSecrets(s.Ctx, step.Repo, step.Environment, secrets, uuid.NewString()); err != nil {
+			return err
+		}
+		step.Pushed = true
+		return nil
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to push to %s: %s", step.label(), err.Error()))
+		return err
+	}
+	forgetPrefetched(step.Repo, step.Environment, deps)
+
+	if len(rule.Verify) > 0 {
+		hookEnv := make(map[string]string, len(secrets)+2)
+		for k, v := range secrets {
+			hookEnv[k] = v
+		}
+		hookEnv["KEYWAY_ROTATION_REPO"] = step.Repo
+		hookEnv["KEYWAY_ROTATION_ENV"] = step.Environment
+		deps.UI.Step(fmt.Sprintf("Verifying %s", step.label()))
+		if err := deps.CmdRunner.RunCommand(rule.Verify[0], rule.Verify[1:], hookEnv); err != nil {
+			deps.UI.Error(fmt.Sprintf("Verification of %s failed: %s", step.label(), err.Error()))
+			if step.PreviousVersion > 0 {
+				deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Put the old value back with: keyway rollback v%d -e %s (from %s)", step.PreviousVersion, step.Environment, step.Repo)))
+			}
+			return err
+		}
+	}
+	step.Verified = true
+	deps.UI.Success(fmt.Sprintf("%s has the new value", step.label()))
+	return nil
+}
+
+// revokeOldValues runs the revoke command once per old value, reading the old
+// values of environments rotated by an earlier run from their history
+func revokeOldValues(s *Session, state rotationState, newValue string, rule config.RotationRule, oldValues map[int]string) error {
+	deps := s.Deps
+	if len(rule.Revoke) == 0 {
+		deps.UI.Warn(fmt.Sprintf("No revoke command for %s in %s, revoke the old value where it was issued", state.Key, config.ProjectFile))
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var olds []string
+	for i, step := range state.Steps {
+		old, ok := oldValues[i]
+		if !ok && step.PreviousVersion > 0 {
+			resp, err := s.Client.PullSecretsAt(s.Ctx, step.Repo, step.Environment, api.Revision{Version: step.PreviousVersion})
+			if err == nil {
+				old, ok = env.ParseVault(resp.Content)[state.Key]
+			}
+		}
+		if !ok {
+			if step.PreviousVersion > 0 {
+				deps.UI.Warn(fmt.Sprintf("The old value of %s is not available, revoke it by hand", step.label()))
+			}
+			continue
+		}
+		if old == "" || old == newValue || seen[old] {
+			continue
+		}
+		seen[old] = true
+		olds = append(olds, old)
+	}
+
+	for n, old := range olds {
+		deps.UI.Step(fmt.Sprintf("Revoking old value %d of %d", n+1, len(olds)))
+		hookEnv := map[string]string{
+			"KEYWAY_ROTATION_KEY": state.Key,
+			"KEYWAY_OLD_VALUE":    old,
+		}
+		if err := deps.CmdRunner.RunCommand(rule.Revoke[0], rule.Revoke[1:], hookEnv); err != nil {
+			deps.UI.Error(fmt.Sprintf("Revoking the old value of %s failed: %s", state.Key, err.Error()))
+			return err
+		}
+	}
+	return nil
+}
+
+// rotationsPath returns the file holding the rotations in progress
+func rotationsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "rotations.json")
+}
+
+// loadRotations returns the rotations in progress, by organization and key
+func loadRotations(deps *Dependencies) map[string]rotationState {
+	rotations := make(map[string]rotationState)
+	path := rotationsPath()
+	if path == "" {
+		return rotations
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &rotations)
+	}
+	return rotations
+}
+
+// saveRotation stores a rotation in progress
+func saveRotation(state rotationState, deps *Dependencies) error {
+	rotations := loadRotations(deps)
+	rotations[state.Org+":"+state.Key] = state
+	return writeRotations(rotations, deps)
+}
+
+// removeRotation forgets a rotation
+func removeRotation(id string, deps *Dependencies) error {
+	rotations := loadRotations(deps)
+	delete(rotations, id)
+	return writeRotations(rotations, deps)
+}
+
+// writeRotations writes the rotations in progress
+func writeRotations(rotations map[string]rotationState, deps *Dependencies) error {
+	path := rotationsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rotations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
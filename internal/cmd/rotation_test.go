@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// rotationProject declares verify and revoke commands for STRIPE_KEY
+const rotationProject = `{"rotation": {"STRIPE_KEY": {"verify": ["./check"], "revoke": ["./revoke"]}}}`
+
+func TestNewRotation_ProductionLast(t *testing.T) {
+	state := newRotation("acme", "STRIPE_KEY", []api.KeyLocation{
+		{Repo: "acme/api", Environment: "production"},
+		{Repo: "acme/worker", Environment: "staging"},
+		{Repo: "acme/api", Environment: "development"},
+		{Repo: "acme/worker", Environment: "staging"},
+	})
+
+	var got []string
+	for _, step := range state.Steps {
+		got = append(got, step.label())
+	}
+	want := []string{"acme/api · development", "acme/worker · staging", "acme/api · production"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected order: %v", got)
+	}
+}
+
+func TestRunRotationPlanWithDeps_RotatesEverywhere(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	fsMock.Files[".keyway.json"] = []byte(rotationProject)
+	apiMock.KeyLocations = []api.KeyLocation{
+		{Repo: "acme/api", Environment: "production"},
+		{Repo: "acme/worker", Environment: "staging"},
+	}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=old\nOTHER=1\n", Version: 4}
+	var calls []map[string]string
+	runner.OnRun = func(secrets map[string]string) { calls = append(calls, secrets) }
+
+	opts := RotationOptions{Key: "STRIPE_KEY", Generate: "hex:16", TrustValidators: true, Yes: true}
+	if err := runRotationPlanWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	staging, production := apiMock.PushedByEnv["staging"], apiMock.PushedByEnv["production"]
+	if staging["STRIPE_KEY"] == "old" || len(staging["STRIPE_KEY"]) != 32 || production["STRIPE_KEY"] != staging["STRIPE_KEY"] {
+		t.Errorf("expected the same new value everywhere, got %v and %v", staging, production)
+	}
+	if staging["OTHER"] != "1" {
+		t.Errorf("expected the other keys to be kept, got %v", staging)
+	}
+	// Two verifications, then one revoke for the single old value
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(calls))
+	}
+	if calls[0]["KEYWAY_ROTATION_ENV"] != "staging" || calls[1]["KEYWAY_ROTATION_ENV"] != "production" {
+		t.Errorf("expected staging to be verified before production, got %v", calls[:2])
+	}
+	if runner.LastCommand != "./revoke" || calls[2]["KEYWAY_OLD_VALUE"] != "old" {
+		t.Errorf("expected the old value to be revoked, got %s %v", runner.LastCommand, calls[2])
+	}
+
+	var rotations map[string]rotationState
+	_ = json.Unmarshal(fsMock.Written[rotationsPath()], &rotations)
+	if len(rotations) != 0 {
+		t.Errorf("expected the rotation to be forgotten, got %v", rotations)
+	}
+}
+
+func TestRunRotationPlanWithDeps_StopsWhenVerifyFails(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	runner.RunError = errors.New("exit status 1")
+	fsMock.Files[".keyway.json"] = []byte(rotationProject)
+	apiMock.KeyLocations = []api.KeyLocation{
+		{Repo: "acme/api", Environment: "production"},
+		{Repo: "acme/worker", Environment: "staging"},
+	}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=old\n", Version: 4}
+
+	opts := RotationOptions{Key: "STRIPE_KEY", Org: "acme", Generate: "uuid", TrustValidators: true, Yes: true}
+	if err := runRotationPlanWithDeps(opts, deps); err == nil {
+		t.Fatal("expected an error when the verification fails")
+	}
+	if _, ok := apiMock.PushedByEnv["production"]; ok {
+		t.Error("production must not be rotated after staging failed")
+	}
+
+	var rotations map[string]rotationState
+	_ = json.Unmarshal(fsMock.Written[rotationsPath()], &rotations)
+	state := rotations["acme:STRIPE_KEY"]
+	if len(state.Steps) != 2 || !state.Steps[0].Pushed || state.Steps[0].Verified || state.Steps[0].PreviousVersion != 4 {
+		t.Errorf("expected the rotation to stop at staging, got %+v", state)
+	}
+}
+
+func TestRunRotationPlanWithDeps_Resume(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	runner := deps.CmdRunner.(*MockCommandRunner)
+	fsMock.Files[".keyway.json"] = []byte(rotationProject)
+	stored := map[string]rotationState{"acme:STRIPE_KEY": {Org: "acme", Key: "STRIPE_KEY", Steps: []rotationStep{
+		{Repo: "acme/worker", Environment: "staging", PreviousVersion: 4, Pushed: true, Verified: true},
+		{Repo: "acme/api", Environment: "production"},
+	}}}
+	data, _ := json.Marshal(stored)
+	fsMock.Files[rotationsPath()] = data
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "STRIPE_KEY=new\n"}, // staging, for the new value
+		{Content: "STRIPE_KEY=old\n"}, // production
+	}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{4: {Content: "STRIPE_KEY=older\n"}}
+
+	// Resuming needs --resume
+	if err := runRotationPlanWithDeps(RotationOptions{Key: "STRIPE_KEY", Org: "acme", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for a rotation in progress")
+	}
+
+	opts := RotationOptions{Key: "STRIPE_KEY", Org: "acme", Resume: true, TrustValidators: true, Yes: true}
+	if err := runRotationPlanWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := apiMock.PushedByEnv["staging"]; ok {
+		t.Error("staging was already rotated")
+	}
+	if apiMock.PushedByEnv["production"]["STRIPE_KEY"] != "new" {
+		t.Errorf("expected production to get the value pushed to staging, got %v", apiMock.PushedByEnv)
+	}
+	if runner.LastCommand != "./revoke" {
+		t.Errorf("expected the old values to be revoked, last command %q", runner.LastCommand)
+	}
+}
+
+func TestRunRotationPlanWithDeps_DryRun(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.KeyLocations = []api.KeyLocation{{Repo: "acme/api", Environment: "production"}}
+
+	if err := runRotationPlanWithDeps(RotationOptions{Key: "STRIPE_KEY", Org: "acme", DryRun: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("a dry run must not push")
+	}
+}
+
+func TestRunRotationPlanWithDeps_NoNewValue(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.KeyLocations = []api.KeyLocation{{Repo: "acme/api", Environment: "staging"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=old\n"}
+
+	if err := runRotationPlanWithDeps(RotationOptions{Key: "STRIPE_KEY", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error without a way to make the new value")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
+
+func TestRunRotationPlanWithDeps_NotFound(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runRotationPlanWithDeps(RotationOptions{Key: "STRIPE_KEY", Org: "acme", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error when no vault holds the key")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "No vault of acme holds STRIPE_KEY" {
+		t.Errorf("unexpected error: %v", uiMock.ErrorCalls)
+	}
+}
@@ -3,9 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/injector"
 	"github.com/spf13/cobra"
 )
 
@@ -16,26 +19,79 @@ var runCmd = &cobra.Command{
 Secrets are fetched from the vault and injected directly into the process memory.
 They are never written to disk.
 
+With --reload-on-change, keyway follows the vault and reloads the command's
+secrets when they change, without restarting it: the new secrets are written
+to the file named by KEYWAY_RELOAD_FILE, then --reload-signal is sent to the
+command. See "Live reload" in the README for the contract.
+
+With --watch, keyway restarts the command when its secrets change instead, for
+commands that only read their environment at startup: the command is asked to
+stop (SIGTERM), killed if it still runs after --stop-timeout, then started
+again with the new secrets.
+
+The sources of .keyway.json, when declared, compose the secrets from the
+process environment, local files, other vault environments and defaults, the
+first source having a key winning. See "Sources" in the README.
+
+With --expand, ${VAR} references in values are resolved from the other keys,
+then from the environment of keyway, e.g. DATABASE_URL=postgres://${DB_USER}@db/app.
+
 This is particularly useful for:
 - Running local development servers without .env files
 - CI/CD pipelines
 - Using AI agents (Claude Code, Gemini CLI, Codex) safely: the agent runs the command but cannot see the secrets on disk.`,
-	Example: `  keyway run --env development -- npm run dev
-  keyway run --env development -- python3 main.py
-  keyway run --env production -- ./deploy.sh`,
 	RunE: runRunCmd,
 }
 
 func init() {
 	runCmd.Flags().StringP("env", "e", "development", "Environment name")
+	runCmd.Flags().Bool("reload-on-change", false, "Reload the command's secrets when they change in the vault")
+	runCmd.Flags().String("reload-signal", "SIGHUP", "Signal sent to the command after a reload (SIGHUP, SIGUSR1 or SIGUSR2)")
+	runCmd.Flags().Bool("watch", false, "Restart the command when its secrets change in the vault")
+	runCmd.Flags().Duration("stop-timeout", 10*time.Second, "How long to wait for the command to stop before killing it (with --watch)")
+	runCmd.Flags().String("remap", "", "Rename keys with a remap declared in .keyway.json")
+	runCmd.Flags().Bool("expand", false, "Resolve ${VAR} references in values")
+	runCmd.Flags().Bool("no-cache", false, "Fetch the secrets even when keyway prefetch cached them")
 }
 
+// runSecrets parses the vault's content into the secrets given to a command
+func runSecrets(content, remap string, expand bool, deps *Dependencies) (map[string]string, error) {
+	return commandSecrets(env.ParseVault(content), remap, expand, deps)
+}
+
+// commandSecrets expands the secrets, recomputes derived keys and renames
+// them for a command
+func commandSecrets(secrets map[string]string, remap string, expand bool, deps *Dependencies) (map[string]string, error) {
+	secrets, err := applyExpand(secrets, expand, deps)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err = applyDerived(secrets, deps)
+	if err != nil {
+		return nil, err
+	}
+	return applyRemap(secrets, remap, deps)
+}
+
+// runReconnectDelay is how long run --reload-on-change waits before following
+// the vault again after the connection was lost
+var runReconnectDelay = 3 * time.Second
+
 // RunOptions contains the parsed flags for the run command
 type RunOptions struct {
 	EnvName    string
 	EnvFlagSet bool
 	Command    string
 	Args       []string
+	Remap      string
+	Expand     bool
+	NoCache    bool
+
+	ReloadOnChange bool
+	ReloadSignal   string
+
+	Watch       bool
+	StopTimeout time.Duration
 }
 
 // runRunCmd is the entry point for the run command (uses default dependencies)
@@ -50,36 +106,68 @@ func runRunCmd(cmd *cobra.Command, args []string) error {
 		Args:       args[1:],
 	}
 	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Remap, _ = cmd.Flags().GetString("remap")
+	opts.Expand, _ = cmd.Flags().GetBool("expand")
+	opts.NoCache, _ = cmd.Flags().GetBool("no-cache")
+	opts.ReloadOnChange, _ = cmd.Flags().GetBool("reload-on-change")
+	opts.ReloadSignal, _ = cmd.Flags().GetString("reload-signal")
+	if cmd.Flags().Changed("reload-signal") && !opts.ReloadOnChange {
+		return fmt.Errorf("--reload-signal requires --reload-on-change")
+	}
+	opts.Watch, _ = cmd.Flags().GetBool("watch")
+	opts.StopTimeout, _ = cmd.Flags().GetDuration("stop-timeout")
+	if cmd.Flags().Changed("stop-timeout") && !opts.Watch {
+		return fmt.Errorf("--stop-timeout requires --watch")
+	}
 
 	return runRunWithDeps(opts, defaultDeps)
 }
 
 // runRunWithDeps is the testable version of runRun
 func runRunWithDeps(opts RunOptions, deps *Dependencies) error {
-	// 1. Detect Repo
-	repo, err := deps.Git.DetectRepo()
-	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
-		return err
+	if opts.Watch && opts.ReloadOnChange {
+		deps.UI.Error("--watch restarts the command, --reload-on-change reloads it: use one of them")
+		return fmt.Errorf("--watch and --reload-on-change cannot be used together")
+	}
+	if opts.Watch && opts.StopTimeout <= 0 {
+		opts.StopTimeout = 10 * time.Second
+	}
+
+	var reloadSignal os.Signal
+	if opts.ReloadOnChange {
+		if opts.ReloadSignal == "" {
+			opts.ReloadSignal = "SIGHUP"
+		}
+		sig, name, err := injector.ParseReloadSignal(opts.ReloadSignal)
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		reloadSignal, opts.ReloadSignal = sig, name
 	}
 
-	// 2. Ensure Login
-	token, err := deps.Auth.EnsureLogin()
+	hostLabel, err := checkHostEnvironment(opts.EnvName, opts.EnvFlagSet, deps)
 	if err != nil {
-		deps.UI.Error(err.Error())
 		return err
 	}
 
-	// 3. Setup Client
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
+	return runPipeline(deps, func(s *Session) error {
+		return runWithSecrets(s, opts, hostLabel, reloadSignal)
+	}, withRepo, withLogin)
+}
+
+// runWithSecrets runs the command with the secrets of the environment, and
+// with --watch or --reload-on-change follows the environment until it exits
+func runWithSecrets(s *Session, opts RunOptions, hostLabel string, reloadSignal os.Signal) error {
+	deps := s.Deps
+	repo := s.Repo
 
 	// 4. Determine Environment
 	envName := opts.EnvName
 
 	if !opts.EnvFlagSet && deps.UI.IsInteractive() {
 		// Fetch available environments
-		vaultEnvs, err := client.GetVaultEnvironments(ctx, repo)
+		vaultEnvs, err := s.Client.GetVaultEnvironments(s.Ctx, repo)
 		if err != nil || len(vaultEnvs) == 0 {
 			vaultEnvs = []string{"development", "staging", "production"}
 		}
@@ -107,30 +195,248 @@ func runRunWithDeps(opts RunOptions, deps *Dependencies) error {
 
 	deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(envName)))
 
-	// 5. Fetch Secrets
+	if hostLabel != "" {
+		recordHostInvocation(s.Ctx, s.Client, repo, envName, hostLabel, "run", opts.Command, deps)
+	}
+
+	// 5. Fetch Secrets, unless keyway prefetch cached them recently. Labeled
+	// hosts always fetch them.
 	var vaultContent string
-	err = deps.UI.Spin("Fetching secrets...", func() error {
-		resp, err := client.PullSecrets(ctx, repo, envName)
+	cached := false
+	if !opts.NoCache && hostLabel == "" {
+		vaultContent, cached = prefetchedContent(repo, envName, deps)
+	}
+	if !cached {
+		err := s.Spin("Fetching secrets...", func() error {
+			resp, err := s.Client.PullSecrets(s.Ctx, repo, envName)
+			if err != nil {
+				return err
+			}
+			vaultContent = resp.Content
+			return nil
+		})
+
 		if err != nil {
+			if apiErr, ok := err.(*api.APIError); ok {
+				deps.UI.Error(apiErr.Error())
+			} else {
+				deps.UI.Error(err.Error())
+			}
+			explainMovedVault(s.Ctx, s.Client, repo, err, deps)
 			return err
 		}
-		vaultContent = resp.Content
-		return nil
-	})
+	}
 
+	// 6. Parse Secrets, compose them with the sources of .keyway.json,
+	// recompute derived keys and rename them for the command
+	sourcesStep(envName, deps)
+	secrets, err := runSourceSecrets(s.Ctx, s.Client, repo, envName, vaultContent, opts, deps)
 	if err != nil {
-		if apiErr, ok := err.(*api.APIError); ok {
-			deps.UI.Error(apiErr.Error())
-		} else {
-			deps.UI.Error(err.Error())
-		}
+		deps.UI.Error(err.Error())
+		return err
+	}
+	// Files are written to a private temp dir, their variables hold the paths
+	secrets, cleanupFiles, err := injector.WriteFiles(secrets)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write file secrets: %s", err.Error()))
 		return err
 	}
+	defer func() { cleanupFiles() }()
 
-	// 6. Parse Secrets
-	secrets := env.Parse(vaultContent)
 	deps.UI.Success(fmt.Sprintf("Injected %d secrets", len(secrets)))
 
 	// 7. Execute Command
-	return deps.CmdRunner.RunCommand(opts.Command, opts.Args, secrets)
-}
\ No newline at end of file
+	if opts.Watch {
+		proc, err := deps.CmdRunner.StartCommand(opts.Command, opts.Args, secrets)
+		if err != nil {
+			deps.UI.Error(err.Error())
+			return err
+		}
+		done := waitCommand(proc)
+
+		// 8. Restart the command when the environment changes, until it exits
+		watchCtx, stopWatching := context.WithCancel(s.Ctx)
+		defer stopWatching()
+		changes := make(chan struct{}, 1)
+		watchErrs := make(chan error, 1)
+		go watchEnvironment(watchCtx, s.Client, repo, envName, changes, watchErrs)
+
+		for {
+			select {
+			case err := <-done:
+				return err
+
+			case err := <-watchErrs:
+				deps.UI.Warn(fmt.Sprintf("Stopped following %s, the command will not be restarted: %s", envName, err.Error()))
+
+			case <-changes:
+				content, next, cleanupNext, err := reloadSecrets(s.Ctx, s.Client, repo, envName, vaultContent, opts, deps)
+				if err != nil {
+					deps.UI.Warn(fmt.Sprintf("Could not reload secrets, %s keeps running: %s", opts.Command, err.Error()))
+					continue
+				}
+				if next == nil {
+					continue
+				}
+
+				deps.UI.Info(fmt.Sprintf("Secrets changed in %s, restarting %s...", envName, opts.Command))
+				stopCommand(proc, done, opts.StopTimeout, deps)
+				cleanupFiles()
+				cleanupFiles = cleanupNext
+				vaultContent = content
+
+				proc, err = deps.CmdRunner.StartCommand(opts.Command, opts.Args, next)
+				if err != nil {
+					deps.UI.Error(err.Error())
+					return err
+				}
+				done = waitCommand(proc)
+				deps.UI.Success(fmt.Sprintf("Restarted %s with %d secrets", opts.Command, len(next)))
+			}
+		}
+	}
+	if !opts.ReloadOnChange {
+		return deps.CmdRunner.RunCommand(opts.Command, opts.Args, secrets)
+	}
+
+	reloadFile, err := injector.NewReloadFile(secrets)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write the reload file: %s", err.Error()))
+		return err
+	}
+	defer reloadFile.Close()
+
+	cmdEnv := make(map[string]string, len(secrets)+2)
+	for k, v := range secrets {
+		cmdEnv[k] = v
+	}
+	cmdEnv[injector.ReloadFileEnv] = reloadFile.Path()
+	cmdEnv[injector.ReloadSignalEnv] = opts.ReloadSignal
+
+	proc, err := deps.CmdRunner.StartCommand(opts.Command, opts.Args, cmdEnv)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	done := waitCommand(proc)
+
+	// 8. Reload the secrets when the environment changes, until the command exits
+	watchCtx, stopWatching := context.WithCancel(s.Ctx)
+	defer stopWatching()
+	changes := make(chan struct{}, 1)
+	watchErrs := make(chan error, 1)
+	go watchEnvironment(watchCtx, s.Client, repo, envName, changes, watchErrs)
+
+	for {
+		select {
+		case err := <-done:
+			return err
+
+		case err := <-watchErrs:
+			deps.UI.Warn(fmt.Sprintf("Stopped following %s, secrets will not be reloaded: %s", envName, err.Error()))
+
+		case <-changes:
+			content, next, cleanupNext, err := reloadSecrets(s.Ctx, s.Client, repo, envName, vaultContent, opts, deps)
+			if err != nil {
+				deps.UI.Warn(fmt.Sprintf("Could not reload secrets: %s", err.Error()))
+				continue
+			}
+			if next == nil {
+				continue
+			}
+			if err := reloadFile.Write(next); err != nil {
+				cleanupNext()
+				deps.UI.Warn(fmt.Sprintf("Could not reload secrets: %s", err.Error()))
+				continue
+			}
+			cleanupFiles()
+			cleanupFiles = cleanupNext
+			vaultContent = content
+
+			if err := proc.Signal(reloadSignal); err != nil {
+				deps.UI.Warn(fmt.Sprintf("Could not send %s: %s", opts.ReloadSignal, err.Error()))
+				continue
+			}
+			deps.UI.Info(fmt.Sprintf("Secrets changed in %s, reloaded (version %d) and sent %s", envName, reloadFile.Version(), opts.ReloadSignal))
+		}
+	}
+}
+
+// reloadSecrets pulls an environment after a change, and returns its content
+// and the secrets for the command, their files written. The secrets are nil
+// when the content is still current.
+func reloadSecrets(ctx context.Context, client api.APIClient, repo, envName, current string, opts RunOptions, deps *Dependencies) (string, map[string]string, func(), error) {
+	resp, err := client.PullSecrets(ctx, repo, envName)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if resp.Content == current {
+		return current, nil, nil, nil
+	}
+	refreshPrefetched(repo, envName, resp, deps)
+	secrets, err := runSourceSecrets(ctx, client, repo, envName, resp.Content, opts, deps)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	secrets, cleanup, err := injector.WriteFiles(secrets)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return resp.Content, secrets, cleanup, nil
+}
+
+// waitCommand returns a channel receiving the result of the command's Wait
+func waitCommand(proc RunningCommand) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- proc.Wait() }()
+	return done
+}
+
+// stopCommand asks a command to exit and waits for it, killing it if it still
+// runs after timeout
+func stopCommand(proc RunningCommand, done <-chan error, timeout time.Duration, deps *Dependencies) {
+	if err := proc.Stop(injector.StopSignal); err != nil {
+		// Already exited
+		<-done
+		return
+	}
+	select {
+	case <-done:
+		return
+	case <-time.After(timeout):
+	}
+	deps.UI.Warn(fmt.Sprintf("The command did not stop within %s, killing it", timeout))
+	_ = proc.Signal(os.Kill)
+	<-done
+}
+
+// watchEnvironment follows the events of an environment and signals changes
+// until ctx is done. Errors the stream cannot recover from are sent to errs,
+// and end the watch.
+func watchEnvironment(ctx context.Context, client api.APIClient, repo, envName string, changes chan<- struct{}, errs chan<- error) {
+	filter := api.EventFilter{Environment: envName}
+	lastID := ""
+	for {
+		err := client.StreamVaultEvents(ctx, repo, filter, lastID, func(event api.VaultEvent) error {
+			lastID = event.ID
+			select {
+			case changes <- struct{}{}:
+			default: // A reload is already pending
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode < 500 {
+			errs <- apiErr
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(runReconnectDelay):
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/injector"
 )
 
 func TestRunRunWithDeps_Success(t *testing.T) {
@@ -235,3 +243,245 @@ func TestRunRunWithDeps_MultipleArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestRunRunWithDeps_ReloadOnChange(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("reload signals are not supported on Windows")
+	}
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "API_KEY=old"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=rotated"}
+	apiMock.StreamEvents = []api.VaultEvent{{ID: "1", Type: "secrets.pushed", Environment: "staging", Keys: []string{"API_KEY"}}}
+	cmdRunner.WaitForSignal = true
+
+	var state injector.ReloadState
+	cmdRunner.OnSignal = func(sig os.Signal) {
+		data, err := os.ReadFile(cmdRunner.LastSecrets[injector.ReloadFileEnv])
+		if err != nil {
+			t.Errorf("expected the reload file to be readable: %v", err)
+			return
+		}
+		_ = json.Unmarshal(data, &state)
+	}
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", ReloadOnChange: true, ReloadSignal: "hup"}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want, _, _ := injector.ParseReloadSignal("SIGHUP")
+	if len(cmdRunner.Signals) != 1 || cmdRunner.Signals[0] != want {
+		t.Fatalf("expected one SIGHUP, got %v", cmdRunner.Signals)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "old" || cmdRunner.LastSecrets[injector.ReloadSignalEnv] != "SIGHUP" {
+		t.Errorf("unexpected command environment %v", cmdRunner.LastSecrets)
+	}
+	if state.Version != 2 || state.Secrets["API_KEY"] != "rotated" {
+		t.Errorf("expected version 2 with the rotated secret, got %+v", state)
+	}
+	if apiMock.EventFilter.Environment != "staging" {
+		t.Errorf("expected to follow staging, got %q", apiMock.EventFilter.Environment)
+	}
+	if len(uiMock.InfoCalls) == 0 || !strings.Contains(uiMock.InfoCalls[len(uiMock.InfoCalls)-1], "version 2") {
+		t.Errorf("expected the reload to be reported, got %v", uiMock.InfoCalls)
+	}
+	if _, err := os.Stat(cmdRunner.LastSecrets[injector.ReloadFileEnv]); !os.IsNotExist(err) {
+		t.Error("expected the reload file to be removed when the command exits")
+	}
+}
+
+func TestRunRunWithDeps_InvalidReloadSignal(t *testing.T) {
+	deps, _, _, _, cmdRunner, _ := NewTestDepsWithRunner()
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", ReloadOnChange: true, ReloadSignal: "SIGKILL"}, deps)
+
+	if err == nil {
+		t.Fatal("expected an error for SIGKILL")
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected the command not to run")
+	}
+}
+
+func TestRunRunWithDeps_Watch(t *testing.T) {
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "API_KEY=old"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=rotated"}
+	apiMock.StreamEvents = []api.VaultEvent{{ID: "1", Type: "secrets.pushed", Environment: "staging", Keys: []string{"API_KEY"}}}
+	cmdRunner.WaitForSignal = true
+	var started []map[string]string
+	cmdRunner.OnRun = func(secrets map[string]string) {
+		started = append(started, secrets)
+		// The restarted command exits by itself, which ends keyway run
+		cmdRunner.WaitForSignal = len(started) == 1
+	}
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", Watch: true}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(started) != 2 || started[0]["API_KEY"] != "old" || started[1]["API_KEY"] != "rotated" {
+		t.Fatalf("expected the command to be restarted with the rotated secret, got %v", started)
+	}
+	if len(cmdRunner.Signals) != 1 || cmdRunner.Signals[0] != injector.StopSignal {
+		t.Errorf("expected the command to be stopped once, got %v", cmdRunner.Signals)
+	}
+	if _, ok := started[0][injector.ReloadFileEnv]; ok {
+		t.Error("a restarted command has no reload file")
+	}
+	if !slices.Contains(uiMock.SuccessCalls, "Restarted ./server with 1 secrets") {
+		t.Errorf("expected the restart to be reported, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunRunWithDeps_WatchKillsAfterTimeout(t *testing.T) {
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "API_KEY=old"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=rotated"}
+	apiMock.StreamEvents = []api.VaultEvent{{ID: "1", Type: "secrets.pushed", Environment: "staging"}}
+	cmdRunner.WaitForSignal = true
+	cmdRunner.IgnoreStop = true
+	cmdRunner.OnRun = func(secrets map[string]string) {
+		cmdRunner.WaitForSignal = cmdRunner.Starts == 0
+	}
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", Watch: true, StopTimeout: time.Millisecond}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cmdRunner.Signals) != 2 || cmdRunner.Signals[0] != injector.StopSignal || cmdRunner.Signals[1] != os.Kill {
+		t.Errorf("expected the command to be killed after the timeout, got %v", cmdRunner.Signals)
+	}
+	if cmdRunner.Starts != 2 {
+		t.Errorf("expected the command to be restarted, got %d starts", cmdRunner.Starts)
+	}
+	if len(uiMock.WarnCalls) == 0 || !strings.Contains(uiMock.WarnCalls[0], "killing it") {
+		t.Errorf("expected the kill to be reported, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunRunWithDeps_WatchWithReloadOnChange(t *testing.T) {
+	deps, _, _, _, cmdRunner, _ := NewTestDepsWithRunner()
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", Watch: true, ReloadOnChange: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected an error for --watch with --reload-on-change")
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected the command not to run")
+	}
+}
+
+func TestRunRunWithDeps_Sources(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	fsMock := deps.FS.(*MockFileSystem)
+	fsMock.Files[".keyway.json"] = []byte(`{"sources": [
+		{"process": true},
+		{"file": ".env.local", "optional": true},
+		{"vault": "{env}"},
+		{"vault": "shared"},
+		{"defaults": {"LOG_LEVEL": "info", "REGION": "us"}}
+	]}`)
+	fsMock.Files[".env.local"] = []byte("API_KEY=local\nLOCAL=1\n")
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "API_KEY=vault\nDB=staging\nKEYWAY_TEST_SHELL=vault"},
+		{Content: "DB=shared\nREGION=eu"},
+	}
+	t.Setenv("KEYWAY_TEST_SHELL", "shell")
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", NoCache: true}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{
+		"API_KEY":           "local",
+		"LOCAL":             "1",
+		"DB":                "staging",
+		"REGION":            "eu",
+		"LOG_LEVEL":         "info",
+		"KEYWAY_TEST_SHELL": "shell",
+	}
+	if !reflect.DeepEqual(cmdRunner.LastSecrets, want) {
+		t.Errorf("expected the first source having a key to win, got %v", cmdRunner.LastSecrets)
+	}
+}
+
+func TestRunRunWithDeps_MissingSource(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	deps.FS.(*MockFileSystem).Files[".keyway.json"] = []byte(`{"sources": [{"file": ".env.{env}.local"}, {"vault": "{env}"}]}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=vault"}
+
+	err := runRunWithDeps(RunOptions{EnvName: "staging", EnvFlagSet: true, Command: "./server", NoCache: true}, deps)
+
+	if err == nil || !strings.Contains(err.Error(), ".env.staging.local") {
+		t.Fatalf("expected an error naming the missing file, got %v", err)
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected the command not to run")
+	}
+}
+
+func TestRunRunWithDeps_Remap(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	deps.FS.(*MockFileSystem).Files[".keyway.json"] = []byte(`{"remaps": {"worker": {"DATABASE_URL": "DB_URL", "REDIS_*": "CACHE_*"}}}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{
+		Content: "DATABASE_URL=postgres://db\nREDIS_URL=redis://cache\nAPI_KEY=secret",
+	}
+
+	err := runRunWithDeps(RunOptions{EnvName: "production", EnvFlagSet: true, Command: "./worker", Remap: "worker"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"DB_URL": "postgres://db", "CACHE_URL": "redis://cache", "API_KEY": "secret"}
+	if len(cmdRunner.LastSecrets) != len(want) {
+		t.Fatalf("expected %v, got %v", want, cmdRunner.LastSecrets)
+	}
+	for k, v := range want {
+		if cmdRunner.LastSecrets[k] != v {
+			t.Errorf("expected %s=%s, got %v", k, v, cmdRunner.LastSecrets)
+		}
+	}
+}
+
+func TestRunRunWithDeps_Expand(t *testing.T) {
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DB_USER=app\nDATABASE_URL=postgres://${DB_USER}@db/app"}
+
+	if err := runRunWithDeps(RunOptions{EnvName: "production", EnvFlagSet: true, Command: "./server"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cmdRunner.LastSecrets["DATABASE_URL"]; got != "postgres://${DB_USER}@db/app" {
+		t.Errorf("expected no expansion without --expand, got %q", got)
+	}
+
+	// "expand" in .keyway.json enables it like --expand
+	deps.FS.(*MockFileSystem).Files[".keyway.json"] = []byte(`{"expand": true}`)
+	if err := runRunWithDeps(RunOptions{EnvName: "production", EnvFlagSet: true, Command: "./server"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cmdRunner.LastSecrets["DATABASE_URL"]; got != "postgres://app@db/app" {
+		t.Errorf("expected DATABASE_URL to be expanded, got %q", got)
+	}
+}
+
+func TestRunRunWithDeps_UnknownRemap(t *testing.T) {
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	deps.FS.(*MockFileSystem).Files[".keyway.json"] = []byte(`{"remaps": {"api": {"*": "APP_*"}}}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret"}
+
+	err := runRunWithDeps(RunOptions{EnvName: "production", EnvFlagSet: true, Command: "./worker", Remap: "worker"}, deps)
+	if err == nil {
+		t.Fatal("expected an error for an unknown remap")
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected the command not to run")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.Contains(uiMock.ErrorCalls[0], "api") {
+		t.Errorf("expected the available remaps in the error, got %v", uiMock.ErrorCalls)
+	}
+}
@@ -203,20 +203,13 @@ var scanCmd = &cobra.Command{
 	Long: `Scan files in the current directory (or specified path) for potential
 secret leaks such as API keys, tokens, and passwords.
 
-Uses regex patterns from gitleaks and trufflehog to detect common secrets.
-
-Examples:
-  keyway scan                    # Scan current directory
-  keyway scan ./src              # Scan specific directory
-  keyway scan --json             # Output as JSON (for CI)
-  keyway scan -e test -e mocks   # Exclude additional directories`,
+Uses regex patterns from gitleaks and trufflehog to detect common secrets.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runScan,
 }
 
 func init() {
 	scanCmd.Flags().StringSliceP("exclude", "e", nil, "Additional directories/patterns to exclude")
-	scanCmd.Flags().Bool("json", false, "Output as JSON")
 	scanCmd.Flags().Bool("show-all", false, "Show all matches including potential false positives")
 }
 
@@ -266,7 +259,11 @@ func runScan(cmd *cobra.Command, args []string) error {
 			return scanErr
 		})
 	} else {
-		filesScanned, findings, err = scanDirectory(absPath, allExcludes)
+		err = quietUI{ndjson: true}.Spin("Scanning files...", func() error {
+			var scanErr error
+			filesScanned, findings, scanErr = scanDirectory(absPath, allExcludes)
+			return scanErr
+		})
 	}
 
 	if err != nil {
@@ -417,6 +414,18 @@ func scanFile(path, relPath string) ([]Finding, error) {
 	return findings, scanner.Err()
 }
 
+// secretKind returns the name of the secret pattern a value matches, or "" if
+// it doesn't look like a secret
+func secretKind(key, value string) string {
+	line := fmt.Sprintf("%s=\"%s\"", key, value)
+	for _, pattern := range secretPatterns {
+		if pattern.Regex.MatchString(line) {
+			return pattern.Name
+		}
+	}
+	return ""
+}
+
 // maskSecret masks the middle of a secret, showing only first 4 and last 3 chars
 func maskSecret(secret string) string {
 	if len(secret) <= 10 {
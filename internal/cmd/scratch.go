@@ -0,0 +1,642 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var envScratchCmd = &cobra.Command{
+	Use:   "scratch",
+	Short: "Experiment on a personal copy of an environment",
+	Long: `Clone an environment into a personal scratch environment, named
+scratch-<login>-<environment>, and pin it with keyway use so that pull, run, set
+and push work on the copy. The shared environment is never touched.
+
+keyway env scratch diff shows what the scratch changed, keyway env scratch merge
+copies the chosen changes back, and keyway env scratch drop deletes the scratch
+and pins the environment used before.
+
+Merging only applies what the scratch changed since it was cloned: keys changed
+in the shared environment meanwhile are kept, and the ones changed on both
+sides are flagged before anything is written.`,
+	Args: cobra.NoArgs,
+	RunE: runEnvScratch,
+}
+
+var envScratchDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what the scratch environment changed",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvScratchDiff,
+}
+
+var envScratchMergeCmd = &cobra.Command{
+	Use:   "merge [KEY...]",
+	Short: "Copy changes of the scratch environment back",
+	Long: `Copy changes of the scratch environment back to the environment it was cloned
+from. Without keys, asks which changes to merge, or with --yes, merges them all.`,
+	RunE: runEnvScratchMerge,
+}
+
+var envScratchDropCmd = &cobra.Command{
+	Use:   "drop",
+	Short: "Delete the scratch environment",
+	Args:  cobra.NoArgs,
+	RunE:  runEnvScratchDrop,
+}
+
+func init() {
+	envScratchCmd.Flags().StringP("env", "e", "development", "Environment to clone")
+	for _, c := range []*cobra.Command{envScratchDiffCmd, envScratchMergeCmd, envScratchDropCmd} {
+		c.Flags().StringP("env", "e", "", "Scratch environment, or the environment it was cloned from")
+	}
+	envScratchMergeCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	envScratchDropCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	envScratchCmd.AddCommand(envScratchDiffCmd)
+	envScratchCmd.AddCommand(envScratchMergeCmd)
+	envScratchCmd.AddCommand(envScratchDropCmd)
+	envCmd.AddCommand(envScratchCmd)
+}
+
+// EnvScratchOptions contains the parsed flags for the env scratch commands
+type EnvScratchOptions struct {
+	EnvName string
+	Keys    []string
+	Yes     bool
+}
+
+// storedScratch is a scratch environment created by keyway env scratch
+type storedScratch struct {
+	Repo   string `json:"repo"`
+	Env    string `json:"environment"`
+	Source string `json:"source"`
+	// BaseVersion is the version of the source the scratch was cloned at, 0 if unknown
+	BaseVersion int `json:"baseVersion,omitempty"`
+	// PreviousEnv is the environment pinned before the scratch, restored by drop
+	PreviousEnv string `json:"previousEnvironment,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// scratchChange is a key the scratch changed since it was cloned
+type scratchChange struct {
+	Key    string
+	Action string // added, changed or removed
+	// Conflict is true when the source changed the key too
+	Conflict bool
+}
+
+// runEnvScratch is the entry point for the env scratch command (uses default dependencies)
+func runEnvScratch(cmd *cobra.Command, args []string) error {
+	opts := EnvScratchOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runEnvScratchWithDeps(opts, defaultDeps)
+}
+
+// runEnvScratchWithDeps is the testable version of runEnvScratch
+func runEnvScratchWithDeps(opts EnvScratchOptions, deps *Dependencies) error {
+	return runPipeline(deps, envScratch, withIntro("env scratch"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// envScratch clones an environment into a scratch environment and pins it
+func envScratch(s *Session) error {
+	deps := s.Deps
+	if scratch, ok := findScratch(s.Repo, s.EnvName, deps); ok && scratch.Env == s.EnvName {
+		err := fmt.Errorf("%s is already a scratch of %s", s.EnvName, scratch.Source)
+		deps.UI.Error(err.Error())
+		deps.UI.Message(deps.UI.Dim("Merge it with keyway env scratch merge, or delete it with keyway env scratch drop"))
+		return err
+	}
+
+	login := ""
+	if deps.AuthStore != nil {
+		if stored, err := deps.AuthStore.GetAuth(); err == nil && stored != nil {
+			login = strings.ToLower(stored.GitHubLogin)
+		}
+	}
+	if login == "" {
+		deps.UI.Error("Cannot tell who you are to name the scratch environment")
+		deps.UI.Message(deps.UI.Dim("Log in again with: keyway login"))
+		return fmt.Errorf("no GitHub login stored")
+	}
+	name := fmt.Sprintf("scratch-%s-%s", login, s.EnvName)
+	if err := validateEnvName(name); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "creating a scratch environment", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, name, deps); err != nil {
+		return err
+	}
+	if _, err := requireEnvironment(s, s.EnvName, "env scratch"); err != nil {
+		return err
+	}
+	if _, found, err := findEnvironment(s, name, "env scratch"); err != nil {
+		return err
+	} else if found {
+		err := fmt.Errorf("%s already exists", name)
+		deps.UI.Error(err.Error())
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Use it with: keyway use --env %s, or delete it with: keyway env scratch drop -e %s", name, name)))
+		return err
+	}
+
+	var source *api.PullSecretsResponse
+	err := s.Spin(fmt.Sprintf("Pulling %s...", s.EnvName), func() error {
+		var err error
+		source, err = s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env scratch", err, deps)
+	}
+	secrets := env.ParseVault(source.Content)
+
+	err = s.Spin(fmt.Sprintf("Creating %s...", name), func() error {
+		if err := s.Client.CreateEnvironment(s.Ctx, s.Repo, name); err != nil {
+			return err
+		}
+		if len(secrets) == 0 {
+			return nil
+		}
+		_, err := s.Client.PushSecrets(s.Ctx, s.Repo, name, secrets, uuid.NewString())
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env scratch", err, deps)
+	}
+
+	pinned := loadPinnedContext(deps)
+	scratch := storedScratch{
+		Repo:        s.Repo,
+		Env:         name,
+		Source:      s.EnvName,
+		BaseVersion: source.Version,
+		PreviousEnv: pinned.Env,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := saveScratch(scratch, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the scratch environment: %s", err.Error()))
+		return err
+	}
+	pinned.Env = name
+	if err := savePinnedContext(pinned, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the context: %s", err.Error()))
+		return err
+	}
+
+	analytics.Track("cli_env_scratch", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"secretCount":  len(secrets),
+	})
+	deps.UI.Success(fmt.Sprintf("Cloned %s into %s (%d secrets)", s.EnvName, name, len(secrets)))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Commands now use %s, %s is left as is", name, s.EnvName)))
+	deps.UI.Message(deps.UI.Dim("Review with keyway env scratch diff, then keyway env scratch merge or drop"))
+	return nil
+}
+
+// runEnvScratchDiff is the entry point for the env scratch diff command (uses default dependencies)
+func runEnvScratchDiff(cmd *cobra.Command, args []string) error {
+	opts := EnvScratchOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runEnvScratchDiffWithDeps(opts, defaultDeps)
+}
+
+// runEnvScratchDiffWithDeps is the testable version of runEnvScratchDiff
+func runEnvScratchDiffWithDeps(opts EnvScratchOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		scratch, err := requireScratch(s, opts.EnvName)
+		if err != nil {
+			return err
+		}
+		changes, _, _, err := loadScratchChanges(s, scratch, "env scratch diff")
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			deps.UI.Info(fmt.Sprintf("%s has no changes from %s", scratch.Env, scratch.Source))
+			return nil
+		}
+		printScratchChanges(changes, scratch, deps)
+		deps.UI.Message(deps.UI.Dim("Merge them with: keyway env scratch merge [KEY...]"))
+		return nil
+	}, withIntro("env scratch diff"), withRepo, withLogin)
+}
+
+// runEnvScratchMerge is the entry point for the env scratch merge command (uses default dependencies)
+func runEnvScratchMerge(cmd *cobra.Command, args []string) error {
+	opts := EnvScratchOptions{Keys: args}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvScratchMergeWithDeps(opts, defaultDeps)
+}
+
+// runEnvScratchMergeWithDeps is the testable version of runEnvScratchMerge
+func runEnvScratchMergeWithDeps(opts EnvScratchOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envScratchMerge(s, opts)
+	}, withIntro("env scratch merge"), withRepo, withLogin)
+}
+
+// envScratchMerge pushes the chosen changes of a scratch to its source
+func envScratchMerge(s *Session, opts EnvScratchOptions) error {
+	deps := s.Deps
+	scratch, err := requireScratch(s, opts.EnvName)
+	if err != nil {
+		return err
+	}
+	changes, scratchSecrets, sourceSecrets, err := loadScratchChanges(s, scratch, "env scratch merge")
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		deps.UI.Info(fmt.Sprintf("%s has no changes from %s, nothing to merge", scratch.Env, scratch.Source))
+		return nil
+	}
+
+	byKey := make(map[string]scratchChange, len(changes))
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		byKey[c.Key] = c
+		keys = append(keys, c.Key)
+	}
+	picked := opts.Keys
+	for _, k := range picked {
+		if _, ok := byKey[k]; !ok {
+			err := fmt.Errorf("%s did not change %s", scratch.Env, k)
+			deps.UI.Error(err.Error())
+			return err
+		}
+	}
+	if len(picked) == 0 && !opts.Yes && deps.UI.IsInteractive() {
+		if picked, err = pickKeys(fmt.Sprintf("Changes to merge into %s:", scratch.Source), keys, deps); err != nil {
+			return err
+		}
+	}
+	if len(picked) == 0 {
+		picked = keys
+	}
+	sort.Strings(picked)
+
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "merging into "+scratch.Source, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, scratch.Source, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, scratch.Source, deps); err != nil {
+		return err
+	}
+
+	next := make(map[string]string, len(sourceSecrets))
+	for k, v := range sourceSecrets {
+		next[k] = v
+	}
+	var added, conflicts []string
+	merged := make([]scratchChange, 0, len(picked))
+	for _, k := range picked {
+		c := byKey[k]
+		merged = append(merged, c)
+		if c.Conflict {
+			conflicts = append(conflicts, k)
+		}
+		if c.Action == "removed" {
+			delete(next, k)
+			continue
+		}
+		if _, ok := sourceSecrets[k]; !ok {
+			added = append(added, k)
+		}
+		next[k] = scratchSecrets[k]
+	}
+	if len(added) > 0 {
+		if err := checkKeyNamingPolicy(added, deps); err != nil {
+			return err
+		}
+	}
+	warnKeyOwners(s.Ctx, s.Client, s.Repo, picked, deps)
+	if err := checkValidators(s.Ctx, s.Repo, scratch.Source, next, sourceSecrets, env.CalculatePushDiff(next, sourceSecrets), true, false, deps); err != nil {
+		return err
+	}
+
+	printScratchChanges(merged, scratch, deps)
+	if len(conflicts) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%s changed %s since the scratch was cloned, merging overwrites it", scratch.Source, strings.Join(conflicts, ", ")))
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Merge %d changes into %s?", len(merged), scratch.Source), len(conflicts) == 0)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err = s.Spin(fmt.Sprintf("Pushing to %s...", scratch.Source), func() error {
+		_, err := s.Client.PushSecrets(s.Ctx, s.Repo, scratch.Source, next, uuid.NewString())
+		return err
+	})
+	if err != nil {
+		return reportEnvError("env scratch merge", err, deps)
+	}
+	forgetPrefetched(s.Repo, scratch.Source, deps)
+
+	analytics.Track("cli_env_scratch_merge", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  scratch.Source,
+		"keys":         len(merged),
+		"conflicts":    len(conflicts),
+	})
+	deps.UI.Success(fmt.Sprintf("Merged %d changes into %s", len(merged), scratch.Source))
+	deps.UI.Message(deps.UI.Dim("Delete the scratch with: keyway env scratch drop"))
+	return nil
+}
+
+// runEnvScratchDrop is the entry point for the env scratch drop command (uses default dependencies)
+func runEnvScratchDrop(cmd *cobra.Command, args []string) error {
+	opts := EnvScratchOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runEnvScratchDropWithDeps(opts, defaultDeps)
+}
+
+// runEnvScratchDropWithDeps is the testable version of runEnvScratchDrop
+func runEnvScratchDropWithDeps(opts EnvScratchOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return envScratchDrop(s, opts)
+	}, withIntro("env scratch drop"), withRepo, withLogin)
+}
+
+// envScratchDrop deletes a scratch and pins the environment used before it
+func envScratchDrop(s *Session, opts EnvScratchOptions) error {
+	deps := s.Deps
+	scratch, err := requireScratch(s, opts.EnvName)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		deps.UI.Warn(fmt.Sprintf("Changes not merged into %s are lost", scratch.Source))
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Delete %s?", scratch.Env), false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err = s.Spin("Deleting environment...", func() error {
+		return s.Client.DeleteEnvironment(s.Ctx, s.Repo, scratch.Env)
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); !ok || apiErr.StatusCode != 404 {
+			return reportEnvError("env scratch drop", err, deps)
+		}
+		// Already deleted, e.g. with keyway env delete
+	}
+	forgetPrefetched(s.Repo, scratch.Env, deps)
+	if err := removeScratch(scratch, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to remove the scratch environment: %s", err.Error()))
+		return err
+	}
+
+	pinned := loadPinnedContext(deps)
+	if pinned.Env == scratch.Env {
+		pinned.Env = scratch.PreviousEnv
+		if err := savePinnedContext(pinned, deps); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to save the context: %s", err.Error()))
+			return err
+		}
+	}
+	deps.UI.Success(fmt.Sprintf("Deleted %s", scratch.Env))
+	if pinned.Env != "" {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Commands now use %s", pinned.Env)))
+	}
+	return nil
+}
+
+// requireScratch returns the scratch named by envName, which may also be its
+// source. Without a name, the repository must have a single scratch.
+func requireScratch(s *Session, envName string) (storedScratch, error) {
+	deps := s.Deps
+	envName = normalizeEnvName(envName)
+	if scratch, ok := findScratch(s.Repo, envName, deps); ok {
+		s.EnvName = scratch.Env
+		deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(scratch.Env)))
+		return scratch, nil
+	}
+
+	var err error
+	if envName != "" {
+		err = fmt.Errorf("%s is not a scratch environment", envName)
+	} else if n := len(repoScratches(s.Repo, deps)); n > 1 {
+		err = fmt.Errorf("%d scratch environments, pick one with --env", n)
+	} else {
+		err = fmt.Errorf("no scratch environment")
+	}
+	deps.UI.Error(err.Error())
+	deps.UI.Message(deps.UI.Dim("Create one with: keyway env scratch -e <environment>"))
+	return storedScratch{}, err
+}
+
+// findScratch returns the scratch of a repository named envName, or cloned
+// from it. An empty name finds the repository's only scratch.
+func findScratch(repo, envName string, deps *Dependencies) (storedScratch, bool) {
+	scratches := repoScratches(repo, deps)
+	if envName == "" {
+		if len(scratches) == 1 {
+			return scratches[0], true
+		}
+		return storedScratch{}, false
+	}
+	var fromSource []storedScratch
+	for _, scratch := range scratches {
+		if scratch.Env == envName {
+			return scratch, true
+		}
+		if scratch.Source == envName {
+			fromSource = append(fromSource, scratch)
+		}
+	}
+	if len(fromSource) == 1 {
+		return fromSource[0], true
+	}
+	return storedScratch{}, false
+}
+
+// repoScratches returns the scratches of a repository, sorted by name
+func repoScratches(repo string, deps *Dependencies) []storedScratch {
+	var scratches []storedScratch
+	for _, scratch := range loadScratches(deps) {
+		if scratch.Repo == repo {
+			scratches = append(scratches, scratch)
+		}
+	}
+	sort.Slice(scratches, func(i, j int) bool { return scratches[i].Env < scratches[j].Env })
+	return scratches
+}
+
+// loadScratchChanges returns the keys a scratch changed since it was cloned,
+// with the secrets of the scratch and of its source
+func loadScratchChanges(s *Session, scratch storedScratch, command string) ([]scratchChange, map[string]string, map[string]string, error) {
+	var scratchSecrets, sourceSecrets, baseSecrets map[string]string
+	err := s.Spin("Comparing environments...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, scratch.Env)
+		if err != nil {
+			return err
+		}
+		scratchSecrets = env.ParseVault(resp.Content)
+		if resp, err = s.Client.PullSecrets(s.Ctx, s.Repo, scratch.Source); err != nil {
+			return err
+		}
+		sourceSecrets = env.ParseVault(resp.Content)
+
+		// Without the version cloned, the source as it is now is the base
+		baseSecrets = sourceSecrets
+		if scratch.BaseVersion > 0 {
+			resp, err := s.Client.PullSecretsAt(s.Ctx, s.Repo, scratch.Source, api.Revision{Version: scratch.BaseVersion})
+			if err == nil {
+				baseSecrets = env.ParseVault(resp.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, reportEnvError(command, err, s.Deps)
+	}
+	return scratchChanges(baseSecrets, scratchSecrets, sourceSecrets), scratchSecrets, sourceSecrets, nil
+}
+
+// scratchChanges returns the keys the scratch changed from base, sorted. A
+// change conflicts when the source changed the key since base too, to another
+// value.
+func scratchChanges(base, scratch, source map[string]string) []scratchChange {
+	var changes []scratchChange
+	for _, k := range sortedKeys(unionKeys(base, scratch)) {
+		baseValue, inBase := base[k]
+		value, inScratch := scratch[k]
+		c := scratchChange{Key: k}
+		switch {
+		case !inBase:
+			c.Action = "added"
+		case !inScratch:
+			c.Action = "removed"
+		case value != baseValue:
+			c.Action = "changed"
+		default:
+			continue
+		}
+		sourceValue, inSource := source[k]
+		sourceChanged := inSource != inBase || sourceValue != baseValue
+		sameResult := inSource == inScratch && sourceValue == value
+		c.Conflict = sourceChanged && !sameResult
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// unionKeys returns the keys of a and b
+func unionKeys(a, b map[string]string) map[string]string {
+	union := make(map[string]string, len(a)+len(b))
+	for k := range a {
+		union[k] = ""
+	}
+	for k := range b {
+		union[k] = ""
+	}
+	return union
+}
+
+// printScratchChanges shows the changes of a scratch, flagging conflicts
+func printScratchChanges(changes []scratchChange, scratch storedScratch, deps *Dependencies) {
+	deps.UI.Message("")
+	deps.UI.Message(fmt.Sprintf("Changes of %s from %s:", scratch.Env, scratch.Source))
+	for _, c := range changes {
+		key := c.Key
+		if c.Conflict {
+			key += deps.UI.Dim(fmt.Sprintf(" (also changed in %s)", scratch.Source))
+		}
+		switch c.Action {
+		case "added":
+			deps.UI.DiffAdded(key)
+		case "removed":
+			deps.UI.DiffRemoved(key)
+		default:
+			deps.UI.DiffChanged(key)
+		}
+	}
+	deps.UI.Message("")
+}
+
+// scratchesPath returns the file holding the scratch environments
+func scratchesPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "scratches.json")
+}
+
+// loadScratches returns the stored scratches, by repository and environment
+func loadScratches(deps *Dependencies) map[string]storedScratch {
+	scratches := make(map[string]storedScratch)
+	path := scratchesPath()
+	if path == "" {
+		return scratches
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &scratches)
+	}
+	return scratches
+}
+
+// saveScratch stores a scratch
+func saveScratch(scratch storedScratch, deps *Dependencies) error {
+	scratches := loadScratches(deps)
+	scratches[scratch.Repo+":"+scratch.Env] = scratch
+	return writeScratches(scratches, deps)
+}
+
+// removeScratch forgets a scratch
+func removeScratch(scratch storedScratch, deps *Dependencies) error {
+	scratches := loadScratches(deps)
+	delete(scratches, scratch.Repo+":"+scratch.Env)
+	return writeScratches(scratches, deps)
+}
+
+// writeScratches writes the stored scratches
+func writeScratches(scratches map[string]storedScratch, deps *Dependencies) error {
+	path := scratchesPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(scratches, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
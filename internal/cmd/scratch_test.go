@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunEnvScratchWithDeps_ClonesAndPins(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	deps.AuthStore = &MockAuthStore{StoredAuth: &StoredAuthInfo{GitHubLogin: "Alice"}}
+	apiMock.VaultEnvs = []string{"development", "staging"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_URL=https://api\nTIMEOUT=30\n", Version: 7}
+	fsMock.Files[pinnedContextPath()] = []byte(`{"environment":"staging"}`)
+
+	if err := runEnvScratchWithDeps(EnvScratchOptions{EnvName: "staging"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.CreatedEnvironment != "scratch-alice-staging" {
+		t.Errorf("expected the scratch to be created, got %q", apiMock.CreatedEnvironment)
+	}
+	if want := map[string]string{"API_URL": "https://api", "TIMEOUT": "30"}; !reflect.DeepEqual(apiMock.PushedByEnv["scratch-alice-staging"], want) {
+		t.Errorf("expected the secrets to be copied, got %v", apiMock.PushedByEnv)
+	}
+	if _, ok := apiMock.PushedByEnv["staging"]; ok {
+		t.Error("the source must not be written")
+	}
+
+	var pinned PinnedContext
+	_ = json.Unmarshal(fsMock.Written[pinnedContextPath()], &pinned)
+	if pinned.Env != "scratch-alice-staging" {
+		t.Errorf("expected the scratch to be pinned, got %+v", pinned)
+	}
+	var scratches map[string]storedScratch
+	_ = json.Unmarshal(fsMock.Written[scratchesPath()], &scratches)
+	scratch := scratches["owner/repo:scratch-alice-staging"]
+	if scratch.Source != "staging" || scratch.BaseVersion != 7 || scratch.PreviousEnv != "staging" {
+		t.Errorf("unexpected stored scratch: %+v", scratches)
+	}
+}
+
+func TestRunEnvScratchWithDeps_AlreadyExists(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	deps.AuthStore = &MockAuthStore{StoredAuth: &StoredAuthInfo{GitHubLogin: "alice"}}
+	apiMock.VaultEnvs = []string{"development", "scratch-alice-development"}
+
+	if err := runEnvScratchWithDeps(EnvScratchOptions{EnvName: "development"}, deps); err == nil {
+		t.Fatal("expected an error when the scratch exists")
+	}
+	if apiMock.CreatedEnvironment != "" {
+		t.Error("expected nothing to be created")
+	}
+}
+
+// withScratch stores a scratch of staging cloned at version 3
+func withScratch(t *testing.T, fsMock *MockFileSystem) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	scratch := storedScratch{Repo: "owner/repo", Env: "scratch-alice-staging", Source: "staging", BaseVersion: 3, PreviousEnv: "staging"}
+	data, _ := json.Marshal(map[string]storedScratch{"owner/repo:scratch-alice-staging": scratch})
+	fsMock.Files[scratchesPath()] = data
+	fsMock.Files[pinnedContextPath()] = []byte(`{"environment":"scratch-alice-staging"}`)
+}
+
+func TestScratchChanges(t *testing.T) {
+	base := map[string]string{"A": "1", "B": "1", "C": "1", "D": "1"}
+	scratch := map[string]string{"A": "2", "B": "1", "C": "2", "E": "1"}
+	source := map[string]string{"A": "1", "B": "3", "C": "3", "D": "1"}
+
+	changes := scratchChanges(base, scratch, source)
+	want := []scratchChange{
+		{Key: "A", Action: "changed"},
+		{Key: "C", Action: "changed", Conflict: true},
+		{Key: "D", Action: "removed"},
+		{Key: "E", Action: "added"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("unexpected changes:\n got %+v\nwant %+v", changes, want)
+	}
+}
+
+func TestRunEnvScratchMergeWithDeps_KeepsUpstreamChanges(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	withScratch(t, fsMock)
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "TIMEOUT=60\nFLAGS=new\nNEW_KEY=x\n"},             // scratch
+		{Content: "TIMEOUT=30\nFLAGS=old\nREGION=eu\n", Version: 5}, // staging now
+	}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "TIMEOUT=30\nFLAGS=old\n"}}
+
+	err := runEnvScratchMergeWithDeps(EnvScratchOptions{Keys: []string{"TIMEOUT", "NEW_KEY"}, Yes: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"TIMEOUT": "60", "FLAGS": "old", "REGION": "eu", "NEW_KEY": "x"}
+	if !reflect.DeepEqual(apiMock.PushedByEnv["staging"], want) {
+		t.Errorf("expected only the picked keys to be merged, got %v", apiMock.PushedByEnv["staging"])
+	}
+}
+
+func TestRunEnvScratchMergeWithDeps_UnchangedKey(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	withScratch(t, fsMock)
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "TIMEOUT=60\n"}, {Content: "TIMEOUT=30\n"}}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "TIMEOUT=30\n"}}
+
+	if err := runEnvScratchMergeWithDeps(EnvScratchOptions{Keys: []string{"REGION"}, Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for a key the scratch did not change")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
+
+func TestRunEnvScratchMergeWithDeps_WarnsOnConflicts(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	withScratch(t, fsMock)
+	apiMock.PullSequence = []*api.PullSecretsResponse{{Content: "TIMEOUT=60\n"}, {Content: "TIMEOUT=45\n"}}
+	apiMock.PullAtVersions = map[int]*api.PullSecretsResponse{3: {Content: "TIMEOUT=30\n"}}
+
+	if err := runEnvScratchMergeWithDeps(EnvScratchOptions{Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(strings.Join(uiMock.WarnCalls, "\n"), "staging changed TIMEOUT") {
+		t.Errorf("expected a conflict warning, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunEnvScratchDropWithDeps_RestoresContext(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	withScratch(t, fsMock)
+
+	if err := runEnvScratchDropWithDeps(EnvScratchOptions{EnvName: "staging", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.DeletedEnvironment != "scratch-alice-staging" {
+		t.Errorf("expected the scratch to be deleted, got %q", apiMock.DeletedEnvironment)
+	}
+	var pinned PinnedContext
+	_ = json.Unmarshal(fsMock.Written[pinnedContextPath()], &pinned)
+	if pinned.Env != "staging" {
+		t.Errorf("expected staging to be pinned again, got %+v", pinned)
+	}
+	var scratches map[string]storedScratch
+	_ = json.Unmarshal(fsMock.Written[scratchesPath()], &scratches)
+	if len(scratches) != 0 {
+		t.Errorf("expected the scratch to be forgotten, got %v", scratches)
+	}
+}
+
+func TestRunEnvScratchDropWithDeps_NoScratch(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	if err := runEnvScratchDropWithDeps(EnvScratchOptions{Yes: true}, deps); err == nil {
+		t.Fatal("expected an error without a scratch")
+	}
+	if apiMock.DeletedEnvironment != "" {
+		t.Error("expected nothing to be deleted")
+	}
+}
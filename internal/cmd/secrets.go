@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Read or change single secrets without pulling or pushing a file",
+	Long: `Read or change single secrets of an environment without pulling or pushing
+a whole env file.`,
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the keys of an environment with masked values and who last set them",
+	Long: `List the keys of an environment with a masked preview of each value (its last
+2 characters and length), when it was last set and by whom, without writing
+anything to disk. Config keys of .keyway.json are shown unmasked.
+
+In a terminal the keys are shown as a table; in scripts, one tab-separated line
+per key. --json prints the same as JSON.
+
+--template formats the output with a Go template, which gets .repository,
+.environment and .keys, each key having .name, .value, .updatedAt and
+.updatedBy.`,
+	Args: cobra.NoArgs,
+	RunE: runSecretsList,
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the value of one secret",
+	Long: `Print the value of one secret to stdout, and nothing else, for shell scripts
+and CI steps. Errors go to stderr and the exit code is not 0 when the key is
+missing.
+
+Same as keyway get. --plain prints the value exactly, without the trailing
+newline.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGet,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <KEY=VALUE>...",
+	Short: "Add or update one or more secrets in the vault",
+	Long: `Add or update one or more secrets of an environment in a single push, the
+other keys staying as they are. The keys changed are shown first, and updating
+existing keys asks for a confirmation unless --yes is passed.
+
+Same as keyway set. A single KEY without a value prompts for it (masked).`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSet,
+}
+
+var secretsRmCmd = &cobra.Command{
+	Use:   "rm <KEY>...",
+	Short: "Delete one or more secrets from the vault",
+	Long: fmt.Sprintf(`Delete keys from an environment, the other keys staying as they are, even if
+they changed since your last pull. Asks for a confirmation unless --yes is
+passed.
+
+Deleted keys are kept in the environment's trash for %d days, see keyway trash.`, api.TrashRetentionDays),
+	Args:    cobra.MinimumNArgs(1),
+	Aliases: []string{"delete"},
+	RunE:    runSecretsRm,
+}
+
+func init() {
+	secretsListCmd.Flags().StringP("env", "e", "development", "Environment name")
+	addTemplateFlag(secretsListCmd)
+
+	secretsGetCmd.Flags().StringP("env", "e", "development", "Environment name")
+	secretsGetCmd.Flags().Bool("plain", false, "Print the value exactly, without a trailing newline")
+
+	secretsSetCmd.Flags().StringP("env", "e", "", "Environment name (default: development)")
+	secretsSetCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompts")
+	secretsSetCmd.Flags().Bool("trust-validators", false, "Run the executable validators of .keyway.json without asking to trust them")
+
+	secretsRmCmd.Flags().StringP("env", "e", "development", "Environment name")
+	secretsRmCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsGetCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsRmCmd)
+}
+
+// SecretsListOptions contains the parsed flags for the secrets list command
+type SecretsListOptions struct {
+	EnvName    string
+	JSONOutput bool
+	Template   string
+}
+
+// SecretListing is a key of keyway secrets list
+type SecretListing struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"` // masked, except for config keys
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+// secretsListView is what --template gets from keyway secrets list
+type secretsListView struct {
+	Repository  string          `json:"repository"`
+	Environment string          `json:"environment"`
+	Keys        []secretKeyView `json:"keys"`
+}
+
+// secretKeyView is a key of secretsListView
+type secretKeyView struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	UpdatedAt string `json:"updatedAt"`
+	UpdatedBy string `json:"updatedBy"`
+}
+
+// runSecretsList is the entry point for the secrets list command (uses default dependencies)
+func runSecretsList(cmd *cobra.Command, args []string) error {
+	opts := SecretsListOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runSecretsListWithDeps(opts, deps)
+}
+
+// runSecretsListWithDeps is the testable version of runSecretsList
+func runSecretsListWithDeps(opts SecretsListOptions, deps *Dependencies) error {
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return secretsList(s, opts, tmpl)
+	}, withIntro("secrets list"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// secretsList shows the keys of an environment, masked, with who last set them
+func secretsList(s *Session, opts SecretsListOptions, tmpl *template.Template) error {
+	deps := s.Deps
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	var secrets map[string]string
+	var metadata []api.SecretMetadata
+	err = s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		secrets = env.ParseVault(resp.Content)
+
+		metadata, err = s.Client.ListSecretMetadata(s.Ctx, s.Repo, s.EnvName)
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			// Servers without metadata still list the keys
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return reportEnvError("secrets list", err, deps)
+	}
+
+	byKey := make(map[string]api.SecretMetadata, len(metadata))
+	for _, m := range metadata {
+		byKey[m.Key] = m
+	}
+	listing := make([]SecretListing, 0, len(secrets))
+	for _, key := range sortedKeys(secrets) {
+		value := previewValue(secrets[key])
+		if project.KeyClass(key) == config.ClassConfig {
+			value = secrets[key]
+		}
+		listing = append(listing, SecretListing{
+			Key:       key,
+			Value:     value,
+			UpdatedAt: byKey[key].UpdatedAt,
+			UpdatedBy: byKey[key].UpdatedBy,
+		})
+	}
+
+	if opts.JSONOutput {
+		output, err := json.MarshalIndent(listing, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	if tmpl != nil {
+		view := secretsListView{Repository: s.Repo, Environment: s.EnvName, Keys: []secretKeyView{}}
+		for _, l := range listing {
+			view.Keys = append(view.Keys, secretKeyView{Name: l.Key, Value: l.Value, UpdatedAt: l.UpdatedAt, UpdatedBy: l.UpdatedBy})
+		}
+		return printTemplate(tmpl, view, deps)
+	}
+
+	if len(listing) == 0 {
+		deps.UI.Info(fmt.Sprintf("%s has no keys", s.EnvName))
+		return nil
+	}
+
+	rows := make([][]string, 0, len(listing))
+	for _, l := range listing {
+		updated := ""
+		if l.UpdatedAt != "" {
+			updated = formatEventTime(l.UpdatedAt)
+		}
+		rows = append(rows, []string{l.Key, l.Value, updated, l.UpdatedBy})
+	}
+
+	if !deps.UI.IsInteractive() {
+		for _, row := range rows {
+			fmt.Fprintln(getOutput, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+
+	lines := formatTable(append([][]string{{"KEY", "VALUE", "UPDATED", "BY"}}, rows...))
+	deps.UI.Message("")
+	deps.UI.Message(deps.UI.Dim(lines[0]))
+	for _, line := range lines[1:] {
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+	deps.UI.Outro(fmt.Sprintf("%d keys in %s", len(listing), s.EnvName))
+	return nil
+}
+
+// formatTable pads the cells of rows into aligned columns, two spaces apart
+func formatTable(rows [][]string) []string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+		}
+		lines[r] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+// SecretsRmOptions contains the parsed flags for the secrets rm command
+type SecretsRmOptions struct {
+	Keys    []string
+	EnvName string
+	Yes     bool
+}
+
+// runSecretsRm is the entry point for the secrets rm command (uses default dependencies)
+func runSecretsRm(cmd *cobra.Command, args []string) error {
+	opts := SecretsRmOptions{Keys: args}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runSecretsRmWithDeps(opts, defaultDeps)
+}
+
+// runSecretsRmWithDeps is the testable version of runSecretsRm
+func runSecretsRmWithDeps(opts SecretsRmOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return secretsRm(s, opts)
+	}, withIntro("secrets rm"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// secretsRm deletes keys from an environment
+func secretsRm(s *Session, opts SecretsRmOptions) error {
+	deps, envName := s.Deps, s.EnvName
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "deleting secrets", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+
+	var vaultSecrets map[string]string
+	err := s.Spin("Fetching current secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, envName)
+		if err != nil {
+			return err
+		}
+		vaultSecrets = env.ParseVault(resp.Content)
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("secrets rm", err, deps)
+	}
+
+	unique := make(map[string]bool, len(opts.Keys))
+	for _, k := range opts.Keys {
+		unique[k] = true
+	}
+	keys := sortedKeys(unique)
+	var missing []string
+	for _, k := range keys {
+		if _, ok := vaultSecrets[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		deps.UI.Error(fmt.Sprintf("Not in %s: %s", envName, strings.Join(missing, ", ")))
+		return fmt.Errorf("%s not in %s", strings.Join(missing, ", "), envName)
+	}
+
+	deps.UI.Message("")
+	deps.UI.Message("Will be deleted from vault:")
+	for _, k := range keys {
+		deps.UI.DiffRemoved(k)
+	}
+	deps.UI.Message("")
+
+	if confirmationNeeded(envName, len(keys), opts.Yes, deps) {
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("Use --yes to delete secrets in non-interactive mode")
+			return fmt.Errorf("confirmation required")
+		}
+		prompt := fmt.Sprintf("Delete %s from %s?", keys[0], envName)
+		if len(keys) > 1 {
+			prompt = fmt.Sprintf("Delete these %d secrets from %s?", len(keys), envName)
+		}
+		confirm, _ := deps.UI.Confirm(prompt, false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	}
+
+	warnKeyOwners(s.Ctx, s.Client, s.Repo, keys, deps)
+
+	analytics.Track("cli_secrets_rm", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  envName,
+		"keys":         len(keys),
+	})
+
+	err = s.Spin("Deleting from vault...", func() error {
+		return s.Client.DeleteSecrets(s.Ctx, s.Repo, envName, keys)
+	})
+	if err != nil {
+		return reportEnvError("secrets rm", err, deps)
+	}
+	forgetPrefetched(s.Repo, envName, deps)
+
+	if len(keys) == 1 {
+		deps.UI.Success(fmt.Sprintf("Deleted %s from vault (%s)", keys[0], envName))
+	} else {
+		deps.UI.Success(fmt.Sprintf("Deleted %d secrets from vault (%s)", len(keys), envName))
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Restore within %d days with: keyway trash restore <KEY> -e %s", api.TrashRetentionDays, envName)))
+	return nil
+}
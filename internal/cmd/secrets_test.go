@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunSecretsRmWithDeps_DeletesKeys(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "OLD_TOKEN=a\nLEGACY_URL=b\nAPI_KEY=c\n"}
+
+	err := runSecretsRmWithDeps(SecretsRmOptions{Keys: []string{"OLD_TOKEN", "LEGACY_URL", "OLD_TOKEN"}, EnvName: "staging", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"LEGACY_URL", "OLD_TOKEN"}
+	if !reflect.DeepEqual(apiMock.DeletedKeys, want) {
+		t.Errorf("expected %v to be deleted, got %v", want, apiMock.DeletedKeys)
+	}
+	if !reflect.DeepEqual(uiMock.DiffRemovedCalls, want) {
+		t.Errorf("expected the deleted keys to be shown, got %v", uiMock.DiffRemovedCalls)
+	}
+	if len(uiMock.SuccessCalls) != 1 || uiMock.SuccessCalls[0] != "Deleted 2 secrets from vault (staging)" {
+		t.Errorf("unexpected success message: %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunSecretsRmWithDeps_MissingKey(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=c\n"}
+
+	err := runSecretsRmWithDeps(SecretsRmOptions{Keys: []string{"API_KEY", "OLD_TOKEN"}, EnvName: "staging", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected an error for a key not in the environment")
+	}
+	if apiMock.DeletedKeys != nil {
+		t.Errorf("nothing should be deleted, got %v", apiMock.DeletedKeys)
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "Not in staging: OLD_TOKEN" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunSecretsRmWithDeps_Confirmation(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "OLD_TOKEN=a\n"}
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = false
+
+	if err := runSecretsRmWithDeps(SecretsRmOptions{Keys: []string{"OLD_TOKEN"}, EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.ConfirmCalls) != 1 || uiMock.ConfirmCalls[0] != "Delete OLD_TOKEN from production?" {
+		t.Errorf("expected a confirmation, got %v", uiMock.ConfirmCalls)
+	}
+	if apiMock.DeletedKeys != nil {
+		t.Errorf("nothing should be deleted once declined, got %v", apiMock.DeletedKeys)
+	}
+
+	uiMock.Interactive = false
+	if err := runSecretsRmWithDeps(SecretsRmOptions{Keys: []string{"OLD_TOKEN"}, EnvName: "production"}, deps); err == nil {
+		t.Fatal("expected an error without --yes in non-interactive mode")
+	}
+}
+
+func TestRunSecretsListWithDeps_Table(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_abcdef\nDEBUG=true\n"}
+	apiMock.SecretMetadata = []api.SecretMetadata{
+		{Key: "API_KEY", UpdatedAt: "2026-03-01T10:00:00Z", UpdatedBy: "alice"},
+	}
+
+	if err := runSecretsListWithDeps(SecretsListOptions{EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := strings.Join(uiMock.MessageCalls, "\n")
+	if strings.Contains(output, "sk_live_abcdef") {
+		t.Errorf("values must be masked, got:\n%s", output)
+	}
+	if !strings.Contains(output, "KEY      VALUE") || !strings.Contains(output, "alice") {
+		t.Errorf("expected an aligned table with who set each key, got:\n%s", output)
+	}
+	if len(uiMock.OutroCalls) != 1 || uiMock.OutroCalls[0] != "2 keys in production" {
+		t.Errorf("unexpected outro: %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunSecretsListWithDeps_WithoutMetadata(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	buf := captureGetOutput(t)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_abcdef\n"}
+	apiMock.SecretMetadataError = &api.APIError{StatusCode: 404}
+
+	if err := runSecretsListWithDeps(SecretsListOptions{EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "API_KEY\t"+previewValue("sk_live_abcdef")+"\t\t\n" {
+		t.Errorf("expected one tab-separated line, got %q", got)
+	}
+}
+
+func TestFormatTable(t *testing.T) {
+	lines := formatTable([][]string{{"KEY", "BY"}, {"DATABASE_URL", "bob"}, {"A", ""}})
+	want := []string{"KEY           BY", "DATABASE_URL  bob", "A"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("expected %q, got %q", want, lines)
+	}
+}
+
+func TestRunSecretsListWithDeps_Template(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=sk_live_abcdef\nDEBUG=true\n"}
+	apiMock.SecretMetadata = []api.SecretMetadata{
+		{Key: "API_KEY", UpdatedAt: "2026-03-01T10:00:00Z", UpdatedBy: "alice"},
+	}
+
+	output := captureStdout(t, func() {
+		opts := SecretsListOptions{EnvName: "production", Template: `{{range .keys}}{{.name}}\t{{.updatedAt}}\n{{end}}`}
+		if err := runSecretsListWithDeps(opts, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if string(output) != "API_KEY\t2026-03-01T10:00:00Z\nDEBUG\t\n" {
+		t.Errorf("unexpected output: %q", output)
+	}
+}
+
+func TestRunSecretsListWithDeps_TemplateAndJSON(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runSecretsListWithDeps(SecretsListOptions{EnvName: "production", JSONOutput: true, Template: "{{.}}"}, deps); err == nil {
+		t.Fatal("expected an error for --json with --template")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "--json and --template cannot be used together" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List and revoke the sessions of your account",
+	Long: `Every keyway login creates a session, and every API key is one too. List them
+to spot a lost laptop or a forgotten token, and revoke them: their token stops
+working right away.`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the active sessions of your account",
+	Long: `List the active sessions of your account, the one of this CLI first.
+
+--template formats the output with a Go template, which gets .sessions with
+the fields of --json (.id, .kind, .name, .device, .createdAt, .lastUsedAt,
+.current...).`,
+	Args: cobra.NoArgs,
+	RunE: runSessionsList,
+}
+
+var sessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke [SESSION_ID]",
+	Short: "Revoke a session, or all sessions but this one",
+	Long: `Revoke a session by its ID, or a unique prefix of it, as shown by keyway
+sessions list. With --all-others, revoke every session but the one of this CLI,
+e.g. during offboarding or after a leak.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSessionsRevoke,
+}
+
+func init() {
+	addTemplateFlag(sessionsListCmd)
+	sessionsRevokeCmd.Flags().Bool("all-others", false, "Revoke every session but this one")
+	sessionsRevokeCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsRevokeCmd)
+}
+
+// SessionsOptions contains the parsed flags for the sessions subcommands
+type SessionsOptions struct {
+	SessionID  string
+	AllOthers  bool
+	Yes        bool
+	JSONOutput bool
+	Template   string
+}
+
+// sessionsListView is what --template gets from keyway sessions list
+type sessionsListView struct {
+	Sessions []api.AuthSession `json:"sessions"`
+}
+
+// runSessionsList is the entry point for the sessions list command (uses default dependencies)
+func runSessionsList(cmd *cobra.Command, args []string) error {
+	opts := SessionsOptions{}
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.Template, _ = cmd.Flags().GetString("template")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runSessionsListWithDeps(opts, deps)
+}
+
+// runSessionsRevoke is the entry point for the sessions revoke command (uses default dependencies)
+func runSessionsRevoke(cmd *cobra.Command, args []string) error {
+	opts := SessionsOptions{}
+	if len(args) > 0 {
+		opts.SessionID = args[0]
+	}
+	opts.AllOthers, _ = cmd.Flags().GetBool("all-others")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runSessionsRevokeWithDeps(opts, defaultDeps)
+}
+
+// runSessionsListWithDeps is the testable version of runSessionsList
+func runSessionsListWithDeps(opts SessionsOptions, deps *Dependencies) error {
+	if err := checkOutputFlags(opts.JSONOutput, opts.Template, deps); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return sessionsList(s, opts, tmpl)
+	}, withIntro("sessions list"), withLogin)
+}
+
+// sessionsList shows the active sessions of the account
+func sessionsList(s *Session, opts SessionsOptions, tmpl *template.Template) error {
+	deps := s.Deps
+	sessions, err := fetchSessions(s)
+	if err != nil {
+		return err
+	}
+
+	if sessions == nil {
+		sessions = []api.AuthSession{}
+	}
+	if tmpl != nil {
+		return printTemplate(tmpl, sessionsListView{Sessions: sessions}, deps)
+	}
+	if opts.JSONOutput {
+		output, err := json.MarshalIndent(sessions, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	deps.UI.Message("")
+	for _, session := range sessions {
+		deps.UI.Message(formatSession(session, deps))
+	}
+	deps.UI.Message("")
+
+	if len(sessions) > 1 {
+		deps.UI.Outro("Revoke one with: keyway sessions revoke <SESSION_ID>, or all others with --all-others")
+	}
+	return nil
+}
+
+// runSessionsRevokeWithDeps is the testable version of runSessionsRevoke
+func runSessionsRevokeWithDeps(opts SessionsOptions, deps *Dependencies) error {
+	deps.UI.Intro("sessions revoke")
+
+	if opts.AllOthers == (opts.SessionID != "") {
+		deps.UI.Error("Give a session ID, or --all-others")
+		deps.UI.Message(deps.UI.Dim("See the sessions with: keyway sessions list"))
+		return fmt.Errorf("a session ID or --all-others is required")
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		if opts.AllOthers {
+			return revokeOtherSessions(s, opts)
+		}
+		return revokeSession(s, opts)
+	}, withLogin)
+}
+
+// revokeSession revokes one session, found by its ID or a unique prefix
+func revokeSession(s *Session, opts SessionsOptions) error {
+	deps := s.Deps
+	sessions, err := fetchSessions(s)
+	if err != nil {
+		return err
+	}
+
+	var matches []api.AuthSession
+	for _, session := range sessions {
+		if session.ID == opts.SessionID {
+			matches = []api.AuthSession{session}
+			break
+		}
+		if strings.HasPrefix(session.ID, opts.SessionID) {
+			matches = append(matches, session)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		deps.UI.Error(fmt.Sprintf("No session %s", opts.SessionID))
+		deps.UI.Message(deps.UI.Dim("See the sessions with: keyway sessions list"))
+		return fmt.Errorf("no session %s", opts.SessionID)
+	case len(matches) > 1:
+		deps.UI.Error(fmt.Sprintf("%s matches %d sessions, give more of the ID", opts.SessionID, len(matches)))
+		return fmt.Errorf("ambiguous session ID %s", opts.SessionID)
+	}
+	target := matches[0]
+	if target.Current {
+		deps.UI.Error("This is the session of this CLI")
+		deps.UI.Message(deps.UI.Dim("Log out with: keyway logout"))
+		return fmt.Errorf("cannot revoke the current session")
+	}
+
+	deps.UI.Message(formatSession(target, deps))
+	if confirmed, err := confirmSessionsRevoke("Revoke this session?", opts, deps); err != nil || !confirmed {
+		return err
+	}
+
+	err = s.Spin("Revoking session...", func() error {
+		return s.Client.RevokeSession(s.Ctx, target.ID)
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to revoke the session: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Revoked %s", sessionLabel(target)))
+	return nil
+}
+
+// revokeOtherSessions revokes every session but the current one
+func revokeOtherSessions(s *Session, opts SessionsOptions) error {
+	deps := s.Deps
+	sessions, err := fetchSessions(s)
+	if err != nil {
+		return err
+	}
+
+	var others []api.AuthSession
+	for _, session := range sessions {
+		if !session.Current {
+			others = append(others, session)
+		}
+	}
+	if len(others) == 0 {
+		deps.UI.Info("No other session")
+		return nil
+	}
+
+	deps.UI.Message("")
+	for _, session := range others {
+		deps.UI.Message(formatSession(session, deps))
+	}
+	deps.UI.Message("")
+	if confirmed, err := confirmSessionsRevoke(fmt.Sprintf("Revoke these %d sessions?", len(others)), opts, deps); err != nil || !confirmed {
+		return err
+	}
+
+	var revoked int
+	err = s.Spin("Revoking sessions...", func() error {
+		var err error
+		revoked, err = s.Client.RevokeOtherSessions(s.Ctx)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to revoke the sessions: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Revoked %d session(s), only this one is left", revoked))
+	return nil
+}
+
+// confirmSessionsRevoke asks before revoking, which --yes skips
+func confirmSessionsRevoke(message string, opts SessionsOptions, deps *Dependencies) (bool, error) {
+	if opts.Yes {
+		return true, nil
+	}
+	if !deps.UI.IsInteractive() {
+		return false, fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+	confirm, _ := deps.UI.Confirm(message, false)
+	if !confirm {
+		deps.UI.Warn("Revoke aborted.")
+	}
+	return confirm, nil
+}
+
+// fetchSessions returns the sessions of the account
+func fetchSessions(s *Session) ([]api.AuthSession, error) {
+	var sessions []api.AuthSession
+	err := s.Spin("Fetching sessions...", func() error {
+		var err error
+		sessions, err = s.Client.ListSessions(s.Ctx)
+		return err
+	})
+	if err != nil {
+		s.Deps.UI.Error(fmt.Sprintf("Failed to list sessions: %s", err.Error()))
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// sessionLabel names a session: an API key's name, or the device of a login
+func sessionLabel(session api.AuthSession) string {
+	switch {
+	case session.Name != "":
+		return session.Name
+	case session.Device != "":
+		return session.Device
+	case session.Kind == "api_key":
+		return "API key"
+	default:
+		return "CLI login"
+	}
+}
+
+// formatSession describes a session on one line
+func formatSession(session api.AuthSession, deps *Dependencies) string {
+	line := fmt.Sprintf("  %s  %s", session.ID, sessionLabel(session))
+	if session.Current {
+		line += " " + deps.UI.Bold("(this session)")
+	}
+
+	var details []string
+	if session.Kind == "api_key" {
+		details = append(details, "API key")
+	}
+	if where := session.Location; where != "" || session.IP != "" {
+		if where == "" {
+			where = session.IP
+		}
+		details = append(details, where)
+	}
+	if session.LastUsedAt != "" {
+		details = append(details, "last used "+formatEventTime(session.LastUsedAt))
+	} else {
+		details = append(details, "created "+formatEventTime(session.CreatedAt))
+	}
+	return line + "  " + deps.UI.Dim(strings.Join(details, ", "))
+}
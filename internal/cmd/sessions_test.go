@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func testSessions() []api.AuthSession {
+	return []api.AuthSession{
+		{ID: "a1b2c3", Kind: "cli", Device: "work-laptop", CreatedAt: "2024-06-01T12:00:00Z", Current: true},
+		{ID: "d4e5f6", Kind: "cli", Device: "old-laptop", Location: "Paris, FR", LastUsedAt: "2024-05-01T12:00:00Z"},
+		{ID: "d4f7a8", Kind: "api_key", Name: "deploy", CreatedAt: "2024-04-01T12:00:00Z"},
+	}
+}
+
+func TestRunSessionsListWithDeps(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+
+	if err := runSessionsListWithDeps(SessionsOptions{}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listed := strings.Join(uiMock.MessageCalls, "\n")
+	for _, want := range []string{"a1b2c3  work-laptop (this session)", "d4e5f6  old-laptop", "Paris, FR", "d4f7a8  deploy", "API key"} {
+		if !strings.Contains(listed, want) {
+			t.Errorf("expected %q in the list, got:\n%s", want, listed)
+		}
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_ByPrefix(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{SessionID: "d4e", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.RevokedSessions) != 1 || apiMock.RevokedSessions[0] != "d4e5f6" {
+		t.Errorf("expected d4e5f6 revoked, got %v", apiMock.RevokedSessions)
+	}
+	if len(uiMock.SuccessCalls) != 1 || uiMock.SuccessCalls[0] != "Revoked old-laptop" {
+		t.Errorf("unexpected success: %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_AmbiguousPrefix(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{SessionID: "d4", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for an ambiguous prefix")
+	}
+	if len(apiMock.RevokedSessions) != 0 {
+		t.Errorf("expected nothing revoked, got %v", apiMock.RevokedSessions)
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_RefusesCurrent(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{SessionID: "a1b2c3", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error when revoking the current session")
+	}
+	if len(apiMock.RevokedSessions) != 0 {
+		t.Errorf("expected nothing revoked, got %v", apiMock.RevokedSessions)
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_AllOthers(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+	apiMock.RevokedOthers = 2
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{AllOthers: true, Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RevokeOthersCalls != 1 {
+		t.Errorf("expected one RevokeOtherSessions call, got %d", apiMock.RevokeOthersCalls)
+	}
+	if len(uiMock.SuccessCalls) != 1 || !strings.Contains(uiMock.SuccessCalls[0], "Revoked 2 session(s)") {
+		t.Errorf("unexpected success: %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_RequiresConfirmation(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Sessions = testSessions()
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{AllOthers: true}, deps); err == nil {
+		t.Fatal("expected an error without --yes in non-interactive mode")
+	}
+	if apiMock.RevokeOthersCalls != 0 {
+		t.Error("expected no sessions revoked")
+	}
+}
+
+func TestRunSessionsRevokeWithDeps_RequiresTarget(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runSessionsRevokeWithDeps(SessionsOptions{}, deps); err == nil {
+		t.Error("expected an error without a session ID or --all-others")
+	}
+	if err := runSessionsRevokeWithDeps(SessionsOptions{SessionID: "a1", AllOthers: true}, deps); err == nil {
+		t.Error("expected an error with both a session ID and --all-others")
+	}
+}
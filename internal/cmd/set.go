@@ -1,11 +1,10 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"sort"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/keywaysh/cli/internal/analytics"
 	"github.com/keywaysh/cli/internal/api"
 	"github.com/keywaysh/cli/internal/config"
@@ -16,31 +15,30 @@ import (
 var setCmd = &cobra.Command{
 	Use:   "set <KEY> [VALUE]",
 	Short: "Set a secret in the vault",
-	Long: `Set a secret in the vault for the current repository.
-
-Examples:
-  keyway set API_KEY                    # Prompt for value (masked)
-  keyway set API_KEY=sk_live_xxx        # Set with inline value
-  keyway set API_KEY -e production      # Set in specific environment
-  keyway set API_KEY -y                 # Skip confirmation if updating`,
-	Args: cobra.RangeArgs(1, 2),
-	RunE: runSet,
+	Long:  `Set a secret in the vault for the current repository.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSet,
 }
 
 func init() {
 	setCmd.Flags().StringP("env", "e", "", "Environment name (default: development)")
 	setCmd.Flags().BoolP("local", "l", false, "Write to local .env file instead of vault (legacy)")
 	setCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompts")
+	setCmd.Flags().Bool("trust-validators", false, "Run the executable validators of .keyway.json without asking to trust them")
 }
 
 // SetOptions contains the parsed flags for the set command
 type SetOptions struct {
-	Key        string
-	Value      string
-	EnvName    string
-	LocalOnly  bool
-	Yes        bool
-	EnvFlagSet bool
+	Key             string
+	Value           string
+	EnvName         string
+	LocalOnly       bool
+	Yes             bool
+	EnvFlagSet      bool
+	TrustValidators bool
+
+	// More holds other KEY=VALUE pairs, set in the same push as Key
+	More map[string]string
 }
 
 // runSet is the entry point for the set command (uses default dependencies)
@@ -49,25 +47,49 @@ func runSet(cmd *cobra.Command, args []string) error {
 		EnvFlagSet: cmd.Flags().Changed("env"),
 	}
 
-	// Parse KEY or KEY=VALUE from first arg
-	if strings.Contains(args[0], "=") {
-		parts := strings.SplitN(args[0], "=", 2)
-		opts.Key = parts[0]
-		opts.Value = parts[1]
-	} else {
-		opts.Key = args[0]
-		if len(args) > 1 {
-			opts.Value = args[1]
-		}
+	var err error
+	if opts.Key, opts.Value, opts.More, err = parseSetArgs(args); err != nil {
+		return err
 	}
 
 	opts.EnvName, _ = cmd.Flags().GetString("env")
 	opts.LocalOnly, _ = cmd.Flags().GetBool("local")
 	opts.Yes, _ = cmd.Flags().GetBool("yes")
+	opts.TrustValidators, _ = cmd.Flags().GetBool("trust-validators")
 
 	return runSetWithDeps(opts, defaultDeps)
 }
 
+// parseSetArgs parses KEY [VALUE], or one or more KEY=VALUE pairs, the first
+// pair being returned apart from the others
+func parseSetArgs(args []string) (key, value string, more map[string]string, err error) {
+	if !strings.Contains(args[0], "=") {
+		if len(args) > 2 {
+			return "", "", nil, fmt.Errorf("expected KEY [VALUE] or KEY=VALUE pairs")
+		}
+		if len(args) == 2 {
+			value = args[1]
+		}
+		return args[0], value, nil, nil
+	}
+
+	key, value, _ = strings.Cut(args[0], "=")
+	for _, arg := range args[1:] {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			return "", "", nil, fmt.Errorf("expected KEY=VALUE, got %q", arg)
+		}
+		if _, dup := more[k]; dup || k == key {
+			return "", "", nil, fmt.Errorf("%s is set twice", k)
+		}
+		if more == nil {
+			more = make(map[string]string)
+		}
+		more[k] = v
+	}
+	return key, value, more, nil
+}
+
 // runSetWithDeps is the testable version of runSet
 func runSetWithDeps(opts SetOptions, deps *Dependencies) error {
 	deps.UI.Intro("set")
@@ -79,14 +101,31 @@ func runSetWithDeps(opts SetOptions, deps *Dependencies) error {
 	}
 
 	// Validate key format (alphanumeric and underscores only)
-	for _, c := range opts.Key {
-		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
-			deps.UI.Error("Key must contain only alphanumeric characters and underscores")
+	if !isValidKeyName(opts.Key) {
+		deps.UI.Error("Key must contain only alphanumeric characters and underscores")
+		return fmt.Errorf("invalid key format")
+	}
+
+	for k, v := range opts.More {
+		if !isValidKeyName(k) {
+			deps.UI.Error(fmt.Sprintf("Key %s must contain only alphanumeric characters and underscores", k))
 			return fmt.Errorf("invalid key format")
 		}
+		if v == "" {
+			deps.UI.Error(fmt.Sprintf("Value of %s cannot be empty", k))
+			return fmt.Errorf("value cannot be empty")
+		}
+	}
+	if len(opts.More) > 0 && opts.LocalOnly {
+		deps.UI.Error("--local sets one key at a time")
+		return fmt.Errorf("--local sets one key at a time")
 	}
 
-	deps.UI.Step(fmt.Sprintf("Key: %s", deps.UI.Value(opts.Key)))
+	if len(opts.More) == 0 {
+		deps.UI.Step(fmt.Sprintf("Key: %s", deps.UI.Value(opts.Key)))
+	} else {
+		deps.UI.Step(fmt.Sprintf("Keys: %s", deps.UI.Value(strings.Join(sortedKeys(opts.values()), ", "))))
+	}
 
 	// Prompt for value if not provided
 	if opts.Value == "" {
@@ -115,6 +154,15 @@ func runSetWithDeps(opts SetOptions, deps *Dependencies) error {
 	return runSetRemote(opts, deps)
 }
 
+// values returns the pairs to set, Key's included
+func (opts SetOptions) values() map[string]string {
+	values := map[string]string{opts.Key: opts.Value}
+	for k, v := range opts.More {
+		values[k] = v
+	}
+	return values
+}
+
 // runSetLocal handles the legacy --local mode
 func runSetLocal(opts SetOptions, deps *Dependencies) error {
 	envFile := ".env"
@@ -162,31 +210,27 @@ func runSetLocal(opts SetOptions, deps *Dependencies) error {
 
 // runSetRemote handles pushing to the vault (default behavior)
 func runSetRemote(opts SetOptions, deps *Dependencies) error {
-	// Detect repo
-	repo, err := deps.Git.DetectRepo()
-	if err != nil {
-		deps.UI.Error("Not in a git repository with GitHub remote")
-		return err
-	}
-	deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(repo)))
+	return runPipeline(deps, func(s *Session) error {
+		return setRemote(s, opts)
+	}, withRepo, withLogin)
+}
 
-	// Ensure logged in
-	token, err := deps.Auth.EnsureLogin()
-	if err != nil {
-		deps.UI.Error(err.Error())
+// setRemote sets the keys in the vault environment
+func setRemote(s *Session, opts SetOptions) error {
+	deps := s.Deps
+	repo := s.Repo
+
+	if err := requirePermission(s.Ctx, s.Client, repo, api.PermissionWrite, "setting secrets", deps); err != nil {
 		return err
 	}
 
-	client := deps.APIFactory.NewClient(token)
-	ctx := context.Background()
-
 	envName := opts.EnvName
 
 	// Default to development if not specified
 	if envName == "" {
 		if !opts.EnvFlagSet && deps.UI.IsInteractive() {
 			// Fetch available environments
-			vaultEnvs, err := client.GetVaultEnvironments(ctx, repo)
+			vaultEnvs, err := s.Client.GetVaultEnvironments(s.Ctx, repo)
 			if err != nil || len(vaultEnvs) == 0 {
 				vaultEnvs = []string{"development", "staging", "production"}
 			}
@@ -203,10 +247,18 @@ func runSetRemote(opts SetOptions, deps *Dependencies) error {
 
 	deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(envName)))
 
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, repo, envName, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, repo, envName, deps); err != nil {
+		return err
+	}
+	warnConcurrentEdits(s.Ctx, s.Client, repo, envName, deps)
+
 	// Fetch current vault state
 	var vaultSecrets map[string]string
-	err = deps.UI.Spin("Fetching current secrets...", func() error {
-		resp, err := client.PullSecrets(ctx, repo, envName)
+	err := s.Spin("Fetching current secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, repo, envName)
 		if err != nil {
 			if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
 				vaultSecrets = make(map[string]string)
@@ -214,105 +266,124 @@ func runSetRemote(opts SetOptions, deps *Dependencies) error {
 			}
 			return err
 		}
-		vaultSecrets = env.Parse(resp.Content)
+		vaultSecrets = env.ParseVault(resp.Content)
 		return nil
 	})
-
 	if err != nil {
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
-			}
-			client = deps.APIFactory.NewClient(newToken)
-			err = deps.UI.Spin("Fetching current secrets...", func() error {
-				resp, err := client.PullSecrets(ctx, repo, envName)
-				if err != nil {
-					if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
-						vaultSecrets = make(map[string]string)
-						return nil
-					}
-					return err
-				}
-				vaultSecrets = env.Parse(resp.Content)
-				return nil
-			})
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	values := opts.values()
+	keys := sortedKeys(values)
+	var existing, added []string
+	for _, k := range keys {
+		if _, ok := vaultSecrets[k]; ok {
+			existing = append(existing, k)
+		} else {
+			added = append(added, k)
 		}
-		if err != nil {
-			deps.UI.Error(err.Error())
-			return err
+	}
+
+	// Show what the push changes when it sets several keys
+	if len(keys) > 1 {
+		deps.UI.Message("")
+		deps.UI.Message("Will be pushed to vault:")
+		for _, k := range keys {
+			if old, ok := vaultSecrets[k]; !ok {
+				deps.UI.DiffAdded(k)
+			} else if old != values[k] {
+				deps.UI.DiffChanged(k)
+			}
 		}
+		deps.UI.Message("")
 	}
 
-	// Check if key exists in vault
-	existsInVault := false
-	if existingValue, ok := vaultSecrets[opts.Key]; ok {
-		existsInVault = true
-		if !opts.Yes {
-			deps.UI.Warn(fmt.Sprintf("%s already exists in vault (%s)", opts.Key, envName))
-			deps.UI.Message(fmt.Sprintf("  Current: %s", deps.UI.Dim(maskValue(existingValue))))
-			deps.UI.Message(fmt.Sprintf("  New:     %s", deps.UI.Value(maskValue(opts.Value))))
+	// Check if keys exist in vault
+	if len(existing) > 0 && confirmationNeeded(envName, len(keys), opts.Yes, deps) {
+		for _, k := range existing {
+			deps.UI.Warn(fmt.Sprintf("%s already exists in vault (%s)", k, envName))
+			deps.UI.Message(fmt.Sprintf("  Current: %s", deps.UI.Dim(maskValue(vaultSecrets[k]))))
+			deps.UI.Message(fmt.Sprintf("  New:     %s", deps.UI.Value(maskValue(values[k]))))
+		}
 
-			if !deps.UI.IsInteractive() {
-				deps.UI.Error("Use --yes to update existing secret in non-interactive mode")
-				return fmt.Errorf("confirmation required")
-			}
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("Use --yes to update existing secret in non-interactive mode")
+			return fmt.Errorf("confirmation required")
+		}
 
-			confirm, _ := deps.UI.Confirm("Update this secret?", false)
-			if !confirm {
-				deps.UI.Warn("Aborted.")
-				return nil
-			}
+		prompt := "Update this secret?"
+		if len(existing) > 1 {
+			prompt = fmt.Sprintf("Update these %d secrets?", len(existing))
+		}
+		confirm, _ := deps.UI.Confirm(prompt, false)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	}
+
+	if len(added) > 0 {
+		if err := checkKeyNamingPolicy(added, deps); err != nil {
+			return err
 		}
 	}
 
+	warnKeyOwners(s.Ctx, s.Client, repo, keys, deps)
+
+	next := make(map[string]string, len(vaultSecrets)+len(values))
+	for k, v := range vaultSecrets {
+		next[k] = v
+	}
+	for k, v := range values {
+		next[k] = v
+	}
+	if err := checkValidators(s.Ctx, repo, envName, next, vaultSecrets, env.CalculatePushDiff(next, vaultSecrets), false, opts.TrustValidators, deps); err != nil {
+		return err
+	}
+
 	// Track analytics
 	analytics.Track("cli_set", map[string]interface{}{
 		"repoFullName": repo,
 		"environment":  envName,
-		"isUpdate":     existsInVault,
+		"isUpdate":     len(existing) > 0,
+		"keys":         len(keys),
 	})
 
 	// Merge and push
-	vaultSecrets[opts.Key] = opts.Value
+	for k, v := range values {
+		vaultSecrets[k] = v
+	}
 
-	err = deps.UI.Spin("Pushing to vault...", func() error {
-		_, pushErr := client.PushSecrets(ctx, repo, envName, vaultSecrets)
+	idempotencyKey := uuid.NewString()
+	err = s.Spin("Pushing to vault...", func() error {
+		_, pushErr := s.Client.PushSecrets(s.Ctx, repo, envName, vaultSecrets, idempotencyKey)
 		return pushErr
 	})
-
 	if err != nil {
-		if isAuthError(err) {
-			newToken, authErr := handleAuthError(err, deps)
-			if authErr != nil {
-				return authErr
+		analytics.Track(analytics.EventError, map[string]interface{}{
+			"command": "set",
+			"error":   err.Error(),
+		})
+		if apiErr, ok := err.(*api.APIError); ok {
+			deps.UI.Error(apiErr.Error())
+			explainProtectionViolation(apiErr, deps)
+			if apiErr.UpgradeURL != "" {
+				deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
 			}
-			client = deps.APIFactory.NewClient(newToken)
-			err = deps.UI.Spin("Pushing to vault...", func() error {
-				_, pushErr := client.PushSecrets(ctx, repo, envName, vaultSecrets)
-				return pushErr
-			})
-		}
-		if err != nil {
-			analytics.Track(analytics.EventError, map[string]interface{}{
-				"command": "set",
-				"error":   err.Error(),
-			})
-			if apiErr, ok := err.(*api.APIError); ok {
-				deps.UI.Error(apiErr.Error())
-				if apiErr.UpgradeURL != "" {
-					deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(apiErr.UpgradeURL)))
-				}
-			} else {
-				deps.UI.Error(err.Error())
-			}
-			return err
+		} else {
+			deps.UI.Error(err.Error())
 		}
+		return err
 	}
+	forgetPrefetched(repo, envName, deps)
 
-	if existsInVault {
+	switch {
+	case len(keys) > 1:
+		deps.UI.Success(fmt.Sprintf("Set %d secrets in vault (%s): %d added, %d updated", len(keys), envName, len(added), len(existing)))
+	case len(existing) > 0:
 		deps.UI.Success(fmt.Sprintf("Updated %s in vault (%s)", opts.Key, envName))
-	} else {
+	default:
 		deps.UI.Success(fmt.Sprintf("Added %s to vault (%s)", opts.Key, envName))
 	}
 
@@ -330,17 +401,18 @@ func runSetRemote(opts SetOptions, deps *Dependencies) error {
 	return nil
 }
 
-// formatEnvContent formats a map as env file content (sorted for deterministic output)
+// formatEnvContent formats a map as env file content (sorted for deterministic
+// output), quoting values that need it
 func formatEnvContent(secrets map[string]string) string {
-	keys := make([]string, 0, len(secrets))
-	for k := range secrets {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	return env.Apply("", secrets)
+}
 
-	var lines []string
-	for _, k := range keys {
-		lines = append(lines, fmt.Sprintf("%s=%s", k, secrets[k]))
+// isValidKeyName returns true if key only has alphanumeric characters and underscores
+func isValidKeyName(key string) bool {
+	for _, c := range key {
+		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
+			return false
+		}
 	}
-	return strings.Join(lines, "\n") + "\n"
+	return true
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/keywaysh/cli/internal/api"
@@ -689,3 +690,76 @@ func TestFormatEnvContent_SortsKeys(t *testing.T) {
 		t.Errorf("expected sorted output:\n%s\ngot:\n%s", expected, result)
 	}
 }
+
+func TestRunSetWithDeps_UpdateExistingSecret_ConfirmSkipped(t *testing.T) {
+	deps, _, _, _, fsMock, _, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".keyway.json"] = []byte(`{"confirm": [{"environments": ["development"], "skipUpTo": 1}]}`)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old_value"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secret saved"}
+
+	err := runSetWithDeps(SetOptions{Key: "API_KEY", Value: "new_value", EnvName: "development", EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("expected the update not to need --yes, got %v", err)
+	}
+	if apiMock.PushedSecrets["API_KEY"] != "new_value" {
+		t.Errorf("expected the update to be pushed, got %v", apiMock.PushedSecrets)
+	}
+}
+
+func TestParseSetArgs(t *testing.T) {
+	key, value, more, err := parseSetArgs([]string{"API_KEY=sk", "LOG_LEVEL=info", "URL=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "API_KEY" || value != "sk" || more["LOG_LEVEL"] != "info" || more["URL"] != "a=b" {
+		t.Errorf("unexpected parse: %s=%s %v", key, value, more)
+	}
+
+	if key, value, more, err := parseSetArgs([]string{"API_KEY", "sk"}); err != nil || key != "API_KEY" || value != "sk" || more != nil {
+		t.Errorf("expected KEY VALUE to be parsed, got %s=%s %v %v", key, value, more, err)
+	}
+	for _, args := range [][]string{{"A=1", "B"}, {"A=1", "A=2"}, {"A", "1", "2"}} {
+		if _, _, _, err := parseSetArgs(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestRunSetWithDeps_SeveralKeys(t *testing.T) {
+	deps, _, _, uiMock, _, _, apiMock := NewTestDepsWithEnv()
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = true
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old\nKEEP=me\n"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+
+	opts := SetOptions{Key: "API_KEY", Value: "new", More: map[string]string{"LOG_LEVEL": "info"}, EnvName: "production", EnvFlagSet: true}
+	err := runSetWithDeps(opts, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{"API_KEY": "new", "LOG_LEVEL": "info", "KEEP": "me"}
+	if !reflect.DeepEqual(apiMock.PushedSecrets, want) {
+		t.Errorf("expected %v to be pushed, got %v", want, apiMock.PushedSecrets)
+	}
+	if !reflect.DeepEqual(uiMock.DiffAddedCalls, []string{"LOG_LEVEL"}) || !reflect.DeepEqual(uiMock.DiffChangedCalls, []string{"API_KEY"}) {
+		t.Errorf("expected the diff to be shown, got added %v changed %v", uiMock.DiffAddedCalls, uiMock.DiffChangedCalls)
+	}
+	if len(uiMock.ConfirmCalls) != 1 {
+		t.Errorf("expected one confirmation for the updated key, got %v", uiMock.ConfirmCalls)
+	}
+}
+
+func TestRunSetWithDeps_SeveralKeysNeedYesToUpdate(t *testing.T) {
+	deps, _, _, _, _, _, apiMock := NewTestDepsWithEnv()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old\n"}
+
+	opts := SetOptions{Key: "API_KEY", Value: "new", More: map[string]string{"LOG_LEVEL": "info"}, EnvName: "production", EnvFlagSet: true}
+	if err := runSetWithDeps(opts, deps); err == nil {
+		t.Fatal("expected --yes to be required in non-interactive mode")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Error("expected nothing to be pushed")
+	}
+}
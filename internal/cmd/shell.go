@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/injector"
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start a subshell with secrets loaded",
+	Long: `Start an interactive subshell with the secrets of an environment exported.
+
+The prompt shows the active environment, and KEYWAY_SHELL is set to its name.
+Secrets only live in the subshell's memory: they are gone when you exit it,
+and nothing is written to disk.`,
+	Args: cobra.NoArgs,
+	RunE: runShell,
+}
+
+func init() {
+	shellCmd.Flags().StringP("env", "e", "development", "Environment name")
+	shellCmd.Flags().String("shell", "", "Shell to start (default: $SHELL)")
+	shellCmd.Flags().String("remap", "", "Rename keys with a remap declared in .keyway.json")
+	shellCmd.Flags().Bool("expand", false, "Resolve ${VAR} references in values")
+}
+
+// ShellOptions contains the parsed flags for the shell command
+type ShellOptions struct {
+	EnvName    string
+	EnvFlagSet bool
+	Shell      string
+	Remap      string
+	Expand     bool
+}
+
+// runShell is the entry point for the shell command (uses default dependencies)
+func runShell(cmd *cobra.Command, args []string) error {
+	opts := ShellOptions{
+		EnvFlagSet: cmd.Flags().Changed("env"),
+	}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Shell, _ = cmd.Flags().GetString("shell")
+	opts.Remap, _ = cmd.Flags().GetString("remap")
+	opts.Expand, _ = cmd.Flags().GetBool("expand")
+
+	return runShellWithDeps(opts, defaultDeps)
+}
+
+// runShellWithDeps is the testable version of runShell
+func runShellWithDeps(opts ShellOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return startShell(s, opts)
+	}, withIntro("shell"), withRepo, withLogin)
+}
+
+// startShell starts a subshell with the secrets of the environment
+func startShell(s *Session, opts ShellOptions) error {
+	deps := s.Deps
+	if active := os.Getenv(injector.ShellEnvVar); active != "" {
+		err := fmt.Errorf("already in a keyway shell (%s)", active)
+		deps.UI.Error(err.Error())
+		deps.UI.Message(deps.UI.Dim("Type exit to leave it first"))
+		return err
+	}
+
+	envName := opts.EnvName
+	if !opts.EnvFlagSet && deps.UI.IsInteractive() {
+		vaultEnvs, err := s.Client.GetVaultEnvironments(s.Ctx, s.Repo)
+		if err != nil || len(vaultEnvs) == 0 {
+			vaultEnvs = []string{"development", "staging", "production"}
+		}
+
+		// Put the default (development) first
+		for i, e := range vaultEnvs {
+			if e == "development" {
+				vaultEnvs[0], vaultEnvs[i] = vaultEnvs[i], vaultEnvs[0]
+				break
+			}
+		}
+
+		selected, err := deps.UI.Select("Environment:", vaultEnvs)
+		if err != nil {
+			return err
+		}
+		envName = selected
+	}
+
+	deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(envName)))
+
+	var vaultContent string
+	err := s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, envName)
+		if err != nil {
+			return err
+		}
+		vaultContent = resp.Content
+		return nil
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok {
+			deps.UI.Error(apiErr.Error())
+		} else {
+			deps.UI.Error(err.Error())
+		}
+		return err
+	}
+
+	secrets, err := runSecrets(vaultContent, opts.Remap, opts.Expand, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	secrets, cleanupFiles, err := injector.WriteFiles(secrets)
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write file secrets: %s", err.Error()))
+		return err
+	}
+	defer cleanupFiles()
+
+	shellPath := opts.Shell
+	if shellPath == "" {
+		shellPath = injector.DefaultShell()
+	}
+	shell, err := injector.PrepareShell(shellPath, envName)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	defer shell.Cleanup()
+
+	// Shell variables go last so that a secret cannot override the prompt setup
+	shellEnv := make(map[string]string, len(secrets)+len(shell.Env))
+	for k, v := range secrets {
+		shellEnv[k] = v
+	}
+	for k, v := range shell.Env {
+		shellEnv[k] = v
+	}
+
+	deps.UI.Success(fmt.Sprintf("Loaded %d secrets into a %s shell", len(secrets), envName))
+	deps.UI.Message(deps.UI.Dim("Type exit to leave, secrets are dropped with the shell"))
+
+	if err := deps.CmdRunner.RunCommand(shell.Command, shell.Args, shellEnv); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	deps.UI.Outro(fmt.Sprintf("Left the %s shell", envName))
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/injector"
+)
+
+func TestRunShellWithDeps_Success(t *testing.T) {
+	t.Setenv(injector.ShellEnvVar, "")
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret123\nPS1=oops"}
+
+	err := runShellWithDeps(ShellOptions{EnvName: "staging", EnvFlagSet: true, Shell: "/bin/sh"}, deps)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cmdRunner.LastCommand != "/bin/sh" {
+		t.Errorf("expected /bin/sh, got %q", cmdRunner.LastCommand)
+	}
+	if cmdRunner.LastSecrets["API_KEY"] != "secret123" {
+		t.Errorf("expected API_KEY to be exported, got %q", cmdRunner.LastSecrets["API_KEY"])
+	}
+	if cmdRunner.LastSecrets[injector.ShellEnvVar] != "staging" {
+		t.Errorf("expected %s=staging, got %q", injector.ShellEnvVar, cmdRunner.LastSecrets[injector.ShellEnvVar])
+	}
+	if !strings.HasPrefix(cmdRunner.LastSecrets["PS1"], "(keyway:staging)") {
+		t.Errorf("expected prompt to show the environment, got %q", cmdRunner.LastSecrets["PS1"])
+	}
+	if len(uiMock.OutroCalls) == 0 || uiMock.OutroCalls[0] != "Left the staging shell" {
+		t.Errorf("unexpected outro: %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunShellWithDeps_Nested(t *testing.T) {
+	t.Setenv(injector.ShellEnvVar, "production")
+	deps, _, _, _, cmdRunner, _ := NewTestDepsWithRunner()
+
+	err := runShellWithDeps(ShellOptions{EnvName: "staging", EnvFlagSet: true, Shell: "/bin/sh"}, deps)
+
+	if err == nil || !strings.Contains(err.Error(), "already in a keyway shell (production)") {
+		t.Errorf("expected nested shell error, got %v", err)
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected no shell to be started")
+	}
+}
+
+func TestRunShellWithDeps_APIError(t *testing.T) {
+	t.Setenv(injector.ShellEnvVar, "")
+	deps, _, _, uiMock, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullError = &api.APIError{StatusCode: 404, Detail: "Environment not found"}
+
+	err := runShellWithDeps(ShellOptions{EnvName: "staging", EnvFlagSet: true, Shell: "/bin/sh"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(uiMock.ErrorCalls) == 0 {
+		t.Error("expected error to be shown")
+	}
+	if cmdRunner.LastCommand != "" {
+		t.Error("expected no shell to be started")
+	}
+}
+
+func TestRunShellWithDeps_ShellError(t *testing.T) {
+	t.Setenv(injector.ShellEnvVar, "")
+	deps, _, _, _, cmdRunner, apiMock := NewTestDepsWithRunner()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret123"}
+	cmdRunner.RunError = errors.New("failed to start command")
+
+	err := runShellWithDeps(ShellOptions{EnvName: "staging", EnvFlagSet: true, Shell: "/bin/nope"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
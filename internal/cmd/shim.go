@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var shimCmd = &cobra.Command{
+	Use:   "shim <npm|yarn|pnpm> [script...]",
+	Short: "Make package.json scripts run under keyway run",
+	Long: `Patch package.json so scripts transparently run with secrets injected.
+
+"npm run dev" keeps working as before, but runs under "keyway run".
+Without script names, every script except install/publish lifecycle hooks is shimmed.
+Scripts that need a shell (chained commands, variables, redirects, globs or a
+leading NAME=value) are run by sh -c under keyway run, so that every command
+and every $VAR sees the secrets.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runShim,
+}
+
+func init() {
+	shimCmd.Flags().StringP("env", "e", "development", "Environment to inject")
+	shimCmd.Flags().Bool("remove", false, "Remove keyway shims from scripts")
+	shimCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+}
+
+// shimPrefixRegex matches the prefix added by keyway shim
+var shimPrefixRegex = regexp.MustCompile(`^keyway run(?: -e \S+)? -- `)
+
+// shimShellRegex matches a script wrapped in sh -c by keyway shim
+var shimShellRegex = regexp.MustCompile(`^sh -c '((?:[^']|'\\'')*)'$`)
+
+// shimAssignRegex matches a script starting with a variable assignment
+var shimAssignRegex = regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_]*=`)
+
+// shimShellChars are the characters that make a script need a shell
+const shimShellChars = "&|;\n$`<>*?[]{}()~\\'\"#"
+
+// lifecycleScripts are run by the package manager itself and are never shimmed by default
+var lifecycleScripts = map[string]bool{
+	"preinstall": true, "install": true, "postinstall": true,
+	"prepublish": true, "prepublishOnly": true, "publish": true, "postpublish": true,
+	"prepack": true, "postpack": true, "prepare": true,
+	"preuninstall": true, "uninstall": true, "postuninstall": true,
+}
+
+// ShimOptions contains the parsed flags for the shim command
+type ShimOptions struct {
+	Manager string
+	Scripts []string
+	EnvName string
+	Remove  bool
+	Yes     bool
+}
+
+// runShim is the entry point for the shim command (uses default dependencies)
+func runShim(cmd *cobra.Command, args []string) error {
+	opts := ShimOptions{
+		Manager: args[0],
+		Scripts: args[1:],
+	}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Remove, _ = cmd.Flags().GetBool("remove")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runShimWithDeps(opts, defaultDeps)
+}
+
+// runShimWithDeps is the testable version of runShim
+func runShimWithDeps(opts ShimOptions, deps *Dependencies) error {
+	deps.UI.Intro("shim")
+
+	switch opts.Manager {
+	case "npm", "yarn", "pnpm":
+	default:
+		deps.UI.Error(fmt.Sprintf("Unsupported package manager: %s", opts.Manager))
+		deps.UI.Message(deps.UI.Dim("Supported: npm, yarn, pnpm"))
+		return fmt.Errorf("unsupported package manager: %s", opts.Manager)
+	}
+
+	const packageFile = "package.json"
+	content, err := deps.FS.ReadFile(packageFile)
+	if err != nil {
+		deps.UI.Error("No package.json found in the current directory")
+		return err
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to parse package.json: %s", err.Error()))
+		return err
+	}
+	if len(pkg.Scripts) == 0 {
+		deps.UI.Warn("No scripts found in package.json")
+		return nil
+	}
+
+	selected := map[string]bool{}
+	for _, name := range opts.Scripts {
+		if _, ok := pkg.Scripts[name]; !ok {
+			deps.UI.Error(fmt.Sprintf("Script not found: %s", name))
+			return fmt.Errorf("script not found: %s", name)
+		}
+		selected[name] = true
+	}
+
+	// Compute the new value for each affected script
+	updates := map[string]string{}
+	for name, value := range pkg.Scripts {
+		if len(selected) > 0 && !selected[name] {
+			continue
+		}
+		if opts.Remove {
+			if isShimmed(value) {
+				updates[name] = unshimScript(value)
+			}
+			continue
+		}
+		if len(selected) == 0 && lifecycleScripts[name] {
+			continue
+		}
+		if shimmed := shimScript(value, opts.EnvName); shimmed != value {
+			updates[name] = shimmed
+		}
+	}
+
+	if len(updates) == 0 {
+		if opts.Remove {
+			deps.UI.Info("No shimmed scripts found")
+		} else {
+			deps.UI.Info("All selected scripts are already shimmed")
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(updates))
+	for name := range updates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deps.UI.Message("")
+	for _, name := range names {
+		deps.UI.DiffChanged(name)
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("    %s", updates[name])))
+	}
+	deps.UI.Message("")
+
+	action := "Shim"
+	if opts.Remove {
+		action = "Unshim"
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("%s %d script(s) in package.json?", action, len(names)), true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	newContent, missed := rewritePackageScripts(content, pkg.Scripts, updates)
+	if len(missed) > 0 {
+		deps.UI.Warn(fmt.Sprintf("Could not rewrite %s (unusual formatting), edit them by hand", strings.Join(missed, ", ")))
+	}
+
+	if err := deps.FS.WriteFile(packageFile, newContent, 0644); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write package.json: %s", err.Error()))
+		return err
+	}
+
+	if opts.Remove {
+		deps.UI.Success(fmt.Sprintf("Removed keyway from %d script(s)", len(names)-len(missed)))
+	} else {
+		deps.UI.Success(fmt.Sprintf("Shimmed %d script(s)", len(names)-len(missed)))
+		for _, name := range names {
+			if !slices.Contains(missed, name) {
+				deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Run as usual: %s run %s", opts.Manager, name)))
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// isShimmed returns true if a script already runs under keyway
+func isShimmed(script string) bool {
+	return shimPrefixRegex.MatchString(script)
+}
+
+// shimScript prefixes a script with keyway run, replacing any existing shim.
+// keyway run execs its argv, and package managers run scripts with sh -c: a
+// script needing a shell, such as "next build && next start" or
+// "NODE_ENV=production next build", is run by a shell under keyway run, for
+// every command of it to get the secrets and its $VARs to expand to them.
+func shimScript(script, envName string) string {
+	base := unshimScript(script)
+	if needsShell(base) {
+		base = "sh -c " + shellQuote(base)
+	}
+	if envName == "" || envName == "development" {
+		return "keyway run -- " + base
+	}
+	return fmt.Sprintf("keyway run -e %s -- %s", envName, base)
+}
+
+// needsShell returns true if a script cannot be exec'd as a plain argv
+func needsShell(script string) bool {
+	return strings.ContainsAny(script, shimShellChars) || shimAssignRegex.MatchString(script)
+}
+
+// unshimScript strips the keyway run prefix from a script, and the sh -c
+// wrapping a script that needs a shell
+func unshimScript(script string) string {
+	if !isShimmed(script) {
+		return script
+	}
+	base := shimPrefixRegex.ReplaceAllString(script, "")
+	if m := shimShellRegex.FindStringSubmatch(base); m != nil {
+		return strings.ReplaceAll(m[1], `'\''`, "'")
+	}
+	return base
+}
+
+// rewritePackageScripts replaces script values in place so that key order,
+// indentation and the rest of package.json are left untouched.
+// Returns the new content and the names of scripts that could not be located.
+func rewritePackageScripts(content []byte, scripts, updates map[string]string) ([]byte, []string) {
+	var missed []string
+	result := content
+	for name, newValue := range updates {
+		pattern := regexp.MustCompile(regexp.QuoteMeta(jsonString(name)) + `(\s*:\s*)` + regexp.QuoteMeta(jsonString(scripts[name])))
+		loc := pattern.FindSubmatchIndex(result)
+		if loc == nil {
+			missed = append(missed, name)
+			continue
+		}
+		var buf bytes.Buffer
+		buf.Write(result[:loc[0]])
+		buf.WriteString(jsonString(name))
+		buf.Write(result[loc[2]:loc[3]])
+		buf.WriteString(jsonString(newValue))
+		buf.Write(result[loc[1]:])
+		result = buf.Bytes()
+	}
+	sort.Strings(missed)
+	return result, missed
+}
+
+// jsonString encodes s as a JSON string literal without HTML escaping
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testPackageJSON = `{
+  "name": "my-app",
+  "scripts": {
+    "dev": "next dev",
+    "test": "jest --coverage",
+    "postinstall": "patch-package"
+  },
+  "dependencies": {
+    "next": "14.0.0"
+  }
+}
+`
+
+func TestShimScript(t *testing.T) {
+	tests := []struct {
+		script   string
+		env      string
+		expected string
+	}{
+		{"next dev", "development", "keyway run -- next dev"},
+		{"next dev", "", "keyway run -- next dev"},
+		{"next dev", "staging", "keyway run -e staging -- next dev"},
+		{"keyway run -- next dev", "staging", "keyway run -e staging -- next dev"},
+		{"keyway run -e staging -- next dev", "development", "keyway run -- next dev"},
+		{"next build && next start", "", "keyway run -- sh -c 'next build && next start'"},
+		{"tsc; echo 'done'", "staging", `keyway run -e staging -- sh -c 'tsc; echo '\''done'\'''`},
+		{"keyway run -- sh -c 'a | b'", "staging", "keyway run -e staging -- sh -c 'a | b'"},
+		// keyway run would exec NODE_ENV=x as the command
+		{"NODE_ENV=x cmd", "", "keyway run -- sh -c 'NODE_ENV=x cmd'"},
+		// npm's shell would expand $SECRET before keyway run injects it
+		{"echo $SECRET > out", "", "keyway run -- sh -c 'echo $SECRET > out'"},
+		{"node ${ENTRY:-server.js}", "staging", "keyway run -e staging -- sh -c 'node ${ENTRY:-server.js}'"},
+		{"eslint src/*.ts", "", "keyway run -- sh -c 'eslint src/*.ts'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script+"/"+tt.env, func(t *testing.T) {
+			if got := shimScript(tt.script, tt.env); got != tt.expected {
+				t.Errorf("shimScript(%q, %q) = %q, want %q", tt.script, tt.env, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnshimScript(t *testing.T) {
+	tests := []struct {
+		script   string
+		expected string
+	}{
+		{"keyway run -- next dev", "next dev"},
+		{"keyway run -e production -- node server.js", "node server.js"},
+		{"next dev", "next dev"},
+		{"echo keyway run -- x", "echo keyway run -- x"},
+		{"keyway run -- sh -c 'next build && next start'", "next build && next start"},
+		{`keyway run -e staging -- sh -c 'tsc; echo '\''done'\'''`, "tsc; echo 'done'"},
+		{"sh -c 'a && b'", "sh -c 'a && b'"},
+		{"keyway run -- sh -c 'NODE_ENV=x cmd'", "NODE_ENV=x cmd"},
+		{"keyway run -- sh -c 'echo $SECRET > out'", "echo $SECRET > out"},
+	}
+
+	for _, tt := range tests {
+		if got := unshimScript(tt.script); got != tt.expected {
+			t.Errorf("unshimScript(%q) = %q, want %q", tt.script, got, tt.expected)
+		}
+	}
+}
+
+func TestRewritePackageScripts_PreservesLayout(t *testing.T) {
+	scripts := map[string]string{"dev": "next dev", "test": "jest --coverage"}
+	updates := map[string]string{"dev": "keyway run -- next dev"}
+
+	result, missed := rewritePackageScripts([]byte(testPackageJSON), scripts, updates)
+
+	if len(missed) != 0 {
+		t.Fatalf("unexpected missed scripts: %v", missed)
+	}
+	expected := strings.Replace(testPackageJSON, `"dev": "next dev"`, `"dev": "keyway run -- next dev"`, 1)
+	if string(result) != expected {
+		t.Errorf("unexpected content:\n%s", result)
+	}
+}
+
+func TestRunShimWithDeps_ShimsAllButLifecycle(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	fsMock.Files["package.json"] = []byte(testPackageJSON)
+
+	err := runShimWithDeps(ShimOptions{Manager: "npm", EnvName: "development", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written := string(fsMock.Written["package.json"])
+	if !strings.Contains(written, `"dev": "keyway run -- next dev"`) {
+		t.Errorf("expected dev to be shimmed, got:\n%s", written)
+	}
+	if !strings.Contains(written, `"test": "keyway run -- jest --coverage"`) {
+		t.Errorf("expected test to be shimmed, got:\n%s", written)
+	}
+	if !strings.Contains(written, `"postinstall": "patch-package"`) {
+		t.Errorf("expected postinstall to be left alone, got:\n%s", written)
+	}
+	if len(uiMock.SuccessCalls) == 0 {
+		t.Error("expected Success to be called")
+	}
+}
+
+func TestRunShimWithDeps_SuggestsRewrittenScript(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	// build cannot be located with its escaped space, start can
+	fsMock.Files["package.json"] = []byte(`{"scripts": {"build": "next\u0020build", "start": "next start"}}`)
+
+	if err := runShimWithDeps(ShimOptions{Manager: "npm", EnvName: "development", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(uiMock.MessageCalls, "Run as usual: npm run start") {
+		t.Errorf("expected a shimmed script to be suggested, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunShimWithDeps_SelectedScripts(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["package.json"] = []byte(testPackageJSON)
+
+	err := runShimWithDeps(ShimOptions{Manager: "yarn", Scripts: []string{"test"}, EnvName: "staging", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written := string(fsMock.Written["package.json"])
+	if !strings.Contains(written, `"dev": "next dev"`) {
+		t.Errorf("expected dev untouched, got:\n%s", written)
+	}
+	if !strings.Contains(written, `"test": "keyway run -e staging -- jest --coverage"`) {
+		t.Errorf("expected test shimmed with staging, got:\n%s", written)
+	}
+}
+
+func TestRunShimWithDeps_Remove(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	shimmed := strings.Replace(testPackageJSON, `"next dev"`, `"keyway run -- next dev"`, 1)
+	fsMock.Files["package.json"] = []byte(shimmed)
+
+	err := runShimWithDeps(ShimOptions{Manager: "npm", Remove: true, Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(fsMock.Written["package.json"]) != testPackageJSON {
+		t.Errorf("expected original package.json, got:\n%s", fsMock.Written["package.json"])
+	}
+}
+
+func TestRunShimWithDeps_UnknownScript(t *testing.T) {
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	fsMock.Files["package.json"] = []byte(testPackageJSON)
+
+	err := runShimWithDeps(ShimOptions{Manager: "npm", Scripts: []string{"deploy"}, Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for unknown script")
+	}
+	if len(uiMock.ErrorCalls) == 0 {
+		t.Error("expected UI.Error to be called")
+	}
+}
+
+func TestRunShimWithDeps_UnsupportedManager(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	err := runShimWithDeps(ShimOptions{Manager: "cargo"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for unsupported manager")
+	}
+}
+
+func TestRunShimWithDeps_RequiresYesNonInteractive(t *testing.T) {
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files["package.json"] = []byte(testPackageJSON)
+
+	err := runShimWithDeps(ShimOptions{Manager: "npm", EnvName: "development"}, deps)
+
+	if err == nil {
+		t.Fatal("expected confirmation error")
+	}
+	if len(fsMock.Written) != 0 {
+		t.Error("expected no write without confirmation")
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var shipCmd = &cobra.Command{
+	Use:     "ship",
+	Aliases: []string{"scp"},
+	Short:   "Stream an environment to a remote host over SSH",
+	Long: `Pull an environment and write it to a file on a remote host over SSH.
+
+Secrets are streamed straight to ssh and never written to local disk. The remote
+file is written atomically with restrictive permissions (0600 by default).
+Uses your ssh config and agent, so any host you can "ssh" into works.`,
+	Args: cobra.NoArgs,
+	RunE: runShip,
+}
+
+func init() {
+	shipCmd.Flags().StringP("env", "e", "", "Environment to ship (default: production)")
+	shipCmd.Flags().String("host", "", "SSH destination, e.g. deploy@web1")
+	shipCmd.Flags().String("path", "", "Absolute path of the env file on the remote host")
+	shipCmd.Flags().String("mode", "600", "Permissions of the remote file")
+	shipCmd.Flags().String("restart", "", "systemd service to restart after shipping (uses sudo)")
+	shipCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	_ = shipCmd.MarkFlagRequired("host")
+	_ = shipCmd.MarkFlagRequired("path")
+}
+
+// ShipOptions contains the parsed flags for the ship command
+type ShipOptions struct {
+	EnvName string
+	Host    string
+	Path    string
+	Mode    string
+	Restart string
+	Yes     bool
+}
+
+var (
+	fileModeRegex    = regexp.MustCompile(`^[0-7]{3,4}$`)
+	serviceNameRegex = regexp.MustCompile(`^[A-Za-z0-9@._:-]+$`)
+)
+
+// runShip is the entry point for the ship command (uses default dependencies)
+func runShip(cmd *cobra.Command, args []string) error {
+	opts := ShipOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Host, _ = cmd.Flags().GetString("host")
+	opts.Path, _ = cmd.Flags().GetString("path")
+	opts.Mode, _ = cmd.Flags().GetString("mode")
+	opts.Restart, _ = cmd.Flags().GetString("restart")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runShipWithDeps(opts, defaultDeps)
+}
+
+// runShipWithDeps is the testable version of runShip
+func runShipWithDeps(opts ShipOptions, deps *Dependencies) error {
+	if opts.EnvName == "" {
+		opts.EnvName = "production"
+	}
+	if opts.Mode == "" {
+		opts.Mode = "600"
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return ship(s, opts)
+	}, withIntro("ship"), withShipOptions(opts), withRepo, withEnvironment(opts.EnvName), withLogin)
+}
+
+// withShipOptions stops the command before any setup when the options are invalid
+func withShipOptions(opts ShipOptions) Middleware {
+	return func(next Handler) Handler {
+		return func(s *Session) error {
+			if err := validateShipOptions(opts); err != nil {
+				s.Deps.UI.Error(err.Error())
+				return err
+			}
+			return next(s)
+		}
+	}
+}
+
+// ship pulls the environment and writes it to the file on the host over ssh
+func ship(s *Session, opts ShipOptions) error {
+	deps := s.Deps
+	deps.UI.Step(fmt.Sprintf("Destination: %s", deps.UI.Value(fmt.Sprintf("%s:%s", opts.Host, opts.Path))))
+
+	var content string
+	err := s.Spin("Downloading secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		analytics.Track(analytics.EventError, map[string]interface{}{
+			"command": "ship",
+			"error":   err.Error(),
+		})
+		deps.UI.Error(err.Error())
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.UpgradeURL != "" {
+			deps.UI.Message(fmt.Sprintf("Upgrade: %s", deps.UI.Link(config.DashboardLink(apiErr.UpgradeURL))))
+		}
+		return err
+	}
+
+	count := len(env.ParseVault(content))
+	if count == 0 {
+		deps.UI.Warn(fmt.Sprintf("No secrets in %s, nothing to ship", opts.EnvName))
+		return nil
+	}
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Write %d secrets to %s:%s?", count, opts.Host, opts.Path), true)
+		if !confirm {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	err = deps.UI.Spin(fmt.Sprintf("Shipping to %s...", opts.Host), func() error {
+		return deps.Remote.Run(opts.Host, remoteWriteCommand(opts.Path, opts.Mode), []byte(content))
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to write %s on %s: %s", opts.Path, opts.Host, err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Wrote %d secrets to %s:%s", count, opts.Host, opts.Path))
+
+	if opts.Restart != "" {
+		err = deps.UI.Spin(fmt.Sprintf("Restarting %s...", opts.Restart), func() error {
+			return deps.Remote.Run(opts.Host, "sudo systemctl restart "+shellQuote(opts.Restart), nil)
+		})
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to restart %s: %s", opts.Restart, err.Error()))
+			return err
+		}
+		deps.UI.Success(fmt.Sprintf("Restarted %s", opts.Restart))
+	}
+
+	analytics.Track(analytics.EventPull, map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"target":       "ssh",
+	})
+
+	return nil
+}
+
+// validateShipOptions rejects values that could be misread by ssh or the remote shell
+func validateShipOptions(opts ShipOptions) error {
+	if opts.Host == "" || strings.HasPrefix(opts.Host, "-") || strings.ContainsAny(opts.Host, " \t\n") {
+		return fmt.Errorf("invalid host: %q", opts.Host)
+	}
+	if !path.IsAbs(opts.Path) || strings.HasSuffix(opts.Path, "/") {
+		return fmt.Errorf("remote path must be an absolute file path: %q", opts.Path)
+	}
+	if !fileModeRegex.MatchString(opts.Mode) {
+		return fmt.Errorf("invalid file mode: %q", opts.Mode)
+	}
+	if opts.Restart != "" && !serviceNameRegex.MatchString(opts.Restart) {
+		return fmt.Errorf("invalid service name: %q", opts.Restart)
+	}
+	return nil
+}
+
+// remoteWriteCommand builds the shell command that writes stdin to filePath atomically.
+// The temporary file is created under umask 077 so secrets are never world-readable.
+func remoteWriteCommand(filePath, mode string) string {
+	tmp := filePath + ".keyway-tmp"
+	return fmt.Sprintf("umask 077 && mkdir -p %s && cat > %s && chmod %s %s && mv -f %s %s",
+		shellQuote(path.Dir(filePath)), shellQuote(tmp), mode, shellQuote(tmp), shellQuote(tmp), shellQuote(filePath))
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRemoteWriteCommand(t *testing.T) {
+	got := remoteWriteCommand("/srv/my app/.env", "600")
+	expected := `umask 077 && mkdir -p '/srv/my app' && cat > '/srv/my app/.env.keyway-tmp' && chmod 600 '/srv/my app/.env.keyway-tmp' && mv -f '/srv/my app/.env.keyway-tmp' '/srv/my app/.env'`
+	if got != expected {
+		t.Errorf("remoteWriteCommand() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("shellQuote() = %s", got)
+	}
+}
+
+func TestValidateShipOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ShipOptions
+		wantErr bool
+	}{
+		{"valid", ShipOptions{Host: "deploy@web1", Path: "/srv/app/.env", Mode: "600"}, false},
+		{"option injection", ShipOptions{Host: "-oProxyCommand=x", Path: "/srv/app/.env", Mode: "600"}, true},
+		{"relative path", ShipOptions{Host: "web1", Path: "app/.env", Mode: "600"}, true},
+		{"directory path", ShipOptions{Host: "web1", Path: "/srv/app/", Mode: "600"}, true},
+		{"bad mode", ShipOptions{Host: "web1", Path: "/srv/app/.env", Mode: "777; rm"}, true},
+		{"bad service", ShipOptions{Host: "web1", Path: "/srv/app/.env", Mode: "600", Restart: "app; reboot"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateShipOptions(tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateShipOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunShipWithDeps_Success(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	remote := deps.Remote.(*MockRemoteRunner)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+
+	err := runShipWithDeps(ShipOptions{Host: "deploy@web1", Path: "/srv/app/.env", Restart: "myapp", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(remote.Commands) != 2 {
+		t.Fatalf("expected write and restart commands, got %v", remote.Commands)
+	}
+	if remote.Hosts[0] != "deploy@web1" || string(remote.Stdin[0]) != "API_KEY=secret\n" {
+		t.Errorf("unexpected write: host=%s stdin=%q", remote.Hosts[0], remote.Stdin[0])
+	}
+	if !strings.Contains(remote.Commands[0], "chmod 600") {
+		t.Errorf("expected default mode 600, got %s", remote.Commands[0])
+	}
+	if remote.Commands[1] != "sudo systemctl restart 'myapp'" {
+		t.Errorf("unexpected restart command: %s", remote.Commands[1])
+	}
+	if len(uiMock.SuccessCalls) != 2 {
+		t.Errorf("expected 2 Success calls, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunShipWithDeps_RequiresConfirmation(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	remote := deps.Remote.(*MockRemoteRunner)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+
+	err := runShipWithDeps(ShipOptions{Host: "web1", Path: "/srv/app/.env"}, deps)
+
+	if err == nil {
+		t.Fatal("expected confirmation error")
+	}
+	if len(remote.Commands) != 0 {
+		t.Error("expected nothing to be shipped")
+	}
+}
+
+func TestRunShipWithDeps_RemoteError(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	remote := deps.Remote.(*MockRemoteRunner)
+	remote.RunError = errors.New("exit status 255")
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\n"}
+
+	err := runShipWithDeps(ShipOptions{Host: "web1", Path: "/srv/app/.env", Restart: "myapp", Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(remote.Commands) != 1 {
+		t.Errorf("expected no restart after failed write, got %v", remote.Commands)
+	}
+	if len(uiMock.ErrorCalls) == 0 {
+		t.Error("expected UI.Error to be called")
+	}
+}
+
+func TestRunShipWithDeps_EmptyEnvironment(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	remote := deps.Remote.(*MockRemoteRunner)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+
+	err := runShipWithDeps(ShipOptions{Host: "web1", Path: "/srv/app/.env", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(remote.Commands) != 0 || len(uiMock.WarnCalls) == 0 {
+		t.Error("expected a warning and nothing shipped")
+	}
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// snapshotNamePattern matches the names accepted for named snapshots, such as v1.42-release
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Tag the state of an environment to compare or restore it later",
+	Long: `Tag the exact state of an environment under a name, for example the secrets
+used by a release, then compare the environment with it or restore it.
+
+Named snapshots are kept until deleted from the dashboard, independently of the
+automatic version history used by keyway diff --against and keyway undo.`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Save the current state of an environment under a name",
+	Long: `Save the current state of an environment under a name. Names start with a
+letter or digit and contain letters, digits, dots, dashes and underscores, and
+are unique per environment.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotCreate,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore NAME",
+	Short: "Put an environment back in the state of a named snapshot",
+	Long: `Put an environment back in the state of a named snapshot. The keys that will
+change are shown first, and restoring asks for a confirmation unless --yes is
+passed. The state before the restore can be brought back with keyway undo.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotRestore,
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff NAME",
+	Short: "Compare an environment with a named snapshot",
+	Long:  `Compare the current state of an environment with a named snapshot of it.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotDiff,
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringP("env", "e", "development", "Environment name")
+
+	snapshotRestoreCmd.Flags().StringP("env", "e", "development", "Environment name")
+	snapshotRestoreCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	snapshotDiffCmd.Flags().StringP("env", "e", "development", "Environment name")
+	snapshotDiffCmd.Flags().Bool("show-values", false, "Show actual value differences (sensitive!)")
+	snapshotDiffCmd.Flags().Bool("keys-only", false, "Only show key names, no status details")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotDiffCmd)
+}
+
+// SnapshotOptions contains the parsed flags for the snapshot subcommands
+type SnapshotOptions struct {
+	Name       string
+	EnvName    string
+	Yes        bool
+	ShowValues bool
+	KeysOnly   bool
+	JSONOutput bool
+}
+
+// parseSnapshotOptions reads the flags shared by the snapshot subcommands
+func parseSnapshotOptions(cmd *cobra.Command, args []string) SnapshotOptions {
+	opts := SnapshotOptions{Name: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+	opts.ShowValues, _ = cmd.Flags().GetBool("show-values")
+	opts.KeysOnly, _ = cmd.Flags().GetBool("keys-only")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	return opts
+}
+
+// runSnapshotCreate is the entry point for the snapshot create command (uses default dependencies)
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	return runSnapshotCreateWithDeps(parseSnapshotOptions(cmd, args), defaultDeps)
+}
+
+// runSnapshotCreateWithDeps is the testable version of runSnapshotCreate
+func runSnapshotCreateWithDeps(opts SnapshotOptions, deps *Dependencies) error {
+	if err := validateSnapshotName(opts.Name, deps); err != nil {
+		return err
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return snapshotCreate(s, opts)
+	}, withIntro("snapshot create"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// snapshotCreate saves the current state of an environment under a name
+func snapshotCreate(s *Session, opts SnapshotOptions) error {
+	deps := s.Deps
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "creating snapshots", deps); err != nil {
+		return err
+	}
+
+	var snapshot *api.Snapshot
+	err := s.Spin("Creating snapshot...", func() error {
+		var err error
+		snapshot, err = s.Client.CreateNamedSnapshot(s.Ctx, s.Repo, s.EnvName, opts.Name)
+		return err
+	})
+	if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 409 {
+		deps.UI.Error(fmt.Sprintf("A snapshot named %s already exists in %s", opts.Name, s.EnvName))
+		return err
+	}
+	if err != nil {
+		return reportEnvError("snapshot create", err, deps)
+	}
+
+	analytics.Track("cli_snapshot_create", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+	})
+
+	if opts.JSONOutput {
+		return ui.PrintJSON(snapshot)
+	}
+	deps.UI.Success(fmt.Sprintf("Saved %s as %s", s.EnvName, deps.UI.Value(opts.Name)))
+	deps.UI.Outro(fmt.Sprintf("Compare with: keyway snapshot diff %s -e %s", opts.Name, s.EnvName))
+	return nil
+}
+
+// runSnapshotRestore is the entry point for the snapshot restore command (uses default dependencies)
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	return runSnapshotRestoreWithDeps(parseSnapshotOptions(cmd, args), defaultDeps)
+}
+
+// runSnapshotRestoreWithDeps is the testable version of runSnapshotRestore
+func runSnapshotRestoreWithDeps(opts SnapshotOptions, deps *Dependencies) error {
+	if err := validateSnapshotName(opts.Name, deps); err != nil {
+		return err
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return snapshotRestore(s, opts)
+	}, withIntro("snapshot restore"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// snapshotRestore puts an environment back in the state of a named snapshot
+func snapshotRestore(s *Session, opts SnapshotOptions) error {
+	deps, envName := s.Deps, s.EnvName
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "restoring snapshots", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+
+	snapshot, current, err := fetchNamedSnapshot(s, opts.Name)
+	if err != nil {
+		return err
+	}
+	saved := env.ParseVault(snapshot.Content)
+
+	result := compareSecrets(envName, opts.Name, current, saved, false)
+	changes := result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2 + result.Stats.Different
+	if changes == 0 {
+		deps.UI.Success(fmt.Sprintf("%s is already in the state of %s", envName, opts.Name))
+		return nil
+	}
+
+	deps.UI.Message("")
+	deps.UI.Message(fmt.Sprintf("Restoring %s will change:", opts.Name))
+	for _, key := range result.OnlyInEnv2 {
+		deps.UI.DiffAdded(key)
+	}
+	for _, entry := range result.Different {
+		deps.UI.DiffChanged(entry.Key)
+	}
+	for _, key := range result.OnlyInEnv1 {
+		deps.UI.DiffRemoved(key)
+	}
+	deps.UI.Message("")
+
+	if confirmationNeeded(envName, changes, opts.Yes, deps) {
+		if !deps.UI.IsInteractive() {
+			deps.UI.Error("Use --yes to restore a snapshot in non-interactive mode")
+			return fmt.Errorf("confirmation required")
+		}
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Restore %s to %s?", envName, opts.Name), false)
+		if !confirm {
+			deps.UI.Warn("Restore aborted.")
+			return nil
+		}
+	}
+
+	analytics.Track("cli_snapshot_restore", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  envName,
+		"changes":      changes,
+	})
+
+	// Snapshot the current state first, so that keyway undo can revert the restore
+	var before *api.Snapshot
+	err = s.Spin("Restoring snapshot...", func() error {
+		var err error
+		before, err = s.Client.CreateSnapshot(s.Ctx, s.Repo, envName)
+		if err != nil {
+			return err
+		}
+		return s.Client.RestoreSnapshot(s.Ctx, s.Repo, envName, snapshot.ID)
+	})
+	if err != nil {
+		return reportEnvError("snapshot restore", err, deps)
+	}
+	forgetPrefetched(s.Repo, envName, deps)
+
+	deps.UI.Success(fmt.Sprintf("Restored %s to %s", envName, opts.Name))
+	if before != nil && before.ID != "" {
+		record := pushRecord{Repo: s.Repo, Env: envName, SnapshotID: before.ID, PushedAt: time.Now().UTC()}
+		if err := recordPush(record, deps); err == nil {
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Made a mistake? Run keyway undo within %d minutes", int(undoWindow.Minutes()))))
+		}
+	}
+	deps.UI.Outro("")
+	return nil
+}
+
+// runSnapshotDiff is the entry point for the snapshot diff command (uses default dependencies)
+func runSnapshotDiff(cmd *cobra.Command, args []string) error {
+	return runSnapshotDiffWithDeps(parseSnapshotOptions(cmd, args), defaultDeps)
+}
+
+// runSnapshotDiffWithDeps is the testable version of runSnapshotDiff
+func runSnapshotDiffWithDeps(opts SnapshotOptions, deps *Dependencies) error {
+	if err := validateSnapshotName(opts.Name, deps); err != nil {
+		return err
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return snapshotDiff(s, opts)
+	}, withIntro("snapshot diff"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// snapshotDiff compares an environment with a named snapshot of it
+func snapshotDiff(s *Session, opts SnapshotOptions) error {
+	deps, envName := s.Deps, s.EnvName
+	snapshot, current, err := fetchNamedSnapshot(s, opts.Name)
+	if err != nil {
+		return err
+	}
+	saved := env.ParseVault(snapshot.Content)
+
+	oldLabel := fmt.Sprintf("%s@%s", envName, opts.Name)
+	result := compareSecrets(oldLabel, envName, saved, current, opts.ShowValues)
+	if err := revealConfigValues(result, saved, current, deps); err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	addDiffOwners(s.Ctx, s.Client, s.Repo, result, deps)
+
+	analytics.Track("cli_snapshot_diff", map[string]interface{}{
+		"environment":       envName,
+		"differences_count": result.Stats.Different + result.Stats.OnlyInEnv1 + result.Stats.OnlyInEnv2,
+		"same_count":        result.Stats.Same,
+	})
+
+	if opts.JSONOutput {
+		return printDiffJSON(result)
+	}
+	printDiffResults(result, oldLabel, envName, opts.ShowValues, opts.KeysOnly)
+
+	deps.UI.Outro("")
+	return nil
+}
+
+// fetchNamedSnapshot returns a named snapshot of the session's environment and
+// the environment's current secrets
+func fetchNamedSnapshot(s *Session, name string) (*api.SnapshotContent, map[string]string, error) {
+	var snapshot *api.SnapshotContent
+	var current map[string]string
+	err := s.Spin(fmt.Sprintf("Fetching snapshot %s...", name), func() error {
+		var err error
+		snapshot, err = s.Client.GetSnapshot(s.Ctx, s.Repo, s.EnvName, name)
+		if err != nil {
+			return err
+		}
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		current = env.ParseVault(resp.Content)
+		return nil
+	})
+	if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 && snapshot == nil {
+		s.Deps.UI.Error(fmt.Sprintf("No snapshot named %s in %s", name, s.EnvName))
+		s.Deps.UI.Message(s.Deps.UI.Dim(fmt.Sprintf("Create it with: keyway snapshot create %s -e %s", name, s.EnvName)))
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, reportEnvError("snapshot", err, s.Deps)
+	}
+	return snapshot, current, nil
+}
+
+// validateSnapshotName rejects names that cannot be used as snapshot names
+func validateSnapshotName(name string, deps *Dependencies) error {
+	if !snapshotNamePattern.MatchString(name) {
+		deps.UI.Error(fmt.Sprintf("Invalid snapshot name %q: use letters, digits, dots, dashes and underscores", name))
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
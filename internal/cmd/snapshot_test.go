@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunSnapshotCreateWithDeps_SavesNamedSnapshot(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	err := runSnapshotCreateWithDeps(SnapshotOptions{Name: "v1.42-release", EnvName: "prod"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := apiMock.NamedSnapshots["v1.42-release"]; !ok {
+		t.Errorf("expected v1.42-release to be created, got %v", apiMock.NamedSnapshots)
+	}
+	if len(uiMock.SuccessCalls) != 1 || !strings.Contains(uiMock.SuccessCalls[0], "production") {
+		t.Errorf("unexpected success message: %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunSnapshotCreateWithDeps_NameTaken(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.NamedSnapshotError = &api.APIError{StatusCode: 409, Detail: "Snapshot name already used"}
+
+	if err := runSnapshotCreateWithDeps(SnapshotOptions{Name: "v1", EnvName: "production"}, deps); err == nil {
+		t.Fatal("expected an error for a name already used")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "A snapshot named v1 already exists in production" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunSnapshotCreateWithDeps_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "-v1", "release/1", "v1 final"} {
+		deps, _, _, _, _, apiMock := NewTestDeps()
+		if err := runSnapshotCreateWithDeps(SnapshotOptions{Name: name, EnvName: "production"}, deps); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+		if apiMock.NamedSnapshots != nil {
+			t.Errorf("nothing should be created for %q", name)
+		}
+	}
+}
+
+func TestRunSnapshotRestoreWithDeps_RestoresAndRecordsUndo(t *testing.T) {
+	deps, gitMock, _, uiMock, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\nADDED=1\nSAME=x\n"}
+	apiMock.NamedSnapshots = map[string]*api.SnapshotContent{
+		"v1": {Snapshot: api.Snapshot{ID: "snap_v1", Name: "v1"}, Content: "API_KEY=old\nREMOVED=2\nSAME=x\n"},
+	}
+	apiMock.Snapshot = &api.Snapshot{ID: "snap_before"}
+
+	err := runSnapshotRestoreWithDeps(SnapshotOptions{Name: "v1", EnvName: "production", Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RestoredSnapshot != "snap_v1" {
+		t.Errorf("expected snap_v1 to be restored, got %q", apiMock.RestoredSnapshot)
+	}
+	if !reflect.DeepEqual(uiMock.DiffAddedCalls, []string{"REMOVED"}) ||
+		!reflect.DeepEqual(uiMock.DiffChangedCalls, []string{"API_KEY"}) ||
+		!reflect.DeepEqual(uiMock.DiffRemovedCalls, []string{"ADDED"}) {
+		t.Errorf("unexpected changes shown: +%v ~%v -%v", uiMock.DiffAddedCalls, uiMock.DiffChangedCalls, uiMock.DiffRemovedCalls)
+	}
+	var history []pushRecord
+	if err := json.Unmarshal(fsMock.Written[pushHistoryPath()], &history); err != nil {
+		t.Fatalf("history not saved: %v", err)
+	}
+	if len(history) != 1 || history[0].SnapshotID != "snap_before" || history[0].Env != "production" {
+		t.Errorf("expected the state before the restore to be undoable, got %+v", history)
+	}
+}
+
+func TestRunSnapshotRestoreWithDeps_NotFound(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	if err := runSnapshotRestoreWithDeps(SnapshotOptions{Name: "v9", EnvName: "production", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error for an unknown snapshot")
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Errorf("nothing should be restored, got %q", apiMock.RestoredSnapshot)
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "No snapshot named v9 in production" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunSnapshotRestoreWithDeps_Confirmation(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\n"}
+	apiMock.NamedSnapshots = map[string]*api.SnapshotContent{
+		"v1": {Snapshot: api.Snapshot{ID: "snap_v1"}, Content: "API_KEY=old\n"},
+	}
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = false
+
+	if err := runSnapshotRestoreWithDeps(SnapshotOptions{Name: "v1", EnvName: "production"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.ConfirmCalls) != 1 || uiMock.ConfirmCalls[0] != "Restore production to v1?" {
+		t.Errorf("expected a confirmation, got %v", uiMock.ConfirmCalls)
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Errorf("nothing should be restored once declined, got %q", apiMock.RestoredSnapshot)
+	}
+
+	uiMock.Interactive = false
+	if err := runSnapshotRestoreWithDeps(SnapshotOptions{Name: "v1", EnvName: "production"}, deps); err == nil {
+		t.Fatal("expected an error without --yes in non-interactive mode")
+	}
+}
+
+func TestRunSnapshotRestoreWithDeps_NothingToRestore(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=same\n"}
+	apiMock.NamedSnapshots = map[string]*api.SnapshotContent{
+		"v1": {Snapshot: api.Snapshot{ID: "snap_v1"}, Content: "API_KEY=same\n"},
+	}
+
+	if err := runSnapshotRestoreWithDeps(SnapshotOptions{Name: "v1", EnvName: "production", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Errorf("an identical state should not be restored, got %q", apiMock.RestoredSnapshot)
+	}
+}
+
+func TestRunSnapshotDiffWithDeps_JSON(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\nADDED=1\n"}
+	apiMock.NamedSnapshots = map[string]*api.SnapshotContent{
+		"v1": {Snapshot: api.Snapshot{ID: "snap_v1"}, Content: "API_KEY=old\nREMOVED=2\n"},
+	}
+
+	output := captureStdout(t, func() {
+		if err := runSnapshotDiffWithDeps(SnapshotOptions{Name: "v1", EnvName: "production", JSONOutput: true}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var result DiffResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output, err)
+	}
+	if result.Env1 != "production@v1" || result.Env2 != "production" {
+		t.Errorf("unexpected labels: %s vs %s", result.Env1, result.Env2)
+	}
+	if !reflect.DeepEqual(result.OnlyInEnv1, []string{"REMOVED"}) || !reflect.DeepEqual(result.OnlyInEnv2, []string{"ADDED"}) || result.Stats.Different != 1 {
+		t.Errorf("unexpected diff: %+v", result)
+	}
+}
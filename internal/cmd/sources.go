@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// runSourceSecrets returns the secrets given to keyway run's command: those
+// of content, the vault's content of envName, composed with the other
+// sources of .keyway.json when it declares some
+func runSourceSecrets(ctx context.Context, client api.APIClient, repo, envName, content string, opts RunOptions, deps *Dependencies) (map[string]string, error) {
+	project, err := loadProject(deps)
+	if err != nil {
+		return nil, err
+	}
+	if len(project.Sources) == 0 {
+		return runSecrets(content, opts.Remap, opts.Expand, deps)
+	}
+	secrets, err := composeSources(ctx, client, repo, envName, content, project.Sources, deps)
+	if err != nil {
+		return nil, err
+	}
+	return commandSecrets(secrets, opts.Remap, opts.Expand, deps)
+}
+
+// sourcesStep shows the sources of .keyway.json composing the environment,
+// if any
+func sourcesStep(envName string, deps *Dependencies) {
+	project, err := loadProject(deps)
+	if err != nil || len(project.Sources) == 0 {
+		return
+	}
+	names := make([]string, len(project.Sources))
+	for i, source := range project.Sources {
+		names[i] = source.String(envName)
+	}
+	deps.UI.Step(fmt.Sprintf("Sources: %s", deps.UI.Value(strings.Join(names, " > "))))
+}
+
+// composeSources reads the keys of each source, the first source having a
+// key giving its value. The process source only gives values to keys of the
+// sources after it, not to every variable of keyway's environment.
+func composeSources(ctx context.Context, client api.APIClient, repo, envName, content string, sources []config.Source, deps *Dependencies) (map[string]string, error) {
+	layers := make([]map[string]string, len(sources))
+	for i, source := range sources {
+		keys, err := readSource(ctx, client, repo, envName, content, source, deps)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", source.String(envName), err)
+		}
+		layers[i] = keys
+	}
+
+	// From the last source to the first, each overriding the ones after it
+	secrets := make(map[string]string)
+	for i := len(sources) - 1; i >= 0; i-- {
+		if !sources[i].Process {
+			maps.Copy(secrets, layers[i])
+			continue
+		}
+		for key := range secrets {
+			if value, ok := os.LookupEnv(key); ok {
+				secrets[key] = value
+			}
+		}
+	}
+	return secrets, nil
+}
+
+// readSource returns the keys of a source, nil for the process source and
+// for an optional source that is missing
+func readSource(ctx context.Context, client api.APIClient, repo, envName, content string, source config.Source, deps *Dependencies) (map[string]string, error) {
+	switch {
+	case source.Process:
+		return nil, nil
+
+	case source.File != "":
+		data, err := deps.FS.ReadFile(config.ExpandFileTemplate(source.File, envName))
+		if err != nil {
+			if source.Optional {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return env.Parse(string(data)), nil
+
+	case source.Vault != "":
+		name := config.ExpandFileTemplate(source.Vault, envName)
+		if name == envName {
+			return env.ParseVault(content), nil
+		}
+		resp, err := client.PullSecrets(ctx, repo, name)
+		if err != nil {
+			if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 && source.Optional {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return env.ParseVault(resp.Content), nil
+
+	default:
+		return source.Defaults, nil
+	}
+}
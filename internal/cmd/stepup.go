@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// stepUpPollInterval is how often a step-up is polled when the server does not say
+var stepUpPollInterval = 2 * time.Second
+
+// stepUpTimeout bounds the wait for a step-up when the server does not say
+const stepUpTimeout = 5 * time.Minute
+
+// requiresStepUp returns true if err is a push blocked until the pusher
+// confirms it with a WebAuthn authenticator
+func requiresStepUp(err error) bool {
+	apiErr, ok := err.(*api.APIError)
+	return ok && apiErr.Protection != nil && apiErr.Protection.Rule == api.RuleWebAuthn
+}
+
+// confirmStepUp has the user confirm a write to an environment with their
+// platform authenticator (Touch ID, Windows Hello) or a security key. The
+// assertion is made in the browser, which talks to the authenticator; the CLI
+// waits for it and returns a client carrying the confirmed access.
+func confirmStepUp(ctx context.Context, client api.APIClient, repo, envName string, deps *Dependencies) (api.APIClient, error) {
+	deps.UI.Warn(fmt.Sprintf("%s requires a WebAuthn confirmation for pushes", envName))
+
+	var stepUp *api.StepUp
+	err := deps.UI.Spin("Requesting confirmation...", func() error {
+		var err error
+		stepUp, err = client.StartStepUp(ctx, repo, envName)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to request the confirmation: %s", err.Error()))
+		return nil, err
+	}
+
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Confirm with Touch ID, Windows Hello or your security key: %s", deps.UI.Link(stepUp.VerificationURL))))
+	_ = deps.Browser.OpenURL(stepUp.VerificationURL)
+
+	interval := time.Duration(stepUp.Interval) * time.Second
+	if interval <= 0 {
+		interval = stepUpPollInterval
+	}
+	timeout := time.Duration(stepUp.ExpiresIn) * time.Second
+	if timeout <= 0 || timeout > stepUpTimeout {
+		timeout = stepUpTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	var token string
+	err = deps.UI.Spin("Waiting for your authenticator...", func() error {
+		for time.Now().Before(deadline) {
+			time.Sleep(interval)
+
+			status, err := client.PollStepUp(ctx, repo, envName, stepUp.ID)
+			if err != nil {
+				// Keep polling through network errors
+				continue
+			}
+			switch status.Status {
+			case api.StepUpApproved:
+				if status.Token != "" {
+					token = status.Token
+					return nil
+				}
+			case api.StepUpDenied:
+				return fmt.Errorf("confirmation denied")
+			case api.StepUpExpired:
+				return fmt.Errorf("confirmation expired")
+			}
+		}
+		return fmt.Errorf("confirmation timed out")
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Push not confirmed: %s", err.Error()))
+		return nil, err
+	}
+
+	deps.UI.Success("Confirmed with your authenticator")
+	return deps.APIFactory.NewClient(token), nil
+}
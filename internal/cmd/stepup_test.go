@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// stepUpFactory hands out a separate client for the step-up token
+type stepUpFactory struct {
+	login     *MockAPIClient
+	confirmed *MockAPIClient
+}
+
+func (f *stepUpFactory) NewClient(token string) api.APIClient {
+	if token == "kw_stepup" {
+		return f.confirmed
+	}
+	return f.login
+}
+
+// webAuthnPushDeps returns dependencies for a push to a production
+// environment protected with RuleWebAuthn
+func webAuthnPushDeps(t *testing.T) (*Dependencies, *MockUIProvider, *MockAPIClient, *stepUpFactory) {
+	t.Helper()
+	previous := stepUpPollInterval
+	stepUpPollInterval = time.Millisecond
+	t.Cleanup(func() { stepUpPollInterval = previous })
+
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=secret123")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushError = &api.APIError{
+		StatusCode: 403,
+		Detail:     "Push needs a WebAuthn confirmation",
+		Protection: &api.ProtectionViolation{Rule: api.RuleWebAuthn},
+	}
+	factory := &stepUpFactory{login: apiMock, confirmed: &MockAPIClient{PushResponse: &api.PushSecretsResponse{Message: "Secrets pushed"}}}
+	deps.APIFactory = factory
+	uiMock.Interactive = true
+	return deps, uiMock, apiMock, factory
+}
+
+func TestRunPushWithDeps_ConfirmsWithWebAuthn(t *testing.T) {
+	deps, uiMock, apiMock, factory := webAuthnPushDeps(t)
+	apiMock.StepUp = &api.StepUp{ID: "su_1", VerificationURL: "https://keyway.sh/step-up/su_1"}
+	apiMock.StepUpStatuses = []*api.StepUpStatus{{Status: api.StepUpPending}, {Status: api.StepUpApproved, Token: "kw_stepup"}}
+
+	err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := deps.Browser.(*MockBrowserOpener).LastURL; got != "https://keyway.sh/step-up/su_1" {
+		t.Errorf("expected the confirmation page to be opened, got %q", got)
+	}
+	if apiMock.StepUpPolls != 2 {
+		t.Errorf("expected to poll until approved, got %d polls", apiMock.StepUpPolls)
+	}
+	if factory.confirmed.PushedSecrets["API_KEY"] != "secret123" {
+		t.Errorf("expected the push to be retried with the step-up token, got %v", factory.confirmed.PushedSecrets)
+	}
+	if factory.confirmed.PushedIdempotencyKey != apiMock.PushedIdempotencyKey {
+		t.Errorf("expected the retry to reuse the idempotency key, got %q and %q", apiMock.PushedIdempotencyKey, factory.confirmed.PushedIdempotencyKey)
+	}
+	if len(uiMock.ErrorCalls) != 0 {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPushWithDeps_WebAuthnDenied(t *testing.T) {
+	deps, uiMock, apiMock, factory := webAuthnPushDeps(t)
+	apiMock.StepUpStatuses = []*api.StepUpStatus{{Status: api.StepUpDenied}}
+
+	if err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps); err == nil {
+		t.Fatal("expected an error when the confirmation is denied")
+	}
+	if factory.confirmed.PushedSecrets != nil {
+		t.Error("nothing should be pushed without the confirmation")
+	}
+	if len(uiMock.ErrorCalls) != 1 || uiMock.ErrorCalls[0] != "Push not confirmed: confirmation denied" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPushWithDeps_WebAuthnNonInteractive(t *testing.T) {
+	deps, uiMock, apiMock, factory := webAuthnPushDeps(t)
+	uiMock.Interactive = false
+
+	if err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps); err == nil {
+		t.Fatal("expected the push to stay blocked without a terminal")
+	}
+	if apiMock.StepUpPolls != 0 || factory.confirmed.PushedSecrets != nil {
+		t.Error("no confirmation should be requested without a terminal")
+	}
+	if !strings.Contains(strings.Join(uiMock.MessageCalls, "\n"), "Blocked by rule: WebAuthn confirmation") {
+		t.Errorf("expected the rule to be explained, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestRunEnvProtectWithDeps_RequireWebAuthn(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionAdmin}
+
+	opts := EnvProtectOptions{EnvName: "production", Rules: api.EnvironmentProtection{RequireWebAuthn: true}, Set: true, Yes: true}
+	if err := runEnvProtectWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.SetProtection == nil || !apiMock.SetProtection.RequireWebAuthn {
+		t.Errorf("expected the WebAuthn rule to be saved, got %+v", apiMock.SetProtection)
+	}
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var sudoCmd = &cobra.Command{
+	Use:   "sudo",
+	Short: "Get temporary write access to an environment",
+	Long: `Request write access to an environment you can only read, for a limited time
+and with a reason, both recorded in the vault's audit log.
+
+Until it expires, keyway push, set, undo and trash restore use it for that
+environment; then the CLI drops back to read-only on its own.`,
+	Args: cobra.NoArgs,
+	RunE: runSudo,
+}
+
+func init() {
+	sudoCmd.Flags().StringP("env", "e", "production", "Environment to get write access to")
+	sudoCmd.Flags().Duration("duration", 30*time.Minute, "How long the access lasts")
+	sudoCmd.Flags().String("reason", "", "Why the access is needed, recorded in the audit log")
+	sudoCmd.Flags().Bool("status", false, "Show the active elevations of the repository")
+	sudoCmd.Flags().Bool("drop", false, "End the elevation of the environment now")
+}
+
+// maxElevationDuration bounds what keyway sudo asks for, the server may grant less
+const maxElevationDuration = 12 * time.Hour
+
+// SudoOptions contains the parsed flags for the sudo command
+type SudoOptions struct {
+	EnvName  string
+	Duration time.Duration
+	Reason   string
+	Status   bool
+	Drop     bool
+}
+
+// storedElevation is an elevation kept until it expires, with the repository it is for
+type storedElevation struct {
+	api.Elevation
+	Repo string `json:"repo"`
+}
+
+// expiresAt returns when the elevation ends, the zero time if unknown
+func (e storedElevation) expiresAt() time.Time {
+	t, _ := time.Parse(time.RFC3339, e.ExpiresAt)
+	return t
+}
+
+// runSudo is the entry point for the sudo command (uses default dependencies)
+func runSudo(cmd *cobra.Command, args []string) error {
+	opts := SudoOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Duration, _ = cmd.Flags().GetDuration("duration")
+	opts.Reason, _ = cmd.Flags().GetString("reason")
+	opts.Status, _ = cmd.Flags().GetBool("status")
+	opts.Drop, _ = cmd.Flags().GetBool("drop")
+
+	return runSudoWithDeps(opts, defaultDeps)
+}
+
+// runSudoWithDeps is the testable version of runSudo
+func runSudoWithDeps(opts SudoOptions, deps *Dependencies) error {
+	if opts.Status {
+		return runPipeline(deps, sudoStatus, withIntro("sudo"), withRepo)
+	}
+	if opts.Drop {
+		return runPipeline(deps, sudoDrop, withIntro("sudo"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+	}
+
+	deps.UI.Intro("sudo")
+	if strings.TrimSpace(opts.Reason) == "" {
+		deps.UI.Error("A reason is required, it is recorded in the audit log")
+		deps.UI.Message(deps.UI.Dim(`Add e.g. --reason "hotfix INC-142"`))
+		return fmt.Errorf("--reason is required")
+	}
+	if opts.Duration <= 0 || opts.Duration > maxElevationDuration {
+		deps.UI.Error(fmt.Sprintf("The duration must be between 1s and %s", maxElevationDuration))
+		return fmt.Errorf("invalid duration %s", opts.Duration)
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return sudo(s, opts)
+	}, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// sudo requests an elevation and keeps it for the commands that write
+func sudo(s *Session, opts SudoOptions) error {
+	deps := s.Deps
+	var elevation *api.Elevation
+	err := s.Spin("Requesting write access...", func() error {
+		var err error
+		elevation, err = s.Client.RequestElevation(s.Ctx, s.Repo, s.EnvName, opts.Duration, opts.Reason)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("sudo", err, deps)
+	}
+
+	stored := storedElevation{Elevation: *elevation, Repo: s.Repo}
+	stored.Environment = s.EnvName
+	if err := saveElevation(stored, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the elevation: %s", err.Error()))
+		return err
+	}
+
+	analytics.Track(analytics.EventSudo, map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"durationMin":  int(opts.Duration.Minutes()),
+	})
+
+	until := stored.ExpiresAt
+	if t := stored.expiresAt(); !t.IsZero() {
+		until = t.Local().Format("15:04")
+	}
+	deps.UI.Success(fmt.Sprintf("Write access to %s until %s", s.EnvName, until))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Recorded in the audit log: %s", opts.Reason)))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Drops back to read-only on its own, or now with: keyway sudo --drop -e %s", s.EnvName)))
+	return nil
+}
+
+// sudoStatus shows the active elevations of the repository
+func sudoStatus(s *Session) error {
+	deps := s.Deps
+	now := time.Now()
+	var active []storedElevation
+	for _, e := range loadElevations(deps) {
+		if e.Repo == s.Repo && e.expiresAt().After(now) {
+			active = append(active, e)
+		}
+	}
+	if len(active) == 0 {
+		deps.UI.Info("No elevated access, environments are as your role allows")
+		return nil
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i].Environment < active[j].Environment })
+	for _, e := range active {
+		left := e.expiresAt().Sub(now).Round(time.Minute)
+		deps.UI.Step(fmt.Sprintf("%s: write access for %s %s", deps.UI.Value(e.Environment), left, deps.UI.Dim("("+e.Reason+")")))
+	}
+	return nil
+}
+
+// sudoDrop ends the elevation of an environment before it expires
+func sudoDrop(s *Session) error {
+	deps := s.Deps
+	elevation, ok := activeElevation(s.Repo, s.EnvName, deps)
+	if !ok {
+		deps.UI.Info(fmt.Sprintf("No elevated access to %s", s.EnvName))
+		return nil
+	}
+
+	err := s.Spin("Dropping write access...", func() error {
+		return s.Client.RevokeElevation(s.Ctx, s.Repo, s.EnvName, elevation.ID)
+	})
+	if err != nil {
+		if apiErr, isAPIErr := err.(*api.APIError); !isAPIErr || apiErr.StatusCode != 404 {
+			return reportEnvError("sudo", err, deps)
+		}
+		// Already expired or revoked on the server
+	}
+	if err := removeElevation(s.Repo, s.EnvName, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to remove the elevation: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Back to read-only on %s", s.EnvName))
+	return nil
+}
+
+// activeElevation returns the unexpired elevation of an environment
+func activeElevation(repo, envName string, deps *Dependencies) (storedElevation, bool) {
+	e, ok := loadElevations(deps)[elevationKey(repo, envName)]
+	if !ok || !e.expiresAt().After(time.Now()) {
+		return storedElevation{}, false
+	}
+	return e, true
+}
+
+// hasActiveElevation returns true if any environment of the repository is elevated
+func hasActiveElevation(repo string, deps *Dependencies) bool {
+	now := time.Now()
+	for _, e := range loadElevations(deps) {
+		if e.Repo == repo && e.expiresAt().After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// elevatingFactory creates clients that write to elevated environments with
+// the elevation's token, and with the login's token everywhere else
+type elevatingFactory struct {
+	APIClientFactory
+	deps *Dependencies
+}
+
+func (f elevatingFactory) NewClient(token string) api.APIClient {
+	return elevatedClient{APIClient: f.APIClientFactory.NewClient(token), factory: f.APIClientFactory, deps: f.deps}
+}
+
+// applyElevations makes deps' clients use active elevations
+func applyElevations(deps *Dependencies) {
+	deps.APIFactory = elevatingFactory{APIClientFactory: deps.APIFactory, deps: deps}
+}
+
+// elevatedClient routes the writes to an environment through its elevation
+type elevatedClient struct {
+	api.APIClient
+	factory APIClientFactory
+	deps    *Dependencies
+}
+
+// forEnv returns the client writing to an environment: the elevation's while
+// it lasts, the login's once it expired
+func (c elevatedClient) forEnv(repo, envName string) api.APIClient {
+	if e, ok := activeElevation(repo, envName, c.deps); ok && e.Token != "" {
+		return c.factory.NewClient(e.Token)
+	}
+	return c.APIClient
+}
+
+func (c elevatedClient) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*api.PushSecretsResponse, error) {
+	return c.forEnv(repo, env).PushSecrets(ctx, repo, env, secrets, idempotencyKey)
+}
+
+func (c elevatedClient) CreateSnapshot(ctx context.Context, repoFullName, env string) (*api.Snapshot, error) {
+	return c.forEnv(repoFullName, env).CreateSnapshot(ctx, repoFullName, env)
+}
+
+func (c elevatedClient) RestoreSnapshot(ctx context.Context, repoFullName, env, snapshotID string) error {
+	return c.forEnv(repoFullName, env).RestoreSnapshot(ctx, repoFullName, env, snapshotID)
+}
+
+func (c elevatedClient) RestoreTrashedSecret(ctx context.Context, repoFullName, env, key string) error {
+	return c.forEnv(repoFullName, env).RestoreTrashedSecret(ctx, repoFullName, env, key)
+}
+
+// elevationKey identifies the elevation of an environment
+func elevationKey(repo, envName string) string {
+	return repo + ":" + envName
+}
+
+// elevationsPath returns the file holding the active elevations
+func elevationsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "elevations.json")
+}
+
+// loadElevations returns the stored elevations, by repository and environment
+func loadElevations(deps *Dependencies) map[string]storedElevation {
+	elevations := make(map[string]storedElevation)
+	path := elevationsPath()
+	if path == "" {
+		return elevations
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &elevations)
+	}
+	return elevations
+}
+
+// saveElevation stores an elevation, and drops the expired ones
+func saveElevation(e storedElevation, deps *Dependencies) error {
+	elevations := loadElevations(deps)
+	elevations[elevationKey(e.Repo, e.Environment)] = e
+	return writeElevations(elevations, deps)
+}
+
+// removeElevation drops the elevation of an environment
+func removeElevation(repo, envName string, deps *Dependencies) error {
+	elevations := loadElevations(deps)
+	delete(elevations, elevationKey(repo, envName))
+	return writeElevations(elevations, deps)
+}
+
+// writeElevations writes the unexpired elevations, their tokens readable by the user only
+func writeElevations(elevations map[string]storedElevation, deps *Dependencies) error {
+	path := elevationsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	now := time.Now()
+	for key, e := range elevations {
+		if !e.expiresAt().After(now) {
+			delete(elevations, key)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(elevations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
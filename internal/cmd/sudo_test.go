@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+// storeElevation writes an elevation to the state dir of the test
+func storeElevation(t *testing.T, fsMock *MockFileSystem, e storedElevation) {
+	t.Helper()
+	data, err := json.Marshal(map[string]storedElevation{elevationKey(e.Repo, e.Environment): e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsMock.Files[elevationsPath()] = data
+}
+
+func TestRunSudoWithDeps_RequestsAndStores(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	apiMock.Elevation = &api.Elevation{ID: "el_1", Reason: "hotfix", ExpiresAt: time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339), Token: "kw_elevated"}
+
+	err := runSudoWithDeps(SudoOptions{EnvName: "production", Duration: 30 * time.Minute, Reason: "hotfix"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.ElevationDuration != 30*time.Minute || apiMock.ElevationReason != "hotfix" {
+		t.Errorf("unexpected request: %s, %q", apiMock.ElevationDuration, apiMock.ElevationReason)
+	}
+
+	fsMock.Files[elevationsPath()] = fsMock.Written[elevationsPath()]
+	e, ok := activeElevation("owner/repo", "production", deps)
+	if !ok || e.Token != "kw_elevated" || e.Environment != "production" {
+		t.Errorf("expected the elevation to be stored, got %+v", e)
+	}
+	if len(uiMock.SuccessCalls) != 1 {
+		t.Errorf("expected a success message, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunSudoWithDeps_RequiresReason(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	if err := runSudoWithDeps(SudoOptions{EnvName: "production", Duration: time.Hour}, deps); err == nil {
+		t.Fatal("expected an error without a reason")
+	}
+	if apiMock.ElevationReason != "" || len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected no request, errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunSudoWithDeps_RejectsLongDuration(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runSudoWithDeps(SudoOptions{EnvName: "production", Duration: 24 * time.Hour, Reason: "x"}, deps); err == nil {
+		t.Fatal("expected an error for a duration over the maximum")
+	}
+}
+
+func TestRunSudoWithDeps_Drop(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	storeElevation(t, fsMock, storedElevation{
+		Elevation: api.Elevation{ID: "el_1", Environment: "production", ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+		Repo:      "owner/repo",
+	})
+
+	if err := runSudoWithDeps(SudoOptions{EnvName: "production", Drop: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RevokedElevation != "el_1" {
+		t.Errorf("expected el_1 revoked, got %q", apiMock.RevokedElevation)
+	}
+	fsMock.Files[elevationsPath()] = fsMock.Written[elevationsPath()]
+	if _, ok := activeElevation("owner/repo", "production", deps); ok {
+		t.Error("expected the elevation to be removed")
+	}
+}
+
+// tokenFactory records the token of each client it creates
+type tokenFactory struct {
+	client api.APIClient
+	tokens []string
+}
+
+func (f *tokenFactory) NewClient(token string) api.APIClient {
+	f.tokens = append(f.tokens, token)
+	return f.client
+}
+
+func TestElevatedClient_UsesElevationUntilExpiry(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	factory := &tokenFactory{client: apiMock}
+	deps.APIFactory = factory
+	applyElevations(deps)
+
+	storeElevation(t, fsMock, storedElevation{
+		Elevation: api.Elevation{ID: "el_1", Environment: "production", ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339), Token: "kw_elevated"},
+		Repo:      "owner/repo",
+	})
+	client := deps.APIFactory.NewClient("kw_login")
+	ctx := context.Background()
+
+	_, _ = client.PushSecrets(ctx, "owner/repo", "production", nil, "")
+	_, _ = client.PushSecrets(ctx, "owner/repo", "staging", nil, "")
+	if len(factory.tokens) != 2 || factory.tokens[1] != "kw_elevated" {
+		t.Errorf("expected the elevated token for production only, got %v", factory.tokens)
+	}
+
+	// Expired: back to the login's token
+	storeElevation(t, fsMock, storedElevation{
+		Elevation: api.Elevation{ID: "el_1", Environment: "production", ExpiresAt: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339), Token: "kw_elevated"},
+		Repo:      "owner/repo",
+	})
+	_, _ = client.PushSecrets(ctx, "owner/repo", "production", nil, "")
+	if len(factory.tokens) != 2 {
+		t.Errorf("expected no elevated client after expiry, got %v", factory.tokens)
+	}
+}
+
+func TestRequirePermission_Elevated(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.VaultDetails = &api.VaultDetails{Permission: api.PermissionRead}
+	ctx := context.Background()
+
+	if err := requirePermission(ctx, apiMock, "owner/repo", api.PermissionWrite, "pushing", deps); err == nil {
+		t.Fatal("expected read access to be rejected")
+	}
+
+	storeElevation(t, fsMock, storedElevation{
+		Elevation: api.Elevation{ID: "el_1", Environment: "production", ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+		Repo:      "owner/repo",
+	})
+	if err := requirePermission(ctx, apiMock, "owner/repo", api.PermissionWrite, "pushing", deps); err != nil {
+		t.Errorf("expected elevated access to be accepted, got %v", err)
+	}
+	if err := requirePermission(ctx, apiMock, "owner/repo", api.PermissionMaintain, "freezing", deps); err == nil {
+		t.Error("expected an elevation to grant write access only")
+	}
+}
@@ -28,14 +28,7 @@ var syncCmd = &cobra.Command{
 	Short: "Sync secrets with a provider (vercel, railway)",
 	Long: `Sync secrets between your Keyway vault and a provider like Vercel or Railway.
 
-If no provider is specified, you'll be prompted to select one.
-
-Examples:
-  keyway sync              # Interactive provider selection
-  keyway sync vercel       # Sync with Vercel
-  keyway sync railway      # Sync with Railway
-  keyway sync vercel --push --env production
-  keyway sync vercel --pull --env staging`,
+If no provider is specified, you'll be prompted to select one.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSync,
 }
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var syncGitHubSecretsCmd = &cobra.Command{
+	Use:   "github-secrets",
+	Short: "Mirror secrets into GitHub Actions secrets",
+	Long: `Mirror secrets of a Keyway environment into the Actions secrets of the
+repository, or of one of its GitHub environments, for workflows that cannot
+call Keyway at runtime.
+
+Needs a GitHub token allowed to manage the repository's secrets, read from
+GITHUB_TOKEN or GH_TOKEN.
+
+GitHub never returns secret values, so every selected key that already exists
+is written again. With --prune, Actions secrets matching --keys that are not in
+the environment are deleted; KEYWAY_* secrets are always kept.`,
+	Args: cobra.NoArgs,
+	RunE: runSyncGitHubSecrets,
+}
+
+func init() {
+	syncGitHubSecretsCmd.Flags().StringP("env", "e", "production", "Keyway environment")
+	syncGitHubSecretsCmd.Flags().StringSlice("keys", nil, "Keys to mirror, glob patterns allowed (default: all)")
+	syncGitHubSecretsCmd.Flags().String("github-env", "", "GitHub environment to write to (default: repository secrets)")
+	syncGitHubSecretsCmd.Flags().Bool("prune", false, "Delete Actions secrets matching --keys that are not in the environment")
+	syncGitHubSecretsCmd.Flags().Bool("dry-run", false, "Show what would change without writing")
+	syncGitHubSecretsCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	syncCmd.AddCommand(syncGitHubSecretsCmd)
+}
+
+// SyncGitHubSecretsOptions contains the parsed flags for the sync github-secrets command
+type SyncGitHubSecretsOptions struct {
+	EnvName   string
+	Keys      []string
+	GitHubEnv string
+	Prune     bool
+	DryRun    bool
+	Yes       bool
+}
+
+// gitHubSecretNameRegex is what GitHub accepts as a secret name
+var gitHubSecretNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// gitHubSecretsPlan is what keyway sync github-secrets will change
+type gitHubSecretsPlan struct {
+	Create []string
+	Update []string
+	Delete []string
+}
+
+// IsEmpty returns true when there is nothing to change
+func (p gitHubSecretsPlan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Delete) == 0
+}
+
+// runSyncGitHubSecrets is the entry point for the sync github-secrets command (uses default dependencies)
+func runSyncGitHubSecrets(cmd *cobra.Command, args []string) error {
+	opts := SyncGitHubSecretsOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Keys, _ = cmd.Flags().GetStringSlice("keys")
+	opts.GitHubEnv, _ = cmd.Flags().GetString("github-env")
+	opts.Prune, _ = cmd.Flags().GetBool("prune")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runSyncGitHubSecretsWithDeps(opts, defaultDeps)
+}
+
+// runSyncGitHubSecretsWithDeps is the testable version of runSyncGitHubSecrets
+func runSyncGitHubSecretsWithDeps(opts SyncGitHubSecretsOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return syncGitHubSecrets(s, opts)
+	}, withIntro("sync github-secrets"), withGitHubToken, withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// withGitHubToken stops the command when no GitHub token is set
+func withGitHubToken(next Handler) Handler {
+	return func(s *Session) error {
+		if config.GetGitHubToken() == "" {
+			s.Deps.UI.Error("A GitHub token is required, set GITHUB_TOKEN or GH_TOKEN")
+			s.Deps.UI.Message(s.Deps.UI.Dim("With the gh CLI: GITHUB_TOKEN=$(gh auth token) keyway sync github-secrets"))
+			return fmt.Errorf("GITHUB_TOKEN is not set")
+		}
+		return next(s)
+	}
+}
+
+// syncGitHubSecrets mirrors the selected keys into Actions secrets
+func syncGitHubSecrets(s *Session, opts SyncGitHubSecretsOptions) error {
+	deps := s.Deps
+	patterns := opts.Keys
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	target := "repository secrets"
+	if opts.GitHubEnv != "" {
+		target = fmt.Sprintf("GitHub environment %s", opts.GitHubEnv)
+	}
+	deps.UI.Step(fmt.Sprintf("Target: %s", deps.UI.Value(target)))
+
+	var content string
+	err := s.Spin("Fetching secrets...", func() error {
+		resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+		if err != nil {
+			return err
+		}
+		content = resp.Content
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("sync github-secrets", err, deps)
+	}
+	secrets, err := applyDerived(env.ParseVault(content), deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	selected := validGitHubSecrets(selectKeys(secrets, patterns), deps)
+	if len(selected) == 0 {
+		deps.UI.Warn(fmt.Sprintf("No key of %s to mirror", s.EnvName))
+		return nil
+	}
+
+	client := deps.GitHub.NewClient(config.GetGitHubToken())
+	var existing []github.Secret
+	err = deps.UI.Spin("Fetching GitHub secrets...", func() error {
+		var err error
+		existing, err = client.ListActionsSecrets(s.Ctx, s.Repo, opts.GitHubEnv)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to list the Actions secrets of %s: %s", s.Repo, err.Error()))
+		return err
+	}
+
+	plan := planGitHubSecrets(selected, existing, patterns, opts.Prune)
+	if plan.IsEmpty() {
+		deps.UI.Success("GitHub secrets are up to date")
+		return nil
+	}
+
+	deps.UI.Message("")
+	for _, name := range plan.Create {
+		deps.UI.DiffAdded(name)
+	}
+	for _, name := range plan.Update {
+		deps.UI.DiffChanged(name)
+	}
+	for _, name := range plan.Delete {
+		deps.UI.DiffRemoved(name)
+	}
+	deps.UI.Message("")
+	if len(plan.Update) > 0 {
+		deps.UI.Message(deps.UI.Dim("GitHub doesn't return secret values, existing secrets are written again"))
+	}
+
+	if opts.DryRun {
+		deps.UI.Info("Dry run - nothing was written")
+		return nil
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Write %d and delete %d secrets of %s?", len(plan.Create)+len(plan.Update), len(plan.Delete), s.Repo), true)
+		if !confirm {
+			deps.UI.Warn("Sync aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	var failed []string
+	err = deps.UI.Spin("Writing GitHub secrets...", func() error {
+		for _, name := range append(plan.Create, plan.Update...) {
+			if err := client.PutActionsSecret(s.Ctx, s.Repo, opts.GitHubEnv, name, selected[name]); err != nil {
+				failed = append(failed, fmt.Sprintf("%s (%s)", name, err.Error()))
+			}
+		}
+		for _, name := range plan.Delete {
+			if err := client.DeleteActionsSecret(s.Ctx, s.Repo, opts.GitHubEnv, name); err != nil {
+				failed = append(failed, fmt.Sprintf("%s (%s)", name, err.Error()))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	analytics.Track(analytics.EventSync, map[string]interface{}{
+		"provider":  "github-secrets",
+		"direction": "push",
+		"created":   len(plan.Create),
+		"updated":   len(plan.Update),
+		"deleted":   len(plan.Delete),
+		"failed":    len(failed),
+	})
+
+	if len(failed) > 0 {
+		deps.UI.Error(fmt.Sprintf("%d secret(s) failed: %s", len(failed), strings.Join(failed, ", ")))
+		return fmt.Errorf("%d GitHub secret(s) failed", len(failed))
+	}
+	deps.UI.Success(fmt.Sprintf("Synced %s to %s: %d created, %d updated, %d deleted", s.EnvName, target, len(plan.Create), len(plan.Update), len(plan.Delete)))
+	return nil
+}
+
+// validGitHubSecrets drops, with a warning, the keys GitHub won't store: names
+// it rejects and values over its size limit
+func validGitHubSecrets(secrets map[string]string, deps *Dependencies) map[string]string {
+	valid := make(map[string]string, len(secrets))
+	for _, key := range sortedKeys(secrets) {
+		value := secrets[key]
+		switch {
+		case !gitHubSecretNameRegex.MatchString(key) || strings.HasPrefix(strings.ToUpper(key), "GITHUB_"):
+			deps.UI.Warn(fmt.Sprintf("Skipping %s, not a valid GitHub secret name", key))
+		case len(value) > github.MaxSecretSize:
+			deps.UI.Warn(fmt.Sprintf("Skipping %s, GitHub secrets are limited to 48 KB", key))
+		default:
+			valid[strings.ToUpper(key)] = value
+		}
+	}
+	return valid
+}
+
+// planGitHubSecrets compares the selected secrets with the existing Actions
+// secrets. With prune, existing secrets matching the patterns but not selected
+// are deleted, except KEYWAY_* which workflows use to call Keyway.
+func planGitHubSecrets(selected map[string]string, existing []github.Secret, patterns []string, prune bool) gitHubSecretsPlan {
+	var plan gitHubSecretsPlan
+	exists := make(map[string]bool, len(existing))
+	for _, secret := range existing {
+		exists[secret.Name] = true
+	}
+
+	for _, name := range sortedKeys(selected) {
+		if exists[name] {
+			plan.Update = append(plan.Update, name)
+		} else {
+			plan.Create = append(plan.Create, name)
+		}
+	}
+
+	if prune {
+		for _, secret := range existing {
+			if _, ok := selected[secret.Name]; ok || strings.HasPrefix(secret.Name, "KEYWAY_") {
+				continue
+			}
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(strings.ToUpper(pattern), secret.Name); ok {
+					plan.Delete = append(plan.Delete, secret.Name)
+					break
+				}
+			}
+		}
+		sort.Strings(plan.Delete)
+	}
+	return plan
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/github"
+)
+
+func TestRunSyncGitHubSecretsWithDeps_RequiresToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if err := runSyncGitHubSecretsWithDeps(SyncGitHubSecretsOptions{EnvName: "production", Yes: true}, deps); err == nil {
+		t.Fatal("expected an error without a GitHub token")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.Contains(uiMock.ErrorCalls[0], "GITHUB_TOKEN") {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunSyncGitHubSecretsWithDeps_CreatesAndUpdates(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-token")
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\nDB_URL=postgres://x\nGITHUB_PAT=skip\n"}
+	gh := deps.GitHub.(*MockGitHubClient)
+	gh.Secrets = []github.Secret{{Name: "API_KEY"}, {Name: "STALE"}}
+
+	err := runSyncGitHubSecretsWithDeps(SyncGitHubSecretsOptions{EnvName: "production", GitHubEnv: "prod", Yes: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.Token != "gh-token" {
+		t.Errorf("expected GH_TOKEN to be used, got %q", gh.Token)
+	}
+	if gh.GitHubEnvs[0] != "prod" {
+		t.Errorf("expected the GitHub environment prod, got %q", gh.GitHubEnvs[0])
+	}
+	if !reflect.DeepEqual(gh.Puts, map[string]string{"API_KEY": "new", "DB_URL": "postgres://x"}) {
+		t.Errorf("unexpected writes: %v", gh.Puts)
+	}
+	if len(gh.Deleted) != 0 {
+		t.Errorf("expected no deletion without --prune, got %v", gh.Deleted)
+	}
+	if !reflect.DeepEqual(uiMock.DiffAddedCalls, []string{"DB_URL"}) || !reflect.DeepEqual(uiMock.DiffChangedCalls, []string{"API_KEY"}) {
+		t.Errorf("unexpected diff: added %v, changed %v", uiMock.DiffAddedCalls, uiMock.DiffChangedCalls)
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.Contains(uiMock.WarnCalls[0], "GITHUB_PAT") {
+		t.Errorf("expected a warning for GITHUB_PAT, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunSyncGitHubSecretsWithDeps_DryRun(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\n"}
+	gh := deps.GitHub.(*MockGitHubClient)
+
+	if err := runSyncGitHubSecretsWithDeps(SyncGitHubSecretsOptions{EnvName: "production", DryRun: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gh.Puts) != 0 {
+		t.Errorf("expected nothing written on a dry run, got %v", gh.Puts)
+	}
+	if !reflect.DeepEqual(uiMock.DiffAddedCalls, []string{"API_KEY"}) {
+		t.Errorf("unexpected diff: %v", uiMock.DiffAddedCalls)
+	}
+}
+
+func TestRunSyncGitHubSecretsWithDeps_RequiresConfirmation(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=new\n"}
+	gh := deps.GitHub.(*MockGitHubClient)
+
+	if err := runSyncGitHubSecretsWithDeps(SyncGitHubSecretsOptions{EnvName: "production"}, deps); err == nil {
+		t.Fatal("expected an error without --yes in non-interactive mode")
+	}
+	if len(gh.Puts) != 0 {
+		t.Errorf("expected nothing written, got %v", gh.Puts)
+	}
+}
+
+func TestPlanGitHubSecrets_Prune(t *testing.T) {
+	selected := map[string]string{"STRIPE_KEY": "a"}
+	existing := []github.Secret{{Name: "STRIPE_KEY"}, {Name: "STRIPE_OLD"}, {Name: "SENTRY_DSN"}, {Name: "KEYWAY_TOKEN"}}
+
+	plan := planGitHubSecrets(selected, existing, []string{"stripe_*", "KEYWAY_*"}, true)
+	if !reflect.DeepEqual(plan.Update, []string{"STRIPE_KEY"}) {
+		t.Errorf("unexpected updates: %v", plan.Update)
+	}
+	if !reflect.DeepEqual(plan.Delete, []string{"STRIPE_OLD"}) {
+		t.Errorf("expected only STRIPE_OLD deleted, got %v", plan.Delete)
+	}
+
+	if plan := planGitHubSecrets(selected, existing, []string{"*"}, false); len(plan.Delete) != 0 {
+		t.Errorf("expected no deletion without prune, got %v", plan.Delete)
+	}
+}
+
+func TestValidGitHubSecrets(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+	secrets := map[string]string{
+		"api_key":      "a",
+		"1ST":          "b",
+		"GITHUB_TOKEN": "c",
+		"BIG":          strings.Repeat("x", github.MaxSecretSize+1),
+	}
+
+	valid := validGitHubSecrets(secrets, deps)
+	if !reflect.DeepEqual(valid, map[string]string{"API_KEY": "a"}) {
+		t.Errorf("unexpected valid secrets: %v", valid)
+	}
+	if len(uiMock.WarnCalls) != 3 {
+		t.Errorf("expected 3 warnings, got %v", uiMock.WarnCalls)
+	}
+}
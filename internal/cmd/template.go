@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// templateFuncs are the functions available to --template, on top of Go's
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, values []interface{}) string {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = fmt.Sprint(v)
+		}
+		return strings.Join(parts, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"time":  formatEventTime,
+}
+
+// addTemplateFlag adds --template to a command printing a list or a report
+func addTemplateFlag(cmd *cobra.Command) {
+	cmd.Flags().String("template", "", "Format the output with a Go template, e.g. '{{range .keys}}{{.name}}\\n{{end}}'")
+}
+
+// parseOutputTemplate parses a --template. \n, \t and \\ outside of {{ }} are
+// unescaped, so that templates can be written in single quotes in a shell.
+func parseOutputTemplate(text string, deps *Dependencies) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(unescapeTemplateText(text))
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Invalid --template: %s", strings.TrimPrefix(err.Error(), "template: ")))
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// unescapeTemplateText unescapes \n, \t and \\ in the text parts of a template
+func unescapeTemplateText(text string) string {
+	var b strings.Builder
+	for text != "" {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			start = len(text)
+		}
+		b.WriteString(strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(text[:start]))
+		text = text[start:]
+
+		end := strings.Index(text, "}}")
+		if end < 0 {
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(text[:end+2])
+		text = text[end+2:]
+	}
+	return b.String()
+}
+
+// printTemplate executes tmpl on data. data is passed through JSON first, so the
+// template uses the same field names as --json (e.g. .updatedAt).
+func printTemplate(tmpl *template.Template, data interface{}, deps *Dependencies) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return err
+	}
+	if err := tmpl.Execute(os.Stdout, generic); err != nil {
+		deps.UI.Error(fmt.Sprintf("--template failed: %s", strings.TrimPrefix(err.Error(), "template: ")))
+		return err
+	}
+	return nil
+}
+
+// checkOutputFlags rejects --json and --template used together
+func checkOutputFlags(jsonOutput bool, tmpl string, deps *Dependencies) error {
+	if jsonOutput && tmpl != "" {
+		deps.UI.Error("--json and --template cannot be used together")
+		return fmt.Errorf("--json and --template are mutually exclusive")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnescapeTemplateText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{{.name}}\t{{.updatedAt}}\n`, "{{.name}}\t{{.updatedAt}}\n"},
+		{`{{"\n"}}`, `{{"\n"}}`},
+		{`a\\nb`, `a\nb`},
+		{`{{.name`, `{{.name`},
+	}
+	for _, tt := range tests {
+		if got := unescapeTemplateText(tt.in); got != tt.want {
+			t.Errorf("unescapeTemplateText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPrintTemplate_UsesJSONFieldNames(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+	tmpl, err := parseOutputTemplate(`{{range .keys}}{{.name}}={{upper .value}} {{join "," .tags}}\n{{end}}`, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{"name": "API_KEY", "value": "abc", "tags": []string{"a", "b"}},
+		},
+	}
+
+	output := captureStdout(t, func() {
+		if err := printTemplate(tmpl, data, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if string(output) != "API_KEY=ABC a,b\n" {
+		t.Errorf("unexpected output: %q", output)
+	}
+}
+
+func TestParseOutputTemplate_Invalid(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	if _, err := parseOutputTemplate("{{range .keys}}", deps); err == nil {
+		t.Fatal("expected an error for an unclosed range")
+	}
+	if len(uiMock.ErrorCalls) != 1 || !strings.HasPrefix(uiMock.ErrorCalls[0], "Invalid --template") {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List and restore removed keys",
+	Long: fmt.Sprintf(`Keys removed from an environment (by keyway push --prune or
+keyway secrets rm) are kept in that environment's trash for %d days, and can
+be restored until then.`, api.TrashRetentionDays),
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys in an environment's trash",
+	Long: `List the keys in an environment's trash, when and by whom each was removed
+and how many days are left to restore it.
+
+--template formats the output with a Go template, which gets .repository,
+.environment and .keys, each key having .name, .deletedAt, .deletedBy,
+.expiresAt and .daysLeft.`,
+	Args: cobra.NoArgs,
+	RunE: runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <KEY>",
+	Short: "Put a removed key back with its last value",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTrashRestore,
+}
+
+func init() {
+	trashListCmd.Flags().StringP("env", "e", "development", "Environment name")
+	addTemplateFlag(trashListCmd)
+	trashRestoreCmd.Flags().StringP("env", "e", "development", "Environment name")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+}
+
+// TrashOptions contains the parsed flags for the trash subcommands
+type TrashOptions struct {
+	EnvName  string
+	Key      string
+	Template string
+}
+
+// trashListView is what --template gets from keyway trash list
+type trashListView struct {
+	Repository  string         `json:"repository"`
+	Environment string         `json:"environment"`
+	Keys        []trashKeyView `json:"keys"`
+}
+
+// trashKeyView is a key of trashListView
+type trashKeyView struct {
+	Name      string `json:"name"`
+	DeletedAt string `json:"deletedAt"`
+	DeletedBy string `json:"deletedBy"`
+	ExpiresAt string `json:"expiresAt"`
+	DaysLeft  int    `json:"daysLeft"` // -1 if unknown
+}
+
+// runTrashList is the entry point for the trash list command (uses default dependencies)
+func runTrashList(cmd *cobra.Command, args []string) error {
+	opts := TrashOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Template, _ = cmd.Flags().GetString("template")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if err := checkOutputFlags(jsonOutput, opts.Template, defaultDeps); err != nil {
+		return err
+	}
+	return runTrashListWithDeps(opts, defaultDeps)
+}
+
+// runTrashRestore is the entry point for the trash restore command (uses default dependencies)
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	opts := TrashOptions{Key: args[0]}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+
+	return runTrashRestoreWithDeps(opts, defaultDeps)
+}
+
+// runTrashListWithDeps is the testable version of runTrashList
+func runTrashListWithDeps(opts TrashOptions, deps *Dependencies) error {
+	var tmpl *template.Template
+	if opts.Template != "" {
+		var err error
+		if tmpl, err = parseOutputTemplate(opts.Template, deps); err != nil {
+			return err
+		}
+		deps = withQuietUI(deps)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return trashList(s, tmpl)
+	}, withIntro("trash list"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// trashList lists the keys in the trash of an environment
+func trashList(s *Session, tmpl *template.Template) error {
+	deps := s.Deps
+	var trash []api.TrashedSecret
+	err := s.Spin("Fetching trash...", func() error {
+		var err error
+		trash, err = s.Client.ListTrash(s.Ctx, s.Repo, s.EnvName)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("trash list", err, deps)
+	}
+
+	if tmpl != nil {
+		view := trashListView{Repository: s.Repo, Environment: s.EnvName, Keys: []trashKeyView{}}
+		for _, item := range trash {
+			view.Keys = append(view.Keys, trashKeyView{
+				Name:      item.Key,
+				DeletedAt: item.DeletedAt,
+				DeletedBy: item.DeletedBy,
+				ExpiresAt: item.ExpiresAt,
+				DaysLeft:  trashDaysLeft(item, time.Now()),
+			})
+		}
+		return printTemplate(tmpl, view, deps)
+	}
+
+	if len(trash) == 0 {
+		deps.UI.Info(fmt.Sprintf("The trash of %s is empty", s.EnvName))
+		return nil
+	}
+
+	deps.UI.Message("")
+	for _, item := range trash {
+		line := fmt.Sprintf("  %s  %s", item.Key, deps.UI.Dim("removed "+formatEventTime(item.DeletedAt)))
+		if item.DeletedBy != "" {
+			line += deps.UI.Dim(" by " + item.DeletedBy)
+		}
+		if left := trashDaysLeft(item, time.Now()); left >= 0 {
+			line += deps.UI.Dim(fmt.Sprintf(", %d days left", left))
+		}
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+
+	deps.UI.Outro(fmt.Sprintf("Restore with: keyway trash restore <KEY> -e %s", s.EnvName))
+	return nil
+}
+
+// runTrashRestoreWithDeps is the testable version of runTrashRestore
+func runTrashRestoreWithDeps(opts TrashOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return trashRestore(s, opts.Key)
+	}, withIntro("trash restore"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// trashRestore restores a key from the trash of an environment
+func trashRestore(s *Session, key string) error {
+	deps, envName := s.Deps, s.EnvName
+	if err := requirePermission(s.Ctx, s.Client, s.Repo, api.PermissionWrite, "restoring a key", deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, s.Repo, envName, deps); err != nil {
+		return err
+	}
+
+	err := s.Spin(fmt.Sprintf("Restoring %s...", key), func() error {
+		return s.Client.RestoreTrashedSecret(s.Ctx, s.Repo, envName, key)
+	})
+	if err != nil {
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+			deps.UI.Error(fmt.Sprintf("%s is not in the trash of %s", key, envName))
+			deps.UI.Message(deps.UI.Dim(fmt.Sprintf("See what can be restored with: keyway trash list -e %s", envName)))
+			return fmt.Errorf("%s is not in the trash of %s", key, envName)
+		}
+		return reportEnvError("trash restore", err, deps)
+	}
+
+	deps.UI.Success(fmt.Sprintf("Restored %s to %s", key, envName))
+	return nil
+}
+
+// trashDaysLeft returns the whole days before a trashed key expires, or -1 if unknown
+func trashDaysLeft(item api.TrashedSecret, now time.Time) int {
+	expires, err := time.Parse(time.RFC3339, item.ExpiresAt)
+	if err != nil {
+		deleted, err := time.Parse(time.RFC3339, item.DeletedAt)
+		if err != nil {
+			return -1
+		}
+		expires = deleted.AddDate(0, 0, api.TrashRetentionDays)
+	}
+	left := int(expires.Sub(now).Hours() / 24)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunTrashListWithDeps_ListsKeys(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Trash = []api.TrashedSecret{
+		{Key: "OLD_TOKEN", DeletedAt: "2024-06-01T12:00:00Z", DeletedBy: "alice"},
+	}
+
+	err := runTrashListWithDeps(TrashOptions{EnvName: "prod"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := false
+	for _, msg := range uiMock.MessageCalls {
+		if strings.Contains(msg, "OLD_TOKEN") && strings.Contains(msg, "alice") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected OLD_TOKEN to be listed, got %v", uiMock.MessageCalls)
+	}
+	if len(uiMock.OutroCalls) == 0 || !strings.Contains(uiMock.OutroCalls[0], "-e production") {
+		t.Errorf("expected restore hint for production, got %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunTrashListWithDeps_Empty(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runTrashListWithDeps(TrashOptions{EnvName: "staging"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.InfoCalls) == 0 || !strings.Contains(uiMock.InfoCalls[0], "empty") {
+		t.Errorf("expected empty trash message, got %v", uiMock.InfoCalls)
+	}
+}
+
+func TestRunTrashRestoreWithDeps_Success(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	err := runTrashRestoreWithDeps(TrashOptions{EnvName: "staging", Key: "OLD_TOKEN"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RestoredKey != "OLD_TOKEN" {
+		t.Errorf("expected OLD_TOKEN to be restored, got %q", apiMock.RestoredKey)
+	}
+	if len(uiMock.SuccessCalls) == 0 {
+		t.Error("expected Success to be called")
+	}
+}
+
+func TestRunTrashRestoreWithDeps_NotInTrash(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.TrashError = &api.APIError{StatusCode: 404, Detail: "Not found"}
+
+	err := runTrashRestoreWithDeps(TrashOptions{EnvName: "staging", Key: "MISSING"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(uiMock.ErrorCalls) == 0 || uiMock.ErrorCalls[0] != "MISSING is not in the trash of staging" {
+		t.Errorf("unexpected error message: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunTrashRestoreWithDeps_Frozen(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.Freeze = &api.EnvironmentFreeze{Frozen: true}
+
+	err := runTrashRestoreWithDeps(TrashOptions{EnvName: "production", Key: "OLD_TOKEN"}, deps)
+
+	if err == nil {
+		t.Fatal("expected error for frozen environment")
+	}
+	if apiMock.RestoredKey != "" {
+		t.Error("expected RestoreTrashedSecret not to be called")
+	}
+}
+
+func TestTrashDaysLeft(t *testing.T) {
+	now := time.Date(2024, 6, 11, 12, 0, 0, 0, time.UTC)
+
+	if got := trashDaysLeft(api.TrashedSecret{ExpiresAt: "2024-06-21T12:00:00Z"}, now); got != 10 {
+		t.Errorf("expected 10 days from expiresAt, got %d", got)
+	}
+	if got := trashDaysLeft(api.TrashedSecret{DeletedAt: "2024-06-01T12:00:00Z"}, now); got != 20 {
+		t.Errorf("expected 20 days from deletedAt, got %d", got)
+	}
+	if got := trashDaysLeft(api.TrashedSecret{DeletedAt: "2024-01-01T12:00:00Z"}, now); got != 0 {
+		t.Errorf("expected expired key to have 0 days, got %d", got)
+	}
+	if got := trashDaysLeft(api.TrashedSecret{}, now); got != -1 {
+		t.Errorf("expected -1 without dates, got %d", got)
+	}
+}
+
+func TestRunTrashListWithDeps_Template(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.Trash = []api.TrashedSecret{
+		{Key: "OLD_TOKEN", DeletedAt: "2024-06-01T12:00:00Z", DeletedBy: "alice"},
+	}
+
+	output := captureStdout(t, func() {
+		if err := runTrashListWithDeps(TrashOptions{EnvName: "prod", Template: `{{.environment}}: {{range .keys}}{{.name}} by {{.deletedBy}}{{end}}`}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if string(output) != "production: OLD_TOKEN by alice" {
+		t.Errorf("unexpected output: %q", output)
+	}
+	if len(uiMock.MessageCalls) != 0 {
+		t.Errorf("only the template should be printed, got %v", uiMock.MessageCalls)
+	}
+}
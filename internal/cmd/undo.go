@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// undoWindow is how long after a push keyway undo can still revert it
+const undoWindow = 30 * time.Minute
+
+// maxPushHistory is the number of pushes remembered on this machine
+const maxPushHistory = 20
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last push made from this machine",
+	Long: `Revert the last push made from this machine.
+
+Before each push, keyway asks the server to snapshot the environment.
+keyway undo restores that snapshot, as long as the push is less than
+30 minutes old.`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	undoCmd.Flags().StringP("env", "e", "", "Only undo the last push to this environment")
+	undoCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+}
+
+// UndoOptions contains the parsed flags for the undo command
+type UndoOptions struct {
+	EnvName string
+	Yes     bool
+}
+
+// pushRecord is a push made from this machine, with the snapshot taken before it
+type pushRecord struct {
+	Repo       string    `json:"repo"`
+	Env        string    `json:"environment"`
+	File       string    `json:"file,omitempty"`
+	SnapshotID string    `json:"snapshotId"`
+	PushedAt   time.Time `json:"pushedAt"`
+}
+
+// runUndo is the entry point for the undo command (uses default dependencies)
+func runUndo(cmd *cobra.Command, args []string) error {
+	opts := UndoOptions{}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runUndoWithDeps(opts, defaultDeps)
+}
+
+// runUndoWithDeps is the testable version of runUndo
+func runUndoWithDeps(opts UndoOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return undo(s, opts)
+	}, withIntro("undo"), withRepo)
+}
+
+// undo restores the environment of the last push from this machine to its
+// snapshot from before the push
+func undo(s *Session, opts UndoOptions) error {
+	deps := s.Deps
+	repo := s.Repo
+	envName := ""
+	if opts.EnvName != "" {
+		envName = normalizeEnvName(opts.EnvName)
+	}
+
+	history := loadPushHistory(deps)
+	idx := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Repo == repo && (envName == "" || history[i].Env == envName) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		deps.UI.Error("No push to undo from this machine")
+		return fmt.Errorf("no push to undo")
+	}
+	last := history[idx]
+
+	age := time.Since(last.PushedAt)
+	if age > undoWindow {
+		deps.UI.Error(fmt.Sprintf("The last push to %s was %s ago, undo is only possible within %d minutes", last.Env, age.Round(time.Minute), int(undoWindow.Minutes())))
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Compare with an older state using: keyway diff %s --against %s", last.Env, last.PushedAt.Format("2006-01-02"))))
+		return fmt.Errorf("last push is older than the undo window")
+	}
+
+	from := ""
+	if last.File != "" {
+		from = fmt.Sprintf(" from %s", last.File)
+	}
+	deps.UI.Step(fmt.Sprintf("Last push: %s%s, %s ago", deps.UI.Value(last.Env), from, age.Round(time.Second)))
+
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirm, _ := deps.UI.Confirm(fmt.Sprintf("Restore %s to its state before this push?", last.Env), true)
+		if !confirm {
+			deps.UI.Warn("Undo aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		return fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+	}
+
+	// Login waits until there is a push to undo
+	if err := s.Login(); err != nil {
+		return err
+	}
+
+	if err := requirePermission(s.Ctx, s.Client, repo, api.PermissionWrite, "undoing a push", deps); err != nil {
+		return err
+	}
+
+	if err := checkEnvironmentNotFrozen(s.Ctx, s.Client, repo, last.Env, deps); err != nil {
+		return err
+	}
+	if err := checkEnvironmentPolicy(s.Ctx, s.Client, repo, last.Env, deps); err != nil {
+		return err
+	}
+
+	err := s.Spin("Restoring snapshot...", func() error {
+		return s.Client.RestoreSnapshot(s.Ctx, repo, last.Env, last.SnapshotID)
+	})
+	if err != nil {
+		return reportEnvError("undo", err, deps)
+	}
+
+	// The push is undone, the one before it becomes the next to undo
+	history = append(history[:idx], history[idx+1:]...)
+	_ = savePushHistory(history, deps)
+
+	deps.UI.Outro(fmt.Sprintf("Restored %s to its state before the push", last.Env))
+	return nil
+}
+
+// pushHistoryPath returns the file listing the recent pushes from this machine
+func pushHistoryPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "pushes.json")
+}
+
+// loadPushHistory returns the recent pushes from this machine, oldest first
+func loadPushHistory(deps *Dependencies) []pushRecord {
+	path := pushHistoryPath()
+	if path == "" {
+		return nil
+	}
+	data, err := deps.FS.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var history []pushRecord
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil
+	}
+	return history
+}
+
+// savePushHistory writes the recent pushes, keeping only the last maxPushHistory
+func savePushHistory(history []pushRecord, deps *Dependencies) error {
+	path := pushHistoryPath()
+	if path == "" {
+		return fmt.Errorf("could not locate the keyway config directory")
+	}
+	if len(history) > maxPushHistory {
+		history = history[len(history)-maxPushHistory:]
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
+
+// recordPush remembers a push so that keyway undo can revert it
+func recordPush(record pushRecord, deps *Dependencies) error {
+	return savePushHistory(append(loadPushHistory(deps), record), deps)
+}
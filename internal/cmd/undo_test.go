@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func setPushHistory(t *testing.T, fsMock *MockFileSystem, records ...pushRecord) {
+	t.Helper()
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsMock.Files[pushHistoryPath()] = data
+}
+
+func TestRunUndoWithDeps_RestoresLastPush(t *testing.T) {
+	deps, gitMock, _, uiMock, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	setPushHistory(t, fsMock,
+		pushRecord{Repo: "owner/repo", Env: "staging", SnapshotID: "snap_old", PushedAt: time.Now().Add(-10 * time.Minute)},
+		pushRecord{Repo: "other/repo", Env: "production", SnapshotID: "snap_other", PushedAt: time.Now().Add(-2 * time.Minute)},
+		pushRecord{Repo: "owner/repo", Env: "production", File: ".env.production", SnapshotID: "snap_last", PushedAt: time.Now().Add(-5 * time.Minute)},
+	)
+
+	err := runUndoWithDeps(UndoOptions{Yes: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RestoredSnapshot != "snap_last" {
+		t.Errorf("expected snap_last to be restored, got %q", apiMock.RestoredSnapshot)
+	}
+	var remaining []pushRecord
+	if err := json.Unmarshal(fsMock.Written[pushHistoryPath()], &remaining); err != nil {
+		t.Fatalf("history not saved: %v", err)
+	}
+	if len(remaining) != 2 || remaining[1].SnapshotID != "snap_other" {
+		t.Errorf("expected undone push to be forgotten, got %+v", remaining)
+	}
+	if len(uiMock.OutroCalls) == 0 || !strings.Contains(uiMock.OutroCalls[0], "production") {
+		t.Errorf("unexpected outro: %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunUndoWithDeps_FiltersByEnv(t *testing.T) {
+	deps, gitMock, _, _, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	setPushHistory(t, fsMock,
+		pushRecord{Repo: "owner/repo", Env: "staging", SnapshotID: "snap_staging", PushedAt: time.Now().Add(-10 * time.Minute)},
+		pushRecord{Repo: "owner/repo", Env: "production", SnapshotID: "snap_prod", PushedAt: time.Now().Add(-5 * time.Minute)},
+	)
+
+	if err := runUndoWithDeps(UndoOptions{EnvName: "staging", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RestoredSnapshot != "snap_staging" {
+		t.Errorf("expected snap_staging to be restored, got %q", apiMock.RestoredSnapshot)
+	}
+}
+
+func TestRunUndoWithDeps_OutsideWindow(t *testing.T) {
+	deps, gitMock, _, _, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	setPushHistory(t, fsMock,
+		pushRecord{Repo: "owner/repo", Env: "production", SnapshotID: "snap_1", PushedAt: time.Now().Add(-2 * time.Hour)},
+	)
+
+	err := runUndoWithDeps(UndoOptions{Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error outside the undo window")
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Error("expected nothing to be restored")
+	}
+}
+
+func TestRunUndoWithDeps_NothingToUndo(t *testing.T) {
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runUndoWithDeps(UndoOptions{Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(uiMock.ErrorCalls) == 0 || uiMock.ErrorCalls[0] != "No push to undo from this machine" {
+		t.Errorf("unexpected errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunUndoWithDeps_RequiresConfirmation(t *testing.T) {
+	deps, gitMock, _, uiMock, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	uiMock.Interactive = false
+	setPushHistory(t, fsMock,
+		pushRecord{Repo: "owner/repo", Env: "production", SnapshotID: "snap_1", PushedAt: time.Now()},
+	)
+
+	err := runUndoWithDeps(UndoOptions{}, deps)
+
+	if err == nil || !strings.Contains(err.Error(), "confirmation required") {
+		t.Errorf("expected confirmation error, got %v", err)
+	}
+	if apiMock.RestoredSnapshot != "" {
+		t.Error("expected nothing to be restored")
+	}
+}
+
+func TestRunUndoWithDeps_RestoreError(t *testing.T) {
+	deps, gitMock, _, _, fsMock, apiMock := NewTestDeps()
+	gitMock.Repo = "owner/repo"
+	apiMock.RestoreError = &api.APIError{StatusCode: 410, Detail: "Snapshot expired"}
+	setPushHistory(t, fsMock,
+		pushRecord{Repo: "owner/repo", Env: "production", SnapshotID: "snap_1", PushedAt: time.Now()},
+	)
+
+	err := runUndoWithDeps(UndoOptions{Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := fsMock.Written[pushHistoryPath()]; ok {
+		t.Error("expected history to be kept when the restore fails")
+	}
+}
+
+func TestRunPushWithDeps_RecordsSnapshotForUndo(t *testing.T) {
+	deps, gitMock, _, _, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	gitMock.Repo = "owner/repo"
+	fsMock.Files[".env"] = []byte("API_KEY=new")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "development"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+	apiMock.Snapshot = &api.Snapshot{ID: "snap_before_push"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var history []pushRecord
+	if err := json.Unmarshal(fsMock.Written[pushHistoryPath()], &history); err != nil {
+		t.Fatalf("push history not written: %v", err)
+	}
+	if len(history) != 1 || history[0].SnapshotID != "snap_before_push" || history[0].Env != "development" || history[0].File != ".env" {
+		t.Errorf("unexpected push history: %+v", history)
+	}
+}
+
+func TestRunPushWithDeps_SnapshotFailureStillPushes(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[".env"] = []byte("API_KEY=new")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "development"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=old"}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "Secrets saved"}
+	apiMock.SnapshotError = &api.APIError{StatusCode: 500, Detail: "boom"}
+
+	err := runPushWithDeps(PushOptions{EnvName: "development", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets == nil {
+		t.Error("expected push to go through")
+	}
+	found := false
+	for _, w := range uiMock.WarnCalls {
+		if strings.Contains(w, "cannot be undone") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a warning, got %v", uiMock.WarnCalls)
+	}
+	if _, ok := fsMock.Written[pushHistoryPath()]; ok {
+		t.Error("expected no undo record without a snapshot")
+	}
+}
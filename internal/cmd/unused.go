@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var unusedCmd = &cobra.Command{
+	Use:   "unused [path]",
+	Short: "Find vault keys the code never uses, and env vars missing from the vault",
+	Long: `Scan the source code for environment variable reads (process.env.X,
+os.Getenv("X"), ENV["X"], os.environ["X"]...) and compare them with the vault.
+
+Reports:
+  - vault keys whose name appears nowhere in the code, candidates for removal
+  - variables read by the code that no environment of the vault holds
+
+A key is counted as used wherever its name appears, in any file, so it is
+only reported when nothing mentions it. Env files are not scanned.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUnused,
+}
+
+func init() {
+	unusedCmd.Flags().StringP("env", "e", "", "Only compare with this environment (default: all)")
+	unusedCmd.Flags().StringSlice("exclude", nil, "Additional directories to skip")
+}
+
+// UnusedOptions contains the parsed flags for the unused command
+type UnusedOptions struct {
+	Path       string
+	EnvName    string
+	Excludes   []string
+	JSONOutput bool
+}
+
+// UnusedKey is a vault key that no file mentions
+type UnusedKey struct {
+	Key          string   `json:"key"`
+	Environments []string `json:"environments"`
+}
+
+// MissingKey is a variable read by the code that no environment holds
+type MissingKey struct {
+	Key  string `json:"key"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// UnusedReport is the output of keyway unused
+type UnusedReport struct {
+	FilesScanned int          `json:"filesScanned"`
+	Environments []string     `json:"environments"`
+	Unused       []UnusedKey  `json:"unused"`
+	Missing      []MissingKey `json:"missing"`
+}
+
+// envReadPatterns match environment variable reads, the first group is the name
+var envReadPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`process\.env\.([A-Za-z_][A-Za-z0-9_]*)`),                               // Node
+	regexp.MustCompile(`process\.env\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),               // Node
+	regexp.MustCompile(`import\.meta\.env\.([A-Za-z_][A-Za-z0-9_]*)`),                          // Vite
+	regexp.MustCompile(`(?:Deno|Bun)\.env(?:\.get\(\s*['"]|\.)([A-Za-z_][A-Za-z0-9_]*)`),       // Deno, Bun
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),              // Go
+	regexp.MustCompile(`os\.environ(?:\.get\(\s*|\[\s*)['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),      // Python
+	regexp.MustCompile(`os\.getenv\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),                      // Python
+	regexp.MustCompile(`ENV(?:\.fetch\(\s*|\[\s*)['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),            // Ruby
+	regexp.MustCompile(`(?:getenv\(|\$_ENV\[|\$_SERVER\[)\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`), // PHP
+	regexp.MustCompile(`env::var(?:_os)?\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),                      // Rust
+	regexp.MustCompile(`System\.getenv\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),                        // Java, Kotlin
+	regexp.MustCompile(`GetEnvironmentVariable\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),                // .NET
+}
+
+// identifierRegex splits source into words, to find mentions of key names
+var identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// platformVars are set by the OS, runtimes or hosting platforms, and are not
+// expected in the vault
+var platformVars = []string{
+	"NODE_ENV", "PATH", "HOME", "USER", "PWD", "SHELL", "TERM", "LANG", "TZ", "TMPDIR",
+	"HOSTNAME", "PORT", "HOST", "CI", "DEBUG", "KEYWAY_*", "GITHUB_*", "RUNNER_*",
+	"VERCEL", "VERCEL_*", "NEXT_RUNTIME", "RAILWAY_*", "NETLIFY", "NETLIFY_*", "RENDER", "RENDER_*",
+}
+
+// runUnused is the entry point for the unused command (uses default dependencies)
+func runUnused(cmd *cobra.Command, args []string) error {
+	opts := UnusedOptions{Path: "."}
+	if len(args) > 0 {
+		opts.Path = args[0]
+	}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Excludes, _ = cmd.Flags().GetStringSlice("exclude")
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runUnusedWithDeps(opts, deps)
+}
+
+// runUnusedWithDeps is the testable version of runUnused
+func runUnusedWithDeps(opts UnusedOptions, deps *Dependencies) error {
+	return runPipeline(deps, func(s *Session) error {
+		return unused(s, opts)
+	}, withIntro("unused"), withRepo, withLogin)
+}
+
+// unused compares the vault's keys with the environment variables the code reads
+func unused(s *Session, opts UnusedOptions) error {
+	deps := s.Deps
+
+	// Keys of each environment, by key
+	var envNames []string
+	keyEnvs := make(map[string][]string)
+	err := s.Spin("Fetching vault keys...", func() error {
+		envNames = []string{opts.EnvName}
+		if opts.EnvName == "" {
+			var err error
+			if envNames, err = s.Client.GetVaultEnvironments(s.Ctx, s.Repo); err != nil {
+				return err
+			}
+		}
+		sort.Strings(envNames)
+		keyEnvs = make(map[string][]string)
+		for _, name := range envNames {
+			resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			for key := range env.ParseVault(resp.Content) {
+				keyEnvs[key] = append(keyEnvs[key], name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return reportEnvError("unused", err, deps)
+	}
+
+	var words map[string]bool
+	var reads []MissingKey
+	var filesScanned int
+	err = deps.UI.Spin("Scanning code...", func() error {
+		var err error
+		filesScanned, words, reads, err = scanEnvUsage(opts.Path, append(defaultExcludes, opts.Excludes...), deps)
+		return err
+	})
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Scan failed: %v", err))
+		return err
+	}
+
+	report := UnusedReport{
+		FilesScanned: filesScanned,
+		Environments: envNames,
+		Unused:       []UnusedKey{},
+		Missing:      []MissingKey{},
+	}
+	for _, key := range sortedKeys(keyEnvs) {
+		if !words[key] {
+			report.Unused = append(report.Unused, UnusedKey{Key: key, Environments: keyEnvs[key]})
+		}
+	}
+	for _, read := range reads {
+		if _, ok := keyEnvs[read.Key]; !ok && !isPlatformVar(read.Key) {
+			report.Missing = append(report.Missing, read)
+		}
+	}
+
+	if opts.JSONOutput {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("%d files scanned, %d keys in %s", filesScanned, len(keyEnvs), strings.Join(envNames, ", "))))
+
+	if len(report.Unused) == 0 && len(report.Missing) == 0 {
+		deps.UI.Success("Every vault key is used, and every variable read by the code is in the vault")
+		return nil
+	}
+	if len(report.Unused) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d vault key(s) not found in the code:", len(report.Unused)))
+		for _, u := range report.Unused {
+			deps.UI.Message(fmt.Sprintf("  %s %s", u.Key, deps.UI.Dim("("+strings.Join(u.Environments, ", ")+")")))
+		}
+	}
+	if len(report.Missing) > 0 {
+		deps.UI.Warn(fmt.Sprintf("%d variable(s) read by the code but not in the vault:", len(report.Missing)))
+		for _, m := range report.Missing {
+			deps.UI.Message(fmt.Sprintf("  %s %s", m.Key, deps.UI.Dim(fmt.Sprintf("(%s:%d)", m.File, m.Line))))
+		}
+	}
+	return nil
+}
+
+// scanEnvUsage walks the source tree and returns every word found, and every
+// environment variable read with its first location
+func scanEnvUsage(root string, excludes []string, deps *Dependencies) (int, map[string]bool, []MissingKey, error) {
+	words := make(map[string]bool)
+	firstRead := make(map[string]MissingKey)
+	filesScanned := 0
+
+	err := deps.Walker.Walk(root, func(filePath string, info FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip files we can't access
+		}
+		relPath, relErr := filepath.Rel(root, filePath)
+		if relErr != nil {
+			relPath = filePath
+		}
+
+		if info.IsDir() {
+			for _, exclude := range excludes {
+				if info.Name() == exclude || strings.HasPrefix(relPath, exclude) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if binaryExtensions[strings.ToLower(filepath.Ext(filePath))] || info.Size() > 1024*1024 {
+			return nil
+		}
+		// Env files hold the keys themselves, they would make every key look used
+		if name := info.Name(); name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env") {
+			return nil
+		}
+
+		data, err := deps.FS.ReadFile(filePath)
+		if err != nil {
+			return nil
+		}
+		filesScanned++
+
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, word := range identifierRegex.FindAllString(line, -1) {
+				words[word] = true
+			}
+			for _, pattern := range envReadPatterns {
+				for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+					if _, ok := firstRead[m[1]]; !ok {
+						firstRead[m[1]] = MissingKey{Key: m[1], File: filepath.ToSlash(relPath), Line: i + 1}
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	reads := make([]MissingKey, 0, len(firstRead))
+	for _, key := range sortedKeys(firstRead) {
+		reads = append(reads, firstRead[key])
+	}
+	return filesScanned, words, reads, nil
+}
+
+// isPlatformVar returns true for variables set by the OS or hosting platforms
+func isPlatformVar(key string) bool {
+	for _, pattern := range platformVars {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func setupUnusedFiles(deps *Dependencies, files map[string]string) {
+	walker := &MockFileWalker{}
+	walker.Files = append(walker.Files,
+		MockWalkFile{Path: ".", Info: &MockFileInfo{FileName: ".", FileIsDir: true}},
+		MockWalkFile{Path: "node_modules", Info: &MockFileInfo{FileName: "node_modules", FileIsDir: true}},
+		MockWalkFile{Path: "node_modules/lib/index.js", Info: &MockFileInfo{FileName: "index.js"}},
+	)
+	fs := deps.FS.(*MockFileSystem)
+	fs.Files["node_modules/lib/index.js"] = []byte("process.env.VENDORED_ONLY")
+	for path, content := range files {
+		walker.Files = append(walker.Files, MockWalkFile{Path: path, Info: &MockFileInfo{FileName: path, FileSize: int64(len(content))}})
+		fs.Files[path] = []byte(content)
+	}
+	deps.Walker = walker
+}
+
+func TestRunUnusedWithDeps_Report(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "DATABASE_URL=postgres://db\nLEGACY_TOKEN=abc\nSTRIPE_KEY=sk\n"}
+	setupUnusedFiles(deps, map[string]string{
+		"server.js":   "const db = connect(process.env.DATABASE_URL)\nconst port = process.env.PORT\nconst key = process.env['SENTRY_DSN']",
+		"billing.py":  "stripe.api_key = os.environ.get(\"STRIPE_KEY\")",
+		".env":        "LEGACY_TOKEN=abc",
+		"worker/w.go": "token := os.Getenv(\"QUEUE_TOKEN\")",
+	})
+
+	err := runUnusedWithDeps(UnusedOptions{Path: ".", EnvName: "production"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) != 2 {
+		t.Fatalf("expected unused and missing warnings, got %v", uiMock.WarnCalls)
+	}
+	reported := strings.Join(uiMock.MessageCalls, "\n")
+	for _, key := range []string{"LEGACY_TOKEN", "QUEUE_TOKEN", "SENTRY_DSN"} {
+		if !strings.Contains(reported, "  "+key+" ") {
+			t.Errorf("expected %s to be reported, got %v", key, uiMock.MessageCalls)
+		}
+	}
+	for _, key := range []string{"DATABASE_URL", "STRIPE_KEY", "PORT", "VENDORED_ONLY"} {
+		if strings.Contains(reported, "  "+key+" ") {
+			t.Errorf("expected %s not to be reported, got %v", key, uiMock.MessageCalls)
+		}
+	}
+}
+
+func TestScanEnvUsage_Patterns(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+	setupUnusedFiles(deps, map[string]string{
+		"a.rb":  `ENV["RUBY_KEY"]; ENV.fetch('RUBY_FETCH')`,
+		"b.php": `getenv('PHP_KEY'); $_ENV["PHP_ENV_KEY"];`,
+		"c.rs":  `std::env::var("RUST_KEY")`,
+		"d.ts":  `import.meta.env.VITE_KEY; Deno.env.get("DENO_KEY")`,
+		"e.cs":  `Environment.GetEnvironmentVariable("DOTNET_KEY")`,
+		"f.kt":  `System.getenv("JVM_KEY")`,
+	})
+
+	_, _, reads, err := scanEnvUsage(".", defaultExcludes, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := make(map[string]bool)
+	for _, r := range reads {
+		found[r.Key] = true
+	}
+	for _, key := range []string{"RUBY_KEY", "RUBY_FETCH", "PHP_KEY", "PHP_ENV_KEY", "RUST_KEY", "VITE_KEY", "DENO_KEY", "DOTNET_KEY", "JVM_KEY"} {
+		if !found[key] {
+			t.Errorf("expected %s to be found, got %v", key, reads)
+		}
+	}
+	if found["VENDORED_ONLY"] {
+		t.Error("expected node_modules to be skipped")
+	}
+}
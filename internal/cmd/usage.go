@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show how you use keyway and how long commands take",
+	Long: `Show a summary of the keyway commands you ran and the time they took.
+
+Usage is recorded locally only, in usage.jsonl next to your keyway config,
+and never sent anywhere. Set KEYWAY_DISABLE_USAGE=1 to stop recording.`,
+	Args: cobra.NoArgs,
+	RunE: runUsage,
+}
+
+func init() {
+	usageCmd.Flags().String("since", "30d", "Only include commands run in this period (e.g. 24h, 7d, all)")
+	usageCmd.Flags().String("export", "", "Print the raw records as json or csv instead of a summary")
+	usageCmd.Flags().Bool("clear", false, "Delete the recorded usage")
+}
+
+// UsageOptions contains the parsed flags for the usage command
+type UsageOptions struct {
+	Since  string
+	Export string
+	Clear  bool
+}
+
+// runUsage is the entry point for the usage command (uses default dependencies)
+func runUsage(cmd *cobra.Command, args []string) error {
+	opts := UsageOptions{}
+	opts.Since, _ = cmd.Flags().GetString("since")
+	opts.Export, _ = cmd.Flags().GetString("export")
+	opts.Clear, _ = cmd.Flags().GetBool("clear")
+
+	return runUsageWithDeps(opts, defaultDeps)
+}
+
+// runUsageWithDeps is the testable version of runUsage
+func runUsageWithDeps(opts UsageOptions, deps *Dependencies) error {
+	if opts.Export != "" {
+		deps = withQuietUI(deps)
+	}
+	deps.UI.Intro("usage")
+
+	path := usage.Path()
+	if path == "" {
+		err := fmt.Errorf("could not locate the keyway config directory")
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	if opts.Clear {
+		if err := deps.FS.WriteFile(path, []byte{}, 0600); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to clear usage: %v", err))
+			return err
+		}
+		deps.UI.Outro("Usage history cleared")
+		return nil
+	}
+
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	var records []usage.Record
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		for _, r := range usage.Parse(data) {
+			if !r.StartedAt.Before(since) {
+				records = append(records, r)
+			}
+		}
+	}
+
+	switch opts.Export {
+	case "":
+	case "json":
+		if records == nil {
+			records = []usage.Record{}
+		}
+		output, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	case "csv":
+		return writeUsageCSV(records)
+	default:
+		err := fmt.Errorf("invalid export format %q (use json or csv)", opts.Export)
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	if usage.IsDisabled() {
+		deps.UI.Warn("Usage recording is disabled (KEYWAY_DISABLE_USAGE)")
+	}
+	if len(records) == 0 {
+		deps.UI.Info("No usage recorded yet")
+		return nil
+	}
+
+	summary := usage.Summarize(records, since)
+	deps.UI.Step(fmt.Sprintf("Period: %s → %s", summary.From.Local().Format("2006-01-02"), summary.To.Local().Format("2006-01-02")))
+	deps.UI.Message("")
+	for _, stats := range summary.Commands {
+		line := fmt.Sprintf("  %-14s %4d runs   avg %-8s total %-8s",
+			stats.Command, stats.Runs, formatUsageDuration(stats.Average), formatUsageDuration(stats.Total))
+		if stats.Failures > 0 {
+			line += deps.UI.Dim(fmt.Sprintf("  %d failed", stats.Failures))
+		}
+		deps.UI.Message(line)
+	}
+	deps.UI.Message("")
+
+	deps.UI.Outro(fmt.Sprintf("%d commands, %s spent in keyway", summary.Runs, formatUsageDuration(summary.Total)))
+	return nil
+}
+
+// parseSince converts a period like 24h, 7d or "all" to the time it starts at
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" || since == "all" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid period %q (e.g. 24h, 7d, all)", since)
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	d, err := time.ParseDuration(since)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid period %q (e.g. 24h, 7d, all)", since)
+	}
+	return now.Add(-d), nil
+}
+
+// writeUsageCSV prints records as CSV, one row per command run
+func writeUsageCSV(records []usage.Record) error {
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"command", "startedAt", "durationMs", "success", "version", "ci"})
+	for _, r := range records {
+		_ = w.Write([]string{
+			r.Command,
+			r.StartedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(r.DurationMs, 10),
+			strconv.FormatBool(r.Success),
+			r.Version,
+			strconv.FormatBool(r.CI),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatUsageDuration prints a duration at a precision that suits its size
+func formatUsageDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// recordUsage appends the command that just ran to the local usage log.
+// Failing to record never affects the command itself.
+func recordUsage(cmd *cobra.Command, start time.Time, err error, ver string) {
+	if usage.IsDisabled() || cmd == nil || cmd == rootCmd || cmd == usageCmd || !cmd.Runnable() {
+		return
+	}
+	if help, _ := cmd.Flags().GetBool("help"); help {
+		return
+	}
+	path := usage.Path()
+	if path == "" {
+		return
+	}
+
+	var envName string
+	if f := cmd.Flags().Lookup("env"); f != nil && f.Changed {
+		envName = normalizeEnvName(f.Value.String())
+	}
+
+	_ = usage.Append(path, usage.Record{
+		Command:     strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "),
+		StartedAt:   start.UTC(),
+		DurationMs:  time.Since(start).Milliseconds(),
+		Success:     err == nil,
+		Version:     ver,
+		CI:          config.IsCI(),
+		Environment: envName,
+	})
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/usage"
+)
+
+func TestRunUsageWithDeps_Summary(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+
+	now := time.Now().UTC()
+	fsMock.Files[usage.Path()] = []byte(strings.Join([]string{
+		`{"command":"pull","startedAt":"` + now.Add(-time.Hour).Format(time.RFC3339) + `","durationMs":1500,"success":true}`,
+		`{"command":"pull","startedAt":"` + now.Add(-2*time.Hour).Format(time.RFC3339) + `","durationMs":500,"success":false}`,
+		`{"command":"push","startedAt":"` + now.AddDate(0, 0, -60).Format(time.RFC3339) + `","durationMs":900,"success":true}`,
+	}, "\n"))
+
+	err := runUsageWithDeps(UsageOptions{Since: "30d"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rows []string
+	for _, m := range uiMock.MessageCalls {
+		if strings.Contains(m, "runs") {
+			rows = append(rows, m)
+		}
+	}
+	if len(rows) != 1 || !strings.Contains(rows[0], "pull") || !strings.Contains(rows[0], "1 failed") {
+		t.Errorf("expected only pull within 30 days, got %v", rows)
+	}
+	if len(uiMock.OutroCalls) == 0 || !strings.Contains(uiMock.OutroCalls[0], "2 commands, 2s") {
+		t.Errorf("unexpected outro: %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunUsageWithDeps_Empty(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runUsageWithDeps(UsageOptions{Since: "30d"}, deps)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.InfoCalls) == 0 || uiMock.InfoCalls[0] != "No usage recorded yet" {
+		t.Errorf("expected empty notice, got %v", uiMock.InfoCalls)
+	}
+}
+
+func TestRunUsageWithDeps_Clear(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files[usage.Path()] = []byte(`{"command":"pull"}`)
+
+	if err := runUsageWithDeps(UsageOptions{Clear: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, ok := fsMock.Written[usage.Path()]; !ok || len(data) != 0 {
+		t.Errorf("expected usage log to be emptied, got %q", data)
+	}
+}
+
+func TestRunUsageWithDeps_InvalidOptions(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	if err := runUsageWithDeps(UsageOptions{Since: "last week"}, deps); err == nil {
+		t.Error("expected error for invalid period")
+	}
+	if err := runUsageWithDeps(UsageOptions{Since: "all", Export: "xml"}, deps); err == nil {
+		t.Error("expected error for invalid export format")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"all", time.Time{}},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"24h", now.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.input, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
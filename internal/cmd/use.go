@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/auth"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var useCmd = &cobra.Command{
+	Use:   "use [owner/repo]",
+	Short: "Pin a repository, environment and profile for the next commands",
+	Long: `Pin a context so later commands need no flags: the repository replaces the
+one detected from the git remote, the environment is the default of every --env
+flag, and the profile selects which login is used.
+
+Only the parts given are changed. Commands show the pinned context under their
+title, and a flag given on the command line still wins.
+
+Without arguments, shows the pinned context.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUse,
+}
+
+func init() {
+	useCmd.Flags().StringP("env", "e", "", "Environment to pin")
+	useCmd.Flags().String("profile", "", "Login profile to pin, log in to it afterwards with keyway login")
+	useCmd.Flags().Bool("unset", false, "Clear the pinned context")
+}
+
+// UseOptions contains the parsed flags for the use command
+type UseOptions struct {
+	Repo    string
+	EnvName string
+	Profile string
+	Unset   bool
+}
+
+// PinnedContext is what keyway use pins for later commands
+type PinnedContext struct {
+	Repo    string `json:"repo,omitempty"`
+	Env     string `json:"environment,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// IsEmpty returns true when nothing is pinned
+func (c PinnedContext) IsEmpty() bool {
+	return c.Repo == "" && c.Env == "" && c.Profile == ""
+}
+
+// String describes the context, e.g. "acme/api · staging · profile work"
+func (c PinnedContext) String() string {
+	var parts []string
+	if c.Repo != "" {
+		parts = append(parts, c.Repo)
+	}
+	if c.Env != "" {
+		parts = append(parts, c.Env)
+	}
+	if c.Profile != "" {
+		parts = append(parts, "profile "+c.Profile)
+	}
+	return strings.Join(parts, " · ")
+}
+
+var (
+	repoNameRegex    = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// runUse is the entry point for the use command (uses default dependencies)
+func runUse(cmd *cobra.Command, args []string) error {
+	opts := UseOptions{}
+	if len(args) > 0 {
+		opts.Repo = args[0]
+	}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.Profile, _ = cmd.Flags().GetString("profile")
+	opts.Unset, _ = cmd.Flags().GetBool("unset")
+
+	return runUseWithDeps(opts, defaultDeps)
+}
+
+// runUseWithDeps is the testable version of runUse
+func runUseWithDeps(opts UseOptions, deps *Dependencies) error {
+	deps.UI.Intro("use")
+
+	if opts.Unset {
+		if opts.Repo != "" || opts.EnvName != "" || opts.Profile != "" {
+			deps.UI.Error("--unset cannot be combined with a context")
+			return fmt.Errorf("--unset cannot be combined with a context")
+		}
+		if err := savePinnedContext(PinnedContext{}, deps); err != nil {
+			deps.UI.Error(fmt.Sprintf("Failed to clear the context: %s", err.Error()))
+			return err
+		}
+		deps.UI.Success("Cleared the pinned context")
+		return nil
+	}
+
+	pinned := loadPinnedContext(deps)
+	if opts.Repo == "" && opts.EnvName == "" && opts.Profile == "" {
+		if pinned.IsEmpty() {
+			deps.UI.Info("No context pinned, commands use the repository of the git remote")
+			deps.UI.Message(deps.UI.Dim("Pin one with: keyway use owner/repo --env staging"))
+			return nil
+		}
+		printPinnedContext(pinned, deps)
+		return nil
+	}
+
+	if opts.Repo != "" {
+		if !repoNameRegex.MatchString(opts.Repo) {
+			deps.UI.Error(fmt.Sprintf("Invalid repository %q, use owner/repo", opts.Repo))
+			return fmt.Errorf("invalid repository %q", opts.Repo)
+		}
+		pinned.Repo = opts.Repo
+	}
+	if opts.EnvName != "" {
+		pinned.Env = normalizeEnvName(opts.EnvName)
+	}
+	if opts.Profile != "" {
+		if !profileNameRegex.MatchString(opts.Profile) {
+			deps.UI.Error(fmt.Sprintf("Invalid profile %q, use letters, digits, - and _", opts.Profile))
+			return fmt.Errorf("invalid profile %q", opts.Profile)
+		}
+		pinned.Profile = opts.Profile
+	}
+
+	if err := savePinnedContext(pinned, deps); err != nil {
+		deps.UI.Error(fmt.Sprintf("Failed to save the context: %s", err.Error()))
+		return err
+	}
+	deps.UI.Success(fmt.Sprintf("Now using %s", pinned))
+	printPinnedContext(pinned, deps)
+	deps.UI.Message(deps.UI.Dim("Clear it with: keyway use --unset"))
+	return nil
+}
+
+// printPinnedContext shows each part of the context
+func printPinnedContext(c PinnedContext, deps *Dependencies) {
+	if c.Repo != "" {
+		deps.UI.Step(fmt.Sprintf("Repository: %s", deps.UI.Value(c.Repo)))
+	}
+	if c.Env != "" {
+		deps.UI.Step(fmt.Sprintf("Environment: %s", deps.UI.Value(c.Env)))
+	}
+	if c.Profile != "" {
+		deps.UI.Step(fmt.Sprintf("Profile: %s", deps.UI.Value(c.Profile)))
+	}
+}
+
+// applyPinnedContext makes deps use the pinned context: its repository
+// replaces the detected one, its profile selects the login unless
+// KEYWAY_PROFILE is set, and commands show it under their title
+func applyPinnedContext(c PinnedContext, deps *Dependencies) {
+	if profile := config.GetProfile(); profile != "" {
+		auth.SetProfile(profile)
+	} else if c.Profile != "" {
+		auth.SetProfile(c.Profile)
+	}
+	if c.IsEmpty() {
+		return
+	}
+	if c.Repo != "" {
+		deps.Git = pinnedGit{GitClient: deps.Git, repo: c.Repo}
+	}
+	deps.UI = contextUI{UIProvider: deps.UI, context: c}
+}
+
+// withPinnedEnv makes the pinned environment the default of the command's
+// --env flag, when the flag isn't given
+func withPinnedEnv(envName string) CommandMiddleware {
+	return func(next RunFunc) RunFunc {
+		return func(cmd *cobra.Command, args []string) error {
+			// keyway use has its own --env, the one to pin
+			if flag := cmd.Flags().Lookup("env"); envName != "" && cmd != useCmd && flag != nil && !flag.Changed && flag.Value.Type() == "string" {
+				_ = flag.Value.Set(envName)
+			}
+			return next(cmd, args)
+		}
+	}
+}
+
+// pinnedGit reports the pinned repository instead of the git remote's
+type pinnedGit struct {
+	GitClient
+	repo string
+}
+
+func (g pinnedGit) DetectRepo() (string, error) {
+	return g.repo, nil
+}
+
+// contextUI shows the pinned context under each command's title
+type contextUI struct {
+	UIProvider
+	context PinnedContext
+}
+
+func (u contextUI) Intro(command string) {
+	u.UIProvider.Intro(command)
+	if command != "use" {
+		u.UIProvider.Message(u.UIProvider.Dim(fmt.Sprintf("Context: %s (keyway use --unset to clear)", u.context)))
+	}
+}
+
+// pinnedContextPath returns the file holding the pinned context
+func pinnedContextPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "context.json")
+}
+
+// loadPinnedContext returns the pinned context, empty when there is none
+func loadPinnedContext(deps *Dependencies) PinnedContext {
+	var c PinnedContext
+	path := pinnedContextPath()
+	if path == "" {
+		return c
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &c)
+	}
+	return c
+}
+
+// savePinnedContext writes the pinned context
+func savePinnedContext(c PinnedContext, deps *Dependencies) error {
+	path := pinnedContextPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
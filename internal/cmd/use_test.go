@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+func TestRunUseWithDeps_PinsContext(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+
+	err := runUseWithDeps(UseOptions{Repo: "acme/api", EnvName: "stg"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var pinned PinnedContext
+	if err := json.Unmarshal(fsMock.Written[pinnedContextPath()], &pinned); err != nil {
+		t.Fatalf("expected the context to be saved: %v", err)
+	}
+	if pinned != (PinnedContext{Repo: "acme/api", Env: "staging"}) {
+		t.Errorf("unexpected context: %+v", pinned)
+	}
+	if len(uiMock.SuccessCalls) != 1 || uiMock.SuccessCalls[0] != "Now using acme/api · staging" {
+		t.Errorf("unexpected success message: %v", uiMock.SuccessCalls)
+	}
+
+	// Only the parts given change
+	fsMock.Files[pinnedContextPath()] = fsMock.Written[pinnedContextPath()]
+	if err := runUseWithDeps(UseOptions{Profile: "work"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = json.Unmarshal(fsMock.Written[pinnedContextPath()], &pinned)
+	if pinned != (PinnedContext{Repo: "acme/api", Env: "staging", Profile: "work"}) {
+		t.Errorf("expected the profile to be added, got %+v", pinned)
+	}
+}
+
+func TestRunUseWithDeps_Unset(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, _ := NewTestDeps()
+	fsMock.Files[pinnedContextPath()] = []byte(`{"repo": "acme/api"}`)
+
+	if err := runUseWithDeps(UseOptions{Unset: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fsMock.Files[pinnedContextPath()] = fsMock.Written[pinnedContextPath()]
+	if pinned := loadPinnedContext(deps); !pinned.IsEmpty() {
+		t.Errorf("expected the context to be cleared, got %+v", pinned)
+	}
+
+	if err := runUseWithDeps(UseOptions{Unset: true, Repo: "acme/api"}, deps); err == nil {
+		t.Error("expected --unset with a context to fail")
+	}
+}
+
+func TestRunUseWithDeps_Show(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	fsMock.Files[pinnedContextPath()] = []byte(`{"repo": "acme/api", "profile": "work"}`)
+
+	if err := runUseWithDeps(UseOptions{}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fsMock.Written) != 0 {
+		t.Error("showing the context should not write it")
+	}
+	if len(uiMock.StepCalls) != 2 || !strings.HasPrefix(uiMock.StepCalls[0], "Repository:") || !strings.HasPrefix(uiMock.StepCalls[1], "Profile:") {
+		t.Errorf("expected the repository and profile, got %v", uiMock.StepCalls)
+	}
+}
+
+func TestRunUseWithDeps_InvalidInput(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	for _, opts := range []UseOptions{{Repo: "acme"}, {Repo: "acme/api/x"}, {Profile: "my profile"}} {
+		deps, _, _, _, fsMock, _ := NewTestDeps()
+		if err := runUseWithDeps(opts, deps); err == nil {
+			t.Errorf("expected %+v to be rejected", opts)
+		}
+		if len(fsMock.Written) != 0 {
+			t.Errorf("expected nothing to be saved for %+v", opts)
+		}
+	}
+}
+
+func TestApplyPinnedContext(t *testing.T) {
+	t.Setenv("KEYWAY_PROFILE", "")
+	t.Cleanup(func() { auth.SetProfile("") })
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	applyPinnedContext(PinnedContext{Repo: "acme/api", Env: "staging", Profile: "work"}, deps)
+
+	if repo, _ := deps.Git.DetectRepo(); repo != "acme/api" {
+		t.Errorf("expected the pinned repository, got %q", repo)
+	}
+	if auth.Profile() != "work" {
+		t.Errorf("expected the pinned profile to be selected, got %q", auth.Profile())
+	}
+	deps.UI.Intro("pull")
+	if len(uiMock.MessageCalls) != 1 || !strings.Contains(uiMock.MessageCalls[0], "acme/api · staging · profile work") {
+		t.Errorf("expected the context under the title, got %v", uiMock.MessageCalls)
+	}
+}
+
+func TestApplyPinnedContext_ProfileFromEnv(t *testing.T) {
+	t.Setenv("KEYWAY_PROFILE", "ci")
+	t.Cleanup(func() { auth.SetProfile("") })
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	applyPinnedContext(PinnedContext{Profile: "work"}, deps)
+
+	if auth.Profile() != "ci" {
+		t.Errorf("expected KEYWAY_PROFILE to win, got %q", auth.Profile())
+	}
+}
+
+func TestWithPinnedEnv(t *testing.T) {
+	var got string
+	cmd := &cobra.Command{Use: "pull", RunE: func(cmd *cobra.Command, args []string) error {
+		got, _ = cmd.Flags().GetString("env")
+		return nil
+	}}
+	cmd.Flags().StringP("env", "e", "development", "Environment name")
+	run := withPinnedEnv("staging")(cmd.RunE)
+
+	if err := run(cmd, nil); err != nil || got != "staging" {
+		t.Errorf("expected the pinned environment, got %q (%v)", got, err)
+	}
+
+	_ = cmd.Flags().Set("env", "production")
+	if err := run(cmd, nil); err != nil || got != "production" {
+		t.Errorf("expected the flag to win, got %q (%v)", got, err)
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/keywaysh/cli/internal/validator"
+)
+
+// runValidator runs a validation plugin, replaced in tests
+var runValidator = validator.Run
+
+// trustedValidator records that the user allowed an executable validator of a repository to run
+type trustedValidator struct {
+	Repo      string   `json:"repo"`
+	Name      string   `json:"name"`
+	Exec      []string `json:"exec"`
+	TrustedAt string   `json:"trustedAt"`
+}
+
+// checkValidators runs the validators declared in .keyway.json on the changes
+// of a push to envName and shows their annotations. It returns an error when a
+// validator blocks the changes, fails, or may not run.
+func checkValidators(ctx context.Context, repo, envName string, local, vault map[string]string, diff *env.PushDiff, prune, trust bool, deps *Dependencies) error {
+	project, err := loadProject(deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	blocked := false
+	for _, v := range project.Validators {
+		if !v.Applies(envName) {
+			continue
+		}
+		changes := validatorChanges(v, local, vault, diff, prune)
+		if len(changes) == 0 {
+			continue
+		}
+		if len(v.Exec) > 0 {
+			if err := ensureValidatorTrusted(repo, v, trust, deps); err != nil {
+				return err
+			}
+		}
+
+		var result *validator.Result
+		err := deps.UI.Spin(fmt.Sprintf("Running validator %s...", v.Name), func() error {
+			var err error
+			result, err = runValidator(ctx, v, ".", validator.Request{Repo: repo, Environment: envName, Changes: changes})
+			return err
+		})
+		if err != nil {
+			deps.UI.Error(fmt.Sprintf("Validator %s failed: %s", v.Name, err.Error()))
+			blocked = true
+			continue
+		}
+		for _, a := range result.Annotations {
+			msg := fmt.Sprintf("%s: %s", v.Name, a.Message)
+			if a.Key != "" {
+				msg = fmt.Sprintf("%s: %s: %s", v.Name, a.Key, a.Message)
+			}
+			switch a.Level {
+			case validator.LevelError:
+				deps.UI.Error(msg)
+			case validator.LevelWarning:
+				deps.UI.Warn(msg)
+			default:
+				deps.UI.Info(msg)
+			}
+		}
+		blocked = blocked || result.Blocks()
+	}
+
+	if blocked {
+		return fmt.Errorf("changes rejected by the validators of %s", config.ProjectFile)
+	}
+	return nil
+}
+
+// validatorChanges returns the changes of a push a validator receives
+func validatorChanges(v config.Validator, local, vault map[string]string, diff *env.PushDiff, prune bool) []validator.Change {
+	var changes []validator.Change
+	for _, key := range diff.Added {
+		if v.Wants(key) {
+			changes = append(changes, validator.Change{Key: key, Action: validator.ActionAdded, Value: local[key]})
+		}
+	}
+	for _, key := range diff.Changed {
+		if v.Wants(key) {
+			changes = append(changes, validator.Change{Key: key, Action: validator.ActionChanged, Value: local[key], Previous: vault[key]})
+		}
+	}
+	if prune {
+		for _, key := range diff.Removed {
+			if v.Wants(key) {
+				changes = append(changes, validator.Change{Key: key, Action: validator.ActionRemoved, Previous: vault[key]})
+			}
+		}
+	}
+	return changes
+}
+
+// ensureValidatorTrusted asks before running an executable validator for the
+// first time, since it comes with the repository. Trust is lost when the
+// command or the file it runs changes.
+func ensureValidatorTrusted(repo string, v config.Validator, trust bool, deps *Dependencies) error {
+	trusted := loadTrustedValidators(deps)
+	id := validatorTrustID(repo, v, deps)
+	if _, ok := trusted[id]; ok || trust {
+		return nil
+	}
+
+	command := strings.Join(v.Exec, " ")
+	if !deps.UI.IsInteractive() {
+		deps.UI.Error(fmt.Sprintf("Validator %s (%s) has not been trusted on this machine", v.Name, command))
+		deps.UI.Message(deps.UI.Dim("Run it with: --trust-validators"))
+		return fmt.Errorf("validator %s is not trusted", v.Name)
+	}
+	deps.UI.Warn(fmt.Sprintf("%s declares a validator that runs %s", config.ProjectFile, command))
+	confirmed, err := deps.UI.Confirm(fmt.Sprintf("Trust validator %s and run it?", v.Name), false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		deps.UI.Error(fmt.Sprintf("Validator %s was not trusted, the changes cannot be checked", v.Name))
+		return fmt.Errorf("validator %s is not trusted", v.Name)
+	}
+
+	trusted[id] = trustedValidator{
+		Repo:      repo,
+		Name:      v.Name,
+		Exec:      v.Exec,
+		TrustedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := writeTrustedValidators(trusted, deps); err != nil {
+		deps.UI.Warn(fmt.Sprintf("Failed to remember the validator: %s", err.Error()))
+	}
+	return nil
+}
+
+// validatorTrustID identifies an executable validator of a repository, with
+// the content of the file it runs when that is part of the project
+func validatorTrustID(repo string, v config.Validator, deps *Dependencies) string {
+	h := sha256.New()
+	h.Write([]byte(repo))
+	for _, arg := range v.Exec {
+		h.Write([]byte{0})
+		h.Write([]byte(arg))
+	}
+	if data, err := deps.FS.ReadFile(v.Exec[0]); err == nil {
+		h.Write([]byte{0})
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// trustedValidatorsPath returns the file holding the trusted validators
+func trustedValidatorsPath() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "trusted-validators.json")
+}
+
+// loadTrustedValidators returns the trusted validators, by trust id
+func loadTrustedValidators(deps *Dependencies) map[string]trustedValidator {
+	trusted := make(map[string]trustedValidator)
+	path := trustedValidatorsPath()
+	if path == "" {
+		return trusted
+	}
+	if data, err := deps.FS.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &trusted)
+	}
+	return trusted
+}
+
+// writeTrustedValidators writes the trusted validators
+func writeTrustedValidators(trusted map[string]trustedValidator, deps *Dependencies) error {
+	path := trustedValidatorsPath()
+	if path == "" {
+		return fmt.Errorf("cannot find the home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return deps.FS.WriteFile(path, data, 0600)
+}
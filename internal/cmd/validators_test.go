@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/validator"
+)
+
+// stubValidator replaces runValidator for the test, recording the requests
+func stubValidator(t *testing.T, fn func(v config.Validator, req validator.Request) (*validator.Result, error)) *[]validator.Request {
+	t.Helper()
+	var requests []validator.Request
+	old := runValidator
+	runValidator = func(ctx context.Context, v config.Validator, dir string, req validator.Request) (*validator.Result, error) {
+		requests = append(requests, req)
+		return fn(v, req)
+	}
+	t.Cleanup(func() { runValidator = old })
+	return &requests
+}
+
+const stripeValidatorProject = `{"validators": [{"name": "stripe-live", "wasm": "checks/stripe.wasm", "environments": ["production"], "keys": ["STRIPE_*"]}]}`
+
+func TestRunPushWithDeps_ValidatorBlocks(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[config.ProjectFile] = []byte(stripeValidatorProject)
+	fsMock.Files[".env"] = []byte("STRIPE_KEY=sk_test_123\nAPI_URL=https://api.test\n")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "STRIPE_KEY=sk_live_456\n"}
+	requests := stubValidator(t, func(v config.Validator, req validator.Request) (*validator.Result, error) {
+		return &validator.Result{Annotations: []validator.Annotation{{Key: "STRIPE_KEY", Level: validator.LevelError, Message: "not a live key"}}}, nil
+	})
+
+	err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected the push to be blocked")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing pushed, got %v", apiMock.PushedSecrets)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("expected one validator run, got %d", len(*requests))
+	}
+	changes := (*requests)[0].Changes
+	if len(changes) != 1 || changes[0].Key != "STRIPE_KEY" || changes[0].Value != "sk_test_123" || changes[0].Previous != "sk_live_456" {
+		t.Errorf("expected only the STRIPE_KEY change, got %+v", changes)
+	}
+	found := false
+	for _, msg := range uiMock.ErrorCalls {
+		found = found || strings.Contains(msg, "not a live key")
+	}
+	if !found {
+		t.Errorf("expected the annotation to be shown, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunPushWithDeps_ValidatorWarns(t *testing.T) {
+	deps, _, _, uiMock, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[config.ProjectFile] = []byte(stripeValidatorProject)
+	fsMock.Files[".env"] = []byte("STRIPE_KEY=sk_live_789\n")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "production"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "ok"}
+	stubValidator(t, func(v config.Validator, req validator.Request) (*validator.Result, error) {
+		return &validator.Result{Annotations: []validator.Annotation{{Key: "STRIPE_KEY", Level: validator.LevelWarning, Message: "restricted key"}}}, nil
+	})
+
+	if err := runPushWithDeps(PushOptions{EnvName: "production", File: ".env", Yes: true, EnvFlagSet: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.PushedSecrets["STRIPE_KEY"] != "sk_live_789" {
+		t.Errorf("expected the push to go through, got %v", apiMock.PushedSecrets)
+	}
+	if len(uiMock.WarnCalls) == 0 || !strings.Contains(strings.Join(uiMock.WarnCalls, "\n"), "restricted key") {
+		t.Errorf("expected the warning to be shown, got %v", uiMock.WarnCalls)
+	}
+}
+
+func TestRunPushWithDeps_ValidatorSkippedForOtherEnvironments(t *testing.T) {
+	deps, _, _, _, fsMock, envMock, apiMock := NewTestDepsWithEnv()
+	fsMock.Files[config.ProjectFile] = []byte(stripeValidatorProject)
+	fsMock.Files[".env"] = []byte("STRIPE_KEY=sk_test_123\n")
+	envMock.Candidates = []EnvCandidate{{File: ".env", Env: "staging"}}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	apiMock.PushResponse = &api.PushSecretsResponse{Message: "ok"}
+	requests := stubValidator(t, func(v config.Validator, req validator.Request) (*validator.Result, error) {
+		return nil, errors.New("should not run")
+	})
+
+	if err := runPushWithDeps(PushOptions{EnvName: "staging", File: ".env", Yes: true, EnvFlagSet: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("expected the validator not to run for staging")
+	}
+}
+
+func TestRunSetWithDeps_ValidatorFailureBlocks(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(stripeValidatorProject)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	stubValidator(t, func(v config.Validator, req validator.Request) (*validator.Result, error) {
+		return nil, errors.New("exit status 1")
+	})
+
+	err := runSetWithDeps(SetOptions{Key: "STRIPE_KEY", Value: "sk_test_1", EnvName: "production", EnvFlagSet: true, Yes: true}, deps)
+
+	if err == nil {
+		t.Fatal("expected a failing validator to block the set")
+	}
+	if apiMock.PushedSecrets != nil {
+		t.Errorf("expected nothing pushed, got %v", apiMock.PushedSecrets)
+	}
+	if len(uiMock.ErrorCalls) == 0 || !strings.Contains(uiMock.ErrorCalls[0], "stripe-live failed") {
+		t.Errorf("expected the failure to be shown, got %v", uiMock.ErrorCalls)
+	}
+}
+
+const execValidatorProject = `{"validators": [{"name": "naming", "exec": ["./scripts/check-naming"]}]}`
+
+func TestCheckValidators_ExecNeedsTrust(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[config.ProjectFile] = []byte(execValidatorProject)
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: ""}
+	requests := stubValidator(t, func(v config.Validator, req validator.Request) (*validator.Result, error) {
+		return &validator.Result{}, nil
+	})
+
+	err := runSetWithDeps(SetOptions{Key: "API_KEY", Value: "x", EnvName: "development", EnvFlagSet: true, Yes: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Fatalf("expected an untrusted validator error, got %v", err)
+	}
+	if len(*requests) != 0 {
+		t.Error("expected the untrusted validator not to run")
+	}
+
+	err = runSetWithDeps(SetOptions{Key: "API_KEY", Value: "x", EnvName: "development", EnvFlagSet: true, Yes: true, TrustValidators: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Error("expected the validator to run with --trust-validators")
+	}
+}
+
+func TestEnsureValidatorTrusted_RemembersAndNoticesChanges(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, fsMock, _ := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = true
+	v := config.Validator{Name: "naming", Exec: []string{"./scripts/check-naming"}}
+	fsMock.Files["./scripts/check-naming"] = []byte("#!/bin/sh\nexit 0\n")
+
+	if err := ensureValidatorTrusted("owner/repo", v, false, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fsMock.Files[trustedValidatorsPath()] = fsMock.Written[trustedValidatorsPath()]
+
+	uiMock.ConfirmResult = false
+	if err := ensureValidatorTrusted("owner/repo", v, false, deps); err != nil {
+		t.Fatalf("expected the trust to be remembered: %v", err)
+	}
+	if len(uiMock.ConfirmCalls) != 1 {
+		t.Errorf("expected a single prompt, got %v", uiMock.ConfirmCalls)
+	}
+
+	fsMock.Files["./scripts/check-naming"] = []byte("#!/bin/sh\ncurl https://evil.test\n")
+	if err := ensureValidatorTrusted("owner/repo", v, false, deps); err == nil {
+		t.Error("expected a changed script to need trust again")
+	}
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Manage the vault of the repository",
+	Long:  `Manage the vault of the current repository itself, rather than its secrets.`,
+}
+
+var vaultRelinkCmd = &cobra.Command{
+	Use:   "relink",
+	Short: "Reconnect the vault of a renamed or transferred repository",
+	Long: `Reconnect the vault of a repository renamed or transferred on GitHub. The
+vault stays under the repository's previous name until it is relinked, so
+commands no longer find it.
+
+Modes:
+  relink   Move the vault to the new name, with its history and settings
+  migrate  Copy the secrets of every environment into a new vault, keeping
+           the old one (e.g. when the old name is reused by another repository)
+
+The previous name is looked up on the server. Give it with --from when the
+lookup cannot find it, e.g. after several renames.`,
+	Args: cobra.NoArgs,
+	RunE: runVaultRelink,
+}
+
+func init() {
+	vaultRelinkCmd.Flags().String("from", "", "Name the vault is stored under (default: looked up)")
+	vaultRelinkCmd.Flags().String("mode", "", "relink or migrate (default: asked, relink when non-interactive)")
+	vaultRelinkCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	vaultCmd.AddCommand(vaultRelinkCmd)
+}
+
+// Modes of keyway vault relink
+const (
+	relinkModeRelink  = "relink"
+	relinkModeMigrate = "migrate"
+)
+
+// VaultRelinkOptions contains the parsed flags for the vault relink command
+type VaultRelinkOptions struct {
+	From string
+	Mode string
+	Yes  bool
+}
+
+// runVaultRelink is the entry point for the vault relink command (uses default dependencies)
+func runVaultRelink(cmd *cobra.Command, args []string) error {
+	opts := VaultRelinkOptions{}
+	opts.From, _ = cmd.Flags().GetString("from")
+	opts.Mode, _ = cmd.Flags().GetString("mode")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+
+	return runVaultRelinkWithDeps(opts, defaultDeps)
+}
+
+// runVaultRelinkWithDeps is the testable version of runVaultRelink
+func runVaultRelinkWithDeps(opts VaultRelinkOptions, deps *Dependencies) error {
+	if opts.Mode != "" && opts.Mode != relinkModeRelink && opts.Mode != relinkModeMigrate {
+		err := fmt.Errorf("invalid mode %q, use relink or migrate", opts.Mode)
+		deps.UI.Error(err.Error())
+		return err
+	}
+	if opts.From != "" && !repoNameRegex.MatchString(opts.From) {
+		err := fmt.Errorf("invalid repository %q, use owner/repo", opts.From)
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	return runPipeline(deps, func(s *Session) error {
+		return vaultRelink(s, opts)
+	}, withIntro("vault relink"), withRepo, withLogin)
+}
+
+// vaultRelink finds the vault of the repository under its previous name and
+// relinks or migrates it
+func vaultRelink(s *Session, opts VaultRelinkOptions) error {
+	deps := s.Deps
+
+	var exists bool
+	err := s.Spin("Checking the vault...", func() error {
+		var err error
+		exists, err = s.Client.CheckVaultExists(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return reportEnvError("vault relink", err, deps)
+	}
+	if exists {
+		if opts.From == "" {
+			deps.UI.Success(fmt.Sprintf("The vault is already linked to %s", s.Repo))
+			return nil
+		}
+		err := fmt.Errorf("%s already has a vault, %s cannot be relinked to it", s.Repo, opts.From)
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	moved := &api.MovedVault{RepoFullName: opts.From, CurrentRepoFullName: s.Repo}
+	if opts.From == "" {
+		err = s.Spin("Looking up the previous names of the repository...", func() error {
+			var err error
+			moved, err = s.Client.FindMovedVault(s.Ctx, s.Repo)
+			return err
+		})
+		if err != nil {
+			return reportEnvError("vault relink", err, deps)
+		}
+		if moved == nil {
+			err := fmt.Errorf("no vault found under a previous name of %s", s.Repo)
+			deps.UI.Error(err.Error())
+			deps.UI.Message(deps.UI.Dim("Give the name it is stored under with: keyway vault relink --from owner/repo"))
+			deps.UI.Message(deps.UI.Dim("Or create a new vault with: keyway init"))
+			return err
+		}
+	}
+	if moved.RepoFullName == s.Repo {
+		deps.UI.Success(fmt.Sprintf("The vault is already linked to %s", s.Repo))
+		return nil
+	}
+	deps.UI.Step(fmt.Sprintf("Vault: %s", deps.UI.Value(moved.RepoFullName)))
+	if moved.Reason != "" {
+		deps.UI.Info(fmt.Sprintf("The repository was %s from %s", moved.Reason, moved.RepoFullName))
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = relinkModeRelink
+		if deps.UI.IsInteractive() {
+			selected, err := deps.UI.Select("How should the vault follow the repository?", []string{
+				relinkModeRelink + " - move the vault, with its history and settings",
+				relinkModeMigrate + " - copy the secrets into a new vault, keeping the old one",
+			})
+			if err != nil {
+				return err
+			}
+			mode, _, _ = strings.Cut(selected, " ")
+		}
+	}
+
+	question := fmt.Sprintf("Move the vault of %s to %s?", moved.RepoFullName, s.Repo)
+	if mode == relinkModeMigrate {
+		question = fmt.Sprintf("Copy the secrets of %s into a new vault for %s?", moved.RepoFullName, s.Repo)
+	}
+	if !opts.Yes && deps.UI.IsInteractive() {
+		confirmed, err := deps.UI.Confirm(question, true)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			deps.UI.Warn("Aborted.")
+			return nil
+		}
+	} else if !opts.Yes {
+		err := fmt.Errorf("confirmation required - use --yes in non-interactive mode")
+		deps.UI.Error(err.Error())
+		return err
+	}
+
+	if mode == relinkModeMigrate {
+		err = migrateVault(s, moved.RepoFullName)
+	} else {
+		err = s.Spin("Relinking the vault...", func() error {
+			return s.Client.RelinkVault(s.Ctx, moved.RepoFullName, s.Repo)
+		})
+		if err == nil {
+			deps.UI.Success(fmt.Sprintf("Relinked the vault of %s to %s", moved.RepoFullName, s.Repo))
+		}
+	}
+	if err != nil {
+		return reportEnvError("vault relink", err, deps)
+	}
+
+	// A context pinned to the previous name would keep pointing at it
+	if pinned := loadPinnedContext(deps); pinned.Repo == moved.RepoFullName {
+		pinned.Repo = s.Repo
+		if err := savePinnedContext(pinned, deps); err != nil {
+			deps.UI.Warn(fmt.Sprintf("Failed to update the pinned context: %s", err.Error()))
+		} else {
+			deps.UI.Step(fmt.Sprintf("Pinned context now uses %s", deps.UI.Value(s.Repo)))
+		}
+	}
+	return nil
+}
+
+// migrateVault creates the vault of the session's repository and copies the
+// secrets of every environment of the vault stored under from into it
+func migrateVault(s *Session, from string) error {
+	deps := s.Deps
+
+	var envs []string
+	err := s.Spin("Reading the environments...", func() error {
+		var err error
+		envs, err = s.Client.GetVaultEnvironments(s.Ctx, from)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.Spin("Creating the vault...", func() error {
+		_, err := s.Client.InitVault(s.Ctx, s.Repo)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	copied := 0
+	for _, envName := range envs {
+		err := s.Spin(fmt.Sprintf("Copying %s...", envName), func() error {
+			resp, err := s.Client.PullSecrets(s.Ctx, from, envName)
+			if err != nil {
+				if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode == 404 {
+					return nil
+				}
+				return err
+			}
+			secrets := env.ParseVault(resp.Content)
+			if len(secrets) == 0 {
+				return nil
+			}
+			if _, err := s.Client.PushSecrets(s.Ctx, s.Repo, envName, secrets, uuid.NewString()); err != nil {
+				return err
+			}
+			copied += len(secrets)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("copying %s: %w", envName, err)
+		}
+	}
+
+	deps.UI.Success(fmt.Sprintf("Copied %d secrets from %s to %s", copied, from, s.Repo))
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("The vault of %s is kept, delete it from the dashboard once %s is checked", from, s.Repo)))
+	return nil
+}
+
+// explainMovedVault tells, when a vault is not found, whether it is still
+// under a previous name of the repository. It returns true if it is.
+func explainMovedVault(ctx context.Context, client api.APIClient, repo string, err error, deps *Dependencies) bool {
+	apiErr, ok := err.(*api.APIError)
+	if !ok || apiErr.StatusCode != 404 {
+		return false
+	}
+	moved, lookupErr := client.FindMovedVault(ctx, repo)
+	if lookupErr != nil || moved == nil || moved.RepoFullName == repo {
+		return false
+	}
+	reason := moved.Reason
+	if reason == "" {
+		reason = "moved"
+	}
+	deps.UI.Warn(fmt.Sprintf("The vault is still under %s, the repository was %s since", moved.RepoFullName, reason))
+	deps.UI.Message(deps.UI.Dim("Reconnect it with: keyway vault relink"))
+	return true
+}
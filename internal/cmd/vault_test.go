@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestRunVaultRelinkWithDeps_Relinks(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.MovedVault = &api.MovedVault{RepoFullName: "owner/old", CurrentRepoFullName: "owner/repo", Reason: api.MovedRenamed}
+
+	err := runVaultRelinkWithDeps(VaultRelinkOptions{Yes: true}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.RelinkedVault) != 2 || apiMock.RelinkedVault[0] != "owner/old" || apiMock.RelinkedVault[1] != "owner/repo" {
+		t.Errorf("expected owner/old to be relinked to owner/repo, got %v", apiMock.RelinkedVault)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_AlreadyLinked(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	apiMock.VaultExists = true
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RelinkedVault != nil {
+		t.Errorf("expected no relink, got %v", apiMock.RelinkedVault)
+	}
+	if len(uiMock.SuccessCalls) != 1 {
+		t.Errorf("expected a success message, got %v", uiMock.SuccessCalls)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_NothingFound(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{Yes: true}, deps); err == nil {
+		t.Fatal("expected an error when no previous vault is found")
+	}
+	if apiMock.RelinkedVault != nil || len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected no relink and an error, errors: %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_From(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{From: "acme/legacy", Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apiMock.RelinkedVault) != 2 || apiMock.RelinkedVault[0] != "acme/legacy" {
+		t.Errorf("expected acme/legacy to be relinked, got %v", apiMock.RelinkedVault)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_RejectsInvalidOptions(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{Mode: "copy", Yes: true}, deps); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{From: "not-a-repo", Yes: true}, deps); err == nil {
+		t.Error("expected an error for an invalid --from")
+	}
+	if apiMock.RelinkedVault != nil {
+		t.Errorf("expected no relink, got %v", apiMock.RelinkedVault)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_RequiresConfirmation(t *testing.T) {
+	deps, _, _, _, _, apiMock := NewTestDeps()
+	apiMock.MovedVault = &api.MovedVault{RepoFullName: "owner/old", CurrentRepoFullName: "owner/repo"}
+
+	err := runVaultRelinkWithDeps(VaultRelinkOptions{}, deps)
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected a confirmation error, got %v", err)
+	}
+	if apiMock.RelinkedVault != nil {
+		t.Errorf("expected no relink, got %v", apiMock.RelinkedVault)
+	}
+}
+
+func TestRunVaultRelinkWithDeps_InteractiveMigrate(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	uiMock.Interactive = true
+	uiMock.ConfirmResult = true
+	uiMock.SelectResult = "migrate - copy the secrets into a new vault, keeping the old one"
+	apiMock.MovedVault = &api.MovedVault{RepoFullName: "owner/old", CurrentRepoFullName: "owner/repo", Reason: api.MovedTransferred}
+	apiMock.InitResponse = &api.InitVaultResponse{VaultID: "vault-2"}
+	apiMock.VaultEnvs = []string{"development", "production"}
+	apiMock.PullResponse = &api.PullSecretsResponse{Content: "API_KEY=secret\nDB_URL=postgres://x\n"}
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiMock.RelinkedVault != nil {
+		t.Errorf("expected a migration, not a relink")
+	}
+	for _, envName := range apiMock.VaultEnvs {
+		if apiMock.PushedByEnv[envName]["API_KEY"] != "secret" {
+			t.Errorf("expected %s to be copied, got %v", envName, apiMock.PushedByEnv[envName])
+		}
+	}
+}
+
+func TestRunVaultRelinkWithDeps_UpdatesPinnedContext(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", t.TempDir())
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.MovedVault = &api.MovedVault{RepoFullName: "owner/old", CurrentRepoFullName: "owner/repo"}
+	data, _ := json.Marshal(PinnedContext{Repo: "owner/old", Env: "staging"})
+	fsMock.Files[pinnedContextPath()] = data
+
+	if err := runVaultRelinkWithDeps(VaultRelinkOptions{Yes: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var pinned PinnedContext
+	if err := json.Unmarshal(fsMock.Written[pinnedContextPath()], &pinned); err != nil {
+		t.Fatal(err)
+	}
+	if pinned.Repo != "owner/repo" || pinned.Env != "staging" {
+		t.Errorf("expected the pinned repository to follow, got %+v", pinned)
+	}
+}
+
+func TestExplainMovedVault(t *testing.T) {
+	deps, _, _, uiMock, _, apiMock := NewTestDeps()
+	notFound := &api.APIError{StatusCode: 404, Detail: "Vault not found"}
+
+	if explainMovedVault(context.Background(), apiMock, "owner/repo", notFound, deps) {
+		t.Error("expected no hint without a moved vault")
+	}
+
+	apiMock.MovedVault = &api.MovedVault{RepoFullName: "owner/old", CurrentRepoFullName: "owner/repo", Reason: api.MovedRenamed}
+	if explainMovedVault(context.Background(), apiMock, "owner/repo", &api.APIError{StatusCode: 403}, deps) {
+		t.Error("expected no hint for other errors")
+	}
+	if !explainMovedVault(context.Background(), apiMock, "owner/repo", notFound, deps) {
+		t.Fatal("expected a hint for a moved vault")
+	}
+	if len(uiMock.WarnCalls) != 1 || !strings.Contains(uiMock.WarnCalls[0], "owner/old") {
+		t.Errorf("expected a warning naming the old repository, got %v", uiMock.WarnCalls)
+	}
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/keywaysh/cli/internal/config"
+	"github.com/keywaysh/cli/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var verifyInstallCmd = &cobra.Command{
+	Use:   "verify-install",
+	Short: "Check this binary against its published release",
+	Long: `Check that the running keyway binary is the one published for its version:
+the release's checksums are downloaded and their signature verified, then the
+archive for this platform is checked against them and its binary compared with
+this one, byte for byte.
+
+Reports the binary's provenance (version, commit, build date, builder) and
+exits with an error when anything does not match. A build without a release
+key cannot check the signature, and is not verified unless --allow-unsigned
+accepts the unsigned checksums. KEYWAY_RELEASES_URL points
+at a mirror of the release assets, e.g. on hosts without access to GitHub.`,
+	Args: cobra.NoArgs,
+	RunE: runVerifyInstall,
+}
+
+func init() {
+	verifyInstallCmd.Flags().Bool("allow-unsigned", false, "Compare against the checksums when this build cannot check their signature")
+}
+
+// VerifyInstallOptions contains the parsed flags for the verify-install command
+type VerifyInstallOptions struct {
+	JSONOutput    bool
+	AllowUnsigned bool
+	Build         version.Build
+	Executable    string // path of the binary to check, the running one by default
+	GOOS          string
+	GOARCH        string
+}
+
+// installReport is what keyway verify-install found
+type installReport struct {
+	version.Build
+	GoVersion     string `json:"goVersion"`
+	Platform      string `json:"platform"`
+	Executable    string `json:"executable"`
+	SHA256        string `json:"sha256"`
+	Archive       string `json:"archive,omitempty"`
+	ArchiveSHA256 string `json:"archiveSha256,omitempty"`
+	Signature     string `json:"signature"` // verified, unchecked or failed
+	Matches       bool   `json:"matches"`   // the binary is the one in the release checksums
+	Verified      bool   `json:"verified"`  // and the checksums' signature is verified
+	Error         string `json:"error,omitempty"`
+}
+
+// Signature states of an installReport
+const (
+	signatureVerified  = "verified"
+	signatureUnchecked = "unchecked"
+	signatureFailed    = "failed"
+)
+
+// runVerifyInstall is the entry point for the verify-install command (uses default dependencies)
+func runVerifyInstall(cmd *cobra.Command, args []string) error {
+	opts := VerifyInstallOptions{
+		Build:  version.CurrentBuild(),
+		GOOS:   runtime.GOOS,
+		GOARCH: runtime.GOARCH,
+	}
+	opts.JSONOutput, _ = cmd.Flags().GetBool("json")
+	opts.AllowUnsigned, _ = cmd.Flags().GetBool("allow-unsigned")
+
+	deps := defaultDeps
+	if opts.JSONOutput {
+		deps = withJSONUI(deps)
+	}
+	return runVerifyInstallWithDeps(opts, deps)
+}
+
+// runVerifyInstallWithDeps is the testable version of runVerifyInstall
+func runVerifyInstallWithDeps(opts VerifyInstallOptions, deps *Dependencies) error {
+	deps.UI.Intro("verify-install")
+
+	report := installReport{
+		Build:     opts.Build,
+		GoVersion: runtime.Version(),
+		Platform:  opts.GOOS + "/" + opts.GOARCH,
+		Signature: signatureUnchecked,
+	}
+	err := verifyInstall(&report, opts, deps)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if opts.JSONOutput {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		printInstallReport(report, deps)
+	}
+	if err != nil {
+		deps.UI.Error(fmt.Sprintf("Not verified: %s", err.Error()))
+		return err
+	}
+	if report.Signature == signatureUnchecked {
+		deps.UI.Outro("This binary matches the release checksums, whose signature was not checked")
+		return nil
+	}
+	deps.UI.Outro("This binary is the published release")
+	return nil
+}
+
+// verifyInstall fills report, and returns why the binary is not verified
+func verifyInstall(report *installReport, opts VerifyInstallOptions, deps *Dependencies) error {
+	executable := opts.Executable
+	if executable == "" {
+		var err error
+		if executable, err = os.Executable(); err != nil {
+			return fmt.Errorf("cannot locate the running binary: %w", err)
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	report.Executable = executable
+
+	binary, err := os.ReadFile(executable)
+	if err != nil {
+		return fmt.Errorf("cannot read the running binary: %w", err)
+	}
+	report.SHA256 = sha256Hex(binary)
+
+	if !opts.Build.IsRelease() {
+		return fmt.Errorf("development build, there is no published release to check it against")
+	}
+
+	ctx := context.Background()
+	baseURL := config.GetReleasesURL()
+	ver := opts.Build.Version
+
+	var checksums, signature []byte
+	err = deps.UI.Spin("Downloading the release checksums...", func() error {
+		var err error
+		if checksums, err = version.Download(ctx, version.AssetURL(baseURL, ver, version.ChecksumsFile)); err != nil {
+			return err
+		}
+		signature, err = version.Download(ctx, version.AssetURL(baseURL, ver, version.SignatureFile))
+		if errors.Is(err, version.ErrNotFound) {
+			signature, err = nil, nil
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, version.ErrNotFound) {
+			return fmt.Errorf("no published release %s", ver)
+		}
+		return fmt.Errorf("cannot download the release checksums: %w", err)
+	}
+
+	switch {
+	case opts.Build.ReleaseKey == "":
+		// Builds without a release key cannot tell a genuine signature from
+		// another, and the checksums come from the same unauthenticated download
+		if !opts.AllowUnsigned {
+			return fmt.Errorf("this build has no release key to check the checksums' signature (--allow-unsigned compares against them anyway)")
+		}
+		deps.UI.Warn("This build has no release key, the checksums' signature is not checked")
+	case signature == nil:
+		report.Signature = signatureFailed
+		return fmt.Errorf("release %s has no signature", ver)
+	default:
+		if err := version.VerifySignature(checksums, signature, opts.Build.ReleaseKey); err != nil {
+			report.Signature = signatureFailed
+			return fmt.Errorf("release checksums: %w", err)
+		}
+		report.Signature = signatureVerified
+	}
+
+	report.Archive = version.ArchiveName(ver, opts.GOOS, opts.GOARCH)
+	report.ArchiveSHA256 = version.ParseChecksums(checksums)[report.Archive]
+	if report.ArchiveSHA256 == "" {
+		return fmt.Errorf("release %s has no archive for %s", ver, report.Platform)
+	}
+
+	var archive []byte
+	err = deps.UI.Spin(fmt.Sprintf("Downloading %s...", report.Archive), func() error {
+		var err error
+		archive, err = version.Download(ctx, version.AssetURL(baseURL, ver, report.Archive))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot download %s: %w", report.Archive, err)
+	}
+	if sha256Hex(archive) != report.ArchiveSHA256 {
+		return fmt.Errorf("%s does not match the release checksums", report.Archive)
+	}
+
+	published, err := version.ExtractBinary(archive, report.Archive, version.BinaryName(opts.GOOS))
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", report.Archive, err)
+	}
+	if sha256Hex(published) != report.SHA256 {
+		return fmt.Errorf("this binary differs from the one published in %s", report.Archive)
+	}
+
+	report.Matches = true
+	report.Verified = report.Signature == signatureVerified
+	return nil
+}
+
+// printInstallReport shows the provenance and the checks of the binary
+func printInstallReport(report installReport, deps *Dependencies) {
+	unknown := func(s string) string {
+		if s == "" {
+			return deps.UI.Dim("unknown")
+		}
+		return s
+	}
+	deps.UI.Step(fmt.Sprintf("Version:    %s", deps.UI.Value(report.Version)))
+	deps.UI.Step(fmt.Sprintf("Commit:     %s", unknown(report.Commit)))
+	deps.UI.Step(fmt.Sprintf("Built:      %s", unknown(report.Date)))
+	deps.UI.Step(fmt.Sprintf("Builder:    %s", unknown(report.BuiltBy)))
+	deps.UI.Step(fmt.Sprintf("Go:         %s, %s", report.GoVersion, report.Platform))
+	deps.UI.Step(fmt.Sprintf("Binary:     %s", report.Executable))
+	deps.UI.Step(fmt.Sprintf("SHA-256:    %s", unknown(report.SHA256)))
+	if report.Archive != "" {
+		deps.UI.Step(fmt.Sprintf("Release:    %s %s", report.Archive, deps.UI.Dim(report.ArchiveSHA256)))
+	}
+	deps.UI.Step(fmt.Sprintf("Signature:  %s", report.Signature))
+}
+
+// sha256Hex returns the hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/version"
+)
+
+// fakeRelease serves a signed release 1.2.3 holding binary for linux/amd64,
+// and returns the public key of its signature
+func fakeRelease(t *testing.T, binary []byte) string {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "keyway", Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+
+	name := version.ArchiveName("1.2.3", "linux", "amd64")
+	checksums := []byte(fmt.Sprintf("%s  %s\n", sha256Hex(archive.Bytes()), name))
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	assets := map[string][]byte{
+		"/v1.2.3/" + version.ChecksumsFile: checksums,
+		"/v1.2.3/" + version.SignatureFile: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, checksums))),
+		"/v1.2.3/" + name:                  archive.Bytes(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("KEYWAY_RELEASES_URL", server.URL)
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+// writeExecutable writes a binary to check
+func writeExecutable(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keyway")
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunVerifyInstallWithDeps_Verified(t *testing.T) {
+	binary := []byte("genuine keyway 1.2.3")
+	key := fakeRelease(t, binary)
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runVerifyInstallWithDeps(VerifyInstallOptions{
+		Build:      version.Build{Version: "1.2.3", Commit: "abc1234", ReleaseKey: key},
+		Executable: writeExecutable(t, binary),
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+	}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsCall(uiMock.StepCalls, "Signature:  verified") || !containsCall(uiMock.StepCalls, "abc1234") {
+		t.Errorf("expected the provenance and a verified signature, got %v", uiMock.StepCalls)
+	}
+}
+
+func TestRunVerifyInstallWithDeps_ModifiedBinary(t *testing.T) {
+	key := fakeRelease(t, []byte("genuine keyway 1.2.3"))
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runVerifyInstallWithDeps(VerifyInstallOptions{
+		Build:      version.Build{Version: "1.2.3", ReleaseKey: key},
+		Executable: writeExecutable(t, []byte("patched keyway")),
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+	}, deps)
+	if err == nil || !strings.Contains(err.Error(), "differs") {
+		t.Fatalf("expected a mismatch, got %v", err)
+	}
+	if len(uiMock.ErrorCalls) != 1 {
+		t.Errorf("expected an error message, got %v", uiMock.ErrorCalls)
+	}
+}
+
+func TestRunVerifyInstallWithDeps_WrongReleaseKey(t *testing.T) {
+	binary := []byte("genuine keyway 1.2.3")
+	fakeRelease(t, binary)
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	err := runVerifyInstallWithDeps(VerifyInstallOptions{
+		Build:      version.Build{Version: "1.2.3", ReleaseKey: base64.StdEncoding.EncodeToString(other)},
+		Executable: writeExecutable(t, binary),
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+	}, deps)
+	if err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Fatalf("expected a signature error, got %v", err)
+	}
+}
+
+func TestRunVerifyInstallWithDeps_NoReleaseKey(t *testing.T) {
+	binary := []byte("genuine keyway 1.2.3")
+	fakeRelease(t, binary)
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	err := runVerifyInstallWithDeps(VerifyInstallOptions{
+		Build:      version.Build{Version: "1.2.3"},
+		Executable: writeExecutable(t, binary),
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+	}, deps)
+	if err == nil || !strings.Contains(err.Error(), "release key") {
+		t.Fatalf("expected an unsigned build not to be verified, got %v", err)
+	}
+	if len(uiMock.OutroCalls) != 0 {
+		t.Errorf("expected no outro, got %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunVerifyInstallWithDeps_AllowUnsigned(t *testing.T) {
+	binary := []byte("genuine keyway 1.2.3")
+	fakeRelease(t, binary)
+	deps, _, _, uiMock, _, _ := NewTestDeps()
+
+	report := installReport{Signature: signatureUnchecked}
+	opts := VerifyInstallOptions{
+		AllowUnsigned: true,
+		Build:         version.Build{Version: "1.2.3"},
+		Executable:    writeExecutable(t, binary),
+		GOOS:          "linux",
+		GOARCH:        "amd64",
+	}
+	if err := verifyInstall(&report, opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Matches || report.Verified {
+		t.Errorf("expected a match without verification, got %+v", report)
+	}
+
+	uiMock.WarnCalls = nil
+	if err := runVerifyInstallWithDeps(opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uiMock.WarnCalls) != 1 || !containsCall(uiMock.StepCalls, "Signature:  unchecked") {
+		t.Errorf("expected the unchecked signature to be reported, got %v %v", uiMock.WarnCalls, uiMock.StepCalls)
+	}
+	if len(uiMock.OutroCalls) != 1 || !strings.Contains(uiMock.OutroCalls[0], "signature was not checked") {
+		t.Errorf("expected the outro to say the signature was not checked, got %v", uiMock.OutroCalls)
+	}
+}
+
+func TestRunVerifyInstallWithDeps_DevBuild(t *testing.T) {
+	deps, _, _, _, _, _ := NewTestDeps()
+
+	err := runVerifyInstallWithDeps(VerifyInstallOptions{
+		Build:      version.Build{Version: "dev"},
+		Executable: writeExecutable(t, []byte("dev")),
+		GOOS:       "linux",
+		GOARCH:     "amd64",
+	}, deps)
+	if err == nil {
+		t.Fatal("expected a development build not to be verified")
+	}
+}
+
+// containsCall returns true if one of the recorded UI calls contains s
+func containsCall(calls []string, s string) bool {
+	for _, call := range calls {
+		if strings.Contains(call, s) {
+			return true
+		}
+	}
+	return false
+}
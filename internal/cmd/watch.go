@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/keywaysh/cli/internal/analytics"
+	"github.com/keywaysh/cli/internal/api"
+	"github.com/keywaysh/cli/internal/env"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep an env file in sync with the vault",
+	Long: `Follow an environment and update the local env file each time its secrets
+change in the vault, e.g. when a teammate rotates a key. The keys that changed
+are printed each time, never their values.
+
+keyway watch starts with a pull. Then only the keys changed in the vault are
+written, so values edited in the file meanwhile are kept, as are comments,
+order and local-only keys. Keys deleted from the vault are removed from the file.
+
+Changes are followed as they happen. Where the vault's events cannot be
+streamed, e.g. behind a proxy, --interval polls the vault instead.`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().StringP("env", "e", "development", "Environment name")
+	watchCmd.Flags().StringP("file", "f", ".env", "Env file to keep in sync ({env} is replaced by the environment name)")
+	watchCmd.Flags().Duration("interval", 0, "Poll the vault at this interval instead of following its events")
+}
+
+// WatchOptions contains the parsed flags for the watch command
+type WatchOptions struct {
+	EnvName     string
+	File        string
+	FileFlagSet bool
+	Interval    time.Duration
+}
+
+// runWatch is the entry point for the watch command (uses default dependencies)
+func runWatch(cmd *cobra.Command, args []string) error {
+	opts := WatchOptions{FileFlagSet: cmd.Flags().Changed("file")}
+	opts.EnvName, _ = cmd.Flags().GetString("env")
+	opts.File, _ = cmd.Flags().GetString("file")
+	opts.Interval, _ = cmd.Flags().GetDuration("interval")
+
+	return runWatchWithDeps(opts, defaultDeps)
+}
+
+// runWatchWithDeps is the testable version of runWatch
+func runWatchWithDeps(opts WatchOptions, deps *Dependencies) error {
+	if opts.Interval < 0 {
+		deps.UI.Intro("watch")
+		deps.UI.Error("--interval must be positive")
+		return fmt.Errorf("invalid interval %s", opts.Interval)
+	}
+	return runPipeline(deps, func(s *Session) error {
+		return watch(s, opts)
+	}, withIntro("watch"), withRepo, withEnvironment(normalizeEnvName(opts.EnvName)), withLogin)
+}
+
+// envWatch is an env file kept in sync with an environment
+type envWatch struct {
+	file    string
+	content string            // content of the vault at the last sync
+	vault   map[string]string // secrets of the vault at the last sync, nil before the first
+}
+
+// watch syncs the env file, then again on each change until interrupted
+func watch(s *Session, opts WatchOptions) error {
+	deps := s.Deps
+	file, err := pullTargetFile(PullOptions{File: opts.File, FileFlagSet: opts.FileFlagSet}, s.EnvName, deps)
+	if err != nil {
+		deps.UI.Error(err.Error())
+		return err
+	}
+	deps.UI.Step(fmt.Sprintf("File: %s", deps.UI.File(file)))
+
+	w := &envWatch{file: filepath.Clean(file)}
+	if err := w.sync(s, ""); err != nil {
+		return reportEnvError("watch", err, deps)
+	}
+	analytics.Track("cli_watch", map[string]interface{}{
+		"repoFullName": s.Repo,
+		"environment":  s.EnvName,
+		"polling":      opts.Interval > 0,
+	})
+
+	ctx, stop := signal.NotifyContext(s.Ctx, os.Interrupt)
+	defer stop()
+	s.Ctx = ctx
+	if opts.Interval > 0 {
+		deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Checking %s every %s (Ctrl+C to stop)...", s.EnvName, opts.Interval)))
+		return w.poll(s, opts.Interval)
+	}
+	deps.UI.Message(deps.UI.Dim(fmt.Sprintf("Watching %s (Ctrl+C to stop)...", s.EnvName)))
+	return w.follow(s)
+}
+
+// follow syncs the file on each event of the environment
+func (w *envWatch) follow(s *Session) error {
+	deps := s.Deps
+	filter := api.EventFilter{Environment: s.EnvName}
+	lastID := ""
+	for {
+		err := s.Client.StreamVaultEvents(s.Ctx, s.Repo, filter, lastID, func(event api.VaultEvent) error {
+			lastID = event.ID
+			if err := w.sync(s, event.Actor); err != nil {
+				deps.UI.Warn(fmt.Sprintf("Could not update %s: %s", w.file, err.Error()))
+			}
+			return nil
+		})
+		if s.Ctx.Err() != nil {
+			return nil
+		}
+
+		if isAuthError(err) {
+			if err := s.Relogin(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode < 500 {
+			deps.UI.Error(apiErr.Error())
+			deps.UI.Message(deps.UI.Dim("Poll the vault instead with: keyway watch --interval 1m"))
+			return err
+		}
+
+		deps.UI.Warn("Connection lost, reconnecting...")
+		select {
+		case <-s.Ctx.Done():
+			return nil
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
+
+// poll syncs the file every interval
+func (w *envWatch) poll(s *Session, interval time.Duration) error {
+	deps := s.Deps
+	for {
+		select {
+		case <-s.Ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+
+		err := w.sync(s, "")
+		if s.Ctx.Err() != nil {
+			return nil
+		}
+		if isAuthError(err) {
+			if err := s.Relogin(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if apiErr, ok := err.(*api.APIError); ok && apiErr.StatusCode < 500 {
+			deps.UI.Error(apiErr.Error())
+			return err
+		}
+		if err != nil {
+			deps.UI.Warn(fmt.Sprintf("Could not update %s: %s", w.file, err.Error()))
+		}
+	}
+}
+
+// sync pulls the environment and writes the keys that changed to the file,
+// printing them. actor is who changed the environment, when known.
+func (w *envWatch) sync(s *Session, actor string) error {
+	deps := s.Deps
+	resp, err := s.Client.PullSecrets(s.Ctx, s.Repo, s.EnvName)
+	if err != nil {
+		return err
+	}
+	if w.vault != nil && resp.Content == w.content {
+		return nil
+	}
+	refreshPrefetched(s.Repo, s.EnvName, resp, deps)
+
+	vault, err := watchedSecrets(resp.Content, deps)
+	if err != nil {
+		return err
+	}
+	equal, err := loadValueEqual(deps)
+	if err != nil {
+		return err
+	}
+
+	content := ""
+	if data, err := deps.FS.ReadFile(w.file); err == nil {
+		content = string(data)
+	}
+	local := env.Parse(content)
+	added, changed, removed := watchChanges(local, vault, w.vault, equal)
+	first := w.vault == nil
+	w.content, w.vault = resp.Content, vault
+	if len(added)+len(changed)+len(removed) == 0 {
+		if first {
+			deps.UI.Success(fmt.Sprintf("%s is up to date", w.file))
+		}
+		return nil
+	}
+
+	updates := make(map[string]string, len(added)+len(changed))
+	for _, key := range append(append([]string{}, added...), changed...) {
+		updates[key] = vault[key]
+	}
+	content = env.Remove(env.Merge(content, updates, env.Keys(resp.Content)), removed)
+	if dir := filepath.Dir(w.file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := deps.FS.WriteFile(w.file, []byte(content), 0600); err != nil {
+		return err
+	}
+
+	deps.UI.Message("")
+	switch {
+	case first:
+		deps.UI.Message(fmt.Sprintf("%s Pulled %s:", time.Now().Format("15:04:05"), s.EnvName))
+	case actor != "":
+		deps.UI.Message(fmt.Sprintf("%s %s changed %s:", time.Now().Format("15:04:05"), actor, s.EnvName))
+	default:
+		deps.UI.Message(fmt.Sprintf("%s %s changed:", time.Now().Format("15:04:05"), s.EnvName))
+	}
+	for _, key := range added {
+		deps.UI.DiffAdded(key)
+	}
+	for _, key := range changed {
+		deps.UI.DiffChanged(key)
+	}
+	for _, key := range removed {
+		deps.UI.DiffRemoved(key)
+	}
+	deps.UI.Success(fmt.Sprintf("Updated %s", deps.UI.File(w.file)))
+	return nil
+}
+
+// watchedSecrets returns the secrets of an environment as keyway pull writes
+// them: derived keys recomputed, excluded keys left out and file secrets
+// written to their paths
+func watchedSecrets(content string, deps *Dependencies) (map[string]string, error) {
+	secrets, err := applyExpand(env.ParseVault(content), false, deps)
+	if err != nil {
+		return nil, err
+	}
+	if secrets, err = applyDerived(secrets, deps); err != nil {
+		return nil, err
+	}
+	if secrets, _, err = stripExcluded(secrets, deps); err != nil {
+		return nil, err
+	}
+	paths, err := writePulledFiles(secrets, deps)
+	if err != nil {
+		return nil, err
+	}
+	for key, path := range paths {
+		secrets[key] = path
+	}
+	return secrets, nil
+}
+
+// watchChanges returns the keys to add to the local file, to change in it and
+// to remove from it. Once previous, the vault at the last sync, is known, only
+// the keys changed in the vault since then are written, so that values edited
+// in the file meanwhile are kept. A nil equal compares values byte for byte.
+func watchChanges(local, vault, previous map[string]string, equal env.ValueEqual) (added, changed, removed []string) {
+	for key, value := range vault {
+		if previous != nil {
+			if old, ok := previous[key]; ok && old == value {
+				continue
+			}
+		}
+		localValue, ok := local[key]
+		switch {
+		case !ok:
+			added = append(added, key)
+		case localValue != value && (equal == nil || !equal(key, localValue, value)):
+			changed = append(changed, key)
+		}
+	}
+	for key := range previous {
+		if _, inVault := vault[key]; inVault {
+			continue
+		}
+		if _, ok := local[key]; ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return added, changed, removed
+}
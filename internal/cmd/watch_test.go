@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/api"
+)
+
+func TestWatchChanges(t *testing.T) {
+	equal := func(key, a, b string) bool { return a == b }
+	local := map[string]string{"API_KEY": "edited", "DB_URL": "old", "TOKEN": "a", "LOCAL": "1"}
+
+	// The first sync writes every key that differs, like keyway pull
+	vault := map[string]string{"API_KEY": "v1", "DB_URL": "new", "TOKEN": "a", "REGION": "eu"}
+	added, changed, removed := watchChanges(local, vault, nil, equal)
+	if !reflect.DeepEqual(added, []string{"REGION"}) || !reflect.DeepEqual(changed, []string{"API_KEY", "DB_URL"}) || removed != nil {
+		t.Errorf("unexpected first sync: %v %v %v", added, changed, removed)
+	}
+
+	// Then only what changed in the vault, the local edit of API_KEY is kept
+	previous := map[string]string{"API_KEY": "v1", "DB_URL": "old", "TOKEN": "a"}
+	added, changed, removed = watchChanges(local, vault, previous, equal)
+	if !reflect.DeepEqual(added, []string{"REGION"}) || !reflect.DeepEqual(changed, []string{"DB_URL"}) || removed != nil {
+		t.Errorf("unexpected sync: %v %v %v", added, changed, removed)
+	}
+
+	// Keys deleted from the vault are removed, local-only keys stay
+	added, changed, removed = watchChanges(local, map[string]string{"API_KEY": "v1", "DB_URL": "old"}, previous, equal)
+	if added != nil || changed != nil || !reflect.DeepEqual(removed, []string{"TOKEN"}) {
+		t.Errorf("unexpected removal: %v %v %v", added, changed, removed)
+	}
+}
+
+func TestRunWatchWithDeps_FollowsChanges(t *testing.T) {
+	deps, _, _, uiMock, fsMock, apiMock := NewTestDeps()
+	fsMock.Files[".env"] = []byte("# Local\nAPI_KEY=old\nTOKEN=a\nLOCAL=1\n")
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "API_KEY=old\nTOKEN=a\n"},
+		{Content: "API_KEY=new\n"},
+	}
+	apiMock.StreamEvents = []api.VaultEvent{{ID: "1", Type: "secrets.pushed", Environment: "staging", Actor: "alice"}}
+	apiMock.StreamError = &api.APIError{StatusCode: 403, Detail: "No access"}
+
+	if err := runWatchWithDeps(WatchOptions{EnvName: "staging", File: ".env"}, deps); err == nil {
+		t.Fatal("expected the watch to stop on a client error")
+	}
+	if apiMock.EventFilter.Environment != "staging" {
+		t.Errorf("expected the events of staging to be followed, got %+v", apiMock.EventFilter)
+	}
+	if got := string(fsMock.Written[".env"]); got != "# Local\nAPI_KEY=new\nLOCAL=1\n" {
+		t.Errorf("unexpected file:\n%s", got)
+	}
+	if !strings.Contains(strings.Join(uiMock.MessageCalls, "\n"), "alice changed staging") {
+		t.Errorf("expected the change to be attributed, got %v", uiMock.MessageCalls)
+	}
+	if !reflect.DeepEqual(uiMock.DiffChangedCalls, []string{"API_KEY"}) || !reflect.DeepEqual(uiMock.DiffRemovedCalls, []string{"TOKEN"}) {
+		t.Errorf("expected the changed keys to be printed, got %v and %v", uiMock.DiffChangedCalls, uiMock.DiffRemovedCalls)
+	}
+}
+
+func TestRunWatchWithDeps_Polls(t *testing.T) {
+	deps, _, _, _, fsMock, apiMock := NewTestDeps()
+	apiMock.PullSequence = []*api.PullSecretsResponse{
+		{Content: "API_KEY=v1\n"},
+		{Content: "API_KEY=v1\n"},
+		{Content: "API_KEY=v2\n"},
+	}
+	apiMock.PullError = &api.APIError{StatusCode: 404, Detail: "Environment not found"}
+
+	if err := runWatchWithDeps(WatchOptions{EnvName: "development", File: ".env", Interval: time.Millisecond}, deps); err == nil {
+		t.Fatal("expected the watch to stop on a client error")
+	}
+	if apiMock.StreamCalls != 0 {
+		t.Error("polling must not follow the events")
+	}
+	if got := string(fsMock.Written[".env"]); got != "API_KEY=v2\n" {
+		t.Errorf("expected the last version to be written, got %q", got)
+	}
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +16,8 @@ const (
 	DefaultGitHubAPIURL  = "https://api.github.com"
 	DefaultGitHubBaseURL = "https://github.com"
 	DefaultDocsURL       = "https://docs.keyway.sh"
+	DefaultReleasesURL   = "https://github.com/keywaysh/cli/releases/download"
+	DefaultDopplerAPIURL = "https://api.doppler.com"
 )
 
 // Blank by default - set via build or env
@@ -27,14 +33,47 @@ func GetAPIURL() string {
 	return DefaultAPIURL
 }
 
-// GetDashboardURL returns the dashboard URL from env or default
+// GetDashboardURL returns the dashboard URL from env or default. A self-hosted
+// instance without KEYWAY_DASHBOARD_URL serves it next to its API: on the
+// app. host when the API is on an api. host, on the same origin otherwise.
 func GetDashboardURL() string {
 	if url := os.Getenv("KEYWAY_DASHBOARD_URL"); url != "" {
-		return url
+		return strings.TrimSuffix(url, "/")
+	}
+	if IsCustomAPIURL() {
+		if u, err := url.Parse(GetAPIURL()); err == nil && u.Scheme != "" && u.Host != "" {
+			host := u.Host
+			if rest, ok := strings.CutPrefix(host, "api."); ok {
+				host = "app." + rest
+			}
+			return u.Scheme + "://" + host
+		}
 	}
 	return DefaultDashboardURL
 }
 
+// DashboardLink points a keyway.sh link, such as an upgrade URL returned by
+// the API, at the configured dashboard, so that self-hosted users are not
+// sent to the hosted service. Other links are returned unchanged.
+func DashboardLink(link string) string {
+	dashboard := GetDashboardURL()
+	if link == "" || dashboard == DefaultDashboardURL {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Hostname() != "keyway.sh" && !strings.HasSuffix(u.Hostname(), ".keyway.sh")) {
+		return link
+	}
+	rebased := dashboard + u.EscapedPath()
+	if u.RawQuery != "" {
+		rebased += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		rebased += "#" + u.EscapedFragment()
+	}
+	return rebased
+}
+
 // GetPostHogHost returns the PostHog host
 func GetPostHogHost() string {
 	if host := os.Getenv("KEYWAY_POSTHOG_HOST"); host != "" {
@@ -57,10 +96,104 @@ func IsTelemetryDisabled() bool {
 	return val == "1" || val == "true"
 }
 
+// IsVerbose returns true if KEYWAY_VERBOSE asks for diagnostics, e.g. the
+// number of requests a command sent
+func IsVerbose() bool {
+	val := os.Getenv("KEYWAY_VERBOSE")
+	return val == "1" || val == "true"
+}
+
+// IsAccessible returns true if KEYWAY_ACCESSIBLE asks for the accessibility
+// mode, for screen readers
+func IsAccessible() bool {
+	val := os.Getenv("KEYWAY_ACCESSIBLE")
+	return val == "1" || val == "true"
+}
+
+// GetNetworkBudget returns the KEYWAY_NETWORK_BUDGET override of the number of
+// requests a command may send, 0 meaning unlimited. ok is false when not set.
+func GetNetworkBudget() (limit int, ok bool) {
+	limit, err := strconv.Atoi(os.Getenv("KEYWAY_NETWORK_BUDGET"))
+	if err != nil || limit < 0 {
+		return 0, false
+	}
+	return limit, true
+}
+
 // IsCI returns true if running in CI environment
 func IsCI() bool {
-	ci := os.Getenv("CI")
-	return ci == "true" || ci == "1"
+	return CIProvider() != ""
+}
+
+// CIProvider returns the name of the CI the CLI runs in, from the variables
+// the provider sets, "CI" for another one setting CI, and "" outside of CI.
+// KEYWAY_CI=0 turns the detection off, KEYWAY_CI=1 forces it on.
+func CIProvider() string {
+	switch os.Getenv("KEYWAY_CI") {
+	case "0", "false":
+		return ""
+	}
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return "GitHub Actions"
+	case os.Getenv("GITLAB_CI") == "true":
+		return "GitLab CI"
+	case os.Getenv("CIRCLECI") == "true":
+		return "CircleCI"
+	case os.Getenv("JENKINS_URL") != "" && os.Getenv("BUILD_ID") != "":
+		// Jenkins doesn't set CI, but sets both for every build
+		return "Jenkins"
+	}
+	if ci := os.Getenv("CI"); ci == "true" || ci == "1" {
+		return "CI"
+	}
+	if ci := os.Getenv("KEYWAY_CI"); ci == "true" || ci == "1" {
+		return "CI"
+	}
+	return ""
+}
+
+// GetConfigDir returns the KEYWAY_CONFIG_DIR override, or "" for the platform default.
+// Devcontainers and WSL can point this at a mounted directory to share a login with the host.
+func GetConfigDir() string {
+	return os.Getenv("KEYWAY_CONFIG_DIR")
+}
+
+// GetStateDir returns the directory for local state (usage log, undo records):
+// KEYWAY_CONFIG_DIR if set, ~/.config/keyway otherwise. It returns "" if no home directory is known.
+func GetStateDir() string {
+	if dir := GetConfigDir(); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "keyway")
+}
+
+// hostEnvFile labels a host with an environment, e.g. "production" on
+// servers, for keyway run and pull to enforce it
+var hostEnvFile = defaultHostEnvFile()
+
+func defaultHostEnvFile() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "keyway", "host-env")
+	}
+	return "/etc/keyway/host-env"
+}
+
+// GetHostEnvironment returns the environment the host is labeled with, from
+// KEYWAY_HOST_ENV or the host-env file provisioned on servers, "" if unlabeled
+func GetHostEnvironment() string {
+	if label := strings.TrimSpace(os.Getenv("KEYWAY_HOST_ENV")); label != "" {
+		return label
+	}
+	data, err := os.ReadFile(hostEnvFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
 }
 
 // GetToken returns the KEYWAY_TOKEN from env (for CI use)
@@ -68,6 +201,17 @@ func GetToken() string {
 	return os.Getenv("KEYWAY_TOKEN")
 }
 
+// GetProfile returns the named login selected with KEYWAY_PROFILE, "" for the default one
+func GetProfile() string {
+	return os.Getenv("KEYWAY_PROFILE")
+}
+
+// GetIdempotencyKey returns the KEYWAY_IDEMPOTENCY_KEY from env, used by CI to
+// make reruns of the same pipeline push at most once
+func GetIdempotencyKey() string {
+	return os.Getenv("KEYWAY_IDEMPOTENCY_KEY")
+}
+
 // GetGitHubURL returns the GitHub base URL from env or default
 func GetGitHubURL() string {
 	if url := os.Getenv("KEYWAY_GITHUB_URL"); url != "" {
@@ -76,6 +220,24 @@ func GetGitHubURL() string {
 	return DefaultGitHubBaseURL
 }
 
+// GetGitHubToken returns the GitHub token from GITHUB_TOKEN, or GH_TOKEN as
+// set by the gh CLI
+func GetGitHubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// GetReleasesURL returns where release assets are downloaded from, e.g. an
+// internal mirror, from env or default
+func GetReleasesURL() string {
+	if url := os.Getenv("KEYWAY_RELEASES_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return DefaultReleasesURL
+}
+
 // GetGitHubAPIURL returns the GitHub API URL from env or default
 func GetGitHubAPIURL() string {
 	if url := os.Getenv("KEYWAY_GITHUB_API_URL"); url != "" {
@@ -90,6 +252,15 @@ func GetGitHubAPIURL() string {
 	return DefaultGitHubAPIURL
 }
 
+// GetDopplerAPIURL returns the Doppler API URL used by keyway migrate, from
+// DOPPLER_API_HOST (as the Doppler CLI) or default
+func GetDopplerAPIURL() string {
+	if url := os.Getenv("DOPPLER_API_HOST"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return DefaultDopplerAPIURL
+}
+
 // GetGitHubBaseURL returns the GitHub base URL from env or default
 // Deprecated: Use GetGitHubURL instead
 func GetGitHubBaseURL() string {
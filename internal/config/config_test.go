@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetAPIURL_Default(t *testing.T) {
@@ -99,7 +102,16 @@ func TestGetToken_Set(t *testing.T) {
 	}
 }
 
+// clearCIEnv unsets the variables CI providers set, for tests run in CI
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"CI", "KEYWAY_CI", "GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "JENKINS_URL", "BUILD_ID"} {
+		t.Setenv(name, "")
+	}
+}
+
 func TestIsCI_NotSet(t *testing.T) {
+	clearCIEnv(t)
 	os.Unsetenv("CI")
 
 	if IsCI() {
@@ -125,6 +137,80 @@ func TestIsCI_SetToOne(t *testing.T) {
 	}
 }
 
+func TestCIProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"none", nil, ""},
+		{"GitHub Actions", map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, "GitHub Actions"},
+		{"GitLab", map[string]string{"CI": "true", "GITLAB_CI": "true"}, "GitLab CI"},
+		{"CircleCI", map[string]string{"CI": "true", "CIRCLECI": "true"}, "CircleCI"},
+		{"Jenkins", map[string]string{"JENKINS_URL": "https://ci.example.com/", "BUILD_ID": "42"}, "Jenkins"},
+		{"Jenkins URL only", map[string]string{"JENKINS_URL": "https://ci.example.com/"}, ""},
+		{"other CI", map[string]string{"CI": "1"}, "CI"},
+		{"forced", map[string]string{"KEYWAY_CI": "1"}, "CI"},
+		{"turned off", map[string]string{"CI": "true", "GITHUB_ACTIONS": "true", "KEYWAY_CI": "0"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearCIEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := CIProvider(); got != tt.want {
+				t.Errorf("CIProvider() = %q, want %q", got, tt.want)
+			}
+			if IsCI() != (tt.want != "") {
+				t.Errorf("IsCI() = %v, want %v", IsCI(), tt.want != "")
+			}
+		})
+	}
+}
+
+func TestIsVerbose(t *testing.T) {
+	t.Setenv("KEYWAY_VERBOSE", "")
+	if IsVerbose() {
+		t.Error("IsVerbose() should return false when KEYWAY_VERBOSE not set")
+	}
+	t.Setenv("KEYWAY_VERBOSE", "1")
+	if !IsVerbose() {
+		t.Error("IsVerbose() should return true when KEYWAY_VERBOSE=1")
+	}
+}
+
+func TestIsAccessible(t *testing.T) {
+	t.Setenv("KEYWAY_ACCESSIBLE", "")
+	if IsAccessible() {
+		t.Error("IsAccessible() should return false when KEYWAY_ACCESSIBLE not set")
+	}
+	t.Setenv("KEYWAY_ACCESSIBLE", "true")
+	if !IsAccessible() {
+		t.Error("IsAccessible() should return true when KEYWAY_ACCESSIBLE=true")
+	}
+}
+
+func TestGetNetworkBudget(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		limit int
+		ok    bool
+	}{
+		{"", 0, false},
+		{"12", 12, true},
+		{"0", 0, true},
+		{"-3", 0, false},
+		{"many", 0, false},
+	} {
+		t.Setenv("KEYWAY_NETWORK_BUDGET", tt.value)
+		limit, ok := GetNetworkBudget()
+		if limit != tt.limit || ok != tt.ok {
+			t.Errorf("GetNetworkBudget() with %q = %d, %v, want %d, %v", tt.value, limit, ok, tt.limit, tt.ok)
+		}
+	}
+}
+
 func TestDefaultAPIURL(t *testing.T) {
 	if DefaultAPIURL != "https://api.keyway.sh" {
 		t.Errorf("DefaultAPIURL = %v, want https://api.keyway.sh", DefaultAPIURL)
@@ -180,6 +266,17 @@ func TestGetGitHubAPIURL_FromEnv(t *testing.T) {
 	}
 }
 
+func TestGetDopplerAPIURL(t *testing.T) {
+	t.Setenv("DOPPLER_API_HOST", "")
+	if url := GetDopplerAPIURL(); url != DefaultDopplerAPIURL {
+		t.Errorf("GetDopplerAPIURL() = %v, want %v", url, DefaultDopplerAPIURL)
+	}
+	t.Setenv("DOPPLER_API_HOST", "https://doppler.example.com/")
+	if url := GetDopplerAPIURL(); url != "https://doppler.example.com" {
+		t.Errorf("GetDopplerAPIURL() = %v, want https://doppler.example.com", url)
+	}
+}
+
 func TestGetGitHubAPIURL_DerivedFromGHE(t *testing.T) {
 	os.Unsetenv("KEYWAY_GITHUB_API_URL")
 	os.Setenv("KEYWAY_GITHUB_URL", "https://github.example.com")
@@ -255,3 +352,430 @@ func TestIsCustomAPIURL_SetToCustom(t *testing.T) {
 		t.Error("IsCustomAPIURL() should return true when set to custom URL")
 	}
 }
+
+func TestGetDashboardURL_SelfHosted(t *testing.T) {
+	tests := []struct {
+		apiURL, dashboardURL, want string
+	}{
+		{"", "", DefaultDashboardURL},
+		{DefaultAPIURL, "", DefaultDashboardURL},
+		{"https://api.keyway.example.com", "", "https://app.keyway.example.com"},
+		{"https://keyway.example.com/api", "", "https://keyway.example.com"},
+		{"http://localhost:8080", "", "http://localhost:8080"},
+		{"https://api.keyway.example.com", "https://vault.example.com/", "https://vault.example.com"},
+	}
+	for _, tt := range tests {
+		t.Setenv("KEYWAY_API_URL", tt.apiURL)
+		t.Setenv("KEYWAY_DASHBOARD_URL", tt.dashboardURL)
+		if got := GetDashboardURL(); got != tt.want {
+			t.Errorf("GetDashboardURL() with API %q and dashboard %q = %q, want %q", tt.apiURL, tt.dashboardURL, got, tt.want)
+		}
+	}
+}
+
+func TestDashboardLink(t *testing.T) {
+	t.Setenv("KEYWAY_DASHBOARD_URL", "")
+	t.Setenv("KEYWAY_API_URL", "")
+	if got := DashboardLink("https://keyway.sh/upgrade"); got != "https://keyway.sh/upgrade" {
+		t.Errorf("DashboardLink() on keyway.sh = %q, want the link unchanged", got)
+	}
+
+	t.Setenv("KEYWAY_API_URL", "https://api.keyway.example.com")
+	tests := map[string]string{
+		"https://keyway.sh/upgrade":                 "https://app.keyway.example.com/upgrade",
+		"https://www.keyway.sh/upgrade?plan=team#x": "https://app.keyway.example.com/upgrade?plan=team#x",
+		"https://app.keyway.sh/vaults/owner/repo":   "https://app.keyway.example.com/vaults/owner/repo",
+		"https://billing.example.com/upgrade":       "https://billing.example.com/upgrade",
+		"https://notkeyway.sh/upgrade":              "https://notkeyway.sh/upgrade",
+		"":                                          "",
+	}
+	for link, want := range tests {
+		if got := DashboardLink(link); got != want {
+			t.Errorf("DashboardLink(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestGetConfigDir(t *testing.T) {
+	os.Unsetenv("KEYWAY_CONFIG_DIR")
+	if dir := GetConfigDir(); dir != "" {
+		t.Errorf("GetConfigDir() = %q, want empty", dir)
+	}
+
+	os.Setenv("KEYWAY_CONFIG_DIR", "/workspaces/.keyway")
+	defer os.Unsetenv("KEYWAY_CONFIG_DIR")
+
+	if dir := GetConfigDir(); dir != "/workspaces/.keyway" {
+		t.Errorf("GetConfigDir() = %q, want /workspaces/.keyway", dir)
+	}
+}
+
+func TestParseProject(t *testing.T) {
+	project, err := ParseProject([]byte(`{"derived": {"DATABASE_URL": "postgres://${DB_HOST}"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if project.Derived["DATABASE_URL"] != "postgres://${DB_HOST}" {
+		t.Errorf("unexpected derived: %v", project.Derived)
+	}
+
+	if _, err := ParseProject([]byte(`{invalid`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestProject_IsExcluded(t *testing.T) {
+	project, err := ParseProject([]byte(`{"exclude": ["AWS_SESSION_TOKEN", "*_LOCAL"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, want := range map[string]bool{"AWS_SESSION_TOKEN": true, "DEBUG_LOCAL": true, "API_KEY": false} {
+		if got := project.IsExcluded(key); got != want {
+			t.Errorf("IsExcluded(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	if _, err := ParseProject([]byte(`{"exclude": ["["]}`)); err == nil {
+		t.Error("expected error for a bad exclude pattern")
+	}
+}
+
+func TestProject_KeyClass(t *testing.T) {
+	project := &Project{Config: []string{"NODE_ENV", "NEXT_PUBLIC_*"}}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"NODE_ENV", ClassConfig},
+		{"NEXT_PUBLIC_API_URL", ClassConfig},
+		{"API_KEY", ClassSecret},
+		{"NODE_ENV_SECRET", ClassSecret},
+	}
+
+	for _, tt := range tests {
+		if got := project.KeyClass(tt.key); got != tt.expected {
+			t.Errorf("KeyClass(%q) = %q, want %q", tt.key, got, tt.expected)
+		}
+	}
+
+	if _, err := ParseProject([]byte(`{"config": ["[bad"]}`)); err == nil {
+		t.Error("expected error for invalid config pattern")
+	}
+}
+
+func TestProject_OutputFile(t *testing.T) {
+	project, err := ParseProject([]byte(`{"outputs": {"production": "deploy/secrets.env", "staging": "envs/{env}.env"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := project.OutputFile("production"); got != "deploy/secrets.env" {
+		t.Errorf("OutputFile(production) = %q", got)
+	}
+	if got := project.OutputFile("staging"); got != "envs/staging.env" {
+		t.Errorf("OutputFile(staging) = %q", got)
+	}
+	if got := project.OutputFile("development"); got != "" {
+		t.Errorf("expected no output for development, got %q", got)
+	}
+	if got := ExpandFileTemplate(".env.{env}", "preview"); got != ".env.preview" {
+		t.Errorf("ExpandFileTemplate = %q", got)
+	}
+
+	if _, err := ParseProject([]byte(`{"outputs": {"production": " "}}`)); err == nil {
+		t.Error("expected error for empty output file")
+	}
+}
+
+func TestParseProject_Remaps(t *testing.T) {
+	project, err := ParseProject([]byte(`{"remaps": {"api": {"*": "APP_*"}, "worker": {"DATABASE_URL": "DB_URL"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if project.Remaps["worker"]["DATABASE_URL"] != "DB_URL" {
+		t.Errorf("unexpected remaps: %v", project.Remaps)
+	}
+
+	for _, bad := range []string{
+		`{"remaps": {"api": {"REDIS_*": "CACHE"}}}`,
+		`{"remaps": {"api": {"*_URL": "*_URI"}}}`,
+		`{"remaps": {"api": {"DATABASE_URL": ""}}}`,
+	} {
+		if _, err := ParseProject([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestParseProject_Validators(t *testing.T) {
+	project, err := ParseProject([]byte(`{"validators": [
+		{"name": "stripe-live", "exec": ["./scripts/check-stripe", "--live"], "environments": ["production"], "keys": ["STRIPE_*"]},
+		{"name": "urls", "wasm": "checks/urls.wasm"}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(project.Validators) != 2 {
+		t.Fatalf("expected 2 validators, got %v", project.Validators)
+	}
+	stripe := project.Validators[0]
+	if !stripe.Applies("production") || stripe.Applies("staging") {
+		t.Error("expected stripe-live to run for production only")
+	}
+	if !stripe.Wants("STRIPE_KEY") || stripe.Wants("DATABASE_URL") {
+		t.Error("expected stripe-live to receive STRIPE_* keys only")
+	}
+	if !project.Validators[1].Applies("staging") || !project.Validators[1].Wants("ANY") {
+		t.Error("expected a validator without patterns to run for everything")
+	}
+
+	for _, bad := range []string{
+		`{"validators": [{"exec": ["./check"]}]}`,
+		`{"validators": [{"name": "x"}]}`,
+		`{"validators": [{"name": "x", "exec": ["./check"], "wasm": "x.wasm"}]}`,
+		`{"validators": [{"name": "x", "exec": [""]}]}`,
+		`{"validators": [{"name": "x", "wasm": "x.wasm", "keys": ["[bad"]}]}`,
+		`{"validators": [{"name": "x", "wasm": "a.wasm"}, {"name": "x", "wasm": "b.wasm"}]}`,
+	} {
+		if _, err := ParseProject([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestParseProject_Owners(t *testing.T) {
+	project, err := ParseProject([]byte(`{"owners": {
+		"STRIPE_*": ["@acme/payments"],
+		"STRIPE_WEBHOOK_*": ["@acme/webhooks"],
+		"STRIPE_WEBHOOK_SECRET": ["@alice"]
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := map[string]string{
+		"STRIPE_KEY":            "@acme/payments",
+		"STRIPE_WEBHOOK_URL":    "@acme/webhooks",
+		"STRIPE_WEBHOOK_SECRET": "@alice",
+		"DATABASE_URL":          "",
+	}
+	for key, want := range tests {
+		got := strings.Join(KeyOwners(project.Owners, key), ",")
+		if got != want {
+			t.Errorf("owners of %s: got %q, want %q", key, got, want)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"owners": {"[bad": ["@alice"]}}`,
+		`{"owners": {"API_KEY": []}}`,
+		`{"owners": {"API_KEY": ["alice"]}}`,
+	} {
+		if _, err := ParseProject([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestMergeOwnersAndSameOwner(t *testing.T) {
+	merged := MergeOwners(map[string][]string{"A_*": {"@a"}, "B": {"@b"}}, map[string][]string{"B": {"@server"}})
+	if merged["A_*"][0] != "@a" || merged["B"][0] != "@server" {
+		t.Errorf("expected the overrides to win, got %v", merged)
+	}
+	if !SameOwner("@Acme/Payments", "acme/payments") || SameOwner("@alice", "@bob") {
+		t.Error("expected owners to compare without case and @")
+	}
+}
+
+func TestParseProject_Confirm(t *testing.T) {
+	project, err := ParseProject([]byte(`{"confirm": [
+		{"environments": ["dev*"], "skipUpTo": 3},
+		{"environments": ["production"], "always": true}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule := project.ConfirmRule("development"); rule.SkipUpTo != 3 {
+		t.Errorf("expected development to skip up to 3 keys, got %+v", rule)
+	}
+	if rule := project.ConfirmRule("production"); !rule.Always {
+		t.Errorf("expected production to always confirm, got %+v", rule)
+	}
+	if rule := project.ConfirmRule("staging"); rule.Always || rule.SkipUpTo != 0 {
+		t.Errorf("expected no rule for staging, got %+v", rule)
+	}
+
+	for _, bad := range []string{
+		`{"confirm": [{"skipUpTo": -1}]}`,
+		`{"confirm": [{"skipUpTo": 2, "always": true}]}`,
+		`{"confirm": [{"environments": ["[bad"], "always": true}]}`,
+	} {
+		if _, err := ParseProject([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestParseProject_Lint(t *testing.T) {
+	project, err := ParseProject([]byte(`{"lint": {"url-credentials": "error", "plain-http": "off"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if project.Lint["url-credentials"] != "error" || project.Lint["plain-http"] != "off" {
+		t.Errorf("unexpected lint severities: %v", project.Lint)
+	}
+
+	if _, err := ParseProject([]byte(`{"lint": {"url-credentials": "fatal"}}`)); err == nil {
+		t.Error("expected error for an unknown severity")
+	}
+}
+
+func TestParseProject_Rotation(t *testing.T) {
+	project, err := ParseProject([]byte(`{"rotation": {
+		"STRIPE_*": {"verify": ["./scripts/check-stripe"]},
+		"STRIPE_WEBHOOK_SECRET": {"generate": "hex:32"}
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule := project.RotationRuleFor("STRIPE_WEBHOOK_SECRET"); rule.Generate != "hex:32" || len(rule.Verify) != 0 {
+		t.Errorf("expected the exact key to win, got %+v", rule)
+	}
+	if rule := project.RotationRuleFor("STRIPE_KEY"); len(rule.Verify) != 1 || rule.Verify[0] != "./scripts/check-stripe" {
+		t.Errorf("expected the pattern to match, got %+v", rule)
+	}
+	if rule := project.RotationRuleFor("DB_PASSWORD"); rule.Generate != "" || rule.Verify != nil {
+		t.Errorf("expected no rule, got %+v", rule)
+	}
+
+	if _, err := ParseProject([]byte(`{"rotation": {"KEY": {"revoke": [""]}}}`)); err == nil {
+		t.Error("expected error for an empty command")
+	}
+	if _, err := ParseProject([]byte(`{"rotation": {"[": {}}}`)); err == nil {
+		t.Error("expected error for a bad pattern")
+	}
+}
+
+func TestParseProject_HealthChecks(t *testing.T) {
+	project, err := ParseProject([]byte(`{"healthChecks": [
+		{"name": "api", "url": "https://api.acme.dev/healthz", "environments": ["staging", "prod*"], "wait": "2m"},
+		{"name": "smoke", "exec": ["./scripts/smoke"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if checks := project.HealthChecksFor("production"); len(checks) != 2 || checks[0].WaitDuration() != 2*time.Minute {
+		t.Errorf("expected both checks for production, got %+v", checks)
+	}
+	if checks := project.HealthChecksFor("development"); len(checks) != 1 || checks[0].Name != "smoke" {
+		t.Errorf("expected only the unrestricted check, got %+v", checks)
+	}
+
+	invalid := []string{
+		`{"healthChecks": [{"url": "https://api.acme.dev"}]}`,
+		`{"healthChecks": [{"name": "api"}]}`,
+		`{"healthChecks": [{"name": "api", "url": "https://api.acme.dev", "exec": ["./check"]}]}`,
+		`{"healthChecks": [{"name": "api", "url": "ftp://api.acme.dev"}]}`,
+		`{"healthChecks": [{"name": "api", "url": "https://api.acme.dev", "wait": "soon"}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseProject([]byte(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestParseProject_Sources(t *testing.T) {
+	project, err := ParseProject([]byte(`{"sources": [
+		{"process": true},
+		{"file": ".env.{env}.local", "optional": true},
+		{"vault": "{env}"},
+		{"vault": "shared"},
+		{"defaults": {"LOG_LEVEL": "info"}}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, source := range project.Sources {
+		names = append(names, source.String("staging"))
+	}
+	if got := strings.Join(names, ", "); got != "process, .env.staging.local, vault staging, vault shared, defaults" {
+		t.Errorf("unexpected sources: %s", got)
+	}
+
+	invalid := []string{
+		`{"sources": [{}]}`,
+		`{"sources": [{"file": ".env", "vault": "shared"}]}`,
+		`{"sources": [{"vault": " "}]}`,
+		`{"sources": [{"process": true, "optional": true}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseProject([]byte(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestParseProject_DefaultEnv(t *testing.T) {
+	project, err := ParseProject([]byte(`{"defaultEnv": {
+		"env": "local",
+		"branches": [
+			{"defaultBranch": true, "ci": true, "env": "production"},
+			{"branch": "release/*", "env": "staging"}
+		]
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		branch string
+		ci     bool
+		want   string
+	}{
+		{"main", true, "production"},
+		{"main", false, "local"},
+		{"release/2.0", false, "staging"},
+		{"feature/x", true, "local"},
+		{"", true, "local"}, // detached HEAD
+	}
+	for _, tt := range tests {
+		if got := project.DefaultEnvFor(tt.branch, "main", tt.ci); got != tt.want {
+			t.Errorf("DefaultEnvFor(%q, ci=%v) = %q, want %q", tt.branch, tt.ci, got, tt.want)
+		}
+	}
+	if got := (&Project{}).DefaultEnvFor("main", "main", true); got != "" {
+		t.Errorf("expected no default without defaultEnv, got %q", got)
+	}
+
+	for _, bad := range []string{
+		`{"defaultEnv": {"branches": [{"branch": "main"}]}}`,
+		`{"defaultEnv": {"branches": [{"env": "production"}]}}`,
+		`{"defaultEnv": {"branches": [{"branch": "[bad", "env": "production"}]}}`,
+	} {
+		if _, err := ParseProject([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestGetHostEnvironment(t *testing.T) {
+	previous := hostEnvFile
+	hostEnvFile = filepath.Join(t.TempDir(), "host-env")
+	t.Cleanup(func() { hostEnvFile = previous })
+	t.Setenv("KEYWAY_HOST_ENV", "")
+
+	if label := GetHostEnvironment(); label != "" {
+		t.Errorf("expected an unlabeled host, got %q", label)
+	}
+	if err := os.WriteFile(hostEnvFile, []byte("production\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if label := GetHostEnvironment(); label != "production" {
+		t.Errorf("expected the label of the file, got %q", label)
+	}
+	t.Setenv("KEYWAY_HOST_ENV", "staging")
+	if label := GetHostEnvironment(); label != "staging" {
+		t.Errorf("expected KEYWAY_HOST_ENV to win, got %q", label)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// HealthCheck verifies that a service still works once keyway push changed
+// the secrets of an environment: an HTTP(S) endpoint answering with a 2xx
+// status, or a command exiting with 0
+type HealthCheck struct {
+	Name string `json:"name"`
+	// URL is requested with GET, e.g. "https://staging.acme.dev/healthz"
+	URL string `json:"url,omitempty"`
+	// Exec is the command and its arguments, run with the secrets of the
+	// environment, e.g. ["./scripts/smoke-test"]
+	Exec []string `json:"exec,omitempty"`
+	// Environments restricts the check to some environments (glob patterns),
+	// it runs after pushes to all of them when empty
+	Environments []string `json:"environments,omitempty"`
+	// Wait is how long a failing check is retried, e.g. "2m" for a service
+	// that restarts when its secrets change. It runs once when empty.
+	Wait string `json:"wait,omitempty"`
+}
+
+// Applies returns true if the check runs after pushes to an environment
+func (c HealthCheck) Applies(env string) bool {
+	return len(c.Environments) == 0 || matchAny(c.Environments, env)
+}
+
+// WaitDuration returns how long a failing check is retried, 0 to run it once
+func (c HealthCheck) WaitDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Wait)
+	return d
+}
+
+// HealthChecksFor returns the checks run after pushes to an environment
+func (p *Project) HealthChecksFor(env string) []HealthCheck {
+	var checks []HealthCheck
+	for _, check := range p.HealthChecks {
+		if check.Applies(env) {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// validateHealthCheck checks a health check: a name, and either an HTTP(S)
+// URL or a command
+func validateHealthCheck(c HealthCheck) error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("a check has no name")
+	}
+	if (c.URL == "") == (len(c.Exec) == 0) {
+		return fmt.Errorf("%s needs either url or exec", c.Name)
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url of %s must be an http or https URL", c.Name)
+		}
+	}
+	if len(c.Exec) > 0 && strings.TrimSpace(c.Exec[0]) == "" {
+		return fmt.Errorf("%s has an empty command", c.Name)
+	}
+	for _, pattern := range c.Environments {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad environment pattern %q in %s", pattern, c.Name)
+		}
+	}
+	if c.Wait != "" {
+		if d, err := time.ParseDuration(c.Wait); err != nil || d < 0 {
+			return fmt.Errorf("wait of %s must be a duration like 30s or 2m", c.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// KeyOwners returns the owners of a key under rules mapping keys or glob
+// patterns to owners, like CODEOWNERS: an exact key wins over patterns, and a
+// longer pattern over shorter ones. A key no rule matches has no owner.
+func KeyOwners(rules map[string][]string, key string) []string {
+	if owners, ok := rules[key]; ok {
+		return owners
+	}
+	best := ""
+	for pattern := range rules {
+		if ok, _ := path.Match(pattern, key); !ok {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return rules[best]
+}
+
+// MergeOwners returns the rules of base with those of overrides on top
+func MergeOwners(base, overrides map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(base)+len(overrides))
+	for pattern, owners := range base {
+		merged[pattern] = owners
+	}
+	for pattern, owners := range overrides {
+		merged[pattern] = owners
+	}
+	return merged
+}
+
+// SameOwner returns true if two owners, "@login" or "@org/team", are the same
+// regardless of case and of the leading @
+func SameOwner(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "@"), strings.TrimPrefix(b, "@"))
+}
+
+// OwnerPatterns returns the patterns of rules, sorted
+func OwnerPatterns(rules map[string][]string) []string {
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// validateOwners checks an owners rule: a valid pattern and @-prefixed owners
+func validateOwners(pattern string, owners []string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("bad pattern %q", pattern)
+	}
+	if len(owners) == 0 {
+		return fmt.Errorf("no owner for %s", pattern)
+	}
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") || len(owner) < 2 {
+			return fmt.Errorf("owner %q of %s must be @login or @org/team", owner, pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ProjectFile is the per-repository config file, committed alongside the code
+const ProjectFile = ".keyway.json"
+
+// Project is the per-repository configuration read from ProjectFile
+type Project struct {
+	// Derived maps a key to a template built from other keys, e.g.
+	// "DATABASE_URL": "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app"
+	Derived map[string]string `json:"derived,omitempty"`
+
+	// Config lists keys (glob patterns like "NEXT_PUBLIC_*") holding plain
+	// configuration rather than secrets. Config values may be shown unmasked
+	// and pulled into committed files, every other key is treated as a secret.
+	Config []string `json:"config,omitempty"`
+
+	// Exclude lists keys (glob patterns like "*_LOCAL") that never leave the
+	// machine: keyway push leaves them out and keyway pull never writes them.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Comparators maps keys (glob patterns) to the comparator used when
+	// diffing their values, e.g. "GOOGLE_CREDENTIALS": "json". Values that
+	// compare equal are not reported as changed by push and pull.
+	Comparators map[string]string `json:"comparators,omitempty"`
+
+	// Outputs maps an environment to the file keyway pull writes it to,
+	// e.g. "production": "deploy/secrets.env". Paths may use {env}.
+	Outputs map[string]string `json:"outputs,omitempty"`
+
+	// Files maps keys holding files (stored with keyway file push) to the
+	// path keyway pull writes them to, e.g. "GCP_SA_JSON": "secrets/gcp-sa.json"
+	Files map[string]string `json:"files,omitempty"`
+
+	// Remaps names sets of key renames applied by keyway run --remap, for
+	// services with their own naming conventions. A rule maps a key or a
+	// prefix ending with *, e.g. "worker": {"DATABASE_URL": "DB_URL", "REDIS_*": "CACHE_*"}
+	Remaps map[string]map[string]string `json:"remaps,omitempty"`
+
+	// Validators are run on the pending changes before keyway push and set,
+	// and can block them, e.g. to check that a Stripe key is a live one
+	Validators []Validator `json:"validators,omitempty"`
+
+	// Rotation maps keys (glob patterns) to how keyway rotation plan rotates
+	// them: how the new value is made, and the commands verifying it and
+	// revoking the old one
+	Rotation map[string]RotationRule `json:"rotation,omitempty"`
+
+	// HealthChecks are run after keyway push, to tell whether the services
+	// using an environment still work with its new secrets
+	HealthChecks []HealthCheck `json:"healthChecks,omitempty"`
+
+	// Owners maps keys (glob patterns) to the users and teams owning them,
+	// e.g. "STRIPE_*": ["@acme/payments"]. Pushes changing keys owned by others
+	// are flagged, see KeyOwners.
+	Owners map[string][]string `json:"owners,omitempty"`
+
+	// Confirm says when writes to an environment need a confirmation, e.g.
+	// none for small changes to development, always for production. The
+	// first rule matching the environment applies.
+	Confirm []ConfirmRule `json:"confirm,omitempty"`
+
+	// DefaultEnv sets the environment of the .env file, development when
+	// unset, e.g. production on the default branch in CI
+	DefaultEnv *DefaultEnv `json:"defaultEnv,omitempty"`
+
+	// Lint sets the severity of the value rules keyway push checks, e.g.
+	// "url-credentials": "error". An error blocks the push, a warning is
+	// shown, off disables the rule.
+	Lint map[string]string `json:"lint,omitempty"`
+
+	// Sources compose the environment of keyway run, in order of precedence:
+	// the first source having a key gives its value, e.g. the process
+	// environment, then .env.local, then the vault. Only the environment
+	// keyway run was given is used when empty.
+	Sources []Source `json:"sources,omitempty"`
+
+	// Expand resolves ${VAR} references in values for keyway pull, run and
+	// shell, as --expand does. The vault keeps the references.
+	Expand bool `json:"expand,omitempty"`
+}
+
+// DefaultEnv sets the environment of the .env file for the repository and
+// per branch
+type DefaultEnv struct {
+	// Env is the environment of .env when no branch rule matches
+	Env string `json:"env,omitempty"`
+	// Branches are checked in order, the first rule matching applies
+	Branches []BranchRule `json:"branches,omitempty"`
+}
+
+// BranchRule sets the environment of .env on some branches
+type BranchRule struct {
+	// Branch is a glob pattern, e.g. "release/*"
+	Branch string `json:"branch,omitempty"`
+	// DefaultBranch matches the default branch of the repository
+	DefaultBranch bool `json:"defaultBranch,omitempty"`
+	// CI restricts the rule to CI runs
+	CI  bool   `json:"ci,omitempty"`
+	Env string `json:"env"`
+}
+
+// Matches returns true if the rule applies on branch
+func (r BranchRule) Matches(branch, defaultBranch string, ci bool) bool {
+	if branch == "" || (r.CI && !ci) {
+		return false
+	}
+	if r.DefaultBranch && branch != defaultBranch {
+		return false
+	}
+	if r.Branch != "" {
+		ok, _ := path.Match(r.Branch, branch)
+		return ok
+	}
+	return r.DefaultBranch
+}
+
+// DefaultEnvFor returns the environment of .env on branch, or an empty string
+// if .keyway.json doesn't set one
+func (p *Project) DefaultEnvFor(branch, defaultBranch string, ci bool) string {
+	if p.DefaultEnv == nil {
+		return ""
+	}
+	for _, rule := range p.DefaultEnv.Branches {
+		if rule.Matches(branch, defaultBranch, ci) {
+			return rule.Env
+		}
+	}
+	return p.DefaultEnv.Env
+}
+
+// ConfirmRule sets when writes to some environments are confirmed. Without a
+// rule, they are unless --yes is passed.
+type ConfirmRule struct {
+	// Environments the rule applies to (glob patterns), all when empty
+	Environments []string `json:"environments,omitempty"`
+	// SkipUpTo skips the confirmation for changes of at most this many keys
+	SkipUpTo int `json:"skipUpTo,omitempty"`
+	// Always asks even with --yes, in an interactive terminal
+	Always bool `json:"always,omitempty"`
+}
+
+// ConfirmRule returns the confirmation rule of an environment, the zero rule
+// if none matches
+func (p *Project) ConfirmRule(env string) ConfirmRule {
+	for _, rule := range p.Confirm {
+		if len(rule.Environments) == 0 || matchAny(rule.Environments, env) {
+			return rule
+		}
+	}
+	return ConfirmRule{}
+}
+
+// Validator is a plugin checking the changes of a push: an executable, or a
+// WebAssembly (WASI) module run without access to the network or the files.
+// It reads the changes as JSON on stdin and writes its annotations to stdout.
+type Validator struct {
+	Name string `json:"name"`
+	// Exec is the command and its arguments, e.g. ["./scripts/check-stripe"]
+	Exec []string `json:"exec,omitempty"`
+	// Wasm is the path of the module, relative to the project
+	Wasm string `json:"wasm,omitempty"`
+	// Environments restricts the validator to some environments (glob
+	// patterns), it runs for all of them when empty
+	Environments []string `json:"environments,omitempty"`
+	// Keys restricts the changes the validator receives (glob patterns)
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Applies returns true if the validator runs for an environment
+func (v Validator) Applies(env string) bool {
+	return len(v.Environments) == 0 || matchAny(v.Environments, env)
+}
+
+// Wants returns true if the validator receives the changes of a key
+func (v Validator) Wants(key string) bool {
+	return len(v.Keys) == 0 || matchAny(v.Keys, key)
+}
+
+// matchAny returns true if name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvPlaceholder is replaced by the environment name in output file paths
+const EnvPlaceholder = "{env}"
+
+// ExpandFileTemplate replaces {env} in a file path with the environment name
+func ExpandFileTemplate(file, env string) string {
+	return strings.ReplaceAll(file, EnvPlaceholder, env)
+}
+
+// OutputFile returns the file declared for an environment under outputs,
+// or an empty string if there is none
+func (p *Project) OutputFile(env string) string {
+	if file, ok := p.Outputs[env]; ok {
+		return ExpandFileTemplate(file, env)
+	}
+	return ""
+}
+
+// Key classes
+const (
+	ClassSecret = "secret"
+	ClassConfig = "config"
+)
+
+// IsConfigKey returns true if key matches one of the config patterns
+func (p *Project) IsConfigKey(key string) bool {
+	for _, pattern := range p.Config {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcluded returns true if key matches one of the exclude patterns
+func (p *Project) IsExcluded(key string) bool {
+	return matchAny(p.Exclude, key)
+}
+
+// KeyClass returns ClassConfig or ClassSecret for a key
+func (p *Project) KeyClass(key string) string {
+	if p.IsConfigKey(key) {
+		return ClassConfig
+	}
+	return ClassSecret
+}
+
+// ParseProject parses the content of a project config file
+func ParseProject(data []byte) (*Project, error) {
+	var project Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProjectFile, err)
+	}
+	for _, pattern := range project.Config {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: bad config pattern %q", ProjectFile, pattern)
+		}
+	}
+	for _, pattern := range project.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: bad exclude pattern %q", ProjectFile, pattern)
+		}
+	}
+	for pattern := range project.Comparators {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: bad comparator pattern %q", ProjectFile, pattern)
+		}
+	}
+	for env, file := range project.Outputs {
+		if strings.TrimSpace(file) == "" {
+			return nil, fmt.Errorf("invalid %s: empty output file for %s", ProjectFile, env)
+		}
+	}
+	for name, rules := range project.Remaps {
+		for from, to := range rules {
+			if err := validateRemapRule(from, to); err != nil {
+				return nil, fmt.Errorf("invalid %s: remap %s: %w", ProjectFile, name, err)
+			}
+		}
+	}
+	for pattern, owners := range project.Owners {
+		if err := validateOwners(pattern, owners); err != nil {
+			return nil, fmt.Errorf("invalid %s: owners: %w", ProjectFile, err)
+		}
+	}
+	for pattern, rule := range project.Rotation {
+		if err := validateRotationRule(pattern, rule); err != nil {
+			return nil, fmt.Errorf("invalid %s: rotation: %w", ProjectFile, err)
+		}
+	}
+	for _, check := range project.HealthChecks {
+		if err := validateHealthCheck(check); err != nil {
+			return nil, fmt.Errorf("invalid %s: healthChecks: %w", ProjectFile, err)
+		}
+	}
+	for _, source := range project.Sources {
+		if err := validateSource(source); err != nil {
+			return nil, fmt.Errorf("invalid %s: sources: %w", ProjectFile, err)
+		}
+	}
+	for _, rule := range project.Confirm {
+		if err := validateConfirmRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid %s: confirm: %w", ProjectFile, err)
+		}
+	}
+	if project.DefaultEnv != nil {
+		for _, rule := range project.DefaultEnv.Branches {
+			if err := validateBranchRule(rule); err != nil {
+				return nil, fmt.Errorf("invalid %s: defaultEnv: %w", ProjectFile, err)
+			}
+		}
+	}
+	for rule, severity := range project.Lint {
+		switch severity {
+		case "error", "warning", "off":
+		default:
+			return nil, fmt.Errorf("invalid %s: lint: severity of %s must be error, warning or off", ProjectFile, rule)
+		}
+	}
+	names := make(map[string]bool, len(project.Validators))
+	for _, v := range project.Validators {
+		if err := validateValidator(v); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", ProjectFile, err)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("invalid %s: validator %s is declared twice", ProjectFile, v.Name)
+		}
+		names[v.Name] = true
+	}
+	return &project, nil
+}
+
+// validateValidator checks that a validator has a name, and one of exec and wasm
+func validateValidator(v Validator) error {
+	if strings.TrimSpace(v.Name) == "" {
+		return fmt.Errorf("validator without a name")
+	}
+	if (len(v.Exec) == 0) == (v.Wasm == "") {
+		return fmt.Errorf("validator %s needs one of exec and wasm", v.Name)
+	}
+	if len(v.Exec) > 0 && strings.TrimSpace(v.Exec[0]) == "" {
+		return fmt.Errorf("validator %s has an empty command", v.Name)
+	}
+	for _, pattern := range append(append([]string{}, v.Environments...), v.Keys...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("validator %s: bad pattern %q", v.Name, pattern)
+		}
+	}
+	return nil
+}
+
+// validateConfirmRule checks that a confirmation rule skips or always asks, not both
+func validateConfirmRule(rule ConfirmRule) error {
+	if rule.SkipUpTo < 0 {
+		return fmt.Errorf("skipUpTo must not be negative")
+	}
+	if rule.Always && rule.SkipUpTo > 0 {
+		return fmt.Errorf("always and skipUpTo cannot be combined")
+	}
+	for _, pattern := range rule.Environments {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// validateBranchRule checks that a branch rule matches some branches and sets
+// an environment
+func validateBranchRule(rule BranchRule) error {
+	if strings.TrimSpace(rule.Env) == "" {
+		return fmt.Errorf("branch rule without env")
+	}
+	if rule.Branch == "" && !rule.DefaultBranch {
+		return fmt.Errorf("branch rule for %s needs a branch or defaultBranch", rule.Env)
+	}
+	if _, err := path.Match(rule.Branch, ""); err != nil {
+		return fmt.Errorf("bad branch pattern %q", rule.Branch)
+	}
+	return nil
+}
+
+// validateRemapRule checks that a rename maps a key to a key, or a prefix to a prefix
+func validateRemapRule(from, to string) error {
+	if strings.TrimSpace(to) == "" {
+		return fmt.Errorf("empty name for %s", from)
+	}
+	for _, name := range []string{from, to} {
+		if strings.Contains(strings.TrimSuffix(name, "*"), "*") {
+			return fmt.Errorf("%q may only end with *", name)
+		}
+	}
+	if strings.HasSuffix(from, "*") != strings.HasSuffix(to, "*") {
+		return fmt.Errorf("%s and %s must both be prefixes ending with *, or both keys", from, to)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RotationRule says how keyway rotation plan rotates a key
+type RotationRule struct {
+	// Generate makes the new value, e.g. "hex:32", like the generators of
+	// blueprints. Without it the new value is asked for.
+	Generate string `json:"generate,omitempty"`
+	// Verify is run after each environment gets the new value, with that
+	// environment's secrets, and stops the rotation when it fails
+	Verify []string `json:"verify,omitempty"`
+	// Revoke is run once every environment has the new value, with the old
+	// value in KEYWAY_OLD_VALUE, e.g. to delete the old API key at the provider
+	Revoke []string `json:"revoke,omitempty"`
+}
+
+// RotationRuleFor returns the rotation rule of a key: an exact key wins over
+// patterns, and a longer pattern over shorter ones, as for owners
+func (p *Project) RotationRuleFor(key string) RotationRule {
+	if rule, ok := p.Rotation[key]; ok {
+		return rule
+	}
+	best := ""
+	for pattern := range p.Rotation {
+		if ok, _ := path.Match(pattern, key); !ok {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return RotationRule{}
+	}
+	return p.Rotation[best]
+}
+
+// validateRotationRule checks a rotation rule: a valid pattern and no empty command
+func validateRotationRule(pattern string, rule RotationRule) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("bad pattern %q", pattern)
+	}
+	for name, command := range map[string][]string{"verify": rule.Verify, "revoke": rule.Revoke} {
+		if len(command) > 0 && strings.TrimSpace(command[0]) == "" {
+			return fmt.Errorf("%s of %s has an empty command", name, pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source is where keyway run reads keys from when .keyway.json composes its
+// environment. Exactly one of Process, File, Vault and Defaults is set.
+type Source struct {
+	// Process reads keys already set in the environment of keyway, among
+	// those of the other sources
+	Process bool `json:"process,omitempty"`
+	// File is a local env file, e.g. ".env.local". It may use {env}.
+	File string `json:"file,omitempty"`
+	// Vault is an environment of the vault, e.g. "shared". {env} is the
+	// environment keyway run was given.
+	Vault string `json:"vault,omitempty"`
+	// Defaults are fixed values, e.g. {"LOG_LEVEL": "info"}
+	Defaults map[string]string `json:"defaults,omitempty"`
+	// Optional skips a missing file or vault environment instead of failing
+	Optional bool `json:"optional,omitempty"`
+}
+
+// String describes the source for an environment, e.g. "vault shared"
+func (s Source) String(env string) string {
+	switch {
+	case s.Process:
+		return "process"
+	case s.File != "":
+		return ExpandFileTemplate(s.File, env)
+	case s.Vault != "":
+		return "vault " + ExpandFileTemplate(s.Vault, env)
+	default:
+		return "defaults"
+	}
+}
+
+// validateSource checks that a source has exactly one kind
+func validateSource(s Source) error {
+	kinds := 0
+	if s.Process {
+		kinds++
+	}
+	if s.File != "" {
+		kinds++
+	}
+	if s.Vault != "" {
+		kinds++
+	}
+	if s.Defaults != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("a source needs exactly one of process, file, vault and defaults")
+	}
+	if s.File != "" && strings.TrimSpace(s.File) == "" {
+		return fmt.Errorf("a source has an empty file")
+	}
+	if s.Vault != "" && strings.TrimSpace(s.Vault) == "" {
+		return fmt.Errorf("a source has an empty vault environment")
+	}
+	if s.Optional && (s.Process || s.Defaults != nil) {
+		return fmt.Errorf("only file and vault sources can be optional")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Thresholds of DetectAnomalies. Values shorter than anomalyMinLength are too
+// short for their length or entropy to say anything.
+const (
+	anomalyMinLength     = 16
+	anomalyLengthRatio   = 4.0
+	anomalyMinEntropy    = 3.5
+	anomalyEntropyChange = 1.5
+)
+
+// Anomaly is a value change that looks like a mistake, e.g. a long token
+// replaced by "test"
+type Anomaly struct {
+	Key    string
+	Reason string
+}
+
+// Entropy returns the Shannon entropy of a value, in bits per character
+func Entropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// DetectAnomalies compares the new value of each changed key with its current
+// one and returns, sorted by key, the changes whose length or entropy differ
+// drastically. Values are never included in the reasons.
+func DetectAnomalies(current, next map[string]string) []Anomaly {
+	var anomalies []Anomaly
+	for key, newValue := range next {
+		oldValue, ok := current[key]
+		if !ok || oldValue == newValue {
+			continue
+		}
+		// Files are compared by content elsewhere, their encoding says nothing
+		if _, isFile := DecodeFile(oldValue); isFile {
+			continue
+		}
+		if reason := valueAnomaly(oldValue, newValue); reason != "" {
+			anomalies = append(anomalies, Anomaly{Key: key, Reason: reason})
+		}
+	}
+	sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].Key < anomalies[j].Key })
+	return anomalies
+}
+
+// valueAnomaly describes why replacing oldValue by newValue looks like a
+// mistake, or returns "" if it doesn't
+func valueAnomaly(oldValue, newValue string) string {
+	oldLen, newLen := len(oldValue), len(newValue)
+	if oldLen < anomalyMinLength && newLen < anomalyMinLength {
+		return ""
+	}
+
+	if newLen == 0 {
+		return fmt.Sprintf("%d characters replaced by an empty value", oldLen)
+	}
+	if oldLen >= anomalyMinLength && float64(oldLen) >= float64(newLen)*anomalyLengthRatio {
+		return fmt.Sprintf("%d characters replaced by %d", oldLen, newLen)
+	}
+	if newLen >= anomalyMinLength && float64(newLen) >= float64(oldLen)*anomalyLengthRatio*anomalyLengthRatio {
+		return fmt.Sprintf("%d characters replaced by %d", oldLen, newLen)
+	}
+
+	oldEntropy, newEntropy := Entropy(oldValue), Entropy(newValue)
+	if oldEntropy >= anomalyMinEntropy && oldEntropy-newEntropy >= anomalyEntropyChange {
+		return fmt.Sprintf("randomness dropped from %.1f to %.1f bits per character", oldEntropy, newEntropy)
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package env
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bundleVersion is the format version of export bundles
+const bundleVersion = 1
+
+// bundleKeyPrefix marks the one-time key printed by keyway export
+const bundleKeyPrefix = "kwk1_"
+
+// BundleManifest describes what an export bundle holds. It is readable without
+// the key, so a vendor can see what they were sent, but is authenticated by the
+// encryption and, when signed, by the signature.
+type BundleManifest struct {
+	Repo        string    `json:"repo"`
+	Environment string    `json:"environment"`
+	Keys        []string  `json:"keys"`
+	CreatedAt   time.Time `json:"createdAt"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+}
+
+// bundleFile is the on-disk form of an export bundle
+type bundleFile struct {
+	Version int `json:"version"`
+	// Format is empty for export bundles, and names the archive otherwise
+	Format     string          `json:"format,omitempty"`
+	Manifest   json.RawMessage `json:"manifest"`
+	Nonce      string          `json:"nonce"`
+	Ciphertext string          `json:"ciphertext"`
+	Signer     string          `json:"signer,omitempty"`
+	Signature  string          `json:"signature,omitempty"`
+}
+
+// OpenedBundle is a decrypted export bundle
+type OpenedBundle struct {
+	Manifest BundleManifest
+	Secrets  map[string]string
+	// Signer is the public key that signed the bundle, nil if it is unsigned
+	Signer ed25519.PublicKey
+}
+
+// SealBundle encrypts secrets with a new random key and returns the bundle and
+// that key. The manifest lists the keys and is bound to the ciphertext. If
+// signer is not nil, the manifest and ciphertext are signed with it.
+func SealBundle(manifest BundleManifest, secrets map[string]string, signer ed25519.PrivateKey) ([]byte, string, error) {
+	manifest.Keys = make([]string, 0, len(secrets))
+	for k := range secrets {
+		manifest.Keys = append(manifest.Keys, k)
+	}
+	sort.Strings(manifest.Keys)
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, "", err
+	}
+	return seal("", manifestJSON, plaintext, signer)
+}
+
+// seal encrypts plaintext with a new random key, bound to the manifest, signs
+// both if signer is not nil, and returns the file and the key
+func seal(format string, manifestJSON, plaintext []byte, signer ed25519.PrivateKey) ([]byte, string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", err
+	}
+	gcm, err := bundleCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, manifestJSON)
+
+	b := bundleFile{
+		Version:    bundleVersion,
+		Format:     format,
+		Manifest:   manifestJSON,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}
+	if signer != nil {
+		b.Signer = base64.StdEncoding.EncodeToString(signer.Public().(ed25519.PublicKey))
+		b.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(signer, signedBytes(manifestJSON, nonce, ciphertext)))
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	return data, bundleKeyPrefix + base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// ReadBundleManifest returns the manifest of a bundle without decrypting it
+func ReadBundleManifest(data []byte) (*BundleManifest, error) {
+	b, err := parseBundle(data, "")
+	if err != nil {
+		return nil, err
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(b.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// OpenBundle checks the signature of a bundle, if any, and decrypts it with the
+// key returned by SealBundle.
+func OpenBundle(data []byte, key string) (*OpenedBundle, error) {
+	b, err := parseBundle(data, "")
+	if err != nil {
+		return nil, err
+	}
+	plaintext, signer, err := open(b, key)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := &OpenedBundle{Signer: signer}
+	if err := json.Unmarshal(b.Manifest, &opened.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if err := json.Unmarshal(plaintext, &opened.Secrets); err != nil {
+		return nil, fmt.Errorf("invalid bundle content: %w", err)
+	}
+	return opened, nil
+}
+
+// open checks the signature of a parsed file, if any, decrypts it with the
+// key returned by seal, and returns the plaintext and the signer
+func open(b *bundleFile, key string) ([]byte, ed25519.PublicKey, error) {
+	encodedKey, ok := strings.CutPrefix(strings.TrimSpace(key), bundleKeyPrefix)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid key, it should start with %s", bundleKeyPrefix)
+	}
+	rawKey, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil || len(rawKey) != 32 {
+		return nil, nil, fmt.Errorf("invalid key")
+	}
+	nonce, err := base64.StdEncoding.DecodeString(b.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid bundle nonce")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(b.Ciphertext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid bundle ciphertext")
+	}
+
+	var signer ed25519.PublicKey
+	if b.Signature != "" || b.Signer != "" {
+		signer, err = base64.StdEncoding.DecodeString(b.Signer)
+		if err != nil || len(signer) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("invalid bundle signer")
+		}
+		signature, err := base64.StdEncoding.DecodeString(b.Signature)
+		if err != nil || !ed25519.Verify(signer, signedBytes(b.Manifest, nonce, ciphertext), signature) {
+			return nil, nil, fmt.Errorf("bundle signature is invalid, it may have been tampered with")
+		}
+	}
+
+	gcm, err := bundleCipher(rawKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, nil, fmt.Errorf("invalid bundle nonce")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, b.Manifest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decrypt bundle: wrong key or tampered bundle")
+	}
+	return plaintext, signer, nil
+}
+
+// KeyFingerprint returns a short, human comparable fingerprint of a signing key
+func KeyFingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	encoded := hex.EncodeToString(sum[:10])
+	var groups []string
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, ":")
+}
+
+// parseBundle reads a bundle file of the given format, empty for export bundles
+func parseBundle(data []byte, format string) (*bundleFile, error) {
+	var b bundleFile
+	if err := json.Unmarshal(data, &b); err != nil || b.Format != format {
+		if format == complianceFormat {
+			return nil, fmt.Errorf("not a keyway compliance archive")
+		}
+		return nil, fmt.Errorf("not a keyway export bundle")
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	// The manifest is indented in the file, but sealed and signed compact
+	var manifest bytes.Buffer
+	if err := json.Compact(&manifest, b.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	b.Manifest = manifest.Bytes()
+	return &b, nil
+}
+
+func bundleCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// signedBytes is what a bundle signature covers: the manifest, nonce and ciphertext
+func signedBytes(manifest, nonce, ciphertext []byte) []byte {
+	h := sha256.New()
+	for _, part := range [][]byte{manifest, nonce, ciphertext} {
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,74 @@
+package env
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestSealOpenBundle(t *testing.T) {
+	_, signer, _ := ed25519.GenerateKey(rand.Reader)
+	secrets := map[string]string{"VENDOR_TOKEN": "tok_123", "VENDOR_URL": "https://api.vendor.test"}
+
+	data, key, err := SealBundle(BundleManifest{Repo: "owner/repo", Environment: "production"}, secrets, signer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "tok_123") {
+		t.Error("expected values to be encrypted")
+	}
+
+	manifest, err := ReadBundleManifest(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(manifest.Keys, ",") != "VENDOR_TOKEN,VENDOR_URL" {
+		t.Errorf("unexpected manifest keys: %v", manifest.Keys)
+	}
+
+	opened, err := OpenBundle(data, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened.Secrets["VENDOR_TOKEN"] != "tok_123" || opened.Manifest.Repo != "owner/repo" {
+		t.Errorf("unexpected bundle: %+v", opened)
+	}
+	if KeyFingerprint(opened.Signer) != KeyFingerprint(signer.Public().(ed25519.PublicKey)) {
+		t.Error("expected signer to be returned")
+	}
+}
+
+func TestOpenBundle_WrongKey(t *testing.T) {
+	data, _, _ := SealBundle(BundleManifest{}, map[string]string{"A": "1"}, nil)
+	_, other, _ := SealBundle(BundleManifest{}, map[string]string{"A": "1"}, nil)
+
+	if _, err := OpenBundle(data, other); err == nil {
+		t.Error("expected error with another bundle's key")
+	}
+	if _, err := OpenBundle(data, "not-a-key"); err == nil {
+		t.Error("expected error for malformed key")
+	}
+}
+
+func TestOpenBundle_TamperedManifest(t *testing.T) {
+	_, signer, _ := ed25519.GenerateKey(rand.Reader)
+	data, key, _ := SealBundle(BundleManifest{Repo: "owner/repo"}, map[string]string{"A": "1"}, signer)
+
+	tampered := []byte(strings.Replace(string(data), "owner/repo", "other/repo", 1))
+	if _, err := OpenBundle(tampered, key); err == nil {
+		t.Error("expected error for tampered manifest")
+	}
+}
+
+func TestOpenBundle_Unsigned(t *testing.T) {
+	data, key, _ := SealBundle(BundleManifest{}, map[string]string{"A": "1"}, nil)
+
+	opened, err := OpenBundle(data, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened.Signer != nil {
+		t.Error("expected unsigned bundle")
+	}
+}
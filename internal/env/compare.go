@@ -0,0 +1,133 @@
+package env
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/pem"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Comparator reports whether two values are equivalent.
+type Comparator func(a, b string) bool
+
+// ValueEqual reports whether two values of key are equivalent.
+// A nil ValueEqual compares values byte for byte.
+type ValueEqual func(key, a, b string) bool
+
+var comparators = map[string]Comparator{
+	"exact": func(a, b string) bool { return a == b },
+	"json":  jsonEqual,
+	"cert":  certEqual,
+	"url":   urlEqual,
+}
+
+// LookupComparator returns the built-in comparator with the given name.
+func LookupComparator(name string) (Comparator, bool) {
+	c, ok := comparators[name]
+	return c, ok
+}
+
+// ComparatorNames returns the names of the built-in comparators, sorted.
+func ComparatorNames() []string {
+	names := make([]string, 0, len(comparators))
+	for name := range comparators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompareByPattern returns a ValueEqual using the comparator of the pattern
+// matching the key. An exact key wins over a glob, and a longer glob over a
+// shorter one. Keys matching no pattern are compared byte for byte.
+func CompareByPattern(byPattern map[string]Comparator) ValueEqual {
+	patterns := make([]string, 0, len(byPattern))
+	for pattern := range byPattern {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	return func(key, a, b string) bool {
+		if a == b {
+			return true
+		}
+		if c, ok := byPattern[key]; ok {
+			return c(a, b)
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, key); ok {
+				return byPattern[pattern](a, b)
+			}
+		}
+		return false
+	}
+}
+
+func (eq ValueEqual) equal(key, a, b string) bool {
+	if eq == nil {
+		return a == b
+	}
+	return eq(key, a, b)
+}
+
+// jsonEqual compares JSON documents structurally, ignoring whitespace and key order.
+func jsonEqual(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// certEqual compares PEM values by the fingerprints of their blocks, so that
+// line wrapping, escaped newlines and surrounding whitespace don't matter.
+func certEqual(a, b string) bool {
+	fa, fb := pemFingerprints(a), pemFingerprints(b)
+	if fa == nil || fb == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(fa, fb)
+}
+
+func pemFingerprints(value string) []string {
+	rest := []byte(strings.ReplaceAll(value, `\n`, "\n"))
+	var fingerprints []string
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		sum := sha256.Sum256(block.Bytes)
+		fingerprints = append(fingerprints, block.Type+":"+string(sum[:]))
+	}
+	return fingerprints
+}
+
+// urlEqual compares URLs ignoring scheme and host case and a trailing slash.
+func urlEqual(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil || ua.Scheme == "" || ub.Scheme == "" {
+		return a == b
+	}
+	return normalizeURL(ua) == normalizeURL(ub)
+}
+
+func normalizeURL(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	n.Path = strings.TrimSuffix(n.Path, "/")
+	n.RawPath = ""
+	return n.String()
+}
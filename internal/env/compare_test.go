@@ -0,0 +1,117 @@
+package env
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinComparators(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator string
+		a, b       string
+		want       bool
+	}{
+		{"json key order", "json", `{"a":1,"b":[1,2]}`, `{ "b": [1, 2], "a": 1 }`, true},
+		{"json different value", "json", `{"a":1}`, `{"a":2}`, false},
+		{"json invalid falls back", "json", `{a`, `{a`, true},
+		{"url trailing slash", "url", "https://api.example.com/v1/", "https://api.example.com/v1", true},
+		{"url host case", "url", "https://API.example.com", "https://api.example.com/", true},
+		{"url different path", "url", "https://api.example.com/v1", "https://api.example.com/v2", false},
+		{"url path case matters", "url", "https://example.com/A", "https://example.com/a", false},
+		{"exact", "exact", "a ", "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := LookupComparator(tt.comparator)
+			if !ok {
+				t.Fatalf("comparator %q not found", tt.comparator)
+			}
+			if got := c(tt.a, tt.b); got != tt.want {
+				t.Errorf("%s(%q, %q) = %v, want %v", tt.comparator, tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertComparator(t *testing.T) {
+	cert1, cert2 := testCertificate(t), testCertificate(t)
+	cert, _ := LookupComparator("cert")
+
+	// Same certificate, re-wrapped and with escaped newlines
+	escaped := strings.ReplaceAll(strings.TrimSpace(cert1), "\n", `\n`)
+	if !cert(cert1, escaped) {
+		t.Error("expected escaped PEM to match")
+	}
+	if cert(cert1, cert2) {
+		t.Error("expected different certificates to differ")
+	}
+	if cert("not a cert", "not a cert ") {
+		t.Error("expected non-PEM values to be compared exactly")
+	}
+}
+
+func TestCompareByPattern(t *testing.T) {
+	jsonEq, _ := LookupComparator("json")
+	exact, _ := LookupComparator("exact")
+	equal := CompareByPattern(map[string]Comparator{
+		"*_JSON":      jsonEq,
+		"STRICT_JSON": exact,
+	})
+
+	if !equal("CONFIG_JSON", `{"a":1}`, `{ "a": 1 }`) {
+		t.Error("expected glob pattern to apply")
+	}
+	if equal("STRICT_JSON", `{"a":1}`, `{ "a": 1 }`) {
+		t.Error("expected exact key to win over glob")
+	}
+	if equal("OTHER", `{"a":1}`, `{ "a": 1 }`) {
+		t.Error("expected unmatched keys to be compared byte for byte")
+	}
+}
+
+func TestCalculatePushDiffWith(t *testing.T) {
+	jsonEq, _ := LookupComparator("json")
+	equal := CompareByPattern(map[string]Comparator{"CREDS": jsonEq})
+
+	local := map[string]string{"CREDS": `{"a": 1}`, "KEY": "new"}
+	vault := map[string]string{"CREDS": `{"a":1}`, "KEY": "old"}
+
+	diff := CalculatePushDiffWith(local, vault, equal)
+	if len(diff.Changed) != 1 || diff.Changed[0] != "KEY" {
+		t.Errorf("expected only KEY changed, got %v", diff.Changed)
+	}
+
+	pull := CalculatePullDiffWith(local, vault, equal)
+	if len(pull.Unchanged) != 1 || pull.Unchanged[0] != "CREDS" {
+		t.Errorf("expected CREDS unchanged, got %v", pull.Unchanged)
+	}
+}
+
+func testCertificate(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
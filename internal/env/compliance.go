@@ -0,0 +1,151 @@
+package env
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// complianceFormat marks a compliance archive. It is sealed and signed like
+// an export bundle, with a dump of the whole vault as content.
+const complianceFormat = "keyway-compliance"
+
+// ComplianceSchema identifies the version of the ComplianceDump schema
+const ComplianceSchema = "keyway.compliance.v1"
+
+// ComplianceManifest describes what a compliance archive holds. It is readable
+// without the key and authenticated like a bundle manifest.
+type ComplianceManifest struct {
+	Schema       string    `json:"schema"`
+	Repo         string    `json:"repo"`
+	Environments []string  `json:"environments"`
+	Events       int       `json:"events"`
+	CreatedAt    time.Time `json:"createdAt"`
+	CreatedBy    string    `json:"createdBy,omitempty"`
+	// Digest is the SHA-256 of the dump, for evidence records
+	Digest string `json:"digest"`
+	// Truncated tells that the audit trail holds only the latest Events events
+	Truncated bool `json:"truncated"`
+}
+
+// ComplianceDump is the decrypted content of a compliance archive
+type ComplianceDump struct {
+	Schema       string                  `json:"schema"`
+	Repo         string                  `json:"repo"`
+	ExportedAt   time.Time               `json:"exportedAt"`
+	ExportedBy   string                  `json:"exportedBy,omitempty"`
+	Environments []ComplianceEnvironment `json:"environments"`
+	AuditTrail   []ComplianceEvent       `json:"auditTrail"`
+	// AuditTrailTruncated tells that older events were left out of AuditTrail
+	AuditTrailTruncated bool `json:"auditTrailTruncated"`
+}
+
+// ComplianceEnvironment is an environment of the vault at the time of the export
+type ComplianceEnvironment struct {
+	Name    string            `json:"name"`
+	Version int               `json:"version,omitempty"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// ComplianceEvent is an entry of the vault's audit trail
+type ComplianceEvent struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	Environment string   `json:"environment,omitempty"`
+	Keys        []string `json:"keys,omitempty"`
+	Actor       string   `json:"actor,omitempty"`
+	CreatedAt   string   `json:"createdAt"`
+}
+
+// OpenedCompliance is a decrypted compliance archive
+type OpenedCompliance struct {
+	Manifest ComplianceManifest
+	Dump     ComplianceDump
+	// Content is the dump as sealed, matching the manifest digest
+	Content []byte
+	Signer  ed25519.PublicKey
+}
+
+// SealCompliance encrypts a vault dump with a new random key, signs it, and
+// returns the archive and that key
+func SealCompliance(dump ComplianceDump, signer ed25519.PrivateKey) ([]byte, string, error) {
+	if signer == nil {
+		return nil, "", fmt.Errorf("compliance archives must be signed")
+	}
+	dump.Schema = ComplianceSchema
+	content, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+
+	manifest := ComplianceManifest{
+		Schema:    ComplianceSchema,
+		Repo:      dump.Repo,
+		Events:    len(dump.AuditTrail),
+		Truncated: dump.AuditTrailTruncated,
+		CreatedAt: dump.ExportedAt,
+		CreatedBy: dump.ExportedBy,
+		Digest:    contentDigest(content),
+	}
+	manifest.Environments = make([]string, 0, len(dump.Environments))
+	for _, e := range dump.Environments {
+		manifest.Environments = append(manifest.Environments, e.Name)
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	return seal(complianceFormat, manifestJSON, content, signer)
+}
+
+// ReadComplianceManifest returns the manifest of an archive without decrypting it
+func ReadComplianceManifest(data []byte) (*ComplianceManifest, error) {
+	b, err := parseBundle(data, complianceFormat)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ComplianceManifest
+	if err := json.Unmarshal(b.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// OpenCompliance checks the signature and digest of an archive and decrypts
+// it with the key returned by SealCompliance
+func OpenCompliance(data []byte, key string) (*OpenedCompliance, error) {
+	b, err := parseBundle(data, complianceFormat)
+	if err != nil {
+		return nil, err
+	}
+	if b.Signer == "" {
+		return nil, fmt.Errorf("archive is not signed, its origin cannot be verified")
+	}
+	content, signer, err := open(b, key)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := &OpenedCompliance{Content: content, Signer: signer}
+	if err := json.Unmarshal(b.Manifest, &opened.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+	if opened.Manifest.Digest != contentDigest(content) {
+		return nil, fmt.Errorf("archive digest does not match its content")
+	}
+	if err := json.Unmarshal(content, &opened.Dump); err != nil {
+		return nil, fmt.Errorf("invalid archive content: %w", err)
+	}
+	if opened.Dump.Schema != ComplianceSchema {
+		return nil, fmt.Errorf("unsupported archive schema %q", opened.Dump.Schema)
+	}
+	return opened, nil
+}
+
+func contentDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
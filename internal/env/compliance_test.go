@@ -0,0 +1,88 @@
+package env
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDump() ComplianceDump {
+	return ComplianceDump{
+		Repo:       "owner/repo",
+		ExportedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		ExportedBy: "alice",
+		Environments: []ComplianceEnvironment{
+			{Name: "production", Version: 3, Secrets: map[string]string{"API_KEY": "prod_secret"}},
+			{Name: "staging", Secrets: map[string]string{}},
+		},
+		AuditTrail: []ComplianceEvent{{ID: "ev_1", Type: "secrets.pushed", Environment: "production", Actor: "alice", CreatedAt: "2024-05-30T10:00:00Z"}},
+	}
+}
+
+func TestSealOpenCompliance(t *testing.T) {
+	_, signer, _ := ed25519.GenerateKey(rand.Reader)
+
+	data, key, err := SealCompliance(testDump(), signer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "prod_secret") {
+		t.Error("expected values to be encrypted")
+	}
+
+	manifest, err := ReadComplianceManifest(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest.Schema != ComplianceSchema || strings.Join(manifest.Environments, ",") != "production,staging" || manifest.Events != 1 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+	if !strings.HasPrefix(manifest.Digest, "sha256:") {
+		t.Errorf("expected a SHA-256 digest, got %q", manifest.Digest)
+	}
+
+	opened, err := OpenCompliance(data, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened.Dump.Environments[0].Secrets["API_KEY"] != "prod_secret" || opened.Dump.AuditTrail[0].ID != "ev_1" {
+		t.Errorf("unexpected dump: %+v", opened.Dump)
+	}
+	if KeyFingerprint(opened.Signer) != KeyFingerprint(signer.Public().(ed25519.PublicKey)) {
+		t.Error("expected signer to be returned")
+	}
+}
+
+func TestSealCompliance_RequiresSigner(t *testing.T) {
+	if _, _, err := SealCompliance(testDump(), nil); err == nil {
+		t.Error("expected an error without a signing key")
+	}
+}
+
+func TestOpenCompliance_TamperedManifest(t *testing.T) {
+	_, signer, _ := ed25519.GenerateKey(rand.Reader)
+	data, key, _ := SealCompliance(testDump(), signer)
+
+	tampered := []byte(strings.Replace(string(data), `"events": 1`, `"events": 0`, 1))
+	if string(tampered) == string(data) {
+		tampered = []byte(strings.Replace(string(data), "owner/repo", "other/repo", 1))
+	}
+	if _, err := OpenCompliance(tampered, key); err == nil {
+		t.Error("expected error for tampered manifest")
+	}
+}
+
+func TestCompliance_NotABundle(t *testing.T) {
+	_, signer, _ := ed25519.GenerateKey(rand.Reader)
+	archive, key, _ := SealCompliance(testDump(), signer)
+	bundle, bundleKey, _ := SealBundle(BundleManifest{Repo: "owner/repo"}, map[string]string{"A": "1"}, signer)
+
+	if _, err := OpenBundle(archive, key); err == nil {
+		t.Error("expected a compliance archive not to open as a bundle")
+	}
+	if _, err := OpenCompliance(bundle, bundleKey); err == nil {
+		t.Error("expected a bundle not to open as a compliance archive")
+	}
+}
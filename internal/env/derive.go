@@ -0,0 +1,232 @@
+package env
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var referenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.]*)\}`)
+
+// References returns the keys referenced as ${KEY} in a template, sorted and deduplicated.
+func References(template string) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, m := range referenceRegex.FindAllStringSubmatch(template, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			refs = append(refs, m[1])
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Dependents returns every derived key that depends on key, directly or
+// through other derived keys, sorted.
+func Dependents(templates map[string]string, key string) []string {
+	affected := make(map[string]bool)
+	queue := []string{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for derived, template := range templates {
+			if affected[derived] {
+				continue
+			}
+			for _, ref := range References(template) {
+				if ref == current {
+					affected[derived] = true
+					queue = append(queue, derived)
+					break
+				}
+			}
+		}
+	}
+	delete(affected, key)
+
+	result := make([]string, 0, len(affected))
+	for k := range affected {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Derive computes derived values from templates and returns a copy of secrets
+// with them applied. Derived keys may reference other derived keys.
+// Keys whose template references a missing key, or that are part of a cycle,
+// keep their stored value and are returned as unresolved (sorted).
+func Derive(secrets map[string]string, templates map[string]string) (map[string]string, []string) {
+	result := make(map[string]string, len(secrets)+len(templates))
+	for k, v := range secrets {
+		result[k] = v
+	}
+
+	resolved := make(map[string]bool)
+	failed := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var resolve func(key string) bool
+	resolve = func(key string) bool {
+		template, isDerived := templates[key]
+		if !isDerived {
+			_, ok := secrets[key]
+			return ok
+		}
+		if resolved[key] {
+			return true
+		}
+		if failed[key] || visiting[key] {
+			return false
+		}
+
+		visiting[key] = true
+		ok := true
+		for _, ref := range References(template) {
+			if !resolve(ref) {
+				ok = false
+			}
+		}
+		visiting[key] = false
+
+		if !ok {
+			failed[key] = true
+			return false
+		}
+		result[key] = referenceRegex.ReplaceAllStringFunc(template, func(m string) string {
+			return result[m[2:len(m)-1]]
+		})
+		resolved[key] = true
+		return true
+	}
+
+	var unresolved []string
+	for key := range templates {
+		if !resolve(key) {
+			unresolved = append(unresolved, key)
+		}
+	}
+	sort.Strings(unresolved)
+	return result, unresolved
+}
+
+// Apply sets values in env file content, replacing existing KEY= lines in place
+// and appending new keys at the end in sorted order. Comments and layout are kept.
+func Apply(content string, values map[string]string) string {
+	return apply(content, scan(content), values)
+}
+
+// ApplyVault sets values in env content pulled from the vault, like Apply but
+// reading its unquoted values up to the end of their line, see scanVault
+func ApplyVault(content string, values map[string]string) string {
+	return apply(content, scanVault(content), values)
+}
+
+// apply sets values in content, of which entries are the scanned entries
+func apply(content string, entries []entry, values map[string]string) string {
+	done := make(map[string]bool)
+	lines := strings.Split(content, "\n")
+	var out []string
+	next := 0
+	for _, e := range entries {
+		value, ok := values[e.Key]
+		if e.Key == "" || !ok {
+			continue
+		}
+		out = append(append(out, lines[next:e.First]...), entryLine(e, value))
+		next = e.Last + 1
+		done[e.Key] = true
+	}
+	out = append(out, lines[next:]...)
+
+	var missing []string
+	for key := range values {
+		if !done[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return strings.Join(out, "\n")
+	}
+	sort.Strings(missing)
+
+	result := strings.TrimRight(strings.Join(out, "\n"), "\n")
+	if result != "" {
+		result += "\n"
+	}
+	for _, key := range missing {
+		result += key + "=" + formatValue(values[key]) + "\n"
+	}
+	return result
+}
+
+// Remove deletes the KEY= lines of keys from env file content, all the lines
+// of a multiline value. Comments and the other lines are kept.
+func Remove(content string, keys []string) string {
+	if len(keys) == 0 {
+		return content
+	}
+	remove := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		remove[key] = true
+	}
+
+	lines := strings.Split(content, "\n")
+	var kept []string
+	next := 0
+	for _, e := range scan(content) {
+		if e.Key == "" || !remove[e.Key] {
+			continue
+		}
+		kept = append(kept, lines[next:e.First]...)
+		next = e.Last + 1
+	}
+	kept = append(kept, lines[next:]...)
+	return strings.Join(kept, "\n")
+}
+
+// Rename renames the KEY= lines of env file content, names mapping a key to
+// its new name. Values, comments and the other lines are kept.
+func Rename(content string, names map[string]string) string {
+	lines := strings.Split(content, "\n")
+	for _, e := range scan(content) {
+		name, ok := names[e.Key]
+		if e.Key == "" || !ok || name == e.Key {
+			continue
+		}
+		raw := lines[e.First]
+		idx := strings.LastIndex(raw[:strings.Index(raw, "=")], e.Key)
+		lines[e.First] = raw[:idx] + name + raw[idx+len(e.Key):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entryLine returns the line of an entry set to value, keeping its export
+// prefix and inline comment
+func entryLine(e entry, value string) string {
+	line := e.Key + "=" + formatValue(value) + e.Comment
+	if e.Export {
+		line = "export " + line
+	}
+	return line
+}
+
+// formatValue quotes a value if it contains characters that Parse would otherwise
+// mangle. Multiline values and values with both kinds of quotes are double-quoted
+// with escapes.
+func formatValue(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t#\"'`\n\r") {
+		return value
+	}
+	if !strings.ContainsAny(value, "\"\\\n\r") {
+		return `"` + value + `"`
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	return `"` + valueEscaper.Replace(value) + `"`
+}
+
+// valueEscaper escapes a value for a double-quoted value, see unescape
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
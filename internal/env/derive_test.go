@@ -0,0 +1,148 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	got := References("postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/${DB_USER}")
+	expected := []string{"DB_HOST", "DB_PASSWORD", "DB_USER"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("References() = %v, want %v", got, expected)
+	}
+	if refs := References("no references $HOME"); len(refs) != 0 {
+		t.Errorf("expected no references, got %v", refs)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	templates := map[string]string{
+		"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app",
+		"READ_URL":     "${DATABASE_URL}?readonly=1",
+		"CACHE_URL":    "redis://${REDIS_HOST}",
+	}
+
+	got := Dependents(templates, "DB_HOST")
+	expected := []string{"DATABASE_URL", "READ_URL"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Dependents() = %v, want %v", got, expected)
+	}
+	if deps := Dependents(templates, "UNRELATED"); len(deps) != 0 {
+		t.Errorf("expected no dependents, got %v", deps)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	secrets := map[string]string{"DB_USER": "app", "DB_HOST": "db.internal", "DATABASE_URL": "stale"}
+	templates := map[string]string{
+		"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST}/app",
+		"READ_URL":     "${DATABASE_URL}?readonly=1",
+		"MISSING":      "${NOPE}",
+	}
+
+	result, unresolved := Derive(secrets, templates)
+
+	if result["DATABASE_URL"] != "postgres://app@db.internal/app" {
+		t.Errorf("DATABASE_URL = %q", result["DATABASE_URL"])
+	}
+	if result["READ_URL"] != "postgres://app@db.internal/app?readonly=1" {
+		t.Errorf("READ_URL = %q", result["READ_URL"])
+	}
+	if !reflect.DeepEqual(unresolved, []string{"MISSING"}) {
+		t.Errorf("unresolved = %v", unresolved)
+	}
+	if secrets["DATABASE_URL"] != "stale" {
+		t.Error("Derive must not modify its input")
+	}
+}
+
+func TestDerive_Cycle(t *testing.T) {
+	secrets := map[string]string{"A": "stored"}
+	templates := map[string]string{"A": "${B}", "B": "${A}"}
+
+	result, unresolved := Derive(secrets, templates)
+
+	if !reflect.DeepEqual(unresolved, []string{"A", "B"}) {
+		t.Errorf("unresolved = %v", unresolved)
+	}
+	if result["A"] != "stored" {
+		t.Errorf("expected stored value to be kept, got %q", result["A"])
+	}
+}
+
+func TestApply(t *testing.T) {
+	content := "# Database\nDB_HOST=old\nAPI_KEY=x\n"
+
+	got := Apply(content, map[string]string{"DB_HOST": "new", "URL": "a b"})
+
+	expected := "# Database\nDB_HOST=new\nAPI_KEY=x\nURL=\"a b\"\n"
+	if got != expected {
+		t.Errorf("Apply() = %q, want %q", got, expected)
+	}
+	if parsed := Parse(got); parsed["URL"] != "a b" {
+		t.Errorf("expected quoted value to round-trip, got %q", parsed["URL"])
+	}
+}
+
+func TestApplyVault_ReplacesWholeUnquotedValue(t *testing.T) {
+	got := ApplyVault("PASSWORD=p@ss #123\nAPI_KEY=x\n", map[string]string{"PASSWORD": "new"})
+
+	expected := "PASSWORD=new\nAPI_KEY=x\n"
+	if got != expected {
+		t.Errorf("ApplyVault() = %q, want %q", got, expected)
+	}
+}
+
+func TestApply_Multiline(t *testing.T) {
+	content := "export CERT=\"-----BEGIN-----\nold\n-----END-----\"\nAPI_KEY=x\n"
+	values := map[string]string{
+		"CERT":  "-----BEGIN-----\nnew\n-----END-----",
+		"QUOTE": `it's "quoted" \ back`,
+	}
+
+	got := Apply(content, values)
+
+	expected := "export CERT=\"-----BEGIN-----\\nnew\\n-----END-----\"\nAPI_KEY=x\nQUOTE=\"it's \\\"quoted\\\" \\\\ back\"\n"
+	if got != expected {
+		t.Errorf("Apply() = %q, want %q", got, expected)
+	}
+	parsed := Parse(got)
+	for key, want := range values {
+		if parsed[key] != want {
+			t.Errorf("expected %s to round-trip, got %q", key, parsed[key])
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	content := "# Database\nDB_HOST=old\nAPI_KEY=x\n# API_KEY=commented\n"
+
+	got := Remove(content, []string{"API_KEY", "MISSING"})
+
+	expected := "# Database\nDB_HOST=old\n# API_KEY=commented\n"
+	if got != expected {
+		t.Errorf("Remove() = %q, want %q", got, expected)
+	}
+}
+
+func TestRemove_Multiline(t *testing.T) {
+	content := "A=1\nCERT='-----BEGIN-----\nabc\n-----END-----'\nB=2\n"
+
+	got := Remove(content, []string{"CERT"})
+
+	if got != "A=1\nB=2\n" {
+		t.Errorf("Remove() = %q, want every line of the value removed", got)
+	}
+}
+
+func TestRename(t *testing.T) {
+	content := "# Database\nexport port=5432 # local\nDATABASE_URL='postgres://db'\n# DATABASE_URL=commented\nA=1\n"
+
+	got := Rename(content, map[string]string{"port": "PGPORT", "DATABASE_URL": "DB_URL", "MISSING": "X"})
+
+	expected := "# Database\nexport PGPORT=5432 # local\nDB_URL='postgres://db'\n# DATABASE_URL=commented\nA=1\n"
+	if got != expected {
+		t.Errorf("Rename() = %q, want %q", got, expected)
+	}
+}
@@ -16,12 +16,17 @@ func (d *PushDiff) HasChanges() bool {
 
 // CalculatePushDiff calculates the differences between local and vault secrets for pushing.
 func CalculatePushDiff(local, vault map[string]string) *PushDiff {
+	return CalculatePushDiffWith(local, vault, nil)
+}
+
+// CalculatePushDiffWith is CalculatePushDiff with a custom value comparison.
+func CalculatePushDiffWith(local, vault map[string]string, equal ValueEqual) *PushDiff {
 	diff := &PushDiff{}
 
 	// Check local secrets against vault
 	for key, localVal := range local {
 		if vaultVal, exists := vault[key]; exists {
-			if localVal != vaultVal {
+			if !equal.equal(key, localVal, vaultVal) {
 				diff.Changed = append(diff.Changed, key)
 			}
 		} else {
@@ -59,12 +64,17 @@ func (d *PullDiff) HasChanges() bool {
 
 // CalculatePullDiff calculates the differences between local and vault secrets for pulling.
 func CalculatePullDiff(local, vault map[string]string) *PullDiff {
+	return CalculatePullDiffWith(local, vault, nil)
+}
+
+// CalculatePullDiffWith is CalculatePullDiff with a custom value comparison.
+func CalculatePullDiffWith(local, vault map[string]string, equal ValueEqual) *PullDiff {
 	diff := &PullDiff{}
 
 	// Check vault secrets against local
 	for key, vaultVal := range vault {
 		if localVal, exists := local[key]; exists {
-			if localVal != vaultVal {
+			if !equal.equal(key, localVal, vaultVal) {
 				diff.Changed = append(diff.Changed, key)
 			} else {
 				diff.Unchanged = append(diff.Unchanged, key)
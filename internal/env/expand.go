@@ -0,0 +1,142 @@
+package env
+
+import (
+	"regexp"
+	"sort"
+)
+
+// expandRegex matches ${VAR} and ${VAR:-default}, and \${VAR} written to keep
+// the reference as is
+var expandRegex = regexp.MustCompile(`\\?\$\{([A-Za-z_][A-Za-z0-9_.]*)(?::-([^}]*))?\}`)
+
+// HasReferences returns true if value references another variable as ${VAR}
+func HasReferences(value string) bool {
+	for _, m := range expandRegex.FindAllString(value, -1) {
+		if m[0] != '\\' {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand resolves ${VAR} references in values, like shells and most dotenv
+// loaders do, and returns a copy of secrets with them resolved. A reference is
+// resolved from the other keys, which may reference others in turn, then from
+// lookup (e.g. os.LookupEnv) when it is not a key. ${VAR:-default} falls back
+// to default when VAR is unset or empty, and \${VAR} is kept as ${VAR}.
+// Keys referencing a variable that is set nowhere, or part of a cycle, keep
+// their stored value and are returned as unresolved (sorted).
+func Expand(secrets map[string]string, lookup func(string) (string, bool)) (map[string]string, []string) {
+	result := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		result[k] = v
+	}
+
+	resolved := make(map[string]bool)
+	failed := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var resolve func(key string) bool
+	// value returns the resolved value of a referenced variable
+	value := func(name string) (string, bool) {
+		if _, ok := secrets[name]; ok {
+			if !resolve(name) {
+				return "", false
+			}
+			return result[name], true
+		}
+		if lookup != nil {
+			return lookup(name)
+		}
+		return "", false
+	}
+
+	resolve = func(key string) bool {
+		if resolved[key] {
+			return true
+		}
+		if failed[key] || visiting[key] {
+			return false
+		}
+
+		visiting[key] = true
+		ok := true
+		expanded := expandRegex.ReplaceAllStringFunc(secrets[key], func(m string) string {
+			if m[0] == '\\' {
+				return m[1:]
+			}
+			sub := expandRegex.FindStringSubmatch(m)
+			v, set := value(sub[1])
+			if v == "" && hasDefault(m) {
+				return sub[2]
+			}
+			if !set {
+				ok = false
+			}
+			return v
+		})
+		visiting[key] = false
+
+		if !ok {
+			failed[key] = true
+			return false
+		}
+		result[key] = expanded
+		resolved[key] = true
+		return true
+	}
+
+	var unresolved []string
+	for key := range secrets {
+		if !resolve(key) {
+			unresolved = append(unresolved, key)
+		}
+	}
+	sort.Strings(unresolved)
+	return result, unresolved
+}
+
+// MissingReferences returns the variables referenced by value that are neither
+// keys of secrets nor found by lookup, and have no default (sorted)
+func MissingReferences(value string, secrets map[string]string, lookup func(string) (string, bool)) []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, sub := range expandRegex.FindAllStringSubmatch(value, -1) {
+		if sub[0][0] == '\\' || hasDefault(sub[0]) || seen[sub[1]] {
+			continue
+		}
+		if _, ok := secrets[sub[1]]; ok {
+			continue
+		}
+		if lookup != nil {
+			if _, ok := lookup(sub[1]); ok {
+				continue
+			}
+		}
+		seen[sub[1]] = true
+		missing = append(missing, sub[1])
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// hasDefault returns true if a reference matched by expandRegex has a :- default
+func hasDefault(ref string) bool {
+	sub := expandRegex.FindStringSubmatch(ref)
+	return len(sub[0]) > len(sub[1])+3
+}
+
+// KeepReferences wraps a value comparison so that a local value equal to the
+// expansion of a vault value is equal to it. expanded holds the vault values
+// with their references resolved: pushing a file pulled with expansion must not
+// replace ${VAR} references with the values they resolved to.
+func KeepReferences(equal ValueEqual, expanded map[string]string) ValueEqual {
+	return func(key, a, b string) bool {
+		if HasReferences(b) && !HasReferences(a) {
+			if v, ok := expanded[key]; ok && v == a {
+				return true
+			}
+		}
+		return equal.equal(key, a, b)
+	}
+}
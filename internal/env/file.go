@@ -0,0 +1,68 @@
+package env
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// filePrefix marks a value holding a file, encoded as
+// keyway-file:v1:<name>:<base64 content>
+const filePrefix = "keyway-file:v1:"
+
+// MaxFileSize is the largest file that can be stored as a secret
+const MaxFileSize = 64 * 1024
+
+// File is a small file (service account JSON, keystore...) stored as a secret
+type File struct {
+	Name string
+	Data []byte
+}
+
+// EncodeFile encodes a file as a secret value
+func EncodeFile(name string, data []byte) (string, error) {
+	if len(data) > MaxFileSize {
+		return "", fmt.Errorf("file is %d bytes, the limit is %d", len(data), MaxFileSize)
+	}
+	if strings.ContainsAny(name, ":\n") {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return filePrefix + name + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeFile decodes a secret value produced by EncodeFile.
+// It returns false for values that don't hold a file.
+func DecodeFile(value string) (*File, bool) {
+	rest, ok := strings.CutPrefix(value, filePrefix)
+	if !ok {
+		return nil, false
+	}
+	name, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	return &File{Name: name, Data: data}, true
+}
+
+// IsFile returns true if a secret value holds a file
+func IsFile(value string) bool {
+	_, ok := DecodeFile(value)
+	return ok
+}
+
+// KeepFiles wraps a value comparison so that a file in the vault is equal to any
+// plain local value. Pulled files are replaced by their path in env files, and
+// pushing that path back must not overwrite the file: files are only updated
+// by keyway file push.
+func KeepFiles(equal ValueEqual) ValueEqual {
+	return func(key, a, b string) bool {
+		if IsFile(a) != IsFile(b) {
+			return true
+		}
+		return equal.equal(key, a, b)
+	}
+}
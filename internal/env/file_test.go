@@ -0,0 +1,62 @@
+package env
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeFile(t *testing.T) {
+	data := []byte{0x00, 0xff, 'k', 'e', 'y', '\n'}
+	value, err := EncodeFile("keystore.jks", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.ContainsAny(value, "\n") {
+		t.Errorf("expected a single line value, got %q", value)
+	}
+
+	file, ok := DecodeFile(value)
+	if !ok {
+		t.Fatal("expected value to decode")
+	}
+	if file.Name != "keystore.jks" || !bytes.Equal(file.Data, data) {
+		t.Errorf("unexpected file: %+v", file)
+	}
+
+	// Survives a round trip through an env file
+	parsed := Parse(Apply("", map[string]string{"KEYSTORE": value}))
+	if !IsFile(parsed["KEYSTORE"]) {
+		t.Errorf("expected file to survive env formatting, got %q", parsed["KEYSTORE"])
+	}
+}
+
+func TestEncodeFile_TooLarge(t *testing.T) {
+	if _, err := EncodeFile("big.bin", make([]byte, MaxFileSize+1)); err == nil {
+		t.Error("expected error for a file over the limit")
+	}
+}
+
+func TestDecodeFile_PlainValues(t *testing.T) {
+	for _, value := range []string{"", "secret", "keyway-file:v1:", "keyway-file:v1:name:%%%"} {
+		if IsFile(value) {
+			t.Errorf("expected %q not to be a file", value)
+		}
+	}
+}
+
+func TestKeepFiles(t *testing.T) {
+	value, _ := EncodeFile("sa.json", []byte("{}"))
+	equal := KeepFiles(nil)
+
+	if !equal("GCP_SA_JSON", "secrets/sa.json", value) {
+		t.Error("expected a local path to equal the vault file")
+	}
+	if equal("API_KEY", "a", "b") {
+		t.Error("expected plain values to be compared")
+	}
+	other, _ := EncodeFile("sa.json", []byte(`{"a":1}`))
+	if equal("GCP_SA_JSON", other, value) {
+		t.Error("expected different files to differ")
+	}
+}
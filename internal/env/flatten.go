@@ -0,0 +1,196 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the config files Flatten reads and Unflatten writes
+const (
+	ConfigJSON = "json"
+	ConfigYAML = "yaml"
+)
+
+// ConfigFormat returns the format of a config file from its extension, or ""
+func ConfigFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigJSON
+	case ".yaml", ".yml":
+		return ConfigYAML
+	}
+	return ""
+}
+
+// Flatten turns a nested JSON or YAML config into env keys, joining the path of
+// each value with sep: {"database": {"host": "x"}} gives database__host=x with
+// sep "__". List items are numbered from 0, and null values are empty.
+// Two paths giving the same key are an error.
+func Flatten(data []byte, format, sep string) (map[string]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator is required")
+	}
+
+	var root interface{}
+	switch format {
+	case ConfigJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&root); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case ConfigYAML:
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+
+	secrets := make(map[string]string)
+	if err := flattenValue(secrets, "", sep, root); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// flattenValue adds value, found at key, to secrets
+func flattenValue(secrets map[string]string, key, sep string, value interface{}) error {
+	join := func(child string) string {
+		if key == "" {
+			return child
+		}
+		return key + sep + child
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if err := flattenValue(secrets, join(k), sep, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]interface{}:
+		for k, child := range v {
+			if err := flattenValue(secrets, join(fmt.Sprint(k)), sep, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, child := range v {
+			if err := flattenValue(secrets, join(strconv.Itoa(i)), sep, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if key == "" {
+		return fmt.Errorf("config is a single value, not a mapping")
+	}
+	if _, ok := secrets[key]; ok {
+		return fmt.Errorf("%s is set twice", key)
+	}
+	switch v := value.(type) {
+	case nil:
+		secrets[key] = ""
+	case string:
+		secrets[key] = v
+	case float64:
+		secrets[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		secrets[key] = v.Format(time.RFC3339Nano)
+	default:
+		secrets[key] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// Unflatten is the reverse of Flatten: it splits keys on sep into a nested
+// JSON or YAML config. Values stay strings, and mappings whose keys are 0 to n-1
+// become lists.
+func Unflatten(secrets map[string]string, format, sep string) ([]byte, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator is required")
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := make(map[string]interface{})
+	for _, key := range keys {
+		parts := strings.Split(key, sep)
+		node := root
+		for i, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("cannot unflatten %s: empty segment", key)
+			}
+			if i == len(parts)-1 {
+				if _, ok := node[part]; ok {
+					return nil, fmt.Errorf("cannot unflatten %s: %s is also a section", key, strings.Join(parts[:i+1], sep))
+				}
+				node[part] = secrets[key]
+				break
+			}
+			child, ok := node[part]
+			if !ok {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+			section, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot unflatten %s: %s is also a value", key, strings.Join(parts[:i+1], sep))
+			}
+			node = section
+		}
+	}
+
+	config := listify(root)
+	switch format {
+	case ConfigJSON:
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case ConfigYAML:
+		return yaml.Marshal(config)
+	}
+	return nil, fmt.Errorf("unsupported config format %q", format)
+}
+
+// listify turns the mappings of node whose keys are 0 to n-1 into lists
+func listify(node interface{}) interface{} {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+	for k, child := range m {
+		m[k] = listify(child)
+	}
+	list := make([]interface{}, len(m))
+	for k, child := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		list[i] = child
+	}
+	if len(list) == 0 {
+		return m
+	}
+	return list
+}
@@ -0,0 +1,128 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   map[string]string
+	}{
+		{
+			name:   "yaml",
+			format: ConfigYAML,
+			data:   "database:\n  host: db.internal\n  port: 5432\n  ssl: true\nreplicas:\n  - host: r1\n  - host: r2\nmotd: null\n",
+			want: map[string]string{
+				"database__host":    "db.internal",
+				"database__port":    "5432",
+				"database__ssl":     "true",
+				"replicas__0__host": "r1",
+				"replicas__1__host": "r2",
+				"motd":              "",
+			},
+		},
+		{
+			name:   "json keeps numbers as written",
+			format: ConfigJSON,
+			data:   `{"cache": {"ttl": 1.50, "size": 1e3, "servers": ["a", "b"]}}`,
+			want: map[string]string{
+				"cache__ttl":        "1.50",
+				"cache__size":       "1e3",
+				"cache__servers__0": "a",
+				"cache__servers__1": "b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Flatten([]byte(tt.data), tt.format, "__")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatten_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		sep    string
+	}{
+		{"collision", ConfigJSON, `{"a_b": "1", "a": {"b": "2"}}`, "_"},
+		{"scalar root", ConfigYAML, "just a string\n", "__"},
+		{"invalid json", ConfigJSON, `{"a":`, "__"},
+		{"no separator", ConfigJSON, `{"a": "1"}`, ""},
+		{"unknown format", "toml", `a = 1`, "__"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Flatten([]byte(tt.data), tt.format, tt.sep); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestUnflatten_RoundTrip(t *testing.T) {
+	secrets := map[string]string{
+		"database__host":    "db.internal",
+		"database__port":    "5432",
+		"replicas__0__host": "r1",
+		"replicas__1__host": "r2",
+		"API_KEY":           "sk_123",
+	}
+
+	for _, format := range []string{ConfigJSON, ConfigYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Unflatten(secrets, format, "__")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := Flatten(data, format, "__")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, secrets) {
+				t.Errorf("got %v, want %v", got, secrets)
+			}
+		})
+	}
+}
+
+func TestUnflatten_Lists(t *testing.T) {
+	data, err := Unflatten(map[string]string{"hosts__0": "a", "hosts__1": "b", "ports__1": "80"}, ConfigJSON, "__")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"hosts": [`) {
+		t.Errorf("expected hosts to be a list, got %s", data)
+	}
+	if strings.Contains(string(data), `"ports": [`) {
+		t.Errorf("expected ports, not numbered from 0, to stay a mapping, got %s", data)
+	}
+}
+
+func TestUnflatten_Conflicts(t *testing.T) {
+	tests := []map[string]string{
+		{"database": "x", "database__host": "y"},
+		{"__host": "y"},
+		{"database____host": "y"},
+	}
+	for _, secrets := range tests {
+		if _, err := Unflatten(secrets, ConfigYAML, "__"); err == nil {
+			t.Errorf("expected an error for %v", secrets)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package env
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// defaultGenerateSize is the number of random bytes or characters of a
+// generator given without size, e.g. "hex"
+const defaultGenerateSize = 32
+
+// passwordAlphabet is what generated passwords are made of, safe in URLs and shells
+const passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// Generate returns a random value from a generator: "hex:N" and "base64:N"
+// encode N random bytes, "password:N" has N letters and digits, and "uuid"
+// is a random UUID. N defaults to 32.
+func Generate(spec string) (string, error) {
+	kind, sizeText, hasSize := strings.Cut(strings.TrimSpace(spec), ":")
+	size := defaultGenerateSize
+	if hasSize {
+		n, err := strconv.Atoi(sizeText)
+		if err != nil || n < 8 || n > 512 {
+			return "", fmt.Errorf("invalid size in generator %q, use 8 to 512", spec)
+		}
+		size = n
+	}
+
+	switch kind {
+	case "hex":
+		return hex.EncodeToString(randomBytes(size)), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(randomBytes(size)), nil
+	case "password":
+		out := make([]byte, size)
+		max := big.NewInt(int64(len(passwordAlphabet)))
+		for i := range out {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			out[i] = passwordAlphabet[n.Int64()]
+		}
+		return string(out), nil
+	case "uuid":
+		if hasSize {
+			return "", fmt.Errorf("generator uuid takes no size")
+		}
+		return uuid.NewString(), nil
+	default:
+		return "", fmt.Errorf("unknown generator %q, use hex, base64, password or uuid", spec)
+	}
+}
+
+// randomBytes returns n random bytes. crypto/rand never fails on supported
+// platforms, it crashes the program instead.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
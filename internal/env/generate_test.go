@@ -0,0 +1,53 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerate(t *testing.T) {
+	value, err := Generate("hex:16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, err := hex.DecodeString(value); err != nil || len(b) != 16 {
+		t.Errorf("expected 16 hex-encoded bytes, got %q", value)
+	}
+
+	value, _ = Generate("base64")
+	if b, err := base64.StdEncoding.DecodeString(value); err != nil || len(b) != defaultGenerateSize {
+		t.Errorf("expected %d base64-encoded bytes, got %q", defaultGenerateSize, value)
+	}
+
+	value, _ = Generate("password:24")
+	if len(value) != 24 {
+		t.Errorf("expected 24 characters, got %q", value)
+	}
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			t.Errorf("unexpected character %q in %q", c, value)
+		}
+	}
+
+	value, _ = Generate("uuid")
+	if _, err := uuid.Parse(value); err != nil {
+		t.Errorf("expected a UUID, got %q", value)
+	}
+
+	a, _ := Generate("hex")
+	b, _ := Generate("hex")
+	if a == b {
+		t.Error("expected two generated values to differ")
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "random", "hex:abc", "hex:4", "password:10000", "uuid:8"} {
+		if _, err := Generate(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package env
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off" // disables a value rule
+)
+
+// Issue is a problem found in env file content
+type Issue struct {
+	Line     int    `json:"line"` // 1-based, 0 when not tied to a line
+	Key      string `json:"key,omitempty"`
+	Rule     string `json:"rule,omitempty"` // the value rule, see LintValues
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+var validKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Lint checks env file content for lines Parse would skip or misread:
+// missing "=", invalid key names, duplicate keys and unterminated quotes.
+func Lint(content string) []Issue {
+	var issues []Issue
+	seen := make(map[string]int)
+
+	for _, e := range scan(content) {
+		lineNo := e.First + 1
+		if e.Key == "" {
+			issues = append(issues, Issue{Line: lineNo, Severity: SeverityError, Message: "Line is not KEY=VALUE and will be ignored"})
+			continue
+		}
+
+		key := e.Key
+		if !isASCII(key) {
+			// Often a look-alike character pasted from a doc or a chat
+			issues = append(issues, Issue{Line: lineNo, Key: key, Severity: SeverityError, Message: fmt.Sprintf("Key %q has non-ASCII characters", key)})
+			continue
+		}
+		if !validKeyRegex.MatchString(key) {
+			issues = append(issues, Issue{Line: lineNo, Key: key, Severity: SeverityError, Message: fmt.Sprintf("Invalid key name %q", key)})
+			continue
+		}
+
+		if first, ok := seen[key]; ok {
+			issues = append(issues, Issue{Line: lineNo, Key: key, Severity: SeverityWarning, Message: fmt.Sprintf("%s is already defined on line %d, this value wins", key, first)})
+		} else {
+			seen[key] = lineNo
+		}
+
+		if e.Unterminated {
+			issues = append(issues, Issue{Line: lineNo, Key: key, Severity: SeverityError, Message: "Unterminated quoted value"})
+		}
+	}
+
+	return issues
+}
+
+// isASCII returns true if s only has ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
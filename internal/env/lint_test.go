@@ -0,0 +1,55 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint_Clean(t *testing.T) {
+	content := `# comment
+API_KEY=secret
+QUOTED="hello world"
+EMPTY=
+`
+	if issues := Lint(content); len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestLint_Issues(t *testing.T) {
+	content := `API_KEY=one
+not a variable
+1BAD=value
+export FOO=bar
+API_KEY=two
+OPEN="unterminated
+`
+	issues := Lint(content)
+
+	expected := []struct {
+		line     int
+		severity string
+	}{
+		{2, SeverityError},
+		{3, SeverityError},
+		{5, SeverityWarning},
+		{6, SeverityError},
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, e := range expected {
+		if issues[i].Line != e.line || issues[i].Severity != e.severity {
+			t.Errorf("issue %d = %+v, want line %d severity %s", i, issues[i], e.line, e.severity)
+		}
+	}
+}
+
+func TestLint_NonASCIIKey(t *testing.T) {
+	// The Е is Cyrillic
+	issues := Lint("STRIPE_KEY=sk_1\nSTRIPЕ_SECRET=sk_2\n")
+	if len(issues) != 1 || issues[0].Line != 2 || !strings.Contains(issues[0].Message, "non-ASCII") {
+		t.Errorf("expected a non-ASCII key issue on line 2, got %+v", issues)
+	}
+}
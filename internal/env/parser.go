@@ -6,74 +6,311 @@ import (
 	"strings"
 )
 
-// Parse parses env file content and returns a map of key-value pairs.
-// It handles comments, empty lines, and quoted values.
-func Parse(content string) map[string]string {
-	result := make(map[string]string)
-	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSpace(line)
+// entry is an assignment of env file content, or a line that is not one
+type entry struct {
+	Key          string // empty for a line that is not KEY=VALUE
+	Value        string // unquoted and unescaped; the line itself when Key is empty
+	Export       bool   // written as export KEY=VALUE
+	First, Last  int    // 0-based lines of the entry, a quoted value may span several
+	Unterminated bool   // the opening quote is never closed, the value is read as is
+	Comment      string // inline comment after the value, with the spaces before it
+}
+
+// scan reads env file content into its entries, in order. It follows the
+// usual dotenv rules:
+//   - blank lines and lines starting with # are skipped
+//   - an "export " prefix is allowed
+//   - unquoted values end at an inline comment (" #"), and trailing spaces
+//   - single-quoted and backquoted values are taken literally
+//   - double-quoted values expand \n, \r, \t, \" and \\
+//   - quoted values may span lines
+func scan(content string) []entry {
+	return scanContent(content, true)
+}
+
+// scanVault reads vault content into its entries. Older CLIs pushed values
+// as is, without quoting them, so an unquoted value runs to the end of its
+// line: a " #" in it is part of the value, not a comment.
+func scanVault(content string) []entry {
+	return scanContent(content, false)
+}
+
+// scanContent reads content into its entries, unquoted values ending at an
+// inline comment when comments is set
+func scanContent(content string, comments bool) []entry {
+	lines := strings.Split(content, "\n")
+	var entries []entry
+	for i := 0; i < len(lines); i++ {
+		raw := strings.TrimSuffix(lines[i], "\r")
+		line := strings.TrimSpace(raw)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+
+		e := entry{First: i, Last: i}
+		if rest := strings.TrimPrefix(line, "export"); rest != line && strings.TrimLeft(rest, " \t") != rest {
+			e.Export = true
+			line = strings.TrimLeft(rest, " \t")
+		}
 		idx := strings.Index(line, "=")
 		if idx == -1 {
+			entries = append(entries, entry{Value: line, First: i, Last: i})
 			continue
 		}
-		key := strings.TrimSpace(line[:idx])
+		e.Key = strings.TrimSpace(line[:idx])
 		value := line[idx+1:]
 
-		// Remove surrounding quotes
-		if len(value) >= 2 {
-			if (value[0] == '"' && value[len(value)-1] == '"') ||
-				(value[0] == '\'' && value[len(value)-1] == '\'') {
-				value = value[1 : len(value)-1]
+		if value != "" && strings.ContainsRune("\"'`", rune(value[0])) {
+			// The raw line keeps the spaces a multiline value may end its first line with
+			start := strings.Index(raw, "=") + 1
+			if quoted, last, rest, ok := readQuoted(lines, i, raw[start:]); ok {
+				e.Value, e.Last = quoted, last
+				if strings.HasPrefix(strings.TrimSpace(rest), "#") {
+					e.Comment = strings.TrimRight(rest, " \t")
+				}
+			} else {
+				e.Value, e.Unterminated = value, true
+			}
+		} else if !comments {
+			e.Value = value
+		} else {
+			e.Value = stripComment(value)
+			if rest := value[len(e.Value):]; strings.Contains(rest, "#") {
+				e.Comment = rest
+			}
+		}
+		entries = append(entries, e)
+		i = e.Last
+	}
+	return entries
+}
+
+// readQuoted reads the quoted value starting text, the part of line i after
+// the "=", across lines until its closing quote. It returns the value, the
+// line it ends on and what follows the closing quote on that line, e.g. a
+// comment, or false if the quote is never closed.
+func readQuoted(lines []string, i int, text string) (string, int, string, bool) {
+	quote := text[0]
+	text = text[1:]
+	var b strings.Builder
+	for {
+		for j := 0; j < len(text); j++ {
+			c := text[j]
+			if c == '\\' && quote == '"' && j+1 < len(text) {
+				j++
+				b.WriteString(unescape(text[j]))
+				continue
 			}
+			if c == quote {
+				return b.String(), i, text[j+1:], true
+			}
+			b.WriteByte(c)
+		}
+		i++
+		if i >= len(lines) {
+			return "", 0, "", false
+		}
+		b.WriteByte('\n')
+		text = strings.TrimSuffix(lines[i], "\r")
+	}
+}
+
+// unescape returns what an escape sequence of a double-quoted value stands
+// for, unknown ones being kept as is
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\':
+		return string(c)
+	}
+	return "\\" + string(c)
+}
+
+// stripComment removes an inline comment and trailing spaces from an
+// unquoted value. A # only starts a comment after a space, so that URLs
+// and colors keep theirs.
+func stripComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
 		}
+	}
+	return strings.TrimRight(value, " \t")
+}
+
+// Parse parses env file content and returns a map of key-value pairs.
+// It handles comments, quoted and multiline values, escapes and export
+// prefixes, see scan. The last definition of a key wins.
+func Parse(content string) map[string]string {
+	result := make(map[string]string)
+	for _, e := range scan(content) {
+		if e.Key != "" {
+			result[e.Key] = e.Value
+		}
+	}
+	return result
+}
 
-		if key != "" {
-			result[key] = value
+// ParseVault parses env content pulled from the vault, like Parse but taking
+// unquoted values up to the end of their line, see scanVault
+func ParseVault(content string) map[string]string {
+	result := make(map[string]string)
+	for _, e := range scanVault(content) {
+		if e.Key != "" {
+			result[e.Key] = e.Value
 		}
 	}
 	return result
 }
 
-// CountLines counts non-empty, non-comment lines in env content.
+// NormalizeVault returns env content pulled from the vault with the values
+// that Parse would read differently, e.g. an unquoted value holding a " #",
+// quoted so that the content can be written to a local env file
+func NormalizeVault(content string) string {
+	local := Parse(content)
+	updates := make(map[string]string)
+	for key, value := range ParseVault(content) {
+		if v, ok := local[key]; !ok || v != value {
+			updates[key] = value
+		}
+	}
+	if len(updates) == 0 {
+		return content
+	}
+	return ApplyVault(content, updates)
+}
+
+// Keys returns the keys of env file content in the order they are first
+// defined
+func Keys(content string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range scan(content) {
+		if e.Key != "" && !seen[e.Key] {
+			seen[e.Key] = true
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// CountLines counts the assignments in env content, a multiline value
+// counting once.
 func CountLines(content string) int {
 	count := 0
-	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "#") {
+	for _, e := range scan(content) {
+		if e.Key != "" {
 			count++
 		}
 	}
 	return count
 }
 
-// Merge merges vault content with local-only secrets.
-// Returns the merged content with local-only secrets appended.
-func Merge(vaultContent string, local, vault map[string]string) string {
-	// Start with vault content
-	result := strings.TrimRight(vaultContent, "\n")
+// Merge writes values into local env file content, keeping its layout:
+// comments, blank lines, the order of the keys and the formatting of the lines
+// that don't change. Existing keys are updated in place. A new key is inserted
+// after the key it follows in order (the keys of the vault, in order), or
+// before the first key it precedes, and appended at the end otherwise.
+func Merge(content string, values map[string]string, order []string) string {
+	lines := strings.Split(content, "\n")
+	entries := scan(content)
+
+	first := make(map[string]int)
+	last := make(map[string]int)
+	for i, e := range entries {
+		if e.Key == "" {
+			continue
+		}
+		if _, ok := first[e.Key]; !ok {
+			first[e.Key] = i
+		}
+		last[e.Key] = i
+	}
+
+	// Place the new keys next to their neighbours in order
+	after := make(map[int][]string)  // entry index -> keys to insert after it
+	before := make(map[int][]string) // entry index -> keys to insert before it
+	placed := make(map[string]bool)
+	anchor := -1
+	var leading []string
+	for _, key := range order {
+		if i, ok := last[key]; ok {
+			if len(leading) > 0 {
+				before[first[key]] = leading
+				leading = nil
+			}
+			anchor = i
+			continue
+		}
+		if _, ok := values[key]; !ok || placed[key] {
+			continue
+		}
+		placed[key] = true
+		if anchor >= 0 {
+			after[anchor] = append(after[anchor], key)
+		} else {
+			leading = append(leading, key)
+		}
+	}
+	tail := leading
+	var unordered []string
+	for key := range values {
+		if _, ok := last[key]; !ok && !placed[key] {
+			unordered = append(unordered, key)
+		}
+	}
+	sort.Strings(unordered)
+	tail = append(tail, unordered...)
 
-	// Find local-only secrets and collect keys for sorting
-	var localOnlyKeys []string
-	for key := range local {
-		if _, exists := vault[key]; !exists {
-			localOnlyKeys = append(localOnlyKeys, key)
+	newLines := func(keys []string) []string {
+		out := make([]string, len(keys))
+		for i, key := range keys {
+			out[i] = key + "=" + formatValue(values[key])
 		}
+		return out
 	}
 
-	if len(localOnlyKeys) > 0 {
-		// Sort keys for deterministic output
-		sort.Strings(localOnlyKeys)
+	var out []string
+	next := 0
+	for i, e := range entries {
+		if keys, ok := before[i]; ok {
+			// Above the comments that head the key
+			start := e.First
+			for start > next && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+				start--
+			}
+			out = append(append(out, lines[next:start]...), newLines(keys)...)
+			next = start
+		}
+		value, update := values[e.Key]
+		if e.Key != "" && update && value != e.Value {
+			out = append(append(out, lines[next:e.First]...), entryLine(e, value))
+			next = e.Last + 1
+		}
+		if keys, ok := after[i]; ok {
+			out = append(append(out, lines[next:e.Last+1]...), newLines(keys)...)
+			next = e.Last + 1
+		}
+	}
+	out = append(out, lines[next:]...)
 
-		result += "\n\n# Local variables (not in vault)\n"
-		for _, key := range localOnlyKeys {
-			result += key + "=" + local[key] + "\n"
+	if len(tail) > 0 {
+		// Before the blank lines that end the file
+		end := len(out)
+		for end > 0 && strings.TrimSpace(out[end-1]) == "" {
+			end--
 		}
-	} else {
+		out = append(append(out[:end:end], newLines(tail)...), out[end:]...)
+	}
+	result := strings.Join(out, "\n")
+	if !strings.HasSuffix(result, "\n") {
 		result += "\n"
 	}
-
 	return result
 }
@@ -1,6 +1,7 @@
 package env
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -167,6 +168,92 @@ URL=https://example.com?foo=bar&baz=qux`
 	}
 }
 
+func TestParse_DotenvSyntax(t *testing.T) {
+	content := "export API_KEY=secret123\n" +
+		"PRIVATE_KEY=\"-----BEGIN KEY-----\nabc\n-----END KEY-----\"\n" +
+		"ESCAPED=\"line1\\nline2 \\\"quoted\\\" C:\\\\dir\"\n" +
+		"LITERAL='no \\n escapes # here'\n" +
+		"COMMENTED=value # inline comment\n" +
+		"QUOTED_COMMENTED=\"value\" # inline comment\n" +
+		"COLOR=#ffffff\n" +
+		"URL=https://example.com/#anchor\r\n" +
+		"MULTI_SINGLE='a\n  b'\n" +
+		"AFTER=1\n"
+
+	result := Parse(content)
+
+	expected := map[string]string{
+		"API_KEY":          "secret123",
+		"PRIVATE_KEY":      "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"ESCAPED":          "line1\nline2 \"quoted\" C:\\dir",
+		"LITERAL":          "no \\n escapes # here",
+		"COMMENTED":        "value",
+		"QUOTED_COMMENTED": "value",
+		"COLOR":            "#ffffff",
+		"URL":              "https://example.com/#anchor",
+		"MULTI_SINGLE":     "a\n  b",
+		"AFTER":            "1",
+	}
+	if len(result) != len(expected) {
+		t.Errorf("expected %d keys, got %d: %v", len(expected), len(result), result)
+	}
+	for key, want := range expected {
+		if result[key] != want {
+			t.Errorf("%s = %q, want %q", key, result[key], want)
+		}
+	}
+}
+
+func TestParse_UnterminatedQuote(t *testing.T) {
+	result := Parse("OPEN=\"unterminated\nNEXT=1\n")
+
+	// Read as is rather than swallowing the rest of the file
+	if result["OPEN"] != "\"unterminated" || result["NEXT"] != "1" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func TestParseVault_UnquotedValuesFromOlderCLIs(t *testing.T) {
+	// Older CLIs pushed values as is, a " #" being part of the value
+	content := "PASSWORD=p@ss #123\nQUOTED=\"a #b\" # comment\nCOLOR=#fff\n"
+
+	result := ParseVault(content)
+
+	if result["PASSWORD"] != "p@ss #123" {
+		t.Errorf("PASSWORD = %q, want %q", result["PASSWORD"], "p@ss #123")
+	}
+	if result["QUOTED"] != "a #b" || result["COLOR"] != "#fff" {
+		t.Errorf("unexpected result: %v", result)
+	}
+	if Parse(content)["PASSWORD"] != "p@ss" {
+		t.Error("expected Parse to keep stripping inline comments of local files")
+	}
+}
+
+func TestNormalizeVault(t *testing.T) {
+	content := "API_KEY=x\nPASSWORD=p@ss #123\n"
+
+	got := NormalizeVault(content)
+
+	expected := "API_KEY=x\nPASSWORD=\"p@ss #123\"\n"
+	if got != expected {
+		t.Errorf("NormalizeVault() = %q, want %q", got, expected)
+	}
+	if Parse(got)["PASSWORD"] != "p@ss #123" {
+		t.Errorf("expected the value to survive a local parse, got %q", Parse(got)["PASSWORD"])
+	}
+	if NormalizeVault(expected) != expected {
+		t.Error("expected content already read the same to be kept as is")
+	}
+}
+
+func TestKeys_InFileOrder(t *testing.T) {
+	keys := Keys("B=1\nA=\"x\ny\"\nexport C=3\nB=2\n")
+	if strings.Join(keys, ",") != "B,A,C" {
+		t.Errorf("Keys() = %v, want [B A C]", keys)
+	}
+}
+
 func TestCountLines(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -178,6 +265,7 @@ func TestCountLines(t *testing.T) {
 		{"with comments", "# comment\nKEY=value", 1},
 		{"multiple", "A=1\nB=2\nC=3", 3},
 		{"with empty lines", "A=1\n\nB=2\n\n", 2},
+		{"multiline value", "A=\"1\n2\"\nB=2", 2},
 	}
 
 	for _, tt := range tests {
@@ -190,69 +278,82 @@ func TestCountLines(t *testing.T) {
 	}
 }
 
-func TestMerge_NoLocalOnly(t *testing.T) {
-	vaultContent := "A=1\nB=2"
-	local := map[string]string{"A": "1", "B": "2"}
-	vault := map[string]string{"A": "1", "B": "2"}
+func TestMerge_NoChanges(t *testing.T) {
+	content := "A=1\nB=2"
 
-	result := Merge(vaultContent, local, vault)
+	result := Merge(content, map[string]string{}, []string{"A", "B"})
 
-	// Should just return vault content with newline
+	// Should keep the file, ending it with a newline
 	expected := "A=1\nB=2\n"
 	if result != expected {
 		t.Errorf("Merge() = %q, want %q", result, expected)
 	}
 }
 
-func TestMerge_WithLocalOnly(t *testing.T) {
-	vaultContent := "A=1"
-	local := map[string]string{"A": "1", "LOCAL_SECRET": "my_value"}
-	vault := map[string]string{"A": "1"}
+func TestMerge_KeepsLocalOnlyInPlace(t *testing.T) {
+	content := "A=1\nLOCAL_SECRET=my_value\nB=2\n"
+
+	result := Merge(content, map[string]string{"B": "3"}, []string{"A", "B"})
+
+	expected := "A=1\nLOCAL_SECRET=my_value\nB=3\n"
+	if result != expected {
+		t.Errorf("Merge() = %q, want %q", result, expected)
+	}
+}
+
+func TestMerge_UpdatesInPlace(t *testing.T) {
+	content := "# Database\nexport DB_HOST=old # primary\nDB_PORT='5432'\n\n# API Keys\nAPI_KEY=\"secret\"  # rotated monthly\n"
+	values := map[string]string{"DB_HOST": "db.internal", "DB_PORT": "5432", "API_KEY": "new secret"}
 
-	result := Merge(vaultContent, local, vault)
+	result := Merge(content, values, []string{"API_KEY", "DB_HOST", "DB_PORT"})
 
-	// Should append local-only secrets
-	if result != "A=1\n\n# Local variables (not in vault)\nLOCAL_SECRET=my_value\n" {
-		t.Errorf("Merge() = %q", result)
+	// Unchanged values keep their quotes, changed ones their export and comment
+	expected := "# Database\nexport DB_HOST=db.internal # primary\nDB_PORT='5432'\n\n# API Keys\nAPI_KEY=\"new secret\"  # rotated monthly\n"
+	if result != expected {
+		t.Errorf("Merge() = %q, want %q", result, expected)
 	}
 }
 
-func TestMerge_MultipleLocalOnly(t *testing.T) {
-	vaultContent := "SHARED=value"
-	local := map[string]string{"SHARED": "value", "LOCAL_A": "a", "LOCAL_B": "b"}
-	vault := map[string]string{"SHARED": "value"}
+func TestMerge_InsertsNewKeysNextToNeighbours(t *testing.T) {
+	content := "# Database\nDB_HOST=localhost\nDB_PORT=5432\n\n# Stripe\nSTRIPE_KEY=sk\n"
+	values := map[string]string{"DB_USER": "app", "DB_NAME": "main", "APP_NAME": "shop", "STRIPE_WEBHOOK": "wh"}
+	order := []string{"APP_NAME", "DB_HOST", "DB_USER", "DB_NAME", "DB_PORT", "STRIPE_KEY", "STRIPE_WEBHOOK"}
 
-	result := Merge(vaultContent, local, vault)
+	result := Merge(content, values, order)
 
-	// Local-only should be sorted alphabetically
-	expected := "SHARED=value\n\n# Local variables (not in vault)\nLOCAL_A=a\nLOCAL_B=b\n"
+	expected := "APP_NAME=shop\n# Database\nDB_HOST=localhost\nDB_USER=app\nDB_NAME=main\nDB_PORT=5432\n\n# Stripe\nSTRIPE_KEY=sk\nSTRIPE_WEBHOOK=wh\n"
 	if result != expected {
 		t.Errorf("Merge() = %q, want %q", result, expected)
 	}
 }
 
-func TestMerge_EmptyVault(t *testing.T) {
-	vaultContent := ""
-	local := map[string]string{"LOCAL": "secret"}
-	vault := map[string]string{}
+func TestMerge_AppendsKeysWithoutNeighbours(t *testing.T) {
+	content := "# Local overrides\nDEBUG=true\n\n"
+
+	result := Merge(content, map[string]string{"B": "2", "A": "1"}, []string{"B"})
+
+	// Keys in order first, then the others sorted, before the trailing blank lines
+	expected := "# Local overrides\nDEBUG=true\nB=2\nA=1\n\n"
+	if result != expected {
+		t.Errorf("Merge() = %q, want %q", result, expected)
+	}
+}
 
-	result := Merge(vaultContent, local, vault)
+func TestMerge_EmptyFile(t *testing.T) {
+	result := Merge("", map[string]string{"B": "2", "A": "1"}, []string{"B", "A"})
 
-	expected := "\n\n# Local variables (not in vault)\nLOCAL=secret\n"
+	expected := "B=2\nA=1\n"
 	if result != expected {
 		t.Errorf("Merge() = %q, want %q", result, expected)
 	}
 }
 
-func TestMerge_PreservesVaultFormatting(t *testing.T) {
-	vaultContent := "# Database config\nDB_HOST=localhost\n\n# API Keys\nAPI_KEY=secret"
-	local := map[string]string{"DB_HOST": "localhost", "API_KEY": "secret"}
-	vault := map[string]string{"DB_HOST": "localhost", "API_KEY": "secret"}
+func TestMerge_MultilineValue(t *testing.T) {
+	content := "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nAFTER=1\n"
 
-	result := Merge(vaultContent, local, vault)
+	result := Merge(content, map[string]string{"CERT": "short", "NEW": "x"}, []string{"CERT", "NEW", "AFTER"})
 
-	// Should preserve vault formatting (comments, blank lines)
-	expected := "# Database config\nDB_HOST=localhost\n\n# API Keys\nAPI_KEY=secret\n"
+	expected := "CERT=short\nNEW=x\nAFTER=1\n"
 	if result != expected {
 		t.Errorf("Merge() = %q, want %q", result, expected)
 	}
@@ -0,0 +1,238 @@
+package env
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of values Inspect recognizes
+const (
+	KindJSON = "json"
+	KindPEM  = "pem"
+	KindJWT  = "jwt"
+	KindFile = "file"
+	KindText = "text"
+)
+
+// jwtClaimsShown are the registered JWT claims shown as is, the others are masked
+var jwtClaimsShown = map[string]bool{"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true}
+
+// Preview is a readable rendering of a value, to check it at a glance
+type Preview struct {
+	Kind  string
+	Lines []string
+}
+
+// Inspect renders a value according to what it holds: JSON is indented,
+// certificates show their subject and expiry, JWTs their header and claims.
+// Unless reveal is set, strings in JSON, private keys and non-registered JWT
+// claims are masked. JWT signatures are never shown, nor verified.
+func Inspect(value string, reveal bool, now time.Time) Preview {
+	if file, ok := DecodeFile(value); ok {
+		inner := Inspect(string(file.Data), reveal, now)
+		lines := []string{fmt.Sprintf("file %s, %d bytes", file.Name, len(file.Data))}
+		if inner.Kind != KindText {
+			lines = append(lines, inner.Lines...)
+		}
+		return Preview{Kind: KindFile, Lines: lines}
+	}
+
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "-----BEGIN ") {
+		if lines, ok := inspectPEM(trimmed, now); ok {
+			return Preview{Kind: KindPEM, Lines: lines}
+		}
+	}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if lines, ok := inspectJSON(trimmed, reveal); ok {
+			return Preview{Kind: KindJSON, Lines: lines}
+		}
+	}
+	if strings.HasPrefix(trimmed, "eyJ") && strings.Count(trimmed, ".") == 2 {
+		if lines, ok := inspectJWT(trimmed, reveal, now); ok {
+			return Preview{Kind: KindJWT, Lines: lines}
+		}
+	}
+
+	if reveal {
+		return Preview{Kind: KindText, Lines: []string{value}}
+	}
+	return Preview{Kind: KindText, Lines: []string{maskText(value)}}
+}
+
+// inspectJSON indents a JSON value, masking its strings
+func inspectJSON(value string, reveal bool) ([]string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil || decoder.More() {
+		return nil, false
+	}
+	if !reveal {
+		data = maskJSON(data)
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, false
+	}
+	return strings.Split(string(out), "\n"), true
+}
+
+// maskJSON masks the strings of a decoded JSON value, keeping its structure
+func maskJSON(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = maskJSON(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = maskJSON(item)
+		}
+		return v
+	case string:
+		return maskText(v)
+	default:
+		return v
+	}
+}
+
+// inspectPEM describes the blocks of a PEM value, certificates in detail
+func inspectPEM(value string, now time.Time) ([]string, bool) {
+	// Multi-line values are often stored with escaped newlines
+	if !strings.Contains(value, "\n") {
+		value = strings.ReplaceAll(value, `\n`, "\n")
+	}
+	rest := []byte(value)
+	var lines []string
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			lines = append(lines, fmt.Sprintf("%s (%d bytes, not shown)", block.Type, len(block.Bytes)))
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("CERTIFICATE (invalid: %s)", err.Error()))
+			continue
+		}
+		lines = append(lines, "CERTIFICATE")
+		lines = append(lines, "  subject: "+cert.Subject.String())
+		lines = append(lines, "  issuer:  "+cert.Issuer.String())
+		if len(cert.DNSNames) > 0 {
+			lines = append(lines, "  names:   "+strings.Join(cert.DNSNames, ", "))
+		}
+		lines = append(lines, fmt.Sprintf("  valid:   %s to %s (%s)", cert.NotBefore.UTC().Format(time.DateOnly), cert.NotAfter.UTC().Format(time.DateOnly), expiry(cert.NotAfter, now)))
+	}
+	if len(lines) == 0 || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, false
+	}
+	return lines, true
+}
+
+// inspectJWT shows the header and claims of a JWT, masking custom claims
+func inspectJWT(value string, reveal bool, now time.Time) ([]string, bool) {
+	parts := strings.Split(value, ".")
+	var header map[string]interface{}
+	if !decodeJWTPart(parts[0], &header) {
+		return nil, false
+	}
+	var claims map[string]interface{}
+	if !decodeJWTPart(parts[1], &claims) {
+		return nil, false
+	}
+
+	lines := []string{"header"}
+	lines = append(lines, sortedFields(header, func(k string, v interface{}) string { return fmt.Sprint(v) })...)
+	lines = append(lines, "claims")
+	lines = append(lines, sortedFields(claims, func(k string, v interface{}) string {
+		switch {
+		case k == "exp" || k == "nbf" || k == "iat":
+			if n, ok := v.(json.Number); ok {
+				if secs, err := n.Int64(); err == nil {
+					t := time.Unix(secs, 0).UTC()
+					if k == "exp" {
+						return fmt.Sprintf("%s (%s)", t.Format(time.RFC3339), expiry(t, now))
+					}
+					return t.Format(time.RFC3339)
+				}
+			}
+			return fmt.Sprint(v)
+		case reveal || jwtClaimsShown[k]:
+			if s, ok := v.(string); ok {
+				return s
+			}
+			out, _ := json.Marshal(v)
+			return string(out)
+		default:
+			return maskText(fmt.Sprint(v))
+		}
+	})...)
+	lines = append(lines, "signature not shown, not verified")
+	return lines, true
+}
+
+// decodeJWTPart decodes a base64url JSON part of a JWT
+func decodeJWTPart(part string, v interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v) == nil
+}
+
+// sortedFields renders the fields of an object, one per line, sorted by name
+func sortedFields(fields map[string]interface{}, render func(k string, v interface{}) string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %s: %s", name, render(name, fields[name])))
+	}
+	return lines
+}
+
+// expiry says when t is, relative to now, in days
+func expiry(t, now time.Time) string {
+	days := int(t.Sub(now).Hours() / 24)
+	switch {
+	case !t.After(now):
+		return "expired"
+	case days == 0:
+		return "expires today"
+	case days == 1:
+		return "expires in 1 day"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
+// maskText hides a value but its last 2 characters and its length
+func maskText(value string) string {
+	length := len(value)
+	switch {
+	case length == 0:
+		return "(empty)"
+	case length <= 2:
+		return fmt.Sprintf("** (%d chars)", length)
+	default:
+		return fmt.Sprintf("**%s (%d chars)", value[length-2:], length)
+	}
+}
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+var previewNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// expiringCertificate returns a self-signed certificate expiring at notAfter, as PEM
+func expiringCertificate(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		DNSNames:     []string{"api.example.com"},
+		NotBefore:    previewNow.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestInspect_JSON(t *testing.T) {
+	preview := Inspect(`{"type":"service_account","port":5432,"tls":true,"hosts":["db1"]}`, false, previewNow)
+
+	if preview.Kind != KindJSON {
+		t.Fatalf("expected json, got %s", preview.Kind)
+	}
+	out := strings.Join(preview.Lines, "\n")
+	if strings.Contains(out, "service_account") || strings.Contains(out, "db1") {
+		t.Errorf("expected strings to be masked, got %s", out)
+	}
+	if !strings.Contains(out, `"port": 5432`) || !strings.Contains(out, `"tls": true`) {
+		t.Errorf("expected numbers and booleans as is, got %s", out)
+	}
+
+	revealed := Inspect(`{"type":"service_account"}`, true, previewNow)
+	if !strings.Contains(strings.Join(revealed.Lines, "\n"), `"type": "service_account"`) {
+		t.Errorf("expected the revealed value, got %v", revealed.Lines)
+	}
+}
+
+func TestInspect_Certificate(t *testing.T) {
+	cert := expiringCertificate(t, previewNow.Add(10*24*time.Hour))
+
+	// Stored with escaped newlines, as in many env files
+	preview := Inspect(strings.ReplaceAll(cert, "\n", `\n`), false, previewNow)
+
+	if preview.Kind != KindPEM {
+		t.Fatalf("expected pem, got %s: %v", preview.Kind, preview.Lines)
+	}
+	out := strings.Join(preview.Lines, "\n")
+	if !strings.Contains(out, "CN=api.example.com") || !strings.Contains(out, "expires in 10 days") {
+		t.Errorf("expected subject and expiry, got %s", out)
+	}
+
+	expired := Inspect(expiringCertificate(t, previewNow.Add(-time.Hour)), false, previewNow)
+	if !strings.Contains(strings.Join(expired.Lines, "\n"), "expired") {
+		t.Errorf("expected an expired certificate, got %v", expired.Lines)
+	}
+}
+
+func TestInspect_PrivateKeyHidden(t *testing.T) {
+	value := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("secret key material")}))
+
+	preview := Inspect(value, true, previewNow)
+
+	if preview.Kind != KindPEM || len(preview.Lines) != 1 || !strings.Contains(preview.Lines[0], "PRIVATE KEY (19 bytes, not shown)") {
+		t.Errorf("expected the key to be described only, got %+v", preview)
+	}
+}
+
+func TestInspect_JWT(t *testing.T) {
+	part := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	token := part(`{"alg":"HS256","typ":"JWT"}`) + "." + part(`{"sub":"user-1","exp":1767312000,"email":"alice@example.com"}`) + ".c2lnbmF0dXJl"
+
+	preview := Inspect(token, false, previewNow)
+
+	if preview.Kind != KindJWT {
+		t.Fatalf("expected jwt, got %s", preview.Kind)
+	}
+	out := strings.Join(preview.Lines, "\n")
+	for _, want := range []string{"alg: HS256", "sub: user-1", "exp: 2026-01-02T00:00:00Z (expires in 1 day)", "signature not shown"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %s", want, out)
+		}
+	}
+	if strings.Contains(out, "alice@example.com") || strings.Contains(out, "c2lnbmF0dXJl") {
+		t.Errorf("expected custom claims and the signature to be hidden, got %s", out)
+	}
+}
+
+func TestInspect_FileAndText(t *testing.T) {
+	value, err := EncodeFile("sa.json", []byte(`{"private_key":"xyz"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	preview := Inspect(value, false, previewNow)
+	if preview.Kind != KindFile || preview.Lines[0] != "file sa.json, 21 bytes" || !strings.Contains(strings.Join(preview.Lines, "\n"), `"private_key"`) {
+		t.Errorf("expected the file and its JSON, got %+v", preview)
+	}
+
+	text := Inspect("sk_live_abcdef", false, previewNow)
+	if text.Kind != KindText || text.Lines[0] != "**ef (14 chars)" {
+		t.Errorf("expected a masked text, got %+v", text)
+	}
+	if notJSON := Inspect("{not json", false, previewNow); notJSON.Kind != KindText {
+		t.Errorf("expected invalid JSON to be text, got %+v", notJSON)
+	}
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Remap renames keys for a service with its own naming convention. A rule maps
+// a key to a new name ("DATABASE_URL": "DB_URL"), or a prefix to another
+// ("REDIS_*": "CACHE_*", "*": "APP_*"). An exact rule wins over prefixes, and
+// the longest prefix over shorter ones. Keys no rule matches keep their name.
+// Two keys renamed to the same name are an error.
+func Remap(secrets map[string]string, rules map[string]string) (map[string]string, error) {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	names, err := RemapNames(keys, rules)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		result[names[key]] = value
+	}
+	return result, nil
+}
+
+// RemapNames returns the name of each key under rules, as Remap renames them
+func RemapNames(keys []string, rules map[string]string) (map[string]string, error) {
+	prefixes := rulePrefixes(rules)
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	names := make(map[string]string, len(keys))
+	source := make(map[string]string, len(keys))
+	for _, key := range sorted {
+		name := remapKey(key, rules, prefixes)
+		if other, ok := source[name]; ok {
+			return nil, fmt.Errorf("%s and %s are both mapped to %s", other, key, name)
+		}
+		source[name] = key
+		names[key] = name
+	}
+	return names, nil
+}
+
+// Unremap gives back their vault names to keys renamed by Remap with rules,
+// e.g. to push a file pulled with a remap. A key that no vault key is
+// renamed to under rules is an error.
+func Unremap(secrets map[string]string, rules map[string]string) (map[string]string, error) {
+	inverse := make(map[string]string, len(rules))
+	for from, to := range rules {
+		if other, ok := inverse[to]; ok {
+			if other > from {
+				other, from = from, other
+			}
+			return nil, fmt.Errorf("%s and %s are both mapped to %s", other, from, to)
+		}
+		inverse[to] = from
+	}
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	names, err := RemapNames(keys, inverse)
+	if err != nil {
+		return nil, err
+	}
+
+	// A name the rules cannot produce, e.g. DB_URL when DATABASE_URL is
+	// renamed to it but DB_* to SQL_*, has no vault key to go back to
+	prefixes := rulePrefixes(rules)
+	restored := make(map[string]string, len(secrets))
+	for _, key := range keys {
+		if remapKey(names[key], rules, prefixes) != key {
+			return nil, fmt.Errorf("%s is not a name this remap gives to a vault key", key)
+		}
+		restored[names[key]] = secrets[key]
+	}
+	return restored, nil
+}
+
+// rulePrefixes returns the prefix rules, longest first so that the most
+// specific prefix matches
+func rulePrefixes(rules map[string]string) []string {
+	var prefixes []string
+	for from := range rules {
+		if strings.HasSuffix(from, "*") {
+			prefixes = append(prefixes, from)
+		}
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	return prefixes
+}
+
+// remapKey returns the name of key under rules
+func remapKey(key string, rules map[string]string, prefixes []string) string {
+	if to, ok := rules[key]; ok && !strings.HasSuffix(key, "*") {
+		return to
+	}
+	for _, from := range prefixes {
+		prefix := strings.TrimSuffix(from, "*")
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimSuffix(rules[from], "*") + strings.TrimPrefix(key, prefix)
+		}
+	}
+	return key
+}
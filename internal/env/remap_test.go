@@ -0,0 +1,97 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemap(t *testing.T) {
+	secrets := map[string]string{
+		"DATABASE_URL":   "postgres://db",
+		"REDIS_URL":      "redis://cache",
+		"REDIS_PASSWORD": "pw",
+		"STRIPE_KEY":     "sk",
+	}
+
+	tests := []struct {
+		name  string
+		rules map[string]string
+		want  map[string]string
+	}{
+		{
+			name:  "exact rename",
+			rules: map[string]string{"DATABASE_URL": "DB_URL"},
+			want:  map[string]string{"DB_URL": "postgres://db", "REDIS_URL": "redis://cache", "REDIS_PASSWORD": "pw", "STRIPE_KEY": "sk"},
+		},
+		{
+			name:  "prefix",
+			rules: map[string]string{"REDIS_*": "CACHE_*"},
+			want:  map[string]string{"DATABASE_URL": "postgres://db", "CACHE_URL": "redis://cache", "CACHE_PASSWORD": "pw", "STRIPE_KEY": "sk"},
+		},
+		{
+			name:  "exact wins over the longest prefix, which wins over *",
+			rules: map[string]string{"*": "APP_*", "REDIS_*": "CACHE_*", "REDIS_URL": "REDIS"},
+			want:  map[string]string{"APP_DATABASE_URL": "postgres://db", "REDIS": "redis://cache", "CACHE_PASSWORD": "pw", "APP_STRIPE_KEY": "sk"},
+		},
+		{
+			name:  "strip a prefix",
+			rules: map[string]string{"STRIPE_*": "*"},
+			want:  map[string]string{"DATABASE_URL": "postgres://db", "REDIS_URL": "redis://cache", "REDIS_PASSWORD": "pw", "KEY": "sk"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Remap(secrets, tt.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Remap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemap_Collision(t *testing.T) {
+	secrets := map[string]string{"DATABASE_URL": "a", "DB_URL": "b"}
+
+	if _, err := Remap(secrets, map[string]string{"DATABASE_URL": "DB_URL"}); err == nil {
+		t.Error("expected an error when two keys map to the same name")
+	}
+}
+
+func TestUnremap(t *testing.T) {
+	rules := map[string]string{"*": "APP_*", "REDIS_*": "CACHE_*", "REDIS_URL": "REDIS"}
+	vault := map[string]string{"DATABASE_URL": "postgres://db", "REDIS_URL": "redis://cache", "REDIS_PASSWORD": "pw"}
+
+	remapped, err := Remap(vault, rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := Unremap(remapped, rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, vault) {
+		t.Errorf("Unremap() = %v, want %v", got, vault)
+	}
+}
+
+func TestUnremap_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		rules   map[string]string
+	}{
+		{"name no key is renamed to", map[string]string{"DATABASE_URL": "a"}, map[string]string{"*": "APP_*"}},
+		{"two keys renamed to the same name", map[string]string{"DB": "a"}, map[string]string{"DATABASE_URL": "DB", "PG_URL": "DB"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unremap(tt.secrets, tt.rules); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Strength severities, from the most to the least urgent
+const (
+	StrengthCritical = "critical"
+	StrengthHigh     = "high"
+	StrengthMedium   = "medium"
+)
+
+// Thresholds of AuditStrength
+const (
+	strengthMinLength   = 16
+	strengthShortLength = 8
+	strengthMinEntropy  = 3.0
+)
+
+// strengthRanks orders severities, the most urgent first
+var strengthRanks = map[string]int{StrengthCritical: 0, StrengthHigh: 1, StrengthMedium: 2}
+
+// SeverityAtLeast returns true if severity is as urgent as min or more
+func SeverityAtLeast(severity, min string) bool {
+	rank, ok := strengthRanks[severity]
+	minRank, minOK := strengthRanks[min]
+	return ok && minOK && rank <= minRank
+}
+
+// IsStrengthSeverity returns true for a known strength severity
+func IsStrengthSeverity(severity string) bool {
+	_, ok := strengthRanks[severity]
+	return ok
+}
+
+// weakWords are passwords and placeholders found in leaks and sample configs.
+// A value made of one of them, give or take digits and symbols, is guessed
+// in seconds.
+var weakWords = []string{
+	"password", "passwd", "secret", "admin", "root", "changeme", "letmein",
+	"welcome", "qwerty", "azerty", "default", "example", "sample", "test",
+	"testing", "dummy", "demo", "guest", "master", "login", "token", "apikey",
+	"dragon", "monkey", "iloveyou", "abc123", "123456", "postgres", "mysql",
+	"redis", "keyway", "todo", "fixme", "replace", "placeholder",
+}
+
+// StrengthFinding is a weak secret value. Values are never included.
+type StrengthFinding struct {
+	Key          string   `json:"key"`
+	Environments []string `json:"environments"`
+	Severity     string   `json:"severity"`
+	Reason       string   `json:"reason"`
+	Remediation  string   `json:"remediation"`
+}
+
+// AuditStrength checks the values of the keys isSecret accepts, in every
+// environment of envs (secrets by environment name), for weak patterns:
+// dictionary words, short or repetitive values, and values reused across
+// environments. Findings are sorted by severity, then key.
+func AuditStrength(envs map[string]map[string]string, isSecret func(key string) bool) []StrengthFinding {
+	var findings []StrengthFinding
+
+	envNames := make([]string, 0, len(envs))
+	for name := range envs {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+
+	// Environments of each key and value, to find reuse
+	type keyValue struct{ key, value string }
+	holders := make(map[keyValue][]string)
+
+	for _, name := range envNames {
+		for key, value := range envs[name] {
+			if !isSecret(key) || value == "" {
+				continue
+			}
+			// Files are certificates or documents, their strength is not a length
+			if _, isFile := DecodeFile(value); isFile {
+				continue
+			}
+			holders[keyValue{key, value}] = append(holders[keyValue{key, value}], name)
+			if f, ok := weakValue(value); ok {
+				f.Key = key
+				f.Environments = []string{name}
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	for kv, names := range holders {
+		if len(names) < 2 {
+			continue
+		}
+		severity := StrengthHigh
+		for _, name := range names {
+			if isProductionEnv(name) {
+				// A leak of any other environment leaks production
+				severity = StrengthCritical
+			}
+		}
+		findings = append(findings, StrengthFinding{
+			Key:          kv.key,
+			Environments: names,
+			Severity:     severity,
+			Reason:       fmt.Sprintf("same value in %d environments", len(names)),
+			Remediation:  "Generate a distinct value for each environment",
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return strengthRanks[a.Severity] < strengthRanks[b.Severity]
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return strings.Join(a.Environments, ",") < strings.Join(b.Environments, ",")
+	})
+	return findings
+}
+
+// weakValue returns the most urgent weakness of a value, if it has one
+func weakValue(value string) (StrengthFinding, bool) {
+	if isDictionaryWord(value) {
+		return StrengthFinding{
+			Severity:    StrengthCritical,
+			Reason:      "common password or placeholder",
+			Remediation: "Replace it with a generated value",
+		}, true
+	}
+	if len(value) < strengthShortLength {
+		return StrengthFinding{
+			Severity:    StrengthCritical,
+			Reason:      fmt.Sprintf("%d characters", len(value)),
+			Remediation: fmt.Sprintf("Use at least %d random characters", strengthMinLength),
+		}, true
+	}
+	if len(value) < strengthMinLength {
+		return StrengthFinding{
+			Severity:    StrengthHigh,
+			Reason:      fmt.Sprintf("%d characters", len(value)),
+			Remediation: fmt.Sprintf("Use at least %d random characters", strengthMinLength),
+		}, true
+	}
+	if entropy := Entropy(value); entropy < strengthMinEntropy {
+		return StrengthFinding{
+			Severity:    StrengthMedium,
+			Reason:      fmt.Sprintf("repetitive, %.1f bits of randomness per character", entropy),
+			Remediation: "Replace it with a generated value",
+		}, true
+	}
+	return StrengthFinding{}, false
+}
+
+// isDictionaryWord returns true if a value is one of weakWords, ignoring case
+// and the digits and symbols around it, e.g. "Password123!"
+func isDictionaryWord(value string) bool {
+	core := strings.ToLower(strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}))
+	for _, word := range weakWords {
+		if core == word || strings.ToLower(value) == word {
+			return true
+		}
+	}
+	return false
+}
+
+// isProductionEnv returns true for the usual names of production environments
+func isProductionEnv(name string) bool {
+	name = strings.ToLower(name)
+	return name == "production" || name == "prod" || name == "live"
+}
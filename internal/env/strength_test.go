@@ -0,0 +1,79 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func isKeyOrPassword(key string) bool {
+	return strings.HasSuffix(key, "_KEY") || strings.HasSuffix(key, "_PASSWORD")
+}
+
+func TestAuditStrength_WeakValues(t *testing.T) {
+	envs := map[string]map[string]string{
+		"production": {
+			"DB_PASSWORD": "Password123!",
+			"API_KEY":     "sk_short1",
+			"SIGNING_KEY": "aaaaaaaaaaaaaaaaaaaaaaaabbbbbbbb",
+			"STRONG_KEY":  "hV3q9ZkR2xW7mP4tYb8LcN6s",
+			"NODE_ENV":    "x",
+		},
+	}
+
+	findings := AuditStrength(envs, isKeyOrPassword)
+	got := make(map[string]string)
+	for _, f := range findings {
+		got[f.Key] = f.Severity
+		if strings.Contains(f.Reason, envs["production"][f.Key]) {
+			t.Errorf("reason of %s should not include the value: %q", f.Key, f.Reason)
+		}
+	}
+	want := map[string]string{
+		"DB_PASSWORD": StrengthCritical,
+		"API_KEY":     StrengthHigh,
+		"SIGNING_KEY": StrengthMedium,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for key, severity := range want {
+		if got[key] != severity {
+			t.Errorf("%s: expected %s, got %s", key, severity, got[key])
+		}
+	}
+	if findings[0].Key != "DB_PASSWORD" || findings[len(findings)-1].Key != "SIGNING_KEY" {
+		t.Errorf("findings should be sorted by severity: %v", findings)
+	}
+}
+
+func TestAuditStrength_ReusedValues(t *testing.T) {
+	shared := "hV3q9ZkR2xW7mP4tYb8LcN6s"
+	envs := map[string]map[string]string{
+		"development": {"API_KEY": shared, "JWT_KEY": "q8Wm2Zr5Tx9Kp3Vn7Ld4Hs6B"},
+		"staging":     {"API_KEY": shared, "JWT_KEY": "q8Wm2Zr5Tx9Kp3Vn7Ld4Hs6B"},
+		"production":  {"API_KEY": shared, "JWT_KEY": "Z3mK8vQ2nR7wT5yP9xB4cL6d"},
+	}
+
+	findings := AuditStrength(envs, isKeyOrPassword)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+	if findings[0].Key != "API_KEY" || findings[0].Severity != StrengthCritical || len(findings[0].Environments) != 3 {
+		t.Errorf("a value shared with production should be critical: %+v", findings[0])
+	}
+	if findings[1].Key != "JWT_KEY" || findings[1].Severity != StrengthHigh {
+		t.Errorf("a value shared outside production should be high: %+v", findings[1])
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	if !SeverityAtLeast(StrengthCritical, StrengthHigh) {
+		t.Error("critical should be at least high")
+	}
+	if SeverityAtLeast(StrengthMedium, StrengthHigh) {
+		t.Error("medium should not be at least high")
+	}
+	if SeverityAtLeast(StrengthCritical, "none") {
+		t.Error("no severity is at least an unknown one")
+	}
+}
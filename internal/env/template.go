@@ -0,0 +1,90 @@
+package env
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templateSuffixes mark files that document variables rather than hold them
+var templateSuffixes = []string{".example", ".sample", ".template", ".dist", ".defaults"}
+
+// IsTemplateFile returns true for files like .env.example or .env.production.sample
+func IsTemplateFile(file string) bool {
+	base := strings.ToLower(filepath.Base(file))
+	for _, suffix := range templateSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateCandidates returns the templates that could document an env file,
+// in order of preference: .env.staging.example for .env.staging, then the
+// .env.example next to it
+func TemplateCandidates(file string) []string {
+	var candidates []string
+	for _, suffix := range templateSuffixes {
+		candidates = append(candidates, file+suffix)
+	}
+	if filepath.Base(file) != ".env" {
+		base := filepath.Join(filepath.Dir(file), ".env")
+		for _, suffix := range templateSuffixes {
+			candidates = append(candidates, base+suffix)
+		}
+	}
+	return candidates
+}
+
+// placeholderWords are values commonly left in templates instead of real secrets
+var placeholderWords = map[string]bool{
+	"changeme":    true,
+	"change-me":   true,
+	"change_me":   true,
+	"replaceme":   true,
+	"replace-me":  true,
+	"replace_me":  true,
+	"placeholder": true,
+	"todo":        true,
+	"fixme":       true,
+	"tbd":         true,
+	"example":     true,
+	"...":         true,
+}
+
+var (
+	angleBracketsRegex = regexp.MustCompile(`^<[^<>]+>$`)
+	maskedRegex        = regexp.MustCompile(`^[xX*]{3,}([-_][xX*]{3,})*$`)
+	yourValueRegex     = regexp.MustCompile(`(?i)^(your|my)[-_ ]`)
+	hereSuffixRegex    = regexp.MustCompile(`(?i)[-_ ]here$`)
+)
+
+// IsPlaceholder returns true if a value looks like a template placeholder,
+// e.g. your-api-key, changeme, <token> or xxxx-xxxx
+func IsPlaceholder(value string) bool {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return false
+	}
+	if placeholderWords[strings.ToLower(v)] {
+		return true
+	}
+	return angleBracketsRegex.MatchString(v) ||
+		maskedRegex.MatchString(v) ||
+		yourValueRegex.MatchString(v) ||
+		hereSuffixRegex.MatchString(v)
+}
+
+// Placeholders returns the sorted keys whose values look like placeholders
+func Placeholders(secrets map[string]string) []string {
+	var keys []string
+	for k, v := range secrets {
+		if IsPlaceholder(v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
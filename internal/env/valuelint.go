@@ -0,0 +1,161 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Value rules of LintValues
+const (
+	RuleURLCredentials    = "url-credentials"
+	RuleQueryCredentials  = "query-credentials"
+	RuleDebugInProduction = "debug-in-production"
+	RuleInsecureTLS       = "insecure-tls"
+	RulePlainHTTP         = "plain-http"
+)
+
+// valueRules are the value rules with their default severity
+var valueRules = map[string]string{
+	RuleURLCredentials:    SeverityWarning,
+	RuleQueryCredentials:  SeverityWarning,
+	RuleDebugInProduction: SeverityError,
+	RuleInsecureTLS:       SeverityError,
+	RulePlainHTTP:         SeverityWarning,
+}
+
+// ValueRuleNames returns the names of the value rules, sorted
+func ValueRuleNames() []string {
+	names := make([]string, 0, len(valueRules))
+	for name := range valueRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsValueRule returns true if name is a value rule
+func IsValueRule(name string) bool {
+	_, ok := valueRules[name]
+	return ok
+}
+
+var (
+	productionEnvRegex = regexp.MustCompile(`^(prod|production|prd|live)([-_.].*)?$`)
+	debugKeyRegex      = regexp.MustCompile(`(^|_)DEBUG$`)
+	secretParamRegex   = regexp.MustCompile(`(?i)^(access_?token|api_?key|apikey|auth|key|password|passwd|pwd|secret|sig|signature|token)$`)
+	urlInValueRegex    = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+)
+
+// webSchemes are the schemes of URLs called by HTTP clients
+var webSchemes = map[string]bool{"http": true, "https": true, "ws": true, "wss": true}
+
+// insecureTLSKeys are the settings turning off certificate checks when set to
+// the value they map to
+var insecureTLSKeys = map[string]string{
+	"NODE_TLS_REJECT_UNAUTHORIZED": "0",
+	"PYTHONHTTPSVERIFY":            "0",
+	"GIT_SSL_NO_VERIFY":            "true",
+	"CURL_INSECURE":                "true",
+}
+
+// IsProductionEnv returns true if an environment name says production, e.g.
+// production, prod or prod-eu
+func IsProductionEnv(name string) bool {
+	return productionEnvRegex.MatchString(strings.ToLower(name))
+}
+
+// LintValues checks the values of secrets pushed to envName for foot-guns:
+// credentials embedded in URLs, which end up in logs and error reports, debug
+// flags and disabled certificate checks in production, plain HTTP endpoints.
+// severities overrides the default severity of rules, SeverityOff disables
+// them. Issues are sorted by key, and never include values.
+func LintValues(secrets map[string]string, envName string, severities map[string]string) []Issue {
+	production := IsProductionEnv(envName)
+	var issues []Issue
+	seen := make(map[string]bool)
+	add := func(key, rule, message string) {
+		if seen[key+" "+rule] {
+			return
+		}
+		seen[key+" "+rule] = true
+		severity := valueRules[rule]
+		if s, ok := severities[rule]; ok {
+			severity = s
+		}
+		if severity == SeverityOff {
+			return
+		}
+		issues = append(issues, Issue{Key: key, Rule: rule, Severity: severity, Message: message})
+	}
+
+	for key, value := range secrets {
+		// Files hold their own format, placeholders have no value yet
+		if IsFile(value) || IsPlaceholder(value) {
+			continue
+		}
+		upper := strings.ToUpper(key)
+
+		for _, raw := range urlInValueRegex.FindAllString(value, -1) {
+			u, err := url.Parse(raw)
+			if err != nil || u.Host == "" {
+				continue
+			}
+			// Database URLs need their password, HTTP clients log the URLs they call
+			if password, ok := u.User.Password(); ok && webSchemes[u.Scheme] && password != "" && !HasReferences(password) {
+				add(key, RuleURLCredentials, fmt.Sprintf("%s embeds a password in a URL, which shows up in logs and error reports", key))
+			}
+			query := u.Query()
+			for _, param := range sortedParams(query) {
+				if secretParamRegex.MatchString(param) && query.Get(param) != "" && !HasReferences(query.Get(param)) {
+					add(key, RuleQueryCredentials, fmt.Sprintf("%s passes a credential as the %q query parameter, which shows up in access logs", key, param))
+					break
+				}
+			}
+			if production && u.Scheme == "http" && !isLocalHost(u.Hostname()) {
+				add(key, RulePlainHTTP, fmt.Sprintf("%s points %s at a plain HTTP endpoint", key, envName))
+			}
+		}
+
+		if production && debugKeyRegex.MatchString(upper) && isTruthy(value) {
+			add(key, RuleDebugInProduction, fmt.Sprintf("%s turns debugging on in %s", key, envName))
+		}
+		if want, ok := insecureTLSKeys[upper]; ok && strings.EqualFold(strings.TrimSpace(value), want) {
+			add(key, RuleInsecureTLS, fmt.Sprintf("%s turns off TLS certificate checks", key))
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Key != issues[j].Key {
+			return issues[i].Key < issues[j].Key
+		}
+		return issues[i].Rule < issues[j].Rule
+	})
+	return issues
+}
+
+// isTruthy returns true if a value turns a flag on
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on", "*":
+		return true
+	}
+	return false
+}
+
+// isLocalHost returns true if host is the machine itself
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1" || strings.HasSuffix(host, ".localhost")
+}
+
+// sortedParams returns the names of the parameters of a query, sorted
+func sortedParams(query url.Values) []string {
+	params := make([]string, 0, len(query))
+	for param := range query {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	return params
+}
@@ -0,0 +1,61 @@
+// Package filelock provides advisory locks between keyway processes, so that
+// writes to the same file, e.g. by a watcher and a manual pull, are queued
+// instead of interleaved.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollInterval is how often a lock held by another process is tried again
+var pollInterval = 25 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked")
+
+// Lock is an exclusive lock held on a lock file
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the exclusive lock of path, creating the file if needed, and
+// waits up to timeout while another process holds it
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("still locked by another keyway process after %s (%s)", timeout, path)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	err := unlock(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
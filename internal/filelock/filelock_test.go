@@ -0,0 +1,64 @@
+package filelock
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquire_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "a.lock")
+
+	first, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Acquire(path, 50*time.Millisecond); err == nil {
+		t.Fatal("expected the second lock to time out while the first is held")
+	}
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("expected the lock once released, got %v", err)
+	}
+	second.Unlock()
+}
+
+func TestAcquire_QueuesWaiters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.lock")
+	var mu sync.Mutex
+	inside, maxInside := 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := Acquire(path, 5*time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			inside++
+			if inside > maxInside {
+				maxInside = inside
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxInside != 1 {
+		t.Errorf("expected one holder at a time, got %d", maxInside)
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -67,13 +68,37 @@ func GetGitRoot() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// CheckEnvGitignore checks if .env files are in .gitignore
+// envIgnoreProbes are the files checked to decide whether .env files are ignored.
+// Any ignore rule covering one of them (.env, .env*, .env.*, *.env) counts.
+var envIgnoreProbes = []string{".env", ".env.local"}
+
+// CheckEnvGitignore checks if .env files in the current directory are ignored by git.
+// It asks git itself, so nested .gitignore files, .git/info/exclude, the global
+// excludesFile and worktrees are all taken into account.
 func CheckEnvGitignore() bool {
 	gitRoot, err := GetGitRoot()
 	if err != nil {
 		return true // Not a git repo, don't warn
 	}
 
+	// --no-index evaluates the ignore rules even for files that are tracked
+	args := append([]string{"check-ignore", "--no-index", "--"}, envIgnoreProbes...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = nil
+	err = cmd.Run()
+	if err == nil {
+		return true
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false // Nothing ignored
+	}
+
+	// git could not answer, fall back to reading the root .gitignore
+	return checkRootGitignore(gitRoot)
+}
+
+// checkRootGitignore looks for a .env pattern in the .gitignore at the repository root
+func checkRootGitignore(gitRoot string) bool {
 	gitignorePath := filepath.Join(gitRoot, ".gitignore")
 	content, err := os.ReadFile(gitignorePath)
 	if err != nil {
@@ -163,3 +188,64 @@ func DetectMonorepo() MonorepoInfo {
 
 	return MonorepoInfo{IsMonorepo: false}
 }
+
+// CurrentBranch returns the branch being built or checked out, or "" on a
+// detached HEAD. CI checkouts are often detached, so the branch the CI
+// provider reports comes first.
+func CurrentBranch() string {
+	// GitHub Actions: the source branch on pull requests, else the pushed ref
+	if branch := os.Getenv("GITHUB_HEAD_REF"); branch != "" {
+		return branch
+	}
+	if os.Getenv("GITHUB_REF_TYPE") == "branch" {
+		if branch := os.Getenv("GITHUB_REF_NAME"); branch != "" {
+			return branch
+		}
+	}
+	// GitLab CI
+	if branch := os.Getenv("CI_COMMIT_BRANCH"); branch != "" {
+		return branch
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Stderr = nil
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+// DefaultBranch returns the default branch of the repository, or "" if it
+// can't be told: from the CI provider, else from origin's HEAD
+func DefaultBranch() string {
+	// GitLab CI
+	if branch := os.Getenv("CI_DEFAULT_BRANCH"); branch != "" {
+		return branch
+	}
+	// GitHub Actions: the event payload describes the repository
+	if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			var event struct {
+				Repository struct {
+					DefaultBranch string `json:"default_branch"`
+				} `json:"repository"`
+			}
+			if json.Unmarshal(data, &event) == nil && event.Repository.DefaultBranch != "" {
+				return event.Repository.DefaultBranch
+			}
+		}
+	}
+
+	cmd := exec.Command("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	cmd.Stderr = nil
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/")
+}
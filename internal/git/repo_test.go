@@ -434,3 +434,122 @@ func TestGetGitRoot_NotInGitRepo(t *testing.T) {
 		t.Error("GetGitRoot() should error when not in git repo")
 	}
 }
+
+func TestCheckEnvGitignore_NestedGitignore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nested-gitignore-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+
+	// Monorepo package with its own .gitignore, nothing at the root
+	pkgDir := filepath.Join(tmpDir, "apps", "web")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, ".gitignore"), []byte(".env*\n"), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(pkgDir)
+	defer os.Chdir(origDir)
+
+	if !CheckEnvGitignore() {
+		t.Error("CheckEnvGitignore() should return true when a nested .gitignore covers .env")
+	}
+}
+
+func TestCheckEnvGitignore_ExcludesFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "excludes-file-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+
+	excludes := filepath.Join(tmpDir, "global-ignore")
+	if err := os.WriteFile(excludes, []byte("*.env\n.env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command("git", "config", "core.excludesFile", excludes)
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to set core.excludesFile: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+
+	if !CheckEnvGitignore() {
+		t.Error("CheckEnvGitignore() should return true when core.excludesFile covers .env")
+	}
+}
+
+func TestCheckEnvGitignore_InfoExclude(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "info-exclude-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+
+	excludePath := filepath.Join(tmpDir, ".git", "info", "exclude")
+	os.MkdirAll(filepath.Dir(excludePath), 0755)
+	if err := os.WriteFile(excludePath, []byte(".env.*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(origDir)
+
+	if !CheckEnvGitignore() {
+		t.Error("CheckEnvGitignore() should return true when .git/info/exclude covers .env files")
+	}
+}
+
+func TestCurrentBranch_FromCI(t *testing.T) {
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("GITHUB_REF_TYPE", "branch")
+	t.Setenv("GITHUB_REF_NAME", "main")
+	t.Setenv("CI_COMMIT_BRANCH", "")
+	if branch := CurrentBranch(); branch != "main" {
+		t.Errorf("CurrentBranch() = %q, want main", branch)
+	}
+
+	t.Setenv("GITHUB_HEAD_REF", "feature/login")
+	if branch := CurrentBranch(); branch != "feature/login" {
+		t.Errorf("CurrentBranch() = %q, want the source branch of the pull request", branch)
+	}
+}
+
+func TestDefaultBranch_FromGitHubEvent(t *testing.T) {
+	event := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(event, []byte(`{"repository": {"default_branch": "trunk"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CI_DEFAULT_BRANCH", "")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+
+	if branch := DefaultBranch(); branch != "trunk" {
+		t.Errorf("DefaultBranch() = %q, want trunk", branch)
+	}
+}
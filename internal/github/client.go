@@ -0,0 +1,190 @@
+// Package github manages GitHub Actions secrets through the GitHub REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// MaxSecretSize is the largest value GitHub accepts for an Actions secret
+const MaxSecretSize = 48 * 1024
+
+// Secret is an Actions secret, GitHub never returns its value
+type Secret struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Error is an error returned by the GitHub API
+type Error struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("GitHub API error (%d)", e.StatusCode)
+	}
+	return fmt.Sprintf("GitHub API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// publicKey is the key Actions secrets of a repository or environment are sealed with
+type publicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// Client manages the Actions secrets of repositories and their environments
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	keys       map[string]*publicKey
+}
+
+// NewClient creates a client authenticated with a GitHub token
+func NewClient(token string) *Client {
+	return &Client{
+		baseURL:    config.GetGitHubAPIURL(),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		keys:       make(map[string]*publicKey),
+	}
+}
+
+// secretsPath returns the path of the Actions secrets of a repository
+// ("owner/repo"), or of one of its environments when environment is set
+func secretsPath(repo, environment string) string {
+	if environment == "" {
+		return fmt.Sprintf("/repos/%s/actions/secrets", repo)
+	}
+	return fmt.Sprintf("/repos/%s/environments/%s/secrets", repo, url.PathEscape(environment))
+}
+
+// ListActionsSecrets returns the names of the Actions secrets of a repository,
+// or of one of its environments
+func (c *Client) ListActionsSecrets(ctx context.Context, repo, environment string) ([]Secret, error) {
+	var secrets []Secret
+	for page := 1; ; page++ {
+		var resp struct {
+			TotalCount int      `json:"total_count"`
+			Secrets    []Secret `json:"secrets"`
+		}
+		path := fmt.Sprintf("%s?per_page=100&page=%d", secretsPath(repo, environment), page)
+		if err := c.do(ctx, "GET", path, nil, &resp); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, resp.Secrets...)
+		if len(resp.Secrets) == 0 || len(secrets) >= resp.TotalCount {
+			return secrets, nil
+		}
+	}
+}
+
+// PutActionsSecret creates or updates an Actions secret, sealed with the
+// public key of the repository or environment
+func (c *Client) PutActionsSecret(ctx context.Context, repo, environment, name, value string) error {
+	key, err := c.publicKey(ctx, repo, environment)
+	if err != nil {
+		return err
+	}
+	sealed, err := seal(key.Key, value)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"encrypted_value": sealed,
+		"key_id":          key.KeyID,
+	}
+	return c.do(ctx, "PUT", secretsPath(repo, environment)+"/"+name, body, nil)
+}
+
+// DeleteActionsSecret deletes an Actions secret
+func (c *Client) DeleteActionsSecret(ctx context.Context, repo, environment, name string) error {
+	return c.do(ctx, "DELETE", secretsPath(repo, environment)+"/"+name, nil, nil)
+}
+
+// publicKey returns the public key of a repository or environment, fetched once
+func (c *Client) publicKey(ctx context.Context, repo, environment string) (*publicKey, error) {
+	path := secretsPath(repo, environment) + "/public-key"
+	if key, ok := c.keys[path]; ok {
+		return key, nil
+	}
+	var key publicKey
+	if err := c.do(ctx, "GET", path, nil, &key); err != nil {
+		return nil, err
+	}
+	c.keys[path] = &key
+	return &key, nil
+}
+
+// seal encrypts a value for GitHub with a sealed box, as its API requires
+func seal(publicKeyB64, value string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(publicKeyB64)
+	if err != nil || len(raw) != 32 {
+		return "", fmt.Errorf("invalid public key returned by GitHub")
+	}
+	var key [32]byte
+	copy(key[:], raw)
+
+	sealed, err := box.SealAnonymous(nil, []byte(value), &key, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// do sends a request to the GitHub API and decodes the response into result
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", "keyway-cli")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+	if result != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, result)
+	}
+	return nil
+}
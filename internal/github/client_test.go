@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func newTestClient(server *httptest.Server) *Client {
+	c := NewClient("gh-token")
+	c.baseURL = server.URL
+	return c
+}
+
+func TestSecretsPath(t *testing.T) {
+	if got := secretsPath("acme/api", ""); got != "/repos/acme/api/actions/secrets" {
+		t.Errorf("repository path = %q", got)
+	}
+	if got := secretsPath("acme/api", "prod eu"); got != "/repos/acme/api/environments/prod%20eu/secrets" {
+		t.Errorf("environment path = %q", got)
+	}
+}
+
+func TestListActionsSecrets_Paginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/repos/acme/api/actions/secrets" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"total_count":3,"secrets":[{"name":"A"},{"name":"B"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"total_count":3,"secrets":[{"name":"C"}]}`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer server.Close()
+
+	secrets, err := newTestClient(server).ListActionsSecrets(context.Background(), "acme/api", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets) != 3 || secrets[2].Name != "C" {
+		t.Errorf("secrets = %+v", secrets)
+	}
+}
+
+func TestPutActionsSecret_SealsValue(t *testing.T) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFetches := 0
+	received := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/acme/api/environments/production/secrets/public-key":
+			keyFetches++
+			fmt.Fprintf(w, `{"key_id":"k1","key":%q}`, base64.StdEncoding.EncodeToString(pub[:]))
+		case r.Method == "PUT":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["key_id"] != "k1" {
+				t.Errorf("key_id = %q", body["key_id"])
+			}
+			sealed, _ := base64.StdEncoding.DecodeString(body["encrypted_value"])
+			opened, ok := box.OpenAnonymous(nil, sealed, pub, priv)
+			if !ok {
+				t.Error("cannot open the sealed value")
+			}
+			received[r.URL.Path] = string(opened)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	ctx := context.Background()
+	if err := c.PutActionsSecret(ctx, "acme/api", "production", "API_KEY", "s3cret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.PutActionsSecret(ctx, "acme/api", "production", "DB_URL", "postgres://x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if keyFetches != 1 {
+		t.Errorf("public key fetched %d times, want 1", keyFetches)
+	}
+	if received["/repos/acme/api/environments/production/secrets/API_KEY"] != "s3cret" {
+		t.Errorf("received = %v", received)
+	}
+	if received["/repos/acme/api/environments/production/secrets/DB_URL"] != "postgres://x" {
+		t.Errorf("received = %v", received)
+	}
+}
+
+func TestDeleteActionsSecret_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/repos/acme/api/actions/secrets/OLD" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	}))
+	defer server.Close()
+
+	err := newTestClient(server).DeleteActionsSecret(context.Background(), "acme/api", "", "OLD")
+	ghErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if ghErr.StatusCode != 403 || ghErr.Message != "Resource not accessible by integration" {
+		t.Errorf("error = %+v", ghErr)
+	}
+}
+
+func TestSeal_InvalidKey(t *testing.T) {
+	if _, err := seal("bm90IGEga2V5", "value"); err == nil {
+		t.Error("expected an error for a key that isn't 32 bytes")
+	}
+}
@@ -0,0 +1,118 @@
+package injector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ActiveEnvVar is set to the environment name in a shell where keyway activate
+// was evaluated, so that prompts can show it.
+const ActiveEnvVar = "KEYWAY_ACTIVE"
+
+// ActivationEnvVar identifies the activation of a shell, keyway deactivate
+// reads it to find the variables to unset.
+const ActivationEnvVar = "KEYWAY_ACTIVATION"
+
+var varNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidVarName returns true if name can be set as a variable by every shell
+// supported by ActivateScript
+func ValidVarName(name string) bool {
+	return varNameRegex.MatchString(name)
+}
+
+// ActivateScript returns the code that, evaluated by shell, exports secrets
+// into it and prefixes its prompt with "(keyway:<envName>)".
+// Only POSIX shells, fish and PowerShell can evaluate it.
+func ActivateScript(shell, envName, activationID string, secrets map[string]string) (string, error) {
+	vars := make(map[string]string, len(secrets)+2)
+	for key, value := range secrets {
+		if !ValidVarName(key) {
+			return "", fmt.Errorf("cannot export %q: not a valid variable name", key)
+		}
+		vars[key] = value
+	}
+	// Set last, so that a secret cannot override them
+	vars[ActiveEnvVar] = envName
+	vars[ActivationEnvVar] = activationID
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	prefix := fmt.Sprintf("(keyway:%s) ", envName)
+	var b strings.Builder
+	switch ShellName(shell) {
+	case "fish":
+		for _, key := range keys {
+			fmt.Fprintf(&b, "set -gx %s %s\n", key, fishQuote(vars[key]))
+		}
+		b.WriteString("functions -q __keyway_prompt; or functions -c fish_prompt __keyway_prompt\n")
+		fmt.Fprintf(&b, "function fish_prompt; echo -n %s; __keyway_prompt; end\n", fishQuote(prefix))
+
+	case "powershell", "pwsh":
+		for _, key := range keys {
+			fmt.Fprintf(&b, "$env:%s = %s\n", key, powershellQuote(vars[key]))
+		}
+		b.WriteString("if (-not (Test-Path Function:\\__keyway_prompt)) { ${function:global:__keyway_prompt} = ${function:prompt} }\n")
+		fmt.Fprintf(&b, "function global:prompt { %s + (__keyway_prompt) }\n", powershellQuote(prefix))
+
+	case "cmd":
+		return "", fmt.Errorf("cmd.exe cannot evaluate scripts, use PowerShell or keyway shell")
+
+	default:
+		// bash, zsh and other POSIX shells
+		for _, key := range keys {
+			fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(vars[key]))
+		}
+		b.WriteString(`[ -n "${_KEYWAY_OLD_PS1+x}" ] || _KEYWAY_OLD_PS1="${PS1-}"` + "\n")
+		fmt.Fprintf(&b, "PS1=%s\"$_KEYWAY_OLD_PS1\"\n", shellQuote(prefix))
+	}
+	return b.String(), nil
+}
+
+// DeactivateScript returns the code that, evaluated by shell, unsets keys and
+// the variables of the activation, and restores the prompt
+func DeactivateScript(shell string, keys []string) (string, error) {
+	var names []string
+	for _, key := range keys {
+		if !ValidVarName(key) {
+			return "", fmt.Errorf("cannot unset %q: not a valid variable name", key)
+		}
+		names = append(names, key)
+	}
+	names = append(names, ActiveEnvVar, ActivationEnvVar)
+	sort.Strings(names)
+
+	var b strings.Builder
+	switch ShellName(shell) {
+	case "fish":
+		for _, name := range names {
+			fmt.Fprintf(&b, "set -e %s\n", name)
+		}
+		b.WriteString("if functions -q __keyway_prompt; functions -e fish_prompt; functions -c __keyway_prompt fish_prompt; functions -e __keyway_prompt; end\n")
+
+	case "powershell", "pwsh":
+		for _, name := range names {
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", name)
+		}
+		b.WriteString("if (Test-Path Function:\\__keyway_prompt) { ${function:global:prompt} = ${function:__keyway_prompt}; Remove-Item Function:\\__keyway_prompt }\n")
+
+	case "cmd":
+		return "", fmt.Errorf("cmd.exe cannot evaluate scripts, use PowerShell or keyway shell")
+
+	default:
+		fmt.Fprintf(&b, "unset %s\n", strings.Join(names, " "))
+		b.WriteString(`if [ -n "${_KEYWAY_OLD_PS1+x}" ]; then PS1="$_KEYWAY_OLD_PS1"; unset _KEYWAY_OLD_PS1; fi` + "\n")
+	}
+	return b.String(), nil
+}
+
+// fishQuote quotes s as a fish single-quoted string, where backslashes escape
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
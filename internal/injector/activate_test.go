@@ -0,0 +1,104 @@
+package injector
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestActivateScript_Bash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	secrets := map[string]string{"API_KEY": "it's a \"secret\" $HOME\nline two", "DB_URL": `postgres://a\b`}
+	activate, err := ActivateScript(bash, "staging", "act_1", secrets)
+	if err != nil {
+		t.Fatalf("ActivateScript failed: %v", err)
+	}
+	deactivate, err := DeactivateScript(bash, []string{"API_KEY", "DB_URL"})
+	if err != nil {
+		t.Fatalf("DeactivateScript failed: %v", err)
+	}
+
+	script := `PS1='$ '
+eval "$ACTIVATE"
+printf '%s|%s|%s|%s|' "$API_KEY" "$DB_URL" "$KEYWAY_ACTIVE" "$PS1"
+eval "$ACTIVATE"
+printf '%s|' "$PS1"
+eval "$DEACTIVATE"
+printf '%s|%s|%s' "${API_KEY-unset}" "${KEYWAY_ACTIVATION-unset}" "$PS1"`
+	cmd := exec.Command(bash, "--norc", "--noprofile", "-c", script)
+	cmd.Env = []string{"ACTIVATE=" + activate, "DEACTIVATE=" + deactivate}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+
+	want := secrets["API_KEY"] + "|" + secrets["DB_URL"] + "|staging|(keyway:staging) $ |" +
+		"(keyway:staging) $ |" +
+		"unset|unset|$ "
+	if string(out) != want {
+		t.Errorf("unexpected output:\n%q\nwant:\n%q", out, want)
+	}
+}
+
+func TestActivateScript_Fish(t *testing.T) {
+	script, err := ActivateScript("/usr/bin/fish", "dev", "act_1", map[string]string{"PATH_LIKE": `C:\dir's`})
+	if err != nil {
+		t.Fatalf("ActivateScript failed: %v", err)
+	}
+	if !strings.Contains(script, `set -gx PATH_LIKE 'C:\\dir\'s'`) {
+		t.Errorf("expected a fish quoted value, got:\n%s", script)
+	}
+	if !strings.Contains(script, "set -gx KEYWAY_ACTIVE 'dev'") {
+		t.Errorf("expected %s to be set, got:\n%s", ActiveEnvVar, script)
+	}
+}
+
+func TestActivateScript_PowerShell(t *testing.T) {
+	script, err := ActivateScript(`C:\Program Files\PowerShell\7\pwsh.exe`, "dev", "act_1", map[string]string{"API_KEY": "it's"})
+	if err != nil {
+		t.Fatalf("ActivateScript failed: %v", err)
+	}
+	if !strings.Contains(script, "$env:API_KEY = 'it''s'") {
+		t.Errorf("expected a PowerShell quoted value, got:\n%s", script)
+	}
+
+	deactivate, err := DeactivateScript("powershell", []string{"API_KEY"})
+	if err != nil {
+		t.Fatalf("DeactivateScript failed: %v", err)
+	}
+	if !strings.Contains(deactivate, "Remove-Item Env:API_KEY") {
+		t.Errorf("expected API_KEY to be removed, got:\n%s", deactivate)
+	}
+}
+
+func TestActivateScript_PowerShellTypographicQuotes(t *testing.T) {
+	// PowerShell ends a single-quoted string on ’ as well as on '
+	value := "x\u2019; Remove-Item -Recurse ~; \u2019"
+	script, err := ActivateScript("pwsh", "dev", "act_1", map[string]string{"API_KEY": value})
+	if err != nil {
+		t.Fatalf("ActivateScript failed: %v", err)
+	}
+	expected := "$env:API_KEY = 'x\u2019\u2019; Remove-Item -Recurse ~; \u2019\u2019'"
+	if !strings.Contains(script, expected) {
+		t.Errorf("expected %q in the script, got:\n%s", expected, script)
+	}
+
+	for _, quote := range []string{"\u2018", "\u201A", "\u201B"} {
+		if got, want := powershellQuote("a"+quote+"b"), "'a"+quote+quote+"b'"; got != want {
+			t.Errorf("powershellQuote(%q) = %q, want %q", "a"+quote+"b", got, want)
+		}
+	}
+}
+
+func TestActivateScript_Rejects(t *testing.T) {
+	if _, err := ActivateScript("bash", "dev", "act_1", map[string]string{"A;rm -rf ~": "x"}); err == nil {
+		t.Error("expected an error for an invalid variable name")
+	}
+	if _, err := ActivateScript("cmd.exe", "dev", "act_1", nil); err == nil {
+		t.Error("expected an error for cmd.exe")
+	}
+}
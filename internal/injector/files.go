@@ -0,0 +1,54 @@
+package injector
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// WriteFiles writes the secrets holding files (see env.EncodeFile) to a private
+// temporary directory, and returns the secrets with those values replaced by
+// the path of their file. Cleanup removes the directory, it also runs if
+// RunCommand exits with the command's exit code.
+func WriteFiles(secrets map[string]string) (map[string]string, func(), error) {
+	var dir string
+	cleanup := func() {
+		if dir != "" {
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	result := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		file, ok := env.DecodeFile(value)
+		if !ok {
+			result[key] = value
+			continue
+		}
+		if dir == "" {
+			var err error
+			if dir, err = os.MkdirTemp("", "keyway-files-"); err != nil {
+				return nil, func() {}, err
+			}
+			exitCleanups = append(exitCleanups, cleanup)
+		}
+		// One directory per key keeps the original file name without collisions
+		keyDir := filepath.Join(dir, key)
+		if err := os.Mkdir(keyDir, 0700); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		name := filepath.Base(file.Name)
+		if name == "." || name == string(filepath.Separator) {
+			name = key
+		}
+		path := filepath.Join(keyDir, name)
+		if err := os.WriteFile(path, file.Data, 0600); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		result[key] = path
+	}
+	return result, cleanup, nil
+}
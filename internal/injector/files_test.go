@@ -0,0 +1,53 @@
+package injector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keywaysh/cli/internal/env"
+)
+
+func TestWriteFiles(t *testing.T) {
+	encoded, err := env.EncodeFile("gcp-sa.json", []byte(`{"type":"service_account"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secrets := map[string]string{"API_KEY": "secret", "GCP_SA_JSON": encoded}
+
+	result, cleanup, err := WriteFiles(secrets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result["API_KEY"] != "secret" {
+		t.Errorf("expected plain secrets unchanged, got %q", result["API_KEY"])
+	}
+	path := result["GCP_SA_JSON"]
+	if filepath.Base(path) != "gcp-sa.json" {
+		t.Errorf("expected the original file name, got %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != `{"type":"service_account"}` {
+		t.Errorf("unexpected file content %q (%v)", data, err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the file to be removed by cleanup")
+	}
+}
+
+func TestWriteFiles_NoFiles(t *testing.T) {
+	result, cleanup, err := WriteFiles(map[string]string{"API_KEY": "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+	if len(result) != 1 || result["API_KEY"] != "secret" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
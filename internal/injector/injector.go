@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 )
 
@@ -13,6 +14,23 @@ import (
 // RunCommand executes a command with the provided secrets injected into the environment.
 // It handles signal forwarding and exit code propagation.
 func RunCommand(command string, args []string, secrets map[string]string) error {
+	p, err := StartCommand(command, args, secrets)
+	if err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Process is a command started by StartCommand
+type Process struct {
+	cmd     *exec.Cmd
+	sigs    chan os.Signal
+	stopped atomic.Bool
+}
+
+// StartCommand starts a command with the provided secrets injected into the
+// environment, and forwards the signals keyway receives to it until Wait returns.
+func StartCommand(command string, args []string, secrets map[string]string) (*Process, error) {
 	// Prepare the command
 	cmd := exec.Command(command, args...)
 
@@ -39,7 +57,8 @@ func RunCommand(command string, args []string, secrets map[string]string) error
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start command: %w", err)
+		signal.Stop(sigs)
+		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
 	// Forward signals to the child process
@@ -51,17 +70,52 @@ func RunCommand(command string, args []string, secrets map[string]string) error
 		}
 	}()
 
+	return &Process{cmd: cmd, sigs: sigs}, nil
+}
+
+// Signal sends a signal to the command
+func (p *Process) Signal(sig os.Signal) error {
+	return p.cmd.Process.Signal(sig)
+}
+
+// Stop sends a signal asking the command to exit, e.g. StopSignal. Once
+// stopped, Wait returns when the command exits, whatever its exit code.
+func (p *Process) Stop(sig os.Signal) error {
+	p.stopped.Store(true)
+	return p.cmd.Process.Signal(sig)
+}
+
+// Wait waits for the command to finish. If it fails with an exit code, keyway
+// exits with the same code, unless the command was stopped.
+func (p *Process) Wait() error {
 	// Wait for the command to finish
-	err := cmd.Wait()
+	err := p.cmd.Wait()
+	signal.Stop(p.sigs)
+	close(p.sigs)
+	if p.stopped.Load() {
+		return nil
+	}
 
 	// Handle exit code
 	if exitError, ok := err.(*exec.ExitError); ok {
 		// The process exited with a non-zero status
 		if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-			os.Exit(status.ExitStatus())
+			exit(status.ExitStatus())
 		}
-		os.Exit(1)
+		exit(1)
 	}
 
 	return err
 }
+
+// exitCleanups run before RunCommand exits with the command's exit code,
+// since deferred calls don't run on os.Exit
+var exitCleanups []func()
+
+// exit runs the exit cleanups and exits the process
+func exit(code int) {
+	for _, fn := range exitCleanups {
+		fn()
+	}
+	os.Exit(code)
+}
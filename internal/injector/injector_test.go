@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -193,3 +194,20 @@ func TestRunCommand_NonexistentCommand(t *testing.T) {
 		t.Error("Expected error for non-existent command")
 	}
 }
+
+func TestProcess_Stop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on Windows")
+	}
+	p, err := StartCommand("sleep", []string{"30"}, nil)
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	if err := p.Stop(StopSignal); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	// Killed by a signal, but stopped: Wait must return instead of exiting
+	if err := p.Wait(); err != nil {
+		t.Errorf("expected no error once stopped, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package injector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables given to commands run with reload enabled. This is the
+// contract with frameworks supporting config reload: when the secrets change,
+// the reload file is replaced atomically, then the reload signal is sent.
+const (
+	// ReloadFileEnv holds the path of the reload file
+	ReloadFileEnv = "KEYWAY_RELOAD_FILE"
+	// ReloadSignalEnv holds the name of the signal sent after a reload, e.g. SIGHUP
+	ReloadSignalEnv = "KEYWAY_RELOAD_SIGNAL"
+)
+
+// ReloadState is the content of the reload file
+type ReloadState struct {
+	// Version starts at 1 and is incremented on every reload, so a command can
+	// tell whether the file changed since it last read it
+	Version int               `json:"version"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// ReloadFile is the file through which a running command receives new secrets
+type ReloadFile struct {
+	dir     string
+	version int
+}
+
+// NewReloadFile creates the reload file in a private temporary directory and
+// writes the first version of the secrets to it. Close removes it, it also runs
+// if RunCommand exits with the command's exit code.
+func NewReloadFile(secrets map[string]string) (*ReloadFile, error) {
+	dir, err := os.MkdirTemp("", "keyway-reload-")
+	if err != nil {
+		return nil, err
+	}
+	r := &ReloadFile{dir: dir}
+	exitCleanups = append(exitCleanups, r.Close)
+	if err := r.Write(secrets); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path returns the path of the reload file
+func (r *ReloadFile) Path() string {
+	return filepath.Join(r.dir, "secrets.json")
+}
+
+// Write replaces the reload file with a new version of the secrets. The file is
+// renamed into place, so readers never see a partial write.
+func (r *ReloadFile) Write(secrets map[string]string) error {
+	data, err := json.Marshal(ReloadState{Version: r.version + 1, Secrets: secrets})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(r.dir, "secrets-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), r.Path()); err != nil {
+		return err
+	}
+	r.version++
+	return nil
+}
+
+// Version returns the version last written
+func (r *ReloadFile) Version() int {
+	return r.version
+}
+
+// Close removes the reload file
+func (r *ReloadFile) Close() {
+	_ = os.RemoveAll(r.dir)
+}
+
+// ParseReloadSignal returns the signal named name, with or without the SIG
+// prefix. Only SIGHUP, SIGUSR1 and SIGUSR2 are accepted, and none on Windows.
+func ParseReloadSignal(name string) (os.Signal, string, error) {
+	canonical := strings.ToUpper(name)
+	if !strings.HasPrefix(canonical, "SIG") {
+		canonical = "SIG" + canonical
+	}
+	sig, ok := reloadSignals[canonical]
+	if !ok {
+		if len(reloadSignals) == 0 {
+			return nil, "", fmt.Errorf("reload signals are not supported on this platform")
+		}
+		return nil, "", fmt.Errorf("unsupported reload signal %q, use SIGHUP, SIGUSR1 or SIGUSR2", name)
+	}
+	return sig, canonical, nil
+}
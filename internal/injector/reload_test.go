@@ -0,0 +1,65 @@
+package injector
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestReloadFile(t *testing.T) {
+	r, err := NewReloadFile(map[string]string{"API_KEY": "old"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	read := func() ReloadState {
+		t.Helper()
+		data, err := os.ReadFile(r.Path())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var state ReloadState
+		if err := json.Unmarshal(data, &state); err != nil {
+			t.Fatalf("invalid reload file: %v", err)
+		}
+		return state
+	}
+
+	if state := read(); state.Version != 1 || state.Secrets["API_KEY"] != "old" {
+		t.Errorf("unexpected first version %+v", state)
+	}
+	if info, err := os.Stat(r.Path()); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+
+	if err := r.Write(map[string]string{"API_KEY": "new"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state := read(); state.Version != 2 || state.Secrets["API_KEY"] != "new" || r.Version() != 2 {
+		t.Errorf("unexpected second version %+v", state)
+	}
+
+	r.Close()
+	if _, err := os.Stat(r.Path()); !os.IsNotExist(err) {
+		t.Error("expected the reload file to be removed by Close")
+	}
+}
+
+func TestParseReloadSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("reload signals are not supported on Windows")
+	}
+	for _, name := range []string{"SIGHUP", "hup", "SIGUSR1", "usr2"} {
+		if _, _, err := ParseReloadSignal(name); err != nil {
+			t.Errorf("ParseReloadSignal(%q) returned %v", name, err)
+		}
+	}
+	if _, name, _ := ParseReloadSignal("hup"); name != "SIGHUP" {
+		t.Errorf("expected canonical name SIGHUP, got %q", name)
+	}
+	if _, _, err := ParseReloadSignal("SIGKILL"); err == nil {
+		t.Error("expected SIGKILL to be rejected")
+	}
+}
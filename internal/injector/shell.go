@@ -0,0 +1,155 @@
+package injector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ShellEnvVar is set to the environment name inside a keyway shell, so that
+// prompts can show it and nested shells can be detected.
+const ShellEnvVar = "KEYWAY_SHELL"
+
+// Shell describes how to start an interactive subshell with a keyway prompt
+type Shell struct {
+	Command string
+	Args    []string
+	Env     map[string]string // extra variables, applied after the secrets
+	dir     string            // temporary startup files, if any
+}
+
+// Cleanup removes the temporary startup files of the shell.
+// Startup files also delete themselves once sourced, so that nothing is left
+// behind when the shell exits in a way that skips Cleanup.
+func (s *Shell) Cleanup() {
+	if s.dir != "" {
+		_ = os.RemoveAll(s.dir)
+	}
+}
+
+// DefaultShell returns the user's login shell
+func DefaultShell() string {
+	if runtime.GOOS == "windows" {
+		if comspec := os.Getenv("COMSPEC"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// PrepareShell builds the command starting shell with its prompt prefixed by
+// "(keyway:<envName>)". Startup files of the user are still loaded.
+func PrepareShell(shell, envName string) (*Shell, error) {
+	prefix := fmt.Sprintf("(keyway:%s) ", envName)
+	s := &Shell{
+		Command: shell,
+		Env:     map[string]string{ShellEnvVar: envName},
+	}
+
+	switch ShellName(shell) {
+	case "bash":
+		dir, err := os.MkdirTemp("", "keyway-shell-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare shell: %w", err)
+		}
+		s.dir = dir
+		rcFile := filepath.Join(dir, "bashrc")
+		rc := fmt.Sprintf(`rm -rf %s
+[ -f "$HOME/.bashrc" ] && . "$HOME/.bashrc"
+PS1=%s"$PS1"
+`, shellQuote(dir), shellQuote(prefix))
+		if err := os.WriteFile(rcFile, []byte(rc), 0600); err != nil {
+			s.Cleanup()
+			return nil, fmt.Errorf("failed to prepare shell: %w", err)
+		}
+		s.Args = []string{"--rcfile", rcFile, "-i"}
+
+	case "zsh":
+		// zsh has no --rcfile, point ZDOTDIR at startup files that chain to the user's
+		dir, err := os.MkdirTemp("", "keyway-shell-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare shell: %w", err)
+		}
+		s.dir = dir
+		userDir := os.Getenv("ZDOTDIR")
+		if userDir == "" {
+			userDir, _ = os.UserHomeDir()
+		}
+		// The user's .zshenv may itself move ZDOTDIR, remember where it points
+		zshenv := fmt.Sprintf(`ZDOTDIR=%s
+[ -f "$ZDOTDIR/.zshenv" ] && . "$ZDOTDIR/.zshenv"
+__keyway_zdotdir=$ZDOTDIR
+ZDOTDIR=%s
+`, shellQuote(userDir), shellQuote(dir))
+		zshrc := fmt.Sprintf(`ZDOTDIR=$__keyway_zdotdir
+unset __keyway_zdotdir
+rm -rf %s
+[ -f "$ZDOTDIR/.zshrc" ] && . "$ZDOTDIR/.zshrc"
+PROMPT=%s"$PROMPT"
+`, shellQuote(dir), shellQuote(prefix))
+		if err := os.WriteFile(filepath.Join(dir, ".zshenv"), []byte(zshenv), 0600); err != nil {
+			s.Cleanup()
+			return nil, fmt.Errorf("failed to prepare shell: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".zshrc"), []byte(zshrc), 0600); err != nil {
+			s.Cleanup()
+			return nil, fmt.Errorf("failed to prepare shell: %w", err)
+		}
+		s.Env["ZDOTDIR"] = dir
+		s.Args = []string{"-i"}
+
+	case "fish":
+		s.Args = []string{"-i", "--init-command", fmt.Sprintf(
+			"functions -c fish_prompt __keyway_prompt; function fish_prompt; echo -n %s; __keyway_prompt; end",
+			shellQuote(prefix))}
+
+	case "cmd":
+		s.Env["PROMPT"] = prefix + "$P$G"
+
+	case "powershell", "pwsh":
+		s.Args = []string{"-NoExit", "-Command", fmt.Sprintf(
+			"function global:prompt { %s + \"PS $($executionContext.SessionState.Path.CurrentLocation)> \" }",
+			powershellQuote(prefix))}
+
+	default:
+		// POSIX sh, dash, ksh... read PS1 from the environment
+		s.Env["PS1"] = prefix + "$ "
+		s.Args = []string{"-i"}
+	}
+
+	return s, nil
+}
+
+// ShellName returns the name of a shell from its path, e.g. "bash" for
+// /bin/bash or "pwsh" for C:\Program Files\PowerShell\7\pwsh.exe
+func ShellName(shell string) string {
+	// Windows paths may be given on any platform, e.g. from $COMSPEC
+	name := shell[strings.LastIndexAny(shell, `/\`)+1:]
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
+
+// shellQuote quotes s for POSIX shells (and fish) using single quotes
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powershellQuote quotes s as a PowerShell single-quoted string. PowerShell
+// also ends such a string on the typographic quotes ‘ ’ ‚ ‛, which are doubled
+// like ' to stay literal.
+func powershellQuote(s string) string {
+	return "'" + powershellQuoteReplacer.Replace(s) + "'"
+}
+
+var powershellQuoteReplacer = strings.NewReplacer(
+	"'", "''",
+	"\u2018", "\u2018\u2018",
+	"\u2019", "\u2019\u2019",
+	"\u201A", "\u201A\u201A",
+	"\u201B", "\u201B\u201B",
+)
@@ -0,0 +1,114 @@
+package injector
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepareShell_Bash(t *testing.T) {
+	s, err := PrepareShell("/bin/bash", "staging")
+	if err != nil {
+		t.Fatalf("PrepareShell failed: %v", err)
+	}
+	defer s.Cleanup()
+
+	if s.Env[ShellEnvVar] != "staging" {
+		t.Errorf("expected %s=staging, got %q", ShellEnvVar, s.Env[ShellEnvVar])
+	}
+	if len(s.Args) < 2 || s.Args[0] != "--rcfile" {
+		t.Fatalf("expected --rcfile, got %v", s.Args)
+	}
+	rc, err := os.ReadFile(s.Args[1])
+	if err != nil {
+		t.Fatalf("rcfile not written: %v", err)
+	}
+	if !strings.Contains(string(rc), `PS1='(keyway:staging) '"$PS1"`) {
+		t.Errorf("expected prompt prefix in rcfile, got:\n%s", rc)
+	}
+
+	s.Cleanup()
+	if _, err := os.Stat(filepath.Dir(s.Args[1])); !os.IsNotExist(err) {
+		t.Error("expected Cleanup to remove the startup files")
+	}
+}
+
+func TestPrepareShell_BashRemovesStartupFiles(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := PrepareShell(bash, "staging")
+	if err != nil {
+		t.Fatalf("PrepareShell failed: %v", err)
+	}
+	out, err := exec.Command(s.Command, append(s.Args, "-c", `printf '%s' "$PS1"`)...).Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "(keyway:staging) ") {
+		t.Errorf("expected prompt prefix, got %q", out)
+	}
+	if _, err := os.Stat(s.dir); !os.IsNotExist(err) {
+		t.Error("expected startup files to delete themselves once sourced")
+	}
+}
+
+func TestPrepareShell_Zsh(t *testing.T) {
+	t.Setenv("ZDOTDIR", "/home/me/.config/zsh")
+
+	s, err := PrepareShell("/usr/bin/zsh", "production")
+	if err != nil {
+		t.Fatalf("PrepareShell failed: %v", err)
+	}
+	defer s.Cleanup()
+
+	if s.Env["ZDOTDIR"] == "" || s.Env["ZDOTDIR"] != s.dir {
+		t.Fatalf("expected ZDOTDIR to point at the startup files, got %q", s.Env["ZDOTDIR"])
+	}
+	zshenv, _ := os.ReadFile(filepath.Join(s.dir, ".zshenv"))
+	if !strings.Contains(string(zshenv), "ZDOTDIR='/home/me/.config/zsh'") {
+		t.Errorf("expected user ZDOTDIR to be restored, got:\n%s", zshenv)
+	}
+	zshrc, _ := os.ReadFile(filepath.Join(s.dir, ".zshrc"))
+	if !strings.Contains(string(zshrc), `PROMPT='(keyway:production) '"$PROMPT"`) {
+		t.Errorf("expected prompt prefix in .zshrc, got:\n%s", zshrc)
+	}
+}
+
+func TestPrepareShell_Others(t *testing.T) {
+	tests := []struct {
+		shell   string
+		wantEnv string
+		wantArg string
+	}{
+		{"/bin/sh", "PS1", ""},
+		{"/usr/bin/fish", "", "fish_prompt"},
+		{`C:\Windows\System32\cmd.exe`, "PROMPT", ""},
+		{"pwsh", "", "function global:prompt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			s, err := PrepareShell(tt.shell, "dev")
+			if err != nil {
+				t.Fatalf("PrepareShell failed: %v", err)
+			}
+			defer s.Cleanup()
+
+			if s.dir != "" {
+				t.Errorf("expected no startup files, got %s", s.dir)
+			}
+			if tt.wantEnv != "" && !strings.HasPrefix(s.Env[tt.wantEnv], "(keyway:dev) ") {
+				t.Errorf("expected %s prompt prefix, got %q", tt.wantEnv, s.Env[tt.wantEnv])
+			}
+			if tt.wantArg != "" && !strings.Contains(strings.Join(s.Args, " "), tt.wantArg) {
+				t.Errorf("expected args to contain %q, got %v", tt.wantArg, s.Args)
+			}
+		})
+	}
+}
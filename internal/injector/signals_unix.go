@@ -8,3 +8,13 @@ import (
 )
 
 var signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// reloadSignals are the signals keyway run can send to ask a command to reload
+var reloadSignals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// StopSignal asks a command to exit gracefully before keyway run restarts it
+var StopSignal os.Signal = syscall.SIGTERM
@@ -12,3 +12,9 @@ import (
 // SIGTERM to child processes via Process.Signal is not supported on Windows —
 // the attempt will fail silently.
 var signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// reloadSignals is empty: Windows has no signal a command could reload on
+var reloadSignals = map[string]os.Signal{}
+
+// StopSignal kills the command: Windows cannot deliver SIGTERM to a child process
+var StopSignal os.Signal = os.Kill
@@ -0,0 +1,190 @@
+// Package jsonrpc implements a minimal JSON-RPC 2.0 server over a byte stream,
+// using the Content-Length framing of the Language Server Protocol.
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Standard JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// MaxMessageSize is the largest message body ReadMessage accepts, so that a
+// peer cannot make it allocate any amount of memory
+const MaxMessageSize = 10 << 20
+
+// ErrExit is returned by a handler to stop the server after replying
+var ErrExit = errors.New("exit")
+
+// Error is a JSON-RPC error object. Handlers may return it to control the code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// HandlerFunc handles a single method call. The returned value is sent as the result.
+type HandlerFunc func(params json.RawMessage) (interface{}, error)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Server dispatches framed JSON-RPC requests to registered handlers
+type Server struct {
+	handlers map[string]HandlerFunc
+	mu       sync.Mutex
+}
+
+// NewServer creates an empty server
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers a handler for a method
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.handlers[method] = fn
+}
+
+// Serve reads requests from r and writes responses to w until r is closed
+// or a handler returns ErrExit. Requests are processed in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		body, err := ReadMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(w, response{ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+
+		result, err := s.dispatch(req)
+		exit := errors.Is(err, ErrExit)
+		if exit {
+			err = nil
+		}
+
+		// Notifications (no id) never get a response
+		if len(req.ID) > 0 {
+			resp := response{ID: req.ID, Result: result}
+			if err != nil {
+				var rpcErr *Error
+				if !errors.As(err, &rpcErr) {
+					rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+				}
+				resp.Error = rpcErr
+				resp.Result = nil
+			} else if result == nil {
+				resp.Result = json.RawMessage("null")
+			}
+			if writeErr := s.reply(w, resp); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		if exit {
+			return nil
+		}
+	}
+}
+
+func (s *Server) dispatch(req request) (interface{}, error) {
+	if req.Method == "" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "missing method"}
+	}
+	fn, ok := s.handlers[req.Method]
+	if !ok {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+	return fn(req.Params)
+}
+
+func (s *Server) reply(w io.Writer, resp response) error {
+	resp.JSONRPC = "2.0"
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return WriteMessage(w, body)
+}
+
+// ReadMessage reads one Content-Length framed message
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || (errors.Is(err, io.ErrUnexpectedEOF) && len(headers) == 0) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header")
+	}
+	if length > MaxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the limit of %d", length, MaxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// WriteMessage writes one Content-Length framed message
+func WriteMessage(w io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// Unmarshal decodes params into v, returning an InvalidParams error on failure
+func Unmarshal(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func frame(body string) string {
+	var buf bytes.Buffer
+	WriteMessage(&buf, []byte(body))
+	return buf.String()
+}
+
+func readResponses(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var responses []map[string]interface{}
+	r := bufio.NewReader(out)
+	for {
+		body, err := ReadMessage(r)
+		if err != nil {
+			break
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf("invalid response JSON: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServer_Dispatch(t *testing.T) {
+	s := NewServer()
+	s.Handle("echo", func(params json.RawMessage) (interface{}, error) {
+		var p struct{ Text string }
+		if err := Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return p.Text, nil
+	})
+	s.Handle("fail", func(params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	in := frame(`{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`) +
+		frame(`{"jsonrpc":"2.0","id":2,"method":"fail"}`) +
+		frame(`{"jsonrpc":"2.0","id":3,"method":"missing"}`) +
+		frame(`{"jsonrpc":"2.0","method":"echo","params":{"text":"notification"}}`)
+
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	responses := readResponses(t, &out)
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses (no reply to notifications), got %d", len(responses))
+	}
+	if responses[0]["result"] != "hi" {
+		t.Errorf("expected echo result, got %v", responses[0])
+	}
+	if e := responses[1]["error"].(map[string]interface{}); e["code"].(float64) != CodeInternalError || e["message"] != "boom" {
+		t.Errorf("unexpected error response: %v", e)
+	}
+	if e := responses[2]["error"].(map[string]interface{}); e["code"].(float64) != CodeMethodNotFound {
+		t.Errorf("expected method not found, got %v", e)
+	}
+}
+
+func TestServer_Exit(t *testing.T) {
+	s := NewServer()
+	s.Handle("exit", func(params json.RawMessage) (interface{}, error) {
+		return nil, ErrExit
+	})
+	s.Handle("ping", func(params json.RawMessage) (interface{}, error) {
+		return "pong", nil
+	})
+
+	in := frame(`{"jsonrpc":"2.0","id":1,"method":"exit"}`) +
+		frame(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	responses := readResponses(t, &out)
+	if len(responses) != 1 {
+		t.Fatalf("expected server to stop after exit, got %d responses", len(responses))
+	}
+	if _, ok := responses[0]["result"]; !ok {
+		t.Errorf("expected null result for exit, got %v", responses[0])
+	}
+}
+
+func TestServer_ParseError(t *testing.T) {
+	s := NewServer()
+
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(frame(`{not json`)), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	responses := readResponses(t, &out)
+	if len(responses) != 1 {
+		t.Fatalf("expected 1 response, got %d", len(responses))
+	}
+	if e := responses[0]["error"].(map[string]interface{}); e["code"].(float64) != CodeParseError {
+		t.Errorf("expected parse error, got %v", e)
+	}
+}
+
+func TestReadMessage_InvalidLength(t *testing.T) {
+	for _, length := range []string{"abc", "-1", "104857601"} {
+		r := bufio.NewReader(strings.NewReader("Content-Length: " + length + "\r\n\r\n{}"))
+		if _, err := ReadMessage(r); err == nil {
+			t.Errorf("expected error for Content-Length %s", length)
+		}
+	}
+}
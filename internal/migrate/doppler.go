@@ -0,0 +1,205 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DopplerFile is the project mapping a Doppler repository commits
+const DopplerFile = "doppler.yaml"
+
+// DopplerSetup maps a directory of the repository to a Doppler project and config
+type DopplerSetup struct {
+	Project string `yaml:"project"`
+	Config  string `yaml:"config"`
+	Path    string `yaml:"path"`
+}
+
+// ParseDopplerSetup parses a doppler.yaml, whose setup is a single mapping or
+// a list of them (one per directory in a monorepo)
+func ParseDopplerSetup(data []byte) ([]DopplerSetup, error) {
+	var file struct {
+		Setup yaml.Node `yaml:"setup"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", DopplerFile, err)
+	}
+
+	var setups []DopplerSetup
+	switch file.Setup.Kind {
+	case yaml.MappingNode:
+		var setup DopplerSetup
+		if err := file.Setup.Decode(&setup); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", DopplerFile, err)
+		}
+		setups = append(setups, setup)
+	case yaml.SequenceNode:
+		if err := file.Setup.Decode(&setups); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", DopplerFile, err)
+		}
+	}
+	for _, setup := range setups {
+		if setup.Project == "" {
+			return nil, fmt.Errorf("invalid %s: setup without a project", DopplerFile)
+		}
+	}
+	if len(setups) == 0 {
+		return nil, fmt.Errorf("invalid %s: no setup", DopplerFile)
+	}
+	return setups, nil
+}
+
+// dopplerUserConfig is the part of ~/.doppler/.doppler.yaml keyway reads:
+// settings scoped by directory, the longest matching scope winning
+type dopplerUserConfig struct {
+	Scoped map[string]struct {
+		Token   string `yaml:"token"`
+		Project string `yaml:"enclave.project"`
+		Config  string `yaml:"enclave.config"`
+	} `yaml:"scoped"`
+}
+
+// DopplerLogin returns the token to read Doppler with, from DOPPLER_TOKEN or
+// the login of the Doppler CLI for dir, and the project the Doppler CLI was
+// set up with there, if any
+func DopplerLogin(dir string) (token, project string) {
+	envToken := os.Getenv("DOPPLER_TOKEN")
+	token = envToken
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return token, ""
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".doppler", ".doppler.yaml"))
+	if err != nil {
+		return token, ""
+	}
+	var cfg dopplerUserConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return token, ""
+	}
+
+	tokenScope, projectScope := -1, -1
+	for scope, settings := range cfg.Scoped {
+		if !inScope(dir, scope) {
+			continue
+		}
+		if settings.Token != "" && len(scope) > tokenScope && envToken == "" {
+			token, tokenScope = settings.Token, len(scope)
+		}
+		if settings.Project != "" && len(scope) > projectScope {
+			project, projectScope = settings.Project, len(scope)
+		}
+	}
+	return token, project
+}
+
+// inScope returns true if dir is scope or one of its subdirectories
+func inScope(dir, scope string) bool {
+	scope = filepath.Clean(scope)
+	if scope == string(filepath.Separator) {
+		return true
+	}
+	return dir == scope || strings.HasPrefix(dir, scope+string(filepath.Separator))
+}
+
+// DopplerClient reads the configs and secrets of Doppler projects
+type DopplerClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewDopplerClient creates a client authenticated with a Doppler token
+func NewDopplerClient(token string) *DopplerClient {
+	return &DopplerClient{
+		baseURL:    config.GetDopplerAPIURL(),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ReadProject returns the root configs of a Doppler project, one per
+// environment (branch configs only override them), with their secrets
+func (c *DopplerClient) ReadProject(ctx context.Context, project string) ([]Environment, error) {
+	var resp struct {
+		Configs []struct {
+			Name        string `json:"name"`
+			Environment string `json:"environment"`
+			Root        bool   `json:"root"`
+		} `json:"configs"`
+	}
+	query := url.Values{"project": {project}, "per_page": {"100"}}
+	if err := c.do(ctx, "/v3/configs?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	var envs []Environment
+	for _, cfg := range resp.Configs {
+		if !cfg.Root {
+			continue
+		}
+		secrets := make(map[string]string)
+		query := url.Values{"project": {project}, "config": {cfg.Name}, "format": {"json"}}
+		if err := c.do(ctx, "/v3/configs/config/secrets/download?"+query.Encode(), &secrets); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", project, cfg.Name, err)
+		}
+		// Doppler adds these to every config
+		for key := range secrets {
+			if strings.HasPrefix(key, "DOPPLER_") {
+				delete(secrets, key)
+			}
+		}
+		envs = append(envs, Environment{
+			Name:    KeywayEnvName(cfg.Environment),
+			Source:  project + "/" + cfg.Name,
+			Secrets: secrets,
+		})
+	}
+	sortEnvironments(envs)
+	return envs, nil
+}
+
+// do sends a GET request to the Doppler API and decodes the JSON response
+func (c *DopplerClient) do(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", "keyway-cli")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Messages []string `json:"messages"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		if len(apiErr.Messages) > 0 {
+			return fmt.Errorf("Doppler API error (%d): %s", resp.StatusCode, strings.Join(apiErr.Messages, ", "))
+		}
+		return fmt.Errorf("Doppler API error (%d)", resp.StatusCode)
+	}
+	return json.Unmarshal(body, result)
+}
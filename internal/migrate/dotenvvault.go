@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/keywaysh/cli/internal/env"
+)
+
+// Files of a dotenv-vault project
+const (
+	DotenvVaultFile = ".env.vault" // encrypted environments, committed
+	DotenvKeysFile  = ".env.keys"  // decryption keys, one per environment
+	DotenvMeFile    = ".env.me"    // login to dotenv.org
+)
+
+// dotenv-vault variable prefixes, followed by the environment name in capitals
+const (
+	dotenvVaultPrefix = "DOTENV_VAULT_"
+	dotenvKeyPrefix   = "DOTENV_KEY_"
+)
+
+// ReadDotenvVault decrypts the environments of a .env.vault file with the keys
+// of a .env.keys file. Environments without a key are returned as skipped.
+func ReadDotenvVault(vault, keys []byte) (envs []Environment, skipped []string, err error) {
+	vaults := env.Parse(string(vault))
+	keyURIs := env.Parse(string(keys))
+
+	for name, ciphertext := range vaults {
+		envName, ok := strings.CutPrefix(name, dotenvVaultPrefix)
+		// DOTENV_VAULT_<ENV>_VERSION and the like are not environments
+		if !ok || strings.Contains(envName, "_") {
+			continue
+		}
+		uri, ok := keyURIs[dotenvKeyPrefix+envName]
+		if !ok {
+			skipped = append(skipped, strings.ToLower(envName))
+			continue
+		}
+		key, err := dotenvKey(uri)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s%s: %w", dotenvKeyPrefix, envName, err)
+		}
+		plaintext, err := decryptDotenvVault(ciphertext, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s%s: %w", dotenvVaultPrefix, envName, err)
+		}
+		envs = append(envs, Environment{
+			Name:    KeywayEnvName(envName),
+			Source:  strings.ToLower(envName),
+			Secrets: env.Parse(plaintext),
+		})
+	}
+	sortEnvironments(envs)
+	sort.Strings(skipped)
+	return envs, skipped, nil
+}
+
+// dotenvKey returns the AES key of a DOTENV_KEY, a URI like
+// dotenv://:key_<64 hex>@dotenv.org/vault/.env.vault?environment=production
+func dotenvKey(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return nil, fmt.Errorf("not a DOTENV_KEY")
+	}
+	password, _ := u.User.Password()
+	if len(password) < 64 {
+		return nil, fmt.Errorf("not a DOTENV_KEY")
+	}
+	key, err := hex.DecodeString(password[len(password)-64:])
+	if err != nil {
+		return nil, fmt.Errorf("not a DOTENV_KEY")
+	}
+	return key, nil
+}
+
+// decryptDotenvVault decrypts an environment of a .env.vault: base64 of a
+// 12-byte nonce followed by the AES-256-GCM ciphertext and tag
+func decryptDotenvVault(ciphertext string, key []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("not base64")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return "", fmt.Errorf("too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt, the key does not match")
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,38 @@
+// Package migrate reads the environments of other secret managers (Doppler,
+// dotenv-vault) from their project files, to move them into a Keyway vault.
+package migrate
+
+import (
+	"sort"
+	"strings"
+)
+
+// Environment is an environment of another tool, with the name it gets in Keyway
+type Environment struct {
+	Name    string            // Keyway environment name
+	Source  string            // where it comes from, e.g. "backend/prd"
+	Secrets map[string]string // keys and values
+}
+
+// keywayEnvNames maps the usual short environment names of other tools to the
+// Keyway ones
+var keywayEnvNames = map[string]string{
+	"dev":  "development",
+	"stg":  "staging",
+	"prd":  "production",
+	"prod": "production",
+}
+
+// KeywayEnvName returns the Keyway name of an environment of another tool
+func KeywayEnvName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if mapped, ok := keywayEnvNames[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// sortEnvironments sorts environments by Keyway name
+func sortEnvironments(envs []Environment) {
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+}
@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sealDotenvVault encrypts env content the way dotenv-vault does
+func sealDotenvVault(t *testing.T, content string, key []byte) string {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(content), nil))
+}
+
+func TestReadDotenvVault(t *testing.T) {
+	devKey, prodKey := make([]byte, 32), make([]byte, 32)
+	rand.Read(devKey)
+	rand.Read(prodKey)
+
+	vault := "#/-------------------.env.vault---------------------/\n" +
+		"DOTENV_VAULT_DEVELOPMENT=\"" + sealDotenvVault(t, "API_KEY=dev_123\nDEBUG=true\n", devKey) + "\"\n" +
+		"DOTENV_VAULT_PRODUCTION=\"" + sealDotenvVault(t, "API_KEY=live_456\n", prodKey) + "\"\n" +
+		"DOTENV_VAULT_CI=\"" + sealDotenvVault(t, "API_KEY=ci\n", prodKey) + "\"\n"
+	keys := "DOTENV_KEY_DEVELOPMENT=\"dotenv://:key_" + hex.EncodeToString(devKey) + "@dotenv.org/vault/.env.vault?environment=development\"\n" +
+		"DOTENV_KEY_PRODUCTION=\"dotenv://:key_" + hex.EncodeToString(prodKey) + "@dotenv.org/vault/.env.vault?environment=production\"\n"
+
+	envs, skipped, err := ReadDotenvVault([]byte(vault), []byte(keys))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 2 || envs[0].Name != "development" || envs[1].Name != "production" {
+		t.Fatalf("unexpected environments: %+v", envs)
+	}
+	if !reflect.DeepEqual(envs[0].Secrets, map[string]string{"API_KEY": "dev_123", "DEBUG": "true"}) {
+		t.Errorf("unexpected development secrets: %v", envs[0].Secrets)
+	}
+	if envs[1].Secrets["API_KEY"] != "live_456" {
+		t.Errorf("unexpected production secrets: %v", envs[1].Secrets)
+	}
+	if !reflect.DeepEqual(skipped, []string{"ci"}) {
+		t.Errorf("expected ci to be skipped without a key, got %v", skipped)
+	}
+}
+
+func TestReadDotenvVault_WrongKey(t *testing.T) {
+	key, other := make([]byte, 32), make([]byte, 32)
+	rand.Read(key)
+	rand.Read(other)
+
+	vault := "DOTENV_VAULT_DEVELOPMENT=\"" + sealDotenvVault(t, "A=1\n", key) + "\"\n"
+	keys := "DOTENV_KEY_DEVELOPMENT=\"dotenv://:key_" + hex.EncodeToString(other) + "@dotenv.org/vault/.env.vault?environment=development\"\n"
+
+	if _, _, err := ReadDotenvVault([]byte(vault), []byte(keys)); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected a decryption error, got %v", err)
+	}
+}
+
+func TestParseDopplerSetup(t *testing.T) {
+	single, err := ParseDopplerSetup([]byte("setup:\n  project: backend\n  config: dev\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(single, []DopplerSetup{{Project: "backend", Config: "dev"}}) {
+		t.Errorf("unexpected setup: %+v", single)
+	}
+
+	list, err := ParseDopplerSetup([]byte("setup:\n  - project: api\n    config: dev\n    path: services/api\n  - project: web\n    path: web\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 2 || list[0].Path != "services/api" || list[1].Project != "web" {
+		t.Errorf("unexpected setups: %+v", list)
+	}
+
+	if _, err := ParseDopplerSetup([]byte("setup:\n  config: dev\n")); err == nil {
+		t.Error("expected an error for a setup without a project")
+	}
+}
+
+func TestDopplerClient_ReadProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dp.st.test" {
+			t.Errorf("unexpected authorization: %s", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v3/configs":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"configs": []map[string]interface{}{
+					{"name": "dev", "environment": "dev", "root": true},
+					{"name": "dev_alice", "environment": "dev", "root": false},
+					{"name": "prd", "environment": "prd", "root": true},
+				},
+			})
+		case "/v3/configs/config/secrets/download":
+			json.NewEncoder(w).Encode(map[string]string{
+				"API_KEY":         "key_" + r.URL.Query().Get("config"),
+				"DOPPLER_PROJECT": "backend",
+			})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("DOPPLER_API_HOST", server.URL)
+
+	envs, err := NewDopplerClient("dp.st.test").ReadProject(context.Background(), "backend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Environment{
+		{Name: "development", Source: "backend/dev", Secrets: map[string]string{"API_KEY": "key_dev"}},
+		{Name: "production", Source: "backend/prd", Secrets: map[string]string{"API_KEY": "key_prd"}},
+	}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("expected %+v, got %+v", want, envs)
+	}
+}
+
+func TestDopplerClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"messages":["Invalid Auth token"],"success":false}`))
+	}))
+	defer server.Close()
+	t.Setenv("DOPPLER_API_HOST", server.URL)
+
+	_, err := NewDopplerClient("bad").ReadProject(context.Background(), "backend")
+	if err == nil || !strings.Contains(err.Error(), "Invalid Auth token") {
+		t.Errorf("expected the Doppler message, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package platform detects the runtime environment the CLI is running in
+// (WSL, devcontainers, Codespaces, headless sessions) so commands can adapt.
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Wrapped for testing
+var (
+	getenv   = os.Getenv
+	readFile = os.ReadFile
+	statFile = os.Stat
+	lookPath = exec.LookPath
+	goos     = runtime.GOOS
+)
+
+// Info describes the detected runtime environment
+type Info struct {
+	WSL          bool
+	Devcontainer bool
+	Codespaces   bool
+	SSH          bool
+}
+
+// Detect inspects the current process environment
+func Detect() Info {
+	return Info{
+		WSL:          IsWSL(),
+		Devcontainer: IsDevcontainer(),
+		Codespaces:   getenv("CODESPACES") == "true",
+		SSH:          getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "",
+	}
+}
+
+// Name returns a human-readable name for the environment, or "" for a plain host
+func (i Info) Name() string {
+	switch {
+	case i.Codespaces:
+		return "GitHub Codespaces"
+	case i.Devcontainer:
+		return "devcontainer"
+	case i.WSL:
+		return "WSL"
+	case i.SSH:
+		return "SSH session"
+	default:
+		return ""
+	}
+}
+
+// IsWSL returns true when running inside Windows Subsystem for Linux
+func IsWSL() bool {
+	if goos != "linux" {
+		return false
+	}
+	if getenv("WSL_DISTRO_NAME") != "" || getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	data, err := readFile("/proc/version")
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
+
+// IsDevcontainer returns true when running inside a VS Code devcontainer or similar
+func IsDevcontainer() bool {
+	if getenv("REMOTE_CONTAINERS") == "true" || getenv("DEVCONTAINER") == "true" || getenv("CODESPACES") == "true" {
+		return true
+	}
+	if getenv("REMOTE_CONTAINERS_IPC") != "" || getenv("VSCODE_REMOTE_CONTAINERS_SESSION") != "" {
+		return true
+	}
+	_, err := statFile("/.dockerenv")
+	return err == nil && getenv("TERM_PROGRAM") == "vscode"
+}
+
+// CanOpenBrowser returns true if a browser can plausibly be launched from here.
+// Devcontainers and Codespaces forward URLs through $BROWSER; WSL needs wslview;
+// plain Linux needs a display.
+func CanOpenBrowser() bool {
+	if getenv("BROWSER") != "" {
+		return true
+	}
+	switch goos {
+	case "darwin", "windows":
+		return getenv("SSH_CONNECTION") == ""
+	}
+	if IsWSL() {
+		_, err := lookPath("wslview")
+		return err == nil
+	}
+	if IsDevcontainer() {
+		return false
+	}
+	return getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != ""
+}
+
+// IsWindowsMount returns true if path lives on a Windows drive mounted into WSL
+// (e.g. /mnt/c/Users/...). Files there ignore Unix permissions, so tokens
+// stored on that side are readable by any process in the distro.
+func IsWindowsMount(path string) bool {
+	if len(path) < 7 || !strings.HasPrefix(path, "/mnt/") {
+		return false
+	}
+	drive := path[5]
+	return ((drive >= 'a' && drive <= 'z') || (drive >= 'A' && drive <= 'Z')) && path[6] == '/'
+}
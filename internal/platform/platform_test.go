@@ -0,0 +1,140 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// withEnv replaces the platform hooks for the duration of a test
+func withEnv(t *testing.T, env map[string]string, procVersion string, osName string) {
+	t.Helper()
+	origGetenv, origRead, origStat, origLook, origGOOS := getenv, readFile, statFile, lookPath, goos
+	t.Cleanup(func() {
+		getenv, readFile, statFile, lookPath, goos = origGetenv, origRead, origStat, origLook, origGOOS
+	})
+
+	getenv = func(key string) string { return env[key] }
+	readFile = func(name string) ([]byte, error) {
+		if name == "/proc/version" && procVersion != "" {
+			return []byte(procVersion), nil
+		}
+		return nil, errors.New("not found")
+	}
+	statFile = func(name string) (os.FileInfo, error) { return nil, errors.New("not found") }
+	lookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	goos = osName
+}
+
+func TestIsWSL(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		procVersion string
+		goos        string
+		expected    bool
+	}{
+		{"distro env var", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, "", "linux", true},
+		{"proc version", nil, "Linux version 5.15.90.1-microsoft-standard-WSL2", "linux", true},
+		{"plain linux", nil, "Linux version 6.1.0-generic", "linux", false},
+		{"macOS", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, "", "darwin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnv(t, tt.env, tt.procVersion, tt.goos)
+			if got := IsWSL(); got != tt.expected {
+				t.Errorf("IsWSL() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsDevcontainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{"remote containers", map[string]string{"REMOTE_CONTAINERS": "true"}, true},
+		{"codespaces", map[string]string{"CODESPACES": "true"}, true},
+		{"devcontainer cli", map[string]string{"DEVCONTAINER": "true"}, true},
+		{"none", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnv(t, tt.env, "", "linux")
+			if got := IsDevcontainer(); got != tt.expected {
+				t.Errorf("IsDevcontainer() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanOpenBrowser(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		procVersion string
+		goos        string
+		expected    bool
+	}{
+		{"BROWSER set in devcontainer", map[string]string{"REMOTE_CONTAINERS": "true", "BROWSER": "/vscode/helper.sh"}, "", "linux", true},
+		{"devcontainer without BROWSER", map[string]string{"REMOTE_CONTAINERS": "true"}, "", "linux", false},
+		{"WSL without wslview", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, "", "linux", false},
+		{"linux with display", map[string]string{"DISPLAY": ":0"}, "", "linux", true},
+		{"headless linux", nil, "", "linux", false},
+		{"macOS", nil, "", "darwin", true},
+		{"macOS over SSH", map[string]string{"SSH_CONNECTION": "1.2.3.4 22 5.6.7.8 22"}, "", "darwin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnv(t, tt.env, tt.procVersion, tt.goos)
+			if got := CanOpenBrowser(); got != tt.expected {
+				t.Errorf("CanOpenBrowser() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsWindowsMount(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/mnt/c/Users/me/AppData/Roaming/keyway-nodejs/Config/config.json", true},
+		{"/mnt/D/keyway/config.json", true},
+		{"/mnt/data/keyway/config.json", false},
+		{"/home/me/.config/keyway-nodejs/config.json", false},
+		{"/mnt/c", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsWindowsMount(tt.path); got != tt.expected {
+				t.Errorf("IsWindowsMount(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInfo_Name(t *testing.T) {
+	tests := []struct {
+		info     Info
+		expected string
+	}{
+		{Info{Codespaces: true, Devcontainer: true}, "GitHub Codespaces"},
+		{Info{Devcontainer: true}, "devcontainer"},
+		{Info{WSL: true}, "WSL"},
+		{Info{SSH: true}, "SSH session"},
+		{Info{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.info.Name(); got != tt.expected {
+			t.Errorf("Name() = %q, want %q", got, tt.expected)
+		}
+	}
+}
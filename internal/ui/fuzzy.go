@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore returns how well query matches s: every character of the query
+// must appear in s in order, ignoring case. Consecutive characters and
+// characters starting a word (API_KEY, apiKey, api-key) score higher.
+func fuzzyScore(query, s string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	runes := []rune(s)
+	score, qi, last := 0, 0, -1
+	for i, r := range runes {
+		if qi == len(q) {
+			break
+		}
+		if unicode.ToLower(r) != q[qi] {
+			continue
+		}
+		score++
+		if last == i-1 {
+			score += 5
+		}
+		if i == 0 || isWordStart(runes[i-1], r) {
+			score += 3
+		}
+		last = i
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// Prefer shorter options among equal matches
+	return score*100 - len(runes), true
+}
+
+// isWordStart returns true if r starts a word after prev
+func isWordStart(prev, r rune) bool {
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev) ||
+		unicode.IsLower(prev) && unicode.IsUpper(r)
+}
+
+// FuzzyFilter returns the options matching query, best matches first. Options
+// matching equally keep their order, and an empty query returns them all.
+func FuzzyFilter(query string, options []string) []string {
+	return indexesOf(options, fuzzyMatches(query, options))
+}
+
+// fuzzyMatches returns the indexes of the options matching query, best first
+func fuzzyMatches(query string, options []string) []int {
+	type match struct{ index, score int }
+	var matches []match
+	for i, option := range options {
+		if score, ok := fuzzyScore(query, option); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	if query != "" {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	}
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
+
+func indexesOf(options []string, indexes []int) []string {
+	result := make([]string, len(indexes))
+	for i, index := range indexes {
+		result[i] = options[index]
+	}
+	return result
+}
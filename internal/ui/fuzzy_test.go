@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyFilter(t *testing.T) {
+	options := []string{"STRIPE_SECRET_KEY", "DATABASE_URL", "STRIPE_KEY", "SENTRY_DSN", "API_KEY"}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", options},
+		{"strkey", []string{"STRIPE_KEY", "STRIPE_SECRET_KEY"}},
+		{"dburl", []string{"DATABASE_URL"}},
+		{"key", []string{"API_KEY", "STRIPE_KEY", "STRIPE_SECRET_KEY"}},
+		{"sdsn", []string{"SENTRY_DSN"}},
+		{"zzz", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := FuzzyFilter(tt.query, options); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("FuzzyFilter(%q) = %v, want %v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestFuzzyScore_WordStarts(t *testing.T) {
+	camel, _ := fuzzyScore("ak", "apiKey")
+	inner, _ := fuzzyScore("ak", "bakery")
+	if camel <= inner {
+		t.Errorf("expected a match on word starts to score higher (%d <= %d)", camel, inner)
+	}
+}
+
+func typeKeys(p *picker, keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		p.Update(msg)
+	}
+}
+
+func TestPicker_Single(t *testing.T) {
+	p := newPicker("Environment:", []string{"development", "staging", "production", "preview"}, false)
+
+	typeKeys(p, "p", "r", "o", "enter")
+
+	if !p.done || !reflect.DeepEqual(p.selected(), []string{"production"}) {
+		t.Errorf("expected production to be picked, got %v", p.selected())
+	}
+}
+
+func TestPicker_MultiKeepsChoicesAcrossFilters(t *testing.T) {
+	p := newPicker("Keys:", []string{"API_KEY", "DATABASE_URL", "STRIPE_KEY", "SENTRY_DSN"}, true)
+
+	typeKeys(p, "d", "b", "tab")
+	for range "db" {
+		p.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	typeKeys(p, "s", "d", "s", "n", "tab", "enter")
+
+	if !p.done || !reflect.DeepEqual(p.selected(), []string{"DATABASE_URL", "SENTRY_DSN"}) {
+		t.Errorf("expected both choices to be kept, got %v", p.selected())
+	}
+}
+
+func TestPicker_Abort(t *testing.T) {
+	p := newPicker("Keys:", []string{"API_KEY"}, true)
+
+	typeKeys(p, "esc")
+
+	if !p.aborted || len(p.selected()) != 0 {
+		t.Errorf("expected the picker to be aborted, got %v", p.selected())
+	}
+}
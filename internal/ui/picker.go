@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// fuzzyMinOptions is the number of options from which Select and MultiSelect
+// let the user type to filter them
+const fuzzyMinOptions = 10
+
+// pickerHeight is the number of options shown at once
+const pickerHeight = 10
+
+// picker is a prompt that filters its options as the user types
+type picker struct {
+	title   string
+	options []string
+	multi   bool
+
+	input   textinput.Model
+	matches []int // indexes of the options matching the input, best first
+	cursor  int   // position in matches
+	offset  int   // first match shown
+	chosen  map[int]bool
+
+	done    bool
+	aborted bool
+}
+
+func newPicker(title string, options []string, multi bool) *picker {
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "type to filter"
+	input.Focus()
+
+	p := &picker{
+		title:   title,
+		options: options,
+		multi:   multi,
+		input:   input,
+		chosen:  make(map[int]bool),
+	}
+	p.filter()
+	return p
+}
+
+func (p *picker) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (p *picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+
+	switch key.String() {
+	case "ctrl+c", "esc":
+		p.aborted = true
+		return p, tea.Quit
+	case "enter":
+		if len(p.matches) == 0 {
+			return p, nil
+		}
+		// Without a choice, enter picks the option under the cursor
+		if !p.multi || len(p.chosen) == 0 {
+			p.chosen = map[int]bool{p.matches[p.cursor]: true}
+		}
+		p.done = true
+		return p, tea.Quit
+	case "up", "ctrl+p", "shift+tab":
+		p.move(-1)
+		return p, nil
+	case "down", "ctrl+n":
+		p.move(1)
+		return p, nil
+	case "tab":
+		if !p.multi {
+			p.move(1)
+		} else if len(p.matches) > 0 {
+			index := p.matches[p.cursor]
+			if p.chosen[index] {
+				delete(p.chosen, index)
+			} else {
+				p.chosen[index] = true
+			}
+			p.move(1)
+		}
+		return p, nil
+	case "ctrl+a":
+		if p.multi {
+			for _, index := range p.matches {
+				p.chosen[index] = true
+			}
+		}
+		return p, nil
+	}
+
+	previous := p.input.Value()
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != previous {
+		p.filter()
+	}
+	return p, cmd
+}
+
+// filter updates the matches from the input and moves back to the best one
+func (p *picker) filter() {
+	p.matches = fuzzyMatches(p.input.Value(), p.options)
+	p.cursor, p.offset = 0, 0
+}
+
+// move moves the cursor by delta matches, wrapping around
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+pickerHeight {
+		p.offset = p.cursor - pickerHeight + 1
+	}
+}
+
+func (p *picker) View() string {
+	if p.done || p.aborted {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", bold.Sprint(p.title), p.input.View())
+	end := min(p.offset+pickerHeight, len(p.matches))
+	for i := p.offset; i < end; i++ {
+		index := p.matches[i]
+		cursor := "  "
+		if i == p.cursor {
+			cursor = cyan.Sprint("> ")
+		}
+		box := ""
+		if p.multi {
+			box = "[ ] "
+			if p.chosen[index] {
+				box = green.Sprint("[x] ")
+			}
+		}
+		option := p.options[index]
+		if i == p.cursor {
+			option = cyan.Sprint(option)
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", cursor, box, option)
+	}
+	if len(p.matches) == 0 {
+		b.WriteString(dim.Sprint("  No match") + "\n")
+	}
+
+	help := fmt.Sprintf("%d/%d · ↑↓ move · enter confirm", len(p.matches), len(p.options))
+	if p.multi {
+		help = fmt.Sprintf("%d/%d · %d selected · ↑↓ move · tab select · ctrl+a select all · enter confirm", len(p.matches), len(p.options), len(p.chosen))
+	}
+	b.WriteString(dim.Sprint(help) + "\n")
+	return b.String()
+}
+
+// selected returns the chosen options, in their original order
+func (p *picker) selected() []string {
+	var result []string
+	for i, option := range p.options {
+		if p.chosen[i] {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// run shows the picker and returns the chosen options
+func (p *picker) run() ([]string, error) {
+	if _, err := tea.NewProgram(p).Run(); err != nil {
+		return nil, err
+	}
+	if p.aborted {
+		return nil, huh.ErrUserAborted
+	}
+	return p.selected(), nil
+}
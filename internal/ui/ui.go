@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/charmbracelet/huh"
@@ -18,44 +20,125 @@ var (
 	bold   = color.New(color.Bold)
 )
 
+// accessible is the accessibility mode for screen readers: no spinners or
+// redrawn prompts, state changes printed as lines of their own, and words next
+// to the colors and symbols
+var accessible bool
+
+// SetAccessible turns the accessibility mode on or off
+func SetAccessible(on bool) {
+	accessible = on
+}
+
+// IsAccessible returns true in accessibility mode
+func IsAccessible() bool {
+	return accessible
+}
+
+// ciMode is the output of CI runs: no prompts, and no colors or spinners,
+// which CI logs show as escape codes and redrawn lines
+var ciMode bool
+
+// SetCI turns the CI mode on or off
+func SetCI(on bool) {
+	ciMode = on
+	if on {
+		color.NoColor = true
+	}
+}
+
+// IsCI returns true in CI mode
+func IsCI() bool {
+	return ciMode
+}
+
+// jsonMode is the --json output mode: stdout only carries the JSON result of
+// the command, and the human output goes to stderr
+var jsonMode bool
+
+// SetJSON turns the JSON output mode on or off
+func SetJSON(on bool) {
+	jsonMode = on
+}
+
+// IsJSON returns true in JSON output mode
+func IsJSON() bool {
+	return jsonMode
+}
+
+// output returns where the human output goes: stdout, or stderr in JSON mode
+func output() io.Writer {
+	if jsonMode {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// PrintJSON prints the result of a command as indented JSON on stdout
+func PrintJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
+
+// mark returns the symbol starting a line, or in accessibility mode the word
+// saying what it means
+func mark(symbol, word string) string {
+	if accessible {
+		return word
+	}
+	return symbol
+}
+
 // Intro displays the command intro banner
 func Intro(command string) {
-	fmt.Printf("\n %s \n\n", color.New(color.BgCyan, color.FgBlack).Sprintf(" keyway %s ", command))
+	if accessible {
+		fmt.Fprintf(output(), "\nkeyway %s\n\n", command)
+		return
+	}
+	fmt.Fprintf(output(), "\n %s \n\n", color.New(color.BgCyan, color.FgBlack).Sprintf(" keyway %s ", command))
 }
 
 // Outro displays the command outro message
 func Outro(message string) {
-	fmt.Printf("\n%s\n\n", message)
+	fmt.Fprintf(output(), "\n%s\n\n", message)
 }
 
 // Success displays a success message
 func Success(message string) {
-	green.Printf("✓ %s\n", message)
+	green.Fprintf(output(), "%s %s\n", mark("✓", "Done:"), message)
 }
 
 // Error displays an error message
 func Error(message string) {
-	red.Printf("✗ %s\n", message)
+	red.Fprintf(output(), "%s %s\n", mark("✗", "Error:"), message)
 }
 
 // Warn displays a warning message
 func Warn(message string) {
-	yellow.Printf("⚠ %s\n", message)
+	yellow.Fprintf(output(), "%s %s\n", mark("⚠", "Warning:"), message)
 }
 
 // Info displays an info message
 func Info(message string) {
-	cyan.Printf("ℹ %s\n", message)
+	cyan.Fprintf(output(), "%s %s\n", mark("ℹ", "Info:"), message)
 }
 
 // Step displays a step in a process
 func Step(message string) {
-	fmt.Printf("│ %s\n", message)
+	Message(message)
 }
 
 // Message displays a plain message
 func Message(message string) {
-	fmt.Printf("│ %s\n", message)
+	if accessible {
+		fmt.Fprintln(output(), message)
+		return
+	}
+	fmt.Fprintf(output(), "│ %s\n", message)
 }
 
 // Value formats a value for display
@@ -96,6 +179,7 @@ func Confirm(message string, defaultValue bool) (bool, error) {
 		Value(&result).
 		Affirmative("Yes").
 		Negative("No").
+		WithAccessible(accessible).
 		Run()
 	if err != nil {
 		return defaultValue, err
@@ -103,8 +187,17 @@ func Confirm(message string, defaultValue bool) (bool, error) {
 	return result, nil
 }
 
-// Select prompts for selection from options
+// Select prompts for selection from options. Long lists can be filtered by
+// typing, with fuzzy matching.
 func Select(message string, options []string) (string, error) {
+	if len(options) >= fuzzyMinOptions && !accessible {
+		selected, err := newPicker(message, options, false).run()
+		if err != nil || len(selected) == 0 {
+			return "", err
+		}
+		return selected[0], nil
+	}
+
 	var result string
 	opts := make([]huh.Option[string], len(options))
 	for i, opt := range options {
@@ -115,11 +208,30 @@ func Select(message string, options []string) (string, error) {
 		Title(message).
 		Options(opts...).
 		Value(&result).
+		WithAccessible(accessible).
+		Run()
+	return result, err
+}
+
+// MultiSelect prompts for selection of any number of options. Long lists can
+// be filtered by typing, with fuzzy matching.
+func MultiSelect(message string, options []string) ([]string, error) {
+	if len(options) >= fuzzyMinOptions && !accessible {
+		return newPicker(message, options, true).run()
+	}
+
+	var result []string
+	err := huh.NewMultiSelect[string]().
+		Title(message).
+		Options(huh.NewOptions(options...)...).
+		Value(&result).
+		WithAccessible(accessible).
 		Run()
 	return result, err
 }
 
-// Password prompts for password input (masked)
+// Password prompts for password input (masked). It stays masked in
+// accessibility mode, whose prompts echo what is typed.
 func Password(message string) (string, error) {
 	var result string
 	err := huh.NewInput().
@@ -130,8 +242,33 @@ func Password(message string) (string, error) {
 	return result, err
 }
 
-// Spin shows a spinner while executing a function
+// Input prompts for a line of text, prefilled with defaultValue
+func Input(message, defaultValue string) (string, error) {
+	result := defaultValue
+	err := huh.NewInput().
+		Title(message).
+		Value(&result).
+		WithAccessible(accessible).
+		Run()
+	return result, err
+}
+
+// Spin shows a spinner while executing a function. In accessibility and CI
+// modes, it prints the message, then whether it is done or failed.
 func Spin(message string, fn func() error) error {
+	if jsonMode {
+		return fn()
+	}
+	if accessible || ciMode {
+		Message(message)
+		if err := fn(); err != nil {
+			Message(message + " failed")
+			return err
+		}
+		Message(message + " done")
+		return nil
+	}
+
 	var err error
 	spinErr := spinner.New().
 		Title(message).
@@ -147,6 +284,10 @@ func Spin(message string, fn func() error) error {
 
 // IsInteractive returns true if running in an interactive terminal
 func IsInteractive() bool {
+	// Scripts parsing --json can't answer prompts
+	if jsonMode || ciMode {
+		return false
+	}
 	// Check CI environment
 	if ci := os.Getenv("CI"); ci == "true" || ci == "1" {
 		return false
@@ -161,20 +302,20 @@ func IsInteractive() bool {
 
 // DiffAdded displays a variable that will be added
 func DiffAdded(key string) {
-	green.Printf("  + %s\n", key)
+	green.Fprintf(output(), "  %s %s\n", mark("+", "ADDED"), key)
 }
 
 // DiffChanged displays a variable that will be updated
 func DiffChanged(key string) {
-	yellow.Printf("  ~ %s\n", key)
+	yellow.Fprintf(output(), "  %s %s\n", mark("~", "CHANGED"), key)
 }
 
 // DiffRemoved displays a variable that will be removed
 func DiffRemoved(key string) {
-	red.Printf("  - %s\n", key)
+	red.Fprintf(output(), "  %s %s\n", mark("-", "REMOVED"), key)
 }
 
 // DiffKept displays a variable that will be kept (local only)
 func DiffKept(key string) {
-	dim.Printf("  • %s\n", key)
+	dim.Fprintf(output(), "  %s %s\n", mark("•", "KEPT"), key)
 }
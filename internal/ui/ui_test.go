@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/fatih/color"
 )
 
 func TestIsInteractive_CI(t *testing.T) {
@@ -227,3 +232,116 @@ func TestFormattingFunctions_EmptyInput(t *testing.T) {
 		})
 	}
 }
+
+// captureOutput returns what fn prints to stdout
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	color.Output = w
+	defer func() { color.Output = stdout }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestAccessibleMode_LabelsWithWords(t *testing.T) {
+	SetAccessible(true)
+	defer SetAccessible(false)
+
+	out := captureOutput(t, func() {
+		Step("Environment: production")
+		DiffAdded("API_KEY")
+		DiffChanged("DB_URL")
+		DiffRemoved("OLD_KEY")
+		Success("Secrets synced")
+	})
+
+	for _, want := range []string{"Environment: production\n", "ADDED API_KEY", "CHANGED DB_URL", "REMOVED OLD_KEY", "Done: Secrets synced"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	for _, symbol := range []string{"│", "✓", "+ API_KEY"} {
+		if strings.Contains(out, symbol) {
+			t.Errorf("expected no %q in accessibility mode, got %q", symbol, out)
+		}
+	}
+}
+
+func TestAccessibleMode_SpinPrintsLines(t *testing.T) {
+	SetAccessible(true)
+	defer SetAccessible(false)
+
+	var err error
+	out := captureOutput(t, func() {
+		err = Spin("Fetching secrets...", func() error { return errors.New("boom") })
+	})
+
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("expected the action's error, got %v", err)
+	}
+	if out != "Fetching secrets...\nFetching secrets... failed\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestCIMode_PlainAndNonInteractive(t *testing.T) {
+	noColor := color.NoColor
+	SetCI(true)
+	defer func() {
+		SetCI(false)
+		color.NoColor = noColor
+	}()
+
+	out := captureOutput(t, func() {
+		_ = Spin("Fetching secrets...", func() error { return nil })
+	})
+
+	if out != "│ Fetching secrets...\n│ Fetching secrets... done\n" {
+		t.Errorf("expected the spinner as plain lines, got %q", out)
+	}
+	if !color.NoColor {
+		t.Error("expected colors to be off in CI mode")
+	}
+	if IsInteractive() {
+		t.Error("expected no prompts in CI mode")
+	}
+}
+
+func TestJSONMode_HumanOutputOnStderr(t *testing.T) {
+	SetJSON(true)
+	defer SetJSON(false)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	out := captureOutput(t, func() {
+		Success("Secrets synced")
+		_ = PrintJSON(map[string]int{"variables": 2})
+	})
+	w.Close()
+	human, _ := io.ReadAll(r)
+
+	if out != "{\n  \"variables\": 2\n}\n" {
+		t.Errorf("expected only the JSON result on stdout, got %q", out)
+	}
+	if !strings.Contains(string(human), "Secrets synced") {
+		t.Errorf("expected the human output on stderr, got %q", human)
+	}
+	if IsInteractive() {
+		t.Error("expected JSON mode not to be interactive")
+	}
+}
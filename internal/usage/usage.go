@@ -0,0 +1,178 @@
+// Package usage keeps a local log of the commands run and how long they took.
+// Nothing in this log is ever sent anywhere, it only feeds `keyway usage`.
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// FileName is the name of the usage log in the config directory
+const FileName = "usage.jsonl"
+
+// Record is one command invocation
+type Record struct {
+	Command    string    `json:"command"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+	Version    string    `json:"version,omitempty"`
+	CI         bool      `json:"ci,omitempty"`
+	// Environment is the environment passed with --env, if any
+	Environment string `json:"environment,omitempty"`
+}
+
+// Duration returns how long the command took
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// IsDisabled returns true if local usage tracking is turned off
+func IsDisabled() bool {
+	val := os.Getenv("KEYWAY_DISABLE_USAGE")
+	return val == "1" || val == "true"
+}
+
+// Path returns the location of the usage log
+func Path() string {
+	dir := config.GetStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, FileName)
+}
+
+// Append adds a record to the usage log at path, creating it if needed
+func Append(path string, record Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Parse reads the records of a usage log, skipping lines it cannot decode
+func Parse(data []byte) []Record {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil || r.Command == "" {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// RecentEnvironments returns up to n environments passed to commands, most
+// recently used first
+func RecentEnvironments(records []Record, n int) []string {
+	sorted := append([]Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartedAt.After(sorted[j].StartedAt) })
+
+	var envs []string
+	seen := make(map[string]bool)
+	for _, r := range sorted {
+		if r.Environment == "" || seen[r.Environment] {
+			continue
+		}
+		seen[r.Environment] = true
+		envs = append(envs, r.Environment)
+		if len(envs) == n {
+			break
+		}
+	}
+	return envs
+}
+
+// CommandStats aggregates the records of one command
+type CommandStats struct {
+	Command  string
+	Runs     int
+	Failures int
+	Total    time.Duration
+	Average  time.Duration
+	Longest  time.Duration
+	LastRun  time.Time
+}
+
+// Summary aggregates a set of records
+type Summary struct {
+	From     time.Time
+	To       time.Time
+	Runs     int
+	Failures int
+	Total    time.Duration
+	Commands []CommandStats
+}
+
+// Summarize aggregates records started at or after since, most used command first
+func Summarize(records []Record, since time.Time) Summary {
+	var summary Summary
+	byCommand := make(map[string]*CommandStats)
+
+	for _, r := range records {
+		if r.StartedAt.Before(since) {
+			continue
+		}
+		stats, ok := byCommand[r.Command]
+		if !ok {
+			stats = &CommandStats{Command: r.Command}
+			byCommand[r.Command] = stats
+		}
+
+		d := r.Duration()
+		stats.Runs++
+		stats.Total += d
+		if d > stats.Longest {
+			stats.Longest = d
+		}
+		if r.StartedAt.After(stats.LastRun) {
+			stats.LastRun = r.StartedAt
+		}
+
+		summary.Runs++
+		summary.Total += d
+		if !r.Success {
+			stats.Failures++
+			summary.Failures++
+		}
+		if summary.From.IsZero() || r.StartedAt.Before(summary.From) {
+			summary.From = r.StartedAt
+		}
+		if r.StartedAt.After(summary.To) {
+			summary.To = r.StartedAt
+		}
+	}
+
+	for _, stats := range byCommand {
+		stats.Average = stats.Total / time.Duration(stats.Runs)
+		summary.Commands = append(summary.Commands, *stats)
+	}
+	sort.Slice(summary.Commands, func(i, j int) bool {
+		if summary.Commands[i].Runs != summary.Commands[j].Runs {
+			return summary.Commands[i].Runs > summary.Commands[j].Runs
+		}
+		return summary.Commands[i].Command < summary.Commands[j].Command
+	})
+	return summary
+}
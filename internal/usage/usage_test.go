@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndParse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := Append(path, Record{Command: "pull", StartedAt: start, DurationMs: 1200, Success: true}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := Append(path, Record{Command: "push", StartedAt: start.Add(time.Hour), DurationMs: 300}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := Parse(append(data, []byte("not json\n")...))
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Command != "pull" || records[0].Duration() != 1200*time.Millisecond || !records[0].StartedAt.Equal(start) {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Command: "pull", StartedAt: base.Add(-48 * time.Hour), DurationMs: 5000, Success: true}, // before since
+		{Command: "pull", StartedAt: base, DurationMs: 1000, Success: true},
+		{Command: "pull", StartedAt: base.Add(time.Minute), DurationMs: 3000, Success: false},
+		{Command: "run", StartedAt: base.Add(2 * time.Minute), DurationMs: 500, Success: true},
+	}
+
+	summary := Summarize(records, base.Add(-time.Hour))
+
+	if summary.Runs != 3 || summary.Failures != 1 || summary.Total != 4500*time.Millisecond {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	if len(summary.Commands) != 2 || summary.Commands[0].Command != "pull" {
+		t.Fatalf("expected pull first, got %+v", summary.Commands)
+	}
+	pull := summary.Commands[0]
+	if pull.Runs != 2 || pull.Average != 2*time.Second || pull.Longest != 3*time.Second || pull.Failures != 1 {
+		t.Errorf("unexpected pull stats: %+v", pull)
+	}
+	if !summary.From.Equal(base) || !summary.To.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("unexpected period: %v - %v", summary.From, summary.To)
+	}
+}
+
+func TestRecentEnvironments(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Command: "pull", StartedAt: base, Environment: "staging"},
+		{Command: "run", StartedAt: base.Add(time.Minute), Environment: "production"},
+		{Command: "diff", StartedAt: base.Add(2 * time.Minute)},
+		{Command: "pull", StartedAt: base.Add(3 * time.Minute), Environment: "staging"},
+		{Command: "push", StartedAt: base.Add(-time.Hour), Environment: "preview"},
+	}
+
+	got := RecentEnvironments(records, 2)
+	if len(got) != 2 || got[0] != "staging" || got[1] != "production" {
+		t.Errorf("RecentEnvironments() = %v, want [staging production]", got)
+	}
+	if got := RecentEnvironments(records, 10); len(got) != 3 || got[2] != "preview" {
+		t.Errorf("RecentEnvironments() = %v, want 3 environments ending with preview", got)
+	}
+}
+
+func TestPath_ConfigDirOverride(t *testing.T) {
+	t.Setenv("KEYWAY_CONFIG_DIR", "/shared/keyway")
+	if got := Path(); got != filepath.Join("/shared/keyway", FileName) {
+		t.Errorf("Path() = %q", got)
+	}
+}
@@ -0,0 +1,18 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+)
+
+// runExec runs a command with input on stdin
+func runExec(ctx context.Context, command []string, dir string, input []byte, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
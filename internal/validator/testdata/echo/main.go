@@ -0,0 +1,47 @@
+// Command echo is a validator compiled to WASI by the tests: it rejects values
+// starting with "bad" and notes the others.
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+type change struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type annotation struct {
+	Key     string `json:"key"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func main() {
+	var req struct {
+		Version int      `json:"version"`
+		Changes []change `json:"changes"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil || req.Version != 1 {
+		os.Stderr.WriteString("bad request")
+		os.Exit(2)
+	}
+	if _, err := os.ReadFile("/etc/hostname"); err == nil {
+		os.Stderr.WriteString("files should not be reachable")
+		os.Exit(3)
+	}
+
+	var out struct {
+		Annotations []annotation `json:"annotations"`
+	}
+	for _, c := range req.Changes {
+		level := "info"
+		if strings.HasPrefix(c.Value, "bad") {
+			level = "error"
+		}
+		out.Annotations = append(out.Annotations, annotation{Key: c.Key, Level: level, Message: "checked"})
+	}
+	json.NewEncoder(os.Stdout).Encode(out)
+}
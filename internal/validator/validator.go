@@ -0,0 +1,158 @@
+// Package validator runs the validation plugins a project declares in
+// .keyway.json on the changes of a push.
+//
+// A plugin is an executable or a WebAssembly (WASI) module. It reads a Request
+// as JSON on stdin and writes a Result as JSON to stdout; an error annotation
+// blocks the push. WASM modules run without access to the network, the files or
+// the environment, executables run like any other command of the project.
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// ProtocolVersion is sent in every Request, for plugins to check they understand it
+const ProtocolVersion = 1
+
+// Actions of a Change
+const (
+	ActionAdded   = "added"
+	ActionChanged = "changed"
+	ActionRemoved = "removed"
+)
+
+// Levels of an Annotation
+const (
+	LevelError   = "error" // blocks the push
+	LevelWarning = "warning"
+	LevelInfo    = "info"
+)
+
+// Timeout bounds each run of a plugin
+var Timeout = 30 * time.Second
+
+// maxOutput bounds what a plugin may write to stdout and stderr
+const maxOutput = 1 << 20
+
+// Request is what a plugin reads on stdin
+type Request struct {
+	Version     int      `json:"version"`
+	Repo        string   `json:"repo"`
+	Environment string   `json:"environment"`
+	Changes     []Change `json:"changes"`
+}
+
+// Change is a key the push adds, changes or removes
+type Change struct {
+	Key      string `json:"key"`
+	Action   string `json:"action"`
+	Value    string `json:"value,omitempty"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// Result is what a plugin writes to stdout. Writing nothing accepts the changes.
+type Result struct {
+	Annotations []Annotation `json:"annotations"`
+}
+
+// Annotation is a plugin's remark on a change, or on the whole push without key
+type Annotation struct {
+	Key     string `json:"key,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Blocks returns true if an annotation vetoes the push
+func (r *Result) Blocks() bool {
+	for _, a := range r.Annotations {
+		if a.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs a plugin on a request. dir is the directory of the project, where
+// commands run and relative to which module paths are resolved. A plugin that
+// fails or writes something other than a Result returns an error.
+func Run(ctx context.Context, v config.Validator, dir string, req Request) (*Result, error) {
+	req.Version = ProtocolVersion
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	if v.Wasm != "" {
+		modulePath := v.Wasm
+		if !filepath.IsAbs(modulePath) {
+			modulePath = filepath.Join(dir, modulePath)
+		}
+		err = runWasm(ctx, modulePath, v.Name, input, &stdout, &stderr)
+	} else {
+		err = runExec(ctx, v.Exec, dir, input, &stdout, &stderr)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	if stdout.overflow || stderr.overflow {
+		return nil, fmt.Errorf("wrote more than %d bytes", maxOutput)
+	}
+	return parseResult(stdout.Bytes())
+}
+
+// parseResult decodes the output of a plugin
+func parseResult(output []byte) (*Result, error) {
+	result := &Result{}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(output, result); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	for i, a := range result.Annotations {
+		switch a.Level {
+		case LevelError, LevelWarning, LevelInfo:
+		default:
+			return nil, fmt.Errorf("invalid output: unknown level %q", a.Level)
+		}
+		if strings.TrimSpace(a.Message) == "" {
+			result.Annotations[i].Message = a.Level
+		}
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps the first maxOutput bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); len(p) > room {
+		b.overflow = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
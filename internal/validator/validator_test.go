@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+)
+
+// writeScript writes an executable shell script to a temp dir
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	path := filepath.Join(t.TempDir(), "validator.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var stripeRequest = Request{
+	Repo:        "owner/repo",
+	Environment: "production",
+	Changes:     []Change{{Key: "STRIPE_KEY", Action: ActionChanged, Value: "sk_test_123", Previous: "sk_live_456"}},
+}
+
+func TestRun_Exec(t *testing.T) {
+	script := writeScript(t, `
+input=$(cat)
+case "$input" in
+  *'"version":1'*'"value":"sk_test_'*) echo '{"annotations": [{"key": "STRIPE_KEY", "level": "error", "message": "not a live key"}]}' ;;
+  *) echo '{"annotations": []}' ;;
+esac
+`)
+
+	result, err := Run(context.Background(), config.Validator{Name: "stripe", Exec: []string{script}}, t.TempDir(), stripeRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Blocks() || result.Annotations[0].Message != "not a live key" {
+		t.Errorf("expected the change to be blocked, got %+v", result)
+	}
+}
+
+func TestRun_ExecNoOutputAccepts(t *testing.T) {
+	script := writeScript(t, "cat > /dev/null\n")
+
+	result, err := Run(context.Background(), config.Validator{Name: "quiet", Exec: []string{script}}, t.TempDir(), stripeRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Blocks() || len(result.Annotations) != 0 {
+		t.Errorf("expected no annotations, got %+v", result)
+	}
+}
+
+func TestRun_ExecFailure(t *testing.T) {
+	script := writeScript(t, "echo 'cannot reach the API' >&2\nexit 1\n")
+
+	_, err := Run(context.Background(), config.Validator{Name: "broken", Exec: []string{script}}, t.TempDir(), stripeRequest)
+	if err == nil || !strings.Contains(err.Error(), "cannot reach the API") {
+		t.Errorf("expected the error to carry stderr, got %v", err)
+	}
+}
+
+func TestRun_ExecTimeout(t *testing.T) {
+	script := writeScript(t, "exec sleep 10\n")
+	old := Timeout
+	Timeout = 100 * time.Millisecond
+	defer func() { Timeout = old }()
+
+	_, err := Run(context.Background(), config.Validator{Name: "slow", Exec: []string{script}}, t.TempDir(), stripeRequest)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	if _, err := parseResult([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+	if _, err := parseResult([]byte(`{"annotations": [{"level": "fatal", "message": "x"}]}`)); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	result, err := parseResult([]byte(`{"annotations": [{"level": "warning"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Blocks() || result.Annotations[0].Message != "warning" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRun_Wasm(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a WASI module")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	build := exec.Command(goBin, "build", "-o", filepath.Join(dir, "echo.wasm"), "./testdata/echo")
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("cannot build the module: %v\n%s", err, out)
+	}
+
+	v := config.Validator{Name: "echo", Wasm: "echo.wasm"}
+	req := Request{Repo: "owner/repo", Environment: "production", Changes: []Change{
+		{Key: "GOOD", Action: ActionAdded, Value: "fine"},
+		{Key: "BAD", Action: ActionAdded, Value: "bad value"},
+	}}
+	result, err := Run(context.Background(), v, dir, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Annotations) != 2 || !result.Blocks() {
+		t.Fatalf("expected BAD to be blocked, got %+v", result)
+	}
+	for _, a := range result.Annotations {
+		if (a.Key == "BAD") != (a.Level == LevelError) {
+			t.Errorf("unexpected annotation %+v", a)
+		}
+	}
+}
+
+func TestRun_WasmInvalidModule(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.wasm"), []byte("not wasm"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(context.Background(), config.Validator{Name: "bad", Wasm: "bad.wasm"}, dir, stripeRequest); err == nil {
+		t.Error("expected an error for an invalid module")
+	}
+}
@@ -0,0 +1,56 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmMemoryPages bounds the memory of a module, in 64 KiB pages (64 MiB)
+const wasmMemoryPages = 1024
+
+// runWasm runs a WASI module with input on stdin. The module gets no files,
+// no network and no environment variables.
+func runWasm(ctx context.Context, modulePath, name string, input []byte, stdout, stderr io.Writer) error {
+	code, err := os.ReadFile(modulePath)
+	if err != nil {
+		return err
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryPages))
+	defer runtime.Close(ctx)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return err
+	}
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("invalid module %s: %w", modulePath, err)
+	}
+
+	config := wazero.NewModuleConfig().
+		WithName(name).
+		WithArgs(name).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(stderr)
+	_, err = runtime.InstantiateModule(ctx, compiled, config)
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == 0 {
+			return nil
+		}
+		return fmt.Errorf("exit status %d", exitErr.ExitCode())
+	}
+	return err
+}
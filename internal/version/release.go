@@ -0,0 +1,191 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Build describes how the running binary was built, set from ldflags in main
+type Build struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	BuiltBy string `json:"builtBy,omitempty"`
+	// ReleaseKey is the base64 ed25519 public key the release checksums are signed with
+	ReleaseKey string `json:"-"`
+}
+
+var current = Build{Version: "dev"}
+
+// SetBuild records how the running binary was built
+func SetBuild(b Build) {
+	if b.Version == "" {
+		b.Version = "dev"
+	}
+	current = b
+}
+
+// CurrentBuild returns how the running binary was built
+func CurrentBuild() Build {
+	return current
+}
+
+// IsRelease returns true if the binary claims to be a published release
+func (b Build) IsRelease() bool {
+	return b.Version != "dev" && b.Version != ""
+}
+
+// ChecksumsFile is the release manifest listing the SHA-256 of every archive
+const ChecksumsFile = "checksums.txt"
+
+// SignatureFile holds the base64 ed25519 signature of ChecksumsFile
+const SignatureFile = ChecksumsFile + ".sig"
+
+// ErrNotFound is returned when a release asset does not exist
+var ErrNotFound = errors.New("not found")
+
+// DownloadTimeout bounds each download of a release asset
+var DownloadTimeout = 2 * time.Minute
+
+// maxAssetSize bounds a downloaded release asset
+const maxAssetSize = 200 << 20
+
+// ArchiveName returns the release archive of a version for a platform,
+// as named by the release pipeline
+func ArchiveName(version, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("keyway_%s_%s_%s.%s", strings.TrimPrefix(version, "v"), goos, goarch, ext)
+}
+
+// BinaryName returns the name of the binary in the archive of a platform
+func BinaryName(goos string) string {
+	if goos == "windows" {
+		return "keyway.exe"
+	}
+	return "keyway"
+}
+
+// AssetURL returns the URL of an asset of a release
+func AssetURL(baseURL, version, name string) string {
+	return fmt.Sprintf("%s/v%s/%s", strings.TrimSuffix(baseURL, "/"), strings.TrimPrefix(version, "v"), name)
+}
+
+// Download fetches a release asset
+func Download(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, DownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "keyway-cli")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", url, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxAssetSize)
+	}
+	return data, nil
+}
+
+// ParseChecksums parses a checksums file ("<sha256>  <name>" lines) into the
+// hex digest of each file
+func ParseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return sums
+}
+
+// VerifySignature checks the base64 ed25519 signature of a checksums file
+// against a base64 public key
+func VerifySignature(checksums, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, sig) {
+		return fmt.Errorf("signature does not match the release key")
+	}
+	return nil
+}
+
+// ExtractBinary returns the content of the file called name at the root of a
+// release archive, a .tar.gz or a .zip
+func ExtractBinary(archive []byte, archiveName, name string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if path.Clean(f.Name) == name {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(io.LimitReader(rc, maxAssetSize))
+			}
+		}
+		return nil, fmt.Errorf("%s not in %s", name, archiveName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not in %s", name, archiveName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Clean(hdr.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxAssetSize))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func TestArchiveName(t *testing.T) {
+	if got := ArchiveName("v1.2.3", "linux", "amd64"); got != "keyway_1.2.3_linux_amd64.tar.gz" {
+		t.Errorf("ArchiveName(linux) = %q", got)
+	}
+	if got := ArchiveName("1.2.3", "windows", "arm64"); got != "keyway_1.2.3_windows_arm64.zip" {
+		t.Errorf("ArchiveName(windows) = %q", got)
+	}
+	if got := AssetURL("https://mirror/", "1.2.3", ChecksumsFile); got != "https://mirror/v1.2.3/checksums.txt" {
+		t.Errorf("AssetURL = %q", got)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	sums := ParseChecksums([]byte("ABC123  keyway_1.2.3_linux_amd64.tar.gz\ndef456 *keyway_1.2.3_windows_amd64.zip\n\n"))
+
+	if sums["keyway_1.2.3_linux_amd64.tar.gz"] != "abc123" || sums["keyway_1.2.3_windows_amd64.zip"] != "def456" {
+		t.Errorf("unexpected checksums: %v", sums)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	checksums := []byte("abc  keyway_1.2.3_linux_amd64.tar.gz\n")
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, checksums)))
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	if err := VerifySignature(checksums, signature, key); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	if err := VerifySignature([]byte("tampered"), signature, key); err == nil {
+		t.Error("expected tampered checksums to be rejected")
+	}
+	if err := VerifySignature(checksums, []byte("not base64"), key); err == nil {
+		t.Error("expected a malformed signature to be rejected")
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	binary := []byte("\x7fELF binary")
+
+	var tgz bytes.Buffer
+	gz := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(gz)
+	for name, data := range map[string][]byte{"README.md": []byte("readme"), "keyway": binary} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+	}
+	tw.Close()
+	gz.Close()
+
+	got, err := ExtractBinary(tgz.Bytes(), "keyway_1.2.3_linux_amd64.tar.gz", "keyway")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("ExtractBinary(tar.gz) = %q, %v", got, err)
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("keyway.exe")
+	w.Write(binary)
+	zw.Close()
+
+	got, err = ExtractBinary(zipped.Bytes(), "keyway_1.2.3_windows_amd64.zip", "keyway.exe")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("ExtractBinary(zip) = %q, %v", got, err)
+	}
+	if _, err := ExtractBinary(zipped.Bytes(), "keyway_1.2.3_windows_amd64.zip", "other"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -32,8 +32,9 @@ func CheckForUpdate(ctx context.Context, currentVersion string) *UpdateInfo {
 		return nil
 	}
 
-	// Skip update check for self-hosted instances
-	if config.IsCustomAPIURL() {
+	// Skip update check for self-hosted instances, and in CI where nobody
+	// would act on the notice
+	if config.IsCustomAPIURL() || config.IsCI() {
 		return nil
 	}
 
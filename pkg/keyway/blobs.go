@@ -0,0 +1,209 @@
+package keyway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	envfile "github.com/keywaysh/cli/internal/env"
+)
+
+// MaxInlineValue is the longest value the API stores in an environment. Longer
+// values, e.g. a service account JSON, are uploaded out of band as blobs and
+// the environment stores a reference to them. PushSecrets and PullSecrets do
+// it transparently.
+const MaxInlineValue = 32 * 1024
+
+// blobPrefix marks a value referencing a blob, as keyway-blob:v1:<id>:<sha256>
+const blobPrefix = "keyway-blob:v1:"
+
+// blobRef references a value uploaded as a blob
+type blobRef struct {
+	ID     string
+	SHA256 string
+}
+
+func (r blobRef) String() string {
+	return blobPrefix + r.ID + ":" + r.SHA256
+}
+
+// parseBlobRef parses a value referencing a blob, false for any other value
+func parseBlobRef(value string) (blobRef, bool) {
+	rest, ok := strings.CutPrefix(value, blobPrefix)
+	if !ok {
+		return blobRef{}, false
+	}
+	id, sum, ok := strings.Cut(rest, ":")
+	if !ok || id == "" || len(sum) != sha256.Size*2 {
+		return blobRef{}, false
+	}
+	return blobRef{ID: id, SHA256: sum}, true
+}
+
+// blobSum returns the hex SHA-256 of a value
+func blobSum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// blobTransfer is where to upload or download a blob: a presigned URL, with
+// the headers to send along
+type blobTransfer struct {
+	ID      string            `json:"id"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// offloadLongValues returns secrets with the values longer than MaxInlineValue
+// replaced by references to blobs. Values the client already uploaded or
+// pulled as blobs are not uploaded again.
+func (c *Client) offloadLongValues(ctx context.Context, repo, env string, secrets map[string]string) (map[string]string, error) {
+	var long []string
+	for k, v := range secrets {
+		if len(v) > MaxInlineValue {
+			long = append(long, k)
+		}
+	}
+	if len(long) == 0 {
+		return secrets, nil
+	}
+
+	path, err := environmentPath(repo, env)
+	if err != nil {
+		return nil, err
+	}
+	offloaded := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		offloaded[k] = v
+	}
+	for _, key := range long {
+		value := secrets[key]
+		sum := blobSum(value)
+
+		c.mu.Lock()
+		id, uploaded := c.blobIDs[sum]
+		c.mu.Unlock()
+		if !uploaded {
+			body := map[string]interface{}{"size": len(value), "sha256": sum}
+			var wrapper struct {
+				Data blobTransfer `json:"data"`
+			}
+			if err := c.do(ctx, http.MethodPost, path+"/blobs", body, &wrapper); err != nil {
+				return nil, fmt.Errorf("failed to upload the value of %s: %w", key, err)
+			}
+			if _, err := c.transfer(ctx, http.MethodPut, wrapper.Data, []byte(value)); err != nil {
+				return nil, fmt.Errorf("failed to upload the value of %s: %w", key, err)
+			}
+			id = wrapper.Data.ID
+			c.rememberBlob(id, sum, value)
+		}
+		offloaded[key] = blobRef{ID: id, SHA256: sum}.String()
+	}
+	return offloaded, nil
+}
+
+// resolveBlobs returns content with the references to blobs replaced by their
+// values, checked against the digest of the reference
+func (c *Client) resolveBlobs(ctx context.Context, repo, env, content string) (string, error) {
+	if !strings.Contains(content, blobPrefix) {
+		return content, nil
+	}
+
+	resolved := make(map[string]string)
+	for k, v := range envfile.ParseVault(content) {
+		ref, ok := parseBlobRef(v)
+		if !ok {
+			continue
+		}
+		c.mu.Lock()
+		value, known := c.blobValues[ref.ID]
+		c.mu.Unlock()
+		if !known {
+			data, err := c.downloadBlob(ctx, repo, env, ref)
+			if err != nil {
+				return "", fmt.Errorf("failed to download the value of %s: %w", k, err)
+			}
+			value = string(data)
+			c.rememberBlob(ref.ID, ref.SHA256, value)
+		}
+		resolved[k] = value
+	}
+	if len(resolved) == 0 {
+		return content, nil
+	}
+	return envfile.ApplyVault(content, resolved), nil
+}
+
+// downloadBlob downloads the value of a blob
+func (c *Client) downloadBlob(ctx context.Context, repo, env string, ref blobRef) ([]byte, error) {
+	path, err := environmentPath(repo, env)
+	if err != nil {
+		return nil, err
+	}
+	var wrapper struct {
+		Data blobTransfer `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/blobs/"+ref.ID, nil, &wrapper); err != nil {
+		return nil, err
+	}
+	data, err := c.transfer(ctx, http.MethodGet, wrapper.Data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if blobSum(string(data)) != ref.SHA256 {
+		return nil, fmt.Errorf("blob %s does not match its digest", ref.ID)
+	}
+	return data, nil
+}
+
+// rememberBlob keeps a blob's value, so that it is neither downloaded nor
+// uploaded again by this client
+func (c *Client) rememberBlob(id, sum, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.blobIDs == nil {
+		c.blobIDs = make(map[string]string)
+		c.blobValues = make(map[string]string)
+	}
+	c.blobIDs[sum] = id
+	c.blobValues[id] = value
+}
+
+// transfer sends a request to a presigned URL. It carries no token: the URL
+// itself grants access to the blob.
+func (c *Client) transfer(ctx context.Context, method string, t blobTransfer, body []byte) ([]byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.URL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if err := c.spend(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.handleNetworkError(err)
+	}
+	defer resp.Body.Close()
+	data, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("blob storage answered %s", resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,148 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// blobServer is a vault storing long values as blobs behind presigned URLs
+type blobServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	blobs   map[string]string
+	pushed  map[string]string
+	uploads int
+	corrupt bool
+}
+
+func newBlobServer(t *testing.T) *blobServer {
+	s := &blobServer{blobs: make(map[string]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/vaults/acme/api/environments/production/blobs":
+			if r.Header.Get("Authorization") == "" {
+				t.Error("expected the blob upload to be authorized")
+			}
+			id := "b" + string(rune('0'+len(s.blobs)))
+			s.blobs[id] = ""
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"id": id, "url": s.URL + "/storage/" + id, "headers": map[string]string{"X-Upload": "1"},
+			}})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/storage/"):
+			if r.Header.Get("Authorization") != "" {
+				t.Error("expected no token to be sent to the storage")
+			}
+			if r.Header.Get("X-Upload") != "1" {
+				t.Error("expected the upload headers to be sent")
+			}
+			data, _ := io.ReadAll(r.Body)
+			s.blobs[strings.TrimPrefix(r.URL.Path, "/storage/")] = string(data)
+			s.uploads++
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/vaults/acme/api/environments/production/blobs/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/vaults/acme/api/environments/production/blobs/")
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"id": id, "url": s.URL + "/storage/" + id}})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/"):
+			data := s.blobs[strings.TrimPrefix(r.URL.Path, "/storage/")]
+			if s.corrupt {
+				data += "tampered"
+			}
+			w.Write([]byte(data))
+		case r.URL.Path == "/v1/secrets/push":
+			var body struct {
+				Secrets map[string]string `json:"secrets"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			s.pushed = body.Secrets
+			w.Write([]byte(`{"data":{"success":true}}`))
+		case r.URL.Path == "/v1/secrets/pull":
+			var lines []string
+			for k, v := range s.pushed {
+				lines = append(lines, k+"="+v)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"content": strings.Join(lines, "\n")}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return s
+}
+
+func TestClient_PushSecrets_OffloadsLongValues(t *testing.T) {
+	server := newBlobServer(t)
+	defer server.Close()
+	long := strings.Repeat("x", MaxInlineValue+1)
+
+	client := NewClient("token")
+	client.SetBaseURL(server.URL)
+	_, err := client.PushSecrets(context.Background(), "acme/api", "production", map[string]string{"SA_JSON": long, "API_KEY": "short"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if server.pushed["API_KEY"] != "short" {
+		t.Errorf("expected short values to be pushed inline, got %q", server.pushed["API_KEY"])
+	}
+	ref, ok := parseBlobRef(server.pushed["SA_JSON"])
+	if !ok {
+		t.Fatalf("expected a blob reference, got %.40q", server.pushed["SA_JSON"])
+	}
+	if server.blobs[ref.ID] != long {
+		t.Error("expected the value to be uploaded to the storage")
+	}
+
+	// Pushing the same value again reuses the blob
+	if _, err := client.PushSecrets(context.Background(), "acme/api", "production", map[string]string{"SA_JSON": long}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if server.uploads != 1 {
+		t.Errorf("expected the value to be uploaded once, got %d uploads", server.uploads)
+	}
+}
+
+func TestClient_PullSecrets_ResolvesBlobs(t *testing.T) {
+	server := newBlobServer(t)
+	defer server.Close()
+	long := strings.Repeat("y", MaxInlineValue+1)
+
+	pusher := NewClient("token")
+	pusher.SetBaseURL(server.URL)
+	if _, err := pusher.PushSecrets(context.Background(), "acme/api", "production", map[string]string{"SA_JSON": long}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := NewClient("token")
+	client.SetBaseURL(server.URL)
+	secrets, err := client.PullSecretsMap(context.Background(), "acme/api", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secrets["SA_JSON"] != long {
+		t.Errorf("expected the blob to be resolved, got %.40q", secrets["SA_JSON"])
+	}
+}
+
+func TestClient_PullSecrets_RejectsTamperedBlob(t *testing.T) {
+	server := newBlobServer(t)
+	defer server.Close()
+
+	pusher := NewClient("token")
+	pusher.SetBaseURL(server.URL)
+	if _, err := pusher.PushSecrets(context.Background(), "acme/api", "production", map[string]string{"SA_JSON": strings.Repeat("z", MaxInlineValue+1)}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.corrupt = true
+
+	client := NewClient("token")
+	client.SetBaseURL(server.URL)
+	if _, err := client.PullSecrets(context.Background(), "acme/api", "production"); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("expected a digest mismatch, got %v", err)
+	}
+}
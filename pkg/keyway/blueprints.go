@@ -0,0 +1,52 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// BlueprintKey is a key an environment created from a blueprint starts with
+type BlueprintKey struct {
+	Key         string `json:"key"`
+	Description string `json:"description,omitempty"`
+	// Value is a fixed value, e.g. LOG_LEVEL=info
+	Value string `json:"value,omitempty"`
+	// Generate makes a random value instead: "hex:N" or "base64:N" for N
+	// random bytes, "password:N" for N letters and digits, or "uuid". A key
+	// with neither Value nor Generate starts with a placeholder.
+	Generate string `json:"generate,omitempty"`
+}
+
+// Blueprint is an organization's template of the keys of an environment,
+// e.g. those every web service needs
+type Blueprint struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Keys        []BlueprintKey `json:"keys"`
+}
+
+// ListBlueprints returns the environment blueprints of an organization
+func (c *Client) ListBlueprints(ctx context.Context, orgLogin string) ([]Blueprint, error) {
+	var wrapper struct {
+		Data []Blueprint `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/orgs/%s/blueprints", url.PathEscape(orgLogin)), nil, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// GetBlueprint returns an environment blueprint of an organization by name
+func (c *Client) GetBlueprint(ctx context.Context, orgLogin, name string) (*Blueprint, error) {
+	var wrapper struct {
+		Data Blueprint `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/orgs/%s/blueprints/%s", url.PathEscape(orgLogin), url.PathEscape(name)), nil, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
@@ -0,0 +1,64 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetBlueprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/orgs/acme/blueprints/web-service" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"name": "web-service",
+				"keys": []map[string]string{
+					{"key": "SESSION_SECRET", "generate": "hex:32"},
+					{"key": "LOG_LEVEL", "value": "info"},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	blueprint, err := client.GetBlueprint(context.Background(), "acme", "web-service")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if blueprint.Name != "web-service" || len(blueprint.Keys) != 2 || blueprint.Keys[0].Generate != "hex:32" || blueprint.Keys[1].Value != "info" {
+		t.Errorf("unexpected blueprint: %+v", blueprint)
+	}
+}
+
+func TestClient_ListBlueprints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/orgs/acme/blueprints" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"name": "web-service"}, {"name": "worker"}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	blueprints, err := client.ListBlueprints(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(blueprints) != 2 || blueprints[1].Name != "worker" {
+		t.Errorf("unexpected blueprints: %+v", blueprints)
+	}
+}
@@ -0,0 +1,68 @@
+package keyway
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned instead of sending a request once the budget
+// of the client is spent
+var ErrBudgetExceeded = errors.New("network budget exceeded")
+
+// Budget bounds the number of requests sent by the clients sharing it, e.g.
+// all the clients a command creates. It is safe for concurrent use.
+type Budget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// NewBudget returns a budget of limit requests, unlimited if limit is 0 or less
+func NewBudget(limit int) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Limit returns the number of requests allowed, 0 when unlimited
+func (b *Budget) Limit() int {
+	if b.limit < 0 {
+		return 0
+	}
+	return b.limit
+}
+
+// Used returns the number of requests sent so far
+func (b *Budget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// take spends a request, or fails once the limit is reached
+func (b *Budget) take() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && b.used >= b.limit {
+		return fmt.Errorf("%w: %d requests", ErrBudgetExceeded, b.limit)
+	}
+	b.used++
+	return nil
+}
+
+// SetBudget makes the client count its requests against b, nil removes the budget
+func (c *Client) SetBudget(b *Budget) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.budget = b
+}
+
+// spend takes a request from the client's budget, if it has one
+func (c *Client) spend() error {
+	c.mu.Lock()
+	budget := c.budget
+	c.mu.Unlock()
+	if budget == nil {
+		return nil
+	}
+	return budget.take()
+}
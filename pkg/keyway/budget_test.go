@@ -0,0 +1,64 @@
+package keyway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBudget_SharedByClients(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	budget := NewBudget(2)
+	first, second := NewClient("token"), NewClient("token")
+	for _, c := range []*Client{first, second} {
+		c.SetBaseURL(server.URL)
+		c.SetBudget(budget)
+	}
+
+	if err := first.do(context.Background(), http.MethodGet, "/v1/a", nil, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := second.do(context.Background(), http.MethodGet, "/v1/b", nil, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err := first.do(context.Background(), http.MethodGet, "/v1/c", nil, nil)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", got)
+	}
+	if budget.Used() != 2 || budget.Limit() != 2 {
+		t.Errorf("expected 2 of 2 requests used, got %d of %d", budget.Used(), budget.Limit())
+	}
+}
+
+func TestBudget_Unlimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	budget := NewBudget(0)
+	c := NewClient("token")
+	c.SetBaseURL(server.URL)
+	c.SetBudget(budget)
+
+	for i := 0; i < 5; i++ {
+		if err := c.do(context.Background(), http.MethodGet, "/v1/a", nil, nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if budget.Used() != 5 || budget.Limit() != 0 {
+		t.Errorf("expected 5 requests counted without limit, got %d of %d", budget.Used(), budget.Limit())
+	}
+}
@@ -0,0 +1,294 @@
+package keyway
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keywaysh/cli/internal/config"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// APIVersion is the version of the API this client speaks. The server
+	// answers clients it no longer supports with 426 Upgrade Required.
+	APIVersion = "2025-06-01"
+)
+
+// Capabilities are the optional response features this client understands,
+// so the server can tailor its responses to older clients
+var Capabilities = []string{
+	"problem-details",
+	"upgrade-required",
+	"protection-violations",
+	"trial-info",
+	"idempotency-keys",
+	"events-stream",
+	"delta-pull",
+	"blob-values",
+}
+
+// clientVersion is the CLI version sent in the User-Agent of new clients
+var clientVersion = "dev"
+
+// SetClientVersion sets the CLI version sent by clients created afterwards
+func SetClientVersion(version string) {
+	if version != "" {
+		clientVersion = version
+	}
+}
+
+// Client is the Keyway API client
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+
+	mu sync.Mutex
+	// bodyEncoding is the coding of request bodies the server accepts, learnt from its responses
+	bodyEncoding string
+	// pulls holds the last pull of each environment, the base of delta pulls
+	pulls map[string]PullBase
+	// pullBases keeps those bases across clients, if set
+	pullBases PullBaseStore
+	// budget bounds the requests sent, if set
+	budget *Budget
+	// blobIDs and blobValues hold the blobs uploaded or downloaded, by digest and by ID
+	blobIDs    map[string]string
+	blobValues map[string]string
+}
+
+// TrialEligibility contains trial information for org repos
+type TrialEligibility struct {
+	Eligible      bool   `json:"eligible"`
+	DaysAvailable int    `json:"daysAvailable"`
+	OrgLogin      string `json:"orgLogin"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// APIError represents an error from the API (RFC 7807)
+type APIError struct {
+	StatusCode int                  `json:"-"`
+	Type       string               `json:"type,omitempty"`
+	Title      string               `json:"title,omitempty"`
+	Detail     string               `json:"detail,omitempty"`
+	UpgradeURL string               `json:"upgradeUrl,omitempty"`
+	MinVersion string               `json:"minVersion,omitempty"`
+	TrialInfo  *TrialEligibility    `json:"trialInfo,omitempty"`
+	Protection *ProtectionViolation `json:"protection,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	if e.IsUpgradeRequired() {
+		if e.MinVersion != "" {
+			return fmt.Sprintf("this version of keyway is no longer supported, %s or later is required", e.MinVersion)
+		}
+		return "this version of keyway is no longer supported"
+	}
+	if e.Title != "" {
+		return e.Title
+	}
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// IsUpgradeRequired returns true if the server no longer supports this client
+func (e *APIError) IsUpgradeRequired() bool {
+	return e.StatusCode == http.StatusUpgradeRequired || strings.HasSuffix(e.Type, "/upgrade-required")
+}
+
+// NewClient creates a new API client
+func NewClient(token string) *Client {
+	httpClient := &http.Client{
+		Timeout: defaultTimeout,
+	}
+
+	// Allow insecure TLS for local development (self-signed certs)
+	if os.Getenv("KEYWAY_INSECURE") == "1" {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+
+	return &Client{
+		baseURL:    config.GetAPIURL(),
+		httpClient: httpClient,
+		token:      token,
+		userAgent:  "keyway-cli/" + clientVersion,
+	}
+}
+
+// NewClientWithVersion creates a new API client with version
+func NewClientWithVersion(token, version string) *Client {
+	c := NewClient(token)
+	c.userAgent = fmt.Sprintf("keyway-cli/%s", version)
+	return c
+}
+
+// SetBaseURL points the client at another API, e.g. a self-hosted instance
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetTimeout sets a custom timeout for requests
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// do performs an HTTP request
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	return c.doWithHeaders(ctx, method, path, body, result, nil)
+}
+
+// doWithHeaders performs an HTTP request with extra request headers
+func (c *Client) doWithHeaders(ctx context.Context, method, path string, body, result interface{}, headers map[string]string) error {
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		if jsonBody, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	encoding := c.bodyEncoding
+	c.mu.Unlock()
+	if len(jsonBody) < minCompressedBody {
+		encoding = ""
+	}
+
+	resp, respBody, err := c.send(ctx, method, path, jsonBody, encoding, headers)
+	if err == nil && encoding != "" && resp.StatusCode == http.StatusUnsupportedMediaType {
+		// A proxy in front of the server may not accept what the server does
+		c.mu.Lock()
+		c.bodyEncoding = ""
+		c.mu.Unlock()
+		resp, respBody, err = c.send(ctx, method, path, jsonBody, "", headers)
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		return parseAPIError(resp, respBody)
+	}
+
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// send performs an HTTP request with the body compressed with encoding, if
+// any, and returns the response with its decoded body
+func (c *Client) send(ctx context.Context, method, path string, body []byte, encoding string, headers map[string]string) (*http.Response, []byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		encoded, err := encodeBody(body, encoding)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compress request: %w", err)
+		}
+		bodyReader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.spend(); err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if body != nil && encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	c.setHeaders(req)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, c.handleNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if accepted := resp.Header.Get("Accept-Encoding"); accepted != "" {
+		c.mu.Lock()
+		c.bodyEncoding = requestEncoding(accepted)
+		c.mu.Unlock()
+	}
+
+	respBody, err := readBody(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, respBody, nil
+}
+
+// setHeaders sets the headers sent with every request: the client's version,
+// the API version and capabilities it negotiates with, and the token
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Keyway-API-Version", APIVersion)
+	req.Header.Set("Keyway-Capabilities", strings.Join(Capabilities, ","))
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// parseAPIError reads an error response, a problem details body or anything else
+func parseAPIError(resp *http.Response, body []byte) *APIError {
+	var apiErr APIError
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		apiErr = APIError{Detail: string(body)}
+		if resp.StatusCode == http.StatusUpgradeRequired {
+			// Proxies may answer with a page, the default message is clearer
+			apiErr.Detail = ""
+		}
+	}
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.MinVersion == "" {
+		apiErr.MinVersion = resp.Header.Get("Keyway-Min-Version")
+	}
+	return &apiErr
+}
+
+// handleNetworkError converts network errors to user-friendly messages
+func (c *Client) handleNetworkError(err error) error {
+	if os.IsTimeout(err) {
+		return fmt.Errorf("connection timed out - check your network connection")
+	}
+	// Check for common network errors
+	errStr := err.Error()
+	if strings.Contains(errStr, "no such host") {
+		return fmt.Errorf("DNS lookup failed - check your internet connection")
+	}
+	if strings.Contains(errStr, "connection refused") {
+		return fmt.Errorf("connection refused - is the API server running?")
+	}
+	if strings.Contains(errStr, "certificate") {
+		return fmt.Errorf("SSL certificate error - check your system time")
+	}
+	return fmt.Errorf("network error: %w", err)
+}
@@ -1,10 +1,11 @@
-package api
+package keyway
 
 import (
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -307,6 +308,115 @@ func TestClient_do_UserAgentHeader(t *testing.T) {
 	}
 }
 
+func TestClient_do_NegotiationHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Keyway-API-Version"); got != APIVersion {
+			t.Errorf("expected Keyway-API-Version %q, got %q", APIVersion, got)
+		}
+		if got := r.Header.Get("Keyway-Capabilities"); !strings.Contains(got, "upgrade-required") {
+			t.Errorf("expected capabilities to include upgrade-required, got %q", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.do(context.Background(), "GET", "/test", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSetClientVersion(t *testing.T) {
+	defer func(v string) { clientVersion = v }(clientVersion)
+
+	SetClientVersion("3.1.0")
+	if ua := NewClient("token").userAgent; ua != "keyway-cli/3.1.0" {
+		t.Errorf("expected userAgent 'keyway-cli/3.1.0', got '%s'", ua)
+	}
+
+	SetClientVersion("")
+	if ua := NewClient("token").userAgent; ua != "keyway-cli/3.1.0" {
+		t.Errorf("expected an empty version to be ignored, got '%s'", ua)
+	}
+}
+
+func TestClient_do_UpgradeRequired(t *testing.T) {
+	tests := []struct {
+		name           string
+		statusCode     int
+		header         string
+		responseBody   interface{}
+		expectedMin    string
+		expectedDetail string
+	}{
+		{
+			name:       "426 with problem details",
+			statusCode: http.StatusUpgradeRequired,
+			responseBody: map[string]string{
+				"type":       "https://keyway.sh/errors/upgrade-required",
+				"title":      "Upgrade Required",
+				"minVersion": "2.0.0",
+			},
+			expectedMin:    "2.0.0",
+			expectedDetail: "this version of keyway is no longer supported, 2.0.0 or later is required",
+		},
+		{
+			name:           "426 page from a proxy",
+			statusCode:     http.StatusUpgradeRequired,
+			header:         "2.1.0",
+			responseBody:   "<html>Upgrade Required</html>",
+			expectedMin:    "2.1.0",
+			expectedDetail: "this version of keyway is no longer supported, 2.1.0 or later is required",
+		},
+		{
+			name:       "400 with upgrade-required type",
+			statusCode: http.StatusBadRequest,
+			responseBody: map[string]string{
+				"type":   "https://keyway.sh/errors/upgrade-required",
+				"detail": "Run keyway 2.0 or later",
+			},
+			expectedDetail: "Run keyway 2.0 or later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Keyway-Min-Version", tt.header)
+				}
+				w.WriteHeader(tt.statusCode)
+				if s, ok := tt.responseBody.(string); ok {
+					w.Write([]byte(s))
+				} else {
+					json.NewEncoder(w).Encode(tt.responseBody)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient("token")
+			client.baseURL = server.URL
+
+			err := client.do(context.Background(), "GET", "/test", nil, nil)
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("expected *APIError, got %T", err)
+			}
+			if !apiErr.IsUpgradeRequired() {
+				t.Error("expected an upgrade required error")
+			}
+			if apiErr.MinVersion != tt.expectedMin {
+				t.Errorf("expected min version '%s', got '%s'", tt.expectedMin, apiErr.MinVersion)
+			}
+			if apiErr.Error() != tt.expectedDetail {
+				t.Errorf("expected detail '%s', got '%s'", tt.expectedDetail, apiErr.Error())
+			}
+		})
+	}
+}
+
 func TestAPIError_WithUpgradeURL(t *testing.T) {
 	err := &APIError{
 		StatusCode: 403,
@@ -340,3 +450,28 @@ func TestAPIError_WithTrialInfo(t *testing.T) {
 		t.Errorf("expected 14 days, got %d", err.TrialInfo.DaysAvailable)
 	}
 }
+
+func TestClient_GetOrganizationPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/orgs/acme/policy" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"telemetry": map[string]interface{}{"sink": "otlp", "endpoint": "https://otel.acme.dev"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	policy, err := client.GetOrganizationPolicy(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Telemetry.Sink != "otlp" || policy.Telemetry.Endpoint != "https://otel.acme.dev" {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+}
@@ -0,0 +1,115 @@
+package keyway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings the client speaks, in order of preference
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+// acceptEncoding is sent with every request: responses may be compressed with either
+const acceptEncoding = encodingZstd + ", " + encodingGzip
+
+// minCompressedBody is the size from which request bodies are compressed,
+// smaller ones are not worth it
+const minCompressedBody = 1024
+
+// maxResponseSize bounds a decompressed response, against compression bombs
+const maxResponseSize = 64 << 20
+
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxResponseSize))
+})
+
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+})
+
+// readBody reads a response body, decoded as its Content-Encoding says
+func readBody(resp *http.Response) ([]byte, error) {
+	body := io.LimitReader(resp.Body, maxResponseSize+1)
+	var data []byte
+	var err error
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		data, err = io.ReadAll(body)
+	case encodingGzip:
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(body); err == nil {
+			data, err = io.ReadAll(io.LimitReader(gz, maxResponseSize+1))
+		}
+	case encodingZstd:
+		var dec *zstd.Decoder
+		if dec, err = zstdDecoder(); err == nil {
+			var compressed []byte
+			if compressed, err = io.ReadAll(body); err == nil {
+				data, err = dec.DecodeAll(compressed, nil)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported response encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("response larger than %d bytes", maxResponseSize)
+	}
+	return data, nil
+}
+
+// requestEncoding picks the coding for request bodies among those a server
+// lists in the Accept-Encoding header of its responses (RFC 7694), or ""
+func requestEncoding(accepted string) string {
+	var gzipOK bool
+	for _, part := range strings.Split(accepted, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case encodingZstd:
+			return encodingZstd
+		case encodingGzip:
+			gzipOK = true
+		}
+	}
+	if gzipOK {
+		return encodingGzip
+	}
+	return ""
+}
+
+// encodeBody compresses a request body with a coding returned by requestEncoding
+func encodeBody(body []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case encodingZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return enc.EncodeAll(body, nil), nil
+	case encodingGzip:
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return body, nil
+}
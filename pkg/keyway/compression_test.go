@@ -0,0 +1,139 @@
+package keyway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestClient_do_DecodesCompressedResponses(t *testing.T) {
+	payload := []byte(`{"data": {"content": "API_KEY=secret"}}`)
+
+	for _, encoding := range []string{encodingGzip, encodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != acceptEncoding {
+					t.Errorf("expected Accept-Encoding %q, got %q", acceptEncoding, r.Header.Get("Accept-Encoding"))
+				}
+				body, err := encodeBody(payload, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Header().Set("Content-Encoding", encoding)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			client := NewClient("token")
+			client.baseURL = server.URL
+
+			resp, err := client.PullSecrets(context.Background(), "owner/repo", "production")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Content != "API_KEY=secret" {
+				t.Errorf("unexpected content: %q", resp.Content)
+			}
+		})
+	}
+}
+
+// decodeRequest returns the body of a request, decoded as its Content-Encoding says
+func decodeRequest(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	body, _ := io.ReadAll(r.Body)
+	switch r.Header.Get("Content-Encoding") {
+	case encodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = io.ReadAll(gz)
+	case encodingZstd:
+		dec, _ := zstd.NewReader(nil)
+		defer dec.Close()
+		var err error
+		if body, err = dec.DecodeAll(body, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return body
+}
+
+func TestClient_do_CompressesRequestsOnceAccepted(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		var body map[string]interface{}
+		if err := json.Unmarshal(decodeRequest(t, r), &body); err != nil {
+			t.Errorf("undecodable request body: %v", err)
+		}
+		w.Header().Set("Accept-Encoding", "gzip, zstd")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"success": true}})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+	large := map[string]string{"CERT": strings.Repeat("a", 2*minCompressedBody)}
+
+	for _, secrets := range []map[string]string{large, large, {"SMALL": "x"}} {
+		if _, err := client.PushSecrets(context.Background(), "owner/repo", "production", secrets, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// Plain until the server says what it accepts, never for small bodies
+	if strings.Join(encodings, ",") != ",zstd," {
+		t.Errorf("unexpected request encodings: %q", encodings)
+	}
+}
+
+func TestClient_do_FallsBackOnUnsupportedMediaType(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"success": true}})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+	client.bodyEncoding = encodingGzip
+	large := map[string]string{"CERT": strings.Repeat("a", 2*minCompressedBody)}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.PushSecrets(context.Background(), "owner/repo", "production", large, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if strings.Join(encodings, ",") != "gzip,," {
+		t.Errorf("expected one compressed attempt then plain requests, got %q", encodings)
+	}
+}
+
+func TestRequestEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"gzip":              encodingGzip,
+		"br, gzip, zstd":    encodingZstd,
+		"zstd;q=0, gzip":    encodingGzip,
+		"identity, deflate": "",
+	}
+	for accepted, want := range tests {
+		if got := requestEncoding(accepted); got != want {
+			t.Errorf("requestEncoding(%q) = %q, want %q", accepted, got, want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package keyway is a Go client for the Keyway API, the one the keyway CLI is
+// built on. It covers logging in, reading and writing the secrets of a vault,
+// listing environments, and following a vault's events.
+//
+// Create a client with an API key (Dashboard > Settings > API Keys) or the
+// token of a logged in CLI:
+//
+//	client := keyway.NewClient(os.Getenv("KEYWAY_TOKEN"))
+//
+//	envs, err := client.GetVaultEnvironments(ctx, "acme/api")
+//	secrets, err := client.PullSecretsMap(ctx, "acme/api", "production")
+//	_, err = client.PushSecrets(ctx, "acme/api", "staging", secrets, "")
+//
+//	err = client.StreamVaultEvents(ctx, "acme/api", keyway.EventFilter{}, "", func(e keyway.VaultEvent) error {
+//		fmt.Println(e.Type, e.Environment)
+//		return nil
+//	})
+//
+// The client talks to https://api.keyway.sh unless KEYWAY_API_URL is set, or
+// SetBaseURL is called. Errors returned by the API are *APIError values.
+//
+// Reuse a client rather than creating one per call: pulling an environment
+// again only transfers the keys changed since the client's last pull. Clients
+// in separate processes get the same with a shared PullBaseStore.
+package keyway
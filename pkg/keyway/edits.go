@@ -0,0 +1,66 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EditHint tells teammates that a member is editing an environment locally
+// and is about to push it. Hints are advisory and expire on their own.
+type EditHint struct {
+	Environment string `json:"environment"`
+	Username    string `json:"username"`
+	Note        string `json:"note,omitempty"`
+	StartedAt   string `json:"startedAt"`
+	ExpiresAt   string `json:"expiresAt"`
+	// Mine is true for the hints announced with the client's own account
+	Mine bool `json:"mine"`
+}
+
+// ListEditHints returns the unexpired edit hints of the environments of a vault
+func (c *Client) ListEditHints(ctx context.Context, repoFullName string) ([]EditHint, error) {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	var wrapper struct {
+		Data []EditHint `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/vaults/%s/%s/edits", owner, repo), nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// AnnounceEdit registers an edit hint on an environment for duration,
+// replacing the previous hint of the same member
+func (c *Client) AnnounceEdit(ctx context.Context, repoFullName, env string, duration time.Duration, note string) (*EditHint, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"durationSeconds": int(duration.Seconds()),
+		"note":            note,
+	}
+	var wrapper struct {
+		Data EditHint `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/edits", body, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// WithdrawEdit removes the edit hint of the client's account on an environment
+func (c *Client) WithdrawEdit(ctx context.Context, repoFullName, env string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, path+"/edits/mine", nil, nil)
+}
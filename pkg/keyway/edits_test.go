@@ -0,0 +1,80 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_ListEditHints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/vaults/owner/repo/edits" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"environment": "production", "username": "alice", "startedAt": "2024-06-01T12:00:00Z", "expiresAt": "2024-06-01T12:30:00Z"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	hints, err := client.ListEditHints(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hints) != 1 || hints[0].Username != "alice" || hints[0].Environment != "production" {
+		t.Errorf("unexpected hints: %+v", hints)
+	}
+}
+
+func TestClient_AnnounceEdit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/vaults/owner/repo/environments/production/edits" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["durationSeconds"] != float64(900) || body["note"] != "rotating Stripe keys" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"environment": "production", "username": "alice", "expiresAt": "2024-06-01T12:15:00Z", "mine": true},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	hint, err := client.AnnounceEdit(context.Background(), "owner/repo", "production", 15*time.Minute, "rotating Stripe keys")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hint.Mine || hint.ExpiresAt != "2024-06-01T12:15:00Z" {
+		t.Errorf("unexpected hint: %+v", hint)
+	}
+}
+
+func TestClient_WithdrawEdit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v1/vaults/owner/repo/environments/production/edits/mine" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.WithdrawEdit(context.Background(), "owner/repo", "production"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package keyway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Elevation is temporary write access to an environment, granted to a
+// member who otherwise only reads it and recorded in the audit log
+type Elevation struct {
+	ID          string `json:"id"`
+	Environment string `json:"environment"`
+	Reason      string `json:"reason"`
+	ExpiresAt   string `json:"expiresAt"`
+	// Token carries the elevated access until ExpiresAt, for writes to the
+	// environment only
+	Token string `json:"token"`
+}
+
+// RequestElevation asks for write access to an environment for duration,
+// with a reason recorded in the audit log
+func (c *Client) RequestElevation(ctx context.Context, repoFullName, env string, duration time.Duration, reason string) (*Elevation, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"durationSeconds": int(duration.Seconds()),
+		"reason":          reason,
+	}
+	var wrapper struct {
+		Data Elevation `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/elevations", body, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// RevokeElevation ends an elevation before it expires
+func (c *Client) RevokeElevation(ctx context.Context, repoFullName, env, elevationID string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, path+"/elevations/"+url.PathEscape(elevationID), nil, nil)
+}
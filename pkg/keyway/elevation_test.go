@@ -0,0 +1,55 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_RequestElevation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/vaults/owner/repo/environments/production/elevations" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["durationSeconds"] != float64(1800) || body["reason"] != "hotfix" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": "el_1", "environment": "production", "reason": "hotfix", "expiresAt": "2024-06-01T12:30:00Z", "token": "kw_elevated"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	elevation, err := client.RequestElevation(context.Background(), "owner/repo", "production", 30*time.Minute, "hotfix")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elevation.ID != "el_1" || elevation.Token != "kw_elevated" {
+		t.Errorf("unexpected elevation: %+v", elevation)
+	}
+}
+
+func TestClient_RevokeElevation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v1/vaults/owner/repo/environments/production/elevations/el_1" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.RevokeElevation(context.Background(), "owner/repo", "production", "el_1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
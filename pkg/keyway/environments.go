@@ -0,0 +1,275 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// EnvironmentFreeze describes the freeze state of an environment
+type EnvironmentFreeze struct {
+	Frozen   bool   `json:"frozen"`
+	Reason   string `json:"reason,omitempty"`
+	FrozenBy string `json:"frozenBy,omitempty"`
+	FrozenAt string `json:"frozenAt,omitempty"`
+}
+
+// environmentsPath builds the API path for the environments of a vault
+func environmentsPath(repoFullName string) (string, error) {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return "", fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+	return fmt.Sprintf("/v1/vaults/%s/%s/environments", owner, repo), nil
+}
+
+// environmentPath builds the API path for an environment of a vault
+func environmentPath(repoFullName, env string) (string, error) {
+	path, err := environmentsPath(repoFullName)
+	if err != nil {
+		return "", err
+	}
+	return path + "/" + url.PathEscape(env), nil
+}
+
+// Environment is an environment of a vault
+type Environment struct {
+	Name        string `json:"name"`
+	SecretCount int    `json:"secretCount"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+	UpdatedBy   string `json:"updatedBy,omitempty"`
+}
+
+// ListEnvironments returns the environments of a vault with their number of secrets
+func (c *Client) ListEnvironments(ctx context.Context, repoFullName string) ([]Environment, error) {
+	path, err := environmentsPath(repoFullName)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data []Environment `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// CreateEnvironment creates an empty environment
+func (c *Client) CreateEnvironment(ctx context.Context, repoFullName, env string) error {
+	path, err := environmentsPath(repoFullName)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path, map[string]string{"name": env}, nil)
+}
+
+// DeleteEnvironment deletes an environment and its secrets
+func (c *Client) DeleteEnvironment(ctx context.Context, repoFullName, env string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// RenameEnvironment renames an environment, keeping its secrets, history and rules
+func (c *Client) RenameEnvironment(ctx context.Context, repoFullName, env, newName string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, path, map[string]string{"name": newName}, nil)
+}
+
+// GetEnvironmentFreeze returns the freeze state of an environment
+func (c *Client) GetEnvironmentFreeze(ctx context.Context, repoFullName, env string) (*EnvironmentFreeze, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data EnvironmentFreeze `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/freeze", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// FreezeEnvironment rejects pushes to an environment until it is unfrozen
+func (c *Client) FreezeEnvironment(ctx context.Context, repoFullName, env, reason string) (*EnvironmentFreeze, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]string{"reason": reason}
+	var wrapper struct {
+		Data EnvironmentFreeze `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/freeze", body, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// UnfreezeEnvironment lifts a freeze on an environment
+func (c *Client) UnfreezeEnvironment(ctx context.Context, repoFullName, env string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, path+"/freeze", nil, nil)
+}
+
+// Snapshot is a saved copy of an environment that can be restored
+type Snapshot struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"` // set for named snapshots, e.g. a release
+	Version   int    `json:"version,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+// SnapshotContent is a snapshot with the secrets it holds, as env file content
+type SnapshotContent struct {
+	Snapshot
+	Content string `json:"content"`
+}
+
+// CreateSnapshot saves the current state of an environment before it is modified
+func (c *Client) CreateSnapshot(ctx context.Context, repoFullName, env string) (*Snapshot, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data Snapshot `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/snapshots", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// CreateNamedSnapshot saves the current state of an environment under a name,
+// kept until deleted. Names are unique per environment.
+func (c *Client) CreateNamedSnapshot(ctx context.Context, repoFullName, env, name string) (*Snapshot, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]string{"name": name}
+	var wrapper struct {
+		Data Snapshot `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/snapshots", body, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// GetSnapshot returns a named snapshot of an environment with its secrets
+func (c *Client) GetSnapshot(ctx context.Context, repoFullName, env, name string) (*SnapshotContent, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data SnapshotContent `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/snapshots/"+url.PathEscape(name), nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// RestoreSnapshot puts an environment back in the state saved by a snapshot
+func (c *Client) RestoreSnapshot(ctx context.Context, repoFullName, env, snapshotID string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path+"/snapshots/"+url.PathEscape(snapshotID)+"/restore", nil, nil)
+}
+
+// Protection rules that can block a push
+const (
+	RuleRequiredReviewers = "required_reviewers"
+	RuleAllowedTokens     = "allowed_tokens"
+	RuleAllowedCIDRs      = "allowed_cidrs"
+	RuleKeyOwners         = "key_owners"
+	RuleWebAuthn          = "webauthn"
+)
+
+// EnvironmentProtection holds the rules a push to an environment must satisfy
+type EnvironmentProtection struct {
+	// RequiredReviewers is the number of approvals a push needs from Reviewers
+	RequiredReviewers int      `json:"requiredReviewers,omitempty"`
+	Reviewers         []string `json:"reviewers,omitempty"`
+	// AllowedTokens are the names of the API keys allowed to push, any key if empty
+	AllowedTokens []string `json:"allowedTokens,omitempty"`
+	// AllowedCIDRs are the IP ranges pushes must come from, anywhere if empty
+	AllowedCIDRs []string `json:"allowedCidrs,omitempty"`
+	// RequireWebAuthn makes pushes wait for a WebAuthn assertion from the
+	// pusher's authenticator (Touch ID, Windows Hello, a security key)
+	RequireWebAuthn bool `json:"requireWebauthn,omitempty"`
+}
+
+// IsEmpty returns true if no rule is set
+func (p *EnvironmentProtection) IsEmpty() bool {
+	return p.RequiredReviewers == 0 && len(p.AllowedTokens) == 0 && len(p.AllowedCIDRs) == 0 && !p.RequireWebAuthn
+}
+
+// ProtectionViolation is returned with a 403 when a protection rule blocks a push
+type ProtectionViolation struct {
+	Rule        string   `json:"rule"`
+	Reviewers   []string `json:"reviewers,omitempty"`
+	Required    int      `json:"required,omitempty"`
+	ApprovalURL string   `json:"approvalUrl,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	ClientIP    string   `json:"clientIp,omitempty"`
+	Allowed     []string `json:"allowed,omitempty"`
+	// Keys are the owned keys a RuleKeyOwners violation is about
+	Keys []string `json:"keys,omitempty"`
+}
+
+// GetEnvironmentProtection returns the protection rules of an environment
+func (c *Client) GetEnvironmentProtection(ctx context.Context, repoFullName, env string) (*EnvironmentProtection, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data EnvironmentProtection `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/protection", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// SetEnvironmentProtection replaces the protection rules of an environment.
+// Empty rules remove the protection.
+func (c *Client) SetEnvironmentProtection(ctx context.Context, repoFullName, env string, rules EnvironmentProtection) (*EnvironmentProtection, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data EnvironmentProtection `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPut, path+"/protection", rules, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
@@ -0,0 +1,330 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_FreezeEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/freeze" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["reason"] != "release week" {
+			t.Errorf("expected reason 'release week', got %q", body["reason"])
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"frozen":   true,
+				"reason":   "release week",
+				"frozenBy": "alice",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	freeze, err := client.FreezeEnvironment(context.Background(), "owner/repo", "production", "release week")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !freeze.Frozen || freeze.FrozenBy != "alice" {
+		t.Errorf("unexpected freeze: %+v", freeze)
+	}
+}
+
+func TestClient_ListEnvironments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/vaults/owner/repo/environments" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"name": "development", "secretCount": 12, "updatedAt": "2024-06-01T10:00:00Z"},
+				{"name": "production", "secretCount": 15},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	envs, err := client.ListEnvironments(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 2 || envs[0].Name != "development" || envs[0].SecretCount != 12 || envs[1].Name != "production" {
+		t.Errorf("unexpected environments: %+v", envs)
+	}
+}
+
+func TestClient_CreateEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/vaults/owner/repo/environments" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["name"] != "qa" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.CreateEnvironment(context.Background(), "owner/repo", "qa"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_DeleteEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v1/vaults/owner/repo/environments/qa" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.DeleteEnvironment(context.Background(), "owner/repo", "qa"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_RenameEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v1/vaults/owner/repo/environments/qa" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["name"] != "uat" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.RenameEnvironment(context.Background(), "owner/repo", "qa", "uat"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_GetEnvironmentFreeze(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"frozen": false},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	freeze, err := client.GetEnvironmentFreeze(context.Background(), "owner/repo", "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if freeze.Frozen {
+		t.Error("expected environment not to be frozen")
+	}
+}
+
+func TestClient_UnfreezeEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expected DELETE, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/freeze" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.UnfreezeEnvironment(context.Background(), "owner/repo", "production"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_FreezeEnvironment_InvalidRepo(t *testing.T) {
+	client := NewClient("token")
+	if _, err := client.FreezeEnvironment(context.Background(), "invalid", "production", ""); err == nil {
+		t.Error("expected error for invalid repo")
+	}
+}
+
+func TestClient_CreateSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/snapshots" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": "snap_123", "version": 7},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	snapshot, err := client.CreateSnapshot(context.Background(), "owner/repo", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot.ID != "snap_123" || snapshot.Version != 7 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestClient_CreateNamedSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/vaults/owner/repo/environments/production/snapshots" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["name"] != "v1.42-release" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": "snap_123", "name": "v1.42-release", "version": 7},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	snapshot, err := client.CreateNamedSnapshot(context.Background(), "owner/repo", "production", "v1.42-release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot.ID != "snap_123" || snapshot.Name != "v1.42-release" {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestClient_GetSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/vaults/owner/repo/environments/production/snapshots/v1.42-release" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": "snap_123", "name": "v1.42-release", "content": "API_KEY=abc\n"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	snapshot, err := client.GetSnapshot(context.Background(), "owner/repo", "production", "v1.42-release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot.ID != "snap_123" || snapshot.Content != "API_KEY=abc\n" {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestClient_RestoreSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/snapshots/snap_123/restore" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.RestoreSnapshot(context.Background(), "owner/repo", "production", "snap_123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_SetEnvironmentProtection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/protection" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		var body EnvironmentProtection
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.AllowedCIDRs) != 1 || body.AllowedCIDRs[0] != "10.0.0.0/8" {
+			t.Errorf("unexpected rules: %+v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": body})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	rules, err := client.SetEnvironmentProtection(context.Background(), "owner/repo", "production", EnvironmentProtection{AllowedCIDRs: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules.IsEmpty() {
+		t.Error("expected rules to be returned")
+	}
+}
+
+func TestClient_ProtectionViolation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"detail":"Push blocked","protection":{"rule":"required_reviewers","required":2,"reviewers":["alice","bob"],"approvalUrl":"https://keyway.sh/approve/1"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	_, err := client.GetEnvironmentProtection(context.Background(), "owner/repo", "production")
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.Protection == nil || apiErr.Protection.Rule != RuleRequiredReviewers || apiErr.Protection.Required != 2 {
+		t.Errorf("unexpected violation: %+v", apiErr.Protection)
+	}
+}
@@ -0,0 +1,158 @@
+package keyway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// VaultEvent is a change made to a vault
+type VaultEvent struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"` // e.g. secrets.pushed, secret.deleted, environment.frozen
+	Environment string   `json:"environment,omitempty"`
+	Keys        []string `json:"keys,omitempty"`
+	Actor       string   `json:"actor,omitempty"`
+	CreatedAt   string   `json:"createdAt"`
+}
+
+// EventFilter narrows down the events returned by the API
+type EventFilter struct {
+	Environment string
+	Actor       string
+	Limit       int
+}
+
+func (f EventFilter) values() url.Values {
+	params := url.Values{}
+	if f.Environment != "" {
+		params.Set("environment", f.Environment)
+	}
+	if f.Actor != "" {
+		params.Set("actor", f.Actor)
+	}
+	if f.Limit > 0 {
+		params.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return params
+}
+
+// GetVaultEvents returns the most recent events of a vault, newest first
+func (c *Client) GetVaultEvents(ctx context.Context, repoFullName string, filter EventFilter) ([]VaultEvent, error) {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	path := fmt.Sprintf("/v1/vaults/%s/%s/events", owner, repo)
+	if params := filter.values(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var wrapper struct {
+		Data []VaultEvent `json:"data"`
+	}
+	if err := c.do(ctx, "GET", path, nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// StreamVaultEvents follows the server-sent event stream of a vault and calls fn
+// for each event until ctx is cancelled, the connection drops or fn returns an error.
+// Pass the ID of the last event received to resume after a reconnect.
+func (c *Client) StreamVaultEvents(ctx context.Context, repoFullName string, filter EventFilter, lastEventID string, fn func(VaultEvent) error) error {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	path := fmt.Sprintf("/v1/vaults/%s/%s/events/stream", owner, repo)
+	if params := filter.values(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.setHeaders(req)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	// The stream is long-lived, so the client-wide timeout must not apply
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return c.handleNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return parseAPIError(resp, body)
+	}
+
+	err = readServerSentEvents(resp.Body, func(id, data string) error {
+		var event VaultEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil // ignore keep-alives and unknown payloads
+		}
+		if event.ID == "" {
+			event.ID = id
+		}
+		return fn(event)
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readServerSentEvents parses a text/event-stream body and calls fn with the
+// id and data of each event. Comment lines and empty events are skipped.
+func readServerSentEvents(r io.Reader, fn func(id, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var id string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if err := fn(id, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			data = data[:0]
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
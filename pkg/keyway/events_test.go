@@ -0,0 +1,101 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_GetVaultEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/vaults/owner/repo/events" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("environment") != "production" || r.URL.Query().Get("limit") != "5" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"id": "evt_1", "type": "secrets.pushed", "environment": "production", "keys": []string{"API_KEY"}, "actor": "alice"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	events, err := client.GetVaultEvents(context.Background(), "owner/repo", EventFilter{Environment: "production", Limit: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Actor != "alice" || events[0].Keys[0] != "API_KEY" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestClient_StreamVaultEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("expected event-stream Accept header, got %q", r.Header.Get("Accept"))
+		}
+		if r.Header.Get("Last-Event-ID") != "evt_0" {
+			t.Errorf("expected Last-Event-ID evt_0, got %q", r.Header.Get("Last-Event-ID"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "id: evt_1\ndata: {\"type\":\"secrets.pushed\",\"keys\":[\"A\"]}\n\n")
+		fmt.Fprint(w, "id: evt_2\ndata: {\"id\":\"evt_2\",\"type\":\"secret.deleted\"}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	var received []VaultEvent
+	err := client.StreamVaultEvents(context.Background(), "owner/repo", EventFilter{}, "evt_0", func(e VaultEvent) error {
+		received = append(received, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(received) != 2 || received[0].ID != "evt_1" || received[1].Type != "secret.deleted" {
+		t.Errorf("unexpected events: %+v", received)
+	}
+}
+
+func TestClient_StreamVaultEvents_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"detail": "No access"})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	err := client.StreamVaultEvents(context.Background(), "owner/repo", EventFilter{}, "", func(VaultEvent) error { return nil })
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != 403 || apiErr.Detail != "No access" {
+		t.Errorf("expected 403 APIError, got %v", err)
+	}
+}
+
+func TestReadServerSentEvents_MultilineData(t *testing.T) {
+	var got []string
+	err := readServerSentEvents(strings.NewReader("data: line1\ndata: line2\n\n"), func(id, data string) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "line1\nline2" {
+		t.Errorf("unexpected data: %q", got)
+	}
+}
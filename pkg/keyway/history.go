@@ -0,0 +1,132 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Revision identifies a historical snapshot of an environment,
+// either by version number or by point in time
+type Revision struct {
+	Version int
+	At      time.Time
+}
+
+// IsZero returns true if the revision does not point to any snapshot
+func (r Revision) IsZero() bool {
+	return r.Version == 0 && r.At.IsZero()
+}
+
+// String returns the revision in the format accepted by ParseRevision
+func (r Revision) String() string {
+	if r.Version > 0 {
+		return fmt.Sprintf("version:%d", r.Version)
+	}
+	if r.At.IsZero() {
+		return ""
+	}
+	if r.At.Equal(r.At.Truncate(24 * time.Hour)) {
+		return r.At.Format("2006-01-02")
+	}
+	return r.At.Format(time.RFC3339)
+}
+
+// ParseRevision parses "version:42", "v42", a date (2024-06-01) or an RFC 3339 timestamp
+func ParseRevision(s string) (Revision, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+
+	for _, prefix := range []string{"version:", "v"} {
+		if strings.HasPrefix(lower, prefix) {
+			n, err := strconv.Atoi(s[len(prefix):])
+			if err == nil {
+				if n <= 0 {
+					return Revision{}, fmt.Errorf("invalid version: %s", s)
+				}
+				return Revision{Version: n}, nil
+			}
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return Revision{At: t.UTC()}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return Revision{At: t}, nil
+	}
+
+	return Revision{}, fmt.Errorf("invalid revision %q (expected version:N, YYYY-MM-DD or an RFC 3339 timestamp)", s)
+}
+
+// PullSecretsAt downloads secrets as they were at a given revision
+func (c *Client) PullSecretsAt(ctx context.Context, repo, env string, rev Revision) (*PullSecretsResponse, error) {
+	params := url.Values{}
+	params.Set("repo", repo)
+	params.Set("environment", env)
+	if rev.Version > 0 {
+		params.Set("version", strconv.Itoa(rev.Version))
+	} else if !rev.At.IsZero() {
+		params.Set("at", rev.At.Format(time.RFC3339))
+	}
+
+	var wrapper struct {
+		Data PullSecretsResponse `json:"data"`
+	}
+	if err := c.do(ctx, "GET", "/v1/secrets/pull?"+params.Encode(), nil, &wrapper); err != nil {
+		return &wrapper.Data, err
+	}
+	content, err := c.resolveBlobs(ctx, repo, env, wrapper.Data.Content)
+	if err != nil {
+		return &PullSecretsResponse{}, err
+	}
+	wrapper.Data.Content = content
+	return &wrapper.Data, nil
+}
+
+// EnvironmentVersion is a version of an environment, one per push
+type EnvironmentVersion struct {
+	Version   int      `json:"version"`
+	Actor     string   `json:"actor,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+	Added     []string `json:"added,omitempty"`
+	Changed   []string `json:"changed,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+}
+
+// VersionFilter narrows down the versions returned by ListVersions
+type VersionFilter struct {
+	Key   string // only versions adding, changing or removing this key
+	Limit int
+}
+
+// ListVersions returns the versions of an environment, newest first, with
+// the keys each one changed. Values are never returned.
+func (c *Client) ListVersions(ctx context.Context, repoFullName, env string, filter VersionFilter) ([]EnvironmentVersion, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+	path += "/versions"
+	params := url.Values{}
+	if filter.Key != "" {
+		params.Set("key", filter.Key)
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var wrapper struct {
+		Data []EnvironmentVersion `json:"data"`
+	}
+	if err := c.do(ctx, "GET", path, nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
@@ -0,0 +1,124 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRevision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Revision
+		wantErr  bool
+	}{
+		{"version:42", Revision{Version: 42}, false},
+		{"v7", Revision{Version: 7}, false},
+		{"2024-06-01", Revision{At: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"2024-06-01T12:30:00+02:00", Revision{At: time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)}, false},
+		{"version:0", Revision{}, true},
+		{"version:abc", Revision{}, true},
+		{"yesterday", Revision{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRevision(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRevision(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got.Version != tt.expected.Version || !got.At.Equal(tt.expected.At) {
+				t.Errorf("ParseRevision(%q) = %+v, want %+v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRevision_String(t *testing.T) {
+	tests := []struct {
+		rev      Revision
+		expected string
+	}{
+		{Revision{Version: 42}, "version:42"},
+		{Revision{At: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, "2024-06-01"},
+		{Revision{At: time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)}, "2024-06-01T10:30:00Z"},
+		{Revision{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rev.String(); got != tt.expected {
+			t.Errorf("String() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestClient_PullSecretsAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		rev   Revision
+		param string
+		value string
+	}{
+		{"version", Revision{Version: 42}, "version", "42"},
+		{"date", Revision{At: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, "at", "2024-06-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/secrets/pull" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if got := r.URL.Query().Get(tt.param); got != tt.value {
+					t.Errorf("expected %s=%s, got %q", tt.param, tt.value, got)
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"data": map[string]interface{}{"content": "API_KEY=old"},
+				})
+			}))
+			defer server.Close()
+
+			client := NewClient("token")
+			client.baseURL = server.URL
+
+			resp, err := client.PullSecretsAt(context.Background(), "owner/repo", "production", tt.rev)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Content != "API_KEY=old" {
+				t.Errorf("unexpected content: %q", resp.Content)
+			}
+		})
+	}
+}
+
+func TestClient_ListVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/versions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("key") != "API_KEY" || r.URL.Query().Get("limit") != "5" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"version": 12, "actor": "alice", "createdAt": "2024-06-01T10:00:00Z", "changed": []string{"API_KEY"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	versions, err := client.ListVersions(context.Background(), "owner/repo", "production", VersionFilter{Key: "API_KEY", Limit: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != 12 || versions[0].Actor != "alice" || versions[0].Changed[0] != "API_KEY" {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+}
@@ -0,0 +1,27 @@
+package keyway
+
+import (
+	"context"
+	"net/http"
+)
+
+// Invocation is a command run with the secrets of an environment on a host
+// labeled with an environment, recorded in the vault's audit log
+type Invocation struct {
+	// Command is the keyway command, e.g. "run" or "pull"
+	Command string `json:"command"`
+	// Program is the program keyway run started, without its arguments
+	Program   string `json:"program,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	HostLabel string `json:"hostLabel"`
+}
+
+// RecordInvocation records in the audit log that secrets of an environment
+// were used on a labeled host
+func (c *Client) RecordInvocation(ctx context.Context, repoFullName, env string, invocation Invocation) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path+"/invocations", invocation, nil)
+}
@@ -0,0 +1,35 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_RecordInvocation(t *testing.T) {
+	var got Invocation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/invocations" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	err := client.RecordInvocation(context.Background(), "owner/repo", "production", Invocation{Command: "run", Program: "./server", Hostname: "web-1", HostLabel: "production"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Command != "run" || got.Program != "./server" || got.HostLabel != "production" {
+		t.Errorf("unexpected invocation sent: %+v", got)
+	}
+}
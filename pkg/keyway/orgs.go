@@ -1,4 +1,4 @@
-package api
+package keyway
 
 import (
 	"context"
@@ -27,6 +27,27 @@ type OrganizationInfo struct {
 	Role          string    `json:"role"`
 }
 
+// TelemetryPolicy is where an organization wants CLI telemetry sent:
+// "keyway" (default), "otlp" to its own collector at Endpoint, or "none"
+type TelemetryPolicy struct {
+	Sink     string            `json:"sink"`
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// Required blocks commands when members disable telemetry
+	Required bool `json:"required,omitempty"`
+}
+
+// OrganizationPolicy contains the settings an organization enforces on its members' CLIs
+type OrganizationPolicy struct {
+	Telemetry TelemetryPolicy `json:"telemetry"`
+	// KeyPattern is a regular expression new keys must match, e.g. ^[A-Z][A-Z0-9_]*$
+	KeyPattern string `json:"keyPattern,omitempty"`
+	// ProtectedEnvironments can only be changed by repository admins
+	ProtectedEnvironments []string `json:"protectedEnvironments,omitempty"`
+	// MinCLIVersion is the oldest CLI version allowed to run, e.g. 1.4.0
+	MinCLIVersion string `json:"minCliVersion,omitempty"`
+}
+
 // StartTrialResponse is the response from starting a trial
 type StartTrialResponse struct {
 	Message   string `json:"message"`
@@ -46,6 +67,19 @@ func (c *Client) GetOrganization(ctx context.Context, orgLogin string) (*Organiz
 	return &wrapper.Data, nil
 }
 
+// GetOrganizationPolicy retrieves the policy an organization enforces on the CLI
+func (c *Client) GetOrganizationPolicy(ctx context.Context, orgLogin string) (*OrganizationPolicy, error) {
+	path := fmt.Sprintf("/v1/orgs/%s/policy", orgLogin)
+	var wrapper struct {
+		Data OrganizationPolicy `json:"data"`
+	}
+	err := c.do(ctx, "GET", path, nil, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
 // StartOrganizationTrial starts a trial for an organization
 func (c *Client) StartOrganizationTrial(ctx context.Context, orgLogin string) (*StartTrialResponse, error) {
 	path := fmt.Sprintf("/v1/orgs/%s/trial/start", orgLogin)
@@ -0,0 +1,40 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// KeyOwners are the owners of the keys of a vault, set on the server
+type KeyOwners struct {
+	// Rules maps keys or glob patterns to their owners, "@login" or "@org/team"
+	Rules map[string][]string `json:"rules,omitempty"`
+	// RequireApproval is true when a push changing owned keys needs an owner's
+	// approval, the server then blocks it with a RuleKeyOwners violation
+	RequireApproval bool `json:"requireApproval,omitempty"`
+	// Login and Teams identify the caller, to tell which keys they own
+	Login string   `json:"login,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// GetKeyOwners returns the key owners of a vault. A vault without owners
+// returns empty rules.
+func (c *Client) GetKeyOwners(ctx context.Context, repoFullName string) (*KeyOwners, error) {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	var wrapper struct {
+		Data KeyOwners `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/vaults/%s/%s/owners", owner, repo), nil, &wrapper)
+	if err != nil {
+		if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == 404 {
+			return &KeyOwners{}, nil
+		}
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
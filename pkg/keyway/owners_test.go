@@ -0,0 +1,62 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetKeyOwners(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/owners" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"rules":           map[string][]string{"STRIPE_*": {"@acme/payments"}},
+				"requireApproval": true,
+				"login":           "alice",
+				"teams":           []string{"@acme/platform"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	owners, err := client.GetKeyOwners(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owners.Rules["STRIPE_*"][0] != "@acme/payments" || !owners.RequireApproval {
+		t.Errorf("unexpected owners: %+v", owners)
+	}
+	if owners.Login != "alice" || len(owners.Teams) != 1 {
+		t.Errorf("expected the caller's identity, got %+v", owners)
+	}
+}
+
+func TestClient_GetKeyOwners_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"detail": "Not found"})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	owners, err := client.GetKeyOwners(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(owners.Rules) != 0 {
+		t.Errorf("expected no rules, got %v", owners.Rules)
+	}
+}
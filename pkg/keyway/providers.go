@@ -1,4 +1,4 @@
-package api
+package keyway
 
 import (
 	"context"
@@ -91,6 +91,16 @@ type ProjectLink struct {
 	IsNew               bool    `json:"isNew"`
 }
 
+// SyncLink is a provider project a vault environment syncs to
+type SyncLink struct {
+	Provider            string  `json:"provider"`
+	ProjectID           string  `json:"projectId"`
+	ProjectName         *string `json:"projectName"`
+	KeywayEnvironment   string  `json:"keywayEnvironment"`
+	ProviderEnvironment string  `json:"providerEnvironment"`
+	LastSyncedAt        *string `json:"lastSyncedAt"`
+}
+
 // SyncOptions contains options for sync operations
 type SyncOptions struct {
 	ConnectionID        string  `json:"connectionId"`
@@ -201,6 +211,22 @@ func (c *Client) LinkProject(ctx context.Context, repo string, opts SyncOptions)
 	return &wrapper.Data.Link, nil
 }
 
+// GetSyncLinks returns the provider projects the vault's environments sync to
+func (c *Client) GetSyncLinks(ctx context.Context, repo string) ([]SyncLink, error) {
+	var wrapper struct {
+		Data struct {
+			Links []SyncLink `json:"links"`
+		} `json:"data"`
+	}
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/integrations/vaults/%s/sync/links", repo), nil, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper.Data.Links, nil
+}
+
 // GetSyncStatus returns the sync status for a vault/project pair
 func (c *Client) GetSyncStatus(ctx context.Context, repo, connectionID, projectID, environment string) (*SyncStatus, error) {
 	var wrapper struct {
@@ -0,0 +1,30 @@
+package keyway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// KeyLocation is an environment of a vault holding a key
+type KeyLocation struct {
+	Repo        string `json:"repo"`
+	Environment string `json:"environment"`
+	UpdatedAt   string `json:"updatedAt,omitempty"`
+}
+
+// SearchOrganizationKey returns the environments of an organization's vaults
+// that hold a key, among those the user can read. Values are never returned.
+func (c *Client) SearchOrganizationKey(ctx context.Context, orgLogin, key string) ([]KeyLocation, error) {
+	params := url.Values{}
+	params.Set("key", key)
+	var wrapper struct {
+		Data []KeyLocation `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/orgs/%s/search/keys?%s", url.PathEscape(orgLogin), params.Encode()), nil, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
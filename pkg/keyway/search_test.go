@@ -0,0 +1,41 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_SearchOrganizationKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/orgs/acme/search/keys" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("key"); got != "STRIPE_KEY" {
+			t.Errorf("unexpected key: %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]string{
+				{"repo": "acme/api", "environment": "production"},
+				{"repo": "acme/worker", "environment": "staging"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	locations, err := client.SearchOrganizationKey(context.Background(), "acme", "STRIPE_KEY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locations) != 2 || locations[1].Repo != "acme/worker" || locations[1].Environment != "staging" {
+		t.Errorf("unexpected locations: %+v", locations)
+	}
+}
@@ -0,0 +1,227 @@
+package keyway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	envfile "github.com/keywaysh/cli/internal/env"
+)
+
+// IdempotencyHeader carries the idempotency key of a push. The server applies a
+// push at most once per key and replays the original result for duplicates.
+const IdempotencyHeader = "Idempotency-Key"
+
+// pushAttempts is how many times a push with an idempotency key is sent when
+// the network or the server fails transiently
+const pushAttempts = 3
+
+// pushRetryDelay is the delay before the first retry, doubled for each next one
+var pushRetryDelay = 500 * time.Millisecond
+
+// PushSecretsResponse is the response from pushing secrets
+type PushSecretsResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Stats   *struct {
+		Created int `json:"created"`
+		Updated int `json:"updated"`
+		Deleted int `json:"deleted"`
+	} `json:"stats,omitempty"`
+	// Replayed is true when a push with the same idempotency key was already
+	// applied: nothing changed, and Stats are those of the original push
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// PullSecretsResponse is the response from pulling secrets
+type PullSecretsResponse struct {
+	Content string `json:"content"`
+	// Version is the version of the environment the content is at, 0 if unknown
+	Version int `json:"version,omitempty"`
+}
+
+// pullResponse is a pull as sent by the server: the full content, or with
+// since, only the keys changed and removed since that version (a delta)
+type pullResponse struct {
+	PullSecretsResponse
+	Delta   bool              `json:"delta,omitempty"`
+	Changed map[string]string `json:"changed,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+}
+
+// PullBase is the content of an environment at a version, as last pulled:
+// the base the server sends deltas against
+type PullBase struct {
+	Content string `json:"content"`
+	Version int    `json:"version"`
+}
+
+// PullBaseStore keeps the bases of delta pulls beyond the client, e.g. on disk
+// so that the next process pulling an environment also gets a delta
+type PullBaseStore interface {
+	LoadPullBase(repo, env string) (PullBase, bool)
+	SavePullBase(repo, env string, base PullBase) error
+}
+
+// SetPullBaseStore makes the client load the bases of delta pulls it doesn't
+// hold from store, and save the bases of its pulls there
+func (c *Client) SetPullBaseStore(store PullBaseStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pullBases = store
+}
+
+// PushSecrets uploads secrets to the vault, values longer than MaxInlineValue
+// as blobs. With an idempotency key, a push that fails on the network or with
+// a 502/503/504 is retried, since the server will not apply it twice.
+func (c *Client) PushSecrets(ctx context.Context, repo, env string, secrets map[string]string, idempotencyKey string) (*PushSecretsResponse, error) {
+	secrets, err := c.offloadLongValues(ctx, repo, env, secrets)
+	if err != nil {
+		return &PushSecretsResponse{}, err
+	}
+	body := map[string]interface{}{
+		"repoFullName": repo,
+		"environment":  env,
+		"secrets":      secrets,
+	}
+
+	var headers map[string]string
+	attempts := 1
+	if idempotencyKey != "" {
+		headers = map[string]string{IdempotencyHeader: idempotencyKey}
+		attempts = pushAttempts
+	}
+
+	var wrapper struct {
+		Data PushSecretsResponse `json:"data"`
+	}
+	delay := pushRetryDelay
+	for attempt := 1; ; attempt++ {
+		err = c.doWithHeaders(ctx, "POST", "/v1/secrets/push", body, &wrapper, headers)
+		if err == nil || attempt >= attempts || !isTransient(err) || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return &wrapper.Data, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return &wrapper.Data, err
+}
+
+// isTransient returns true for network errors and gateway or availability errors
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch apiErr.StatusCode {
+	case 502, 503, 504:
+		return true
+	}
+	return false
+}
+
+// PullSecrets downloads secrets from the vault. Once an environment was
+// pulled, by this client or one sharing its PullBaseStore, the client only asks
+// for the keys changed since that version, so pulling a mostly unchanged
+// environment again is cheap.
+func (c *Client) PullSecrets(ctx context.Context, repo, env string) (*PullSecretsResponse, error) {
+	cacheKey := repo + "\x00" + env
+	c.mu.Lock()
+	base, hasBase := c.pulls[cacheKey]
+	store := c.pullBases
+	c.mu.Unlock()
+	if !hasBase && store != nil {
+		base, hasBase = store.LoadPullBase(repo, env)
+		hasBase = hasBase && base.Version > 0
+	}
+
+	params := url.Values{}
+	params.Set("repo", repo)
+	params.Set("environment", env)
+	if hasBase {
+		params.Set("since", strconv.Itoa(base.Version))
+	}
+
+	var wrapper struct {
+		Data pullResponse `json:"data"`
+	}
+	if err := c.do(ctx, "GET", "/v1/secrets/pull?"+params.Encode(), nil, &wrapper); err != nil {
+		return &wrapper.Data.PullSecretsResponse, err
+	}
+
+	resp := wrapper.Data.PullSecretsResponse
+	if wrapper.Data.Delta {
+		if !hasBase {
+			return &PullSecretsResponse{}, fmt.Errorf("unexpected delta for %s without a previous pull", env)
+		}
+		resp.Content = base.Content
+		if len(wrapper.Data.Changed) > 0 || len(wrapper.Data.Removed) > 0 {
+			resp.Content = envfile.ApplyVault(envfile.Remove(base.Content, wrapper.Data.Removed), wrapper.Data.Changed)
+		}
+	}
+
+	c.mu.Lock()
+	if resp.Version > 0 {
+		if c.pulls == nil {
+			c.pulls = make(map[string]PullBase)
+		}
+		c.pulls[cacheKey] = PullBase{Content: resp.Content, Version: resp.Version}
+	} else {
+		// A server without versions cannot send deltas
+		delete(c.pulls, cacheKey)
+	}
+	c.mu.Unlock()
+	if store != nil && resp.Version > 0 {
+		// The next pull still works from this client's base when the store fails
+		_ = store.SavePullBase(repo, env, PullBase{Content: resp.Content, Version: resp.Version})
+	}
+
+	// The last pull keeps the references, deltas may change them
+	content, err := c.resolveBlobs(ctx, repo, env, resp.Content)
+	if err != nil {
+		return &PullSecretsResponse{}, err
+	}
+	resp.Content = content
+	return &resp, nil
+}
+
+// SecretMetadata is when and by whom a key of an environment was last set,
+// without its value
+type SecretMetadata struct {
+	Key       string `json:"key"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+// ListSecretMetadata returns the keys of an environment with when and by whom
+// each was last set. Values are not sent.
+func (c *Client) ListSecretMetadata(ctx context.Context, repo, env string) ([]SecretMetadata, error) {
+	path, err := environmentPath(repo, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data []SecretMetadata `json:"data"`
+	}
+	if err := c.do(ctx, "GET", path+"/secrets", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// PullSecretsMap downloads the secrets of an environment as key/value pairs
+func (c *Client) PullSecretsMap(ctx context.Context, repo, env string) (map[string]string, error) {
+	resp, err := c.PullSecrets(ctx, repo, env)
+	if err != nil {
+		return nil, err
+	}
+	return envfile.ParseVault(resp.Content), nil
+}
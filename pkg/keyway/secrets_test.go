@@ -1,11 +1,13 @@
-package api
+package keyway
 
 import (
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestClient_PushSecrets_Success(t *testing.T) {
@@ -51,7 +53,7 @@ func TestClient_PushSecrets_Success(t *testing.T) {
 		"DB_PASSWORD": "dbpass",
 	}
 
-	resp, err := client.PushSecrets(context.Background(), "owner/repo", "production", secrets)
+	resp, err := client.PushSecrets(context.Background(), "owner/repo", "production", secrets, "")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -95,7 +97,7 @@ func TestClient_PushSecrets_EmptySecrets(t *testing.T) {
 	client := NewClient("token")
 	client.baseURL = server.URL
 
-	resp, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{})
+	resp, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{}, "")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -120,7 +122,7 @@ func TestClient_PushSecrets_Unauthorized(t *testing.T) {
 	client := NewClient("bad-token")
 	client.baseURL = server.URL
 
-	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"})
+	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"}, "")
 
 	if err == nil {
 		t.Fatal("expected error for unauthorized request")
@@ -148,7 +150,7 @@ func TestClient_PushSecrets_Forbidden(t *testing.T) {
 	client := NewClient("token")
 	client.baseURL = server.URL
 
-	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"})
+	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"}, "")
 
 	if err == nil {
 		t.Fatal("expected error for forbidden request")
@@ -314,9 +316,232 @@ func TestClient_PushSecrets_SpecialCharacters(t *testing.T) {
 		"MULTILINE": "line1\nline2\nline3",
 	}
 
-	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", secrets)
+	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", secrets, "")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestClient_PushSecrets_RetriesWithIdempotencyKey(t *testing.T) {
+	defer func(d time.Duration) { pushRetryDelay = d }(pushRetryDelay)
+	pushRetryDelay = time.Millisecond
+
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"success": true, "message": "Secrets saved", "replayed": true},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	resp, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"}, "push-1")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "push-1" || keys[1] != "push-1" {
+		t.Errorf("expected two attempts with the same key, got %v", keys)
+	}
+	if !resp.Replayed {
+		t.Error("expected replayed to be parsed")
+	}
+}
+
+func TestClient_PushSecrets_NoRetryWithoutIdempotencyKey(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Header.Get(IdempotencyHeader) != "" {
+			t.Error("expected no idempotency key")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	_, err := client.PushSecrets(context.Background(), "owner/repo", "production", map[string]string{"KEY": "value"}, "")
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestClient_PullSecretsMap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"content": "API_KEY=secret123\n# comment\nDB_URL=\"postgres://localhost\"",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.SetBaseURL(server.URL + "/")
+
+	secrets, err := client.PullSecretsMap(context.Background(), "owner/repo", "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets) != 2 || secrets["API_KEY"] != "secret123" || secrets["DB_URL"] != "postgres://localhost" {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+}
+
+func TestClient_PullSecrets_Delta(t *testing.T) {
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		sinces = append(sinces, since)
+		var data map[string]interface{}
+		switch since {
+		case "":
+			data = map[string]interface{}{"content": "# App\nAPI_KEY=old\nDB_URL=postgres://db\nLEGACY=x\n", "version": 41}
+		case "41":
+			data = map[string]interface{}{"delta": true, "version": 42, "changed": map[string]string{"API_KEY": "new", "SENTRY_DSN": "https://sentry"}, "removed": []string{"LEGACY"}}
+		default:
+			data = map[string]interface{}{"delta": true, "version": 42}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+	ctx := context.Background()
+
+	if _, err := client.PullSecrets(ctx, "owner/repo", "production"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := client.PullSecrets(ctx, "owner/repo", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "# App\nAPI_KEY=new\nDB_URL=postgres://db\nSENTRY_DSN=https://sentry\n"
+	if resp.Content != want || resp.Version != 42 {
+		t.Errorf("unexpected delta result: %q (version %d), want %q", resp.Content, resp.Version, want)
+	}
+
+	// Unchanged: the previous content as is
+	resp, err = client.PullSecrets(ctx, "owner/repo", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != want {
+		t.Errorf("expected unchanged content, got %q", resp.Content)
+	}
+	if strings.Join(sinces, ",") != ",41,42" {
+		t.Errorf("unexpected since parameters: %v", sinces)
+	}
+}
+
+// memoryPullBases is a PullBaseStore shared by the clients of a test
+type memoryPullBases map[string]PullBase
+
+func (m memoryPullBases) LoadPullBase(repo, env string) (PullBase, bool) {
+	base, ok := m[repo+"/"+env]
+	return base, ok
+}
+
+func (m memoryPullBases) SavePullBase(repo, env string, base PullBase) error {
+	m[repo+"/"+env] = base
+	return nil
+}
+
+func TestClient_PullSecrets_DeltaAcrossClients(t *testing.T) {
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		sinces = append(sinces, since)
+		data := map[string]interface{}{"content": "API_KEY=old\n", "version": 7}
+		if since == "7" {
+			data = map[string]interface{}{"delta": true, "version": 8, "changed": map[string]string{"API_KEY": "new"}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	store := memoryPullBases{}
+	for i, want := range []string{"API_KEY=old\n", "API_KEY=new\n"} {
+		// A new client each time, like each keyway command
+		client := NewClient("token")
+		client.baseURL = server.URL
+		client.SetPullBaseStore(store)
+		resp, err := client.PullSecrets(context.Background(), "owner/repo", "production")
+		if err != nil {
+			t.Fatalf("pull %d: unexpected error: %v", i+1, err)
+		}
+		if resp.Content != want {
+			t.Errorf("pull %d: got %q, want %q", i+1, resp.Content, want)
+		}
+	}
+	if strings.Join(sinces, ",") != ",7" {
+		t.Errorf("expected the second client to pull since version 7, got since parameters %v", sinces)
+	}
+	if store["owner/repo/production"].Version != 8 {
+		t.Errorf("expected the store to hold version 8, got %+v", store)
+	}
+}
+
+func TestClient_PullSecrets_NoDeltaWithoutVersion(t *testing.T) {
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sinces = append(sinces, r.URL.Query().Get("since"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"content": "API_KEY=x"}})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.PullSecrets(context.Background(), "owner/repo", "production"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if strings.Join(sinces, ",") != "," {
+		t.Errorf("expected full pulls, got since parameters %v", sinces)
+	}
+}
+
+func TestClient_ListSecretMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/production/secrets" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "API_KEY", "updatedAt": "2024-06-01T12:00:00Z", "updatedBy": "alice"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	metadata, err := client.ListSecretMetadata(context.Background(), "owner/repo", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata) != 1 || metadata[0].Key != "API_KEY" || metadata[0].UpdatedBy != "alice" {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	}
+}
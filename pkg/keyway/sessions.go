@@ -0,0 +1,51 @@
+package keyway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// AuthSession is a login of the account: a CLI token, or an API key
+type AuthSession struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"` // "cli" or "api_key"
+	Name       string `json:"name,omitempty"`
+	Device     string `json:"device,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	Location   string `json:"location,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt,omitempty"`
+	Current    bool   `json:"current"`
+}
+
+// ListSessions returns the active sessions of the account, the one of the
+// client's token flagged as current
+func (c *Client) ListSessions(ctx context.Context) ([]AuthSession, error) {
+	var wrapper struct {
+		Data []AuthSession `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/sessions", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// RevokeSession revokes a session, its token stops working right away
+func (c *Client) RevokeSession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/sessions/"+url.PathEscape(sessionID), nil, nil)
+}
+
+// RevokeOtherSessions revokes every session but the current one, and returns
+// how many were revoked
+func (c *Client) RevokeOtherSessions(ctx context.Context) (int, error) {
+	var wrapper struct {
+		Data struct {
+			Revoked int `json:"revoked"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/sessions/revoke-others", nil, &wrapper); err != nil {
+		return 0, err
+	}
+	return wrapper.Data.Revoked, nil
+}
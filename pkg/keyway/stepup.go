@@ -0,0 +1,67 @@
+package keyway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Statuses of a step-up
+const (
+	StepUpPending  = "pending"
+	StepUpApproved = "approved"
+	StepUpDenied   = "denied"
+	StepUpExpired  = "expired"
+)
+
+// StepUp is a WebAuthn assertion requested before a push to an environment
+// protected with RuleWebAuthn. The user completes it at VerificationURL, where
+// the browser talks to the platform authenticator.
+type StepUp struct {
+	ID              string `json:"id"`
+	VerificationURL string `json:"verificationUrl"`
+	// ExpiresIn and Interval are in seconds, how long the step-up can be
+	// completed and how often to poll for it
+	ExpiresIn int `json:"expiresIn"`
+	Interval  int `json:"interval"`
+}
+
+// StepUpStatus is the state of a step-up. Once approved, Token carries the
+// confirmed access until ExpiresAt, for writes to the environment only.
+type StepUpStatus struct {
+	Status    string `json:"status"`
+	Token     string `json:"token,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// StartStepUp requests a WebAuthn assertion to write to an environment
+func (c *Client) StartStepUp(ctx context.Context, repoFullName, env string) (*StepUp, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data StepUp `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, path+"/step-up", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// PollStepUp returns the state of a step-up
+func (c *Client) PollStepUp(ctx context.Context, repoFullName, env, stepUpID string) (*StepUpStatus, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data StepUpStatus `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/step-up/"+url.PathEscape(stepUpID), nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
@@ -0,0 +1,55 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_StartStepUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/vaults/owner/repo/environments/production/step-up" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": "su_1", "verificationUrl": "https://keyway.sh/step-up/su_1", "expiresIn": 300, "interval": 2},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	stepUp, err := client.StartStepUp(context.Background(), "owner/repo", "production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stepUp.ID != "su_1" || stepUp.VerificationURL != "https://keyway.sh/step-up/su_1" || stepUp.ExpiresIn != 300 || stepUp.Interval != 2 {
+		t.Errorf("unexpected step-up: %+v", stepUp)
+	}
+}
+
+func TestClient_PollStepUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/vaults/owner/repo/environments/production/step-up/su_1" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"status": "approved", "token": "kw_stepup", "expiresAt": "2024-06-01T12:05:00Z"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	status, err := client.PollStepUp(context.Background(), "owner/repo", "production", "su_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Status != StepUpApproved || status.Token != "kw_stepup" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
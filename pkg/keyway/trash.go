@@ -0,0 +1,55 @@
+package keyway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TrashRetentionDays is how long removed keys stay in an environment's trash
+const TrashRetentionDays = 30
+
+// TrashedSecret is a key removed from an environment that can still be restored
+type TrashedSecret struct {
+	Key       string `json:"key"`
+	DeletedAt string `json:"deletedAt"`
+	DeletedBy string `json:"deletedBy,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// ListTrash returns the keys removed from an environment in the last 30 days
+func (c *Client) ListTrash(ctx context.Context, repoFullName, env string) ([]TrashedSecret, error) {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper struct {
+		Data []TrashedSecret `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/trash", nil, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Data, nil
+}
+
+// DeleteSecrets removes keys from an environment, the other keys staying as
+// they are. The keys are moved to the environment's trash. A key the
+// environment doesn't hold fails the whole call with a 404.
+func (c *Client) DeleteSecrets(ctx context.Context, repoFullName, env string, keys []string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{"keys": keys}
+	return c.do(ctx, http.MethodPost, path+"/trash", body, nil)
+}
+
+// RestoreTrashedSecret puts a removed key back into an environment with its last value
+func (c *Client) RestoreTrashedSecret(ctx context.Context, repoFullName, env, key string) error {
+	path, err := environmentPath(repoFullName, env)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path+"/trash/"+url.PathEscape(key)+"/restore", nil, nil)
+}
@@ -0,0 +1,91 @@
+package keyway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListTrash(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/staging/trash" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"key": "OLD_TOKEN", "deletedAt": "2024-06-01T12:00:00Z", "deletedBy": "alice", "expiresAt": "2024-07-01T12:00:00Z"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	trash, err := client.ListTrash(context.Background(), "owner/repo", "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trash) != 1 || trash[0].Key != "OLD_TOKEN" || trash[0].DeletedBy != "alice" {
+		t.Errorf("unexpected trash: %+v", trash)
+	}
+}
+
+func TestClient_DeleteSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/staging/trash" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		var body struct {
+			Keys []string `json:"keys"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Keys) != 2 || body.Keys[0] != "OLD_TOKEN" || body.Keys[1] != "LEGACY_URL" {
+			t.Errorf("unexpected keys: %v", body.Keys)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.DeleteSecrets(context.Background(), "owner/repo", "staging", []string{"OLD_TOKEN", "LEGACY_URL"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_RestoreTrashedSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/v1/vaults/owner/repo/environments/staging/trash/OLD_TOKEN/restore" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.RestoreTrashedSecret(context.Background(), "owner/repo", "staging", "OLD_TOKEN"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_RestoreTrashedSecret_InvalidRepo(t *testing.T) {
+	client := NewClient("token")
+	if err := client.RestoreTrashedSecret(context.Background(), "invalid", "staging", "KEY"); err == nil {
+		t.Error("expected error for invalid repository")
+	}
+}
@@ -1,8 +1,9 @@
-package api
+package keyway
 
 import (
 	"context"
 	"fmt"
+	"net/url"
 )
 
 // InitVaultResponse is the response from initializing a vault
@@ -24,6 +25,39 @@ type VaultDetails struct {
 	ID           string `json:"id"`
 	RepoFullName string `json:"repoFullName"`
 	SecretCount  int    `json:"secretCount"`
+	// Permission is the caller's GitHub role on the repository
+	// (read, triage, write, maintain or admin)
+	Permission string `json:"permission,omitempty"`
+}
+
+// Repository permission levels, lowest first, as reported by GitHub
+const (
+	PermissionRead     = "read"
+	PermissionTriage   = "triage"
+	PermissionWrite    = "write"
+	PermissionMaintain = "maintain"
+	PermissionAdmin    = "admin"
+)
+
+var permissionRank = map[string]int{
+	PermissionRead:     1,
+	PermissionTriage:   2,
+	PermissionWrite:    3,
+	PermissionMaintain: 4,
+	PermissionAdmin:    5,
+}
+
+// HasPermission reports whether the caller's role is at least the required one.
+// An unknown or missing role is allowed: the server still enforces access.
+func (v *VaultDetails) HasPermission(required string) bool {
+	if v == nil {
+		return true
+	}
+	have, ok := permissionRank[v.Permission]
+	if !ok {
+		return true
+	}
+	return have >= permissionRank[required]
 }
 
 // InitVault creates a new vault for a repository
@@ -102,6 +136,63 @@ func (c *Client) GetVaultEnvironments(ctx context.Context, repoFullName string)
 	return wrapper.Data.Environments, nil
 }
 
+// Reasons a vault is no longer under its repository's name
+const (
+	MovedRenamed     = "renamed"
+	MovedTransferred = "transferred"
+)
+
+// MovedVault is a vault still stored under the name its repository had before
+// being renamed or transferred on GitHub
+type MovedVault struct {
+	RepoFullName        string `json:"repoFullName"`        // the name the vault is stored under
+	CurrentRepoFullName string `json:"currentRepoFullName"` // the repository's name on GitHub
+	Reason              string `json:"reason"`              // MovedRenamed or MovedTransferred
+}
+
+// FindMovedVault looks up the vault of a repository known under another name,
+// because it was renamed or transferred. It returns nil if there is none.
+func (c *Client) FindMovedVault(ctx context.Context, repoFullName string) (*MovedVault, error) {
+	owner, repo := splitRepo(repoFullName)
+	if owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	path := "/v1/vaults/moved?repo=" + url.QueryEscape(repoFullName)
+	var wrapper struct {
+		Data MovedVault `json:"data"`
+	}
+	err := c.do(ctx, "GET", path, nil, &wrapper)
+	if err != nil {
+		if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if wrapper.Data.RepoFullName == "" {
+		return nil, nil
+	}
+	return &wrapper.Data, nil
+}
+
+// RelinkVault moves the vault stored under vaultRepo, with its secrets, history
+// and settings, to the repository now named repoFullName
+func (c *Client) RelinkVault(ctx context.Context, vaultRepo, repoFullName string) error {
+	owner, repo := splitRepo(vaultRepo)
+	if owner == "" || repo == "" {
+		return fmt.Errorf("invalid repository format: %s", vaultRepo)
+	}
+	if o, r := splitRepo(repoFullName); o == "" || r == "" {
+		return fmt.Errorf("invalid repository format: %s", repoFullName)
+	}
+
+	path := fmt.Sprintf("/v1/vaults/%s/%s/relink", owner, repo)
+	body := map[string]string{
+		"repoFullName": repoFullName,
+	}
+	return c.do(ctx, "POST", path, body, nil)
+}
+
 // splitRepo splits "owner/repo" into owner and repo
 func splitRepo(repoFullName string) (string, string) {
 	for i, c := range repoFullName {
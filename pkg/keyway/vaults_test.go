@@ -1,4 +1,4 @@
-package api
+package keyway
 
 import (
 	"context"
@@ -261,3 +261,105 @@ func TestSplitRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestVaultDetails_HasPermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		required   string
+		want       bool
+	}{
+		{"read", PermissionWrite, false},
+		{"triage", PermissionWrite, false},
+		{"write", PermissionWrite, true},
+		{"admin", PermissionWrite, true},
+		{"write", PermissionMaintain, false},
+		{"maintain", PermissionMaintain, true},
+		{"", PermissionAdmin, true},
+		{"owner", PermissionAdmin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.permission+">="+tt.required, func(t *testing.T) {
+			details := &VaultDetails{Permission: tt.permission}
+			if got := details.HasPermission(tt.required); got != tt.want {
+				t.Errorf("HasPermission(%q) with %q = %v, want %v", tt.required, tt.permission, got, tt.want)
+			}
+		})
+	}
+
+	var nilDetails *VaultDetails
+	if !nilDetails.HasPermission(PermissionAdmin) {
+		t.Error("expected nil details to be allowed")
+	}
+}
+
+func TestClient_FindMovedVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/vaults/moved" || r.URL.Query().Get("repo") != "owner/new-name" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"repoFullName":        "owner/old-name",
+				"currentRepoFullName": "owner/new-name",
+				"reason":              "renamed",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	moved, err := client.FindMovedVault(context.Background(), "owner/new-name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if moved == nil || moved.RepoFullName != "owner/old-name" || moved.Reason != MovedRenamed {
+		t.Errorf("unexpected moved vault: %+v", moved)
+	}
+}
+
+func TestClient_FindMovedVault_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"detail": "No moved vault"})
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	moved, err := client.FindMovedVault(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if moved != nil {
+		t.Errorf("expected no moved vault, got %+v", moved)
+	}
+}
+
+func TestClient_RelinkVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/vaults/owner/old-name/relink" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["repoFullName"] != "acme/new-name" {
+			t.Errorf("unexpected body: %v", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	if err := client.RelinkVault(context.Background(), "owner/old-name", "acme/new-name"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.RelinkVault(context.Background(), "owner/old-name", "invalid"); err == nil {
+		t.Error("expected an error for an invalid repository")
+	}
+}
@@ -0,0 +1,15 @@
+#!/bin/sh
+# Signs the release checksums with the ed25519 key in RELEASE_SIGNING_KEY (PEM),
+# writing the base64 signature keyway verify-install checks.
+# The matching public key is built into the binary from RELEASE_SIGNING_PUBLIC_KEY:
+#   openssl pkey -in key.pem -pubout -outform DER | tail -c 32 | base64
+set -eu
+
+checksums="$1"
+signature="$2"
+
+key=$(mktemp)
+trap 'rm -f "$key"' EXIT
+printf '%s\n' "$RELEASE_SIGNING_KEY" > "$key"
+
+openssl pkeyutl -sign -rawin -inkey "$key" -in "$checksums" | base64 | tr -d '\n' > "$signature"